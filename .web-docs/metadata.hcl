@@ -23,6 +23,21 @@ integration {
     name = "vSphere Supervisor"
     slug = "vsphere-supervisor"
   }
+  component {
+    type = "data-source"
+    name = "vSphere Content Library"
+    slug = "vsphere-contentlibrary"
+  }
+  component {
+    type = "data-source"
+    name = "vSphere Datastore"
+    slug = "vsphere-datastore"
+  }
+  component {
+    type = "data-source"
+    name = "vSphere Tag"
+    slug = "vsphere-tag"
+  }
   component {
     type = "post-processor"
     name = "vSphere"
@@ -33,4 +48,14 @@ integration {
     name = "vSphere Template"
     slug = "vsphere-template"
   }
+  component {
+    type = "post-processor"
+    name = "vSphere OVF"
+    slug = "vsphere-ovf"
+  }
+  component {
+    type = "post-processor"
+    name = "vSphere Metadata"
+    slug = "vsphere-metadata"
+  }
 }
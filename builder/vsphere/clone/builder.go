@@ -44,24 +44,105 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&common.StepConnect{
 			Config: &b.config.ConnectConfig,
 		},
+		&common.StepCheckFingerprint{
+			Config:      &b.config.FingerprintConfig,
+			Location:    &b.config.LocationConfig,
+			Fingerprint: b.config.fingerprint,
+			Force:       b.config.PackerConfig.PackerForce,
+		},
+		&common.StepSelectAffinityHost{
+			Config:    &b.config.HostAffinityConfig,
+			Locations: []*common.LocationConfig{&b.config.LocationConfig},
+		},
+		&common.StepSelectHostLocalDatastore{
+			Location: &b.config.LocationConfig,
+		},
+		&common.StepPreflightChecks{
+			Config:    &b.config.PreflightConfig,
+			Location:  &b.config.LocationConfig,
+			Networks:  []string{b.config.Network},
+			Template:  b.config.Template,
+			ImportOVF: b.config.RemoteSource != nil || b.config.ContentLibrarySource != nil,
+		},
+		&common.StepCheckPrivileges{
+			Config:    &b.config.PreflightConfig,
+			Location:  &b.config.LocationConfig,
+			Networks:  []string{b.config.Network},
+			Template:  b.config.Template,
+			ImportOVF: b.config.RemoteSource != nil || b.config.ContentLibrarySource != nil,
+		},
+		&common.StepCheckKeyProvider{
+			Config:   &b.config.HardwareConfig,
+			Location: &b.config.LocationConfig,
+		},
+		&common.StepCheckEncryption{
+			Config:   b.config.Encryption,
+			Location: &b.config.LocationConfig,
+		},
 		&commonsteps.StepCreateCD{
 			Files:   b.config.CDConfig.CDFiles,
 			Content: b.config.CDConfig.CDContent,
 			Label:   b.config.CDConfig.CDLabel,
 		},
+		&common.StepCheckDatastoreSpace{
+			Config:             &b.config.DatastoreSpaceConfig,
+			Location:           &b.config.LocationConfig,
+			Hardware:           &b.config.HardwareConfig,
+			Storage:            &b.config.StorageConfig,
+			ContentLibrary:     b.config.ContentLibraryDestinationConfig,
+			Source:             b.config.Template,
+			LinkedClone:        b.config.LinkedClone,
+			PrimaryDiskSize:    b.config.DiskSize,
+			DiskResizes:        b.config.diskResizes(),
+			UploadsToDatastore: true,
+		},
 		&common.StepRemoteUpload{
 			Datastore:                  b.config.Datastore,
 			Host:                       b.config.Host,
 			SetHostForDatastoreUploads: b.config.SetHostForDatastoreUploads,
+			CDImage:                    &b.config.CDImageConfig,
+		},
+		&common.StepWaitForBuildSlot{
+			Config:   &b.config.BuildSlotConfig,
+			Location: &b.config.LocationConfig,
+		},
+		&common.StepRecordCapacity{
+			Config:   &b.config.CapacityConfig,
+			Location: &b.config.LocationConfig,
 		},
 		&StepCloneVM{
 			Config:   &b.config.CloneConfig,
 			Location: &b.config.LocationConfig,
 			Force:    b.config.PackerConfig.PackerForce,
+			Ctx:      b.config.ctx,
+
+			ConvertToTemplate: b.config.ConvertToTemplate,
+			Timeout:           b.config.Timeouts.Clone,
+		},
+		&common.StepMarkBuildInProgress{
+			Config: &b.config.BuildSlotConfig,
+		},
+		&common.StepSetManagedBy{
+			Config: &b.config.ManagedByConfig,
+		},
+		&common.StepAddHostAffinityRule{
+			Config: &b.config.HostAffinityConfig,
+		},
+		&StepUpgradeVM{
+			Config: &b.config.CloneConfig,
 		},
 		&common.StepConfigureHardware{
 			Config: &b.config.HardwareConfig,
 		},
+		&common.StepEncryptVM{
+			Config: b.config.Encryption,
+		},
+		&common.StepAttachFirstClassDisks{
+			Config: &b.config.StorageConfig,
+		},
+		&common.StepAddSerialPort{
+			Config: &b.config.SerialLogConfig,
+		},
 		&common.StepAddFlag{
 			FlagConfig: b.config.FlagConfig,
 		},
@@ -71,15 +152,24 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&common.StepConfigParams{
 			Config: &b.config.ConfigParamsConfig,
 		},
+		&common.StepCloudInitGuestinfo{
+			Config: b.config.CloudInitGuestinfo,
+		},
 	)
 
+	if b.config.Export != nil {
+		steps = append(steps, &common.StepCreateDifferentialBase{
+			SnapshotName: b.config.Export.DifferentialBaseSnapshot,
+		})
+	}
+
 	if b.config.CustomizeConfig != nil {
 		steps = append(steps, &StepCustomize{
 			Config: b.config.CustomizeConfig,
 		})
 	}
 
-	if b.config.Comm.Type != "none" {
+	if b.config.Comm.Type != "none" || b.config.GuestOperations != nil {
 		steps = append(steps,
 			&commonsteps.StepCreateFloppy{
 				Files:       b.config.FloppyFiles,
@@ -111,8 +201,9 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		} else {
 			// Use IP discovery if neither is specified.
 			steps = append(steps, &common.StepHTTPIPDiscover{
-				HTTPIP:  b.config.BootConfig.HTTPIP,
-				Network: b.config.WaitIpConfig.GetIPNet(),
+				HTTPIP:    b.config.BootConfig.HTTPIP,
+				Network:   b.config.WaitIpConfig.GetIPNet(),
+				Discovery: b.config.BootConfig.HTTPIPDiscovery,
 			})
 		}
 
@@ -127,20 +218,68 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 				Config:   &b.config.RunConfig,
 				SetOrder: false,
 			},
+			&common.StepCollectCrashDump{
+				Config: &b.config.CrashDumpConfig,
+			},
+		)
+
+		if b.config.CustomizeConfig != nil {
+			username, password := b.config.Comm.User(), b.config.Comm.Password()
+			if b.config.GuestOperations != nil {
+				username, password = b.config.GuestOperations.Username, b.config.GuestOperations.Password
+			}
+			steps = append(steps, &StepWaitForCustomization{
+				Config:        b.config.CustomizeConfig,
+				GuestUsername: username,
+				GuestPassword: password,
+			})
+		}
+
+		steps = append(steps,
 			&common.StepBootCommand{
 				Config: &b.config.BootConfig,
 				Ctx:    b.config.ctx,
 				VMName: b.config.VMName,
 			},
-			&common.StepWaitForIp{
-				Config: &b.config.WaitIpConfig,
+		)
+
+		if b.config.Comm.Type != "none" {
+			steps = append(steps,
+				&common.StepWaitForIp{
+					Config: &b.config.WaitIpConfig,
+					Port:   b.config.Comm.Port(),
+				},
+				&communicator.StepConnect{
+					Config:    &b.config.Comm,
+					Host:      common.CommConnectHost(&b.config.Comm),
+					SSHConfig: b.config.Comm.SSHConfigFunc(),
+				},
+				&commonsteps.StepProvision{},
+				&common.StepWaitForPorts{
+					Config: &b.config.WaitForPortsConfig,
+					Host:   common.CommHost(b.config.Comm.Host()),
+				},
+				&common.StepVerifyNetworks{
+					Config: &b.config.NetworkVerificationConfig,
+				},
+			)
+		} else {
+			steps = append(steps,
+				&common.StepGuestOperationsConnect{
+					Config: b.config.GuestOperations,
+				},
+				&commonsteps.StepProvision{},
+			)
+		}
+
+		steps = append(steps,
+			&common.StepSysprep{
+				Config: b.config.Sysprep,
 			},
-			&communicator.StepConnect{
-				Config:    &b.config.Comm,
-				Host:      common.CommHost(b.config.Comm.Host()),
-				SSHConfig: b.config.Comm.SSHConfigFunc(),
+			&common.StepCreateSnapshot{
+				Config:         &b.config.SnapshotConfig,
+				BeforeShutdown: true,
 			},
-			&commonsteps.StepProvision{},
 			&common.StepShutdown{
 				Config: &b.config.ShutdownConfig,
 			},
@@ -159,36 +298,84 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Config:      &b.config.ReattachCDRomConfig,
 			CDRomConfig: &b.config.CDRomConfig,
 		},
+		&common.StepGenerateConfigSnippet{
+			Config: &b.config.ConfigSnippetConfig,
+		},
+		&common.StepDetachFirstClassDisks{
+			Config: &b.config.StorageConfig,
+		},
+		&common.StepRemoveSerialPort{
+			Config: &b.config.SerialLogConfig,
+		},
+		&common.StepClearManagedBy{
+			Config: &b.config.ManagedByConfig,
+		},
+		&common.StepResetVAppProperties{
+			Properties: b.config.VAppConfig.ResetProperties,
+		},
+		&common.StepEnableChangeTracking{
+			Config: &b.config.ChangeTrackingConfig,
+		},
 		&common.StepCreateSnapshot{
-			CreateSnapshot: b.config.CreateSnapshot,
-			SnapshotName:   b.config.SnapshotName,
+			Config: &b.config.SnapshotConfig,
 		},
 		&common.StepRemoveNetworkAdapter{
 			Config: &b.config.RemoveNetworkAdapterConfig,
 		},
+		&common.StepRecordDevices{
+			Config: &b.config.DeviceLabelsConfig,
+		},
 		&common.StepConvertToTemplate{
 			ConvertToTemplate: b.config.ConvertToTemplate,
 		},
+		&common.StepAttachTags{
+			Config: &b.config.TagsConfig,
+		},
+		&common.StepSetCustomAttributes{
+			Config: &b.config.CustomAttributesConfig,
+		},
+		&common.StepSetFingerprint{
+			Config:      &b.config.FingerprintConfig,
+			Fingerprint: b.config.fingerprint,
+		},
 	)
 
 	if b.config.ContentLibraryDestinationConfig != nil {
 		steps = append(steps, &common.StepImportToContentLibrary{
 			ContentLibConfig: b.config.ContentLibraryDestinationConfig,
+			VMName:           b.config.VMName,
 		})
 	}
 
 	if b.config.Export != nil {
 		steps = append(steps, &common.StepExport{
-			Name:       b.config.Export.Name,
-			Force:      b.config.Export.Force,
-			ImageFiles: b.config.Export.ImageFiles,
-			Manifest:   b.config.Export.Manifest,
-			OutputDir:  b.config.Export.OutputDir.OutputDir,
-			Options:    b.config.Export.Options,
-			Format:     b.config.Export.Format,
+			Name:                      b.config.Export.Name,
+			Force:                     b.config.Export.Force,
+			ImageFiles:                b.config.Export.ImageFiles,
+			Manifest:                  b.config.Export.Manifest,
+			OutputDir:                 b.config.Export.OutputDir.OutputDir,
+			Options:                   b.config.Export.Options,
+			IncludeNvram:              b.config.Export.IncludeNvram,
+			ExcludeDevices:            b.config.Export.ExcludeDevices,
+			Format:                    b.config.Export.Format,
+			ParallelDownloads:         b.config.Export.ParallelDownloads,
+			Layout:                    b.config.Export.Layout,
+			Reproducible:              b.config.Export.Reproducible,
+			SigningKey:                b.config.Export.SigningKey,
+			SigningCertificate:        b.config.Export.SigningCertificate,
+			Timeout:                   b.config.Timeouts.Export,
+			DifferentialBaseSnapshot:  b.config.Export.DifferentialBaseSnapshot,
+			DifferentialBaseDirectory: b.config.Export.DifferentialBaseDirectory,
 		})
 	}
 
+	steps = append(steps, &common.StepRecordCapacity{
+		Config:   &b.config.CapacityConfig,
+		Location: &b.config.LocationConfig,
+		After:    true,
+	})
+
+	steps = common.SkipIfFingerprintMatched(steps)
 	b.runner = commonsteps.NewRunnerWithPauseFn(steps, b.config.PackerConfig, ui, state)
 	b.runner.Run(ctx, state)
 
@@ -207,13 +394,24 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		ContentLibraryConfig: b.config.ContentLibraryDestinationConfig,
 		VM:                   vm,
 		StateData: map[string]interface{}{
-			"generated_data":  state.Get("generated_data"),
-			"metadata":        state.Get("metadata"),
-			"source_template": b.config.Template,
+			"generated_data":            state.Get("generated_data"),
+			"metadata":                  state.Get("metadata"),
+			"source_template":           b.config.Template,
+			"source_snapshot":           state.Get("source_snapshot"),
+			"capacity":                  state.Get("capacity"),
+			"network_verification":      state.Get("network_verification"),
+			"devices":                   state.Get("devices"),
+			"build_fingerprint":         state.Get("build_fingerprint"),
+			"vm_id":                     vm.Reference().Value,
+			"content_library_id":        state.Get("content_library_id"),
+			"content_library_url":       state.Get("content_library_url"),
+			"content_library_item_uuid": state.Get("content_library_item_uuid"),
 		},
 	}
 	if b.config.Export != nil {
 		artifact.Outconfig = &b.config.Export.OutputDir
+	} else if b.config.SerialLog {
+		artifact.Outconfig = &common.OutputConfig{OutputDir: b.config.SerialLogConfig.OutputDir()}
 	}
 	return artifact, nil
 }
@@ -7,6 +7,8 @@
 package clone
 
 import (
+	"fmt"
+
 	packerCommon "github.com/hashicorp/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
@@ -20,6 +22,7 @@ type Config struct {
 	packerCommon.PackerConfig `mapstructure:",squash"`
 	commonsteps.HTTPConfig    `mapstructure:",squash"`
 	commonsteps.CDConfig      `mapstructure:",squash"`
+	common.CDImageConfig      `mapstructure:",squash"`
 
 	common.ConnectConfig              `mapstructure:",squash"`
 	CloneConfig                       `mapstructure:",squash"`
@@ -35,15 +38,26 @@ type Config struct {
 	common.RunConfig                  `mapstructure:",squash"`
 	common.BootConfig                 `mapstructure:",squash"`
 	common.WaitIpConfig               `mapstructure:",squash"`
+	common.WaitForPortsConfig         `mapstructure:",squash"`
+	common.NetworkVerificationConfig  `mapstructure:",squash"`
 	Comm                              communicator.Config `mapstructure:",squash"`
 	common.ShutdownConfig             `mapstructure:",squash"`
+	common.ConfigSnippetConfig        `mapstructure:",squash"`
+	common.SerialLogConfig            `mapstructure:",squash"`
+	common.CrashDumpConfig            `mapstructure:",squash"`
+	common.BuildSlotConfig            `mapstructure:",squash"`
+	common.HostAffinityConfig         `mapstructure:",squash"`
+	common.ManagedByConfig            `mapstructure:",squash"`
+	common.TagsConfig                 `mapstructure:",squash"`
+	common.CustomAttributesConfig     `mapstructure:",squash"`
+	common.ChangeTrackingConfig       `mapstructure:",squash"`
+	common.SnapshotConfig             `mapstructure:",squash"`
+	common.DeviceLabelsConfig         `mapstructure:",squash"`
+	common.DatastoreSpaceConfig       `mapstructure:",squash"`
+	common.CapacityConfig             `mapstructure:",squash"`
+	common.FingerprintConfig          `mapstructure:",squash"`
+	common.PreflightConfig            `mapstructure:",squash"`
 
-	// Create a snapshot of the virtual machine to use as a base for linked
-	// clones. Defaults to `false`.
-	CreateSnapshot bool `mapstructure:"create_snapshot"`
-	// The name of the snapshot when `create_snapshot` is `true`.
-	// Defaults to `Created By Packer`.
-	SnapshotName string `mapstructure:"snapshot_name"`
 	// Convert the cloned virtual machine to a template after the build is
 	// complete. Defaults to `false`.
 	// If set to `true`, the virtual machine can not be imported to a content
@@ -58,12 +72,37 @@ type Config struct {
 	// [content library import configuration](#content-library-import-configuration)
 	// is specified. If set, `convert_to_template` must be set to `false`.
 	ContentLibraryDestinationConfig *common.ContentLibraryDestinationConfig `mapstructure:"content_library_destination"`
+	// The timeouts of the long-running steps of the build. Refer to the
+	// [timeouts configuration](#timeouts-configuration) section for more
+	// information.
+	Timeouts common.TimeoutsConfig `mapstructure:"timeouts"`
+	// The cloud-init user data and metadata to pass to the guest operating
+	// system in the `guestinfo` configuration parameters. Refer to the
+	// [cloud-init guestinfo configuration](#cloud-init-guestinfo-configuration)
+	// section for more information.
+	CloudInitGuestinfo *common.CloudInitGuestinfoConfig `mapstructure:"cloud_init_guestinfo"`
+	// The configuration for generalizing a Windows guest operating system
+	// with sysprep before the virtual machine is shut down. Refer to the
+	// [sysprep configuration](#sysprep-configuration) section for more
+	// information.
+	Sysprep *common.SysprepConfig `mapstructure:"sysprep"`
+	// The configuration for encrypting the virtual machine with a key
+	// provider. Refer to the [encryption configuration](#encryption-configuration)
+	// section for more information.
+	Encryption *common.EncryptionConfig `mapstructure:"encryption"`
+	// The configuration for running the provisioners with the guest
+	// operations of VMware Tools when `communicator` is set to `none`. Refer
+	// to the [guest operations configuration](#guest-operations-configuration)
+	// section for more information.
+	GuestOperations *common.GuestOperationsConfig `mapstructure:"guest_operations"`
 	// The customization options for the virtual machine.
 	// Refer to the [customization options](#customization) section for more
 	// information.
 	CustomizeConfig *CustomizeConfig `mapstructure:"customize"`
 
 	ctx interpolate.Context
+	// The build fingerprint of the configuration.
+	fingerprint string
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
@@ -74,6 +113,9 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		InterpolateFilter: &interpolate.RenderFilter{
 			Exclude: []string{
 				"boot_command",
+				"boot_commands",
+				"notes",
+				"cloud_init_guestinfo",
 			},
 		},
 	}, raws...)
@@ -81,20 +123,51 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		return nil, err
 	}
 
+	common.RegisterSensitiveValues(c)
+
 	warnings := make([]string, 0)
 	errs := new(packersdk.MultiError)
 
+	errs = packersdk.MultiErrorAppend(errs, c.Timeouts.Prepare(&c.ShutdownConfig, &c.WaitIpConfig)...)
+	if c.CustomizeConfig != nil && c.Timeouts.CustomizeWait != 0 {
+		if c.CustomizeConfig.CustomizationTimeout != 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'timeouts.customize_wait' and 'customization_timeout' cannot be used together"))
+		} else {
+			c.CustomizeConfig.CustomizationTimeout = c.Timeouts.CustomizeWait
+		}
+	}
+
 	errs = packersdk.MultiErrorAppend(errs, c.ConnectConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.CloneConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.LocationConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.HardwareConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.FlagConfig.Prepare(&c.HardwareConfig)...)
+
+	configParamsWarnings, configParamsErrs := c.ConfigParamsConfig.Prepare(&c.HardwareConfig, &c.FlagConfig,
+		common.ConfigParamOption{Key: "virtualHW.version", Option: "vm_version", Set: c.Version != 0},
+	)
+	warnings = append(warnings, configParamsWarnings...)
+	errs = packersdk.MultiErrorAppend(errs, configParamsErrs...)
+
 	errs = packersdk.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.CDRomConfig.Prepare(&c.ReattachCDRomConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, c.CDConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.CDImageConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.RunConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.WaitIpConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.WaitForPortsConfig.Prepare(c.Comm)...)
+	errs = packersdk.MultiErrorAppend(errs, c.NetworkVerificationConfig.Prepare(c.Comm)...)
 	errs = packersdk.MultiErrorAppend(errs, c.Comm.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ConfigSnippetConfig.Prepare(&c.LocationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.BuildSlotConfig.Prepare(&c.LocationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.HostAffinityConfig.Prepare(&c.LocationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ManagedByConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.TagsConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.CustomAttributesConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.DeviceLabelsConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.DatastoreSpaceConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.FingerprintConfig.Prepare()...)
 
 	_, shutdownErrs := c.ShutdownConfig.Prepare(c.Comm)
 	// shutdownWarnings, shutdownErrs := c.ShutdownConfig.Prepare(c.Comm)
@@ -104,15 +177,49 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	if c.Export != nil {
 		errs = packersdk.MultiErrorAppend(errs, c.Export.Prepare(&c.ctx, &c.LocationConfig, &c.PackerConfig)...)
 	}
+	errs = packersdk.MultiErrorAppend(errs, c.SnapshotConfig.Prepare(c.Export)...)
+	// The virtual machine is only powered on with a communicator or guest
+	// operations, and the memory is only included while it is running.
+	if c.SnapshotMemory && c.Comm.Type == "none" && c.GuestOperations == nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'snapshot_memory' requires a communicator or 'guest_operations'"))
+	}
+	errs = packersdk.MultiErrorAppend(errs, c.SerialLogConfig.Prepare(c.Export, &c.PackerConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.PrepareSerialLog(&c.SerialLogConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.CrashDumpConfig.Prepare(c.Export, &c.PackerConfig)...)
 	if c.ContentLibraryDestinationConfig != nil {
 		errs = packersdk.MultiErrorAppend(errs, c.ContentLibraryDestinationConfig.Prepare(&c.LocationConfig)...)
 	}
+	errs = packersdk.MultiErrorAppend(errs, c.LocationConfig.PrepareHostLocalDatastore(c.Export, c.ContentLibraryDestinationConfig)...)
+	if c.GuestOperations != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.GuestOperations.Prepare(c.Comm)...)
+	}
+	if c.Sysprep != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.Sysprep.Prepare(c.Comm, c.GuestOperations)...)
+	}
+	if c.Encryption != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.Encryption.Prepare(&c.CloneConfig.StorageConfig)...)
+		if c.LinkedClone {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'encryption' cannot be used with 'linked_clone'"))
+		}
+	}
+	if c.CloudInitGuestinfo != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.CloudInitGuestinfo.Prepare(&c.ctx, &c.LocationConfig, &c.ConfigParamsConfig)...)
+	}
 	if c.CustomizeConfig != nil {
 		customizeWarnings, customizeErrors := c.CustomizeConfig.Prepare()
 		errs = packersdk.MultiErrorAppend(errs, customizeErrors...)
 		warnings = append(warnings, customizeWarnings...)
 	}
 
+	// The fingerprint is computed after the defaults are applied.
+	if c.BuildFingerprint {
+		fingerprint, err := common.Fingerprint(c)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("error computing the build fingerprint: %s", err))
+		}
+		c.fingerprint = fingerprint
+	}
+
 	if len(errs.Errors) > 0 {
 		return nil, errs
 	}
@@ -123,3 +230,7 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	return nil, nil
 }
+
+func (c Config) GoString() string { return common.GoString(c) }
+
+func (c FlatConfig) GoString() string { return common.GoString(c) }
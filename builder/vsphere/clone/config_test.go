@@ -4,6 +4,8 @@
 package clone
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -36,6 +38,61 @@ func TestCloneConfig_Timeout(t *testing.T) {
 	}
 }
 
+func TestCloneConfig_TaskRetries(t *testing.T) {
+	conf := new(Config)
+	warns, err := conf.Prepare(minimalConfig())
+	testConfigOk(t, warns, err)
+	if *conf.TaskRetryCount != 3 || *conf.TaskRetryDelay != 5*time.Second {
+		t.Fatalf("unexpected result: expected '3' retries after '5s', but returned '%d' after '%s'", *conf.TaskRetryCount, *conf.TaskRetryDelay)
+	}
+
+	// The retries are disabled rather than set to the defaults.
+	raw := minimalConfig()
+	raw["task_retry_count"] = 0
+	raw["task_retry_delay"] = "0s"
+	conf = new(Config)
+	warns, err = conf.Prepare(raw)
+	testConfigOk(t, warns, err)
+	if *conf.TaskRetryCount != 0 || *conf.TaskRetryDelay != 0 {
+		t.Fatalf("unexpected result: expected '0' retries after '0s', but returned '%d' after '%s'", *conf.TaskRetryCount, *conf.TaskRetryDelay)
+	}
+}
+
+func TestCloneConfig_Timeouts(t *testing.T) {
+	raw := minimalConfig()
+	raw["timeouts"] = map[string]interface{}{
+		"clone":          "30m",
+		"customize_wait": "20m",
+		"shutdown":       "15m",
+	}
+	raw["customize"] = map[string]interface{}{
+		"linux_options": map[string]interface{}{
+			"host_name": "vm-01",
+			"domain":    "example.com",
+		},
+		"network_interface": []map[string]interface{}{
+			{"ipv4_address": "10.0.0.10", "ipv4_netmask": 24},
+		},
+	}
+	conf := new(Config)
+	warns, err := conf.Prepare(raw)
+	testConfigOk(t, warns, err)
+	if conf.Timeouts.Clone != 30*time.Minute {
+		t.Fatalf("unexpected result: expected '30m', but returned '%v'", conf.Timeouts.Clone)
+	}
+	if conf.CustomizeConfig.CustomizationTimeout != 20*time.Minute {
+		t.Fatalf("unexpected result: expected '20m', but returned '%v'", conf.CustomizeConfig.CustomizationTimeout)
+	}
+	if conf.ShutdownConfig.Timeout != 15*time.Minute {
+		t.Fatalf("unexpected result: expected '15m', but returned '%v'", conf.ShutdownConfig.Timeout)
+	}
+
+	raw["shutdown_timeout"] = "3m"
+	conf = new(Config)
+	warns, err = conf.Prepare(raw)
+	testConfigErr(t, "timeouts.shutdown", warns, err)
+}
+
 func TestCloneConfig_RAMReservation(t *testing.T) {
 	raw := minimalConfig()
 	raw["RAM_reservation"] = 1000
@@ -45,6 +102,46 @@ func TestCloneConfig_RAMReservation(t *testing.T) {
 	testConfigErr(t, "RAM_reservation", warns, err)
 }
 
+func TestCloneConfig_ConfigParamsVersion(t *testing.T) {
+	raw := minimalConfig()
+	raw["vm_version"] = 21
+	raw["configuration_parameters"] = map[string]string{"virtualHW.version": "19"}
+	c := new(Config)
+	warns, err := c.Prepare(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected := "'configuration_parameters' key 'virtualHW.version' conflicts with 'vm_version', which takes precedence"
+	if len(warns) != 1 || warns[0] != expected {
+		t.Fatalf("unexpected warnings: expected '%s', but returned '%v'", expected, warns)
+	}
+
+	raw["configuration_parameters_strict"] = true
+	c = new(Config)
+	warns, err = c.Prepare(raw)
+	testConfigErr(t, "virtualHW.version", warns, err)
+}
+
+func TestCloneConfig_GoStringRedactsSensitiveValues(t *testing.T) {
+	raw := minimalConfig()
+	raw["password"] = "vcenter-secret"
+	raw["ssh_password"] = "ssh-secret"
+	c := new(Config)
+	warns, err := c.Prepare(raw)
+	testConfigOk(t, warns, err)
+
+	password, sshPassword := "vcenter-secret", "ssh-secret"
+	flat := FlatConfig{Password: &password, SSHPassword: &sshPassword}
+
+	for _, s := range []string{fmt.Sprintf("%#v", *c), fmt.Sprintf("%#v", c), fmt.Sprintf("%#v", flat)} {
+		for _, secret := range []string{"vcenter-secret", "ssh-secret"} {
+			if strings.Contains(s, secret) {
+				t.Fatalf("unexpected result: expected '%s' to be redacted", secret)
+			}
+		}
+	}
+}
+
 func minimalConfig() map[string]interface{} {
 	return map[string]interface{}{
 		"vcenter_server": "vcenter.example.com",
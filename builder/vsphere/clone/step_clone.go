@@ -2,21 +2,25 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type CloneConfig,vAppConfig
+//go:generate packer-sdc mapstructure-to-hcl2 -type CloneConfig,vAppConfig,RemoteSourceConfig,ContentLibrarySourceConfig,NamespaceImageSourceConfig,DiskResizeConfig
 
 package clone
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
 )
 
 type vAppConfig struct {
@@ -60,18 +64,245 @@ type vAppConfig struct {
 	// export USERDATA=$(gzip -c9 <userdata.yaml | { base64 -w0 2>/dev/null || base64; })
 	// ```
 	Properties map[string]string `mapstructure:"properties"`
+	// The vApp properties to reset to their default values after the build
+	// completes and before the virtual machine is converted to a template,
+	// imported to a content library, or exported, such as properties with
+	// one-time passwords or bootstrap data. The `public-keys` property is
+	// always reset if a temporary SSH key pair is injected by the build.
+	//
+	// HCL Example:
+	// ```hcl
+	//   vapp {
+	//     properties = {
+	//       password = var.bootstrap_password
+	//     }
+	//     reset_properties = ["password"]
+	//   }
+	// ```
+	ResetProperties []string `mapstructure:"reset_properties"`
+}
+
+// The following example imports an OVA that is stored on a datastore as the
+// virtual machine of the build instead of cloning a virtual machine:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	remote_source {
+//	  datastore_path = "[datastore1] images/base.ova"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"remote_source": {
+//	  "datastore_path": "[datastore1] images/base.ova"
+//	},
+//
+// ```
+type RemoteSourceConfig struct {
+	// The datastore path of the OVF descriptor or OVA to import. For example,
+	// `[datastore1] images/base.ova`. The files referenced by an OVF
+	// descriptor must be stored in the same directory as the descriptor.
+	DatastorePath string `mapstructure:"datastore_path" required:"true"`
+}
+
+// The following example deploys an item of a content library as the virtual
+// machine of the build instead of cloning a virtual machine:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	content_library_source {
+//	  library = "Library"
+//	  item    = "ubuntu-server"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"content_library_source": {
+//	  "library": "Library",
+//	  "item": "ubuntu-server"
+//	},
+//
+// ```
+type ContentLibrarySourceConfig struct {
+	// The name of the content library.
+	Library string `mapstructure:"library" required:"true"`
+	// The name of the item in the content library. The item must be an OVF
+	// template or a VM template.
+	Item string `mapstructure:"item" required:"true"`
+}
+
+// Resize an existing disk of the source virtual machine when it is cloned.
+// The disk is identified by its index among the disks of the source, where
+// `0` is the primary disk, or by its label:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	disk_resize {
+//	  index = 1
+//	  size  = 102400
+//	}
+//
+//	disk_resize {
+//	  label = "Hard disk 3"
+//	  size  = 204800
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"disk_resize": [
+//	  {
+//	    "index": 1,
+//	    "size": 102400
+//	  },
+//	  {
+//	    "label": "Hard disk 3",
+//	    "size": 204800
+//	  }
+//	],
+//
+// ```
+//
+// -> **Note:** A disk can only be grown. The partitions and file systems of
+// the guest operating system are not resized.
+type DiskResizeConfig struct {
+	// The index of the disk among the disks of the source virtual machine.
+	// Defaults to `0`, the primary disk. Cannot be used with `label`.
+	Index int `mapstructure:"index"`
+	// The label of the disk, such as `Hard disk 2`. Cannot be used with
+	// `index`.
+	Label string `mapstructure:"label"`
+	// The new size of the disk in MiB, which must be greater than or equal
+	// to the size of the disk.
+	Size int64 `mapstructure:"size" required:"true"`
+}
+
+// The following example deploys a VM image that vSphere with Tanzu publishes
+// to a vSphere Namespace as the virtual machine of the build instead of
+// cloning a virtual machine. The image is resolved from the content libraries
+// that are associated with the namespace for VM Service, so the name of the
+// content library is not required:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	namespace_image_source {
+//	  namespace = "platform"
+//	  image     = "vmi-0a0044d7c690bc9b8"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"namespace_image_source": {
+//	  "namespace": "platform",
+//	  "image": "vmi-0a0044d7c690bc9b8"
+//	},
+//
+// ```
+type NamespaceImageSourceConfig struct {
+	// The name of the vSphere Namespace.
+	Namespace string `mapstructure:"namespace" required:"true"`
+	// The name of the VM image, as listed by `kubectl get virtualmachineimages`
+	// in the namespace, for example `vmi-0a0044d7c690bc9b8`, or the name of
+	// the content library item of the image. The item name must be unique
+	// across the content libraries of the namespace. The image must be an OVF
+	// template or a VM template.
+	Image string `mapstructure:"image" required:"true"`
 }
 
 type CloneConfig struct {
-	// The name of the source virtual machine to clone.
+	// The name of the source virtual machine to clone. Required if
+	// `remote_source`, `content_library_source`, or `namespace_image_source`
+	// is not specified.
 	Template string `mapstructure:"template"`
-	// The size of the primary disk in MiB. Cannot be used with `linked_clone`.
-	// -> **Note:** Only the primary disk size can be specified. Additional
-	// disks are not supported.
+	// Import an OVF or OVA that is stored on a datastore as the virtual
+	// machine of the build instead of cloning a virtual machine. The files are
+	// transferred by the host from the datastore, without passing through the
+	// Packer host. Refer to the [Remote Source Configuration](#remote-source-configuration)
+	// section for additional information. Cannot be used with `template`,
+	// another source, `linked_clone`, `disk_size`, `mac_address`, or
+	// `storage`. Requires vCenter Server.
+	RemoteSource *RemoteSourceConfig `mapstructure:"remote_source"`
+	// Deploy an OVF template or a VM template item of a content library as
+	// the virtual machine of the build instead of cloning a virtual machine.
+	// The disks of the virtual machine are placed on `datastore` and the
+	// networks of the item are connected to `network`. Refer to the
+	// [Content Library Source Configuration](#content-library-source-configuration)
+	// section for additional information. Cannot be used with `template`,
+	// another source, `linked_clone`, `disk_size`, `mac_address`, or
+	// `storage`. Requires vCenter Server.
+	ContentLibrarySource *ContentLibrarySourceConfig `mapstructure:"content_library_source"`
+	// Deploy a VM image that is published to a vSphere Namespace by vSphere
+	// with Tanzu as the virtual machine of the build instead of cloning a
+	// virtual machine. The image is deployed the same as a
+	// `content_library_source`. Refer to the
+	// [Namespace Image Source Configuration](#namespace-image-source-configuration)
+	// section for additional information. Cannot be used with `template`,
+	// another source, `linked_clone`, `disk_size`, `mac_address`, or
+	// `storage`. Requires vCenter Server with vSphere with Tanzu.
+	NamespaceImageSource *NamespaceImageSourceConfig `mapstructure:"namespace_image_source"`
+	// The size of the primary disk in MiB. Cannot be used with `linked_clone`
+	// or `disk_resize`.
+	// -> **Note:** Only the primary disk size can be specified. Use
+	// `disk_resize` to resize the disks of a source with more than one disk.
 	DiskSize int64 `mapstructure:"disk_size"`
+	// Resize the existing disks of the source virtual machine. Refer to the
+	// [Disk Resize Configuration](#disk-resize-configuration) section for
+	// additional information. Cannot be used with `linked_clone`,
+	// `disk_size`, or a source other than `template`.
+	DiskResize []DiskResizeConfig `mapstructure:"disk_resize"`
 	// Create the virtual machine as a linked clone from the latest snapshot.
-	// Defaults to `false`. Cannot be used with `disk_size`.`
+	// Defaults to `false`. Cannot be used with `disk_size` or `disk_resize`.
 	LinkedClone bool `mapstructure:"linked_clone"`
+	// The snapshot of the source virtual machine from which to create the
+	// linked clone. Specify the name of the snapshot, the path of the snapshot
+	// in the snapshot tree (for example, `base/updated`), or the managed
+	// object ID of the snapshot (for example, `snapshot-123`). The name must
+	// be unique in the snapshot tree. Defaults to the current snapshot.
+	// Requires `linked_clone`.
+	LinkedCloneSnapshot string `mapstructure:"linked_clone_snapshot"`
+	// Create a snapshot of the source virtual machine for `linked_clone` if
+	// the source has no snapshots, rather than failing the build. The
+	// snapshot is kept after the build, since linked clones depend on it, and
+	// is recorded in the artifact metadata as `source_snapshot`. If the source
+	// is a template, it is converted to a virtual machine to create the
+	// snapshot and converted back to a template. Requires the
+	// `VirtualMachine.State.CreateSnapshot` privilege on the source.
+	// Defaults to `false`.
+	CreateSnapshotOnSource bool `mapstructure:"create_snapshot_on_source"`
+	// The name of the snapshot created by `create_snapshot_on_source`.
+	// Defaults to `packer-linked-clone-base`.
+	SourceSnapshotName string `mapstructure:"source_snapshot_name"`
+	// Upgrade the virtual hardware version of the virtual machine after it
+	// is cloned, imported, or deployed, such as from version 13 to 21 for a
+	// source created with an earlier release of vSphere. The version must be
+	// supported by the host of the virtual machine and cannot be lower than
+	// the version of the source. Refer to [KB 315655](https://knowledge.broadcom.com/external/article?articleNumber=315655)
+	// for more information on supported virtual hardware versions. Defaults
+	// to the version of the source.
+	Version uint `mapstructure:"vm_version"`
 	// The network to which the virtual machine will connect.
 	//
 	// For example:
@@ -84,16 +315,34 @@ type CloneConfig struct {
 	// - Segment ID: `/infra/segments/<SegmentID>`
 	//
 	// ~> **Note:** If more than one network resolves to the same name, either
-	// the inventory path to network or an ID must be provided.
+	// the inventory path to network or an ID must be provided, or the lookup
+	// must be scoped with `network_switch` or `network_host`.
 	//
 	// ~> **Note:** If no network is specified, provide `host` to allow the
 	// plugin to search for an available network.
 	Network string `mapstructure:"network"`
+	// The name of the distributed virtual switch that owns the network. Used
+	// to select the network when more than one network resolves to the same
+	// name.
+	NetworkSwitch string `mapstructure:"network_switch"`
+	// The ESXi host that must have access to the network. Used to select the
+	// network when more than one network resolves to the same name. Defaults
+	// to the value of `host`.
+	NetworkHost string `mapstructure:"network_host"`
 	// The network card MAC address. For example `00:50:56:00:00:00`.
 	// If set, the `network` must be also specified.
 	MacAddress string `mapstructure:"mac_address"`
-	// The annotations for the virtual machine.
+	// The annotations for the virtual machine. The notes are a template that
+	// can use the `{{ .Name }}` (the name of the virtual machine),
+	// `{{ .Source }}` (the source of the build), `{{ .PluginVersion }}`, and
+	// `{{ .Date }}` build variables, and the [`vsphere`](#vsphere-template-function)
+	// template function.
 	Notes string `mapstructure:"notes"`
+	// Keep the notes of the source virtual machine, or of the OVF, OVA, or
+	// content library item of the source, and append the rendered notes and
+	// the build provenance, including the plugin version, the date, and the
+	// source. Defaults to `false`.
+	AppendNotes bool `mapstructure:"append_notes"`
 	// Destroy the virtual machine after the build is complete.
 	// Defaults to `false`.
 	Destroy bool `mapstructure:"destroy"`
@@ -108,13 +357,44 @@ func (c *CloneConfig) Prepare() []error {
 	var errs []error
 	errs = append(errs, c.StorageConfig.Prepare()...)
 
-	if c.Template == "" {
+	var sources []string
+	if c.RemoteSource != nil {
+		sources = append(sources, "remote_source")
+	}
+	if c.ContentLibrarySource != nil {
+		sources = append(sources, "content_library_source")
+	}
+	if c.NamespaceImageSource != nil {
+		sources = append(sources, "namespace_image_source")
+	}
+
+	switch {
+	case len(sources) > 1:
+		errs = append(errs, fmt.Errorf("'%s' and '%s' cannot be used together", sources[0], sources[1]))
+	case c.RemoteSource != nil:
+		errs = append(errs, c.prepareRemoteSource()...)
+	case c.ContentLibrarySource != nil:
+		errs = append(errs, c.prepareContentLibrarySource()...)
+	case c.NamespaceImageSource != nil:
+		errs = append(errs, c.prepareNamespaceImageSource()...)
+	case c.Template == "":
 		errs = append(errs, fmt.Errorf("'template' is required"))
 	}
 
 	if c.LinkedClone && c.DiskSize != 0 {
 		errs = append(errs, fmt.Errorf("'linked_clone' and 'disk_size' cannot be used together"))
 	}
+	errs = append(errs, c.prepareDiskResize()...)
+
+	if c.LinkedCloneSnapshot != "" && !c.LinkedClone {
+		errs = append(errs, fmt.Errorf("'linked_clone_snapshot' requires 'linked_clone'"))
+	}
+	if c.CreateSnapshotOnSource && !c.LinkedClone {
+		errs = append(errs, fmt.Errorf("'create_snapshot_on_source' requires 'linked_clone'"))
+	}
+	if c.SourceSnapshotName == "" {
+		c.SourceSnapshotName = defaultSourceSnapshotName
+	}
 
 	if c.MacAddress != "" && c.Network == "" {
 		errs = append(errs, fmt.Errorf("'network' is required when 'mac_address' is specified"))
@@ -123,11 +403,131 @@ func (c *CloneConfig) Prepare() []error {
 	return errs
 }
 
+func (c *CloneConfig) prepareRemoteSource() []error {
+	var errs []error
+
+	var dsPath object.DatastorePath
+	if !dsPath.FromString(c.RemoteSource.DatastorePath) || dsPath.Path == "" {
+		errs = append(errs, fmt.Errorf("'remote_source.datastore_path' must be a datastore path, for example '[datastore1] images/base.ova'"))
+	} else if ext := strings.ToLower(path.Ext(dsPath.Path)); ext != ".ovf" && ext != ".ova" {
+		errs = append(errs, fmt.Errorf("'remote_source.datastore_path' must be the path of an OVF descriptor or OVA"))
+	}
+
+	return append(errs, c.prepareSourceConflicts("remote_source")...)
+}
+
+func (c *CloneConfig) prepareContentLibrarySource() []error {
+	var errs []error
+
+	if c.ContentLibrarySource.Library == "" {
+		errs = append(errs, fmt.Errorf("'content_library_source.library' is required"))
+	}
+	if c.ContentLibrarySource.Item == "" {
+		errs = append(errs, fmt.Errorf("'content_library_source.item' is required"))
+	}
+
+	return append(errs, c.prepareSourceConflicts("content_library_source")...)
+}
+
+func (c *CloneConfig) prepareNamespaceImageSource() []error {
+	var errs []error
+
+	if c.NamespaceImageSource.Namespace == "" {
+		errs = append(errs, fmt.Errorf("'namespace_image_source.namespace' is required"))
+	}
+	if c.NamespaceImageSource.Image == "" {
+		errs = append(errs, fmt.Errorf("'namespace_image_source.image' is required"))
+	}
+
+	return append(errs, c.prepareSourceConflicts("namespace_image_source")...)
+}
+
+func (c *CloneConfig) prepareDiskResize() []error {
+	var errs []error
+
+	if len(c.DiskResize) == 0 {
+		return nil
+	}
+	if c.LinkedClone {
+		errs = append(errs, fmt.Errorf("'linked_clone' and 'disk_resize' cannot be used together"))
+	}
+	if c.DiskSize != 0 {
+		errs = append(errs, fmt.Errorf("'disk_size' and 'disk_resize' cannot be used together"))
+	}
+
+	indexes := make(map[int]bool)
+	labels := make(map[string]bool)
+	for i, r := range c.DiskResize {
+		switch {
+		case r.Label != "" && r.Index != 0:
+			errs = append(errs, fmt.Errorf("disk_resize[%d].'index' and 'label' cannot be used together", i))
+		case r.Index < 0:
+			errs = append(errs, fmt.Errorf("disk_resize[%d].'index' must be greater than or equal to 0", i))
+		case r.Label != "" && labels[r.Label]:
+			errs = append(errs, fmt.Errorf("disk_resize[%d].'label' %s is already resized", i, r.Label))
+		case r.Label == "" && indexes[r.Index]:
+			errs = append(errs, fmt.Errorf("disk_resize[%d].'index' %d is already resized", i, r.Index))
+		}
+		if r.Label != "" {
+			labels[r.Label] = true
+		} else {
+			indexes[r.Index] = true
+		}
+		if r.Size <= 0 {
+			errs = append(errs, fmt.Errorf("disk_resize[%d].'size' is required", i))
+		}
+	}
+
+	return errs
+}
+
+// diskResizes returns the disks to resize when the source is cloned.
+func (c *CloneConfig) diskResizes() []driver.DiskResize {
+	var resizes []driver.DiskResize
+	for _, r := range c.DiskResize {
+		resizes = append(resizes, driver.DiskResize{Index: r.Index, Label: r.Label, Size: r.Size})
+	}
+	return resizes
+}
+
+// prepareSourceConflicts validates the options that only apply to a clone
+// when the virtual machine of the build is created from another source.
+func (c *CloneConfig) prepareSourceConflicts(source string) []error {
+	var errs []error
+
+	if c.Template != "" {
+		errs = append(errs, fmt.Errorf("'template' and '%s' cannot be used together", source))
+	}
+	if c.LinkedClone {
+		errs = append(errs, fmt.Errorf("'linked_clone' and '%s' cannot be used together", source))
+	}
+	if c.DiskSize != 0 {
+		errs = append(errs, fmt.Errorf("'disk_size' and '%s' cannot be used together", source))
+	}
+	if len(c.DiskResize) > 0 {
+		errs = append(errs, fmt.Errorf("'disk_resize' and '%s' cannot be used together", source))
+	}
+	if c.MacAddress != "" {
+		errs = append(errs, fmt.Errorf("'mac_address' and '%s' cannot be used together", source))
+	}
+	if len(c.StorageConfig.Storage) > 0 {
+		errs = append(errs, fmt.Errorf("'storage' and '%s' cannot be used together", source))
+	}
+
+	return errs
+}
+
+// The default name of the snapshot created on the source for a linked clone.
+const defaultSourceSnapshotName = "packer-linked-clone-base"
+
 type StepCloneVM struct {
-	Config        *CloneConfig
-	Location      *common.LocationConfig
-	Force         bool
-	GeneratedData *packerbuilderdata.GeneratedData
+	Config            *CloneConfig
+	Location          *common.LocationConfig
+	Force             bool
+	GeneratedData     *packerbuilderdata.GeneratedData
+	Ctx               interpolate.Context
+	ConvertToTemplate bool
+	Timeout           time.Duration
 }
 
 func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -135,6 +535,13 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 	d := state.Get("driver").(driver.Driver)
 	vmPath := path.Join(s.Location.Folder, s.Location.VMName)
 
+	if s.Config.RemoteSource != nil {
+		return s.importRemoteSource(ctx, state, vmPath)
+	}
+	if s.Config.ContentLibrarySource != nil || s.Config.NamespaceImageSource != nil {
+		return s.deployContentLibrarySource(ctx, state, vmPath)
+	}
+
 	ui.Say("Finding virtual machine to clone...")
 	template, err := d.FindVM(s.Config.Template)
 	if err != nil {
@@ -148,34 +555,79 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 		return multistep.ActionHalt
 	}
 
+	if s.Config.LinkedClone && s.Config.CreateSnapshotOnSource {
+		snapshot, err := s.ensureSourceSnapshot(ui, template)
+		if err != nil {
+			state.Put("error", fmt.Errorf("error creating a snapshot of the virtual machine to clone: %s", err))
+			return multistep.ActionHalt
+		}
+		if snapshot != "" {
+			state.Put("source_snapshot", snapshot)
+		}
+	}
+
+	var sourceNotes string
+	if s.Config.AppendNotes {
+		info, err := template.Info("config.annotation")
+		if err != nil {
+			state.Put("error", fmt.Errorf("error retrieving the notes of the virtual machine to clone: %s", err))
+			return multistep.ActionHalt
+		}
+		if info != nil && info.Config != nil {
+			sourceNotes = info.Config.Annotation
+		}
+	}
+	notes, err := common.RenderNotes(s.notesContext(d), s.Config.Notes, s.Config.AppendNotes, sourceNotes, common.NotesTemplateData{
+		Name:   s.Location.VMName,
+		Source: s.Config.Template,
+	})
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
 	ui.Say("Cloning virtual machine...")
-	var disks []driver.Disk
-	for _, disk := range s.Config.StorageConfig.Storage {
-		disks = append(disks, driver.Disk{
-			DiskSize:            disk.DiskSize,
-			DiskEagerlyScrub:    disk.DiskEagerlyScrub,
-			DiskThinProvisioned: disk.DiskThinProvisioned,
-			ControllerIndex:     disk.DiskControllerIndex,
-		})
-	}
-
-	vm, err := template.Clone(ctx, &driver.CloneConfig{
-		Name:            s.Location.VMName,
-		Folder:          s.Location.Folder,
-		Cluster:         s.Location.Cluster,
-		Host:            s.Location.Host,
-		ResourcePool:    s.Location.ResourcePool,
-		Datastore:       s.Location.Datastore,
-		LinkedClone:     s.Config.LinkedClone,
-		Network:         s.Config.Network,
-		MacAddress:      strings.ToLower(s.Config.MacAddress),
-		Annotation:      s.Config.Notes,
-		VAppProperties:  s.Config.VAppConfig.Properties,
-		PrimaryDiskSize: s.Config.DiskSize,
+	disks, err := s.Config.StorageConfig.Disks(d, s.Location.Host)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	storagePolicyID, err := s.Config.StorageConfig.StoragePolicyID(d)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	cloneCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		cloneCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	vm, err := template.Clone(cloneCtx, &driver.CloneConfig{
+		Name:                s.Location.VMName,
+		Folder:              s.Location.Folder,
+		Cluster:             s.Location.Cluster,
+		Host:                s.Location.Host,
+		ResourcePool:        s.Location.ResourcePool,
+		Datastore:           s.Location.Datastore,
+		LinkedClone:         s.Config.LinkedClone,
+		LinkedCloneSnapshot: s.Config.LinkedCloneSnapshot,
+		Network:             s.Config.Network,
+		NetworkSwitch:       s.Config.NetworkSwitch,
+		NetworkHost:         s.Config.NetworkHost,
+		MacAddress:          strings.ToLower(s.Config.MacAddress),
+		Annotation:          notes,
+		VAppProperties:      s.Config.VAppConfig.Properties,
+		PrimaryDiskSize:     s.Config.DiskSize,
+		DiskResizes:         s.Config.diskResizes(),
 		StorageConfig: driver.StorageConfig{
 			DiskControllerType: s.Config.StorageConfig.DiskControllerType,
 			Storage:            disks,
+			StoragePolicyID:    storagePolicyID,
 		},
+		ConvertToTemplate: s.ConvertToTemplate,
 	})
 	if err != nil {
 		state.Put("error", err)
@@ -187,10 +639,266 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 	if s.Config.Destroy {
 		state.Put("destroy_vm", s.Config.Destroy)
 	}
+	if keep := s.Config.StorageConfig.KeepOnDestroy(); len(keep) > 0 {
+		state.Put("keep_disks", keep)
+	}
 	state.Put("vm", vm)
 	return multistep.ActionContinue
 }
 
+// importRemoteSource imports the OVF or OVA of the remote source as the virtual
+// machine of the build.
+func (s *StepCloneVM) importRemoteSource(ctx context.Context, state multistep.StateBag, vmPath string) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	err := d.PreCleanVM(ui, vmPath, s.Force, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	notes, err := common.RenderNotes(s.notesContext(d), s.Config.Notes, false, "", common.NotesTemplateData{
+		Name:   s.Location.VMName,
+		Source: s.Config.RemoteSource.DatastorePath,
+	})
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	// The notes of the source are kept on import and the notes are appended
+	// once the virtual machine exists.
+	if s.Config.AppendNotes {
+		notes = ""
+	}
+
+	importCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		importCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	storagePolicyID, err := s.Config.StorageConfig.StoragePolicyID(d)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	ui.Sayf("Importing %s...", s.Config.RemoteSource.DatastorePath)
+	vm, err := d.ImportOvf(importCtx, &driver.ImportOvfConfig{
+		Name:              s.Location.VMName,
+		Folder:            s.Location.Folder,
+		Cluster:           s.Location.Cluster,
+		Host:              s.Location.Host,
+		ResourcePool:      s.Location.ResourcePool,
+		Datastore:         s.Location.Datastore,
+		DatastorePath:     s.Config.RemoteSource.DatastorePath,
+		Network:           s.Config.Network,
+		NetworkSwitch:     s.Config.NetworkSwitch,
+		NetworkHost:       s.Config.NetworkHost,
+		Annotation:        notes,
+		Properties:        s.Config.VAppConfig.Properties,
+		StoragePolicyID:   storagePolicyID,
+		ConvertToTemplate: s.ConvertToTemplate,
+	})
+	if err != nil {
+		if errors.Is(importCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s importing %s", s.Timeout, s.Config.RemoteSource.DatastorePath)
+		}
+		state.Put("error", fmt.Errorf("error importing remote source: %s", err))
+		return multistep.ActionHalt
+	}
+
+	if s.Config.Destroy {
+		state.Put("destroy_vm", s.Config.Destroy)
+	}
+	state.Put("vm", vm)
+
+	if s.Config.AppendNotes {
+		if err := s.appendNotes(d, vm, s.Config.RemoteSource.DatastorePath); err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+	return multistep.ActionContinue
+}
+
+// deployContentLibrarySource deploys the item of the content library source,
+// or the VM image of the namespace image source, as the virtual machine of
+// the build.
+func (s *StepCloneVM) deployContentLibrarySource(ctx context.Context, state multistep.StateBag, vmPath string) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	var library, namespace, item string
+	if source := s.Config.NamespaceImageSource; source != nil {
+		namespace, item = source.Namespace, source.Image
+	} else {
+		library, item = s.Config.ContentLibrarySource.Library, s.Config.ContentLibrarySource.Item
+	}
+	sourceName, sourceType := path.Join(library, item), "content library source"
+	if namespace != "" {
+		sourceName, sourceType = path.Join(namespace, item), "namespace image source"
+	}
+
+	err := d.PreCleanVM(ui, vmPath, s.Force, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	notes, err := common.RenderNotes(s.notesContext(d), s.Config.Notes, false, "", common.NotesTemplateData{
+		Name:   s.Location.VMName,
+		Source: sourceName,
+	})
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	// The notes of the source are kept on import and the notes are appended
+	// once the virtual machine exists.
+	if s.Config.AppendNotes {
+		notes = ""
+	}
+
+	deployCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		deployCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	storagePolicyID, err := s.Config.StorageConfig.StoragePolicyID(d)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	if namespace != "" {
+		ui.Sayf("Deploying VM image %s from namespace %s...", item, namespace)
+	} else {
+		ui.Sayf("Deploying %s from content library %s...", item, library)
+	}
+	vm, err := d.DeployLibraryItem(deployCtx, &driver.DeployLibraryItemConfig{
+		Library:           library,
+		Item:              item,
+		Namespace:         namespace,
+		Name:              s.Location.VMName,
+		Folder:            s.Location.Folder,
+		Cluster:           s.Location.Cluster,
+		Host:              s.Location.Host,
+		ResourcePool:      s.Location.ResourcePool,
+		Datastore:         s.Location.Datastore,
+		Network:           s.Config.Network,
+		NetworkSwitch:     s.Config.NetworkSwitch,
+		NetworkHost:       s.Config.NetworkHost,
+		Annotation:        notes,
+		Properties:        s.Config.VAppConfig.Properties,
+		StoragePolicyID:   storagePolicyID,
+		ConvertToTemplate: s.ConvertToTemplate,
+	})
+	if err != nil {
+		if errors.Is(deployCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s deploying %s", s.Timeout, item)
+		}
+		state.Put("error", fmt.Errorf("error deploying %s: %s", sourceType, err))
+		return multistep.ActionHalt
+	}
+
+	if s.Config.Destroy {
+		state.Put("destroy_vm", s.Config.Destroy)
+	}
+	state.Put("vm", vm)
+
+	if s.Config.AppendNotes {
+		if err := s.appendNotes(d, vm, sourceName); err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+	return multistep.ActionContinue
+}
+
+// appendNotes appends the rendered notes and the build provenance to the notes
+// of the virtual machine, which are the notes of the imported or deployed
+// source.
+func (s *StepCloneVM) appendNotes(d driver.Driver, vm driver.VirtualMachine, source string) error {
+	existing, err := vm.Notes()
+	if err != nil {
+		return fmt.Errorf("error retrieving the notes of the virtual machine: %s", err)
+	}
+	notes, err := common.RenderNotes(s.notesContext(d), s.Config.Notes, true, existing, common.NotesTemplateData{
+		Name:   s.Location.VMName,
+		Source: source,
+	})
+	if err != nil {
+		return err
+	}
+	if err := vm.SetNotes(notes); err != nil {
+		return fmt.Errorf("error setting the notes of the virtual machine: %s", err)
+	}
+	return nil
+}
+
+// ensureSourceSnapshot creates a snapshot of the source virtual machine if it
+// has no snapshots, and returns the name of the snapshot it created.
+func (s *StepCloneVM) ensureSourceSnapshot(ui packersdk.Ui, template driver.VirtualMachine) (string, error) {
+	info, err := template.Info("snapshot", "config.template")
+	if err != nil {
+		return "", err
+	}
+	if info == nil || info.Snapshot != nil {
+		return "", nil
+	}
+
+	isTemplate := info.Config != nil && info.Config.Template
+	privileges := []string{"VirtualMachine.State.CreateSnapshot"}
+	if isTemplate {
+		privileges = append(privileges, "VirtualMachine.Provisioning.MarkAsVM", "VirtualMachine.Provisioning.MarkAsTemplate")
+	}
+	missing, err := template.MissingPrivileges(privileges...)
+	if err != nil {
+		return "", fmt.Errorf("error checking privileges: %s", err)
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("the source has no snapshots and the privileges to create one are missing: %s", strings.Join(missing, ", "))
+	}
+
+	if isTemplate {
+		ui.Say("Converting the source template to a virtual machine...")
+		if err := template.ConvertToVirtualMachine(s.Location.Cluster, s.Location.Host, s.Location.ResourcePool); err != nil {
+			return "", err
+		}
+	}
+
+	ui.Sayf("Creating snapshot %s of the source for the linked clone...", s.Config.SourceSnapshotName)
+	snapshotErr := template.CreateSnapshot(s.Config.SourceSnapshotName)
+
+	if isTemplate {
+		ui.Say("Converting the source back to a template...")
+		if err := template.ConvertToTemplate(); err != nil {
+			return "", err
+		}
+	}
+	if snapshotErr != nil {
+		return "", snapshotErr
+	}
+	return s.Config.SourceSnapshotName, nil
+}
+
 func (s *StepCloneVM) Cleanup(state multistep.StateBag) {
 	common.CleanupVM(state)
 }
+
+// notesContext returns the interpolation context of the notes, with the
+// `vsphere` template function resolved from the location and network of the
+// configuration.
+func (s *StepCloneVM) notesContext(d driver.Driver) interpolate.Context {
+	return common.WithVSphereFunc(s.Ctx, &common.VSphereTemplateValues{
+		Driver:    d,
+		Datastore: s.Location.Datastore,
+		Host:      s.Location.Host,
+		Network:   s.Config.Network,
+	})
+}
@@ -11,16 +11,29 @@ import (
 // FlatCloneConfig is an auto-generated flat version of CloneConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatCloneConfig struct {
-	Template           *string                 `mapstructure:"template" cty:"template" hcl:"template"`
-	DiskSize           *int64                  `mapstructure:"disk_size" cty:"disk_size" hcl:"disk_size"`
-	LinkedClone        *bool                   `mapstructure:"linked_clone" cty:"linked_clone" hcl:"linked_clone"`
-	Network            *string                 `mapstructure:"network" cty:"network" hcl:"network"`
-	MacAddress         *string                 `mapstructure:"mac_address" cty:"mac_address" hcl:"mac_address"`
-	Notes              *string                 `mapstructure:"notes" cty:"notes" hcl:"notes"`
-	Destroy            *bool                   `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
-	VAppConfig         *FlatvAppConfig         `mapstructure:"vapp" cty:"vapp" hcl:"vapp"`
-	DiskControllerType []string                `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
-	Storage            []common.FlatDiskConfig `mapstructure:"storage" cty:"storage" hcl:"storage"`
+	Template               *string                           `mapstructure:"template" cty:"template" hcl:"template"`
+	RemoteSource           *FlatRemoteSourceConfig           `mapstructure:"remote_source" cty:"remote_source" hcl:"remote_source"`
+	ContentLibrarySource   *FlatContentLibrarySourceConfig   `mapstructure:"content_library_source" cty:"content_library_source" hcl:"content_library_source"`
+	NamespaceImageSource   *FlatNamespaceImageSourceConfig   `mapstructure:"namespace_image_source" cty:"namespace_image_source" hcl:"namespace_image_source"`
+	DiskSize               *int64                            `mapstructure:"disk_size" cty:"disk_size" hcl:"disk_size"`
+	DiskResize             []FlatDiskResizeConfig            `mapstructure:"disk_resize" cty:"disk_resize" hcl:"disk_resize"`
+	LinkedClone            *bool                             `mapstructure:"linked_clone" cty:"linked_clone" hcl:"linked_clone"`
+	LinkedCloneSnapshot    *string                           `mapstructure:"linked_clone_snapshot" cty:"linked_clone_snapshot" hcl:"linked_clone_snapshot"`
+	CreateSnapshotOnSource *bool                             `mapstructure:"create_snapshot_on_source" cty:"create_snapshot_on_source" hcl:"create_snapshot_on_source"`
+	SourceSnapshotName     *string                           `mapstructure:"source_snapshot_name" cty:"source_snapshot_name" hcl:"source_snapshot_name"`
+	Version                *uint                             `mapstructure:"vm_version" cty:"vm_version" hcl:"vm_version"`
+	Network                *string                           `mapstructure:"network" cty:"network" hcl:"network"`
+	NetworkSwitch          *string                           `mapstructure:"network_switch" cty:"network_switch" hcl:"network_switch"`
+	NetworkHost            *string                           `mapstructure:"network_host" cty:"network_host" hcl:"network_host"`
+	MacAddress             *string                           `mapstructure:"mac_address" cty:"mac_address" hcl:"mac_address"`
+	Notes                  *string                           `mapstructure:"notes" cty:"notes" hcl:"notes"`
+	AppendNotes            *bool                             `mapstructure:"append_notes" cty:"append_notes" hcl:"append_notes"`
+	Destroy                *bool                             `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
+	VAppConfig             *FlatvAppConfig                   `mapstructure:"vapp" cty:"vapp" hcl:"vapp"`
+	DiskControllerType     []string                          `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
+	Storage                []common.FlatDiskConfig           `mapstructure:"storage" cty:"storage" hcl:"storage"`
+	FirstClassDisks        []common.FlatFirstClassDiskConfig `mapstructure:"first_class_disk" cty:"first_class_disk" hcl:"first_class_disk"`
+	StoragePolicy          *string                           `mapstructure:"storage_policy" cty:"storage_policy" hcl:"storage_policy"`
 }
 
 // FlatMapstructure returns a new FlatCloneConfig.
@@ -35,16 +48,129 @@ func (*CloneConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.S
 // The decoded values from this spec will then be applied to a FlatCloneConfig.
 func (*FlatCloneConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"template":             &hcldec.AttrSpec{Name: "template", Type: cty.String, Required: false},
-		"disk_size":            &hcldec.AttrSpec{Name: "disk_size", Type: cty.Number, Required: false},
-		"linked_clone":         &hcldec.AttrSpec{Name: "linked_clone", Type: cty.Bool, Required: false},
-		"network":              &hcldec.AttrSpec{Name: "network", Type: cty.String, Required: false},
-		"mac_address":          &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
-		"notes":                &hcldec.AttrSpec{Name: "notes", Type: cty.String, Required: false},
-		"destroy":              &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
-		"vapp":                 &hcldec.BlockSpec{TypeName: "vapp", Nested: hcldec.ObjectSpec((*FlatvAppConfig)(nil).HCL2Spec())},
-		"disk_controller_type": &hcldec.AttrSpec{Name: "disk_controller_type", Type: cty.List(cty.String), Required: false},
-		"storage":              &hcldec.BlockListSpec{TypeName: "storage", Nested: hcldec.ObjectSpec((*common.FlatDiskConfig)(nil).HCL2Spec())},
+		"template":                  &hcldec.AttrSpec{Name: "template", Type: cty.String, Required: false},
+		"remote_source":             &hcldec.BlockSpec{TypeName: "remote_source", Nested: hcldec.ObjectSpec((*FlatRemoteSourceConfig)(nil).HCL2Spec())},
+		"content_library_source":    &hcldec.BlockSpec{TypeName: "content_library_source", Nested: hcldec.ObjectSpec((*FlatContentLibrarySourceConfig)(nil).HCL2Spec())},
+		"namespace_image_source":    &hcldec.BlockSpec{TypeName: "namespace_image_source", Nested: hcldec.ObjectSpec((*FlatNamespaceImageSourceConfig)(nil).HCL2Spec())},
+		"disk_size":                 &hcldec.AttrSpec{Name: "disk_size", Type: cty.Number, Required: false},
+		"disk_resize":               &hcldec.BlockListSpec{TypeName: "disk_resize", Nested: hcldec.ObjectSpec((*FlatDiskResizeConfig)(nil).HCL2Spec())},
+		"linked_clone":              &hcldec.AttrSpec{Name: "linked_clone", Type: cty.Bool, Required: false},
+		"linked_clone_snapshot":     &hcldec.AttrSpec{Name: "linked_clone_snapshot", Type: cty.String, Required: false},
+		"create_snapshot_on_source": &hcldec.AttrSpec{Name: "create_snapshot_on_source", Type: cty.Bool, Required: false},
+		"source_snapshot_name":      &hcldec.AttrSpec{Name: "source_snapshot_name", Type: cty.String, Required: false},
+		"vm_version":                &hcldec.AttrSpec{Name: "vm_version", Type: cty.Number, Required: false},
+		"network":                   &hcldec.AttrSpec{Name: "network", Type: cty.String, Required: false},
+		"network_switch":            &hcldec.AttrSpec{Name: "network_switch", Type: cty.String, Required: false},
+		"network_host":              &hcldec.AttrSpec{Name: "network_host", Type: cty.String, Required: false},
+		"mac_address":               &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
+		"notes":                     &hcldec.AttrSpec{Name: "notes", Type: cty.String, Required: false},
+		"append_notes":              &hcldec.AttrSpec{Name: "append_notes", Type: cty.Bool, Required: false},
+		"destroy":                   &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
+		"vapp":                      &hcldec.BlockSpec{TypeName: "vapp", Nested: hcldec.ObjectSpec((*FlatvAppConfig)(nil).HCL2Spec())},
+		"disk_controller_type":      &hcldec.AttrSpec{Name: "disk_controller_type", Type: cty.List(cty.String), Required: false},
+		"storage":                   &hcldec.BlockListSpec{TypeName: "storage", Nested: hcldec.ObjectSpec((*common.FlatDiskConfig)(nil).HCL2Spec())},
+		"first_class_disk":          &hcldec.BlockListSpec{TypeName: "first_class_disk", Nested: hcldec.ObjectSpec((*common.FlatFirstClassDiskConfig)(nil).HCL2Spec())},
+		"storage_policy":            &hcldec.AttrSpec{Name: "storage_policy", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatContentLibrarySourceConfig is an auto-generated flat version of ContentLibrarySourceConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatContentLibrarySourceConfig struct {
+	Library *string `mapstructure:"library" required:"true" cty:"library" hcl:"library"`
+	Item    *string `mapstructure:"item" required:"true" cty:"item" hcl:"item"`
+}
+
+// FlatMapstructure returns a new FlatContentLibrarySourceConfig.
+// FlatContentLibrarySourceConfig is an auto-generated flat version of ContentLibrarySourceConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*ContentLibrarySourceConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatContentLibrarySourceConfig)
+}
+
+// HCL2Spec returns the hcl spec of a ContentLibrarySourceConfig.
+// This spec is used by HCL to read the fields of ContentLibrarySourceConfig.
+// The decoded values from this spec will then be applied to a FlatContentLibrarySourceConfig.
+func (*FlatContentLibrarySourceConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"library": &hcldec.AttrSpec{Name: "library", Type: cty.String, Required: false},
+		"item":    &hcldec.AttrSpec{Name: "item", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatDiskResizeConfig is an auto-generated flat version of DiskResizeConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDiskResizeConfig struct {
+	Index *int    `mapstructure:"index" cty:"index" hcl:"index"`
+	Label *string `mapstructure:"label" cty:"label" hcl:"label"`
+	Size  *int64  `mapstructure:"size" required:"true" cty:"size" hcl:"size"`
+}
+
+// FlatMapstructure returns a new FlatDiskResizeConfig.
+// FlatDiskResizeConfig is an auto-generated flat version of DiskResizeConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DiskResizeConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDiskResizeConfig)
+}
+
+// HCL2Spec returns the hcl spec of a DiskResizeConfig.
+// This spec is used by HCL to read the fields of DiskResizeConfig.
+// The decoded values from this spec will then be applied to a FlatDiskResizeConfig.
+func (*FlatDiskResizeConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"index": &hcldec.AttrSpec{Name: "index", Type: cty.Number, Required: false},
+		"label": &hcldec.AttrSpec{Name: "label", Type: cty.String, Required: false},
+		"size":  &hcldec.AttrSpec{Name: "size", Type: cty.Number, Required: false},
+	}
+	return s
+}
+
+// FlatNamespaceImageSourceConfig is an auto-generated flat version of NamespaceImageSourceConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatNamespaceImageSourceConfig struct {
+	Namespace *string `mapstructure:"namespace" required:"true" cty:"namespace" hcl:"namespace"`
+	Image     *string `mapstructure:"image" required:"true" cty:"image" hcl:"image"`
+}
+
+// FlatMapstructure returns a new FlatNamespaceImageSourceConfig.
+// FlatNamespaceImageSourceConfig is an auto-generated flat version of NamespaceImageSourceConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*NamespaceImageSourceConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatNamespaceImageSourceConfig)
+}
+
+// HCL2Spec returns the hcl spec of a NamespaceImageSourceConfig.
+// This spec is used by HCL to read the fields of NamespaceImageSourceConfig.
+// The decoded values from this spec will then be applied to a FlatNamespaceImageSourceConfig.
+func (*FlatNamespaceImageSourceConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"namespace": &hcldec.AttrSpec{Name: "namespace", Type: cty.String, Required: false},
+		"image":     &hcldec.AttrSpec{Name: "image", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatRemoteSourceConfig is an auto-generated flat version of RemoteSourceConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatRemoteSourceConfig struct {
+	DatastorePath *string `mapstructure:"datastore_path" required:"true" cty:"datastore_path" hcl:"datastore_path"`
+}
+
+// FlatMapstructure returns a new FlatRemoteSourceConfig.
+// FlatRemoteSourceConfig is an auto-generated flat version of RemoteSourceConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*RemoteSourceConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatRemoteSourceConfig)
+}
+
+// HCL2Spec returns the hcl spec of a RemoteSourceConfig.
+// This spec is used by HCL to read the fields of RemoteSourceConfig.
+// The decoded values from this spec will then be applied to a FlatRemoteSourceConfig.
+func (*FlatRemoteSourceConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"datastore_path": &hcldec.AttrSpec{Name: "datastore_path", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -52,7 +178,8 @@ func (*FlatCloneConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatvAppConfig is an auto-generated flat version of vAppConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatvAppConfig struct {
-	Properties map[string]string `mapstructure:"properties" cty:"properties" hcl:"properties"`
+	Properties      map[string]string `mapstructure:"properties" cty:"properties" hcl:"properties"`
+	ResetProperties []string          `mapstructure:"reset_properties" cty:"reset_properties" hcl:"reset_properties"`
 }
 
 // FlatMapstructure returns a new FlatvAppConfig.
@@ -67,7 +194,8 @@ func (*vAppConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Sp
 // The decoded values from this spec will then be applied to a FlatvAppConfig.
 func (*FlatvAppConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"properties": &hcldec.AttrSpec{Name: "properties", Type: cty.Map(cty.String), Required: false},
+		"properties":       &hcldec.AttrSpec{Name: "properties", Type: cty.Map(cty.String), Required: false},
+		"reset_properties": &hcldec.AttrSpec{Name: "reset_properties", Type: cty.List(cty.String), Required: false},
 	}
 	return s
 }
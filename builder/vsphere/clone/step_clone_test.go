@@ -6,6 +6,8 @@ package clone
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"path"
 	"strings"
 	"testing"
@@ -15,6 +17,7 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/mo"
 )
 
 func TestCreateConfig_Prepare(t *testing.T) {
@@ -120,6 +123,192 @@ func TestCreateConfig_Prepare(t *testing.T) {
 			fail:           true,
 			expectedErrMsg: "'network' is required when 'mac_address' is specified",
 		},
+		{
+			name: "Validate LinkedCloneSnapshot requires LinkedClone",
+			config: &CloneConfig{
+				Template:            "template name",
+				LinkedCloneSnapshot: "base",
+				StorageConfig: common.StorageConfig{
+					DiskControllerType: []string{"test"},
+					Storage: []common.DiskConfig{
+						{
+							DiskSize: 32768,
+						},
+					},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "'linked_clone_snapshot' requires 'linked_clone'",
+		},
+		{
+			name: "Validate CreateSnapshotOnSource requires LinkedClone",
+			config: &CloneConfig{
+				Template:               "template name",
+				CreateSnapshotOnSource: true,
+				StorageConfig: common.StorageConfig{
+					DiskControllerType: []string{"test"},
+					Storage: []common.DiskConfig{
+						{
+							DiskSize: 32768,
+						},
+					},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "'create_snapshot_on_source' requires 'linked_clone'",
+		},
+		{
+			name: "Valid remote source",
+			config: &CloneConfig{
+				RemoteSource: &RemoteSourceConfig{DatastorePath: "[datastore1] images/base.ova"},
+			},
+			fail: false,
+		},
+		{
+			name: "Validate remote source datastore path",
+			config: &CloneConfig{
+				RemoteSource: &RemoteSourceConfig{DatastorePath: "images/base.ova"},
+			},
+			fail:           true,
+			expectedErrMsg: "'remote_source.datastore_path' must be a datastore path, for example '[datastore1] images/base.ova'",
+		},
+		{
+			name: "Validate remote source file type",
+			config: &CloneConfig{
+				RemoteSource: &RemoteSourceConfig{DatastorePath: "[datastore1] images/base.vmdk"},
+			},
+			fail:           true,
+			expectedErrMsg: "'remote_source.datastore_path' must be the path of an OVF descriptor or OVA",
+		},
+		{
+			name: "Validate Template and RemoteSource set at the same time",
+			config: &CloneConfig{
+				Template:     "template name",
+				RemoteSource: &RemoteSourceConfig{DatastorePath: "[datastore1] images/base.ovf"},
+			},
+			fail:           true,
+			expectedErrMsg: "'template' and 'remote_source' cannot be used together",
+		},
+		{
+			name: "Valid content library source",
+			config: &CloneConfig{
+				ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+			},
+			fail: false,
+		},
+		{
+			name: "Validate content library source item",
+			config: &CloneConfig{
+				ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library"},
+			},
+			fail:           true,
+			expectedErrMsg: "'content_library_source.item' is required",
+		},
+		{
+			name: "Validate LinkedClone and ContentLibrarySource set at the same time",
+			config: &CloneConfig{
+				ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+				LinkedClone:          true,
+			},
+			fail:           true,
+			expectedErrMsg: "'linked_clone' and 'content_library_source' cannot be used together",
+		},
+		{
+			name: "Validate RemoteSource and ContentLibrarySource set at the same time",
+			config: &CloneConfig{
+				RemoteSource:         &RemoteSourceConfig{DatastorePath: "[datastore1] images/base.ovf"},
+				ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+			},
+			fail:           true,
+			expectedErrMsg: "'remote_source' and 'content_library_source' cannot be used together",
+		},
+		{
+			name: "Valid namespace image source",
+			config: &CloneConfig{
+				NamespaceImageSource: &NamespaceImageSourceConfig{Namespace: "platform", Image: "vmi-0a0044d7c690bc9b8"},
+			},
+			fail: false,
+		},
+		{
+			name: "Validate namespace image source image",
+			config: &CloneConfig{
+				NamespaceImageSource: &NamespaceImageSourceConfig{Namespace: "platform"},
+			},
+			fail:           true,
+			expectedErrMsg: "'namespace_image_source.image' is required",
+		},
+		{
+			name: "Validate Template and NamespaceImageSource set at the same time",
+			config: &CloneConfig{
+				Template:             "template name",
+				NamespaceImageSource: &NamespaceImageSourceConfig{Namespace: "platform", Image: "vmi-0a0044d7c690bc9b8"},
+			},
+			fail:           true,
+			expectedErrMsg: "'template' and 'namespace_image_source' cannot be used together",
+		},
+		{
+			name: "Validate ContentLibrarySource and NamespaceImageSource set at the same time",
+			config: &CloneConfig{
+				ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+				NamespaceImageSource: &NamespaceImageSourceConfig{Namespace: "platform", Image: "vmi-0a0044d7c690bc9b8"},
+			},
+			fail:           true,
+			expectedErrMsg: "'content_library_source' and 'namespace_image_source' cannot be used together",
+		},
+		{
+			name: "Valid disk resize",
+			config: &CloneConfig{
+				Template:   "template name",
+				DiskResize: []DiskResizeConfig{{Size: 40960}, {Index: 1, Size: 102400}, {Label: "Hard disk 3", Size: 204800}},
+			},
+			fail: false,
+		},
+		{
+			name: "Validate disk resize size",
+			config: &CloneConfig{
+				Template:   "template name",
+				DiskResize: []DiskResizeConfig{{Index: 1}},
+			},
+			fail:           true,
+			expectedErrMsg: "disk_resize[0].'size' is required",
+		},
+		{
+			name: "Validate disk resize index and label set at the same time",
+			config: &CloneConfig{
+				Template:   "template name",
+				DiskResize: []DiskResizeConfig{{Index: 1, Label: "Hard disk 2", Size: 102400}},
+			},
+			fail:           true,
+			expectedErrMsg: "disk_resize[0].'index' and 'label' cannot be used together",
+		},
+		{
+			name: "Validate disk resize duplicate index",
+			config: &CloneConfig{
+				Template:   "template name",
+				DiskResize: []DiskResizeConfig{{Index: 1, Size: 102400}, {Index: 1, Size: 204800}},
+			},
+			fail:           true,
+			expectedErrMsg: "disk_resize[1].'index' 1 is already resized",
+		},
+		{
+			name: "Validate DiskSize and DiskResize set at the same time",
+			config: &CloneConfig{
+				Template:   "template name",
+				DiskSize:   32768,
+				DiskResize: []DiskResizeConfig{{Index: 1, Size: 102400}},
+			},
+			fail:           true,
+			expectedErrMsg: "'disk_size' and 'disk_resize' cannot be used together",
+		},
+		{
+			name: "Validate ContentLibrarySource and DiskResize set at the same time",
+			config: &CloneConfig{
+				ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+				DiskResize:           []DiskResizeConfig{{Index: 1, Size: 102400}},
+			},
+			fail:           true,
+			expectedErrMsg: "'disk_resize' and 'content_library_source' cannot be used together",
+		},
 	}
 
 	for _, c := range tc {
@@ -191,6 +380,290 @@ func TestStepCreateVM_Run(t *testing.T) {
 	}
 }
 
+func TestStepCreateVM_RunRemoteSource(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	})
+	driverMock := driver.NewDriverMock()
+	state.Put("driver", driverMock)
+	step := basicStepCloneVM()
+	step.Config = &CloneConfig{
+		RemoteSource: &RemoteSourceConfig{DatastorePath: "[datastore1] images/base.ova"},
+		Network:      "VM Network",
+		Destroy:      true,
+	}
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if driverMock.FindVMCalled {
+		t.Fatalf("unexpected result: expected '%s' not to be called", "FindVM")
+	}
+	if !driverMock.PreCleanVMCalled {
+		t.Fatalf("unexpected result: expected '%s' to be called", "PreCleanVM")
+	}
+
+	expected := &driver.ImportOvfConfig{
+		Name:          step.Location.VMName,
+		Folder:        step.Location.Folder,
+		Cluster:       step.Location.Cluster,
+		Host:          step.Location.Host,
+		ResourcePool:  step.Location.ResourcePool,
+		Datastore:     step.Location.Datastore,
+		DatastorePath: "[datastore1] images/base.ova",
+		Network:       "VM Network",
+	}
+	if diff := cmp.Diff(driverMock.ImportOvfConfig, expected); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+	if vm, ok := state.GetOk("vm"); !ok || vm != driverMock.VM {
+		t.Fatalf("unexpected result: expected the imported virtual machine in the state")
+	}
+	if _, ok := state.GetOk("destroy_vm"); !ok {
+		t.Fatalf("unexpected state: '%s' not found", "destroy_vm")
+	}
+}
+
+func TestStepCreateVM_RunContentLibrarySource(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	})
+	driverMock := driver.NewDriverMock()
+	state.Put("driver", driverMock)
+	step := basicStepCloneVM()
+	step.Config = &CloneConfig{
+		ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+		Network:              "VM Network",
+		VAppConfig:           vAppConfig{Properties: map[string]string{"hostname": "packer"}},
+	}
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if driverMock.FindVMCalled {
+		t.Fatalf("unexpected result: expected '%s' not to be called", "FindVM")
+	}
+
+	expected := &driver.DeployLibraryItemConfig{
+		Library:      "Library",
+		Item:         "ubuntu-server",
+		Name:         step.Location.VMName,
+		Folder:       step.Location.Folder,
+		Cluster:      step.Location.Cluster,
+		Host:         step.Location.Host,
+		ResourcePool: step.Location.ResourcePool,
+		Datastore:    step.Location.Datastore,
+		Network:      "VM Network",
+		Properties:   map[string]string{"hostname": "packer"},
+	}
+	if diff := cmp.Diff(driverMock.DeployLibraryItemConfig, expected); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+	if vm, ok := state.GetOk("vm"); !ok || vm != driverMock.VM {
+		t.Fatalf("unexpected result: expected the deployed virtual machine in the state")
+	}
+	if _, ok := state.GetOk("destroy_vm"); ok {
+		t.Fatalf("unexpected state: '%s' found", "destroy_vm")
+	}
+}
+
+func TestStepCreateVM_RunContentLibrarySourceError(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	})
+	driverMock := driver.NewDriverMock()
+	driverMock.DeployLibraryItemErr = errors.New("content library item ubuntu-server not found")
+	state.Put("driver", driverMock)
+	step := basicStepCloneVM()
+	step.Config = &CloneConfig{
+		ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"},
+	}
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+	err, ok := state.Get("error").(error)
+	if !ok {
+		t.Fatalf("unexpected state: '%s' not found", "error")
+	}
+	expectedErr := "error deploying content library source: content library item ubuntu-server not found"
+	if err.Error() != expectedErr {
+		t.Fatalf("unexpected error: expected '%s', but returned '%s'", expectedErr, err.Error())
+	}
+	if _, ok := state.GetOk("vm"); ok {
+		t.Fatalf("unexpected state: '%s' found", "vm")
+	}
+}
+
+func TestStepCreateVM_RunNamespaceImageSource(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	})
+	driverMock := driver.NewDriverMock()
+	state.Put("driver", driverMock)
+	step := basicStepCloneVM()
+	step.Config = &CloneConfig{
+		NamespaceImageSource: &NamespaceImageSourceConfig{Namespace: "platform", Image: "vmi-0a0044d7c690bc9b8"},
+	}
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+
+	expected := &driver.DeployLibraryItemConfig{
+		Item:         "vmi-0a0044d7c690bc9b8",
+		Namespace:    "platform",
+		Name:         step.Location.VMName,
+		Folder:       step.Location.Folder,
+		Cluster:      step.Location.Cluster,
+		Host:         step.Location.Host,
+		ResourcePool: step.Location.ResourcePool,
+		Datastore:    step.Location.Datastore,
+	}
+	if diff := cmp.Diff(driverMock.DeployLibraryItemConfig, expected); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+	if vm, ok := state.GetOk("vm"); !ok || vm != driverMock.VM {
+		t.Fatalf("unexpected result: expected the deployed virtual machine in the state")
+	}
+}
+
+func TestStepCreateVM_RunAppendNotesImportedSource(t *testing.T) {
+	tc := []struct {
+		name   string
+		config *CloneConfig
+		source string
+	}{
+		{
+			name:   "Remote source",
+			config: &CloneConfig{RemoteSource: &RemoteSourceConfig{DatastorePath: "[datastore1] images/base.ova"}},
+			source: "[datastore1] images/base.ova",
+		},
+		{
+			name:   "Content library source",
+			config: &CloneConfig{ContentLibrarySource: &ContentLibrarySourceConfig{Library: "Library", Item: "ubuntu-server"}},
+			source: "Library/ubuntu-server",
+		},
+		{
+			name:   "Namespace image source",
+			config: &CloneConfig{NamespaceImageSource: &NamespaceImageSourceConfig{Namespace: "platform", Image: "vmi-0a0044d7c690bc9b8"}},
+			source: "platform/vmi-0a0044d7c690bc9b8",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := new(multistep.BasicStateBag)
+			state.Put("ui", &packersdk.BasicUi{
+				Reader: new(bytes.Buffer),
+				Writer: new(bytes.Buffer),
+			})
+			vmMock := &driver.VirtualMachineMock{NotesValue: "Source notes."}
+			driverMock := driver.NewDriverMock()
+			driverMock.VM = vmMock
+			state.Put("driver", driverMock)
+			step := basicStepCloneVM()
+			step.Config = c.config
+			step.Config.Notes = "Built for {{ .Name }}."
+			step.Config.AppendNotes = true
+
+			if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+				t.Fatalf("unexpected error: '%s'", state.Get("error"))
+			}
+
+			// The notes of the source are kept on import.
+			var annotation string
+			if driverMock.ImportOvfConfig != nil {
+				annotation = driverMock.ImportOvfConfig.Annotation
+			} else {
+				annotation = driverMock.DeployLibraryItemConfig.Annotation
+			}
+			if annotation != "" {
+				t.Fatalf("unexpected result: expected no notes on import, but returned '%s'", annotation)
+			}
+
+			expected := fmt.Sprintf("Source notes.\n\nBuilt for %s.\n\nBuilt by Packer Plugin", step.Location.VMName)
+			if !strings.HasPrefix(vmMock.NotesValue, expected) {
+				t.Fatalf("unexpected result: expected notes starting with '%s', but returned '%s'", expected, vmMock.NotesValue)
+			}
+			if !strings.HasSuffix(vmMock.NotesValue, fmt.Sprintf(" from %s.", c.source)) {
+				t.Fatalf("unexpected result: expected the source '%s' in the notes, but returned '%s'", c.source, vmMock.NotesValue)
+			}
+		})
+	}
+}
+
+func TestStepCreateVM_RunCreateSnapshotOnSource(t *testing.T) {
+	tc := []struct {
+		name             string
+		missing          []string
+		expectedSnapshot string
+		fail             bool
+	}{
+		{
+			name:             "Creates snapshot",
+			expectedSnapshot: defaultSourceSnapshotName,
+		},
+		{
+			name:    "Missing privileges",
+			missing: []string{"VirtualMachine.State.CreateSnapshot"},
+			fail:    true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := new(multistep.BasicStateBag)
+			state.Put("ui", &packersdk.BasicUi{
+				Reader: new(bytes.Buffer),
+				Writer: new(bytes.Buffer),
+			})
+			driverMock := driver.NewDriverMock()
+			state.Put("driver", driverMock)
+			vmMock := &driver.VirtualMachineMock{
+				InfoReturn:              &mo.VirtualMachine{},
+				MissingPrivilegesReturn: c.missing,
+			}
+			driverMock.VM = vmMock
+
+			step := basicStepCloneVM()
+			step.Config.LinkedClone = true
+			step.Config.CreateSnapshotOnSource = true
+			if errs := step.Config.Prepare(); len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+
+			action := step.Run(context.TODO(), state)
+			if c.fail {
+				if action != multistep.ActionHalt {
+					t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+				}
+				if vmMock.CreateSnapshotCalled {
+					t.Fatalf("unexpected result: expected '%s' not to be called", "CreateSnapshot")
+				}
+				return
+			}
+			if action != multistep.ActionContinue {
+				t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+			}
+			if vmMock.CreateSnapshotName != c.expectedSnapshot {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expectedSnapshot, vmMock.CreateSnapshotName)
+			}
+			if snapshot := state.Get("source_snapshot"); snapshot != c.expectedSnapshot {
+				t.Fatalf("unexpected result: expected '%s', but returned '%v'", c.expectedSnapshot, snapshot)
+			}
+		})
+	}
+}
+
 func basicStepCloneVM() *StepCloneVM {
 	step := &StepCloneVM{
 		Config:   createConfig(),
@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type CustomizeConfig,LinuxOptions,WindowsOptions,WindowsOptionsGuiUnattended,WindowsOptionsUserData,WindowsOptionsGuiRunOnce,WindowsOptionsIdentification,WindowsOptionsLicenseFilePrintData,NetworkInterfaces,NetworkInterface,GlobalDnsSettings,GlobalRoutingSettings
+//go:generate packer-sdc mapstructure-to-hcl2 -type CustomizeConfig,LinuxOptions,WindowsOptions,WindowsOptionsGuiUnattended,WindowsOptionsUserData,WindowsOptionsGuiRunOnce,WindowsOptionsIdentification,WindowsOptionsLicenseFilePrintData,NetworkInterfaces,NetworkInterface,GlobalDnsSettings,GlobalRoutingSettings,StaticRoute
 package clone
 
 import (
@@ -10,6 +10,9 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -94,6 +97,27 @@ type CustomizeConfig struct {
 	NetworkInterfaces     NetworkInterfaces `mapstructure:"network_interface"`
 	GlobalRoutingSettings `mapstructure:",squash"`
 	GlobalDnsSettings     `mapstructure:",squash"`
+	// A static route to add to the guest operating system in addition to the
+	// default gateway, such as a route to a network that is reachable only
+	// through another network adapter. Can be specified multiple times. Refer
+	// to the [static route](#static-route-settings) section for additional
+	// details.
+	StaticRoutes []StaticRoute `mapstructure:"static_route"`
+	// Wait for the guest customization to complete after the virtual machine
+	// is powered on. If the customization fails, the build fails with the
+	// details of the failure event and, when the communicator credentials
+	// allow it, the last lines of the guest customization log.
+	// Defaults to `false`.
+	WaitForCustomization bool `mapstructure:"wait_for_customization"`
+	// The amount of time to wait for the guest customization to complete.
+	// Defaults to `30m` (30 minutes).
+	CustomizationTimeout time.Duration `mapstructure:"customization_timeout"`
+	// The number of times to retry the guest customization after a failure
+	// that may be caused by a pending reboot or a VMware Tools issue. Before
+	// each retry, the virtual machine is powered off, the customization is
+	// applied again, and the virtual machine is powered on. Failures caused by
+	// the configuration are not retried. Defaults to `1`.
+	CustomizationRetries *int `mapstructure:"customization_retries"`
 }
 
 type LinuxOptions struct {
@@ -127,9 +151,24 @@ type WindowsOptions struct {
 	// The time zone for the guest operating system.
 	// Defaults to `85` (Pacific Time).
 	TimeZone *int32 `mapstructure:"time_zone"`
-	// The workgroup for the guest operating system.
-	// Joining an Active Directory domain is not supported.
+	// The workgroup for the guest operating system. Cannot be used with
+	// `join_domain`.
 	Workgroup string `mapstructure:"workgroup"`
+	// The Active Directory domain for the guest operating system to join.
+	// Requires `domain_admin_user` and `domain_admin_password`, and the domain
+	// controllers must be reachable from the network interfaces of the guest
+	// operating system during the customization.
+	JoinDomain string `mapstructure:"join_domain"`
+	// The user with the permission to join the guest operating system to the
+	// domain. For example, `administrator@example.com`.
+	DomainAdminUser string `mapstructure:"domain_admin_user"`
+	// The password of `domain_admin_user`.
+	DomainAdminPassword string `mapstructure:"domain_admin_password"`
+	// The organizational unit in which to create the computer account, in
+	// distinguished name format. For example,
+	// `OU=Servers,DC=example,DC=com`. Defaults to the default computer
+	// container of the domain. Requires vSphere 8.0 Update 2 or later.
+	DomainOU string `mapstructure:"domain_ou"`
 	// The hostname for the guest operating system.
 	ComputerName string `mapstructure:"computer_name"`
 	// The full name for the guest operating system's `Administrator` account.
@@ -144,12 +183,14 @@ type WindowsOptions struct {
 
 type NetworkInterface struct {
 	// The DNS servers for a specific network interface on a Windows guest
-	// operating system. Ignored on Linux. Refer to the
+	// operating system. On a Linux guest operating system, the DNS servers
+	// are added to the global `dns_server_list`. Refer to the
 	// [global DNS settings](#global-dns-settings) section for additional
 	// details.
 	DnsServerList []string `mapstructure:"dns_server_list"`
 	// The DNS search domain for a specific network interface on a Windows guest
-	// operating system. Ignored on Linux. Refer to the
+	// operating system. On a Linux guest operating system, the search domain
+	// is added to the global `dns_suffix_list`. Refer to the
 	// [global DNS settings](#global-dns-settings) section for additional
 	// details.
 	DnsDomain string `mapstructure:"dns_domain"`
@@ -165,12 +206,25 @@ type NetworkInterface struct {
 	// The IPv6 subnet mask, in bits, for the network adapter. For example, `64`
 	// for a `/64` subnet.
 	Ipv6NetMask int `mapstructure:"ipv6_netmask"`
+	// The IPv4 gateway for the network adapter. Overrides the global
+	// `ipv4_gateway` for the network adapter. Requires `ipv4_address` and
+	// must be reachable from the IPv4 address of the network adapter.
+	Ipv4Gateway string `mapstructure:"ipv4_gateway"`
+	// The IPv6 gateway for the network adapter. Overrides the global
+	// `ipv6_gateway` for the network adapter. Requires `ipv6_address` and
+	// must be reachable from the IPv6 address of the network adapter.
+	Ipv6Gateway string `mapstructure:"ipv6_gateway"`
+	// The MAC address of the network adapter to apply the settings to. If not
+	// specified, the settings are applied to the network adapters in the order
+	// of the `network_interface` blocks.
+	MacAddress string `mapstructure:"mac_address"`
 }
 
 type NetworkInterfaces []NetworkInterface
 
 // The settings must match the IP address and subnet mask of at least one
-// `network_interface` for the customization.
+// `network_interface` for the customization. The gateway is applied to the
+// first matching `network_interface` that does not set its own gateway.
 type GlobalRoutingSettings struct {
 	// The IPv4 default gateway when using `network_interface` customization.
 	Ipv4Gateway string `mapstructure:"ipv4_gateway"`
@@ -178,18 +232,57 @@ type GlobalRoutingSettings struct {
 	Ipv6Gateway string `mapstructure:"ipv6_gateway"`
 }
 
-// The following settings configure DNS globally for Linux guest operating
-// systems. For Windows guest operating systems, this is set for each network
-// interface. Refer to the [network interface](#network_interface) section for
-// additional details.
+// The following settings configure DNS globally for the guest operating
+// system. Linux guest operating systems do not support DNS settings for each
+// network interface, so the `dns_server_list` and `dns_domain` of each
+// `network_interface` are added to the global settings. For Windows guest
+// operating systems, DNS servers are set for each network interface, and the
+// global `dns_server_list` is used for each `network_interface` with an
+// `ipv4_address` that does not set its own `dns_server_list`. Refer to the
+// [network interface](#network_interface) section for additional details.
 type GlobalDnsSettings struct {
 	// A list of DNS servers to configure on the guest operating system.
 	DnsServerList []string `mapstructure:"dns_server_list"`
 	// A list of DNS search domains to add to the DNS configuration on the guest
-	// operating system.
+	// operating system, in order of preference. On a Windows guest operating
+	// system, the list sets the DNS suffix search order for all network
+	// interfaces.
 	DnsSuffixList []string `mapstructure:"dns_suffix_list"`
 }
 
+// Static routes are not supported by the vSphere guest customization
+// specification and are added by the customization of the guest operating
+// system instead. On Windows guest operating systems, the routes are added as
+// persistent routes with `route -p add` before the `run_once_command_list`
+// commands, which requires `auto_logon`. On Linux guest operating systems, the
+// routes are added with `ip route` by a systemd unit that is installed by a
+// customization script, which requires custom scripts to be enabled in VMware
+// Tools with `vmware-toolbox-cmd config set deployPkg enable-custom-scripts
+// true`. Static routes cannot be used with `windows_sysprep_file` or
+// `windows_sysprep_text`.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	customize {
+//	  static_route {
+//	    destination = "10.20.0.0/16"
+//	    gateway     = "192.168.1.1"
+//	  }
+//	}
+//
+// ```
+type StaticRoute struct {
+	// The destination network of the route in CIDR notation. For example,
+	// `10.20.0.0/16`.
+	Destination string `mapstructure:"destination"`
+	// The IP address of the next hop. Must be of the same address family as
+	// `destination` and reachable from a network interface of the guest
+	// operating system.
+	Gateway string `mapstructure:"gateway"`
+}
+
 type StepCustomize struct {
 	Config *CustomizeConfig
 }
@@ -223,6 +316,35 @@ func (c *CustomizeConfig) Prepare() ([]string, []error) {
 		errs = append(errs, fmt.Errorf("one of `linux_options`, `windows_options`, `windows_sysprep_file`, or 'windows_sysprep_text' must be set"))
 	}
 
+	if c.CustomizationTimeout < 0 {
+		errs = append(errs, fmt.Errorf("`customization_timeout` must be greater than or equal to 0"))
+	}
+	if c.CustomizationTimeout == 0 {
+		c.CustomizationTimeout = 30 * time.Minute
+	}
+	if c.CustomizationRetries == nil {
+		retries := 1
+		c.CustomizationRetries = &retries
+	} else if *c.CustomizationRetries < 0 {
+		errs = append(errs, fmt.Errorf("`customization_retries` must be greater than or equal to 0"))
+	}
+
+	for i, nic := range c.NetworkInterfaces {
+		errs = nic.prepare(i, errs)
+	}
+
+	for i := range c.StaticRoutes {
+		errs = c.StaticRoutes[i].prepare(i, errs)
+	}
+	if len(c.StaticRoutes) > 0 {
+		if c.WindowsSysPrepFile != "" || c.WindowsSysPrepText != "" {
+			errs = append(errs, fmt.Errorf("`static_route` cannot be used with `windows_sysprep_file` or `windows_sysprep_text`"))
+		}
+		if c.WindowsOptions != nil && !boolValue(c.WindowsOptions.AutoLogon, false) {
+			errs = append(errs, fmt.Errorf("`static_route` requires `auto_logon` to be set to `true` in `windows_options`"))
+		}
+	}
+
 	if c.LinuxOptions != nil {
 		errs = c.LinuxOptions.prepare(errs)
 	}
@@ -233,24 +355,55 @@ func (c *CustomizeConfig) Prepare() ([]string, []error) {
 	return warnings, errs
 }
 
+func (n *NetworkInterface) prepare(i int, errs []error) []error {
+	if n.Ipv4Gateway != "" {
+		if n.Ipv4Address == "" {
+			errs = append(errs, fmt.Errorf("`network_interface[%d].ipv4_gateway` requires `ipv4_address`", i))
+		} else if !matchGateway(n.Ipv4Address, n.Ipv4NetMask, n.Ipv4Gateway) {
+			errs = append(errs, fmt.Errorf("`network_interface[%d].ipv4_gateway` %s is not reachable from %s/%d", i, n.Ipv4Gateway, n.Ipv4Address, n.Ipv4NetMask))
+		}
+	}
+	if n.Ipv6Gateway != "" {
+		if n.Ipv6Address == "" {
+			errs = append(errs, fmt.Errorf("`network_interface[%d].ipv6_gateway` requires `ipv6_address`", i))
+		} else if !matchGateway(n.Ipv6Address, n.Ipv6NetMask, n.Ipv6Gateway) {
+			errs = append(errs, fmt.Errorf("`network_interface[%d].ipv6_gateway` %s is not reachable from %s/%d", i, n.Ipv6Gateway, n.Ipv6Address, n.Ipv6NetMask))
+		}
+	}
+	if n.MacAddress != "" {
+		if _, err := net.ParseMAC(n.MacAddress); err != nil {
+			errs = append(errs, fmt.Errorf("`network_interface[%d].mac_address` is not a valid MAC address: %s", i, err))
+		}
+	}
+	return errs
+}
+
+func (r *StaticRoute) prepare(i int, errs []error) []error {
+	_, destination, err := net.ParseCIDR(r.Destination)
+	if err != nil {
+		return append(errs, fmt.Errorf("`static_route[%d].destination` is not a valid network in CIDR notation: %s", i, r.Destination))
+	}
+	gateway := net.ParseIP(r.Gateway)
+	if gateway == nil {
+		return append(errs, fmt.Errorf("`static_route[%d].gateway` is not a valid IP address: %s", i, r.Gateway))
+	}
+	if (destination.IP.To4() == nil) != (gateway.To4() == nil) {
+		return append(errs, fmt.Errorf("`static_route[%d].gateway` %s is not of the same address family as %s", i, r.Gateway, r.Destination))
+	}
+	r.Destination = destination.String()
+	return errs
+}
+
 func (s *StepCustomize) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
 	ui := state.Get("ui").(packersdk.Ui)
 
-	identity, err := s.identitySettings()
+	spec, err := s.spec()
 	if err != nil {
 		state.Put("error", err)
 		return multistep.ActionHalt
 	}
 
-	nicSettingsMap := s.nicSettingsMap()
-	globalIpSettings := s.globalIpSettings()
-
-	spec := types.CustomizationSpec{
-		Identity:         identity,
-		NicSettingMap:    nicSettingsMap,
-		GlobalIPSettings: globalIpSettings,
-	}
 	ui.Say("Customizing VM...")
 	err = vm.Customize(spec)
 	if err != nil {
@@ -261,13 +414,26 @@ func (s *StepCustomize) Run(ctx context.Context, state multistep.StateBag) multi
 	return multistep.ActionContinue
 }
 
+func (s *StepCustomize) spec() (types.CustomizationSpec, error) {
+	identity, err := s.identitySettings()
+	if err != nil {
+		return types.CustomizationSpec{}, err
+	}
+
+	return types.CustomizationSpec{
+		Identity:         identity,
+		NicSettingMap:    s.nicSettingsMap(),
+		GlobalIPSettings: s.globalIpSettings(),
+	}, nil
+}
+
 func (s *StepCustomize) identitySettings() (types.BaseCustomizationIdentitySettings, error) {
 	if s.Config.LinuxOptions != nil {
-		return s.Config.LinuxOptions.linuxPrep(), nil
+		return s.Config.LinuxOptions.linuxPrep(s.Config.StaticRoutes), nil
 	}
 
 	if s.Config.WindowsOptions != nil {
-		return s.Config.WindowsOptions.sysprep(), nil
+		return s.Config.WindowsOptions.sysprep(s.Config.StaticRoutes), nil
 	}
 
 	if s.Config.WindowsSysPrepFile != "" {
@@ -296,7 +462,8 @@ func (s *StepCustomize) nicSettingsMap() []types.CustomizationAdapterMapping {
 		var adapter types.CustomizationIPSettings
 		adapter, ipv4gwFound, ipv6gwFound = s.ipSettings(i, !ipv4gwFound, !ipv6gwFound)
 		obj := types.CustomizationAdapterMapping{
-			Adapter: adapter,
+			MacAddress: strings.ToLower(s.Config.NetworkInterfaces[i].MacAddress),
+			Adapter:    adapter,
 		}
 		result[i] = obj
 	}
@@ -316,7 +483,9 @@ func (s *StepCustomize) ipSettings(n int, ipv4gwAdd bool, ipv6gwAdd bool) (types
 		}
 		obj.SubnetMask = v4CIDRMaskToDotted(ipv4mask)
 		// Check for the gateway
-		if ipv4gwAdd && ipv4Gateway != "" && matchGateway(ipv4Address, ipv4mask, ipv4Gateway) {
+		if gw := s.Config.NetworkInterfaces[n].Ipv4Gateway; gw != "" {
+			obj.Gateway = []string{gw}
+		} else if ipv4gwAdd && ipv4Gateway != "" && matchGateway(ipv4Address, ipv4mask, ipv4Gateway) {
 			obj.Gateway = []string{ipv4Gateway}
 			v4gwFound = true
 		}
@@ -325,6 +494,9 @@ func (s *StepCustomize) ipSettings(n int, ipv4gwAdd bool, ipv6gwAdd bool) (types
 	}
 
 	obj.DnsServerList = s.Config.NetworkInterfaces[n].DnsServerList
+	if len(obj.DnsServerList) == 0 && ipv4Address != "" && s.Config.LinuxOptions == nil {
+		obj.DnsServerList = s.Config.DnsServerList
+	}
 	obj.DnsDomain = s.Config.NetworkInterfaces[n].DnsDomain
 	obj.IpV6Spec, v6gwFound = s.IPSettingsIPV6Address(n, ipv6gwAdd)
 
@@ -356,7 +528,9 @@ func (s *StepCustomize) IPSettingsIPV6Address(n int, gwAdd bool) (*types.Customi
 			},
 		},
 	}
-	if gwAdd && gw != "" && matchGateway(addr, mask, gw) {
+	if nicGw := s.Config.NetworkInterfaces[n].Ipv6Gateway; nicGw != "" {
+		obj.Gateway = []string{nicGw}
+	} else if gwAdd && gw != "" && matchGateway(addr, mask, gw) {
 		obj.Gateway = []string{gw}
 		gwFound = true
 	}
@@ -381,10 +555,25 @@ func matchGateway(a string, m int, g string) bool {
 }
 
 func (s *StepCustomize) globalIpSettings() types.CustomizationGlobalIPSettings {
-	return types.CustomizationGlobalIPSettings{
-		DnsServerList: s.Config.DnsServerList,
-		DnsSuffixList: s.Config.DnsSuffixList,
+	obj := types.CustomizationGlobalIPSettings{
+		DnsServerList: slices.Clone(s.Config.DnsServerList),
+		DnsSuffixList: slices.Clone(s.Config.DnsSuffixList),
+	}
+	// The Linux guest customization ignores the DNS settings of the network
+	// adapters.
+	if s.Config.LinuxOptions != nil {
+		for _, nic := range s.Config.NetworkInterfaces {
+			for _, server := range nic.DnsServerList {
+				if !slices.Contains(obj.DnsServerList, server) {
+					obj.DnsServerList = append(obj.DnsServerList, server)
+				}
+			}
+			if nic.DnsDomain != "" && !slices.Contains(obj.DnsSuffixList, nic.DnsDomain) {
+				obj.DnsSuffixList = append(obj.DnsSuffixList, nic.DnsDomain)
+			}
+		}
 	}
+	return obj
 }
 
 func (l *LinuxOptions) prepare(errs []error) []error {
@@ -404,7 +593,7 @@ func (l *LinuxOptions) prepare(errs []error) []error {
 	return errs
 }
 
-func (l *LinuxOptions) linuxPrep() *types.CustomizationLinuxPrep {
+func (l *LinuxOptions) linuxPrep(routes []StaticRoute) *types.CustomizationLinuxPrep {
 	obj := &types.CustomizationLinuxPrep{
 		HostName: &types.CustomizationFixedName{
 			Name: l.Hostname,
@@ -412,14 +601,56 @@ func (l *LinuxOptions) linuxPrep() *types.CustomizationLinuxPrep {
 		Domain:     l.Domain,
 		TimeZone:   l.Timezone,
 		HwClockUTC: l.HWClockUTC.ToBoolPointer(),
+		ScriptText: staticRoutesScript(routes),
 	}
 	return obj
 }
 
+// staticRoutesScript returns a customization script that installs a systemd
+// unit to add the static routes, so that the routes persist after the guest
+// operating system is restarted.
+func staticRoutesScript(routes []StaticRoute) string {
+	if len(routes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("if [ \"$1\" = \"postcustomization\" ]; then\n")
+	b.WriteString("cat > /etc/systemd/system/packer-static-routes.service <<'EOF'\n")
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Static routes of the guest customization\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	b.WriteString("RemainAfterExit=yes\n")
+	for _, r := range routes {
+		fmt.Fprintf(&b, "ExecStart=/bin/sh -c 'ip route replace %s via %s'\n", r.Destination, r.Gateway)
+	}
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	b.WriteString("EOF\n")
+	b.WriteString("systemctl enable packer-static-routes.service\n")
+	b.WriteString("systemctl start --no-block packer-static-routes.service\n")
+	b.WriteString("fi\n")
+	return b.String()
+}
+
 func (w *WindowsOptions) prepare(errs []error) []error {
 	if w.ComputerName == "" {
 		errs = append(errs, fmt.Errorf("windows options: `computer_name` is required"))
 	}
+	if w.JoinDomain != "" {
+		if w.Workgroup != "" {
+			errs = append(errs, fmt.Errorf("windows options: `workgroup` cannot be used with `join_domain`"))
+		}
+		if w.DomainAdminUser == "" || w.DomainAdminPassword == "" {
+			errs = append(errs, fmt.Errorf("windows options: `join_domain` requires `domain_admin_user` and `domain_admin_password`"))
+		}
+	} else if w.DomainAdminUser != "" || w.DomainAdminPassword != "" || w.DomainOU != "" {
+		errs = append(errs, fmt.Errorf("windows options: `domain_admin_user`, `domain_admin_password`, and `domain_ou` require `join_domain`"))
+	}
 	if w.FullName == "" {
 		w.FullName = "Administrator"
 	}
@@ -429,25 +660,36 @@ func (w *WindowsOptions) prepare(errs []error) []error {
 	return errs
 }
 
-func (w *WindowsOptions) sysprep() *types.CustomizationSysprep {
+func (w *WindowsOptions) sysprep(routes []StaticRoute) *types.CustomizationSysprep {
 	obj := &types.CustomizationSysprep{
 		GuiUnattended:  w.guiUnattended(),
 		UserData:       w.userData(),
-		GuiRunOnce:     w.guiRunOnce(),
+		GuiRunOnce:     w.guiRunOnce(routes),
 		Identification: w.identification(),
 	}
 	return obj
 }
 
-func (w *WindowsOptions) guiRunOnce() *types.CustomizationGuiRunOnce {
-	if len(w.RunOnceCommandList) == 0 {
+func (w *WindowsOptions) guiRunOnce(routes []StaticRoute) *types.CustomizationGuiRunOnce {
+	var commands []string
+	for _, r := range routes {
+		_, destination, _ := net.ParseCIDR(r.Destination)
+		if destination.IP.To4() != nil {
+			commands = append(commands, fmt.Sprintf("route -p add %s mask %s %s", destination.IP, net.IP(destination.Mask), r.Gateway))
+		} else {
+			commands = append(commands, fmt.Sprintf("route -p add %s %s", destination, r.Gateway))
+		}
+	}
+	commands = append(commands, w.RunOnceCommandList...)
+
+	if len(commands) == 0 {
 		return &types.CustomizationGuiRunOnce{
 			CommandList: []string{""},
 		}
 	}
 
 	return &types.CustomizationGuiRunOnce{
-		CommandList: w.RunOnceCommandList,
+		CommandList: commands,
 	}
 }
 
@@ -484,6 +726,15 @@ func (w *WindowsOptions) identification() types.CustomizationIdentification {
 	obj := types.CustomizationIdentification{
 		JoinWorkgroup: w.Workgroup,
 	}
+	if w.JoinDomain != "" {
+		obj.JoinDomain = w.JoinDomain
+		obj.DomainAdmin = w.DomainAdminUser
+		obj.DomainAdminPassword = &types.CustomizationPassword{
+			Value:     w.DomainAdminPassword,
+			PlainText: true,
+		}
+		obj.DomainOU = w.DomainOU
+	}
 	return obj
 }
 
@@ -10,15 +10,19 @@ import (
 // FlatCustomizeConfig is an auto-generated flat version of CustomizeConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatCustomizeConfig struct {
-	LinuxOptions       *FlatLinuxOptions      `mapstructure:"linux_options" cty:"linux_options" hcl:"linux_options"`
-	WindowsOptions     *FlatWindowsOptions    `mapstructure:"windows_options" cty:"windows_options" hcl:"windows_options"`
-	WindowsSysPrepFile *string                `mapstructure:"windows_sysprep_file" cty:"windows_sysprep_file" hcl:"windows_sysprep_file"`
-	WindowsSysPrepText *string                `mapstructure:"windows_sysprep_text" cty:"windows_sysprep_text" hcl:"windows_sysprep_text"`
-	NetworkInterfaces  []FlatNetworkInterface `mapstructure:"network_interface" cty:"network_interface" hcl:"network_interface"`
-	Ipv4Gateway        *string                `mapstructure:"ipv4_gateway" cty:"ipv4_gateway" hcl:"ipv4_gateway"`
-	Ipv6Gateway        *string                `mapstructure:"ipv6_gateway" cty:"ipv6_gateway" hcl:"ipv6_gateway"`
-	DnsServerList      []string               `mapstructure:"dns_server_list" cty:"dns_server_list" hcl:"dns_server_list"`
-	DnsSuffixList      []string               `mapstructure:"dns_suffix_list" cty:"dns_suffix_list" hcl:"dns_suffix_list"`
+	LinuxOptions         *FlatLinuxOptions      `mapstructure:"linux_options" cty:"linux_options" hcl:"linux_options"`
+	WindowsOptions       *FlatWindowsOptions    `mapstructure:"windows_options" cty:"windows_options" hcl:"windows_options"`
+	WindowsSysPrepFile   *string                `mapstructure:"windows_sysprep_file" cty:"windows_sysprep_file" hcl:"windows_sysprep_file"`
+	WindowsSysPrepText   *string                `mapstructure:"windows_sysprep_text" cty:"windows_sysprep_text" hcl:"windows_sysprep_text"`
+	NetworkInterfaces    []FlatNetworkInterface `mapstructure:"network_interface" cty:"network_interface" hcl:"network_interface"`
+	Ipv4Gateway          *string                `mapstructure:"ipv4_gateway" cty:"ipv4_gateway" hcl:"ipv4_gateway"`
+	Ipv6Gateway          *string                `mapstructure:"ipv6_gateway" cty:"ipv6_gateway" hcl:"ipv6_gateway"`
+	DnsServerList        []string               `mapstructure:"dns_server_list" cty:"dns_server_list" hcl:"dns_server_list"`
+	DnsSuffixList        []string               `mapstructure:"dns_suffix_list" cty:"dns_suffix_list" hcl:"dns_suffix_list"`
+	StaticRoutes         []FlatStaticRoute      `mapstructure:"static_route" cty:"static_route" hcl:"static_route"`
+	WaitForCustomization *bool                  `mapstructure:"wait_for_customization" cty:"wait_for_customization" hcl:"wait_for_customization"`
+	CustomizationTimeout *string                `mapstructure:"customization_timeout" cty:"customization_timeout" hcl:"customization_timeout"`
+	CustomizationRetries *int                   `mapstructure:"customization_retries" cty:"customization_retries" hcl:"customization_retries"`
 }
 
 // FlatMapstructure returns a new FlatCustomizeConfig.
@@ -33,15 +37,19 @@ func (*CustomizeConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcld
 // The decoded values from this spec will then be applied to a FlatCustomizeConfig.
 func (*FlatCustomizeConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"linux_options":        &hcldec.BlockSpec{TypeName: "linux_options", Nested: hcldec.ObjectSpec((*FlatLinuxOptions)(nil).HCL2Spec())},
-		"windows_options":      &hcldec.BlockSpec{TypeName: "windows_options", Nested: hcldec.ObjectSpec((*FlatWindowsOptions)(nil).HCL2Spec())},
-		"windows_sysprep_file": &hcldec.AttrSpec{Name: "windows_sysprep_file", Type: cty.String, Required: false},
-		"windows_sysprep_text": &hcldec.AttrSpec{Name: "windows_sysprep_text", Type: cty.String, Required: false},
-		"network_interface":    &hcldec.BlockListSpec{TypeName: "network_interface", Nested: hcldec.ObjectSpec((*FlatNetworkInterface)(nil).HCL2Spec())},
-		"ipv4_gateway":         &hcldec.AttrSpec{Name: "ipv4_gateway", Type: cty.String, Required: false},
-		"ipv6_gateway":         &hcldec.AttrSpec{Name: "ipv6_gateway", Type: cty.String, Required: false},
-		"dns_server_list":      &hcldec.AttrSpec{Name: "dns_server_list", Type: cty.List(cty.String), Required: false},
-		"dns_suffix_list":      &hcldec.AttrSpec{Name: "dns_suffix_list", Type: cty.List(cty.String), Required: false},
+		"linux_options":          &hcldec.BlockSpec{TypeName: "linux_options", Nested: hcldec.ObjectSpec((*FlatLinuxOptions)(nil).HCL2Spec())},
+		"windows_options":        &hcldec.BlockSpec{TypeName: "windows_options", Nested: hcldec.ObjectSpec((*FlatWindowsOptions)(nil).HCL2Spec())},
+		"windows_sysprep_file":   &hcldec.AttrSpec{Name: "windows_sysprep_file", Type: cty.String, Required: false},
+		"windows_sysprep_text":   &hcldec.AttrSpec{Name: "windows_sysprep_text", Type: cty.String, Required: false},
+		"network_interface":      &hcldec.BlockListSpec{TypeName: "network_interface", Nested: hcldec.ObjectSpec((*FlatNetworkInterface)(nil).HCL2Spec())},
+		"ipv4_gateway":           &hcldec.AttrSpec{Name: "ipv4_gateway", Type: cty.String, Required: false},
+		"ipv6_gateway":           &hcldec.AttrSpec{Name: "ipv6_gateway", Type: cty.String, Required: false},
+		"dns_server_list":        &hcldec.AttrSpec{Name: "dns_server_list", Type: cty.List(cty.String), Required: false},
+		"dns_suffix_list":        &hcldec.AttrSpec{Name: "dns_suffix_list", Type: cty.List(cty.String), Required: false},
+		"static_route":           &hcldec.BlockListSpec{TypeName: "static_route", Nested: hcldec.ObjectSpec((*FlatStaticRoute)(nil).HCL2Spec())},
+		"wait_for_customization": &hcldec.AttrSpec{Name: "wait_for_customization", Type: cty.Bool, Required: false},
+		"customization_timeout":  &hcldec.AttrSpec{Name: "customization_timeout", Type: cty.String, Required: false},
+		"customization_retries":  &hcldec.AttrSpec{Name: "customization_retries", Type: cty.Number, Required: false},
 	}
 	return s
 }
@@ -134,6 +142,9 @@ type FlatNetworkInterface struct {
 	Ipv4NetMask   *int     `mapstructure:"ipv4_netmask" cty:"ipv4_netmask" hcl:"ipv4_netmask"`
 	Ipv6Address   *string  `mapstructure:"ipv6_address" cty:"ipv6_address" hcl:"ipv6_address"`
 	Ipv6NetMask   *int     `mapstructure:"ipv6_netmask" cty:"ipv6_netmask" hcl:"ipv6_netmask"`
+	Ipv4Gateway   *string  `mapstructure:"ipv4_gateway" cty:"ipv4_gateway" hcl:"ipv4_gateway"`
+	Ipv6Gateway   *string  `mapstructure:"ipv6_gateway" cty:"ipv6_gateway" hcl:"ipv6_gateway"`
+	MacAddress    *string  `mapstructure:"mac_address" cty:"mac_address" hcl:"mac_address"`
 }
 
 // FlatMapstructure returns a new FlatNetworkInterface.
@@ -154,6 +165,34 @@ func (*FlatNetworkInterface) HCL2Spec() map[string]hcldec.Spec {
 		"ipv4_netmask":    &hcldec.AttrSpec{Name: "ipv4_netmask", Type: cty.Number, Required: false},
 		"ipv6_address":    &hcldec.AttrSpec{Name: "ipv6_address", Type: cty.String, Required: false},
 		"ipv6_netmask":    &hcldec.AttrSpec{Name: "ipv6_netmask", Type: cty.Number, Required: false},
+		"ipv4_gateway":    &hcldec.AttrSpec{Name: "ipv4_gateway", Type: cty.String, Required: false},
+		"ipv6_gateway":    &hcldec.AttrSpec{Name: "ipv6_gateway", Type: cty.String, Required: false},
+		"mac_address":     &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatStaticRoute is an auto-generated flat version of StaticRoute.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatStaticRoute struct {
+	Destination *string `mapstructure:"destination" cty:"destination" hcl:"destination"`
+	Gateway     *string `mapstructure:"gateway" cty:"gateway" hcl:"gateway"`
+}
+
+// FlatMapstructure returns a new FlatStaticRoute.
+// FlatStaticRoute is an auto-generated flat version of StaticRoute.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*StaticRoute) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatStaticRoute)
+}
+
+// HCL2Spec returns the hcl spec of a StaticRoute.
+// This spec is used by HCL to read the fields of StaticRoute.
+// The decoded values from this spec will then be applied to a FlatStaticRoute.
+func (*FlatStaticRoute) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"destination": &hcldec.AttrSpec{Name: "destination", Type: cty.String, Required: false},
+		"gateway":     &hcldec.AttrSpec{Name: "gateway", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -161,16 +200,20 @@ func (*FlatNetworkInterface) HCL2Spec() map[string]hcldec.Spec {
 // FlatWindowsOptions is an auto-generated flat version of WindowsOptions.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatWindowsOptions struct {
-	RunOnceCommandList []string `mapstructure:"run_once_command_list" cty:"run_once_command_list" hcl:"run_once_command_list"`
-	AutoLogon          *bool    `mapstructure:"auto_logon" cty:"auto_logon" hcl:"auto_logon"`
-	AutoLogonCount     *int32   `mapstructure:"auto_logon_count" cty:"auto_logon_count" hcl:"auto_logon_count"`
-	AdminPassword      *string  `mapstructure:"admin_password" cty:"admin_password" hcl:"admin_password"`
-	TimeZone           *int32   `mapstructure:"time_zone" cty:"time_zone" hcl:"time_zone"`
-	Workgroup          *string  `mapstructure:"workgroup" cty:"workgroup" hcl:"workgroup"`
-	ComputerName       *string  `mapstructure:"computer_name" cty:"computer_name" hcl:"computer_name"`
-	FullName           *string  `mapstructure:"full_name" cty:"full_name" hcl:"full_name"`
-	OrganizationName   *string  `mapstructure:"organization_name" cty:"organization_name" hcl:"organization_name"`
-	ProductKey         *string  `mapstructure:"product_key" cty:"product_key" hcl:"product_key"`
+	RunOnceCommandList  []string `mapstructure:"run_once_command_list" cty:"run_once_command_list" hcl:"run_once_command_list"`
+	AutoLogon           *bool    `mapstructure:"auto_logon" cty:"auto_logon" hcl:"auto_logon"`
+	AutoLogonCount      *int32   `mapstructure:"auto_logon_count" cty:"auto_logon_count" hcl:"auto_logon_count"`
+	AdminPassword       *string  `mapstructure:"admin_password" cty:"admin_password" hcl:"admin_password"`
+	TimeZone            *int32   `mapstructure:"time_zone" cty:"time_zone" hcl:"time_zone"`
+	Workgroup           *string  `mapstructure:"workgroup" cty:"workgroup" hcl:"workgroup"`
+	JoinDomain          *string  `mapstructure:"join_domain" cty:"join_domain" hcl:"join_domain"`
+	DomainAdminUser     *string  `mapstructure:"domain_admin_user" cty:"domain_admin_user" hcl:"domain_admin_user"`
+	DomainAdminPassword *string  `mapstructure:"domain_admin_password" cty:"domain_admin_password" hcl:"domain_admin_password"`
+	DomainOU            *string  `mapstructure:"domain_ou" cty:"domain_ou" hcl:"domain_ou"`
+	ComputerName        *string  `mapstructure:"computer_name" cty:"computer_name" hcl:"computer_name"`
+	FullName            *string  `mapstructure:"full_name" cty:"full_name" hcl:"full_name"`
+	OrganizationName    *string  `mapstructure:"organization_name" cty:"organization_name" hcl:"organization_name"`
+	ProductKey          *string  `mapstructure:"product_key" cty:"product_key" hcl:"product_key"`
 }
 
 // FlatMapstructure returns a new FlatWindowsOptions.
@@ -191,6 +234,10 @@ func (*FlatWindowsOptions) HCL2Spec() map[string]hcldec.Spec {
 		"admin_password":        &hcldec.AttrSpec{Name: "admin_password", Type: cty.String, Required: false},
 		"time_zone":             &hcldec.AttrSpec{Name: "time_zone", Type: cty.Number, Required: false},
 		"workgroup":             &hcldec.AttrSpec{Name: "workgroup", Type: cty.String, Required: false},
+		"join_domain":           &hcldec.AttrSpec{Name: "join_domain", Type: cty.String, Required: false},
+		"domain_admin_user":     &hcldec.AttrSpec{Name: "domain_admin_user", Type: cty.String, Required: false},
+		"domain_admin_password": &hcldec.AttrSpec{Name: "domain_admin_password", Type: cty.String, Required: false},
+		"domain_ou":             &hcldec.AttrSpec{Name: "domain_ou", Type: cty.String, Required: false},
 		"computer_name":         &hcldec.AttrSpec{Name: "computer_name", Type: cty.String, Required: false},
 		"full_name":             &hcldec.AttrSpec{Name: "full_name", Type: cty.String, Required: false},
 		"organization_name":     &hcldec.AttrSpec{Name: "organization_name", Type: cty.String, Required: false},
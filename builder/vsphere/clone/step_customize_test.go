@@ -4,6 +4,8 @@
 package clone
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/vmware/govmomi/vim25/types"
@@ -105,3 +107,307 @@ func TestWindowsSysprepTextSetsContent(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s', but returned '%s'", text, sysprepText.Value)
 	}
 }
+
+// TestNetworkInterfaceGatewaySettings validates that the gateway of a network
+// interface overrides the global gateway and that the MAC address is mapped.
+func TestNetworkInterfaceGatewaySettings(t *testing.T) {
+	config := &CustomizeConfig{
+		LinuxOptions: &LinuxOptions{Hostname: "packer", Domain: "example.com"},
+		NetworkInterfaces: []NetworkInterface{
+			{
+				Ipv4Address: "10.0.0.10",
+				Ipv4NetMask: 24,
+			},
+			{
+				Ipv4Address: "192.168.1.10",
+				Ipv4NetMask: 24,
+				Ipv4Gateway: "192.168.1.1",
+				MacAddress:  "00:50:56:AA:BB:CC",
+			},
+		},
+		GlobalRoutingSettings: GlobalRoutingSettings{
+			Ipv4Gateway: "10.0.0.1",
+		},
+	}
+	if _, errs := config.Prepare(); len(errs) > 0 {
+		t.Fatalf("unexpected error: %s", errs)
+	}
+
+	nics := (&StepCustomize{Config: config}).nicSettingsMap()
+	if len(nics) != 2 {
+		t.Fatalf("unexpected result: expected '2', but returned '%d'", len(nics))
+	}
+	if gw := nics[0].Adapter.Gateway; len(gw) != 1 || gw[0] != "10.0.0.1" {
+		t.Fatalf("unexpected result: expected '[10.0.0.1]', but returned '%v'", gw)
+	}
+	if gw := nics[1].Adapter.Gateway; len(gw) != 1 || gw[0] != "192.168.1.1" {
+		t.Fatalf("unexpected result: expected '[192.168.1.1]', but returned '%v'", gw)
+	}
+	if nics[1].MacAddress != "00:50:56:aa:bb:cc" {
+		t.Fatalf("unexpected result: expected '00:50:56:aa:bb:cc', but returned '%s'", nics[1].MacAddress)
+	}
+}
+
+// TestNetworkInterfacePrepare validates the settings of a network interface.
+func TestNetworkInterfacePrepare(t *testing.T) {
+	tc := []struct {
+		name        string
+		nic         NetworkInterface
+		expectedErr string
+	}{
+		{
+			name:        "Gateway without address",
+			nic:         NetworkInterface{Ipv4Gateway: "10.0.0.1"},
+			expectedErr: "`network_interface[0].ipv4_gateway` requires `ipv4_address`",
+		},
+		{
+			name:        "Unreachable gateway",
+			nic:         NetworkInterface{Ipv4Address: "10.0.0.10", Ipv4NetMask: 24, Ipv4Gateway: "10.0.1.1"},
+			expectedErr: "`network_interface[0].ipv4_gateway` 10.0.1.1 is not reachable from 10.0.0.10/24",
+		},
+		{
+			name:        "IPv6 gateway without address",
+			nic:         NetworkInterface{Ipv6Gateway: "fd00::1"},
+			expectedErr: "`network_interface[0].ipv6_gateway` requires `ipv6_address`",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.nic.prepare(0, nil)
+			if len(errs) != 1 {
+				t.Fatalf("unexpected result: expected '1' error, but returned '%d'", len(errs))
+			}
+			if errs[0].Error() != c.expectedErr {
+				t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErr, errs[0])
+			}
+		})
+	}
+
+	if errs := (&NetworkInterface{MacAddress: "invalid"}).prepare(0, nil); len(errs) != 1 {
+		t.Fatalf("unexpected result: expected an error for an invalid MAC address")
+	}
+}
+
+// TestCustomizeDnsSettings validates that the DNS settings of the network
+// interfaces are added to the global settings for Linux and that the global
+// DNS servers are used for the network interfaces on Windows.
+func TestCustomizeDnsSettings(t *testing.T) {
+	nics := []NetworkInterface{
+		{
+			Ipv4Address:   "10.0.0.10",
+			Ipv4NetMask:   24,
+			DnsServerList: []string{"10.0.0.2", "10.0.0.3"},
+			DnsDomain:     "a.example.com",
+		},
+		{
+			Ipv4Address: "192.168.1.10",
+			Ipv4NetMask: 24,
+		},
+		{},
+	}
+	dns := GlobalDnsSettings{
+		DnsServerList: []string{"10.0.0.2"},
+		DnsSuffixList: []string{"example.com"},
+	}
+
+	linux := &StepCustomize{Config: &CustomizeConfig{
+		LinuxOptions:      &LinuxOptions{Hostname: "packer", Domain: "example.com"},
+		NetworkInterfaces: nics,
+		GlobalDnsSettings: dns,
+	}}
+	global := linux.globalIpSettings()
+	if !reflect.DeepEqual(global.DnsServerList, []string{"10.0.0.2", "10.0.0.3"}) {
+		t.Fatalf("unexpected result: expected '[10.0.0.2 10.0.0.3]', but returned '%v'", global.DnsServerList)
+	}
+	if !reflect.DeepEqual(global.DnsSuffixList, []string{"example.com", "a.example.com"}) {
+		t.Fatalf("unexpected result: expected '[example.com a.example.com]', but returned '%v'", global.DnsSuffixList)
+	}
+	if len(dns.DnsServerList) != 1 {
+		t.Fatalf("unexpected result: the global DNS servers of the configuration were modified")
+	}
+
+	windows := &StepCustomize{Config: &CustomizeConfig{
+		WindowsOptions:    &WindowsOptions{ComputerName: "packer"},
+		NetworkInterfaces: nics,
+		GlobalDnsSettings: dns,
+	}}
+	mapping := windows.nicSettingsMap()
+	if !reflect.DeepEqual(mapping[0].Adapter.DnsServerList, []string{"10.0.0.2", "10.0.0.3"}) {
+		t.Fatalf("unexpected result: expected '[10.0.0.2 10.0.0.3]', but returned '%v'", mapping[0].Adapter.DnsServerList)
+	}
+	if !reflect.DeepEqual(mapping[1].Adapter.DnsServerList, []string{"10.0.0.2"}) {
+		t.Fatalf("unexpected result: expected '[10.0.0.2]', but returned '%v'", mapping[1].Adapter.DnsServerList)
+	}
+	if mapping[2].Adapter.DnsServerList != nil {
+		t.Fatalf("unexpected result: expected no DNS servers for DHCP, but returned '%v'", mapping[2].Adapter.DnsServerList)
+	}
+	if global := windows.globalIpSettings(); !reflect.DeepEqual(global.DnsSuffixList, []string{"example.com"}) {
+		t.Fatalf("unexpected result: expected '[example.com]', but returned '%v'", global.DnsSuffixList)
+	}
+}
+
+// TestStaticRoutePrepare validates the settings of a static route.
+func TestStaticRoutePrepare(t *testing.T) {
+	tc := []struct {
+		name        string
+		route       StaticRoute
+		expectedErr string
+	}{
+		{
+			name:        "Invalid destination",
+			route:       StaticRoute{Destination: "10.20.0.0", Gateway: "192.168.1.1"},
+			expectedErr: "`static_route[0].destination` is not a valid network in CIDR notation: 10.20.0.0",
+		},
+		{
+			name:        "Invalid gateway",
+			route:       StaticRoute{Destination: "10.20.0.0/16", Gateway: "gateway"},
+			expectedErr: "`static_route[0].gateway` is not a valid IP address: gateway",
+		},
+		{
+			name:        "Mixed address families",
+			route:       StaticRoute{Destination: "fd00:20::/64", Gateway: "192.168.1.1"},
+			expectedErr: "`static_route[0].gateway` 192.168.1.1 is not of the same address family as fd00:20::/64",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.route.prepare(0, nil)
+			if len(errs) != 1 {
+				t.Fatalf("unexpected result: expected '1' error, but returned '%d'", len(errs))
+			}
+			if errs[0].Error() != c.expectedErr {
+				t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErr, errs[0])
+			}
+		})
+	}
+
+	route := StaticRoute{Destination: "10.20.1.0/16", Gateway: "192.168.1.1"}
+	if errs := route.prepare(0, nil); len(errs) != 0 {
+		t.Fatalf("unexpected error: %s", errs)
+	}
+	if route.Destination != "10.20.0.0/16" {
+		t.Fatalf("unexpected result: expected '10.20.0.0/16', but returned '%s'", route.Destination)
+	}
+}
+
+// TestCustomizeStaticRoutes validates that the static routes are added by the
+// customization script on Linux and by the run once commands on Windows.
+func TestCustomizeStaticRoutes(t *testing.T) {
+	routes := []StaticRoute{
+		{Destination: "10.20.0.0/16", Gateway: "192.168.1.1"},
+		{Destination: "fd00:20::/64", Gateway: "fd00::1"},
+	}
+	nics := []NetworkInterface{{Ipv4Address: "192.168.1.10", Ipv4NetMask: 24}}
+
+	windows := &CustomizeConfig{
+		WindowsOptions:    &WindowsOptions{ComputerName: "packer", RunOnceCommandList: []string{"cmd.exe /c echo done"}},
+		NetworkInterfaces: nics,
+		StaticRoutes:      routes,
+	}
+	if _, errs := windows.Prepare(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "requires `auto_logon`") {
+		t.Fatalf("unexpected result: expected an error for 'auto_logon', but returned '%v'", errs)
+	}
+	autoLogon := true
+	windows.WindowsOptions.AutoLogon = &autoLogon
+	if _, errs := windows.Prepare(); len(errs) > 0 {
+		t.Fatalf("unexpected error: %s", errs)
+	}
+	expected := []string{
+		"route -p add 10.20.0.0 mask 255.255.0.0 192.168.1.1",
+		"route -p add fd00:20::/64 fd00::1",
+		"cmd.exe /c echo done",
+	}
+	if commands := windows.WindowsOptions.guiRunOnce(windows.StaticRoutes).CommandList; !reflect.DeepEqual(commands, expected) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, commands)
+	}
+
+	linux := &CustomizeConfig{
+		LinuxOptions:      &LinuxOptions{Hostname: "packer", Domain: "example.com"},
+		NetworkInterfaces: nics,
+		StaticRoutes:      routes,
+	}
+	if _, errs := linux.Prepare(); len(errs) > 0 {
+		t.Fatalf("unexpected error: %s", errs)
+	}
+	script := linux.LinuxOptions.linuxPrep(linux.StaticRoutes).ScriptText
+	for _, line := range []string{
+		"ExecStart=/bin/sh -c 'ip route replace 10.20.0.0/16 via 192.168.1.1'",
+		"ExecStart=/bin/sh -c 'ip route replace fd00:20::/64 via fd00::1'",
+		"systemctl enable packer-static-routes.service",
+	} {
+		if !strings.Contains(script, line) {
+			t.Fatalf("unexpected result: expected the script to contain '%s', but returned '%s'", line, script)
+		}
+	}
+	if script := linux.LinuxOptions.linuxPrep(nil).ScriptText; script != "" {
+		t.Fatalf("unexpected result: expected no script, but returned '%s'", script)
+	}
+
+	sysprep := &CustomizeConfig{
+		WindowsSysPrepText: "<unattend/>",
+		NetworkInterfaces:  nics,
+		StaticRoutes:       routes,
+	}
+	if _, errs := sysprep.Prepare(); len(errs) != 1 {
+		t.Fatalf("unexpected result: expected '1' error, but returned '%v'", errs)
+	}
+}
+
+// TestWindowsOptionsJoinDomain validates the settings to join a domain and
+// that the credentials are passed to the identification of the sysprep.
+func TestWindowsOptionsJoinDomain(t *testing.T) {
+	tc := []struct {
+		name    string
+		options WindowsOptions
+		fail    bool
+	}{
+		{
+			name:    "Join domain",
+			options: WindowsOptions{ComputerName: "packer", JoinDomain: "example.com", DomainAdminUser: "admin", DomainAdminPassword: "pass"},
+		},
+		{
+			name:    "Join domain without credentials",
+			options: WindowsOptions{ComputerName: "packer", JoinDomain: "example.com"},
+			fail:    true,
+		},
+		{
+			name:    "Join domain with workgroup",
+			options: WindowsOptions{ComputerName: "packer", JoinDomain: "example.com", DomainAdminUser: "admin", DomainAdminPassword: "pass", Workgroup: "WORKGROUP"},
+			fail:    true,
+		},
+		{
+			name:    "Organizational unit without domain",
+			options: WindowsOptions{ComputerName: "packer", DomainOU: "OU=Servers,DC=example,DC=com"},
+			fail:    true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.options.prepare(nil)
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+		})
+	}
+
+	options := WindowsOptions{
+		ComputerName:        "packer",
+		JoinDomain:          "example.com",
+		DomainAdminUser:     "admin",
+		DomainAdminPassword: "pass",
+		DomainOU:            "OU=Servers,DC=example,DC=com",
+	}
+	identification := options.sysprep(nil).Identification
+	if identification.JoinDomain != "example.com" || identification.DomainAdmin != "admin" || identification.DomainOU != "OU=Servers,DC=example,DC=com" {
+		t.Fatalf("unexpected result: '%#v'", identification)
+	}
+	if p := identification.DomainAdminPassword; p == nil || p.Value != "pass" || !p.PlainText {
+		t.Fatalf("unexpected result: expected the domain administrator password, but returned '%#v'", p)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+type StepUpgradeVM struct {
+	Config *CloneConfig
+}
+
+func (s *StepUpgradeVM) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config.Version == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Sayf("Upgrading the virtual hardware version to %d...", s.Config.Version)
+	if err := vm.UpgradeHardwareVersion(s.Config.Version); err != nil {
+		state.Put("error", fmt.Errorf("error upgrading the virtual hardware version: %s", err))
+		return multistep.ActionHalt
+	}
+	return multistep.ActionContinue
+}
+
+func (s *StepUpgradeVM) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clone
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepUpgradeVM_Run(t *testing.T) {
+	tc := []struct {
+		name    string
+		version uint
+		err     error
+		called  bool
+		action  multistep.StepAction
+	}{
+		{
+			name:   "Version not set",
+			action: multistep.ActionContinue,
+		},
+		{
+			name:    "Version upgraded",
+			version: 21,
+			called:  true,
+			action:  multistep.ActionContinue,
+		},
+		{
+			name:    "Version not supported",
+			version: 99,
+			err:     fmt.Errorf("virtual hardware version 99 is not supported"),
+			called:  true,
+			action:  multistep.ActionHalt,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := new(multistep.BasicStateBag)
+			state.Put("ui", &packersdk.BasicUi{
+				Reader: new(bytes.Buffer),
+				Writer: new(bytes.Buffer),
+			})
+			vm := new(driver.VirtualMachineMock)
+			vm.UpgradeHardwareVersionErr = c.err
+			state.Put("vm", vm)
+
+			step := &StepUpgradeVM{Config: &CloneConfig{Version: c.version}}
+			if action := step.Run(context.TODO(), state); action != c.action {
+				t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", c.action, action)
+			}
+			if vm.UpgradeHardwareVersionCalled != c.called {
+				t.Fatalf("unexpected result: expected UpgradeHardwareVersion called to be %t", c.called)
+			}
+			if c.called && vm.UpgradeHardwareVersionVersion != c.version {
+				t.Errorf("unexpected result: expected '%d', but returned '%d'", c.version, vm.UpgradeHardwareVersionVersion)
+			}
+			if _, ok := state.GetOk("error"); ok != (c.err != nil) {
+				t.Errorf("unexpected result: expected an error in state to be %t", c.err != nil)
+			}
+		})
+	}
+}
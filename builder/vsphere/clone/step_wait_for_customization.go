@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clone
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The interval between checks for the completion of the guest customization.
+var customizationPollInterval = 10 * time.Second
+
+// The number of lines of the guest customization log included in the error.
+const customizationLogLines = 20
+
+// Remediation hints for guest customization failures caused by the
+// configuration of the guest operating system. These failures are not retried.
+var customizationFailureHints = map[string]string{
+	string(types.CustomizationFailedReasonCodeUserDefinedScriptDisabled): "custom scripts are disabled in VMware Tools; run 'vmware-toolbox-cmd config set deployPkg enable-custom-scripts true' in the source virtual machine",
+	string(types.CustomizationFailedReasonCodeCustomizationDisabled):     "guest customization is disabled in VMware Tools; enable it in the source virtual machine",
+	string(types.CustomizationFailedReasonCodeRawDataIsNotSupported):     "the version of cloud-init in the source virtual machine does not support raw data",
+	string(types.CustomizationFailedReasonCodeWrongMetadataFormat):       "the cloud-init metadata is not in a valid format",
+}
+
+type StepWaitForCustomization struct {
+	Config *CustomizeConfig
+	// The credentials used to retrieve the guest customization log.
+	GuestUsername string
+	GuestPassword string
+}
+
+func (s *StepWaitForCustomization) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.WaitForCustomization {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(*driver.VirtualMachineDriver)
+
+	ui.Say("Waiting for guest customization to complete...")
+
+	var afterKey int32
+	for attempt := 0; ; attempt++ {
+		e, err := s.waitForCompletion(ctx, vm, afterKey)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+
+		failure, ok := e.(types.BaseCustomizationFailed)
+		if !ok {
+			ui.Say("Guest customization completed successfully.")
+			return multistep.ActionContinue
+		}
+
+		message, retryable := describeCustomizationFailure(failure)
+		if !retryable || attempt >= *s.Config.CustomizationRetries {
+			state.Put("error", s.customizationError(vm, failure, message))
+			return multistep.ActionHalt
+		}
+
+		ui.Sayf("Guest customization failed: %s. Retrying after restarting the virtual machine...", message)
+		if err := s.retry(vm); err != nil {
+			state.Put("error", fmt.Errorf("error retrying guest customization: %s", err))
+			return multistep.ActionHalt
+		}
+		afterKey = e.GetEvent().Key
+	}
+}
+
+// waitForCompletion waits for an event that reports the completion of the
+// guest customization with a key greater than afterKey.
+func (s *StepWaitForCustomization) waitForCompletion(ctx context.Context, vm *driver.VirtualMachineDriver, afterKey int32) (types.BaseEvent, error) {
+	timeout := time.After(s.Config.CustomizationTimeout)
+	for {
+		e, err := vm.LatestCustomizationEvent(afterKey)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving guest customization events: %s", err)
+		}
+		if e != nil {
+			return e, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("interrupted while waiting for guest customization")
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for guest customization to complete")
+		case <-time.After(customizationPollInterval):
+		}
+	}
+}
+
+// retry powers off the virtual machine, applies the guest customization
+// again, and powers on the virtual machine. Restarting the virtual machine
+// completes pending reboots and restarts VMware Tools in the guest.
+func (s *StepWaitForCustomization) retry(vm *driver.VirtualMachineDriver) error {
+	spec, err := (&StepCustomize{Config: s.Config}).spec()
+	if err != nil {
+		return err
+	}
+	if err := vm.PowerOff(); err != nil {
+		return err
+	}
+	if err := vm.Customize(spec); err != nil {
+		return err
+	}
+	return vm.PowerOn()
+}
+
+func (s *StepWaitForCustomization) customizationError(vm *driver.VirtualMachineDriver, failure types.BaseCustomizationFailed, message string) error {
+	logLocation := failure.GetCustomizationFailed().LogLocation
+	if logLocation == "" {
+		return fmt.Errorf("guest customization failed: %s", message)
+	}
+
+	snippet := s.logSnippet(vm, logLocation)
+	if snippet == "" {
+		return fmt.Errorf("guest customization failed: %s (log: %s)", message, logLocation)
+	}
+	return fmt.Errorf("guest customization failed: %s\n\nLast lines of %s:\n%s", message, logLocation, snippet)
+}
+
+// logSnippet returns the last lines of the guest customization log, or an
+// empty string if the log cannot be retrieved.
+func (s *StepWaitForCustomization) logSnippet(vm *driver.VirtualMachineDriver, path string) string {
+	if s.GuestUsername == "" || s.GuestPassword == "" {
+		return ""
+	}
+	content, err := vm.DownloadGuestFile(s.GuestUsername, s.GuestPassword, path)
+	if err != nil {
+		log.Printf("[WARN] Unable to retrieve the guest customization log %s: %s", path, err)
+		return ""
+	}
+	return lastLines(string(content), customizationLogLines)
+}
+
+func (s *StepWaitForCustomization) Cleanup(multistep.StateBag) {}
+
+// describeCustomizationFailure returns a description of a guest customization
+// failure and whether the failure may be resolved by a retry.
+func describeCustomizationFailure(failure types.BaseCustomizationFailed) (string, bool) {
+	f := failure.GetCustomizationFailed()
+
+	message := reflect.TypeOf(failure).Elem().Name()
+	if f.FullFormattedMessage != "" {
+		message = fmt.Sprintf("%s: %s", message, f.FullFormattedMessage)
+	}
+
+	if f.Reason != "" {
+		if hint, ok := customizationFailureHints[f.Reason]; ok {
+			return fmt.Sprintf("%s (%s)", message, hint), false
+		}
+		return fmt.Sprintf("%s (reason: %s)", message, f.Reason), false
+	}
+
+	if _, ok := failure.(*types.CustomizationLinuxIdentityFailed); ok {
+		return message, false
+	}
+	return message, true
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
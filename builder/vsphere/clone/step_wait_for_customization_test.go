@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package clone
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCustomizeConfig_PrepareCustomizationDefaults(t *testing.T) {
+	config := &CustomizeConfig{
+		LinuxOptions:      &LinuxOptions{Hostname: "packer", Domain: "example.com"},
+		NetworkInterfaces: []NetworkInterface{{}},
+	}
+	if _, errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if config.CustomizationTimeout != 30*time.Minute {
+		t.Fatalf("unexpected result: expected '30m0s', but returned '%s'", config.CustomizationTimeout)
+	}
+	if config.CustomizationRetries == nil || *config.CustomizationRetries != 1 {
+		t.Fatalf("unexpected result: expected '1' customization retry")
+	}
+
+	retries := -1
+	config.CustomizationRetries = &retries
+	if _, errs := config.Prepare(); len(errs) != 1 {
+		t.Fatalf("unexpected result: expected 1 error, but returned %d: %v", len(errs), errs)
+	}
+}
+
+func TestDescribeCustomizationFailure(t *testing.T) {
+	tc := []struct {
+		name      string
+		failure   types.BaseCustomizationFailed
+		contains  string
+		retryable bool
+	}{
+		{
+			name:      "Sysprep failure",
+			failure:   &types.CustomizationSysprepFailed{},
+			contains:  "CustomizationSysprepFailed",
+			retryable: true,
+		},
+		{
+			name:      "Unknown failure",
+			failure:   &types.CustomizationUnknownFailure{},
+			contains:  "CustomizationUnknownFailure",
+			retryable: true,
+		},
+		{
+			name:     "Linux identity failure",
+			failure:  &types.CustomizationLinuxIdentityFailed{},
+			contains: "CustomizationLinuxIdentityFailed",
+		},
+		{
+			name: "Customization disabled",
+			failure: &types.CustomizationFailed{
+				Reason: string(types.CustomizationFailedReasonCodeCustomizationDisabled),
+			},
+			contains: "guest customization is disabled in VMware Tools",
+		},
+		{
+			name:     "Unknown reason",
+			failure:  &types.CustomizationFailed{Reason: "example"},
+			contains: "reason: example",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			message, retryable := describeCustomizationFailure(c.failure)
+			if !strings.Contains(message, c.contains) {
+				t.Fatalf("unexpected result: expected '%s' to contain '%s'", message, c.contains)
+			}
+			if retryable != c.retryable {
+				t.Fatalf("unexpected result: expected retryable '%t', but returned '%t'", c.retryable, retryable)
+			}
+		})
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	if got := lastLines("a\nb\nc\n", 2); got != "b\nc" {
+		t.Fatalf("unexpected result: expected 'b\\nc', but returned '%s'", got)
+	}
+	if got := lastLines("a\r\nb", 5); got != "a\r\nb" {
+		t.Fatalf("unexpected result: expected 'a\\r\\nb', but returned '%s'", got)
+	}
+}
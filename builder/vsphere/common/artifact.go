@@ -67,6 +67,24 @@ func (a *Artifact) stateHCPPackerRegistryMetadata() interface{} {
 			labels[label] = data
 		}
 	}
+	capacity, ok := a.StateData["capacity"].(map[string]string)
+	if ok {
+		for label, data := range capacity {
+			labels[label] = data
+		}
+	}
+	networks, ok := a.StateData["network_verification"].(map[string]string)
+	if ok {
+		for label, data := range networks {
+			labels[label] = data
+		}
+	}
+	devices, ok := a.StateData["devices"].(map[string]string)
+	if ok {
+		for label, data := range devices {
+			labels[label] = data
+		}
+	}
 	if a.Location.Cluster != "" {
 		labels["cluster"] = a.Location.Cluster
 	}
@@ -93,6 +111,15 @@ func (a *Artifact) stateHCPPackerRegistryMetadata() interface{} {
 	if ok {
 		sourceID = templatePath
 	}
+	// The snapshot created on the source for a linked clone.
+	snapshot, ok := a.StateData["source_snapshot"].(string)
+	if ok && snapshot != "" {
+		labels["source_snapshot"] = snapshot
+	}
+	fingerprint, ok := a.StateData["build_fingerprint"].(string)
+	if ok && fingerprint != "" {
+		labels["build_fingerprint"] = fingerprint
+	}
 
 	img, _ := registryimage.FromArtifact(a,
 		registryimage.WithID(a.Name),
@@ -4,9 +4,12 @@
 package common
 
 import (
+	"slices"
+
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func CleanupVM(state multistep.StateBag) {
@@ -29,9 +32,40 @@ func CleanupVM(state multistep.StateBag) {
 	}
 
 	ui := state.Get("ui").(packersdk.Ui)
+	if keep, ok := state.GetOk("keep_disks"); ok {
+		if err := detachDisks(vm, keep.([]string)); err != nil {
+			ui.Errorf("error detaching disks: %s", err)
+		}
+	}
+
 	ui.Say("Destroying VM...")
 	err := vm.Destroy()
 	if err != nil {
 		ui.Errorf("%s", err)
 	}
 }
+
+// detachDisks removes the disks backed by the provided datastore paths from
+// the virtual machine and keeps the virtual disk files.
+func detachDisks(vm driver.VirtualMachine, paths []string) error {
+	devices, err := vm.Devices()
+	if err != nil {
+		return err
+	}
+
+	var disks []types.BaseVirtualDevice
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		backing, ok := device.GetVirtualDevice().Backing.(types.BaseVirtualDeviceFileBackingInfo)
+		if !ok {
+			continue
+		}
+		if slices.Contains(paths, backing.GetVirtualDeviceFileBackingInfo().FileName) {
+			disks = append(disks, device)
+		}
+	}
+	if len(disks) == 0 {
+		return nil
+	}
+
+	return vm.RemoveDevice(true, disks...)
+}
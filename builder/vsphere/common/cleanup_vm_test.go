@@ -10,6 +10,8 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func cleanupTestState(mockVM driver.VirtualMachine) multistep.StateBag {
@@ -63,3 +65,45 @@ func Test_CleanupVM(t *testing.T) {
 	}
 
 }
+
+func Test_CleanupVMKeepDisks(t *testing.T) {
+	keep := &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			Key: 2001,
+			Backing: &types.VirtualDiskFlatVer2BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+					FileName: "[datastore1] packer-disks/cache.vmdk",
+				},
+			},
+		},
+	}
+	other := &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			Key: 2000,
+			Backing: &types.VirtualDiskFlatVer2BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+					FileName: "[datastore1] vm/vm.vmdk",
+				},
+			},
+		},
+	}
+
+	mockVM := &driver.VirtualMachineMock{
+		DevicesReturn: object.VirtualDeviceList{other, keep},
+	}
+	state := cleanupTestState(mockVM)
+	state.Put(multistep.StateHalted, true)
+	state.Put("keep_disks", []string{"[datastore1] packer-disks/cache.vmdk"})
+
+	CleanupVM(state)
+
+	if !mockVM.RemoveDeviceCalled || !mockVM.RemoveDeviceKeepFiles {
+		t.Fatalf("unexpected result: expected '%s' to be called with keepFiles", "RemoveDevice")
+	}
+	if len(mockVM.RemoveDeviceDevices) != 1 || mockVM.RemoveDeviceDevices[0] != keep {
+		t.Fatalf("unexpected result: expected only the kept disk to be detached, but returned '%v'", mockVM.RemoveDeviceDevices)
+	}
+	if !mockVM.DestroyCalled {
+		t.Fatalf("unexpected result: expected '%s' to be called", "Destroy")
+	}
+}
@@ -33,10 +33,21 @@ type LocationConfig struct {
 	// ~> **Note:**  The full path to the resource pool must be provided.
 	// For example, a simple resource pool path might resemble `rp-packer` and
 	// a nested path might resemble 'rp-packer/rp-linux-images'.
+	//
+	// The name of a vApp can also be provided to create the virtual machine in
+	// the vApp. Virtual machines in a vApp cannot be converted to a template,
+	// so `convert_to_template` must be `false`.
 	ResourcePool string `mapstructure:"resource_pool"`
 	// The datastore where the virtual machine is created.
 	// Required if `host` is a cluster, or if `host` has multiple datastores.
 	Datastore string `mapstructure:"datastore"`
+	// Create the virtual machine on the local datastore of the `host` with
+	// the most free space, instead of a named `datastore`, such as for a
+	// build on fast local storage. Datastores that are mounted on more than
+	// one host are not selected. Requires `host` and either `export` or
+	// `content_library_destination`, so that the artifact is copied from the
+	// local datastore. Defaults to `false`.
+	HostLocalDatastore bool `mapstructure:"host_local_datastore"`
 	// The ESXI host used for uploading files to the datastore.
 	// Defaults to `false`.
 	SetHostForDatastoreUploads bool `mapstructure:"set_host_for_datastore_uploads"`
@@ -51,6 +62,14 @@ func (c *LocationConfig) Prepare() []error {
 	if c.Cluster == "" && c.Host == "" {
 		errs = append(errs, fmt.Errorf("'host' or 'cluster' is required"))
 	}
+	if c.HostLocalDatastore {
+		if c.Host == "" {
+			errs = append(errs, fmt.Errorf("'host_local_datastore' requires 'host'"))
+		}
+		if c.Datastore != "" {
+			errs = append(errs, fmt.Errorf("'datastore' and 'host_local_datastore' cannot be used together"))
+		}
+	}
 
 	// clean Folder path and remove leading slash as folders are relative within vsphere
 	c.Folder = path.Clean(c.Folder)
@@ -58,3 +77,21 @@ func (c *LocationConfig) Prepare() []error {
 
 	return errs
 }
+
+// PrepareHostLocalDatastore checks that the artifact is copied from the local
+// datastore of the host, either by the export or to a content library, since
+// the virtual machine is only available on the host.
+func (c *LocationConfig) PrepareHostLocalDatastore(export *ExportConfig, library *ContentLibraryDestinationConfig) []error {
+	if !c.HostLocalDatastore || export != nil {
+		return nil
+	}
+	if library == nil {
+		return []error{fmt.Errorf("'host_local_datastore' requires 'export' or 'content_library_destination'")}
+	}
+	// A virtual machine template in a content library is stored on the
+	// datastore of the virtual machine unless a datastore is specified.
+	if !library.Ovf && library.Datastore == "" {
+		return []error{fmt.Errorf("'host_local_datastore' requires 'content_library_destination.ovf' or 'content_library_destination.datastore'")}
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ type FlatLocationConfig struct {
 	Host                       *string `mapstructure:"host" cty:"host" hcl:"host"`
 	ResourcePool               *string `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
 	Datastore                  *string `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
+	HostLocalDatastore         *bool   `mapstructure:"host_local_datastore" cty:"host_local_datastore" hcl:"host_local_datastore"`
 	SetHostForDatastoreUploads *bool   `mapstructure:"set_host_for_datastore_uploads" cty:"set_host_for_datastore_uploads" hcl:"set_host_for_datastore_uploads"`
 }
 
@@ -37,6 +38,7 @@ func (*FlatLocationConfig) HCL2Spec() map[string]hcldec.Spec {
 		"host":                           &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
 		"resource_pool":                  &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
 		"datastore":                      &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
+		"host_local_datastore":           &hcldec.AttrSpec{Name: "host_local_datastore", Type: cty.Bool, Required: false},
 		"set_host_for_datastore_uploads": &hcldec.AttrSpec{Name: "set_host_for_datastore_uploads", Type: cty.Bool, Required: false},
 	}
 	return s
@@ -4,6 +4,9 @@
 package common
 
 import (
+	"net"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 )
 
@@ -16,3 +19,20 @@ func CommHost(host string) func(multistep.StateBag) (string, error) {
 		}
 	}
 }
+
+// CommConnectHost returns the host that the communicator connects to. The
+// WinRM communicator uses the host in a URL, so an IPv6 address is enclosed in
+// brackets, while the SSH communicator expects the address as is.
+func CommConnectHost(config *communicator.Config) func(multistep.StateBag) (string, error) {
+	host := CommHost(config.Host())
+	return func(state multistep.StateBag) (string, error) {
+		h, err := host(state)
+		if err != nil {
+			return "", err
+		}
+		if ip := net.ParseIP(h); config.Type == "winrm" && ip != nil && ip.To4() == nil {
+			h = "[" + h + "]"
+		}
+		return h, nil
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestCommConnectHost(t *testing.T) {
+	tc := []struct {
+		name     string
+		commType string
+		ip       string
+		expected string
+	}{
+		{"SSH IPv4", "ssh", "10.0.0.5", "10.0.0.5"},
+		{"SSH IPv6", "ssh", "2001:db8::5", "2001:db8::5"},
+		{"WinRM IPv4", "winrm", "10.0.0.5", "10.0.0.5"},
+		{"WinRM IPv6", "winrm", "2001:db8::5", "[2001:db8::5]"},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := new(multistep.BasicStateBag)
+			state.Put("ip", c.ip)
+			host, err := CommConnectHost(&communicator.Config{Type: c.commType})(state)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if host != c.expected {
+				t.Fatalf("unexpected host: expected '%s', but returned '%s'", c.expected, host)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type TimeoutsConfig
+
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutsConfig overrides the timeouts of the long-running steps of a build.
+type TimeoutsConfig struct {
+	// Amount of time to wait for the virtual machine to be cloned. Applies to
+	// the `vsphere-clone` builder only. Defaults to no timeout.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	//   timeouts {
+	//     clone          = "30m"
+	//     export         = "2h"
+	//     customize_wait = "20m"
+	//     shutdown       = "15m"
+	//   }
+	// ```
+	Clone time.Duration `mapstructure:"clone"`
+	// Amount of time to wait for the virtual machine to be exported, including
+	// the download of the exported files. Applies only if `export` is
+	// specified. Defaults to no timeout.
+	Export time.Duration `mapstructure:"export"`
+	// Amount of time to wait for the guest operating system customization to
+	// complete. Applies to the `vsphere-clone` builder only if `customize` is
+	// specified. Overrides `customization_timeout`. Defaults to `30m`.
+	CustomizeWait time.Duration `mapstructure:"customize_wait"`
+	// Amount of time to wait for the graceful shut down of the virtual
+	// machine. Overrides `shutdown_timeout`. Defaults to `5m`.
+	Shutdown time.Duration `mapstructure:"shutdown"`
+	// Amount of time to wait for the virtual machine to obtain an IP address.
+	// Overrides `ip_wait_timeout`. Defaults to `30m`.
+	IPWait time.Duration `mapstructure:"ip_wait"`
+}
+
+// Prepare validates the timeouts and applies them to the step configurations
+// that have their own timeout options. It must be called before the step
+// configurations are prepared, so their defaults are only set when neither
+// option is specified.
+func (c *TimeoutsConfig) Prepare(shutdown *ShutdownConfig, waitIp *WaitIpConfig) []error {
+	var errs []error
+
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"clone", c.Clone},
+		{"export", c.Export},
+		{"customize_wait", c.CustomizeWait},
+		{"shutdown", c.Shutdown},
+		{"ip_wait", c.IPWait},
+	}
+	for _, t := range timeouts {
+		if t.value < 0 {
+			errs = append(errs, fmt.Errorf("'timeouts.%s' must be greater than or equal to 0", t.name))
+		}
+	}
+
+	if c.Shutdown != 0 {
+		if shutdown.Timeout != 0 {
+			errs = append(errs, fmt.Errorf("'timeouts.shutdown' and 'shutdown_timeout' cannot be used together"))
+		} else {
+			shutdown.Timeout = c.Shutdown
+		}
+	}
+	if c.IPWait != 0 {
+		if waitIp.WaitTimeout != 0 {
+			errs = append(errs, fmt.Errorf("'timeouts.ip_wait' and 'ip_wait_timeout' cannot be used together"))
+		} else {
+			waitIp.WaitTimeout = c.IPWait
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeoutsConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name             string
+		config           TimeoutsConfig
+		shutdown         ShutdownConfig
+		waitIp           WaitIpConfig
+		expectedShutdown time.Duration
+		expectedWaitIp   time.Duration
+		fail             bool
+	}{
+		{
+			name: "No overrides",
+		},
+		{
+			name:             "Overrides",
+			config:           TimeoutsConfig{Shutdown: 15 * time.Minute, IPWait: time.Hour},
+			expectedShutdown: 15 * time.Minute,
+			expectedWaitIp:   time.Hour,
+		},
+		{
+			name:             "Legacy options",
+			shutdown:         ShutdownConfig{Timeout: 10 * time.Minute},
+			waitIp:           WaitIpConfig{WaitTimeout: 20 * time.Minute},
+			expectedShutdown: 10 * time.Minute,
+			expectedWaitIp:   20 * time.Minute,
+		},
+		{
+			name:     "Shutdown conflict",
+			config:   TimeoutsConfig{Shutdown: 15 * time.Minute},
+			shutdown: ShutdownConfig{Timeout: 10 * time.Minute},
+			fail:     true,
+		},
+		{
+			name:   "IP wait conflict",
+			config: TimeoutsConfig{IPWait: time.Hour},
+			waitIp: WaitIpConfig{WaitTimeout: 20 * time.Minute},
+			fail:   true,
+		},
+		{
+			name:   "Negative",
+			config: TimeoutsConfig{Export: -time.Minute},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(&c.shutdown, &c.waitIp)
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatal("unexpected success: expected failure")
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+			if c.shutdown.Timeout != c.expectedShutdown {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expectedShutdown, c.shutdown.Timeout)
+			}
+			if c.waitIp.WaitTimeout != c.expectedWaitIp {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expectedWaitIp, c.waitIp.WaitTimeout)
+			}
+		})
+	}
+}
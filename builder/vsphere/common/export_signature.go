@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vmware/govmomi/ovf"
+)
+
+// The namespaces of the OVF envelope in versions 1.x and 2.x of the
+// specification.
+var ovfEnvelopeNamespaces = map[string]bool{
+	"http://schemas.dmtf.org/ovf/envelope/1": true,
+	"http://schemas.dmtf.org/ovf/envelope/2": true,
+}
+
+// The hash functions used to sign the manifest, by manifest algorithm.
+var signatureHash = map[string]crypto.Hash{
+	"sha1":   crypto.SHA1,
+	"sha256": crypto.SHA256,
+	"sha512": crypto.SHA512,
+}
+
+// exportSigner signs the manifest of an exported image with an RSA private
+// key and the X.509 certificate of its public key.
+type exportSigner struct {
+	key  *rsa.PrivateKey
+	cert []byte
+}
+
+// loadExportSigner reads the PEM-encoded private key and certificate. The
+// certificate is read from the key file if certPath is empty.
+func loadExportSigner(keyPath string, certPath string) (*exportSigner, error) {
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing key: %s", err)
+	}
+	certPEM := keyPEM
+	if certPath == "" {
+		certPath = keyPath
+	} else {
+		certPEM, err = os.ReadFile(certPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read signing certificate: %s", err)
+		}
+	}
+
+	var key *rsa.PrivateKey
+	for rest := keyPEM; key == nil; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("no private key found in %s", keyPath)
+		}
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return nil, fmt.Errorf("unable to parse signing key: %s", err)
+			}
+		case "PRIVATE KEY":
+			k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse signing key: %s", err)
+			}
+			var ok bool
+			if key, ok = k.(*rsa.PrivateKey); !ok {
+				return nil, fmt.Errorf("unsupported signing key type %T: only RSA keys are supported", k)
+			}
+		case "ENCRYPTED PRIVATE KEY":
+			return nil, fmt.Errorf("encrypted signing keys are not supported")
+		}
+	}
+
+	var cert *pem.Block
+	for rest := certPEM; cert == nil; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found in %s", certPath)
+		}
+		if block.Type == "CERTIFICATE" {
+			cert = block
+		}
+	}
+	c, err := x509.ParseCertificate(cert.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse signing certificate: %s", err)
+	}
+	if pub, ok := c.PublicKey.(*rsa.PublicKey); !ok || !pub.Equal(&key.PublicKey) {
+		return nil, fmt.Errorf("the signing certificate does not match the signing key")
+	}
+
+	return &exportSigner{key: key, cert: pem.EncodeToMemory(cert)}, nil
+}
+
+// sign returns the content of the signature file of the manifest, which is
+// the signature of the manifest followed by the certificate, as defined by
+// the OVF specification and created by ovftool.
+func (s *exportSigner) sign(algorithm string, name string, manifest []byte) ([]byte, error) {
+	hash, ok := signatureHash[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature hash: %s", algorithm)
+	}
+	h := hash.New()
+	h.Write(manifest)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, hash, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "%s(%s)= %x\n", strings.ToUpper(algorithm), name, sig)
+	buf.Write(s.cert)
+	return buf.Bytes(), nil
+}
+
+// privateKeyPEM returns the private key and the certificate in a single PEM
+// document, as expected by the --privateKey option of ovftool.
+func (s *exportSigner) privateKeyPEM() []byte {
+	key := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(s.key)})
+	return append(key, s.cert...)
+}
+
+// validateDescriptor checks that the OVF descriptor is a well-formed OVF
+// envelope with a virtual system, that the files it references are the
+// exported files, and that the disks reference those files, which vCenter
+// Server checks when importing the image.
+func validateDescriptor(desc string, files []string) error {
+	dec := xml.NewDecoder(strings.NewReader(desc))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid ovf descriptor: %s", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "Envelope" || !ovfEnvelopeNamespaces[start.Name.Space] {
+				return fmt.Errorf("invalid ovf descriptor: unexpected root element %s", start.Name.Local)
+			}
+			break
+		}
+	}
+
+	e, err := ovf.Unmarshal(strings.NewReader(desc))
+	if err != nil {
+		return fmt.Errorf("invalid ovf descriptor: %s", err)
+	}
+
+	switch {
+	case e.VirtualSystem != nil:
+		if e.VirtualSystem.ID == "" {
+			return fmt.Errorf("invalid ovf descriptor: virtual system has no id")
+		}
+		if len(e.VirtualSystem.VirtualHardware) == 0 {
+			return fmt.Errorf("invalid ovf descriptor: virtual system has no virtual hardware section")
+		}
+	case e.VirtualSystemCollection != nil:
+		if e.VirtualSystemCollection.ID == "" {
+			return fmt.Errorf("invalid ovf descriptor: virtual system collection has no id")
+		}
+	default:
+		return fmt.Errorf("invalid ovf descriptor: no virtual system")
+	}
+
+	exported := make(map[string]bool, len(files))
+	for _, f := range files {
+		exported[f] = true
+	}
+	refs := make(map[string]bool, len(e.References))
+	for _, f := range e.References {
+		if f.ID == "" || f.Href == "" {
+			return fmt.Errorf("invalid ovf descriptor: file reference requires an id and href")
+		}
+		if refs[f.ID] {
+			return fmt.Errorf("invalid ovf descriptor: duplicate file reference %s", f.ID)
+		}
+		if !exported[f.Href] {
+			return fmt.Errorf("invalid ovf descriptor: file %s is not exported", f.Href)
+		}
+		refs[f.ID] = true
+	}
+
+	if e.Disk != nil {
+		for _, d := range e.Disk.Disks {
+			if d.DiskID == "" || d.Capacity == "" {
+				return fmt.Errorf("invalid ovf descriptor: disk requires a disk id and capacity")
+			}
+			if d.FileRef != nil && !refs[*d.FileRef] {
+				return fmt.Errorf("invalid ovf descriptor: disk %s references unknown file %s", d.DiskID, *d.FileRef)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSigningKey writes a PEM-encoded RSA private key and a self-signed
+// certificate to the directory and returns their paths.
+func writeSigningKey(t *testing.T, dir string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "packer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	keyPath := filepath.Join(dir, "key.pem")
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return keyPath, certPath
+}
+
+func TestLoadExportSigner(t *testing.T) {
+	dir := t.TempDir()
+	keyPath, certPath := writeSigningKey(t, dir)
+
+	if _, err := loadExportSigner(keyPath, certPath); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The certificate is read from the key file if not specified.
+	if _, err := loadExportSigner(keyPath, ""); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	keyPEM, _ := os.ReadFile(keyPath)
+	certPEM, _ := os.ReadFile(certPath)
+	combined := filepath.Join(dir, "combined.pem")
+	if err := os.WriteFile(combined, append(keyPEM, certPEM...), 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := loadExportSigner(combined, ""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The certificate must match the key.
+	otherKeyPath, _ := writeSigningKey(t, t.TempDir())
+	if _, err := loadExportSigner(otherKeyPath, certPath); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestExportSigner_Sign(t *testing.T) {
+	keyPath, certPath := writeSigningKey(t, t.TempDir())
+	signer, err := loadExportSigner(keyPath, certPath)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	manifest := []byte("SHA256(test-vm.ovf)= abcd\n")
+	actual, err := signer.sign("sha256", "test-vm.mf", manifest)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	line, rest, _ := strings.Cut(string(actual), "\n")
+	prefix := "SHA256(test-vm.mf)= "
+	if !strings.HasPrefix(line, prefix) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", prefix, line)
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(line, prefix))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	digest := sha256.Sum256(manifest)
+	if err := rsa.VerifyPKCS1v15(&signer.key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if block, _ := pem.Decode([]byte(rest)); block == nil || block.Type != "CERTIFICATE" {
+		t.Fatal("unexpected result: expected the certificate after the signature")
+	}
+}
+
+func TestValidateDescriptor(t *testing.T) {
+	valid := `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:href="test-vm-disk-0.vmdk" ovf:id="file1" ovf:size="1024"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:capacity="40" ovf:diskId="vmdisk1" ovf:fileRef="file1"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="test-vm">
+    <Info>A virtual machine</Info>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+	tc := []struct {
+		name  string
+		desc  string
+		files []string
+		fail  bool
+	}{
+		{
+			name:  "Valid",
+			desc:  valid,
+			files: []string{"test-vm-disk-0.vmdk"},
+		},
+		{
+			name:  "Missing file",
+			desc:  valid,
+			files: []string{"test-vm-disk-1.vmdk"},
+			fail:  true,
+		},
+		{
+			name:  "Unknown file reference",
+			desc:  strings.Replace(valid, `ovf:fileRef="file1"`, `ovf:fileRef="file2"`, 1),
+			files: []string{"test-vm-disk-0.vmdk"},
+			fail:  true,
+		},
+		{
+			name:  "No virtual system",
+			desc:  strings.Replace(strings.Replace(valid, "<VirtualSystem ", "<Other ", 1), "</VirtualSystem>", "</Other>", 1),
+			files: []string{"test-vm-disk-0.vmdk"},
+			fail:  true,
+		},
+		{
+			name:  "Wrong namespace",
+			desc:  strings.Replace(valid, `xmlns="http://schemas.dmtf.org/ovf/envelope/1"`, `xmlns="urn:example"`, 1),
+			files: []string{"test-vm-disk-0.vmdk"},
+			fail:  true,
+		},
+		{
+			name: "Malformed",
+			desc: "<Envelope",
+			fail: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateDescriptor(c.desc, c.files)
+			if c.fail {
+				if err == nil {
+					t.Fatal("unexpected success: expected failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// guestCommunicator is a communicator that runs commands and transfers files
+// with the guest operations of VMware Tools instead of a network connection
+// to the guest operating system. The output of a command is written to the
+// RemoteCmd when the command completes.
+type guestCommunicator struct {
+	vm       driver.VirtualMachine
+	username string
+	password string
+	windows  bool
+
+	// The interval between the checks of the state of a running command.
+	pollInterval time.Duration
+}
+
+var _ packersdk.Communicator = new(guestCommunicator)
+
+func (c *guestCommunicator) Start(ctx context.Context, cmd *packersdk.RemoteCmd) error {
+	stdout, err := c.vm.CreateGuestTempFile(c.username, c.password, ".out")
+	if err != nil {
+		return fmt.Errorf("error creating a temporary file in the guest: %s", err)
+	}
+	stderr, err := c.vm.CreateGuestTempFile(c.username, c.password, ".err")
+	if err != nil {
+		c.deleteFile(stdout)
+		return fmt.Errorf("error creating a temporary file in the guest: %s", err)
+	}
+
+	program, args := c.command(cmd.Command, stdout, stderr)
+	log.Printf("[DEBUG] Starting guest program: %s %s", program, args)
+	pid, err := c.vm.StartGuestProgram(c.username, c.password, program, args)
+	if err != nil {
+		c.deleteFile(stdout)
+		c.deleteFile(stderr)
+		return fmt.Errorf("error starting the command in the guest: %s", err)
+	}
+
+	go func() {
+		code := c.wait(ctx, pid)
+		c.copyOutput(stdout, cmd.Stdout)
+		c.copyOutput(stderr, cmd.Stderr)
+		cmd.SetExited(code)
+	}()
+	return nil
+}
+
+// command returns the program and arguments that run the command with the
+// shell of the guest operating system and redirect the output of the command
+// to the specified files.
+func (c *guestCommunicator) command(command, stdout, stderr string) (string, string) {
+	if c.windows {
+		return `C:\Windows\System32\cmd.exe`, fmt.Sprintf(`/s /c "%s > "%s" 2> "%s""`, command, stdout, stderr)
+	}
+	return "/bin/sh", "-c " + shellQuote(fmt.Sprintf("%s > %s 2> %s", command, shellQuote(stdout), shellQuote(stderr)))
+}
+
+// wait waits for the program with the specified process identifier to exit
+// and returns the exit code of the program.
+func (c *guestCommunicator) wait(ctx context.Context, pid int64) int {
+	interval := c.pollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	for {
+		code, err := c.vm.GuestProgramExitCode(c.username, c.password, pid)
+		if err != nil {
+			log.Printf("[ERROR] Error retrieving the exit code of guest process %d: %s", pid, err)
+			return packersdk.CmdDisconnect
+		}
+		if code != nil {
+			return int(*code)
+		}
+
+		select {
+		case <-ctx.Done():
+			return packersdk.CmdDisconnect
+		case <-time.After(interval):
+		}
+	}
+}
+
+// copyOutput writes the content of a file in the guest to the writer and
+// deletes the file.
+func (c *guestCommunicator) copyOutput(path string, w io.Writer) {
+	defer c.deleteFile(path)
+	if w == nil {
+		return
+	}
+	content, err := c.vm.DownloadGuestFile(c.username, c.password, path)
+	if err != nil {
+		log.Printf("[WARN] Error downloading guest file %s: %s", path, err)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+// deleteFile deletes a file in the guest and logs the error, if any.
+func (c *guestCommunicator) deleteFile(path string) {
+	if err := c.vm.DeleteGuestFile(c.username, c.password, path); err != nil {
+		log.Printf("[WARN] Error deleting guest file %s: %s", path, err)
+	}
+}
+
+func (c *guestCommunicator) Upload(dst string, r io.Reader, fi *os.FileInfo) error {
+	if fi != nil && (*fi).Mode().IsRegular() {
+		return c.vm.UploadGuestFile(c.username, c.password, dst, r, (*fi).Size())
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return err
+	}
+	return c.vm.UploadGuestFile(c.username, c.password, dst, &buf, int64(buf.Len()))
+}
+
+func (c *guestCommunicator) UploadDir(dst string, src string, exclude []string) error {
+	// The directory is created in the destination unless the source ends
+	// with a separator, as with rsync.
+	if !strings.HasSuffix(src, "/") && !strings.HasSuffix(src, string(filepath.Separator)) {
+		dst = c.join(dst, filepath.Base(src))
+	}
+
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := dst
+		if rel != "." {
+			if excluded(filepath.ToSlash(rel), exclude) {
+				log.Printf("[DEBUG] Excluding %s from the upload", p)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			target = c.join(dst, filepath.ToSlash(rel))
+		}
+
+		if info.IsDir() {
+			return c.vm.MakeGuestDirectory(c.username, c.password, target)
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return c.Upload(target, f, &info)
+	})
+}
+
+func (c *guestCommunicator) Download(src string, w io.Writer) error {
+	content, err := c.vm.DownloadGuestFile(c.username, c.password, src)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func (c *guestCommunicator) DownloadDir(src string, dst string, exclude []string) error {
+	return fmt.Errorf("DownloadDir is not implemented for guest operations")
+}
+
+// excluded returns true if the slash-separated path relative to the source of
+// an upload, or its base name, matches one of the exclude patterns.
+func excluded(rel string, exclude []string) bool {
+	for _, pattern := range exclude {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// join joins a path in the guest with a slash-separated relative path.
+func (c *guestCommunicator) join(dir, rel string) string {
+	if c.windows {
+		return strings.TrimRight(dir, `\/`) + `\` + strings.ReplaceAll(rel, "/", `\`)
+	}
+	return path.Join(dir, rel)
+}
+
+// shellQuote quotes a string for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
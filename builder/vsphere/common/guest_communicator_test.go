@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestGuestCommunicator_Start(t *testing.T) {
+	code := int32(3)
+	vm := &driver.VirtualMachineMock{
+		GuestProgramExitCodeReturn: &code,
+		GuestFiles: map[string][]byte{
+			"/tmp/packer1.out": []byte("hello\n"),
+			"/tmp/packer2.err": []byte("warning\n"),
+		},
+	}
+	comm := &guestCommunicator{vm: vm, username: "root", password: "secret", pollInterval: time.Millisecond}
+
+	var stdout, stderr bytes.Buffer
+	cmd := &packersdk.RemoteCmd{Command: "echo 'hello'", Stdout: &stdout, Stderr: &stderr}
+	if err := cmd.RunWithUi(context.TODO(), comm, packersdk.TestUi(t)); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if cmd.ExitStatus() != 3 {
+		t.Fatalf("unexpected result: expected exit status '3', but returned '%d'", cmd.ExitStatus())
+	}
+
+	expected := `-c 'echo '\''hello'\'' > '\''/tmp/packer1.out'\'' 2> '\''/tmp/packer2.err'\'''`
+	if vm.StartGuestProgramPath != "/bin/sh" || vm.StartGuestProgramArgs != expected {
+		t.Fatalf("unexpected command: '%s %s'", vm.StartGuestProgramPath, vm.StartGuestProgramArgs)
+	}
+	if stdout.String() != "hello\n" || stderr.String() != "warning\n" {
+		t.Fatalf("unexpected output: '%s', '%s'", stdout.String(), stderr.String())
+	}
+	if len(vm.GuestFiles) != 0 {
+		t.Fatalf("unexpected result: expected the temporary files to be deleted, but returned '%v'", vm.GuestFiles)
+	}
+}
+
+func TestGuestCommunicator_StartError(t *testing.T) {
+	vm := &driver.VirtualMachineMock{StartGuestProgramErr: fmt.Errorf("guest operations are not available")}
+	comm := &guestCommunicator{vm: vm, username: "root", password: "secret"}
+
+	cmd := &packersdk.RemoteCmd{Command: "true"}
+	if err := comm.Start(context.TODO(), cmd); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	if vm.GuestTempFileCount != 2 {
+		t.Fatalf("unexpected result: expected 2 temporary files, but returned %d", vm.GuestTempFileCount)
+	}
+	if len(vm.GuestFiles) != 0 {
+		t.Fatalf("unexpected result: expected the temporary files to be deleted, but returned '%v'", vm.GuestFiles)
+	}
+}
+
+func TestGuestCommunicator_CommandWindows(t *testing.T) {
+	comm := &guestCommunicator{windows: true}
+	program, args := comm.command("dir C:\\", `C:\Temp\packer1.out`, `C:\Temp\packer2.err`)
+	if program != `C:\Windows\System32\cmd.exe` {
+		t.Fatalf("unexpected program: '%s'", program)
+	}
+	expected := `/s /c "dir C:\ > "C:\Temp\packer1.out" 2> "C:\Temp\packer2.err""`
+	if args != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, args)
+	}
+}
+
+func TestGuestCommunicator_UploadDir(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "scripts"), 0755); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "scripts", "setup.sh"), []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	vm := new(driver.VirtualMachineMock)
+	comm := &guestCommunicator{vm: vm}
+	if err := comm.UploadDir("/opt", src, nil); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	base := "/opt/" + filepath.Base(src)
+	if diff := cmp.Diff([]string{base, base + "/scripts"}, vm.GuestDirectories); diff != "" {
+		t.Fatalf("unexpected directories: '%s'", diff)
+	}
+	if string(vm.GuestFiles[base+"/scripts/setup.sh"]) != "#!/bin/sh\n" {
+		t.Fatalf("unexpected files: '%v'", vm.GuestFiles)
+	}
+
+	// The contents of the source are uploaded if it ends with a separator.
+	vm = new(driver.VirtualMachineMock)
+	comm = &guestCommunicator{vm: vm, windows: true}
+	if err := comm.UploadDir(`C:\Packer`, src+string(filepath.Separator), nil); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, ok := vm.GuestFiles[`C:\Packer\scripts\setup.sh`]; !ok {
+		t.Fatalf("unexpected files: '%v'", vm.GuestFiles)
+	}
+}
+
+func TestGuestCommunicator_UploadDirExclude(t *testing.T) {
+	src := t.TempDir()
+	for _, dir := range []string{"scripts", ".git"} {
+		if err := os.MkdirAll(filepath.Join(src, dir), 0755); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	for _, file := range []string{"scripts/setup.sh", "scripts/setup.log", ".git/config", "README.md"} {
+		if err := os.WriteFile(filepath.Join(src, file), []byte("content"), 0644); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	vm := new(driver.VirtualMachineMock)
+	comm := &guestCommunicator{vm: vm}
+	if err := comm.UploadDir("/opt/", src+"/", []string{".git", "*.log", "README.md"}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	if diff := cmp.Diff([]string{"/opt/", "/opt/scripts"}, vm.GuestDirectories); diff != "" {
+		t.Fatalf("unexpected directories: '%s'", diff)
+	}
+	var files []string
+	for file := range vm.GuestFiles {
+		files = append(files, file)
+	}
+	if diff := cmp.Diff([]string{"/opt/scripts/setup.sh"}, files); diff != "" {
+		t.Fatalf("unexpected files: '%s'", diff)
+	}
+}
+
+func TestGuestCommunicator_UploadDownload(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	comm := &guestCommunicator{vm: vm}
+
+	if err := comm.Upload("/tmp/file.txt", strings.NewReader("content"), nil); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var buf bytes.Buffer
+	if err := comm.Download("/tmp/file.txt", &buf); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if buf.String() != "content" {
+		t.Fatalf("unexpected result: expected 'content', but returned '%s'", buf.String())
+	}
+	if err := comm.DownloadDir("/tmp", t.TempDir(), nil); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
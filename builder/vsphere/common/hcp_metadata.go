@@ -6,24 +6,57 @@ package common
 import (
 	"fmt"
 	"log"
+	"path"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func GetVMMetadata(vm *driver.VirtualMachineDriver, state multistep.StateBag) map[string]string {
 	labels := make(map[string]string)
-	info, err := vm.Info("config.uuid", "config.annotation", "config.hardware", "resourcePool", "datastore", "network", "summary")
+	info, err := vm.Info("name", "config.uuid", "config.instanceUuid", "config.annotation", "config.hardware", "resourcePool", "datastore", "network", "summary")
 	if err != nil || info == nil {
 		log.Printf("[TRACE] error extracting virtual machine metadata: %s", err)
 		return labels
 	}
+
+	// The identifiers of the virtual machine are not saved if it is destroyed
+	// after the import to a content library.
+	destroyAfterImport, _ := state.Get("destroy_vm").(bool)
+	if !destroyAfterImport {
+		labels["vm_moref_id"] = vm.Reference().Value
+
+		// Save the inventory path of the virtual machine or template,
+		// relative to the virtual machine folder of the datacenter.
+		if folder, err := vm.Folder(); err == nil {
+			if folderPath, err := folder.Path(); err == nil {
+				labels["inventory_path"] = path.Join(folderPath, info.Name)
+			}
+		}
+	}
+
 	if info.Config != nil {
-		// Saved the virtual machine UUID.
-		// If destroyed after import to content library, the UUID is not saved.
-		destroyAfterImport, ok := state.Get("destroy_vm").(bool)
-		if !ok || !destroyAfterImport {
+		// Save the BIOS and instance UUIDs of the virtual machine.
+		if !destroyAfterImport {
 			labels["vsphere_uuid"] = info.Config.Uuid
+			labels["bios_uuid"] = info.Config.Uuid
+			labels["instance_uuid"] = info.Config.InstanceUuid
+
+			// Save the MAC addresses of the network adapters.
+			var i int
+			for _, device := range info.Config.Hardware.Device {
+				card, ok := device.(types.BaseVirtualEthernetCard)
+				if !ok {
+					continue
+				}
+				key := "mac_address"
+				if i > 0 {
+					key = fmt.Sprintf("mac_address_%d", i)
+				}
+				labels[key] = card.GetVirtualEthernetCard().MacAddress
+				i++
+			}
 		}
 
 		// If the content library is used, save the content library item UUID.
@@ -31,6 +64,14 @@ func GetVMMetadata(vm *driver.VirtualMachineDriver, state multistep.StateBag) ma
 			labels["content_library_item_uuid"] = itemUuid
 		}
 
+		// If an ephemeral content library is used, save its identifier and URL.
+		if libraryID, ok := state.Get("content_library_id").(string); ok {
+			labels["content_library_id"] = libraryID
+		}
+		if libraryURL, ok := state.Get("content_library_url").(string); ok {
+			labels["content_library_url"] = libraryURL
+		}
+
 		// Save the virtual machine annotation, if exists.
 		if info.Config.Annotation != "" {
 			labels["annotation"] = info.Config.Annotation
@@ -30,6 +30,13 @@ func TestGetVMMetadata(t *testing.T) {
 		t.Fatalf("unexpected error: '%s'", err)
 	}
 	datastore := simulator.Map.Get(vmSim.Datastore[0]).(*simulator.Datastore)
+	var mac string
+	for _, device := range vmSim.Config.Hardware.Device {
+		if card, ok := device.(types.BaseVirtualEthernetCard); ok {
+			mac = card.GetVirtualEthernetCard().MacAddress
+			break
+		}
+	}
 
 	metadata := GetVMMetadata(vm.(*driver.VirtualMachineDriver), state)
 	// Validate Labels
@@ -40,6 +47,11 @@ func TestGetVMMetadata(t *testing.T) {
 		"datastore":          datastore.Name,
 		"network":            "DC0_DVPG0",
 		"vsphere_uuid":       vmSim.Config.Uuid,
+		"bios_uuid":          vmSim.Config.Uuid,
+		"instance_uuid":      vmSim.Config.InstanceUuid,
+		"vm_moref_id":        vmSim.Reference().Value,
+		"inventory_path":     vmSim.Name,
+		"mac_address":        mac,
 		"template_datastore": "tmpl-datastore-mock",
 	}
 
@@ -47,3 +59,27 @@ func TestGetVMMetadata(t *testing.T) {
 		t.Fatalf("unexpected result: '%s'", diff)
 	}
 }
+
+func TestGetVMMetadata_DestroyAfterImport(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	state := new(multistep.BasicStateBag)
+	state.Put("destroy_vm", true)
+	state.Put("content_library_item_uuid", "item-uuid")
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	metadata := GetVMMetadata(vm.(*driver.VirtualMachineDriver), state)
+
+	for _, key := range []string{"vm_moref_id", "inventory_path", "vsphere_uuid", "bios_uuid", "instance_uuid", "mac_address"} {
+		if value, ok := metadata[key]; ok {
+			t.Fatalf("unexpected result: expected no '%s', but returned '%s'", key, value)
+		}
+	}
+	if metadata["content_library_item_uuid"] != "item-uuid" {
+		t.Fatalf("unexpected result: expected 'item-uuid', but returned '%s'", metadata["content_library_item_uuid"])
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-vsphere/version"
+)
+
+// NotesTemplateData is the data available to the `notes` template.
+type NotesTemplateData struct {
+	// The name of the virtual machine.
+	Name string
+	// The source of the build, such as the ISO or the source virtual machine.
+	Source string
+	// The version of the plugin.
+	PluginVersion string
+	// The date of the build in RFC 3339 format.
+	Date string
+}
+
+// RenderNotes renders the notes template for a virtual machine. If
+// appendNotes is true, the rendered notes and the build provenance are
+// appended to the existing notes of the virtual machine.
+func RenderNotes(ctx interpolate.Context, notes string, appendNotes bool, existing string, data NotesTemplateData) (string, error) {
+	data.PluginVersion = version.PluginVersion.String()
+	data.Date = time.Now().UTC().Format(time.RFC3339)
+
+	ctx.Data = &data
+	rendered, err := interpolate.Render(notes, &ctx)
+	if err != nil {
+		return "", fmt.Errorf("error rendering 'notes': %s", err)
+	}
+	if !appendNotes {
+		return rendered, nil
+	}
+
+	provenance := fmt.Sprintf("Built by Packer Plugin for VMware vSphere v%s on %s", data.PluginVersion, data.Date)
+	if data.Source != "" {
+		provenance += fmt.Sprintf(" from %s", data.Source)
+	}
+	provenance += "."
+
+	var parts []string
+	for _, part := range []string{existing, rendered, provenance} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestRenderNotes(t *testing.T) {
+	ctx := interpolate.Context{BuildName: "example"}
+	data := NotesTemplateData{Name: "vm-01", Source: "[datastore1] iso/example.iso"}
+
+	notes, err := RenderNotes(ctx, "{{ build_name }}: {{ .Name }} from {{ .Source }}", false, "Source notes.", data)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected := "example: vm-01 from [datastore1] iso/example.iso"
+	if notes != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, notes)
+	}
+
+	notes, err = RenderNotes(ctx, "Built for {{ .Name }}.", true, "Source notes.", data)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	parts := strings.Split(notes, "\n\n")
+	if len(parts) != 3 {
+		t.Fatalf("unexpected result: expected 3 paragraphs, but returned '%s'", notes)
+	}
+	if parts[0] != "Source notes." || parts[1] != "Built for vm-01." {
+		t.Fatalf("unexpected result: '%s'", notes)
+	}
+	if !strings.HasPrefix(parts[2], "Built by Packer Plugin for VMware vSphere v") ||
+		!strings.HasSuffix(parts[2], " from [datastore1] iso/example.iso.") {
+		t.Fatalf("unexpected result: '%s'", parts[2])
+	}
+
+	if _, err := RenderNotes(ctx, "{{ .Unknown }}", false, "", data); err == nil {
+		t.Fatalf("unexpected success")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// The configuration attributes whose values are redacted from logs.
+var sensitiveAttributes = map[string]bool{
+	"admin_password":         true,
+	"domain_admin_password":  true,
+	"password":               true,
+	"product_key":            true,
+	"ssh_bastion_password":   true,
+	"ssh_password":           true,
+	"winrm_password":         true,
+	"vcenter_saml_token":     true,
+	"vcenter_session_ticket": true,
+	"windows_sysprep_text":   true,
+	"user_data":              true,
+}
+
+// SensitiveValues returns the non-empty values of the sensitive attributes in
+// a configuration struct, including nested and embedded structs.
+func SensitiveValues(v interface{}) []string {
+	var values []string
+	collectSensitiveValues(reflect.ValueOf(v), &values)
+	return values
+}
+
+func collectSensitiveValues(v reflect.Value, values *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectSensitiveValues(v.Elem(), values)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectSensitiveValues(v.Index(i), values)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if sensitiveAttributes[name] {
+				if s := stringValue(v.Field(i)); s != "" {
+					*values = append(*values, s)
+				}
+				continue
+			}
+			collectSensitiveValues(v.Field(i), values)
+		}
+	}
+}
+
+func stringValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return ""
+	}
+	return v.String()
+}
+
+// logFilterMu serializes the installation of the log filter.
+var logFilterMu sync.Mutex
+
+// logFilter is a writer that redacts the values of the secret filter of the
+// plugin from the log output before it is written to the wrapped writer.
+type logFilter struct {
+	w io.Writer
+}
+
+func (f *logFilter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(f.w, packersdk.LogSecretFilter.FilterString(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// filterLogOutput wraps the output of the standard logger with the log filter,
+// unless it is already wrapped. The existing output is kept, so that the logs
+// are still written where the plugin process sends them.
+func filterLogOutput() {
+	logFilterMu.Lock()
+	defer logFilterMu.Unlock()
+	if _, ok := log.Writer().(*logFilter); ok {
+		return
+	}
+	log.SetOutput(&logFilter{w: log.Writer()})
+}
+
+// RegisterSensitiveValues adds the sensitive values of a configuration struct
+// to the secret filter of the plugin, which redacts them from the user
+// interface and the logs.
+func RegisterSensitiveValues(v interface{}) {
+	packersdk.LogSecretFilter.Set(SensitiveValues(v)...)
+	filterLogOutput()
+}
+
+// RedactSensitiveValues replaces the sensitive values of a configuration
+// struct in a string.
+func RedactSensitiveValues(s string, v interface{}) string {
+	for _, value := range SensitiveValues(v) {
+		// Values are escaped in the Go-syntax representation of a struct.
+		quoted := strconv.Quote(value)
+		s = strings.ReplaceAll(s, quoted[1:len(quoted)-1], "<sensitive>")
+		s = strings.ReplaceAll(s, value, "<sensitive>")
+	}
+	return s
+}
+
+// GoString returns the Go-syntax representation of the exported fields of a
+// configuration struct with the sensitive values redacted. The configurations
+// of the builders and post-processors implement fmt.GoStringer with it, so
+// that the sensitive values are not logged when a configuration is formatted
+// with the %#v verb.
+func GoString(v interface{}) string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	t := rv.Type()
+
+	var b strings.Builder
+	b.WriteString(t.String())
+	b.WriteString("{")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if b.Len() > len(t.String())+1 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s:%#v", field.Name, rv.Field(i).Interface())
+	}
+	b.WriteString("}")
+	return RedactSensitiveValues(b.String(), v)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+type sanitizeTestConfig struct {
+	ConnectConfig `mapstructure:",squash"`
+
+	Nested *sanitizeTestNested  `mapstructure:"nested"`
+	List   []sanitizeTestNested `mapstructure:"list"`
+}
+
+type sanitizeTestNested struct {
+	AdminPassword *string `mapstructure:"admin_password"`
+	Name          string  `mapstructure:"name"`
+}
+
+func TestSensitiveValues(t *testing.T) {
+	nested := "nested-secret"
+	listed := "listed-secret"
+	c := sanitizeTestConfig{
+		ConnectConfig: ConnectConfig{
+			Username: "administrator@vsphere.local",
+			Password: `connect"secret`,
+		},
+		Nested: &sanitizeTestNested{AdminPassword: &nested, Name: "nested"},
+		List:   []sanitizeTestNested{{AdminPassword: &listed}, {Name: "empty"}},
+	}
+
+	values := SensitiveValues(&c)
+	if len(values) != 3 {
+		t.Fatalf("unexpected result: expected '3', but returned '%d': %v", len(values), values)
+	}
+
+	redacted := RedactSensitiveValues(fmt.Sprintf("%#v", c), c)
+	for _, secret := range []string{`connect\"secret`, nested, listed} {
+		if strings.Contains(redacted, secret) {
+			t.Fatalf("unexpected result: expected '%s' to be redacted: %s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, "administrator@vsphere.local") {
+		t.Fatalf("unexpected result: expected the username to be present: %s", redacted)
+	}
+}
+
+func TestGoString(t *testing.T) {
+	productKey := "XXXXX-XXXXX-XXXXX-XXXXX-XXXXX"
+	c := sanitizeTestConfig{
+		ConnectConfig: ConnectConfig{Username: "administrator@vsphere.local", Password: "connect-secret"},
+		Nested:        &sanitizeTestNested{Name: "nested"},
+	}
+	type customization struct {
+		ProductKey string `mapstructure:"product_key"`
+	}
+
+	s := GoString(c)
+	if !strings.HasPrefix(s, "common.sanitizeTestConfig{ConnectConfig:") {
+		t.Fatalf("unexpected result: %s", s)
+	}
+	if strings.Contains(s, "connect-secret") || !strings.Contains(s, "administrator@vsphere.local") {
+		t.Fatalf("unexpected result: expected the password to be redacted: %s", s)
+	}
+	if s := GoString(customization{ProductKey: productKey}); strings.Contains(s, productKey) {
+		t.Fatalf("unexpected result: expected the product key to be redacted: %s", s)
+	}
+}
+
+func TestRegisterSensitiveValues_RedactsLogs(t *testing.T) {
+	output := log.Writer()
+	defer log.SetOutput(output)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	c := sanitizeTestConfig{
+		ConnectConfig: ConnectConfig{Username: "administrator@vsphere.local", Password: "logged-secret"},
+	}
+	RegisterSensitiveValues(&c)
+	// The log output is wrapped only once.
+	RegisterSensitiveValues(&c)
+	if f, ok := log.Writer().(*logFilter); !ok || f.w != &buf {
+		t.Fatalf("unexpected result: expected the log output to be wrapped once")
+	}
+
+	log.Printf("Connecting as %s with password %s", c.Username, c.Password)
+	if strings.Contains(buf.String(), "logged-secret") {
+		t.Fatalf("unexpected result: expected the password to be redacted: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Connecting as administrator@vsphere.local with password <sensitive>") {
+		t.Fatalf("unexpected result: expected the message to be logged: %s", buf.String())
+	}
+}
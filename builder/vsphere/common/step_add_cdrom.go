@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type CDRomConfig
+//go:generate packer-sdc mapstructure-to-hcl2 -type CDRomConfig,CDRomDeviceConfig
 
 package common
 
@@ -53,6 +53,60 @@ type CDRomConfig struct {
 	// To determine the file name, view the datastore backing the content
 	// library or use the `govc` vSphere CLI.
 	ISOPaths []string `mapstructure:"iso_paths"`
+	// A list of ISO files to mount on CD-ROM devices at a specific controller
+	// and unit number, such as to mount the installation media on an IDE
+	// controller and the drivers on a SATA controller. Refer to the
+	// [CD-ROM device configuration](#cd-rom-device-configuration) for more
+	// information.
+	//
+	// -> **Note:** These CD-ROM devices are added before the CD-ROM devices of
+	// `iso_url`, `iso_paths`, and `cd_files`. If `reattach_cdroms` is set, the
+	// CD-ROM devices are reattached at the controller and unit numbers of the
+	// first `reattach_cdroms` entries.
+	CDRoms []CDRomDeviceConfig `mapstructure:"cdroms"`
+}
+
+// The CD-ROM device configuration mounts an ISO file on a CD-ROM device at a
+// specific controller and unit number.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	cdroms {
+//	  path            = "[datastore1] iso/windows-server-2022.iso"
+//	  controller_type = "ide"
+//	  unit_number     = 0
+//	}
+//	cdroms {
+//	  path            = "[datastore1] iso/virtio-win.iso"
+//	  controller_type = "sata"
+//	}
+//
+// ```
+type CDRomDeviceConfig struct {
+	// The path to the ISO file in either a datastore or a content library.
+	Path string `mapstructure:"path" required:"true"`
+	// The type of controller for the CD-ROM device. Defaults to `cdrom_type`.
+	//
+	// The available options for this setting are: `ide` and `sata`.
+	ControllerType string `mapstructure:"controller_type"`
+	// The bus number of the controller. The range is 0 - 1 for `ide` and
+	// 0 - 3 for `sata`. Defaults to `0`. A SATA controller with the bus
+	// number is added if it does not exist.
+	ControllerIndex int32 `mapstructure:"controller_index"`
+	// The unit number of the CD-ROM device on the controller. The range is
+	// 0 - 1 for `ide` and 0 - 29 for `sata`. Defaults to `0`.
+	UnitNumber int32 `mapstructure:"unit_number"`
+}
+
+// placement returns the controller and unit number of the CD-ROM device.
+func (c *CDRomDeviceConfig) placement() *driver.CdromPlacement {
+	return &driver.CdromPlacement{
+		ControllerType:  c.ControllerType,
+		ControllerIndex: c.ControllerIndex,
+		UnitNumber:      c.UnitNumber,
+	}
 }
 
 type StepAddCDRom struct {
@@ -67,6 +121,42 @@ func (c *CDRomConfig) Prepare(k *ReattachCDRomConfig) []error {
 		errs = append(errs, fmt.Errorf("'cdrom_type' must be 'ide' or 'sata'"))
 	}
 
+	placements := make(map[driver.CdromPlacement]bool)
+	for i := range c.CDRoms {
+		cdrom := &c.CDRoms[i]
+		if cdrom.Path == "" {
+			errs = append(errs, fmt.Errorf("'cdroms[%d]' 'path' is required", i))
+		}
+		if cdrom.ControllerType == "" {
+			cdrom.ControllerType = c.CdromType
+		}
+		if cdrom.ControllerType == "" {
+			cdrom.ControllerType = "ide"
+		}
+
+		var maxIndex, maxUnit int32
+		switch cdrom.ControllerType {
+		case "ide":
+			maxIndex, maxUnit = 1, 1
+		case "sata":
+			maxIndex, maxUnit = 3, 29
+		default:
+			errs = append(errs, fmt.Errorf("'cdroms[%d]' 'controller_type' must be 'ide' or 'sata'", i))
+			continue
+		}
+		if cdrom.ControllerIndex < 0 || cdrom.ControllerIndex > maxIndex {
+			errs = append(errs, fmt.Errorf("'cdroms[%d]' 'controller_index' must be between 0 and %d for '%s'", i, maxIndex, cdrom.ControllerType))
+		}
+		if cdrom.UnitNumber < 0 || cdrom.UnitNumber > maxUnit {
+			errs = append(errs, fmt.Errorf("'cdroms[%d]' 'unit_number' must be between 0 and %d for '%s'", i, maxUnit, cdrom.ControllerType))
+		}
+		if p := *cdrom.placement(); placements[p] {
+			errs = append(errs, fmt.Errorf("'cdroms[%d]' is a duplicate of the controller and unit number of another CD-ROM device", i))
+		} else {
+			placements[p] = true
+		}
+	}
+
 	// `reattach_cdroms` should be between 1 and 4 to keep the CD-ROM devices
 	// without any attached media. If `reattach_cdroms` is set to 0, it is
 	// ignored and the step is skipped.
@@ -96,6 +186,14 @@ func (s *StepAddCDRom) Run(_ context.Context, state multistep.StateBag) multiste
 		}
 	}
 
+	for _, cdrom := range s.Config.CDRoms {
+		ui.Sayf("Mounting ISO image '%s' on %s controller %d unit %d...", cdrom.Path, cdrom.ControllerType, cdrom.ControllerIndex, cdrom.UnitNumber)
+		if err := vm.AddCdromAt(cdrom.placement(), cdrom.Path); err != nil {
+			state.Put("error", fmt.Errorf("error mounting an image '%v': %v", cdrom.Path, err))
+			return multistep.ActionHalt
+		}
+	}
+
 	if path, ok := state.GetOk("iso_remote_path"); ok {
 		// The order matters: docs say "iso_url" should go first, so make sure
 		// to prepend it.
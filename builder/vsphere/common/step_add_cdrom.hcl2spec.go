@@ -10,8 +10,9 @@ import (
 // FlatCDRomConfig is an auto-generated flat version of CDRomConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatCDRomConfig struct {
-	CdromType *string  `mapstructure:"cdrom_type" cty:"cdrom_type" hcl:"cdrom_type"`
-	ISOPaths  []string `mapstructure:"iso_paths" cty:"iso_paths" hcl:"iso_paths"`
+	CdromType *string                 `mapstructure:"cdrom_type" cty:"cdrom_type" hcl:"cdrom_type"`
+	ISOPaths  []string                `mapstructure:"iso_paths" cty:"iso_paths" hcl:"iso_paths"`
+	CDRoms    []FlatCDRomDeviceConfig `mapstructure:"cdroms" cty:"cdroms" hcl:"cdroms"`
 }
 
 // FlatMapstructure returns a new FlatCDRomConfig.
@@ -28,6 +29,36 @@ func (*FlatCDRomConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
 		"cdrom_type": &hcldec.AttrSpec{Name: "cdrom_type", Type: cty.String, Required: false},
 		"iso_paths":  &hcldec.AttrSpec{Name: "iso_paths", Type: cty.List(cty.String), Required: false},
+		"cdroms":     &hcldec.BlockListSpec{TypeName: "cdroms", Nested: hcldec.ObjectSpec((*FlatCDRomDeviceConfig)(nil).HCL2Spec())},
+	}
+	return s
+}
+
+// FlatCDRomDeviceConfig is an auto-generated flat version of CDRomDeviceConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatCDRomDeviceConfig struct {
+	Path            *string `mapstructure:"path" required:"true" cty:"path" hcl:"path"`
+	ControllerType  *string `mapstructure:"controller_type" cty:"controller_type" hcl:"controller_type"`
+	ControllerIndex *int32  `mapstructure:"controller_index" cty:"controller_index" hcl:"controller_index"`
+	UnitNumber      *int32  `mapstructure:"unit_number" cty:"unit_number" hcl:"unit_number"`
+}
+
+// FlatMapstructure returns a new FlatCDRomDeviceConfig.
+// FlatCDRomDeviceConfig is an auto-generated flat version of CDRomDeviceConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*CDRomDeviceConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatCDRomDeviceConfig)
+}
+
+// HCL2Spec returns the hcl spec of a CDRomDeviceConfig.
+// This spec is used by HCL to read the fields of CDRomDeviceConfig.
+// The decoded values from this spec will then be applied to a FlatCDRomDeviceConfig.
+func (*FlatCDRomDeviceConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"path":             &hcldec.AttrSpec{Name: "path", Type: cty.String, Required: false},
+		"controller_type":  &hcldec.AttrSpec{Name: "controller_type", Type: cty.String, Required: false},
+		"controller_index": &hcldec.AttrSpec{Name: "controller_index", Type: cty.Number, Required: false},
+		"unit_number":      &hcldec.AttrSpec{Name: "unit_number", Type: cty.Number, Required: false},
 	}
 	return s
 }
@@ -52,6 +52,49 @@ func TestCDRomConfig_Prepare(t *testing.T) {
 			fail:           true,
 			expectedErrMsg: "'cdrom_type' must be 'ide' or 'sata'",
 		},
+		{
+			name: "Valid cdroms",
+			config: &CDRomConfig{
+				CDRoms: []CDRomDeviceConfig{
+					{Path: "[datastore] /iso/windows.iso", ControllerType: "ide"},
+					{Path: "[datastore] /iso/drivers.iso", ControllerType: "sata", ControllerIndex: 3, UnitNumber: 29},
+				},
+			},
+			keepConfig:     new(ReattachCDRomConfig),
+			fail:           false,
+			expectedErrMsg: "",
+		},
+		{
+			name: "Missing cdroms path",
+			config: &CDRomConfig{
+				CDRoms: []CDRomDeviceConfig{{ControllerType: "ide"}},
+			},
+			keepConfig:     new(ReattachCDRomConfig),
+			fail:           true,
+			expectedErrMsg: "'cdroms[0]' 'path' is required",
+		},
+		{
+			name: "Invalid cdroms unit number",
+			config: &CDRomConfig{
+				CDRoms: []CDRomDeviceConfig{{Path: "[datastore] /iso/windows.iso", UnitNumber: 2}},
+			},
+			keepConfig:     new(ReattachCDRomConfig),
+			fail:           true,
+			expectedErrMsg: "'cdroms[0]' 'unit_number' must be between 0 and 1 for 'ide'",
+		},
+		{
+			name: "Duplicate cdroms placement",
+			config: &CDRomConfig{
+				CdromType: "sata",
+				CDRoms: []CDRomDeviceConfig{
+					{Path: "[datastore] /iso/windows.iso", UnitNumber: 1},
+					{Path: "[datastore] /iso/drivers.iso", ControllerType: "sata", UnitNumber: 1},
+				},
+			},
+			keepConfig:     new(ReattachCDRomConfig),
+			fail:           true,
+			expectedErrMsg: "'cdroms[1]' is a duplicate of the controller and unit number of another CD-ROM device",
+		},
 	}
 
 	for _, c := range tc {
@@ -103,6 +146,33 @@ func TestStepAddCDRom_Run(t *testing.T) {
 			fail:       false,
 			errMessage: "",
 		},
+		{
+			name:  "Mount cdroms before iso paths",
+			state: isoRemotePathStateBag(),
+			step: &StepAddCDRom{
+				Config: &CDRomConfig{
+					CDRoms: []CDRomDeviceConfig{
+						{Path: "iso/windows", ControllerType: "ide"},
+						{Path: "iso/drivers", ControllerType: "sata", ControllerIndex: 1, UnitNumber: 2},
+					},
+				},
+			},
+			vmMock:         new(driver.VirtualMachineMock),
+			expectedAction: multistep.ActionContinue,
+			expectedVmMock: &driver.VirtualMachineMock{
+				AddCdromAtPlacements: []*driver.CdromPlacement{
+					{ControllerType: "ide"},
+					{ControllerType: "sata", ControllerIndex: 1, UnitNumber: 2},
+				},
+				AddCdromAtPaths:     []string{"iso/windows", "iso/drivers"},
+				AddCdromCalledTimes: 1,
+				AddCdromTypes:       []string{""},
+				AddCdromPaths:       []string{"remote/path"},
+				CdromDevicesList:    object.VirtualDeviceList{nil, nil, nil},
+			},
+			fail:       false,
+			errMessage: "",
+		},
 		{
 			name:  "Add SATA Controller",
 			state: basicStateBag(nil),
@@ -63,7 +63,7 @@ func (s *StepAddFloppy) Run(_ context.Context, state multistep.StateBag) multist
 	if floppyPath, ok := state.GetOk("floppy_path"); ok {
 		ui.Say("Uploading floppy image...")
 
-		ds, err := d.FindDatastore(s.Datastore, s.Host)
+		ds, err := d.FindDatastore(datastoreName(state, s.Datastore), s.Host)
 		if err != nil {
 			state.Put("error", err)
 			return multistep.ActionHalt
@@ -123,7 +123,7 @@ func (s *StepAddFloppy) Cleanup(state multistep.StateBag) {
 	if UploadedFloppyPath, ok := state.GetOk("uploaded_floppy_path"); ok {
 		ui.Say("Deleting floppy image...")
 
-		ds, err := d.FindDatastore(s.Datastore, s.Host)
+		ds, err := d.FindDatastore(datastoreName(state, s.Datastore), s.Host)
 		if err != nil {
 			state.Put("error", err)
 			return
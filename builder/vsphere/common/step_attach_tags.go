@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type TagsConfig,TagConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The following example attaches two tags to the virtual machine or
+// template:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	tags {
+//	  category = "os"
+//	  name     = "linux"
+//	}
+//	tags {
+//	  category = "environment"
+//	  name     = "production"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"tags": [
+//	  {
+//	    "category": "os",
+//	    "name": "linux"
+//	  },
+//	  {
+//	    "category": "environment",
+//	    "name": "production"
+//	  }
+//	],
+//
+// ```
+type TagConfig struct {
+	// The name of the tag category.
+	Category string `mapstructure:"category" required:"true"`
+	// The name of the tag in the category.
+	Name string `mapstructure:"name" required:"true"`
+}
+
+type TagsConfig struct {
+	// The tags to attach to the virtual machine or template after the build
+	// completes. Refer to the [Tag Configuration](#tag-configuration) section
+	// for additional information. Tags are attached after the virtual
+	// machine is converted to a template, if `convert_to_template` is set.
+	Tags []TagConfig `mapstructure:"tags"`
+	// Create the tag categories and tags in `tags` that do not exist.
+	// Created categories allow multiple tags per object and are associable
+	// with virtual machines. Requires the privileges to create categories
+	// and tags. Defaults to `false`.
+	CreateTags bool `mapstructure:"create_tags"`
+}
+
+func (c *TagsConfig) Prepare() []error {
+	var errs []error
+	for i, tag := range c.Tags {
+		if tag.Category == "" {
+			errs = append(errs, fmt.Errorf("tags[%d].'category' is required", i))
+		}
+		if tag.Name == "" {
+			errs = append(errs, fmt.Errorf("tags[%d].'name' is required", i))
+		}
+	}
+	if c.CreateTags && len(c.Tags) == 0 {
+		errs = append(errs, fmt.Errorf("'create_tags' requires 'tags'"))
+	}
+	return errs
+}
+
+type StepAttachTags struct {
+	Config *TagsConfig
+}
+
+// Run resolves the tags, creating them if allowed, and attaches the tags
+// that are not already attached to the virtual machine. Tags inherited from
+// the source of a clone are left attached.
+func (s *StepAttachTags) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Config.Tags) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Attaching tags to the virtual machine...")
+	attached, err := vm.Tags()
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving the tags of the virtual machine: %s", err))
+		return multistep.ActionHalt
+	}
+
+	for _, tag := range s.Config.Tags {
+		id, err := d.FindOrCreateTag(tag.Category, tag.Name, s.Config.CreateTags)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		if slices.Contains(attached, id) {
+			continue
+		}
+		if err := vm.AttachTag(id); err != nil {
+			state.Put("error", fmt.Errorf("error attaching tag %s/%s: %s", tag.Category, tag.Name, err))
+			return multistep.ActionHalt
+		}
+		attached = append(attached, id)
+		ui.Sayf("Attached tag %s/%s.", tag.Category, tag.Name)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepAttachTags) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestTagsConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name         string
+		config       *TagsConfig
+		expectedErrs []string
+	}{
+		{
+			name:   "No tags",
+			config: &TagsConfig{},
+		},
+		{
+			name:   "Tags",
+			config: &TagsConfig{Tags: []TagConfig{{Category: "os", Name: "linux"}}, CreateTags: true},
+		},
+		{
+			name:   "Missing category and name",
+			config: &TagsConfig{Tags: []TagConfig{{Category: "os"}, {Name: "linux"}}},
+			expectedErrs: []string{
+				"tags[0].'name' is required",
+				"tags[1].'category' is required",
+			},
+		},
+		{
+			name:         "Create without tags",
+			config:       &TagsConfig{CreateTags: true},
+			expectedErrs: []string{"'create_tags' requires 'tags'"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var messages []string
+			for _, err := range c.config.Prepare() {
+				messages = append(messages, err.Error())
+			}
+			if diff := cmp.Diff(c.expectedErrs, messages); diff != "" {
+				t.Fatalf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepAttachTags_Run(t *testing.T) {
+	d := driver.NewDriverMock()
+	d.FindOrCreateTagResult = map[string]string{"os/linux": "tag-1", "environment/production": "tag-2"}
+	vm := new(driver.VirtualMachineMock)
+	vm.TagsReturn = []string{"tag-1"}
+
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	state.Put("vm", vm)
+	step := &StepAttachTags{Config: &TagsConfig{
+		Tags: []TagConfig{
+			{Category: "os", Name: "linux"},
+			{Category: "environment", Name: "production"},
+		},
+		CreateTags: true,
+	}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	if diff := cmp.Diff([]string{"os/linux", "environment/production"}, d.FindOrCreateTagNames); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+	if !d.FindOrCreateTagCreate {
+		t.Fatal("unexpected result: expected tags to be created")
+	}
+	if diff := cmp.Diff([]string{"tag-2"}, vm.AttachTagIDs); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+}
+
+func TestStepAttachTags_RunError(t *testing.T) {
+	d := driver.NewDriverMock()
+	d.FindOrCreateTagErr = fmt.Errorf("error retrieving tag category os: 404 Not Found")
+	vm := new(driver.VirtualMachineMock)
+
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	state.Put("vm", vm)
+	step := &StepAttachTags{Config: &TagsConfig{Tags: []TagConfig{{Category: "os", Name: "linux"}}}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	err, ok := state.Get("error").(error)
+	if !ok {
+		t.Fatal("unexpected success: expected failure")
+	}
+	if err != d.FindOrCreateTagErr {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", d.FindOrCreateTagErr, err)
+	}
+	if len(vm.AttachTagIDs) != 0 {
+		t.Fatalf("unexpected result: expected no tags attached, but attached '%v'", vm.AttachTagIDs)
+	}
+}
@@ -2,6 +2,7 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type BootCommandEntry,BootKeyboardConfig
 package common
 
 import (
@@ -9,6 +10,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/bootcommand"
@@ -22,8 +26,124 @@ import (
 
 type BootConfig struct {
 	bootcommand.BootConfig `mapstructure:",squash"`
+	BootCommandsConfig     `mapstructure:",squash"`
+	BootKeyboardConfig     `mapstructure:",squash"`
 	// The IP address to use for the HTTP server to serve the `http_directory`.
 	HTTPIP string `mapstructure:"http_ip"`
+	// How to discover the IP address of the HTTP server if `http_ip`,
+	// `http_bind_address`, and `http_interface` are not set. Defaults to
+	// `interfaces`.
+	//
+	// The available options for this setting are:
+	//
+	// - `interfaces` - The first address of the Packer host in the
+	//   `ip_wait_address` range is used, or the first IPv4 address if none
+	//   is in the range.
+	// - `host` - The address of the Packer host that is used to reach the
+	//   ESXi host of the virtual machine is used, as selected by the routing
+	//   table of the Packer host. Use this option if the Packer host has
+	//   multiple network interfaces, such as the interface of a VPN, and the
+	//   network of the virtual machine is routed like the management network
+	//   of the ESXi host.
+	HTTPIPDiscovery string `mapstructure:"http_ip_discovery"`
+}
+
+// The available methods to discover the IP address of the HTTP server.
+const (
+	HTTPIPDiscoveryInterfaces = "interfaces"
+	HTTPIPDiscoveryHost       = "host"
+)
+
+// Boot commands can be typed at later stages of the boot, such as after an
+// installer reboots the virtual machine one or more times. Each entry in
+// `boot_commands` is typed after the `boot_command` and the previous entries,
+// once the virtual machine reaches the specified boot.
+//
+// Reboots are detected from the power on, reset, and guest reboot events of
+// the virtual machine, from a change of its power on time, and from VMware
+// Tools stopping and starting again in the guest operating system.
+//
+// -> **Note:** A reboot that is initiated in the guest operating system, such
+// as by an installer, does not raise an event or change the power on time of
+// the virtual machine. It is detected only if VMware Tools runs in the guest
+// operating system before and after the reboot. For the reboots of an
+// installer that runs without VMware Tools, use `console_contains` with
+// `serial_log` to wait for the console output of the boot instead.
+type BootCommandsConfig struct {
+	// A list of boot commands to type at later stages of the boot.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	// boot_commands {
+	//   after_boot = 3
+	//   wait       = "30s"
+	//   command    = ["<leftShiftOn><f10><leftShiftOff>", "OOBE\\BYPASSNRO<enter>"]
+	// }
+	// ```
+	BootCommands []BootCommandEntry `mapstructure:"boot_commands"`
+}
+
+type BootCommandEntry struct {
+	// The keys to type. The syntax is the same as `boot_command`.
+	Command []string `mapstructure:"command" required:"true"`
+	// The boot of the virtual machine after which the command is typed, where
+	// `1` is the initial power on and `2` is the first reboot. Defaults to `1`.
+	AfterBoot int `mapstructure:"after_boot"`
+	// The amount of time to wait after the boot is detected, or after the
+	// previous command if the boot has already been detected, before the
+	// command is typed. Defaults to the value of `boot_wait`.
+	Wait time.Duration `mapstructure:"wait"`
+	// Type the command once the serial console output of the guest operating
+	// system contains this text, such as a message of a later boot stage.
+	// Checked after `after_boot` is reached. Requires `serial_log`.
+	ConsoleContains string `mapstructure:"console_contains"`
+	// The amount of time to wait for the boot and the console output before
+	// the build fails. Defaults to `60m` (60 minutes).
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// The keys of the boot commands are typed on the USB keyboard of the virtual
+// machine by default. On a busy host, keys can be dropped by the virtual
+// machine, which can be addressed with a longer interval between the keys or
+// with the keyboard of the WebMKS console, which is the console of the web
+// client.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	boot_keyboard     = "webmks"
+//	boot_key_interval = "200ms"
+//	boot_key_retries  = 3
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"boot_keyboard": "webmks",
+//	"boot_key_interval": "200ms",
+//	"boot_key_retries": 3,
+//
+// ```
+type BootKeyboardConfig struct {
+	// The keyboard to type the boot commands on. The available options are
+	// `usb`, which sends USB scan codes to the virtual machine and verifies
+	// that the virtual machine received each key, and `webmks`, which types
+	// the keys on the WebMKS console of the virtual machine. The WebMKS
+	// console requires a network connection from Packer to port 443 of the
+	// ESXi host of the virtual machine. Defaults to `usb`.
+	BootKeyboard string `mapstructure:"boot_keyboard"`
+	// The amount of time to wait between keys. Defaults to the value of
+	// `boot_keygroup_interval` for the `usb` keyboard and to `100ms` for the
+	// `webmks` keyboard.
+	BootKeyInterval time.Duration `mapstructure:"boot_key_interval"`
+	// The number of times to retry a key that fails or that is not received
+	// by the virtual machine. For the `webmks` keyboard, the console is
+	// reconnected before a retry. Defaults to `1`.
+	BootKeyRetries int `mapstructure:"boot_key_retries"`
 }
 
 type bootCommandTemplateData struct {
@@ -37,7 +157,82 @@ func (c *BootConfig) Prepare(ctx *interpolate.Context) []error {
 		c.BootWait = 10 * time.Second
 	}
 
-	return c.BootConfig.Prepare(ctx)
+	errs := c.BootConfig.Prepare(ctx)
+
+	if c.BootKeyboard == "" {
+		c.BootKeyboard = "usb"
+	}
+	if c.BootKeyboard != "usb" && c.BootKeyboard != "webmks" {
+		errs = append(errs, fmt.Errorf("'boot_keyboard' must be one of 'usb' or 'webmks'"))
+	}
+	if c.BootKeyInterval < 0 {
+		errs = append(errs, fmt.Errorf("'boot_key_interval' must be greater than or equal to 0"))
+	}
+	if c.BootKeyInterval == 0 {
+		if c.BootKeyboard == "webmks" {
+			c.BootKeyInterval = 100 * time.Millisecond
+		} else {
+			c.BootKeyInterval = c.BootGroupInterval
+		}
+	}
+	if c.BootKeyRetries < 0 {
+		errs = append(errs, fmt.Errorf("'boot_key_retries' must be greater than or equal to 0"))
+	}
+	if c.BootKeyRetries == 0 {
+		c.BootKeyRetries = 1
+	}
+
+	switch c.HTTPIPDiscovery {
+	case "":
+		c.HTTPIPDiscovery = HTTPIPDiscoveryInterfaces
+	case HTTPIPDiscoveryInterfaces:
+	case HTTPIPDiscoveryHost:
+		if c.HTTPIP != "" {
+			errs = append(errs, fmt.Errorf("'http_ip_discovery' cannot be used with 'http_ip'"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("'http_ip_discovery' must be one of '%s' or '%s'", HTTPIPDiscoveryInterfaces, HTTPIPDiscoveryHost))
+	}
+
+	for i := range c.BootCommands {
+		entry := &c.BootCommands[i]
+		if len(entry.Command) == 0 {
+			errs = append(errs, fmt.Errorf("boot_commands[%d].'command' is required", i))
+		}
+		if entry.AfterBoot < 0 {
+			errs = append(errs, fmt.Errorf("boot_commands[%d].'after_boot' must be greater than or equal to 1", i))
+		}
+		if entry.AfterBoot == 0 {
+			entry.AfterBoot = 1
+		}
+		if entry.Wait < 0 {
+			errs = append(errs, fmt.Errorf("boot_commands[%d].'wait' must be greater than or equal to 0", i))
+		}
+		if entry.Wait == 0 {
+			entry.Wait = c.BootWait
+		}
+		if entry.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("boot_commands[%d].'timeout' must be greater than or equal to 0", i))
+		}
+		if entry.Timeout == 0 {
+			entry.Timeout = 60 * time.Minute
+		}
+	}
+
+	return errs
+}
+
+// PrepareSerialLog validates the boot commands that wait for the serial
+// console output of the guest operating system.
+func (c *BootCommandsConfig) PrepareSerialLog(serial *SerialLogConfig) []error {
+	var errs []error
+
+	for i, entry := range c.BootCommands {
+		if entry.ConsoleContains != "" && !serial.SerialLog {
+			errs = append(errs, fmt.Errorf("boot_commands[%d].'console_contains' requires 'serial_log'", i))
+		}
+	}
+	return errs
 }
 
 type StepBootCommand struct {
@@ -51,12 +246,29 @@ func (s *StepBootCommand) Run(ctx context.Context, state multistep.StateBag) mul
 	ui := state.Get("ui").(packersdk.Ui)
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
 
-	if s.Config.BootCommand == nil {
+	if s.Config.BootCommand == nil && len(s.Config.BootCommands) == 0 {
 		return multistep.ActionContinue
 	}
 
+	s.Ctx = WithVSphereFunc(s.Ctx, &VSphereTemplateValues{
+		Driver: state.Get("driver").(driver.Driver),
+		VM:     vm,
+	})
+
+	var boots *bootCounter
+	if len(s.Config.BootCommands) > 0 {
+		var err error
+		boots, err = newBootCounter(vm)
+		if err != nil {
+			err := fmt.Errorf("error retrieving the boot state of the virtual machine: %s", err)
+			state.Put("error", err)
+			ui.Errorf("%s", err)
+			return multistep.ActionHalt
+		}
+	}
+
 	// Wait the for the vm to boot.
-	if int64(s.Config.BootWait) > 0 {
+	if s.Config.BootCommand != nil && int64(s.Config.BootWait) > 0 {
 		ui.Sayf("Waiting %s for boot...", s.Config.BootWait.String())
 		select {
 		case <-time.After(s.Config.BootWait):
@@ -131,77 +343,359 @@ func (s *StepBootCommand) Run(ctx context.Context, state multistep.StateBag) mul
 		ui.Sayf("Serving HTTP requests at http://%v:%v/.", ip, port)
 	}
 
-	var keyAlt, keyCtrl, keyShift bool
-	sendCodes := func(code key.Code, down bool) error {
-		switch code {
-		case key.CodeLeftAlt:
-			keyAlt = down
-		case key.CodeLeftControl:
-			keyCtrl = down
-		case key.CodeLeftShift:
-			keyShift = down
+	var d bootcommand.BCDriver
+	if s.Config.BootKeyboard == "webmks" {
+		keyboard := &webmksKeyboard{
+			dial: func() (*driver.WebMKS, error) {
+				ticket, err := vm.AcquireWebMKSTicket()
+				if err != nil {
+					return nil, fmt.Errorf("error acquiring WebMKS ticket: %s", err)
+				}
+				return driver.DialWebMKS(ctx, ticket)
+			},
+			ui:       ui,
+			retries:  s.Config.BootKeyRetries,
+			interval: s.Config.BootKeyInterval,
+		}
+		defer keyboard.Close()
+		d = bootcommand.NewVNCDriver(keyboard, s.Config.BootKeyInterval)
+	} else {
+		keyboard := &usbKeyboard{
+			typeOnKeyboard: vm.TypeOnKeyboard,
+			ui:             ui,
+			retries:        s.Config.BootKeyRetries,
+			interval:       s.Config.BootKeyInterval,
+		}
+		d = bootcommand.NewUSBDriver(keyboard.sendCodes, s.Config.BootKeyInterval)
+	}
+
+	typeBootCommand := func(flatBootCommand string) (string, error) {
+		command, err := interpolate.Render(flatBootCommand, &s.Ctx)
+		if err != nil {
+			return "", fmt.Errorf("error preparing boot command: %s", err)
+		}
+
+		seq, err := bootcommand.GenerateExpressionSequence(command)
+		if err != nil {
+			return "", fmt.Errorf("error generating boot command: %s", err)
 		}
 
-		shift := down
-		if keyShift {
-			shift = keyShift
+		if err := seq.Do(ctx, d); err != nil {
+			return "", fmt.Errorf("error running boot command: %s", err)
 		}
+		return command, nil
+	}
 
-		_, err := vm.TypeOnKeyboard(driver.KeyInput{
-			Scancode: code,
-			Ctrl:     keyCtrl,
-			Alt:      keyAlt,
-			Shift:    shift,
-		})
+	if s.Config.BootCommand != nil {
+		ui.Say("Typing boot command...")
+		command, err := typeBootCommand(s.Config.FlatBootCommand())
 		if err != nil {
-			// retry once if error
-			ui.Errorf("error typing a boot command (code, down) `%d, %t`: %v", code, down, err)
-			ui.Say("Trying boot command again...")
-			time.Sleep(s.Config.BootGroupInterval)
-			_, err = vm.TypeOnKeyboard(driver.KeyInput{
-				Scancode: code,
-				Ctrl:     keyCtrl,
-				Alt:      keyAlt,
-				Shift:    shift,
-			})
+			state.Put("error", err)
+			ui.Errorf("%s", err)
+			return multistep.ActionHalt
+		}
+
+		if pauseFn != nil {
+			pauseFn(multistep.DebugLocationAfterRun, fmt.Sprintf("boot_command: %s", command), state)
+		}
+	}
+
+	for i, entry := range s.Config.BootCommands {
+		if err := boots.waitFor(ctx, ui, entry.AfterBoot, entry.Timeout); err != nil {
+			err := fmt.Errorf("error waiting for boot %d for boot_commands[%d]: %s", entry.AfterBoot, i, err)
+			state.Put("error", err)
+			ui.Errorf("%s", err)
+			return multistep.ActionHalt
+		}
+		if entry.ConsoleContains != "" {
+			read, err := serialLogReader(state)
+			if err == nil {
+				err = waitForConsole(ctx, ui, read, entry.ConsoleContains, entry.Timeout)
+			}
 			if err != nil {
-				return fmt.Errorf("error typing a boot command (code, down) `%d, %t`: %w", code, down, err)
+				err := fmt.Errorf("error waiting for the console output for boot_commands[%d]: %s", i, err)
+				state.Put("error", err)
+				ui.Errorf("%s", err)
+				return multistep.ActionHalt
 			}
 		}
-		return nil
+
+		ui.Sayf("Waiting %s before typing boot command %d...", entry.Wait.String(), i+1)
+		select {
+		case <-time.After(entry.Wait):
+		case <-ctx.Done():
+			return multistep.ActionHalt
+		}
+
+		ui.Sayf("Typing boot command %d...", i+1)
+		command, err := typeBootCommand(strings.Join(entry.Command, ""))
+		if err != nil {
+			state.Put("error", err)
+			ui.Errorf("%s", err)
+			return multistep.ActionHalt
+		}
+
+		if pauseFn != nil {
+			pauseFn(multistep.DebugLocationAfterRun, fmt.Sprintf("boot_commands[%d]: %s", i, command), state)
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+type usbKeyboard struct {
+	typeOnKeyboard func(driver.KeyInput) (int32, error)
+	ui             packersdk.Ui
+	retries        int
+	interval       time.Duration
+
+	alt, ctrl, shift bool
+}
+
+// sendCodes types the key with a USB scan code. The virtual machine returns
+// the number of key events that it received, so that a dropped key is retried.
+func (k *usbKeyboard) sendCodes(code key.Code, down bool) error {
+	switch code {
+	case key.CodeLeftAlt:
+		k.alt = down
+	case key.CodeLeftControl:
+		k.ctrl = down
+	case key.CodeLeftShift:
+		k.shift = down
+	}
+
+	shift := down
+	if k.shift {
+		shift = k.shift
+	}
+	input := driver.KeyInput{
+		Scancode: code,
+		Ctrl:     k.ctrl,
+		Alt:      k.alt,
+		Shift:    shift,
+	}
+
+	for attempt := 0; ; attempt++ {
+		count, err := k.typeOnKeyboard(input)
+		if err == nil && count == 0 {
+			err = fmt.Errorf("key not received by the virtual machine")
+		}
+		if err == nil {
+			return nil
+		}
+		if attempt >= k.retries {
+			return fmt.Errorf("error typing a boot command (code, down) `%d, %t`: %w", code, down, err)
+		}
+		k.ui.Errorf("error typing a boot command (code, down) `%d, %t`: %v", code, down, err)
+		k.ui.Say("Trying boot command again...")
+		time.Sleep(k.interval)
+	}
+}
+
+type webmksKeyboard struct {
+	dial     func() (*driver.WebMKS, error)
+	ui       packersdk.Ui
+	retries  int
+	interval time.Duration
+
+	conn *driver.WebMKS
+}
+
+// KeyEvent types the key with an X11 keysym on the WebMKS console. The console
+// is connected on the first key, and reconnected with a new ticket when a key
+// fails.
+func (k *webmksKeyboard) KeyEvent(keysym uint32, down bool) error {
+	for attempt := 0; ; attempt++ {
+		err := k.keyEvent(keysym, down)
+		if err == nil {
+			return nil
+		}
+		k.Close()
+		if attempt >= k.retries {
+			return fmt.Errorf("error typing a boot command (keysym, down) `%d, %t`: %w", keysym, down, err)
+		}
+		k.ui.Errorf("error typing a boot command (keysym, down) `%d, %t`: %v", keysym, down, err)
+		k.ui.Say("Trying boot command again...")
+		time.Sleep(k.interval)
+	}
+}
+
+func (k *webmksKeyboard) keyEvent(keysym uint32, down bool) error {
+	if k.conn == nil {
+		conn, err := k.dial()
+		if err != nil {
+			return err
+		}
+		k.conn = conn
 	}
-	d := bootcommand.NewUSBDriver(sendCodes, s.Config.BootGroupInterval)
+	return k.conn.KeyEvent(keysym, down)
+}
+
+// Close closes the connection to the console, if any.
+func (k *webmksKeyboard) Close() {
+	if k.conn != nil {
+		_ = k.conn.Close()
+		k.conn = nil
+	}
+}
+
+// The interval between checks for a reboot of the virtual machine and for the
+// console output of the guest operating system.
+var bootPollInterval = 5 * time.Second
+
+// bootStateSource is the part of a virtual machine that is used to count its
+// boots.
+type bootStateSource interface {
+	BootEvents(afterKey int32) (int, int32, error)
+	BootState() (*driver.BootState, error)
+}
+
+type bootCounter struct {
+	vm       bootStateSource
+	boots    int
+	eventKey int32
+	bootTime *time.Time
+
+	// Whether VMware Tools was running at the last update, whether it has
+	// run since the counter was created, and whether a boot was counted
+	// since it stopped.
+	toolsRunning bool
+	toolsSeen    bool
+	bootCounted  bool
+}
 
-	ui.Say("Typing boot command...")
-	flatBootCommand := s.Config.FlatBootCommand()
-	command, err := interpolate.Render(flatBootCommand, &s.Ctx)
+// newBootCounter returns a counter of the boots of a powered on virtual
+// machine, starting at the first boot.
+func newBootCounter(vm bootStateSource) (*bootCounter, error) {
+	_, eventKey, err := vm.BootEvents(0)
 	if err != nil {
-		err := fmt.Errorf("error preparing boot command: %s", err)
-		state.Put("error", err)
-		ui.Errorf("%s", err)
-		return multistep.ActionHalt
+		return nil, err
+	}
+	bootState, err := vm.BootState()
+	if err != nil {
+		return nil, err
 	}
+	return &bootCounter{
+		vm:           vm,
+		boots:        1,
+		eventKey:     eventKey,
+		bootTime:     bootState.BootTime,
+		toolsRunning: bootState.ToolsRunning,
+		toolsSeen:    bootState.ToolsRunning,
+	}, nil
+}
 
-	seq, err := bootcommand.GenerateExpressionSequence(command)
+// update counts the boots since the last update. The boot events and a
+// change of the power on time are counted first. VMware Tools starting again
+// after it stopped is counted as a reboot of the guest operating system,
+// unless the reboot was already counted from an event.
+func (b *bootCounter) update() error {
+	count, eventKey, err := b.vm.BootEvents(b.eventKey)
 	if err != nil {
-		err := fmt.Errorf("error generating boot command: %s", err)
-		state.Put("error", err)
-		ui.Errorf("%s", err)
-		return multistep.ActionHalt
+		return err
+	}
+	bootState, err := b.vm.BootState()
+	if err != nil {
+		return err
 	}
 
-	if err := seq.Do(ctx, d); err != nil {
-		err := fmt.Errorf("error running boot command: %s", err)
-		state.Put("error", err)
-		ui.Errorf("%s", err)
-		return multistep.ActionHalt
+	if count == 0 && bootState.BootTime != nil && (b.bootTime == nil || bootState.BootTime.After(*b.bootTime)) {
+		count = 1
+	}
+	if count > 0 {
+		b.boots += count
+		b.bootCounted = true
+	}
+	if bootState.ToolsRunning && !b.toolsRunning {
+		if b.toolsSeen && !b.bootCounted {
+			log.Printf("[DEBUG] VMware Tools started again, counting a reboot of the guest operating system")
+			b.boots++
+		}
+		b.toolsSeen = true
+		b.bootCounted = false
 	}
 
-	if pauseFn != nil {
-		pauseFn(multistep.DebugLocationAfterRun, fmt.Sprintf("boot_command: %s", command), state)
+	b.eventKey = eventKey
+	if bootState.BootTime != nil {
+		b.bootTime = bootState.BootTime
 	}
+	b.toolsRunning = bootState.ToolsRunning
+	return nil
+}
 
-	return multistep.ActionContinue
+// waitFor waits until the virtual machine reaches the specified boot.
+func (b *bootCounter) waitFor(ctx context.Context, ui packersdk.Ui, boot int, timeout time.Duration) error {
+	if err := b.update(); err != nil {
+		return err
+	}
+	if b.boots >= boot {
+		return nil
+	}
+
+	ui.Sayf("Waiting for boot %d of the virtual machine...", boot)
+	deadline := time.After(timeout)
+	for b.boots < boot {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted")
+		case <-deadline:
+			return fmt.Errorf("timeout after %d boots", b.boots)
+		case <-time.After(bootPollInterval):
+		}
+		if err := b.update(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serialLogReader returns a function that reads the serial console log of the
+// virtual machine that is added by StepAddSerialPort.
+func serialLogReader(state multistep.StateBag) (func() ([]byte, error), error) {
+	path, ok := state.GetOk("serial_log_path")
+	if !ok {
+		return nil, fmt.Errorf("the virtual machine has no serial console log")
+	}
+	d := state.Get("driver").(driver.Driver)
+
+	return func() ([]byte, error) {
+		dir, err := os.MkdirTemp("", "packer-serial-log")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		dst := filepath.Join(dir, serialLogName)
+		if err := downloadDatastoreFile(d, path.(string), dst); err != nil {
+			return nil, err
+		}
+		return os.ReadFile(dst)
+	}, nil
+}
+
+// waitForConsole waits until the console output returned by read contains the
+// text. An error reading the console output is logged and retried, since the
+// log file is created when the guest operating system first writes to the
+// serial port.
+func waitForConsole(ctx context.Context, ui packersdk.Ui, read func() ([]byte, error), text string, timeout time.Duration) error {
+	ui.Sayf("Waiting for the console output to contain %q...", text)
+	deadline := time.After(timeout)
+	for {
+		content, err := read()
+		if err != nil {
+			log.Printf("[DEBUG] Error reading the serial console log: %s", err)
+		} else if strings.Contains(string(content), text) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted")
+		case <-deadline:
+			return fmt.Errorf("timeout waiting for %q", text)
+		case <-time.After(bootPollInterval):
+		}
+	}
 }
 
 func (s *StepBootCommand) Cleanup(_ multistep.StateBag) {}
@@ -0,0 +1,362 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"golang.org/x/mobile/event/key"
+)
+
+func TestBootConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name        string
+		config      *BootConfig
+		fail        bool
+		expectedErr string
+	}{
+		{
+			name: "Boot commands without command",
+			config: &BootConfig{
+				BootCommandsConfig: BootCommandsConfig{
+					BootCommands: []BootCommandEntry{{AfterBoot: 2}},
+				},
+			},
+			fail:        true,
+			expectedErr: "boot_commands[0].'command' is required",
+		},
+		{
+			name: "Boot commands with negative after_boot",
+			config: &BootConfig{
+				BootCommandsConfig: BootCommandsConfig{
+					BootCommands: []BootCommandEntry{{Command: []string{"<enter>"}, AfterBoot: -1}},
+				},
+			},
+			fail:        true,
+			expectedErr: "boot_commands[0].'after_boot' must be greater than or equal to 1",
+		},
+		{
+			name: "Boot commands with negative wait",
+			config: &BootConfig{
+				BootCommandsConfig: BootCommandsConfig{
+					BootCommands: []BootCommandEntry{{Command: []string{"<enter>"}, Wait: -time.Second}},
+				},
+			},
+			fail:        true,
+			expectedErr: "boot_commands[0].'wait' must be greater than or equal to 0",
+		},
+		{
+			name: "Invalid boot keyboard",
+			config: &BootConfig{
+				BootKeyboardConfig: BootKeyboardConfig{BootKeyboard: "ps2"},
+			},
+			fail:        true,
+			expectedErr: "'boot_keyboard' must be one of 'usb' or 'webmks'",
+		},
+		{
+			name: "Negative boot key retries",
+			config: &BootConfig{
+				BootKeyboardConfig: BootKeyboardConfig{BootKeyRetries: -1},
+			},
+			fail:        true,
+			expectedErr: "'boot_key_retries' must be greater than or equal to 0",
+		},
+		{
+			name:        "Unknown HTTP IP discovery",
+			config:      &BootConfig{HTTPIPDiscovery: "dns"},
+			fail:        true,
+			expectedErr: "'http_ip_discovery' must be one of 'interfaces' or 'host'",
+		},
+		{
+			name:        "HTTP IP discovery with HTTP IP",
+			config:      &BootConfig{HTTPIP: "10.0.2.2", HTTPIPDiscovery: "host"},
+			fail:        true,
+			expectedErr: "'http_ip_discovery' cannot be used with 'http_ip'",
+		},
+		{
+			name: "Valid boot commands",
+			config: &BootConfig{
+				BootCommandsConfig: BootCommandsConfig{
+					BootCommands: []BootCommandEntry{{Command: []string{"<enter>"}, AfterBoot: 2}},
+				},
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(&interpolate.Context{})
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatal("unexpected success: expected failure")
+				}
+				if errs[0].Error() != c.expectedErr {
+					t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErr, errs[0])
+				}
+			} else if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+		})
+	}
+}
+
+func TestBootConfig_PrepareBootCommandsDefaults(t *testing.T) {
+	config := &BootConfig{
+		BootCommandsConfig: BootCommandsConfig{
+			BootCommands: []BootCommandEntry{{Command: []string{"<enter>"}}},
+		},
+	}
+	if errs := config.Prepare(&interpolate.Context{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+
+	entry := config.BootCommands[0]
+	if entry.AfterBoot != 1 {
+		t.Fatalf("unexpected result: expected '1', but returned '%d'", entry.AfterBoot)
+	}
+	if entry.Wait != config.BootWait {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", config.BootWait, entry.Wait)
+	}
+	if entry.Timeout != 60*time.Minute {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", 60*time.Minute, entry.Timeout)
+	}
+}
+
+func TestBootConfig_PrepareBootKeyboardDefaults(t *testing.T) {
+	config := &BootConfig{}
+	if errs := config.Prepare(&interpolate.Context{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.BootKeyboard != "usb" {
+		t.Fatalf("unexpected result: expected 'usb', but returned '%s'", config.BootKeyboard)
+	}
+	if config.BootKeyInterval != config.BootGroupInterval {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", config.BootGroupInterval, config.BootKeyInterval)
+	}
+	if config.BootKeyRetries != 1 {
+		t.Fatalf("unexpected result: expected '1', but returned '%d'", config.BootKeyRetries)
+	}
+
+	config = &BootConfig{BootKeyboardConfig: BootKeyboardConfig{BootKeyboard: "webmks"}}
+	if errs := config.Prepare(&interpolate.Context{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.BootKeyInterval != 100*time.Millisecond {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", 100*time.Millisecond, config.BootKeyInterval)
+	}
+}
+
+func TestUSBKeyboard_SendCodes(t *testing.T) {
+	tc := []struct {
+		name     string
+		results  []int32
+		retries  int
+		fail     bool
+		attempts int
+	}{
+		{
+			name:     "Key received",
+			results:  []int32{1},
+			retries:  1,
+			attempts: 1,
+		},
+		{
+			name:     "Key dropped and retried",
+			results:  []int32{0, 0, 1},
+			retries:  2,
+			attempts: 3,
+		},
+		{
+			name:     "Key dropped after retries",
+			results:  []int32{0, 0, 0},
+			retries:  1,
+			fail:     true,
+			attempts: 2,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var inputs []driver.KeyInput
+			keyboard := &usbKeyboard{
+				typeOnKeyboard: func(input driver.KeyInput) (int32, error) {
+					inputs = append(inputs, input)
+					return c.results[len(inputs)-1], nil
+				},
+				ui:      &packersdk.BasicUi{Writer: &strings.Builder{}, ErrorWriter: &strings.Builder{}},
+				retries: c.retries,
+			}
+
+			err := keyboard.sendCodes(key.CodeA, true)
+			if c.fail && err == nil {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if len(inputs) != c.attempts {
+				t.Fatalf("unexpected result: expected '%d' attempts, but returned '%d'", c.attempts, len(inputs))
+			}
+		})
+	}
+}
+
+func TestWebMKSKeyboard_KeyEvent(t *testing.T) {
+	var dials int
+	keyboard := &webmksKeyboard{
+		dial: func() (*driver.WebMKS, error) {
+			dials++
+			return nil, fmt.Errorf("connection refused")
+		},
+		ui:      &packersdk.BasicUi{Writer: &strings.Builder{}, ErrorWriter: &strings.Builder{}},
+		retries: 2,
+	}
+
+	err := keyboard.KeyEvent(0xff0d, true)
+	if err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	expected := "error typing a boot command (keysym, down) `65293, true`: connection refused"
+	if err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+	if dials != 3 {
+		t.Fatalf("unexpected result: expected '3' connections, but returned '%d'", dials)
+	}
+}
+
+// fakeBootState is the boot state of a virtual machine that changes at each
+// update of a boot counter.
+type fakeBootState struct {
+	events []int
+	states []driver.BootState
+	update int
+}
+
+func (f *fakeBootState) BootEvents(afterKey int32) (int, int32, error) {
+	count := 0
+	if f.update < len(f.events) {
+		count = f.events[f.update]
+	}
+	return count, afterKey + int32(count), nil
+}
+
+func (f *fakeBootState) BootState() (*driver.BootState, error) {
+	state := f.states[len(f.states)-1]
+	if f.update < len(f.states) {
+		state = f.states[f.update]
+	}
+	f.update++
+	return &state, nil
+}
+
+func TestBootCounter_Update(t *testing.T) {
+	powerOn := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	powerOnAgain := powerOn.Add(time.Hour)
+	running := driver.BootState{BootTime: &powerOn, ToolsRunning: true}
+	stopped := driver.BootState{BootTime: &powerOn}
+
+	tc := []struct {
+		name     string
+		events   []int
+		states   []driver.BootState
+		expected int
+	}{
+		{
+			name:     "Guest reboot without an event",
+			states:   []driver.BootState{running, running, stopped, stopped, running},
+			expected: 2,
+		},
+		{
+			name:     "Two guest reboots without an event",
+			states:   []driver.BootState{running, stopped, running, stopped, running},
+			expected: 3,
+		},
+		{
+			name:     "Guest reboot with an event",
+			events:   []int{0, 1, 0, 0},
+			states:   []driver.BootState{running, running, stopped, running},
+			expected: 2,
+		},
+		{
+			name:     "Power on without an event",
+			states:   []driver.BootState{stopped, {BootTime: &powerOnAgain}},
+			expected: 2,
+		},
+		{
+			name:     "VMware Tools starting for the first time",
+			states:   []driver.BootState{stopped, stopped, running},
+			expected: 1,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			vm := &fakeBootState{events: c.events, states: c.states}
+			boots, err := newBootCounter(vm)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			for vm.update < len(c.states) {
+				if err := boots.update(); err != nil {
+					t.Fatalf("unexpected error: '%s'", err)
+				}
+			}
+			if boots.boots != c.expected {
+				t.Fatalf("unexpected result: expected boot '%d', but returned '%d'", c.expected, boots.boots)
+			}
+		})
+	}
+}
+
+func TestWaitForConsole(t *testing.T) {
+	interval := bootPollInterval
+	bootPollInterval = time.Millisecond
+	defer func() { bootPollInterval = interval }()
+
+	reads := 0
+	read := func() ([]byte, error) {
+		reads++
+		switch reads {
+		case 1:
+			return nil, fmt.Errorf("file not found")
+		case 2:
+			return []byte("Booting the installer...\n"), nil
+		}
+		return []byte("Booting the installer...\nWelcome to the setup\n"), nil
+	}
+
+	if err := waitForConsole(context.TODO(), packersdk.TestUi(t), read, "Welcome to the setup", time.Minute); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if reads != 3 {
+		t.Fatalf("unexpected result: expected 3 reads, but returned %d", reads)
+	}
+
+	err := waitForConsole(context.TODO(), packersdk.TestUi(t), read, "Installation complete", 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("unexpected result: expected a timeout, but returned '%v'", err)
+	}
+}
+
+func TestBootCommandsConfig_PrepareSerialLog(t *testing.T) {
+	config := &BootCommandsConfig{
+		BootCommands: []BootCommandEntry{{Command: []string{"<enter>"}, ConsoleContains: "Welcome"}},
+	}
+	if errs := config.PrepareSerialLog(&SerialLogConfig{SerialLog: true}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	errs := config.PrepareSerialLog(&SerialLogConfig{})
+	expected := "boot_commands[0].'console_contains' requires 'serial_log'"
+	if len(errs) != 1 || errs[0].Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", expected, errs)
+	}
+}
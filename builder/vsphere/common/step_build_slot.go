@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type BuildSlotConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The interval between checks for an available build slot.
+var buildSlotPollInterval = 30 * time.Second
+
+// Limit the number of concurrent builds that are placed on the same ESXi host
+// or datastore. Virtual machines that are being built are marked with the
+// `packer_build_in_progress` custom attribute, and a build waits until the
+// number of marked virtual machines on the target host and datastore is below
+// the configured limits before the virtual machine is created.
+//
+// ~> **Note:** The limits are enforced on a best-effort basis. Builds that
+// start at the same time may exceed the limits.
+type BuildSlotConfig struct {
+	// The maximum number of concurrent builds on the ESXi host specified by
+	// `host`. Requires `host`. Defaults to `0` (unlimited).
+	MaxBuildsPerHost int `mapstructure:"max_builds_per_host"`
+	// The maximum number of concurrent builds on the datastore specified by
+	// `datastore`. Requires `datastore`. Defaults to `0` (unlimited).
+	MaxBuildsPerDatastore int `mapstructure:"max_builds_per_datastore"`
+	// The amount of time to wait for a build slot before the build fails.
+	// Defaults to `30m` (30 minutes).
+	BuildSlotTimeout time.Duration `mapstructure:"build_slot_timeout"`
+	// The age after which the `packer_build_in_progress` mark of a virtual
+	// machine is no longer counted, such as the mark of a build that was
+	// killed or crashed before it removed the mark. The stale age must exceed
+	// the duration of the longest build on the host and datastore, since the
+	// mark of a running build that is older than the stale age is not
+	// counted and the limits are no longer enforced for it. Defaults to `0`,
+	// which never expires a mark.
+	BuildSlotStaleAge time.Duration `mapstructure:"build_slot_stale_age"`
+}
+
+func (c *BuildSlotConfig) Prepare(lc *LocationConfig) []error {
+	var errs []error
+
+	if c.MaxBuildsPerHost < 0 {
+		errs = append(errs, fmt.Errorf("'max_builds_per_host' must be greater than or equal to 0"))
+	}
+	if c.MaxBuildsPerDatastore < 0 {
+		errs = append(errs, fmt.Errorf("'max_builds_per_datastore' must be greater than or equal to 0"))
+	}
+	if c.MaxBuildsPerHost > 0 && lc.Host == "" {
+		errs = append(errs, fmt.Errorf("'host' is required when 'max_builds_per_host' is set"))
+	}
+	if c.MaxBuildsPerDatastore > 0 && lc.Datastore == "" {
+		errs = append(errs, fmt.Errorf("'datastore' is required when 'max_builds_per_datastore' is set"))
+	}
+	if c.BuildSlotTimeout < 0 {
+		errs = append(errs, fmt.Errorf("'build_slot_timeout' must be greater than or equal to 0"))
+	}
+	if c.BuildSlotTimeout == 0 {
+		c.BuildSlotTimeout = 30 * time.Minute
+	}
+	if c.BuildSlotStaleAge < 0 {
+		errs = append(errs, fmt.Errorf("'build_slot_stale_age' must be greater than or equal to 0"))
+	}
+
+	return errs
+}
+
+func (c *BuildSlotConfig) enabled() bool {
+	return c.MaxBuildsPerHost > 0 || c.MaxBuildsPerDatastore > 0
+}
+
+type StepWaitForBuildSlot struct {
+	Config   *BuildSlotConfig
+	Location *LocationConfig
+}
+
+func (s *StepWaitForBuildSlot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.enabled() {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(*driver.VCenterDriver)
+
+	ui.Say("Waiting for an available build slot...")
+
+	timeout := time.After(s.Config.BuildSlotTimeout)
+	for {
+		reason, err := s.checkBuildSlot(d)
+		if err != nil {
+			state.Put("error", fmt.Errorf("error checking for an available build slot: %s", err))
+			return multistep.ActionHalt
+		}
+		if reason == "" {
+			return multistep.ActionContinue
+		}
+		ui.Sayf("No build slot available: %s. Retrying in %s...", reason, buildSlotPollInterval)
+
+		select {
+		case <-ctx.Done():
+			state.Put("error", fmt.Errorf("interrupted while waiting for a build slot"))
+			return multistep.ActionHalt
+		case <-timeout:
+			state.Put("error", fmt.Errorf("timeout waiting for a build slot: %s", reason))
+			return multistep.ActionHalt
+		case <-time.After(buildSlotPollInterval):
+		}
+	}
+}
+
+// checkBuildSlot returns the reason a build slot is not available, or an
+// empty string if a build slot is available.
+func (s *StepWaitForBuildSlot) checkBuildSlot(d *driver.VCenterDriver) (string, error) {
+	if s.Config.MaxBuildsPerHost > 0 {
+		host, err := d.FindHost(s.Location.Host)
+		if err != nil {
+			return "", err
+		}
+		info, err := host.Info("vm")
+		if err != nil {
+			return "", err
+		}
+		count, err := d.CountBuildsInProgress(info.Vm, s.Config.BuildSlotStaleAge)
+		if err != nil {
+			return "", err
+		}
+		if count >= s.Config.MaxBuildsPerHost {
+			return fmt.Sprintf("%d of %d builds in progress on host %s", count, s.Config.MaxBuildsPerHost, s.Location.Host), nil
+		}
+	}
+
+	if s.Config.MaxBuildsPerDatastore > 0 {
+		ds, err := d.FindDatastore(s.Location.Datastore, s.Location.Host)
+		if err != nil {
+			return "", err
+		}
+		info, err := ds.Info("vm")
+		if err != nil {
+			return "", err
+		}
+		count, err := d.CountBuildsInProgress(info.Vm, s.Config.BuildSlotStaleAge)
+		if err != nil {
+			return "", err
+		}
+		if count >= s.Config.MaxBuildsPerDatastore {
+			return fmt.Sprintf("%d of %d builds in progress on datastore %s", count, s.Config.MaxBuildsPerDatastore, s.Location.Datastore), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (s *StepWaitForBuildSlot) Cleanup(multistep.StateBag) {}
+
+type StepMarkBuildInProgress struct {
+	Config *BuildSlotConfig
+
+	marked bool
+}
+
+func (s *StepMarkBuildInProgress) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.enabled() {
+		return multistep.ActionContinue
+	}
+
+	vm := state.Get("vm").(*driver.VirtualMachineDriver)
+
+	if err := vm.SetBuildInProgress(true); err != nil {
+		state.Put("error", fmt.Errorf("error marking the build in progress: %s", err))
+		return multistep.ActionHalt
+	}
+	s.marked = true
+
+	return multistep.ActionContinue
+}
+
+func (s *StepMarkBuildInProgress) Cleanup(state multistep.StateBag) {
+	if !s.marked {
+		return
+	}
+
+	vm, ok := state.GetOk("vm")
+	if !ok {
+		return
+	}
+	if err := vm.(*driver.VirtualMachineDriver).SetBuildInProgress(false); err != nil {
+		log.Printf("[WARN] Unable to remove the %s mark from the virtual machine: %s", driver.BuildInProgressAttribute, err)
+	}
+}
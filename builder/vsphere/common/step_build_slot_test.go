@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestBuildSlotConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name     string
+		config   *BuildSlotConfig
+		location *LocationConfig
+		errs     int
+	}{
+		{
+			name:     "Should not fail for empty config",
+			config:   new(BuildSlotConfig),
+			location: new(LocationConfig),
+		},
+		{
+			name:     "Host limit with host",
+			config:   &BuildSlotConfig{MaxBuildsPerHost: 2},
+			location: &LocationConfig{Host: "esxi-01"},
+		},
+		{
+			name:     "Host limit without host",
+			config:   &BuildSlotConfig{MaxBuildsPerHost: 2},
+			location: &LocationConfig{Cluster: "cluster-01"},
+			errs:     1,
+		},
+		{
+			name:     "Datastore limit without datastore",
+			config:   &BuildSlotConfig{MaxBuildsPerDatastore: 2},
+			location: &LocationConfig{Host: "esxi-01"},
+			errs:     1,
+		},
+		{
+			name:     "Negative limits",
+			config:   &BuildSlotConfig{MaxBuildsPerHost: -1, MaxBuildsPerDatastore: -1},
+			location: new(LocationConfig),
+			errs:     2,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(c.location)
+			if len(errs) != c.errs {
+				t.Fatalf("unexpected result: expected %d errors, but returned %d: %v", c.errs, len(errs), errs)
+			}
+			if c.config.BuildSlotTimeout != 30*time.Minute {
+				t.Fatalf("unexpected result: expected '30m0s', but returned '%s'", c.config.BuildSlotTimeout)
+			}
+			if c.config.BuildSlotStaleAge != 0 {
+				t.Fatalf("unexpected result: expected '0s', but returned '%s'", c.config.BuildSlotStaleAge)
+			}
+		})
+	}
+}
+
+func TestStepWaitForBuildSlot_Run(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	host := sim.driver.NewHost(machine.Runtime.Host)
+	hostInfo, err := host.Info("name")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	config := &BuildSlotConfig{MaxBuildsPerHost: 1}
+	location := &LocationConfig{Host: hostInfo.Name}
+	if errs := config.Prepare(location); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	config.BuildSlotTimeout = 10 * time.Millisecond
+
+	pollInterval := buildSlotPollInterval
+	buildSlotPollInterval = time.Millisecond
+	defer func() { buildSlotPollInterval = pollInterval }()
+
+	state := basicStateBag(nil)
+	state.Put("driver", sim.driver)
+	state.Put("vm", vm)
+
+	wait := &StepWaitForBuildSlot{Config: config, Location: location}
+	if action := wait.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%s'", state.Get("error"))
+	}
+
+	mark := &StepMarkBuildInProgress{Config: config}
+	if action := mark.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%s'", state.Get("error"))
+	}
+
+	count, err := sim.driver.CountBuildsInProgress([]types.ManagedObjectReference{machine.Reference()}, config.BuildSlotStaleAge)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if count != 1 {
+		t.Fatalf("unexpected result: expected '1', but returned '%d'", count)
+	}
+
+	state.Remove("error")
+	if action := wait.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected result: expected the step to halt when no build slot is available")
+	}
+
+	mark.Cleanup(state)
+	state.Remove("error")
+	if action := wait.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%s'", state.Get("error"))
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type ChangeTrackingConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The name of the temporary snapshot that activates changed block tracking.
+const changeTrackingSnapshotName = "packer-enable-cbt"
+
+type ChangeTrackingConfig struct {
+	// Enable changed block tracking (CBT) for the disks of the virtual
+	// machine or template, as required by backup applications for
+	// incremental backups of the virtual machines deployed from the
+	// template. Defaults to `false`.
+	//
+	// Changed block tracking is enabled after the build completes and is
+	// activated by creating and removing a temporary snapshot, which sets
+	// `ctkEnabled` and the `ctkEnabled` option of each disk without a power
+	// cycle of the virtual machine.
+	EnableCBT bool `mapstructure:"enable_cbt"`
+}
+
+type StepEnableChangeTracking struct {
+	Config *ChangeTrackingConfig
+}
+
+func (s *StepEnableChangeTracking) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.EnableCBT {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Enabling changed block tracking...")
+	if err := vm.EnableChangeTracking(); err != nil {
+		state.Put("error", fmt.Errorf("error enabling changed block tracking: %s", err))
+		return multistep.ActionHalt
+	}
+
+	// The change tracking files of the disks are created when the virtual
+	// machine is powered on or a snapshot is created.
+	if err := vm.CreateSnapshot(changeTrackingSnapshotName); err != nil {
+		state.Put("error", fmt.Errorf("error activating changed block tracking: %s", err))
+		return multistep.ActionHalt
+	}
+	if err := vm.RemoveSnapshot(changeTrackingSnapshotName); err != nil {
+		state.Put("error", fmt.Errorf("error removing snapshot %s: %s", changeTrackingSnapshotName, err))
+		return multistep.ActionHalt
+	}
+
+	info, err := vm.Info("config.changeTrackingEnabled")
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	if info.Config.ChangeTrackingEnabled == nil || !*info.Config.ChangeTrackingEnabled {
+		state.Put("error", fmt.Errorf("changed block tracking is not enabled for the virtual machine"))
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepEnableChangeTracking) Cleanup(multistep.StateBag) {}
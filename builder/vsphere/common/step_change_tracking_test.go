@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestStepEnableChangeTracking_Run(t *testing.T) {
+	vm := &driver.VirtualMachineMock{
+		InfoReturn: &mo.VirtualMachine{
+			Config: &types.VirtualMachineConfigInfo{ChangeTrackingEnabled: types.NewBool(true)},
+		},
+	}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepEnableChangeTracking{Config: &ChangeTrackingConfig{EnableCBT: true}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if !vm.EnableChangeTrackingCalled {
+		t.Fatal("unexpected result: expected changed block tracking to be enabled")
+	}
+	if vm.CreateSnapshotName != changeTrackingSnapshotName || vm.RemoveSnapshotName != changeTrackingSnapshotName {
+		t.Fatalf("unexpected result: expected snapshot '%s' to be created and removed, but created '%s' and removed '%s'",
+			changeTrackingSnapshotName, vm.CreateSnapshotName, vm.RemoveSnapshotName)
+	}
+}
+
+func TestStepEnableChangeTracking_RunDisabled(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepEnableChangeTracking{Config: &ChangeTrackingConfig{}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.EnableChangeTrackingCalled || vm.CreateSnapshotCalled {
+		t.Fatal("unexpected result: expected no changes to the virtual machine")
+	}
+}
+
+func TestStepEnableChangeTracking_RunError(t *testing.T) {
+	tc := []struct {
+		name string
+		vm   *driver.VirtualMachineMock
+	}{
+		{
+			name: "Snapshot error",
+			vm:   &driver.VirtualMachineMock{CreateSnapshotErr: fmt.Errorf("insufficient disk space")},
+		},
+		{
+			name: "Not enabled",
+			vm: &driver.VirtualMachineMock{
+				InfoReturn: &mo.VirtualMachine{Config: &types.VirtualMachineConfigInfo{}},
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := basicStateBag(nil)
+			state.Put("vm", c.vm)
+
+			step := &StepEnableChangeTracking{Config: &ChangeTrackingConfig{EnableCBT: true}}
+			if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+				t.Fatalf("unexpected action: '%#v'", action)
+			}
+			if _, ok := state.GetOk("error"); !ok {
+				t.Fatal("unexpected result: expected an error in the state")
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatastoreSpaceConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// The default percentage of additional free space required on the
+	// datastore.
+	DefaultDatastoreSpaceHeadroom = 10
+
+	mebibyte = int64(1024 * 1024)
+	gibibyte = 1024 * mebibyte
+)
+
+type DatastoreSpaceConfig struct {
+	// Verify that the datastore has enough free space for the build before
+	// any files are uploaded or the virtual machine is created. The projected
+	// footprint includes the virtual disks, the memory swap file, the files
+	// uploaded to the datastore, and the staging copy for a content library
+	// import to the same datastore. Thin provisioned disks are counted at
+	// their full size. The export is written to the local `output_directory`
+	// and is not counted. Defaults to `false`.
+	CheckDatastoreSpace bool `mapstructure:"check_datastore_space"`
+	// The percentage of additional free space to require beyond the projected
+	// footprint. Set to `0` to require only the projected footprint.
+	// Defaults to `10`.
+	DatastoreSpaceHeadroom *int `mapstructure:"datastore_space_headroom"`
+}
+
+func (c *DatastoreSpaceConfig) Prepare() []error {
+	var errs []error
+
+	if c.DatastoreSpaceHeadroom == nil {
+		headroom := DefaultDatastoreSpaceHeadroom
+		c.DatastoreSpaceHeadroom = &headroom
+	}
+	if *c.DatastoreSpaceHeadroom < 0 {
+		errs = append(errs, fmt.Errorf("'datastore_space_headroom' must be greater than or equal to 0"))
+	}
+
+	return errs
+}
+
+type StepCheckDatastoreSpace struct {
+	Config         *DatastoreSpaceConfig
+	Location       *LocationConfig
+	Hardware       *HardwareConfig
+	Storage        *StorageConfig
+	ContentLibrary *ContentLibraryDestinationConfig
+	// The name of the source virtual machine for a clone.
+	Source          string
+	LinkedClone     bool
+	PrimaryDiskSize int64
+	// The new sizes of the disks of the source for a clone.
+	DiskResizes []driver.DiskResize
+	// Whether the files in the state are uploaded to the build datastore.
+	UploadsToDatastore bool
+}
+
+type datastoreFootprint struct {
+	// The projected space, in bytes, required on the datastore for each part
+	// of the build.
+	Disks          int64
+	MemorySwap     int64
+	Uploads        int64
+	ContentLibrary int64
+}
+
+func (f datastoreFootprint) total() int64 {
+	return f.Disks + f.MemorySwap + f.Uploads + f.ContentLibrary
+}
+
+func (f datastoreFootprint) String() string {
+	parts := []string{
+		fmt.Sprintf("disks: %s", formatBytes(f.Disks)),
+		fmt.Sprintf("memory swap: %s", formatBytes(f.MemorySwap)),
+	}
+	if f.Uploads > 0 {
+		parts = append(parts, fmt.Sprintf("uploads: %s", formatBytes(f.Uploads)))
+	}
+	if f.ContentLibrary > 0 {
+		parts = append(parts, fmt.Sprintf("content library staging: %s", formatBytes(f.ContentLibrary)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s *StepCheckDatastoreSpace) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.CheckDatastoreSpace {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Say("Checking datastore free space...")
+
+	var source driver.VirtualMachine
+	if s.Source != "" {
+		vm, err := d.FindVM(s.Source)
+		if err != nil {
+			state.Put("error", fmt.Errorf("error finding the source virtual machine: %s", err))
+			return multistep.ActionHalt
+		}
+		source = vm
+	}
+
+	footprint, err := s.footprint(state, source)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error calculating the projected build footprint: %s", err))
+		return multistep.ActionHalt
+	}
+
+	ds, err := s.datastore(d, source)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error finding the datastore: %s", err))
+		return multistep.ActionHalt
+	}
+	info, err := ds.Info("name", "summary.freeSpace")
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving the datastore free space: %s", err))
+		return multistep.ActionHalt
+	}
+
+	required := footprint.total() + footprint.total()/100*int64(*s.Config.DatastoreSpaceHeadroom)
+	available := info.Summary.FreeSpace
+	if required > available {
+		state.Put("error", fmt.Errorf("insufficient free space on datastore %s: required %s (%s, headroom: %d%%), available %s",
+			info.Name, formatBytes(required), footprint, *s.Config.DatastoreSpaceHeadroom, formatBytes(available)))
+		return multistep.ActionHalt
+	}
+
+	ui.Sayf("Datastore %s has %s free; the build requires %s (%s, headroom: %d%%).",
+		info.Name, formatBytes(available), formatBytes(required), footprint, *s.Config.DatastoreSpaceHeadroom)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCheckDatastoreSpace) footprint(state multistep.StateBag, source driver.VirtualMachine) (datastoreFootprint, error) {
+	var f datastoreFootprint
+
+	for _, disk := range s.Storage.Storage {
+		if disk.DiskReuseExisting {
+			continue
+		}
+		f.Disks += disk.DiskSize * mebibyte
+	}
+
+	memory := s.Hardware.RAM * mebibyte
+	if source != nil {
+		info, err := source.Info("config.hardware")
+		if err != nil {
+			return f, err
+		}
+		if !s.LinkedClone {
+			f.Disks += sourceDiskSize(info.Config.Hardware.Device, s.PrimaryDiskSize, s.DiskResizes)
+		}
+		if memory == 0 {
+			memory = int64(info.Config.Hardware.MemoryMB) * mebibyte
+		}
+	}
+
+	if !s.Hardware.RAMReserveAll {
+		reservation := s.Hardware.RAMReservation * mebibyte
+		if memory > reservation {
+			f.MemorySwap = memory - reservation
+		}
+	}
+
+	if s.UploadsToDatastore {
+		for _, key := range []string{"iso_path", "cd_path"} {
+			path, ok := state.GetOk(key)
+			if !ok {
+				continue
+			}
+			if fi, err := os.Stat(path.(string)); err == nil {
+				f.Uploads += fi.Size()
+			}
+		}
+	}
+
+	if s.ContentLibrary != nil && s.ContentLibrary.Library != "" && !s.ContentLibrary.SkipImport &&
+		(s.ContentLibrary.Datastore == "" || s.ContentLibrary.Datastore == s.Location.Datastore) {
+		f.ContentLibrary = f.Disks
+	}
+
+	return f, nil
+}
+
+// sourceDiskSize returns the size, in bytes, of the disks of the source
+// virtual machine. The first disk is resized to the primary disk size, in
+// MiB, and the other disks to the size of their resize, if it is larger.
+func sourceDiskSize(devices object.VirtualDeviceList, primaryDiskSize int64, resizes []driver.DiskResize) int64 {
+	var size int64
+	for i, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		diskSize := disk.CapacityInKB * 1024
+		if i == 0 && primaryDiskSize*mebibyte > diskSize {
+			diskSize = primaryDiskSize * mebibyte
+		}
+		for _, r := range resizes {
+			if r.Matches(i, disk) && r.Size*mebibyte > diskSize {
+				diskSize = r.Size * mebibyte
+			}
+		}
+		size += diskSize
+	}
+	return size
+}
+
+func (s *StepCheckDatastoreSpace) datastore(d driver.Driver, source driver.VirtualMachine) (driver.Datastore, error) {
+	if s.Location.Datastore == "" && source != nil {
+		info, err := source.Info("datastore")
+		if err != nil {
+			return nil, err
+		}
+		if len(info.Datastore) > 0 {
+			return d.NewDatastore(&info.Datastore[0]), nil
+		}
+	}
+	return d.FindDatastore(s.Location.Datastore, s.Location.Host)
+}
+
+func (s *StepCheckDatastoreSpace) Cleanup(multistep.StateBag) {}
+
+// formatBytes formats a size in bytes as GiB.
+func formatBytes(size int64) string {
+	return fmt.Sprintf("%.1f GiB", float64(size)/float64(gibibyte))
+}
@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestDatastoreSpaceConfig_Prepare(t *testing.T) {
+	c := new(DatastoreSpaceConfig)
+	if errs := c.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if *c.DatastoreSpaceHeadroom != DefaultDatastoreSpaceHeadroom {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", DefaultDatastoreSpaceHeadroom, *c.DatastoreSpaceHeadroom)
+	}
+
+	// A headroom of 0 is kept rather than replaced with the default.
+	headroom := 0
+	c = &DatastoreSpaceConfig{DatastoreSpaceHeadroom: &headroom}
+	if errs := c.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if *c.DatastoreSpaceHeadroom != 0 {
+		t.Fatalf("unexpected result: expected '0', but returned '%d'", *c.DatastoreSpaceHeadroom)
+	}
+
+	headroom = -1
+	c = &DatastoreSpaceConfig{DatastoreSpaceHeadroom: &headroom}
+	if errs := c.Prepare(); len(errs) != 1 {
+		t.Fatalf("unexpected result: expected one error, but returned %d", len(errs))
+	}
+}
+
+func TestStepCheckDatastoreSpace_footprint(t *testing.T) {
+	iso := filepath.Join(t.TempDir(), "image.iso")
+	if err := os.WriteFile(iso, make([]byte, 2048), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	state := basicStateBag(nil)
+	state.Put("iso_path", iso)
+
+	step := &StepCheckDatastoreSpace{
+		Config:   &DatastoreSpaceConfig{CheckDatastoreSpace: true},
+		Location: &LocationConfig{Datastore: "datastore1"},
+		Hardware: &HardwareConfig{RAM: 4096, RAMReservation: 1024},
+		Storage: &StorageConfig{
+			Storage: []DiskConfig{
+				{DiskSize: 10240},
+				{DiskSize: 20480, DiskPath: "[datastore1] disks/cache.vmdk", DiskReuseExisting: true},
+			},
+		},
+		ContentLibrary:     &ContentLibraryDestinationConfig{Library: "library", Ovf: true},
+		UploadsToDatastore: true,
+	}
+
+	f, err := step.footprint(state, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if f.Disks != 10*gibibyte {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", 10*gibibyte, f.Disks)
+	}
+	if f.MemorySwap != 3*gibibyte {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", 3*gibibyte, f.MemorySwap)
+	}
+	if f.Uploads != 2048 {
+		t.Fatalf("unexpected result: expected '2048', but returned '%d'", f.Uploads)
+	}
+	if f.ContentLibrary != 10*gibibyte {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", 10*gibibyte, f.ContentLibrary)
+	}
+}
+
+func TestSourceDiskSize(t *testing.T) {
+	var devices object.VirtualDeviceList
+	for i := 0; i < 3; i++ {
+		devices = append(devices, &types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				DeviceInfo: &types.Description{Label: fmt.Sprintf("Hard disk %d", i+1)},
+			},
+			CapacityInKB: 10 * 1024 * 1024,
+		})
+	}
+
+	// The disks are counted at the larger of their size and their resize.
+	resizes := []driver.DiskResize{
+		{Index: 1, Size: 20480},
+		{Label: "Hard disk 3", Size: 5120},
+	}
+	actual := sourceDiskSize(devices, 30720, resizes)
+	if expected := int64(60 * gibibyte); actual != expected {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", expected, actual)
+	}
+}
+
+func TestStepCheckDatastoreSpace_Run(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	host := sim.driver.NewHost(machine.Runtime.Host)
+	hostInfo, err := host.Info("name")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	state := basicStateBag(nil)
+	state.Put("driver", sim.driver)
+
+	config := &DatastoreSpaceConfig{CheckDatastoreSpace: true}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	step := &StepCheckDatastoreSpace{
+		Config:   config,
+		Location: &LocationConfig{Host: hostInfo.Name},
+		Hardware: &HardwareConfig{RAMReserveAll: true},
+		Storage: &StorageConfig{
+			// 1 PiB is larger than any simulated datastore.
+			Storage: []DiskConfig{{DiskSize: 1024 * 1024 * 1024}},
+		},
+	}
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected result: expected the step to halt")
+	}
+	err, _ = state.Get("error").(error)
+	if err == nil || !strings.Contains(err.Error(), "insufficient free space on datastore") {
+		t.Fatalf("unexpected result: expected an insufficient free space error, but returned '%v'", err)
+	}
+	if !strings.Contains(err.Error(), "disks: 1048576.0 GiB") {
+		t.Fatalf("unexpected result: expected a breakdown of the footprint, but returned '%s'", err)
+	}
+}
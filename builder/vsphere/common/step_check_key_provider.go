@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// StepCheckKeyProvider verifies that a default key provider is configured for
+// the target host or cluster, or on vCenter Server, when a
+// virtual trusted platform module is enabled, so that the build fails before
+// the virtual machine is created rather than when the device is added. If the
+// default key provider cannot be retrieved, such as without the
+// Cryptographer.ManageKeyServers privilege, a warning is displayed and the
+// build continues.
+type StepCheckKeyProvider struct {
+	Config   *HardwareConfig
+	Location *LocationConfig
+}
+
+func (s *StepCheckKeyProvider) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.VTPMEnabled {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Say("Checking key provider for virtual TPM...")
+	provider, err := d.DefaultKeyProvider(s.Location.Cluster, s.Location.Host)
+	if err != nil {
+		ui.Sayf("Warning: Unable to check the key provider for virtual TPM: %s", err)
+		return multistep.ActionContinue
+	}
+	if provider == "" {
+		state.Put("error", fmt.Errorf("'vTPM' requires a default key provider on vCenter Server; "+
+			"add a key provider, such as a native key provider, and set it as the default"))
+		return multistep.ActionHalt
+	}
+
+	ui.Sayf("Using default key provider %s for virtual TPM.", provider)
+	return multistep.ActionContinue
+}
+
+func (s *StepCheckKeyProvider) Cleanup(multistep.StateBag) {}
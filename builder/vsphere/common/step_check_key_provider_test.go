@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepCheckKeyProvider_Run(t *testing.T) {
+	tc := []struct {
+		name           string
+		config         *HardwareConfig
+		provider       string
+		err            error
+		expectedAction multistep.StepAction
+		expectedCalled bool
+	}{
+		{
+			name:           "vTPM disabled",
+			config:         &HardwareConfig{},
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "Default key provider",
+			config:         &HardwareConfig{VTPMEnabled: true},
+			provider:       "native-provider",
+			expectedAction: multistep.ActionContinue,
+			expectedCalled: true,
+		},
+		{
+			name:           "Key provider lookup error",
+			config:         &HardwareConfig{VTPMEnabled: true},
+			err:            errors.New("permission to perform this operation was denied"),
+			expectedAction: multistep.ActionContinue,
+			expectedCalled: true,
+		},
+		{
+			name:           "No default key provider",
+			config:         &HardwareConfig{VTPMEnabled: true},
+			expectedAction: multistep.ActionHalt,
+			expectedCalled: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := basicStateBag(nil)
+			d := driver.NewDriverMock()
+			d.DefaultKeyProviderResult = c.provider
+			d.DefaultKeyProviderErr = c.err
+			state.Put("driver", d)
+
+			step := &StepCheckKeyProvider{Config: c.config, Location: &LocationConfig{Cluster: "cluster"}}
+			if action := step.Run(context.TODO(), state); action != c.expectedAction {
+				t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", c.expectedAction, action)
+			}
+			if d.DefaultKeyProviderCalled != c.expectedCalled {
+				t.Fatalf("unexpected result: expected '%t', but returned '%t'", c.expectedCalled, d.DefaultKeyProviderCalled)
+			}
+			if _, ok := state.GetOk("error"); ok != (c.expectedAction == multistep.ActionHalt) {
+				t.Fatalf("unexpected state: 'error' present is '%t'", ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// StepCheckPrivileges prints the privileges that the build requires and that
+// the user does not have. The build continues, since the privileges that are
+// used depend on the options of the build.
+type StepCheckPrivileges struct {
+	Config    *PreflightConfig
+	Location  *LocationConfig
+	Networks  []string
+	Template  string
+	ImportOVF bool
+}
+
+func (s *StepCheckPrivileges) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.CheckPrivileges || s.Config.PreflightChecks {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Say("Checking privileges...")
+	missing, err := d.MissingBuildPrivileges(&driver.PreflightSpec{
+		Folder:       s.Location.Folder,
+		Cluster:      s.Location.Cluster,
+		Host:         s.Location.Host,
+		ResourcePool: s.Location.ResourcePool,
+		Datastore:    s.Location.Datastore,
+		Networks:     s.Networks,
+		Template:     s.Template,
+		ImportOVF:    s.ImportOVF,
+	})
+	if err != nil {
+		log.Printf("[WARN] Unable to check privileges: %s", err)
+		ui.Errorf("Unable to check privileges: %s", err)
+		return multistep.ActionContinue
+	}
+	if len(missing) == 0 {
+		ui.Say("The user has the privileges required by the build.")
+		return multistep.ActionContinue
+	}
+
+	lines := []string{"The user does not have the following privileges required by the build:"}
+	for _, m := range missing {
+		lines = append(lines, "  - "+m.Entity+": "+strings.Join(m.Privileges, ", "))
+	}
+	ui.Error(strings.Join(lines, "\n"))
+	return multistep.ActionContinue
+}
+
+func (s *StepCheckPrivileges) Cleanup(multistep.StateBag) {}
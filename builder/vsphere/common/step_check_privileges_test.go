@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepCheckPrivileges_Run(t *testing.T) {
+	d := driver.NewDriverMock()
+	d.MissingBuildPrivilegesResult = []driver.MissingPrivileges{
+		{Entity: `folder "/dc/vm/templates"`, Privileges: []string{"VirtualMachine.Inventory.Create", "Folder.Create"}},
+		{Entity: `network "VM Network"`, Privileges: []string{"Network.Assign"}},
+	}
+	errorBuffer := new(strings.Builder)
+	state := basicStateBag(errorBuffer)
+	state.Put("driver", d)
+
+	step := &StepCheckPrivileges{
+		Config:    &PreflightConfig{CheckPrivileges: true},
+		Location:  &LocationConfig{Folder: "templates"},
+		Networks:  []string{"VM Network"},
+		ImportOVF: true,
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if spec := d.MissingBuildPrivilegesSpec; spec == nil || spec.Folder != "templates" || !spec.ImportOVF {
+		t.Fatalf("unexpected specification: '%#v'", spec)
+	}
+	expected := `  - folder "/dc/vm/templates": VirtualMachine.Inventory.Create, Folder.Create`
+	if !strings.Contains(errorBuffer.String(), expected) || !strings.Contains(errorBuffer.String(), "Network.Assign") {
+		t.Fatalf("unexpected output: '%s'", errorBuffer.String())
+	}
+}
+
+func TestStepCheckPrivileges_RunWithPreflightChecks(t *testing.T) {
+	d := driver.NewDriverMock()
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+
+	step := &StepCheckPrivileges{
+		Config:   &PreflightConfig{CheckPrivileges: true, PreflightChecks: true},
+		Location: &LocationConfig{},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if d.MissingBuildPrivilegesSpec != nil {
+		t.Fatal("unexpected privilege check with 'preflight_checks'")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type CloudInitGuestinfoConfig
+
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The configuration parameters read by the VMware datasource of cloud-init.
+const (
+	guestinfoMetadataKey         = "guestinfo.metadata"
+	guestinfoMetadataEncodingKey = "guestinfo.metadata.encoding"
+	guestinfoUserdataKey         = "guestinfo.userdata"
+	guestinfoUserdataEncodingKey = "guestinfo.userdata.encoding"
+
+	guestinfoEncoding = "gzip+base64"
+)
+
+// CloudInitGuestinfoConfig passes cloud-init user data and metadata to the
+// guest operating system in the `guestinfo` configuration parameters read by
+// the [VMware datasource](https://cloudinit.readthedocs.io/en/latest/reference/datasources/vmware.html)
+// of cloud-init, instead of a seed image on a floppy or CD-ROM.
+//
+// The user data and metadata are rendered as templates, with `{{ .Name }}` set
+// to the name of the virtual machine, and are compressed and encoded as
+// `gzip+base64`.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	cloud_init_guestinfo {
+//	  user_data_file = "${path.root}/cloud-init/user-data"
+//	  meta_data      = <<-EOF
+//	    instance-id: {{ .Name }}
+//	    local-hostname: {{ .Name }}
+//	  EOF
+//	}
+//
+// ```
+//
+// -> **Note:** The guest operating system must have cloud-init 21.3 or later
+// and VMware Tools installed, and the `guestinfo.userdata`,
+// `guestinfo.metadata`, and their `.encoding` configuration parameters cannot
+// be set in `configuration_parameters`.
+type CloudInitGuestinfoConfig struct {
+	// The cloud-init user data, such as a `#cloud-config` document. Conflicts
+	// with `user_data_file`.
+	UserData string `mapstructure:"user_data"`
+	// The path of a file on the Packer host with the cloud-init user data.
+	// Conflicts with `user_data`.
+	UserDataFile string `mapstructure:"user_data_file"`
+	// The cloud-init metadata. Conflicts with `meta_data_file`. Defaults to
+	// an `instance-id` and a `local-hostname` set to the name of the virtual
+	// machine, since the datasource is only used if the metadata is set.
+	MetaData string `mapstructure:"meta_data"`
+	// The path of a file on the Packer host with the cloud-init metadata.
+	// Conflicts with `meta_data`.
+	MetaDataFile string `mapstructure:"meta_data_file"`
+
+	params map[string]string
+}
+
+type cloudInitTemplateData struct {
+	Name string
+}
+
+func (c *CloudInitGuestinfoConfig) Prepare(ctx *interpolate.Context, location *LocationConfig, configParams *ConfigParamsConfig) []error {
+	var errs []error
+
+	if c.UserData != "" && c.UserDataFile != "" {
+		errs = append(errs, fmt.Errorf("'user_data' and 'user_data_file' cannot both be set"))
+	}
+	if c.MetaData != "" && c.MetaDataFile != "" {
+		errs = append(errs, fmt.Errorf("'meta_data' and 'meta_data_file' cannot both be set"))
+	}
+	for _, key := range []string{guestinfoMetadataKey, guestinfoMetadataEncodingKey, guestinfoUserdataKey, guestinfoUserdataEncodingKey} {
+		if _, ok := configParams.ConfigParams[key]; ok {
+			errs = append(errs, fmt.Errorf("'configuration_parameters' cannot set '%s' when 'cloud_init_guestinfo' is set", key))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	renderCtx := *ctx
+	renderCtx.Data = &cloudInitTemplateData{Name: location.VMName}
+
+	userData, err := renderCloudInitData(&renderCtx, "user_data", c.UserData, c.UserDataFile)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	metaData, err := renderCloudInitData(&renderCtx, "meta_data", c.MetaData, c.MetaDataFile)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if metaData == "" {
+		metaData = fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", location.VMName, location.VMName)
+	}
+
+	c.params = make(map[string]string)
+	for key, data := range map[string]string{guestinfoMetadataKey: metaData, guestinfoUserdataKey: userData} {
+		if data == "" {
+			continue
+		}
+		encoded, err := encodeGuestinfo(data)
+		if err != nil {
+			return append(errs, err)
+		}
+		c.params[key] = encoded
+		c.params[key+".encoding"] = guestinfoEncoding
+	}
+
+	return nil
+}
+
+// ConfigParams returns the configuration parameters with the encoded user
+// data and metadata.
+func (c *CloudInitGuestinfoConfig) ConfigParams() map[string]string {
+	return c.params
+}
+
+// renderCloudInitData renders the inline data or the content of the file, if
+// either is set.
+func renderCloudInitData(ctx *interpolate.Context, name string, data string, path string) (string, error) {
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading '%s_file': %s", name, err)
+		}
+		data = string(b)
+	}
+	if data == "" {
+		return "", nil
+	}
+
+	rendered, err := interpolate.Render(data, ctx)
+	if err != nil {
+		return "", fmt.Errorf("error rendering '%s': %s", name, err)
+	}
+	return rendered, nil
+}
+
+// encodeGuestinfo compresses and encodes the data as `gzip+base64`.
+func encodeGuestinfo(data string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+type StepCloudInitGuestinfo struct {
+	Config *CloudInitGuestinfoConfig
+}
+
+// Run adds the cloud-init user data and metadata to the configuration
+// parameters of the virtual machine before it is powered on.
+func (s *StepCloudInitGuestinfo) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config == nil {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Adding cloud-init user data and metadata...")
+	if err := vm.AddConfigParams(s.Config.ConfigParams(), nil); err != nil {
+		state.Put("error", fmt.Errorf("error adding cloud-init user data and metadata: %v", err))
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCloudInitGuestinfo) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func decodeGuestinfo(t *testing.T, data string) string {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return string(decoded)
+}
+
+func TestCloudInitGuestinfoConfig_Prepare(t *testing.T) {
+	userDataFile := filepath.Join(t.TempDir(), "user-data")
+	if err := os.WriteFile(userDataFile, []byte("#cloud-config\nhostname: {{ .Name }}\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name             string
+		config           CloudInitGuestinfoConfig
+		configParams     map[string]string
+		fail             bool
+		expectedErrMsg   string
+		expectedUserData string
+		expectedMetaData string
+	}{
+		{
+			name:             "Default metadata",
+			config:           CloudInitGuestinfoConfig{UserData: "#cloud-config\n"},
+			expectedUserData: "#cloud-config\n",
+			expectedMetaData: "instance-id: vm\nlocal-hostname: vm\n",
+		},
+		{
+			name: "Rendered user data file and metadata",
+			config: CloudInitGuestinfoConfig{
+				UserDataFile: userDataFile,
+				MetaData:     "instance-id: {{ .Name }}-1\n",
+			},
+			expectedUserData: "#cloud-config\nhostname: vm\n",
+			expectedMetaData: "instance-id: vm-1\n",
+		},
+		{
+			name: "User data and user data file",
+			config: CloudInitGuestinfoConfig{
+				UserData:     "#cloud-config\n",
+				UserDataFile: userDataFile,
+			},
+			fail:           true,
+			expectedErrMsg: "'user_data' and 'user_data_file' cannot both be set",
+		},
+		{
+			name:           "Missing user data file",
+			config:         CloudInitGuestinfoConfig{UserDataFile: filepath.Join(t.TempDir(), "missing")},
+			fail:           true,
+			expectedErrMsg: "error reading 'user_data_file'",
+		},
+		{
+			name:           "Conflicting configuration parameter",
+			config:         CloudInitGuestinfoConfig{UserData: "#cloud-config\n"},
+			configParams:   map[string]string{"guestinfo.userdata": "data"},
+			fail:           true,
+			expectedErrMsg: "'configuration_parameters' cannot set 'guestinfo.userdata' when 'cloud_init_guestinfo' is set",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "vm"}, &ConfigParamsConfig{ConfigParams: c.configParams})
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatalf("unexpected success: expected failure")
+				}
+				if !strings.Contains(errs[0].Error(), c.expectedErrMsg) {
+					t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErrMsg, errs[0])
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+
+			params := c.config.ConfigParams()
+			for _, key := range []string{"guestinfo.userdata.encoding", "guestinfo.metadata.encoding"} {
+				if params[key] != "gzip+base64" {
+					t.Fatalf("unexpected result: expected '%s', but returned '%s'", "gzip+base64", params[key])
+				}
+			}
+			if userData := decodeGuestinfo(t, params["guestinfo.userdata"]); userData != c.expectedUserData {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expectedUserData, userData)
+			}
+			if metaData := decodeGuestinfo(t, params["guestinfo.metadata"]); metaData != c.expectedMetaData {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expectedMetaData, metaData)
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/vmware/govmomi/vim25/types"
 
@@ -45,8 +47,13 @@ type ConfigParamsConfig struct {
 	// ~> **Note:** Configuration keys that would conflict with parameters that
 	// are explicitly configurable through other fields in the `ConfigSpec`` object
 	// are silently ignored. Refer to the [`VirtualMachineConfigSpec`](https://dp-downloads.broadcom.com/api-content/apis/API_VWSA_001/8.0U3/html/ReferenceGuides/vim.vm.ConfigSpec.html)
-	// in the vSphere API documentation.
+	// in the vSphere API documentation. A warning is displayed for a key that
+	// is set by another option, such as `firmware` for the `firmware` option
+	// or `vtpm.present` for the `vTPM` option.
 	ConfigParams map[string]string `mapstructure:"configuration_parameters"`
+	// Fail the build if a key in `configuration_parameters` is set by another
+	// option, instead of displaying a warning. Defaults to `false`.
+	ConfigParamsStrict bool `mapstructure:"configuration_parameters_strict"`
 	// Enable time synchronization with the ESXi host where the virtual machine
 	// is running. Defaults to `false`.
 	ToolsSyncTime bool `mapstructure:"tools_sync_time"`
@@ -55,6 +62,82 @@ type ConfigParamsConfig struct {
 	ToolsUpgradePolicy bool `mapstructure:"tools_upgrade_policy"`
 }
 
+type ConfigParamOption struct {
+	// The key of the configuration parameter that the option applies.
+	Key string
+	// The name of the option.
+	Option string
+	// Whether the option is set.
+	Set bool
+}
+
+// hardwareConfigParamOptions returns the options of the hardware that are
+// applied as configuration parameters.
+func hardwareConfigParamOptions(hw *HardwareConfig, flags *FlagConfig) []ConfigParamOption {
+	return []ConfigParamOption{
+		{Key: "numvcpus", Option: "CPUs", Set: hw.CPUs != 0},
+		{Key: "cpuid.coresPerSocket", Option: "cpu_cores", Set: hw.CpuCores != 0},
+		{Key: "sched.cpu.min", Option: "CPU_reservation", Set: hw.CPUReservation != 0},
+		{Key: "sched.cpu.max", Option: "CPU_limit", Set: hw.CPULimit != 0},
+		{Key: "vcpu.hotadd", Option: "CPU_hot_plug", Set: hw.CpuHotAddEnabled},
+		{Key: "memsize", Option: "RAM", Set: hw.RAM != 0},
+		{Key: "sched.mem.min", Option: "RAM_reservation", Set: hw.RAMReservation != 0},
+		{Key: "sched.mem.pin", Option: "RAM_reserve_all", Set: hw.RAMReserveAll},
+		{Key: "mem.hotadd", Option: "RAM_hot_plug", Set: hw.MemoryHotAddEnabled},
+		{Key: "svga.vramSize", Option: "video_ram", Set: hw.VideoRAM != 0},
+		{Key: "svga.numDisplays", Option: "displays", Set: hw.Displays != 0},
+		{Key: "vhv.enable", Option: "NestedHV", Set: hw.NestedHV},
+		{Key: "firmware", Option: "firmware", Set: hw.Firmware != ""},
+		{Key: "uefi.secureBoot.enabled", Option: "firmware", Set: hw.Firmware == "efi-secure"},
+		{Key: "bios.forceSetupOnce", Option: "force_bios_setup", Set: hw.ForceBIOSSetup},
+		{Key: "vtpm.present", Option: "vTPM", Set: hw.VTPMEnabled},
+		{Key: "vbs.enable", Option: "vbs_enabled", Set: flags.VbsEnabled},
+		{Key: "vvtd.enable", Option: "vvtd_enabled", Set: flags.VvtdEnabled},
+	}
+}
+
+// Prepare checks the configuration parameters against the options of the
+// virtual machine that are applied as configuration parameters, and against
+// the options of the builder. A configuration parameter of an option is
+// ignored by vSphere if the option is set, and otherwise bypasses the
+// validation of the option, so it is reported as a warning, or as an error if
+// `configuration_parameters_strict` is set.
+func (c *ConfigParamsConfig) Prepare(hw *HardwareConfig, flags *FlagConfig, options ...ConfigParamOption) ([]string, []error) {
+	var warnings []string
+	var errs []error
+
+	options = append(hardwareConfigParamOptions(hw, flags), options...)
+	options = append(options,
+		ConfigParamOption{Key: "tools.syncTime", Option: "tools_sync_time", Set: c.ToolsSyncTime},
+		ConfigParamOption{Key: "tools.upgrade.policy", Option: "tools_upgrade_policy", Set: c.ToolsUpgradePolicy},
+	)
+
+	for key := range c.ConfigParams {
+		for _, option := range options {
+			// The keys of the configuration parameters are not case-sensitive.
+			if !strings.EqualFold(key, option.Key) {
+				continue
+			}
+			var msg string
+			if option.Set {
+				msg = fmt.Sprintf("'configuration_parameters' key '%s' conflicts with '%s', which takes precedence", key, option.Option)
+			} else {
+				msg = fmt.Sprintf("'configuration_parameters' key '%s' is set by '%s', which should be used instead", key, option.Option)
+			}
+			if c.ConfigParamsStrict {
+				errs = append(errs, fmt.Errorf("%s", msg))
+			} else {
+				warnings = append(warnings, msg)
+			}
+		}
+	}
+	// Sort the results, since the keys of the map are not ordered.
+	sort.Strings(warnings)
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+
+	return warnings, errs
+}
+
 type StepConfigParams struct {
 	Config *ConfigParamsConfig
 }
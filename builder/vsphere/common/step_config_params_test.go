@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfigParamsConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name             string
+		config           *ConfigParamsConfig
+		hardware         *HardwareConfig
+		options          []ConfigParamOption
+		expectedWarnings []string
+		expectedErrs     []string
+	}{
+		{
+			name: "Parameters without options",
+			config: &ConfigParamsConfig{
+				ConfigParams: map[string]string{"disk.EnableUUID": "TRUE"},
+			},
+			hardware: &HardwareConfig{Firmware: "efi"},
+		},
+		{
+			name: "Parameter of a set option",
+			config: &ConfigParamsConfig{
+				ConfigParams: map[string]string{"Firmware": "bios"},
+			},
+			hardware: &HardwareConfig{Firmware: "efi"},
+			expectedWarnings: []string{
+				"'configuration_parameters' key 'Firmware' conflicts with 'firmware', which takes precedence",
+			},
+		},
+		{
+			name: "Secure boot parameter with efi firmware",
+			config: &ConfigParamsConfig{
+				ConfigParams: map[string]string{"uefi.secureBoot.enabled": "TRUE"},
+			},
+			hardware: &HardwareConfig{Firmware: "efi"},
+			expectedWarnings: []string{
+				"'configuration_parameters' key 'uefi.secureBoot.enabled' is set by 'firmware', which should be used instead",
+			},
+		},
+		{
+			name: "Secure boot parameter with efi-secure firmware",
+			config: &ConfigParamsConfig{
+				ConfigParams: map[string]string{"uefi.secureBoot.enabled": "FALSE"},
+			},
+			hardware: &HardwareConfig{Firmware: "efi-secure"},
+			expectedWarnings: []string{
+				"'configuration_parameters' key 'uefi.secureBoot.enabled' conflicts with 'firmware', which takes precedence",
+			},
+		},
+		{
+			name: "Parameter of an unset option",
+			config: &ConfigParamsConfig{
+				ConfigParams: map[string]string{"vtpm.present": "TRUE"},
+			},
+			hardware: &HardwareConfig{},
+			expectedWarnings: []string{
+				"'configuration_parameters' key 'vtpm.present' is set by 'vTPM', which should be used instead",
+			},
+		},
+		{
+			name: "Parameter of a builder option",
+			config: &ConfigParamsConfig{
+				ConfigParams: map[string]string{"guestOS": "other"},
+			},
+			hardware: &HardwareConfig{},
+			options:  []ConfigParamOption{{Key: "guestOS", Option: "guest_os_type", Set: true}},
+			expectedWarnings: []string{
+				"'configuration_parameters' key 'guestOS' conflicts with 'guest_os_type', which takes precedence",
+			},
+		},
+		{
+			name: "Strict parameters",
+			config: &ConfigParamsConfig{
+				ConfigParams:       map[string]string{"numvcpus": "4", "tools.syncTime": "TRUE"},
+				ConfigParamsStrict: true,
+				ToolsSyncTime:      true,
+			},
+			hardware: &HardwareConfig{},
+			expectedErrs: []string{
+				"'configuration_parameters' key 'numvcpus' is set by 'CPUs', which should be used instead",
+				"'configuration_parameters' key 'tools.syncTime' conflicts with 'tools_sync_time', which takes precedence",
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			warnings, errs := c.config.Prepare(c.hardware, &FlagConfig{}, c.options...)
+			if diff := cmp.Diff(c.expectedWarnings, warnings); diff != "" {
+				t.Fatalf("unexpected warnings: %s", diff)
+			}
+			var messages []string
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			if diff := cmp.Diff(c.expectedErrs, messages); diff != "" {
+				t.Fatalf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
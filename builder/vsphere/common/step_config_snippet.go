@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type ConfigSnippetConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Configuration parameter prefixes that are managed by vSphere at runtime and
+// are not included in the generated configuration snippet.
+var configSnippetExcludedPrefixes = []string{
+	"guestinfo.",
+	"migrate.",
+	"monitor.phys_bits_used",
+	"numa.autosize.",
+	"nvram",
+	"pciBridge",
+	"sched.",
+	"scsi",
+	"softPowerOff",
+	"svga.present",
+	"tools.",
+	"toolsInstallManager.",
+	"viv.moid",
+	"vmotion.",
+	"vmware.tools.",
+	"vmxstats.",
+}
+
+type ConfigSnippetConfig struct {
+	// Write an HCL fragment that captures the hardware settings and
+	// configuration parameters of the virtual machine at the end of the build.
+	// The fragment can be committed and reused as the canonical hardware
+	// definition for future builds. Defaults to `false`.
+	GenerateConfigSnippet bool `mapstructure:"generate_config_snippet"`
+	// The path of the generated HCL fragment. Defaults to
+	// `<vm_name>-hardware.hcl` in the current working directory.
+	ConfigSnippetPath string `mapstructure:"config_snippet_path"`
+}
+
+func (c *ConfigSnippetConfig) Prepare(lc *LocationConfig) []error {
+	var errs []error
+
+	if !c.GenerateConfigSnippet {
+		if c.ConfigSnippetPath != "" {
+			errs = append(errs, fmt.Errorf("'config_snippet_path' requires 'generate_config_snippet' to be set to 'true'"))
+		}
+		return errs
+	}
+
+	if c.ConfigSnippetPath == "" {
+		c.ConfigSnippetPath = fmt.Sprintf("%s-hardware.hcl", lc.VMName)
+	}
+
+	return errs
+}
+
+type StepGenerateConfigSnippet struct {
+	Config *ConfigSnippetConfig
+}
+
+func (s *StepGenerateConfigSnippet) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.GenerateConfigSnippet {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(*driver.VirtualMachineDriver)
+
+	ui.Say("Generating configuration snippet...")
+	info, err := vm.Info("config")
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving virtual machine configuration: %s", err))
+		return multistep.ActionHalt
+	}
+
+	snippet := renderConfigSnippet(info)
+
+	if dir := filepath.Dir(s.Config.ConfigSnippetPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			state.Put("error", fmt.Errorf("error creating directory for configuration snippet: %s", err))
+			return multistep.ActionHalt
+		}
+	}
+
+	if err := os.WriteFile(s.Config.ConfigSnippetPath, snippet, 0644); err != nil {
+		state.Put("error", fmt.Errorf("error writing configuration snippet: %s", err))
+		return multistep.ActionHalt
+	}
+
+	ui.Sayf("Configuration snippet written to %s", s.Config.ConfigSnippetPath)
+	state.Put("config_snippet_path", s.Config.ConfigSnippetPath)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepGenerateConfigSnippet) Cleanup(multistep.StateBag) {}
+
+// renderConfigSnippet renders the hardware settings and configuration
+// parameters of a virtual machine using the builder configuration attribute
+// names.
+func renderConfigSnippet(info *mo.VirtualMachine) []byte {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+
+	if info == nil || info.Config == nil {
+		return f.Bytes()
+	}
+	config := info.Config
+
+	body.AppendUnstructuredTokens(hclwrite.Tokens{
+		{Bytes: []byte(fmt.Sprintf("# Generated by Packer from virtual machine %q.\n", config.Name))},
+	})
+
+	body.SetAttributeValue("CPUs", cty.NumberIntVal(int64(config.Hardware.NumCPU)))
+	if config.Hardware.NumCoresPerSocket > 0 {
+		body.SetAttributeValue("cpu_cores", cty.NumberIntVal(int64(config.Hardware.NumCoresPerSocket)))
+	}
+	if config.CpuAllocation != nil {
+		if config.CpuAllocation.Reservation != nil && *config.CpuAllocation.Reservation > 0 {
+			body.SetAttributeValue("CPU_reservation", cty.NumberIntVal(*config.CpuAllocation.Reservation))
+		}
+		if config.CpuAllocation.Limit != nil && *config.CpuAllocation.Limit > 0 {
+			body.SetAttributeValue("CPU_limit", cty.NumberIntVal(*config.CpuAllocation.Limit))
+		}
+	}
+	if isTrue(config.CpuHotAddEnabled) {
+		body.SetAttributeValue("CPU_hot_plug", cty.True)
+	}
+
+	body.SetAttributeValue("RAM", cty.NumberIntVal(int64(config.Hardware.MemoryMB)))
+	if isTrue(config.MemoryReservationLockedToMax) {
+		body.SetAttributeValue("RAM_reserve_all", cty.True)
+	} else if config.MemoryAllocation != nil && config.MemoryAllocation.Reservation != nil && *config.MemoryAllocation.Reservation > 0 {
+		body.SetAttributeValue("RAM_reservation", cty.NumberIntVal(*config.MemoryAllocation.Reservation))
+	}
+	if isTrue(config.MemoryHotAddEnabled) {
+		body.SetAttributeValue("RAM_hot_plug", cty.True)
+	}
+	if isTrue(config.NestedHVEnabled) {
+		body.SetAttributeValue("NestedHV", cty.True)
+	}
+
+	if config.Firmware != "" {
+		firmware := config.Firmware
+		if firmware == "efi" && config.BootOptions != nil && isTrue(config.BootOptions.EfiSecureBootEnabled) {
+			firmware = "efi-secure"
+		}
+		body.SetAttributeValue("firmware", cty.StringVal(firmware))
+	}
+
+	devices := object.VirtualDeviceList(config.Hardware.Device)
+	for _, d := range devices.SelectByType((*types.VirtualMachineVideoCard)(nil)) {
+		card := d.(*types.VirtualMachineVideoCard)
+		body.SetAttributeValue("video_ram", cty.NumberIntVal(card.VideoRamSizeInKB))
+		body.SetAttributeValue("displays", cty.NumberIntVal(int64(card.NumDisplays)))
+		break
+	}
+	if len(devices.SelectByType((*types.VirtualTPM)(nil))) > 0 {
+		body.SetAttributeValue("vTPM", cty.True)
+	}
+	for _, d := range devices.SelectByType((*types.VirtualPrecisionClock)(nil)) {
+		backing, ok := d.GetVirtualDevice().Backing.(*types.VirtualPrecisionClockSystemClockBackingInfo)
+		if ok && backing.Protocol != "" {
+			body.SetAttributeValue("precision_clock", cty.StringVal(backing.Protocol))
+		}
+		break
+	}
+
+	if config.Tools != nil {
+		if isTrue(config.Tools.SyncTimeWithHost) {
+			body.SetAttributeValue("tools_sync_time", cty.True)
+		}
+		if config.Tools.ToolsUpgradePolicy == "UpgradeAtPowerCycle" {
+			body.SetAttributeValue("tools_upgrade_policy", cty.True)
+		}
+	}
+
+	params := configSnippetParams(config.ExtraConfig)
+	if len(params) > 0 {
+		body.SetAttributeValue("configuration_parameters", cty.MapVal(params))
+	}
+
+	return hclwrite.Format(f.Bytes())
+}
+
+// configSnippetParams returns the configuration parameters that are not
+// managed by vSphere at runtime.
+func configSnippetParams(extraConfig []types.BaseOptionValue) map[string]cty.Value {
+	params := make(map[string]cty.Value)
+	for _, option := range extraConfig {
+		o := option.GetOptionValue()
+		if o == nil || excludedConfigSnippetParam(o.Key) {
+			continue
+		}
+		params[o.Key] = cty.StringVal(fmt.Sprintf("%v", o.Value))
+	}
+	return params
+}
+
+func excludedConfigSnippetParam(key string) bool {
+	for _, prefix := range configSnippetExcludedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestConfigSnippetConfig_Prepare(t *testing.T) {
+	lc := &LocationConfig{VMName: "vm-01"}
+
+	c := &ConfigSnippetConfig{GenerateConfigSnippet: true}
+	if errs := c.Prepare(lc); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if c.ConfigSnippetPath != "vm-01-hardware.hcl" {
+		t.Fatalf("unexpected result: expected 'vm-01-hardware.hcl', but returned '%s'", c.ConfigSnippetPath)
+	}
+
+	c = &ConfigSnippetConfig{ConfigSnippetPath: "hardware.hcl"}
+	if errs := c.Prepare(lc); len(errs) != 1 {
+		t.Fatalf("unexpected result: expected one error, but returned %d", len(errs))
+	}
+}
+
+func TestRenderConfigSnippet(t *testing.T) {
+	reservation := int64(1024)
+	info := &mo.VirtualMachine{
+		Config: &types.VirtualMachineConfigInfo{
+			Name:     "golden",
+			Firmware: "efi",
+			BootOptions: &types.VirtualMachineBootOptions{
+				EfiSecureBootEnabled: types.NewBool(true),
+			},
+			CpuHotAddEnabled: types.NewBool(true),
+			MemoryAllocation: &types.ResourceAllocationInfo{
+				Reservation: &reservation,
+			},
+			Hardware: types.VirtualHardware{
+				NumCPU:            4,
+				NumCoresPerSocket: 2,
+				MemoryMB:          8192,
+				Device: []types.BaseVirtualDevice{
+					&types.VirtualMachineVideoCard{VideoRamSizeInKB: 8192, NumDisplays: 1},
+					&types.VirtualTPM{},
+				},
+			},
+			ExtraConfig: []types.BaseOptionValue{
+				&types.OptionValue{Key: "disk.EnableUUID", Value: "TRUE"},
+				&types.OptionValue{Key: "guestinfo.appInfo", Value: "runtime"},
+				&types.OptionValue{Key: "vmware.tools.internalversion", Value: "12352"},
+			},
+		},
+	}
+
+	snippet := renderConfigSnippet(info)
+
+	_, diags := hclsyntax.ParseConfig(snippet, "snippet.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected error parsing snippet: %s\n%s", diags, snippet)
+	}
+
+	for _, expected := range []string{
+		`CPUs`, `= 4`,
+		`cpu_cores`, `= 2`,
+		`CPU_hot_plug`,
+		`RAM_reservation`, `= 1024`,
+		`firmware`, `"efi-secure"`,
+		`video_ram`, `= 8192`,
+		`vTPM`,
+		`"disk.EnableUUID" = "TRUE"`,
+	} {
+		if !strings.Contains(string(snippet), expected) {
+			t.Errorf("expected snippet to contain %q:\n%s", expected, snippet)
+		}
+	}
+
+	for _, unexpected := range []string{"guestinfo.appInfo", "vmware.tools.internalversion"} {
+		if strings.Contains(string(snippet), unexpected) {
+			t.Errorf("expected snippet to not contain %q:\n%s", unexpected, snippet)
+		}
+	}
+}
@@ -11,19 +11,34 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
+const (
+	defaultTaskRetryCount = 3
+	defaultTaskRetryDelay = 5 * time.Second
+)
+
 type ConnectConfig struct {
 	// The fully qualified domain name or IP address of the vCenter Server
 	// instance.
+	//
+	// -> **Note:** A standalone ESXi host can be used instead of a vCenter
+	// Server instance. Content libraries, cloning, and converting to a
+	// template require vCenter Server and are not supported on a standalone
+	// ESXi host.
 	VCenterServer string `mapstructure:"vcenter_server"`
-	// The username to authenticate with the vCenter Server instance.
+	// The username to authenticate with the vCenter Server instance. Not
+	// required if `vcenter_client_cert_file` is set, to log in with the client
+	// certificate, or to log in with a SAML token or a session ticket.
 	Username string `mapstructure:"username"`
-	// The password to authenticate with the vCenter Server instance.
+	// The password to authenticate with the vCenter Server instance. Not
+	// required if `vcenter_client_cert_file` is set, to log in with the client
+	// certificate, or to log in with a SAML token or a session ticket.
 	Password string `mapstructure:"password"`
 	// Do not validate the certificate of the vCenter Server instance.
 	// Defaults to `false`.
@@ -31,11 +46,98 @@ type ConnectConfig struct {
 	// -> **Note:** This option is beneficial in scenarios where the certificate
 	// is self-signed or does not meet standard validation criteria.
 	InsecureConnection bool `mapstructure:"insecure_connection"`
+	// The path of a PEM file with the certificates of the certificate
+	// authorities that issued the certificate of the vCenter Server instance,
+	// such as the VMware Certificate Authority (VMCA) root certificate. The
+	// certificates are trusted in addition to the certificate authorities of
+	// the system. Cannot be used with `insecure_connection`.
+	CACertFile string `mapstructure:"vcenter_ca_cert_file"`
+	// The PEM-encoded certificates of the certificate authorities that issued
+	// the certificate of the vCenter Server instance, such as the content of
+	// a variable, instead of or in addition to `vcenter_ca_cert_file`. Cannot
+	// be used with `insecure_connection`.
+	CACertPEM string `mapstructure:"vcenter_ca_cert_pem"`
+	// The path of a PEM file with a client certificate to present to vCenter
+	// Server, for environments that enforce mutual TLS. If `username` is not
+	// set, the certificate is also used to log in as a solution user of
+	// vCenter Single Sign-On, which requires vCenter Server and cannot be used
+	// with `session_cache`. Requires `vcenter_client_key_file`.
+	ClientCertFile string `mapstructure:"vcenter_client_cert_file"`
+	// The path of a PEM file with the private key of the client certificate.
+	// Requires `vcenter_client_cert_file`.
+	ClientKeyFile string `mapstructure:"vcenter_client_key_file"`
+	// A SAML token issued by vCenter Single Sign-On to log in instead of
+	// `username` and `password`, for environments that require a federated
+	// identity provider, such as Active Directory Federation Services or
+	// Microsoft Entra ID, and do not allow local accounts. A bearer token is
+	// used as is. A holder-of-key token requires the client certificate of the
+	// token in `vcenter_client_cert_file`. Requires vCenter Server and cannot
+	// be used with `username`, `session_cache`, `vcenter_saml_token_file`, or
+	// `vcenter_session_ticket`.
+	//
+	// -> **Note:** The token must be valid for the duration of the build,
+	// since the vSphere Automation API logs in with the token when a content
+	// library or a tag is used.
+	SAMLToken string `mapstructure:"vcenter_saml_token"`
+	// The path of a file with the SAML token, instead of `vcenter_saml_token`,
+	// such as a file that is written by the tooling of the identity provider.
+	SAMLTokenFile string `mapstructure:"vcenter_saml_token_file"`
+	// The clone ticket of an existing authenticated session to log in instead
+	// of `username` and `password`, such as a ticket that is acquired with
+	// `govc session.login -clone`. A ticket can be used only once and expires
+	// after a short time. Cannot be used with `username`, `session_cache`,
+	// `vcenter_saml_token`, or `vcenter_saml_token_file`.
+	SessionTicket string `mapstructure:"vcenter_session_ticket"`
 	// The name of the datacenter object in the vSphere inventory.
 	//
 	// -> **Note:** Required if more than one datacenter object exists in the
 	// vSphere inventory.
 	Datacenter string `mapstructure:"datacenter"`
+	// Reuse an authenticated session from a session cache on the Packer host
+	// instead of logging in for each build, and save new sessions to the
+	// cache. Concurrent builds wait for each other to log in, so that they
+	// share a single session, and a new session is created if the cached
+	// session has expired. Cached sessions are not logged out at the end of
+	// the build. Defaults to `false`.
+	//
+	// -> **Note:** This option reduces the number of sessions on vCenter
+	// Server when many builds run at the same time. The cache is compatible
+	// with the session cache of govc.
+	SessionCache bool `mapstructure:"session_cache"`
+	// The directory of the session cache. Defaults to `$GOVMOMI_HOME`, or
+	// `$HOME/.govmomi` if the variable is not set, which is also used by
+	// govc.
+	SessionCacheDir string `mapstructure:"session_cache_directory"`
+	// The number of times to retry a vSphere task that fails with a transient
+	// error, such as a task in progress on the same object, a network error,
+	// or vCenter Server being temporarily unavailable. Applies to
+	// reconfiguring, migrating, and powering on the virtual machine. The
+	// tasks that clone, create, or import the virtual machine are not started
+	// again, since a task that appears to fail may have created the virtual
+	// machine, and only waiting for their result is retried. Set to `0` to
+	// disable the retries. Defaults to `3`.
+	TaskRetryCount *int `mapstructure:"task_retry_count"`
+	// The amount of time to wait before the first retry of a vSphere task. The
+	// delay is doubled for each subsequent retry. Set to `0s` to retry
+	// without a delay. Defaults to `5s`.
+	TaskRetryDelay *time.Duration `mapstructure:"task_retry_delay"`
+	// The amount of time that vCenter Server can be unreachable before the
+	// build fails, instead of waiting for a call in progress to time out,
+	// which can take more than 30 minutes, such as when vCenter Server
+	// restarts. Reachability is checked in the background while the build
+	// runs. The step in progress fails with an error that reports since when
+	// vCenter Server has been unreachable, and the cleanup of the build waits
+	// for vCenter Server to be reachable again for up to the same amount of
+	// time. For example, `5m`. Defaults to `0s`, which disables the check.
+	UnreachableTimeout time.Duration `mapstructure:"unreachable_timeout"`
+	// The path of a file to which the vSphere API calls are logged, for
+	// troubleshooting a failed call, such as a virtual machine
+	// reconfiguration that vCenter Server rejects. Each request and response
+	// is logged as XML with the time and the duration of the call. The
+	// content of elements that contain credentials, such as passwords, is
+	// redacted. The file is overwritten if it exists. The vSphere Automation
+	// API calls, such as the content library calls, are not logged.
+	VCenterAPILogPath string `mapstructure:"vcenter_api_log_path"`
 }
 
 func (c *ConnectConfig) Prepare() []error {
@@ -44,28 +146,102 @@ func (c *ConnectConfig) Prepare() []error {
 	if c.VCenterServer == "" {
 		errs = append(errs, fmt.Errorf("'vcenter_server' is required"))
 	}
-	if c.Username == "" {
-		errs = append(errs, fmt.Errorf("'username' is required"))
+	tokenLogin := 0
+	for _, v := range []string{c.SAMLToken, c.SAMLTokenFile, c.SessionTicket} {
+		if v != "" {
+			tokenLogin++
+		}
+	}
+	if tokenLogin > 1 {
+		errs = append(errs, fmt.Errorf("only one of 'vcenter_saml_token', 'vcenter_saml_token_file', or 'vcenter_session_ticket' can be set"))
+	}
+	if tokenLogin > 0 {
+		if c.Username != "" || c.Password != "" {
+			errs = append(errs, fmt.Errorf("'username' and 'password' cannot be used with 'vcenter_saml_token', 'vcenter_saml_token_file', or 'vcenter_session_ticket'"))
+		}
+		if c.SessionCache {
+			errs = append(errs, fmt.Errorf("'session_cache' cannot be used with 'vcenter_saml_token', 'vcenter_saml_token_file', or 'vcenter_session_ticket'"))
+		}
+	} else if c.ClientCertFile == "" {
+		if c.Username == "" {
+			errs = append(errs, fmt.Errorf("'username' is required"))
+		}
+		if c.Password == "" {
+			errs = append(errs, fmt.Errorf("'password' is required"))
+		}
+	} else if c.Username == "" && c.SessionCache {
+		errs = append(errs, fmt.Errorf("'session_cache' cannot be used to log in with 'vcenter_client_cert_file'; set 'username' and 'password'"))
+	}
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		errs = append(errs, fmt.Errorf("'vcenter_client_cert_file' and 'vcenter_client_key_file' must be used together"))
+	}
+	if c.InsecureConnection && (c.CACertFile != "" || c.CACertPEM != "") {
+		errs = append(errs, fmt.Errorf("'insecure_connection' cannot be used with 'vcenter_ca_cert_file' or 'vcenter_ca_cert_pem'"))
+	}
+	if c.SessionCacheDir != "" && !c.SessionCache {
+		errs = append(errs, fmt.Errorf("'session_cache_directory' requires 'session_cache'"))
 	}
-	if c.Password == "" {
-		errs = append(errs, fmt.Errorf("'password' is required"))
+	if c.TaskRetryCount == nil {
+		count := defaultTaskRetryCount
+		c.TaskRetryCount = &count
+	}
+	if *c.TaskRetryCount < 0 {
+		errs = append(errs, fmt.Errorf("'task_retry_count' must be greater than or equal to 0"))
+	}
+	if c.TaskRetryDelay == nil {
+		delay := defaultTaskRetryDelay
+		c.TaskRetryDelay = &delay
+	}
+	if *c.TaskRetryDelay < 0 {
+		errs = append(errs, fmt.Errorf("'task_retry_delay' must be greater than or equal to 0"))
+	}
+	if c.UnreachableTimeout < 0 {
+		errs = append(errs, fmt.Errorf("'unreachable_timeout' must be greater than or equal to 0"))
 	}
 
 	return errs
 }
 
+// DriverConfig returns the configuration of the connection of the driver to
+// vCenter Server. The task retries are set to their defaults if the
+// configuration is not prepared.
+func (c *ConnectConfig) DriverConfig() *driver.ConnectConfig {
+	taskRetryCount, taskRetryDelay := defaultTaskRetryCount, defaultTaskRetryDelay
+	if c.TaskRetryCount != nil {
+		taskRetryCount = *c.TaskRetryCount
+	}
+	if c.TaskRetryDelay != nil {
+		taskRetryDelay = *c.TaskRetryDelay
+	}
+
+	return &driver.ConnectConfig{
+		VCenterServer:      c.VCenterServer,
+		Username:           c.Username,
+		Password:           c.Password,
+		InsecureConnection: c.InsecureConnection,
+		Datacenter:         c.Datacenter,
+		SessionCache:       c.SessionCache,
+		SessionCacheDir:    c.SessionCacheDir,
+		TaskRetryCount:     taskRetryCount,
+		TaskRetryDelay:     taskRetryDelay,
+		UnreachableTimeout: c.UnreachableTimeout,
+		APILogPath:         c.VCenterAPILogPath,
+		CACertFile:         c.CACertFile,
+		CACertPEM:          c.CACertPEM,
+		ClientCertFile:     c.ClientCertFile,
+		ClientKeyFile:      c.ClientKeyFile,
+		SAMLToken:          c.SAMLToken,
+		SAMLTokenFile:      c.SAMLTokenFile,
+		SessionTicket:      c.SessionTicket,
+	}
+}
+
 type StepConnect struct {
 	Config *ConnectConfig
 }
 
 func (s *StepConnect) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
-	d, err := driver.NewDriver(&driver.ConnectConfig{
-		VCenterServer:      s.Config.VCenterServer,
-		Username:           s.Config.Username,
-		Password:           s.Config.Password,
-		InsecureConnection: s.Config.InsecureConnection,
-		Datacenter:         s.Config.Datacenter,
-	})
+	d, err := driver.NewDriver(s.Config.DriverConfig())
 	if err != nil {
 		state.Put("error", err)
 		return multistep.ActionHalt
@@ -14,7 +14,20 @@ type FlatConnectConfig struct {
 	Username           *string `mapstructure:"username" cty:"username" hcl:"username"`
 	Password           *string `mapstructure:"password" cty:"password" hcl:"password"`
 	InsecureConnection *bool   `mapstructure:"insecure_connection" cty:"insecure_connection" hcl:"insecure_connection"`
+	CACertFile         *string `mapstructure:"vcenter_ca_cert_file" cty:"vcenter_ca_cert_file" hcl:"vcenter_ca_cert_file"`
+	CACertPEM          *string `mapstructure:"vcenter_ca_cert_pem" cty:"vcenter_ca_cert_pem" hcl:"vcenter_ca_cert_pem"`
+	ClientCertFile     *string `mapstructure:"vcenter_client_cert_file" cty:"vcenter_client_cert_file" hcl:"vcenter_client_cert_file"`
+	ClientKeyFile      *string `mapstructure:"vcenter_client_key_file" cty:"vcenter_client_key_file" hcl:"vcenter_client_key_file"`
+	SAMLToken          *string `mapstructure:"vcenter_saml_token" cty:"vcenter_saml_token" hcl:"vcenter_saml_token"`
+	SAMLTokenFile      *string `mapstructure:"vcenter_saml_token_file" cty:"vcenter_saml_token_file" hcl:"vcenter_saml_token_file"`
+	SessionTicket      *string `mapstructure:"vcenter_session_ticket" cty:"vcenter_session_ticket" hcl:"vcenter_session_ticket"`
 	Datacenter         *string `mapstructure:"datacenter" cty:"datacenter" hcl:"datacenter"`
+	SessionCache       *bool   `mapstructure:"session_cache" cty:"session_cache" hcl:"session_cache"`
+	SessionCacheDir    *string `mapstructure:"session_cache_directory" cty:"session_cache_directory" hcl:"session_cache_directory"`
+	TaskRetryCount     *int    `mapstructure:"task_retry_count" cty:"task_retry_count" hcl:"task_retry_count"`
+	TaskRetryDelay     *string `mapstructure:"task_retry_delay" cty:"task_retry_delay" hcl:"task_retry_delay"`
+	UnreachableTimeout *string `mapstructure:"unreachable_timeout" cty:"unreachable_timeout" hcl:"unreachable_timeout"`
+	VCenterAPILogPath  *string `mapstructure:"vcenter_api_log_path" cty:"vcenter_api_log_path" hcl:"vcenter_api_log_path"`
 }
 
 // FlatMapstructure returns a new FlatConnectConfig.
@@ -29,11 +42,24 @@ func (*ConnectConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec
 // The decoded values from this spec will then be applied to a FlatConnectConfig.
 func (*FlatConnectConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"vcenter_server":      &hcldec.AttrSpec{Name: "vcenter_server", Type: cty.String, Required: false},
-		"username":            &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
-		"password":            &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
-		"insecure_connection": &hcldec.AttrSpec{Name: "insecure_connection", Type: cty.Bool, Required: false},
-		"datacenter":          &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
+		"vcenter_server":           &hcldec.AttrSpec{Name: "vcenter_server", Type: cty.String, Required: false},
+		"username":                 &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
+		"password":                 &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
+		"insecure_connection":      &hcldec.AttrSpec{Name: "insecure_connection", Type: cty.Bool, Required: false},
+		"vcenter_ca_cert_file":     &hcldec.AttrSpec{Name: "vcenter_ca_cert_file", Type: cty.String, Required: false},
+		"vcenter_ca_cert_pem":      &hcldec.AttrSpec{Name: "vcenter_ca_cert_pem", Type: cty.String, Required: false},
+		"vcenter_client_cert_file": &hcldec.AttrSpec{Name: "vcenter_client_cert_file", Type: cty.String, Required: false},
+		"vcenter_client_key_file":  &hcldec.AttrSpec{Name: "vcenter_client_key_file", Type: cty.String, Required: false},
+		"vcenter_saml_token":       &hcldec.AttrSpec{Name: "vcenter_saml_token", Type: cty.String, Required: false},
+		"vcenter_saml_token_file":  &hcldec.AttrSpec{Name: "vcenter_saml_token_file", Type: cty.String, Required: false},
+		"vcenter_session_ticket":   &hcldec.AttrSpec{Name: "vcenter_session_ticket", Type: cty.String, Required: false},
+		"datacenter":               &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
+		"session_cache":            &hcldec.AttrSpec{Name: "session_cache", Type: cty.Bool, Required: false},
+		"session_cache_directory":  &hcldec.AttrSpec{Name: "session_cache_directory", Type: cty.String, Required: false},
+		"task_retry_count":         &hcldec.AttrSpec{Name: "task_retry_count", Type: cty.Number, Required: false},
+		"task_retry_delay":         &hcldec.AttrSpec{Name: "task_retry_delay", Type: cty.String, Required: false},
+		"unreachable_timeout":      &hcldec.AttrSpec{Name: "unreachable_timeout", Type: cty.String, Required: false},
+		"vcenter_api_log_path":     &hcldec.AttrSpec{Name: "vcenter_api_log_path", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// StepConnectNetworkAdapters connects or disconnects the network adapters at
+// the specified indices, such as the adapters configured to start
+// disconnected, when the communicator needs them and before the virtual
+// machine is converted to a template.
+type StepConnectNetworkAdapters struct {
+	Adapters []int
+	Connect  bool
+}
+
+func (s *StepConnectNetworkAdapters) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Adapters) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	action := "connecting"
+	if s.Connect {
+		ui.Say("Connecting network adapters...")
+	} else {
+		action = "disconnecting"
+		ui.Say("Disconnecting network adapters...")
+	}
+
+	if err := vm.SetNetworkAdaptersConnected(s.Adapters, s.Connect); err != nil {
+		state.Put("error", fmt.Errorf("error %s network adapters: %v", action, err))
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepConnectNetworkAdapters) Cleanup(state multistep.StateBag) {
+	// no cleanup
+}
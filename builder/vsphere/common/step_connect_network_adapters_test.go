@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepConnectNetworkAdapters_Run(t *testing.T) {
+	tc := []struct {
+		name           string
+		step           *StepConnectNetworkAdapters
+		expectedAction multistep.StepAction
+		vmMock         *driver.VirtualMachineMock
+		expectedVmMock *driver.VirtualMachineMock
+		errMessage     string
+	}{
+		{
+			name:           "No disconnected network adapters.",
+			step:           &StepConnectNetworkAdapters{Connect: true},
+			expectedAction: multistep.ActionContinue,
+			vmMock:         &driver.VirtualMachineMock{},
+			expectedVmMock: &driver.VirtualMachineMock{},
+		},
+		{
+			name:           "Successfully connect network adapters.",
+			step:           &StepConnectNetworkAdapters{Adapters: []int{0, 2}, Connect: true},
+			expectedAction: multistep.ActionContinue,
+			vmMock:         &driver.VirtualMachineMock{},
+			expectedVmMock: &driver.VirtualMachineMock{
+				SetNetworkAdaptersConnectedCalled:    true,
+				SetNetworkAdaptersConnectedIndices:   []int{0, 2},
+				SetNetworkAdaptersConnectedConnected: true,
+			},
+		},
+		{
+			name:           "Fail to disconnect network adapters.",
+			step:           &StepConnectNetworkAdapters{Adapters: []int{1}},
+			expectedAction: multistep.ActionHalt,
+			vmMock: &driver.VirtualMachineMock{
+				SetNetworkAdaptersConnectedErr: fmt.Errorf("network adapter 1 not found"),
+			},
+			expectedVmMock: &driver.VirtualMachineMock{
+				SetNetworkAdaptersConnectedCalled:  true,
+				SetNetworkAdaptersConnectedIndices: []int{1},
+			},
+			errMessage: "error disconnecting network adapters: network adapter 1 not found",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := basicStateBag(nil)
+			state.Put("vm", c.vmMock)
+
+			if action := c.step.Run(context.TODO(), state); action != c.expectedAction {
+				t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", c.expectedAction, action)
+			}
+			err, ok := state.Get("error").(error)
+			if ok {
+				if err.Error() != c.errMessage {
+					t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.errMessage, err)
+				}
+			} else if c.errMessage != "" {
+				t.Fatalf("unexpected success, expected error: '%s'", c.errMessage)
+			}
+
+			if diff := cmp.Diff(c.vmMock, c.expectedVmMock,
+				cmpopts.IgnoreInterfaces(struct{ error }{})); diff != "" {
+				t.Fatalf("unexpected '%s' calls: %s", "VirtualMachine", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name   string
+		config ConnectConfig
+		fail   bool
+	}{
+		{
+			name:   "Username and password",
+			config: ConnectConfig{VCenterServer: "vcenter", Username: "user", Password: "pass"},
+		},
+		{
+			name:   "Missing password",
+			config: ConnectConfig{VCenterServer: "vcenter", Username: "user"},
+			fail:   true,
+		},
+		{
+			name:   "Client certificate",
+			config: ConnectConfig{VCenterServer: "vcenter", ClientCertFile: "client.pem", ClientKeyFile: "client.key"},
+		},
+		{
+			name:   "Client certificate without key",
+			config: ConnectConfig{VCenterServer: "vcenter", ClientCertFile: "client.pem"},
+			fail:   true,
+		},
+		{
+			name:   "Client certificate with session cache",
+			config: ConnectConfig{VCenterServer: "vcenter", ClientCertFile: "client.pem", ClientKeyFile: "client.key", SessionCache: true},
+			fail:   true,
+		},
+		{
+			name:   "SAML token",
+			config: ConnectConfig{VCenterServer: "vcenter", SAMLToken: "<saml2:Assertion/>"},
+		},
+		{
+			name:   "SAML token with username",
+			config: ConnectConfig{VCenterServer: "vcenter", Username: "user", Password: "pass", SAMLToken: "<saml2:Assertion/>"},
+			fail:   true,
+		},
+		{
+			name:   "SAML token and token file",
+			config: ConnectConfig{VCenterServer: "vcenter", SAMLToken: "<saml2:Assertion/>", SAMLTokenFile: "token.xml"},
+			fail:   true,
+		},
+		{
+			name:   "Session ticket",
+			config: ConnectConfig{VCenterServer: "vcenter", SessionTicket: "cst-VCT-ticket"},
+		},
+		{
+			name:   "Session ticket with session cache",
+			config: ConnectConfig{VCenterServer: "vcenter", SessionTicket: "cst-VCT-ticket", SessionCache: true},
+			fail:   true,
+		},
+		{
+			name:   "Negative task retry count",
+			config: ConnectConfig{VCenterServer: "vcenter", Username: "user", Password: "pass", TaskRetryCount: &[]int{-1}[0]},
+			fail:   true,
+		},
+		{
+			name:   "CA certificates with insecure connection",
+			config: ConnectConfig{VCenterServer: "vcenter", Username: "user", Password: "pass", CACertFile: "ca.pem", InsecureConnection: true},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare()
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+		})
+	}
+}
+
+func TestConnectConfig_DriverConfig(t *testing.T) {
+	config := &ConnectConfig{
+		VCenterServer:      "vcenter.example.com",
+		Username:           "administrator@vsphere.local",
+		Password:           "secret",
+		UnreachableTimeout: 5 * time.Minute,
+		SAMLTokenFile:      "token.xml",
+	}
+
+	// The task retries default to the defaults of Prepare.
+	d := config.DriverConfig()
+	if d.TaskRetryCount != defaultTaskRetryCount || d.TaskRetryDelay != defaultTaskRetryDelay {
+		t.Fatalf("unexpected task retries: '%d', '%s'", d.TaskRetryCount, d.TaskRetryDelay)
+	}
+	if d.VCenterServer != "vcenter.example.com" || d.UnreachableTimeout != 5*time.Minute || d.SAMLTokenFile != "token.xml" {
+		t.Fatalf("unexpected driver configuration: '%#v'", d)
+	}
+
+	count, delay := 0, time.Duration(0)
+	config.TaskRetryCount = &count
+	config.TaskRetryDelay = &delay
+	d = config.DriverConfig()
+	if d.TaskRetryCount != 0 || d.TaskRetryDelay != 0 {
+		t.Fatalf("unexpected task retries: '%d', '%s'", d.TaskRetryCount, d.TaskRetryDelay)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type CrashDumpConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The name of the snapshot that holds the memory of the virtual machine, and
+// of the directory of the crash dump in the output directory.
+const crashDumpName = "packer-crash-dump"
+
+type CrashDumpConfig struct {
+	// Collect a crash dump of the virtual machine if the build fails or is
+	// cancelled, such as after the guest operating system stops responding
+	// with a kernel panic or a stop error during the provisioning, so that
+	// the failure can be analyzed after the virtual machine is destroyed.
+	// Defaults to `false`.
+	//
+	// If the virtual machine is powered on, a snapshot that includes the
+	// memory of the virtual machine is created. The snapshot state and memory
+	// files, the suspended state files, the core dumps, and the logs of the
+	// virtual machine are then downloaded to the `packer-crash-dump`
+	// directory in the output directory. The output directory is the
+	// `output_directory` of the [export configuration](#export-configuration),
+	// or `output-<buildName>` if the virtual machine is not exported. The
+	// memory file can be converted for a debugger with the `vmss2core` tool.
+	//
+	// ~> **Note:** The memory file is the size of the memory of the virtual
+	// machine.
+	CrashDump bool `mapstructure:"crash_dump"`
+
+	outputDir string
+}
+
+func (c *CrashDumpConfig) Prepare(export *ExportConfig, pc *common.PackerConfig) []error {
+	if !c.CrashDump {
+		return nil
+	}
+
+	if export != nil {
+		c.outputDir = export.OutputDir.OutputDir
+	} else {
+		c.outputDir = fmt.Sprintf("output-%s", pc.PackerBuildName)
+	}
+
+	return nil
+}
+
+// Dir returns the local directory of the crash dump.
+func (c *CrashDumpConfig) Dir() string {
+	return filepath.Join(c.outputDir, crashDumpName)
+}
+
+type StepCollectCrashDump struct {
+	Config *CrashDumpConfig
+}
+
+func (s *StepCollectCrashDump) Run(context.Context, multistep.StateBag) multistep.StepAction {
+	return multistep.ActionContinue
+}
+
+// Cleanup collects the crash dump of the virtual machine if the build failed
+// or was cancelled. The step follows StepRun, so that the crash dump is
+// collected before the virtual machine is powered off.
+func (s *StepCollectCrashDump) Cleanup(state multistep.StateBag) {
+	if !s.Config.CrashDump {
+		return
+	}
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+
+	vm, ok := state.Get("vm").(driver.VirtualMachine)
+	if !ok {
+		return
+	}
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Say("Collecting crash dump...")
+	poweredOff, err := vm.IsPoweredOff()
+	if err != nil {
+		ui.Errorf("error checking the power state of the virtual machine: %s", err)
+	} else if !poweredOff {
+		ui.Say("Creating snapshot with the memory of the virtual machine...")
+		if err := vm.CreateMemorySnapshot(crashDumpName); err != nil {
+			ui.Errorf("error creating memory snapshot: %s", err)
+		}
+	}
+
+	files, err := vm.DiagnosticFiles()
+	if err != nil {
+		ui.Errorf("error listing the files of the virtual machine: %s", err)
+		return
+	}
+	var collected int
+	for _, file := range files {
+		dst := filepath.Join(s.Config.Dir(), path.Base(file))
+		if err := downloadDatastoreFile(d, file, dst); err != nil {
+			ui.Errorf("error downloading %s: %s", file, err)
+			continue
+		}
+		collected++
+	}
+	ui.Sayf("Crash dump of %d files saved to %s", collected, s.Config.Dir())
+}
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestCrashDumpConfig_Prepare(t *testing.T) {
+	config := &CrashDumpConfig{CrashDump: true}
+	if errs := config.Prepare(nil, &common.PackerConfig{PackerBuildName: "example"}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if expected := filepath.Join("output-example", "packer-crash-dump"); config.Dir() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, config.Dir())
+	}
+}
+
+func TestStepCollectCrashDump_Cleanup(t *testing.T) {
+	dir := t.TempDir()
+	config := &CrashDumpConfig{CrashDump: true, outputDir: dir}
+	step := &StepCollectCrashDump{Config: config}
+
+	state := basicStateBag(nil)
+	vm := &driver.VirtualMachineMock{
+		DiagnosticFilesReturn: []string{"[datastore1] example/example-Snapshot1.vmsn"},
+	}
+	d := new(driver.DriverMock)
+	state.Put("vm", vm)
+	state.Put("driver", d)
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	step.Cleanup(state)
+	if vm.CreateMemorySnapshotCalled || d.FindDatastoreCalled {
+		t.Fatal("unexpected result: expected no crash dump for a successful build")
+	}
+
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	if vm.CreateMemorySnapshotName != "packer-crash-dump" {
+		t.Fatalf("unexpected result: expected 'packer-crash-dump', but returned '%s'", vm.CreateMemorySnapshotName)
+	}
+	if d.DatastoreMock.DownloadFileSrc != "example/example-Snapshot1.vmsn" {
+		t.Fatalf("unexpected result: expected 'example/example-Snapshot1.vmsn', but returned '%s'", d.DatastoreMock.DownloadFileSrc)
+	}
+	if expected := filepath.Join(dir, "packer-crash-dump", "example-Snapshot1.vmsn"); d.DatastoreMock.DownloadFileDst != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, d.DatastoreMock.DownloadFileDst)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type CustomAttributesConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+type CustomAttributesConfig struct {
+	// The values of the custom attributes to set on the virtual machine or
+	// template after the build completes, by name. Custom attributes that do
+	// not exist in vCenter Server are created for virtual machines, which
+	// requires the `Global.ManageCustomFields` privilege. An empty value
+	// clears the custom attribute.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	//   custom_attributes = {
+	//     "owner" = "platform-team"
+	//   }
+	// ```
+	//
+	// JSON Example:
+	//
+	// ```json
+	//   "custom_attributes": {
+	//     "owner": "platform-team"
+	//   }
+	// ```
+	CustomAttributes map[string]string `mapstructure:"custom_attributes"`
+}
+
+func (c *CustomAttributesConfig) Prepare() []error {
+	var errs []error
+	for name := range c.CustomAttributes {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("'custom_attributes' names must not be empty"))
+		}
+	}
+	return errs
+}
+
+type StepSetCustomAttributes struct {
+	Config *CustomAttributesConfig
+}
+
+// Run sets the custom attributes in the order of their names, so that the
+// custom attributes created by concurrent builds are created in the same
+// order.
+func (s *StepSetCustomAttributes) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Config.CustomAttributes) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	names := make([]string, 0, len(s.Config.CustomAttributes))
+	for name := range s.Config.CustomAttributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ui.Say("Setting custom attributes of the virtual machine...")
+	for _, name := range names {
+		key, err := d.FindOrCreateCustomAttributeKey(name)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		if err := vm.SetCustomAttribute(key, s.Config.CustomAttributes[name]); err != nil {
+			state.Put("error", fmt.Errorf("error setting custom attribute %s: %s", name, err))
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepSetCustomAttributes) Cleanup(multistep.StateBag) {}
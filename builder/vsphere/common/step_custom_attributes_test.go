@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestCustomAttributesConfig_Prepare(t *testing.T) {
+	config := &CustomAttributesConfig{CustomAttributes: map[string]string{"owner": "platform-team"}}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+
+	config = &CustomAttributesConfig{CustomAttributes: map[string]string{"": "platform-team"}}
+	errs := config.Prepare()
+	if len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+	expected := "'custom_attributes' names must not be empty"
+	if errs[0].Error() != expected {
+		t.Fatalf("unexpected error: expected '%s', but returned '%s'", expected, errs[0])
+	}
+}
+
+func TestStepSetCustomAttributes_Run(t *testing.T) {
+	d := driver.NewDriverMock()
+	d.FindOrCreateCustomAttributeKeyResult = map[string]int32{"owner": 101, "cost-center": 102}
+	vm := new(driver.VirtualMachineMock)
+
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	state.Put("vm", vm)
+	step := &StepSetCustomAttributes{Config: &CustomAttributesConfig{
+		CustomAttributes: map[string]string{"owner": "platform-team", "cost-center": "1234"},
+	}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	if diff := cmp.Diff([]string{"cost-center", "owner"}, d.FindOrCreateCustomAttributeKeyNames); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+	expected := map[int32]string{101: "platform-team", 102: "1234"}
+	if diff := cmp.Diff(expected, vm.SetCustomAttributeValues); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+}
+
+func TestStepSetCustomAttributes_RunError(t *testing.T) {
+	d := driver.NewDriverMock()
+	vm := new(driver.VirtualMachineMock)
+	vm.SetCustomAttributeErr = fmt.Errorf("permission denied")
+
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	state.Put("vm", vm)
+	step := &StepSetCustomAttributes{Config: &CustomAttributesConfig{
+		CustomAttributes: map[string]string{"owner": "platform-team"},
+	}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	err, ok := state.Get("error").(error)
+	if !ok {
+		t.Fatal("unexpected success: expected failure")
+	}
+	expected := "error setting custom attribute owner: permission denied"
+	if err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// StepCreateDifferentialBase enables changed block tracking and creates the
+// base snapshot of a differential export before the virtual machine is first
+// powered on, so that the export can find the disks changed by the build.
+type StepCreateDifferentialBase struct {
+	SnapshotName string
+}
+
+func (s *StepCreateDifferentialBase) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.SnapshotName == "" {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Enabling changed block tracking...")
+	if err := vm.EnableChangeTracking(); err != nil {
+		state.Put("error", fmt.Errorf("error enabling changed block tracking: %s", err))
+		return multistep.ActionHalt
+	}
+
+	ui.Sayf("Creating differential export base snapshot %s...", s.SnapshotName)
+	if err := vm.CreateSnapshot(s.SnapshotName); err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCreateDifferentialBase) Cleanup(multistep.StateBag) {}
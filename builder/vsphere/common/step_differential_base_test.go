@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepCreateDifferentialBase_Run(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepCreateDifferentialBase{SnapshotName: "base"}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if !vm.EnableChangeTrackingCalled {
+		t.Fatal("unexpected result: expected changed block tracking to be enabled")
+	}
+	if vm.CreateSnapshotName != "base" {
+		t.Fatalf("unexpected result: expected 'base', but returned '%s'", vm.CreateSnapshotName)
+	}
+}
+
+func TestStepCreateDifferentialBase_RunSkipped(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepCreateDifferentialBase{}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.EnableChangeTrackingCalled || vm.CreateSnapshotCalled {
+		t.Fatal("unexpected result: expected no changes to the virtual machine")
+	}
+}
+
+func TestStepCreateDifferentialBase_RunError(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	vm.EnableChangeTrackingErr = fmt.Errorf("not supported")
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepCreateDifferentialBase{SnapshotName: "base"}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.CreateSnapshotCalled {
+		t.Fatal("unexpected result: expected no snapshot to be created")
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("unexpected result: expected an error in the state")
+	}
+}
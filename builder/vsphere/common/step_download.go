@@ -50,7 +50,7 @@ func (s *StepDownload) Run(ctx context.Context, state multistep.StateBag) multis
 	ui := state.Get("ui").(packersdk.Ui)
 
 	// Set the remote cache datastore. If not set, use the default datastore for the build.
-	remoteCacheDatastore := s.Datastore
+	remoteCacheDatastore := datastoreName(state, s.Datastore)
 	if s.RemoteCacheDatastore != "" {
 		remoteCacheDatastore = s.RemoteCacheDatastore
 	}
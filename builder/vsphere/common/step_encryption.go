@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type EncryptionConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The name of the VM encryption storage policy that vCenter Server provides.
+const defaultEncryptionPolicy = "VM Encryption Policy"
+
+// The home and the disks of the virtual machine are encrypted with a new key
+// of the key provider before the virtual machine is powered on, so that the
+// guest operating system is installed on encrypted disks. The build verifies
+// that the key provider exists and that the host, or the hosts of the
+// cluster, support encryption before the virtual machine is created. If the
+// build fails or is cancelled, the key is removed from vCenter Server with the
+// virtual machine, unless it is a key of a native key provider or the key is
+// in use.
+//
+// -> **Note:** Requires vCenter Server and the `Cryptographer.*` privileges.
+// First Class Disks are not encrypted.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	encryption {
+//	    key_provider = "native-key-provider"
+//	    policy       = "VM Encryption Policy"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"encryption": {
+//	    "key_provider": "native-key-provider",
+//	    "policy": "VM Encryption Policy"
+//	}
+//
+// ```
+type EncryptionConfig struct {
+	// The identifier of the key provider, such as a vSphere Native Key
+	// Provider or a standard key provider, that generates the key. Defaults
+	// to the default key provider of the host or cluster, or of vCenter
+	// Server.
+	KeyProvider string `mapstructure:"key_provider"`
+	// The name of the VM storage policy with the encryption rule to apply to
+	// the virtual machine home and the disks. Defaults to
+	// `VM Encryption Policy`.
+	Policy string `mapstructure:"policy"`
+}
+
+func (c *EncryptionConfig) Prepare(storage *StorageConfig) []error {
+	var errs []error
+
+	if c.Policy == "" {
+		c.Policy = defaultEncryptionPolicy
+	}
+	if storage.StoragePolicy != "" {
+		errs = append(errs, fmt.Errorf("'storage_policy' cannot be used with 'encryption'; set 'encryption.policy' instead"))
+	}
+	for i, disk := range storage.Storage {
+		if disk.DiskStoragePolicy != "" {
+			errs = append(errs, fmt.Errorf("storage[%d].'disk_storage_policy' cannot be used with 'encryption'", i))
+		}
+	}
+	return errs
+}
+
+type StepCheckEncryption struct {
+	Config   *EncryptionConfig
+	Location *LocationConfig
+}
+
+func (s *StepCheckEncryption) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config == nil {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Say("Checking encryption support...")
+	provider, err := d.CheckEncryption(s.Config.KeyProvider, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error checking encryption support: %s", err))
+		return multistep.ActionHalt
+	}
+	policyID, err := d.FindStoragePolicy(s.Config.Policy)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error finding encryption policy %s: %s", s.Config.Policy, err))
+		return multistep.ActionHalt
+	}
+
+	if provider == "" {
+		ui.Say("Using the default key provider for encryption.")
+	} else {
+		ui.Sayf("Using key provider %s for encryption.", provider)
+	}
+	state.Put("encryption", &driver.EncryptionSpec{
+		KeyProvider:     provider,
+		StoragePolicyID: policyID,
+	})
+	return multistep.ActionContinue
+}
+
+// Cleanup removes the key of a failed or cancelled build. The key is removed
+// after the virtual machine is destroyed, since a key that is in use is not
+// removed.
+func (s *StepCheckEncryption) Cleanup(state multistep.StateBag) {
+	key, ok := state.GetOk("encryption_key")
+	if !ok {
+		return
+	}
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+
+	d := state.Get("driver").(driver.Driver)
+	spec := state.Get("encryption").(*driver.EncryptionSpec)
+	if err := d.RemoveEncryptionKey(spec.KeyProvider, key.(string)); err != nil {
+		log.Printf("[WARN] Failed to remove encryption key %s: %s", key, err)
+	}
+}
+
+type StepEncryptVM struct {
+	Config *EncryptionConfig
+}
+
+func (s *StepEncryptVM) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config == nil {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+	spec := state.Get("encryption").(*driver.EncryptionSpec)
+
+	ui.Say("Encrypting virtual machine...")
+	key, err := vm.Encrypt(spec)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error encrypting virtual machine: %s", err))
+		return multistep.ActionHalt
+	}
+	state.Put("encryption_key", key)
+	return multistep.ActionContinue
+}
+
+func (s *StepEncryptVM) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestEncryptionConfig_Prepare(t *testing.T) {
+	config := &EncryptionConfig{}
+	if errs := config.Prepare(&StorageConfig{}); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if config.Policy != defaultEncryptionPolicy {
+		t.Errorf("unexpected result: expected %q, but returned %q", defaultEncryptionPolicy, config.Policy)
+	}
+
+	storage := &StorageConfig{
+		StoragePolicy: "vSAN Default Storage Policy",
+		Storage:       []DiskConfig{{DiskSize: 1024, DiskStoragePolicy: "gold"}},
+	}
+	if errs := config.Prepare(storage); len(errs) != 2 {
+		t.Fatalf("unexpected result: expected 2 errors, but returned %d: %v", len(errs), errs)
+	}
+}
+
+func TestStepCheckEncryption_Run(t *testing.T) {
+	state := basicStateBag(nil)
+	d := driver.NewDriverMock()
+	d.CheckEncryptionResult = "native-provider"
+	d.FindStoragePolicyResult = map[string]string{defaultEncryptionPolicy: "policy-1"}
+	state.Put("driver", d)
+
+	step := &StepCheckEncryption{
+		Config:   &EncryptionConfig{Policy: defaultEncryptionPolicy},
+		Location: &LocationConfig{Cluster: "cluster"},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	spec, ok := state.GetOk("encryption")
+	if !ok {
+		t.Fatal("unexpected result: expected 'encryption' in state")
+	}
+	expected := driver.EncryptionSpec{KeyProvider: "native-provider", StoragePolicyID: "policy-1"}
+	if *spec.(*driver.EncryptionSpec) != expected {
+		t.Errorf("unexpected result: expected '%+v', but returned '%+v'", expected, spec)
+	}
+
+	d.CheckEncryptionErr = fmt.Errorf("host does not support virtual machine encryption")
+	state = basicStateBag(nil)
+	state.Put("driver", d)
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+}
+
+func TestStepCheckEncryption_Cleanup(t *testing.T) {
+	for _, halted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("halted %t", halted), func(t *testing.T) {
+			state := basicStateBag(nil)
+			d := driver.NewDriverMock()
+			state.Put("driver", d)
+			state.Put("encryption", &driver.EncryptionSpec{KeyProvider: "kms"})
+			state.Put("encryption_key", "key-1")
+			if halted {
+				state.Put(multistep.StateHalted, true)
+			}
+
+			step := &StepCheckEncryption{Config: &EncryptionConfig{}}
+			step.Cleanup(state)
+			if d.RemoveEncryptionKeyCalled != halted {
+				t.Fatalf("unexpected result: expected RemoveEncryptionKey called to be %t", halted)
+			}
+			if halted && (d.RemoveEncryptionKeyKeyProvider != "kms" || d.RemoveEncryptionKeyKeyID != "key-1") {
+				t.Errorf("unexpected key removed: %s %s", d.RemoveEncryptionKeyKeyProvider, d.RemoveEncryptionKeyKeyID)
+			}
+		})
+	}
+}
+
+func TestStepEncryptVM_Run(t *testing.T) {
+	state := basicStateBag(nil)
+	vm := new(driver.VirtualMachineMock)
+	vm.EncryptResult = "key-1"
+	state.Put("vm", vm)
+	spec := &driver.EncryptionSpec{KeyProvider: "native-provider"}
+	state.Put("encryption", spec)
+
+	step := &StepEncryptVM{Config: &EncryptionConfig{}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if vm.EncryptSpec != spec {
+		t.Errorf("unexpected result: expected the encryption spec to be used")
+	}
+	if key, _ := state.GetOk("encryption_key"); key != "key-1" {
+		t.Errorf("unexpected result: expected 'key-1', but returned '%v'", key)
+	}
+
+	step = &StepEncryptVM{}
+	vm.EncryptCalled = false
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue || vm.EncryptCalled {
+		t.Fatal("unexpected result: expected the step to be skipped")
+	}
+}
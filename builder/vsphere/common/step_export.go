@@ -18,8 +18,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
@@ -28,8 +33,10 @@ import (
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 	"github.com/pkg/errors"
 	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/vim25/progress"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/sync/errgroup"
 )
 
 const OvftoolWindows = "ovftool.exe"
@@ -74,6 +81,10 @@ const OvftoolWindows = "ovftool.exe"
 // ./output-artifacts/example-ubuntu.mf
 // ./output-artifacts/example-ubuntu.ovf
 // ```
+//
+// The OVF descriptor is validated before it is written, and the export fails
+// if the descriptor is not a valid OVF envelope or references files that are
+// not exported.
 type ExportConfig struct {
 	// The name of the exported image in Open Virtualization Format (OVF).
 	//
@@ -94,6 +105,19 @@ type ExportConfig struct {
 	//
 	// --> **Tip:** Use `none` to disable the creation of a manifest file.
 	Manifest string `mapstructure:"manifest"`
+	// The path to a PEM-encoded RSA private key on the Packer host to sign the
+	// manifest with. Defaults to not signing the manifest.
+	//
+	// When set, a signature file with the extension `.cert` is created with
+	// the signature of the manifest and the certificate, as created by
+	// ovftool, so that vCenter Server can verify the image when it is
+	// imported. The private key must not be encrypted.
+	//
+	// ~> **Note:** This option cannot be used if `manifest` is set to `none`.
+	SigningKey string `mapstructure:"signing_key"`
+	// The path to the PEM-encoded X.509 certificate of the public key of
+	// `signing_key`. Defaults to the certificate in the `signing_key` file.
+	SigningCertificate string `mapstructure:"signing_certificate"`
 	// The path to the directory where the exported image will be saved.
 	OutputDir OutputConfig `mapstructure:",squash"`
 	// Advanced image export options. Available options include:
@@ -125,6 +149,30 @@ type ExportConfig struct {
 	//   },
 	// ```
 	Options []string `mapstructure:"options"`
+	// Include the extra configuration options of the virtual machine in the
+	// OVF descriptor. Equivalent to the `extraconfig` export option. Defaults
+	// to `false`.
+	IncludeExtraConfig bool `mapstructure:"include_extra_config"`
+	// Include the NVRAM file of the virtual machine, which stores the EFI boot
+	// order and variables, and reference the file in the OVF descriptor, so
+	// that the settings persist when the image is imported. Unlike
+	// `image_files`, the log files of the virtual machine are not included.
+	// Defaults to `false`.
+	IncludeNvram bool `mapstructure:"include_nvram"`
+	// A list of device types to remove from the OVF descriptor, so that the
+	// image can be imported on platforms that do not support the devices.
+	// The available options for this setting are: `cdrom`, `ethernet`,
+	// `floppy`, `parallel`, `serial`, `sound`, and `usb`.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	// ...
+	//   export {
+	//     exclude_devices = ["cdrom", "serial"]
+	//   }
+	// ```
+	ExcludeDevices []string `mapstructure:"exclude_devices"`
 	// The output format for the exported virtual machine image.
 	// Defaults to `ovf`. Available options include `ovf` and `ova`.
 	//
@@ -137,8 +185,65 @@ type ExportConfig struct {
 	// installed on the Packer host and accessible in either the system `PATH`
 	// or the user's `PATH`.
 	Format string `mapstructure:"output_format"`
+	// The number of files to download concurrently from the virtual machine.
+	// Defaults to `1`. Increasing this value can reduce the time to export a
+	// virtual machine with multiple disks.
+	ParallelDownloads int `mapstructure:"parallel_downloads"`
+	// The naming of the exported files. Defaults to `default`.
+	//
+	// The available options for this setting are:
+	//
+	// - `default` - Files are named `<name>-disk-0.vmdk`, `<name>-disk-1.vmdk`,
+	//   and so on.
+	// - `vmware-iso-compatible` - Files are named as in the output of the
+	//   `vmware-iso` builder, which exports with ovftool:
+	//   `<name>-disk1.vmdk`, `<name>-disk2.vmdk`, and so on for disks, and
+	//   `<name>-file1.nvram` and so on for additional image files. Use this
+	//   option if scripts that process the output of the `vmware-iso` builder
+	//   are used with the exported files.
+	Layout string `mapstructure:"layout"`
+	// Produce deterministic output that is identical across builds of the same
+	// virtual machine. Defaults to `false`.
+	//
+	// When enabled, the instance identifiers of the virtual hardware items in
+	// the OVF descriptor are numbered sequentially, volatile attributes and
+	// extra configuration options, such as the populated size of the disks and
+	// the generated UUIDs, are removed, and the remaining extra configuration
+	// options are sorted by key. The modification times of the exported files
+	// are set to the value of the `SOURCE_DATE_EPOCH` environment variable, or
+	// to the Unix epoch if the variable is not set.
+	//
+	// ~> **Note:** This option cannot be used with the `mac` or `uuid` export
+	// options.
+	Reproducible bool `mapstructure:"reproducible_export"`
+	// The name of a snapshot to export the disks differentially against.
+	// Defaults to exporting all disks.
+	//
+	// When set, changed block tracking (CBT) is enabled and the snapshot is
+	// created before the virtual machine is first powered on. Only the disks
+	// that changed since the snapshot are exported. The OVF descriptor and the
+	// manifest reference the unchanged disks in
+	// `differential_base_directory`. For example, use this option to export
+	// only the system disk of a virtual machine cloned from a template with
+	// additional data disks that are not modified by the build.
+	//
+	// ~> **Note:** This option cannot be used with the `ova` output format.
+	DifferentialBaseSnapshot string `mapstructure:"differential_base_snapshot"`
+	// The path to the directory on the Packer host with the base export
+	// that is referenced for the unchanged disks, such as an export of the
+	// source template. The base export must use the same `name` and `layout`,
+	// and its disks must be identical to the disks of the virtual machine when
+	// the snapshot is created. Required when `differential_base_snapshot` is
+	// set.
+	DifferentialBaseDirectory string `mapstructure:"differential_base_directory"`
 }
 
+// The available layouts of the exported files.
+const (
+	ExportLayoutDefault   = "default"
+	ExportLayoutVMwareISO = "vmware-iso-compatible"
+)
+
 // Supported hash algorithms.
 var sha = map[string]func() hash.Hash{
 	"none":   nil,
@@ -202,6 +307,39 @@ func (c *ExportConfig) Prepare(ctx *interpolate.Context, lc *LocationConfig, pc
 		return []error{fmt.Errorf("unsupported output format: %s. available options include 'ovf' and 'ova'", c.Format)}
 	}
 
+	switch {
+	case c.ParallelDownloads == 0:
+		c.ParallelDownloads = 1
+	case c.ParallelDownloads < 0:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'parallel_downloads' must be greater than 0"))
+	}
+
+	switch c.Layout {
+	case "":
+		c.Layout = ExportLayoutDefault
+	case ExportLayoutDefault, ExportLayoutVMwareISO:
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unsupported layout: %s. available options include '%s' and '%s'", c.Layout, ExportLayoutDefault, ExportLayoutVMwareISO))
+	}
+
+	if c.IncludeExtraConfig && !slices.Contains(c.Options, "extraconfig") {
+		c.Options = append(c.Options, "extraconfig")
+	}
+
+	for _, device := range c.ExcludeDevices {
+		if !slices.Contains(exportDevices, device) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unsupported device type in 'exclude_devices': %s. available options include '%s'", device, strings.Join(exportDevices, "', '")))
+		}
+	}
+
+	if c.Reproducible {
+		for _, option := range c.Options {
+			if option == "mac" || option == "uuid" {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'reproducible_export' cannot be used with the '%s' export option", option))
+			}
+		}
+	}
+
 	// Check if the hash algorithm is supported.
 	switch c.Manifest {
 	case "":
@@ -212,6 +350,30 @@ func (c *ExportConfig) Prepare(ctx *interpolate.Context, lc *LocationConfig, pc
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unsupported hash: %s. available options include 'none', 'sha1', 'sha256', and 'sha512'", c.Manifest))
 	}
 
+	switch {
+	case c.SigningKey != "" && c.Manifest == "none":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'signing_key' cannot be used when 'manifest' is set to 'none'"))
+	case c.SigningKey != "":
+		if _, err := loadExportSigner(c.SigningKey, c.SigningCertificate); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	case c.SigningCertificate != "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'signing_key' is required when 'signing_certificate' is set"))
+	}
+
+	switch {
+	case c.DifferentialBaseSnapshot != "" && c.Format == "ova":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'differential_base_snapshot' cannot be used with the 'ova' output format"))
+	case c.DifferentialBaseSnapshot != "" && c.DifferentialBaseDirectory == "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'differential_base_directory' is required when 'differential_base_snapshot' is set"))
+	case c.DifferentialBaseSnapshot != "":
+		if _, err := os.Stat(c.DifferentialBaseDirectory); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unable to find 'differential_base_directory': %s", err))
+		}
+	case c.DifferentialBaseDirectory != "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'differential_base_snapshot' is required when 'differential_base_directory' is set"))
+	}
+
 	if errs != nil && len(errs.Errors) > 0 {
 		return errs.Errors
 	}
@@ -224,6 +386,213 @@ func getTarget(dir string, name string, ext string) string {
 	return filepath.Join(dir, name+ext)
 }
 
+// Returns the name of an exported file as named by ovftool in the output of
+// the vmware-iso builder, where n is the 1-based index of the disk or of the
+// additional image file.
+func vmwareISOPath(name string, path string, n int) string {
+	ext := filepath.Ext(path)
+	if ext == ".vmdk" {
+		return fmt.Sprintf("%s-disk%d.vmdk", name, n)
+	}
+	if ext == "" {
+		// Additional image files, such as the NVRAM, have no extension.
+		ext = "." + filepath.Base(path)
+	}
+	return fmt.Sprintf("%s-file%d%s", name, n, ext)
+}
+
+var (
+	// Matches the populated size of a disk, which depends on the blocks written
+	// to the disk during the build.
+	populatedSizeRe = regexp.MustCompile(` ovf:populatedSize="\d+"`)
+	// Matches the instance identifier of a virtual hardware item.
+	instanceIDRe = regexp.MustCompile(`<(\w+):InstanceID>(\d+)</\w+:InstanceID>`)
+	// Matches a reference to the instance identifier of a parent item.
+	parentRe = regexp.MustCompile(`<(\w+):Parent>(\d+)</\w+:Parent>`)
+	// Matches an extra configuration option on a single line.
+	extraConfigRe = regexp.MustCompile(`^\s*<vmw:(?:ExtraConfig|Config)\b[^>]*\bvmw:key="([^"]*)"[^>]*/>\s*$`)
+)
+
+// The device types that can be removed from the OVF descriptor.
+var exportDevices = []string{"cdrom", "ethernet", "floppy", "parallel", "serial", "sound", "usb"}
+
+// The device types of the CIM resource types of the virtual hardware items.
+var exportDeviceResourceTypes = map[string]string{
+	"10": "ethernet",
+	"14": "floppy",
+	"15": "cdrom",
+	"16": "cdrom",
+	"21": "serial",
+	"22": "parallel",
+	"23": "usb",
+}
+
+var (
+	// Matches a virtual hardware item, including the indentation and the
+	// trailing newline.
+	hardwareItemRe = regexp.MustCompile(`(?s)[ \t]*<Item\b[^>]*>.*?</Item>\n?`)
+	// Matches the resource type of a virtual hardware item.
+	resourceTypeRe = regexp.MustCompile(`<\w+:ResourceType>(\d+)</\w+:ResourceType>`)
+	// Matches the resource subtype of a virtual hardware item.
+	resourceSubTypeRe = regexp.MustCompile(`<\w+:ResourceSubType>([^<]*)</\w+:ResourceSubType>`)
+	// Matches a file reference of the OVF descriptor.
+	fileRefRe = regexp.MustCompile(`<(?:\w+:)?File\b[^>]*>`)
+	// Matches the identifier of a file reference.
+	fileIDRe = regexp.MustCompile(`\bovf:id="([^"]*)"`)
+	// Matches the NVRAM extra configuration option.
+	nvramConfigRe = regexp.MustCompile(`(<vmw:(?:ExtraConfig|Config)\b[^>]*\bvmw:key="nvram"[^>]*\bvmw:value=")[^"]*(")`)
+	// Matches the end of the virtual hardware section, including the
+	// indentation.
+	hardwareSectionEndRe = regexp.MustCompile(`([ \t]*)</(?:\w+:)?VirtualHardwareSection>`)
+)
+
+// exportDeviceType returns the device type of a virtual hardware item, or an
+// empty string if the device type cannot be removed.
+func exportDeviceType(item string) string {
+	if sub := resourceSubTypeRe.FindStringSubmatch(item); sub != nil && strings.HasPrefix(sub[1], "vmware.soundcard") {
+		return "sound"
+	}
+	if sub := resourceTypeRe.FindStringSubmatch(item); sub != nil {
+		return exportDeviceResourceTypes[sub[1]]
+	}
+	return ""
+}
+
+// excludeDevices returns the OVF descriptor without the virtual hardware
+// items of the device types.
+func excludeDevices(desc string, devices []string) string {
+	if len(devices) == 0 {
+		return desc
+	}
+	return hardwareItemRe.ReplaceAllStringFunc(desc, func(item string) string {
+		if slices.Contains(devices, exportDeviceType(item)) {
+			return ""
+		}
+		return item
+	})
+}
+
+// referenceNvram returns the OVF descriptor with the NVRAM extra
+// configuration option set to the reference of the exported NVRAM file, which
+// is added to the virtual hardware section if the option is not exported.
+func referenceNvram(desc string, path string) (string, error) {
+	var id string
+	for _, ref := range fileRefRe.FindAllString(desc, -1) {
+		if strings.Contains(ref, `ovf:href="`+path+`"`) {
+			if sub := fileIDRe.FindStringSubmatch(ref); sub != nil {
+				id = sub[1]
+			}
+			break
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("unable to find the reference of the nvram file %s in the ovf descriptor", path)
+	}
+
+	value := "ovf:/file/" + id
+	if nvramConfigRe.MatchString(desc) {
+		return nvramConfigRe.ReplaceAllString(desc, "${1}"+value+"${2}"), nil
+	}
+
+	loc := hardwareSectionEndRe.FindStringSubmatchIndex(desc)
+	if loc == nil {
+		return "", fmt.Errorf("unable to find the virtual hardware section in the ovf descriptor")
+	}
+	indent := desc[loc[2]:loc[3]]
+	config := fmt.Sprintf(`%s  <vmw:ExtraConfig ovf:required="false" vmw:key="nvram" vmw:value="%s"/>`+"\n", indent, value)
+	return desc[:loc[0]] + config + desc[loc[0]:], nil
+}
+
+// isNvram returns whether the path of an exported file is the NVRAM file of
+// the virtual machine.
+func isNvram(path string) bool {
+	return filepath.Base(path) == "nvram" || filepath.Ext(path) == ".nvram"
+}
+
+// Extra configuration options that are generated by vSphere and differ for
+// every virtual machine or every power on.
+var volatileExtraConfig = map[string]bool{
+	"migrate.hostLog":        true,
+	"sched.swap.derivedName": true,
+	"uuid.bios":              true,
+	"uuid.location":          true,
+	"vc.uuid":                true,
+	"vm.genid":               true,
+	"vm.genidX":              true,
+}
+
+// normalizeDescriptor returns the OVF descriptor with the instance identifiers
+// numbered sequentially in document order, the populated sizes and volatile
+// extra configuration options removed, and each contiguous block of extra
+// configuration options sorted by key.
+func normalizeDescriptor(desc string) string {
+	desc = populatedSizeRe.ReplaceAllString(desc, "")
+
+	ids := map[string]string{}
+	desc = instanceIDRe.ReplaceAllStringFunc(desc, func(m string) string {
+		sub := instanceIDRe.FindStringSubmatch(m)
+		id, ok := ids[sub[2]]
+		if !ok {
+			id = strconv.Itoa(len(ids) + 1)
+			ids[sub[2]] = id
+		}
+		return fmt.Sprintf("<%s:InstanceID>%s</%s:InstanceID>", sub[1], id, sub[1])
+	})
+	desc = parentRe.ReplaceAllStringFunc(desc, func(m string) string {
+		sub := parentRe.FindStringSubmatch(m)
+		id, ok := ids[sub[2]]
+		if !ok {
+			return m
+		}
+		return fmt.Sprintf("<%s:Parent>%s</%s:Parent>", sub[1], id, sub[1])
+	})
+
+	lines := strings.Split(desc, "\n")
+	result := make([]string, 0, len(lines))
+	var block []string
+	flush := func() {
+		sort.SliceStable(block, func(i, j int) bool {
+			return extraConfigRe.FindStringSubmatch(block[i])[1] < extraConfigRe.FindStringSubmatch(block[j])[1]
+		})
+		result = append(result, block...)
+		block = nil
+	}
+	for _, line := range lines {
+		sub := extraConfigRe.FindStringSubmatch(line)
+		if sub == nil {
+			flush()
+			result = append(result, line)
+			continue
+		}
+		if !volatileExtraConfig[sub[1]] {
+			block = append(block, line)
+		}
+	}
+	flush()
+
+	return strings.Join(result, "\n")
+}
+
+// setModTimes sets the access and modification times of the files to the
+// value of the SOURCE_DATE_EPOCH environment variable, or to the Unix epoch
+// if the variable is not set.
+func setModTimes(paths ...string) error {
+	t := time.Unix(0, 0)
+	if v := os.Getenv("SOURCE_DATE_EPOCH"); v != "" {
+		epoch, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %s", v, err)
+		}
+		t = time.Unix(epoch, 0)
+	}
+	for _, p := range paths {
+		if err := os.Chtimes(p, t, t); err != nil {
+			return errors.Wrapf(err, "unable to set modification time of %s", filepath.Base(p))
+		}
+	}
+	return nil
+}
+
 // Returns the name of the ovftool executable based on the operating system.
 func getOvftool() string {
 	if runtime.GOOS == "windows" {
@@ -232,24 +601,52 @@ func getOvftool() string {
 	return "ovftool"
 }
 
+// The interval between progress reports for a file that is being downloaded.
+var exportProgressInterval = 30 * time.Second
+
 type StepExport struct {
-	Name       string
-	Force      bool
-	ImageFiles bool
-	Manifest   string
-	OutputDir  string
-	Options    []string
-	Format     string
-	mf         bytes.Buffer
+	Name                      string
+	Force                     bool
+	ImageFiles                bool
+	Manifest                  string
+	OutputDir                 string
+	Options                   []string
+	IncludeNvram              bool
+	ExcludeDevices            []string
+	Format                    string
+	ParallelDownloads         int
+	Layout                    string
+	Reproducible              bool
+	SigningKey                string
+	SigningCertificate        string
+	Timeout                   time.Duration
+	DifferentialBaseSnapshot  string
+	DifferentialBaseDirectory string
+	mf                        bytes.Buffer
 }
 
 func (s *StepExport) Cleanup(multistep.StateBag) {
 }
 
+// timeoutError returns a descriptive error if the export was stopped because
+// the timeout was exceeded, and the original error otherwise.
+func (s *StepExport) timeoutError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s exporting virtual machine", s.Timeout)
+	}
+	return err
+}
+
 func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
 
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
 	// Start exporting the virtual machine image to Open Virtualization Format.
 	ui.Say("Exporting to Open Virtualization Format (OVF)...")
 	lease, err := vm.Export()
@@ -260,7 +657,7 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 
 	info, err := lease.Wait(ctx, nil)
 	if err != nil {
-		state.Put("error", err)
+		state.Put("error", s.timeoutError(ctx, err))
 		return multistep.ActionHalt
 	}
 
@@ -298,25 +695,82 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		}
 	}
 
+	var changed []bool
+	if s.DifferentialBaseSnapshot != "" {
+		ui.Sayf("Finding disks changed since snapshot %s...", s.DifferentialBaseSnapshot)
+		changed, err = vm.ChangedDisks(s.DifferentialBaseSnapshot)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+
+	// The disks are exported in device order, so the exported disks are
+	// matched to the changed disks by their position.
+	var items, downloads []nfc.FileItem
+	var disks, files, disk int
+	var nvram string
 	for _, i := range info.Items {
 		if !s.include(&i) {
 			continue
 		}
+		nvramFile := isNvram(i.Path)
 
-		if !strings.HasPrefix(i.Path, s.Name) {
+		switch {
+		case s.Layout == ExportLayoutVMwareISO && filepath.Ext(i.Path) == ".vmdk":
+			disks++
+			i.Path = vmwareISOPath(s.Name, i.Path, disks)
+		case s.Layout == ExportLayoutVMwareISO:
+			files++
+			i.Path = vmwareISOPath(s.Name, i.Path, files)
+		case !strings.HasPrefix(i.Path, s.Name):
 			i.Path = s.Name + "-" + i.Path
 		}
+		items = append(items, i)
+		if nvramFile {
+			nvram = i.Path
+		}
 
-		file := i.File()
+		if changed != nil && filepath.Ext(i.Path) == ".vmdk" {
+			unchanged := disk < len(changed) && !changed[disk]
+			disk++
+			if unchanged {
+				continue
+			}
+		}
+		downloads = append(downloads, i)
+	}
+	if changed != nil && disk != len(changed) {
+		state.Put("error", fmt.Errorf("unable to match %d exported disks to %d disks of the virtual machine", disk, len(changed)))
+		return multistep.ActionHalt
+	}
 
-		// Download the virtual machine image in Open Virtualization Format.
-		ui.Sayf("Downloading %s...", file.Path)
-		size, err := s.Download(ctx, lease, i)
-		if err != nil {
-			state.Put("error", err)
-			return multistep.ActionHalt
+	// Download the virtual machine image in Open Virtualization Format.
+	sizes, err := s.downloadAll(ctx, ui, lease, downloads)
+	if err != nil {
+		state.Put("error", s.timeoutError(ctx, err))
+		return multistep.ActionHalt
+	}
+	downloaded := make(map[string]int64, len(downloads))
+	for n, i := range downloads {
+		downloaded[i.Path] = sizes[n]
+	}
+
+	for _, i := range items {
+		size, ok := downloaded[i.Path]
+		if !ok {
+			ui.Sayf("Referencing unchanged disk %s in the differential base directory...", i.Path)
+			file, err := s.baseFile(i)
+			if err != nil {
+				state.Put("error", err)
+				return multistep.ActionHalt
+			}
+			cdp.OvfFiles = append(cdp.OvfFiles, file)
+			continue
 		}
 
+		file := i.File()
+
 		// Set the file size in the Open Virtualization Format descriptor.
 		file.Size = size
 
@@ -336,6 +790,28 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		return multistep.ActionHalt
 	}
 
+	desc.OvfDescriptor = excludeDevices(desc.OvfDescriptor, s.ExcludeDevices)
+	if s.IncludeNvram && nvram != "" {
+		desc.OvfDescriptor, err = referenceNvram(desc.OvfDescriptor, nvram)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+
+	if s.Reproducible {
+		desc.OvfDescriptor = normalizeDescriptor(desc.OvfDescriptor)
+	}
+
+	var paths []string
+	for _, f := range cdp.OvfFiles {
+		paths = append(paths, f.Path)
+	}
+	if err := validateDescriptor(desc.OvfDescriptor, paths); err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
 	target := getTarget(s.OutputDir, s.Name, ".ovf")
 	file, err := os.Create(target)
 	if err != nil {
@@ -362,6 +838,17 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		return multistep.ActionHalt
 	}
 
+	if s.Reproducible {
+		paths := []string{target}
+		for _, i := range downloads {
+			paths = append(paths, filepath.Join(s.OutputDir, i.Path))
+		}
+		if err := setModTimes(paths...); err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+
 	// Manifest file will not be created. Continue to the next step.
 	if s.Manifest == "none" {
 		return multistep.ActionContinue
@@ -377,6 +864,7 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		return multistep.ActionHalt
 	}
 
+	manifest := append([]byte(nil), s.mf.Bytes()...)
 	_, err = io.Copy(file, &s.mf)
 	if err != nil {
 		state.Put("error", errors.Wrap(err, "unable to write to manifest"))
@@ -388,6 +876,37 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		state.Put("error", errors.Wrap(err, "unable to close the manifest"))
 		return multistep.ActionHalt
 	}
+	written := []string{file.Name()}
+
+	// Sign the manifest with the private key, if specified.
+	var signer *exportSigner
+	if s.SigningKey != "" {
+		signer, err = loadExportSigner(s.SigningKey, s.SigningCertificate)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+
+		ui.Sayf("Writing signature %s...", s.Name+".cert")
+		cert, err := signer.sign(s.Manifest, s.Name+".mf", manifest)
+		if err != nil {
+			state.Put("error", errors.Wrap(err, "unable to sign the manifest"))
+			return multistep.ActionHalt
+		}
+		certPath := filepath.Join(s.OutputDir, s.Name+".cert")
+		if err := os.WriteFile(certPath, cert, 0644); err != nil {
+			state.Put("error", errors.Wrap(err, "unable to write the signature"))
+			return multistep.ActionHalt
+		}
+		written = append(written, certPath)
+	}
+
+	if s.Reproducible {
+		if err := setModTimes(written...); err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
 
 	// Check the export format to determine if the image should be converted.
 	switch s.Format {
@@ -415,9 +934,25 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		}
 
 		// Convert the Open Virtualization Format (OVF) to Open Virtualization
-		// Archive (OVA).
+		// Archive (OVA). The archive is signed by ovftool with the private
+		// key, since the signature of the manifest is not preserved.
 		ui.Say("Converting to Open Virtualization Archive (OVA)...")
-		cmd := exec.Command(ovftool, target, ovaTarget)
+		args := []string{target, ovaTarget}
+		if signer != nil {
+			dir, err := os.MkdirTemp("", "packer-export")
+			if err != nil {
+				state.Put("error", errors.Wrap(err, "unable to create temporary directory"))
+				return multistep.ActionHalt
+			}
+			defer os.RemoveAll(dir)
+			keyPath := filepath.Join(dir, "key.pem")
+			if err := os.WriteFile(keyPath, signer.privateKeyPEM(), 0600); err != nil {
+				state.Put("error", errors.Wrap(err, "unable to write the signing key for ovftool"))
+				return multistep.ActionHalt
+			}
+			args = append([]string{"--privateKey=" + keyPath}, args...)
+		}
+		cmd := exec.Command(ovftool, args...)
 		err = cmd.Run()
 		if err != nil {
 			state.Put("error", errors.Wrap(err, "unable to convert ovf to ova"))
@@ -431,6 +966,13 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 			return multistep.ActionHalt
 		}
 
+		if s.Reproducible {
+			if err := setModTimes(ovaTarget); err != nil {
+				state.Put("error", err)
+				return multistep.ActionHalt
+			}
+		}
+
 		// Clean up the files used for the conversion.
 		ui.Say("Removing intermediate files...")
 
@@ -444,8 +986,8 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 			}
 		}
 
-		// Removes the .mf, .ovf, .nvram, and .log files.
-		for _, ext := range []string{".mf", ".ovf", ".nvram", ".log"} {
+		// Removes the .mf, .cert, .ovf, .nvram, and .log files.
+		for _, ext := range []string{".mf", ".cert", ".ovf", ".nvram", ".log"} {
 			filePath := filepath.Join(s.OutputDir, s.Name+ext)
 			ui.Sayf("Removing %s...", s.Name+ext)
 			err := os.Remove(filePath)
@@ -464,9 +1006,53 @@ func (s *StepExport) include(item *nfc.FileItem) bool {
 	if s.ImageFiles {
 		return true
 	}
+	if s.IncludeNvram && isNvram(item.Path) {
+		return true
+	}
 	return filepath.Ext(item.Path) == ".vmdk"
 }
 
+// baseFile returns the file of an unchanged disk in the differential base
+// directory, with the path relative to the output directory, and adds the
+// checksum of the file to the manifest.
+func (s *StepExport) baseFile(item nfc.FileItem) (types.OvfFile, error) {
+	file := item.File()
+
+	base, err := filepath.Abs(filepath.Join(s.DifferentialBaseDirectory, item.Path))
+	if err != nil {
+		return file, err
+	}
+	dir, err := filepath.Abs(s.OutputDir)
+	if err != nil {
+		return file, err
+	}
+	rel, err := filepath.Rel(dir, base)
+	if err != nil {
+		return file, err
+	}
+	file.Path = filepath.ToSlash(rel)
+
+	f, err := os.Open(base)
+	if err != nil {
+		return file, errors.Wrapf(err, "unable to find unchanged disk %s in the differential base directory", item.Path)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return file, err
+	}
+	file.Size = info.Size()
+
+	if h, ok := s.newHash(); ok {
+		if _, err := io.Copy(h, f); err != nil {
+			return file, errors.Wrapf(err, "unable to compute the checksum of %s", item.Path)
+		}
+		s.addHash(file.Path, h)
+	}
+	return file, nil
+}
+
 func (s *StepExport) newHash() (hash.Hash, bool) {
 	// Check if the hash function is nil to handle the 'none' case.
 	if h, ok := sha[s.Manifest]; ok && h != nil {
@@ -476,26 +1062,126 @@ func (s *StepExport) newHash() (hash.Hash, bool) {
 }
 
 func (s *StepExport) addHash(p string, h hash.Hash) {
-	_, _ = fmt.Fprintf(&s.mf, "%s(%s)= %x\n", strings.ToUpper(s.Manifest), p, h.Sum(nil))
+	s.addSum(p, h.Sum(nil))
+}
+
+func (s *StepExport) addSum(p string, sum []byte) {
+	_, _ = fmt.Fprintf(&s.mf, "%s(%s)= %x\n", strings.ToUpper(s.Manifest), p, sum)
 }
 
-func (s *StepExport) Download(ctx context.Context, lease *nfc.Lease, item nfc.FileItem) (int64, error) {
+// downloadAll downloads the files from the lease, up to ParallelDownloads at
+// a time, and returns the size of each file. The hashes of the files are added
+// to the manifest in the order of the files.
+func (s *StepExport) downloadAll(ctx context.Context, ui packersdk.Ui, lease *nfc.Lease, items []nfc.FileItem) ([]int64, error) {
+	sizes := make([]int64, len(items))
+	sums := make([][]byte, len(items))
+
+	parallel := s.ParallelDownloads
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallel)
+	for n, item := range items {
+		n, item := n, item
+		g.Go(func() error {
+			ui.Sayf("Downloading %s...", item.Path)
+			size, sum, err := s.Download(ctx, ui, lease, item)
+			if err != nil {
+				return errors.Wrapf(err, "unable to download %s", item.Path)
+			}
+			sizes[n], sums[n] = size, sum
+			ui.Sayf("Downloaded %s.", item.Path)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for n, item := range items {
+		if sums[n] != nil {
+			s.addSum(item.Path, sums[n])
+		}
+	}
+	return sizes, nil
+}
+
+// Download downloads a file from the lease and returns the size of the file
+// and, if a manifest is created, the checksum of the file. The checksum is
+// computed while downloading and verified against the file written to disk.
+func (s *StepExport) Download(ctx context.Context, ui packersdk.Ui, lease *nfc.Lease, item nfc.FileItem) (int64, []byte, error) {
 	path := filepath.Join(s.OutputDir, item.Path)
-	opts := soap.Download{}
+	opts := soap.Download{
+		Progress: progress.Tee(item, newExportProgressLogger(ui, item.Path)),
+	}
 
-	if h, ok := s.newHash(); ok {
+	h, ok := s.newHash()
+	if ok {
 		opts.Writer = h
-		defer s.addHash(item.Path, h)
 	}
 
 	err := lease.DownloadFile(ctx, path, item, opts)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	f, err := os.Stat(path)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
+	}
+	if !ok {
+		return f.Size(), nil, nil
+	}
+
+	sum := h.Sum(nil)
+	if err := s.verifyChecksum(path, sum); err != nil {
+		return 0, nil, err
+	}
+	return f.Size(), sum, nil
+}
+
+// verifyChecksum verifies that the checksum of a downloaded file matches the
+// checksum computed while downloading the file.
+func (s *StepExport) verifyChecksum(path string, sum []byte) error {
+	h, ok := s.newHash()
+	if !ok {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return err
 	}
-	return f.Size(), err
+	if actual := h.Sum(nil); !bytes.Equal(actual, sum) {
+		return fmt.Errorf("checksum mismatch for %s: expected %x, but found %x", filepath.Base(path), sum, actual)
+	}
+	return nil
+}
+
+// newExportProgressLogger returns a progress sink that periodically reports
+// the progress of a file download.
+func newExportProgressLogger(ui packersdk.Ui, name string) progress.Sinker {
+	ch := make(chan progress.Report)
+	go func() {
+		last := time.Now()
+		for r := range ch {
+			if time.Since(last) < exportProgressInterval {
+				continue
+			}
+			last = time.Now()
+			if pct := r.Percentage(); pct > 0 {
+				ui.Sayf("Downloading %s: %.0f%% (%s)", name, pct, r.Detail())
+			} else {
+				ui.Sayf("Downloading %s (%s)", name, r.Detail())
+			}
+		}
+	}()
+	return progress.SinkFunc(func() chan<- progress.Report { return ch })
 }
@@ -12,14 +12,24 @@ import (
 // FlatExportConfig is an auto-generated flat version of ExportConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatExportConfig struct {
-	Name       *string      `mapstructure:"name" cty:"name" hcl:"name"`
-	Force      *bool        `mapstructure:"force" cty:"force" hcl:"force"`
-	ImageFiles *bool        `mapstructure:"image_files" cty:"image_files" hcl:"image_files"`
-	Manifest   *string      `mapstructure:"manifest" cty:"manifest" hcl:"manifest"`
-	OutputDir  *string      `mapstructure:"output_directory" required:"false" cty:"output_directory" hcl:"output_directory"`
-	DirPerm    *fs.FileMode `mapstructure:"directory_permission" required:"false" cty:"directory_permission" hcl:"directory_permission"`
-	Options    []string     `mapstructure:"options" cty:"options" hcl:"options"`
-	Format     *string      `mapstructure:"output_format" cty:"output_format" hcl:"output_format"`
+	Name                      *string      `mapstructure:"name" cty:"name" hcl:"name"`
+	Force                     *bool        `mapstructure:"force" cty:"force" hcl:"force"`
+	ImageFiles                *bool        `mapstructure:"image_files" cty:"image_files" hcl:"image_files"`
+	Manifest                  *string      `mapstructure:"manifest" cty:"manifest" hcl:"manifest"`
+	SigningKey                *string      `mapstructure:"signing_key" cty:"signing_key" hcl:"signing_key"`
+	SigningCertificate        *string      `mapstructure:"signing_certificate" cty:"signing_certificate" hcl:"signing_certificate"`
+	OutputDir                 *string      `mapstructure:"output_directory" required:"false" cty:"output_directory" hcl:"output_directory"`
+	DirPerm                   *fs.FileMode `mapstructure:"directory_permission" required:"false" cty:"directory_permission" hcl:"directory_permission"`
+	Options                   []string     `mapstructure:"options" cty:"options" hcl:"options"`
+	IncludeExtraConfig        *bool        `mapstructure:"include_extra_config" cty:"include_extra_config" hcl:"include_extra_config"`
+	IncludeNvram              *bool        `mapstructure:"include_nvram" cty:"include_nvram" hcl:"include_nvram"`
+	ExcludeDevices            []string     `mapstructure:"exclude_devices" cty:"exclude_devices" hcl:"exclude_devices"`
+	Format                    *string      `mapstructure:"output_format" cty:"output_format" hcl:"output_format"`
+	ParallelDownloads         *int         `mapstructure:"parallel_downloads" cty:"parallel_downloads" hcl:"parallel_downloads"`
+	Layout                    *string      `mapstructure:"layout" cty:"layout" hcl:"layout"`
+	Reproducible              *bool        `mapstructure:"reproducible_export" cty:"reproducible_export" hcl:"reproducible_export"`
+	DifferentialBaseSnapshot  *string      `mapstructure:"differential_base_snapshot" cty:"differential_base_snapshot" hcl:"differential_base_snapshot"`
+	DifferentialBaseDirectory *string      `mapstructure:"differential_base_directory" cty:"differential_base_directory" hcl:"differential_base_directory"`
 }
 
 // FlatMapstructure returns a new FlatExportConfig.
@@ -34,14 +44,24 @@ func (*ExportConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.
 // The decoded values from this spec will then be applied to a FlatExportConfig.
 func (*FlatExportConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"name":                 &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
-		"force":                &hcldec.AttrSpec{Name: "force", Type: cty.Bool, Required: false},
-		"image_files":          &hcldec.AttrSpec{Name: "image_files", Type: cty.Bool, Required: false},
-		"manifest":             &hcldec.AttrSpec{Name: "manifest", Type: cty.String, Required: false},
-		"output_directory":     &hcldec.AttrSpec{Name: "output_directory", Type: cty.String, Required: false},
-		"directory_permission": &hcldec.AttrSpec{Name: "directory_permission", Type: cty.Number, Required: false},
-		"options":              &hcldec.AttrSpec{Name: "options", Type: cty.List(cty.String), Required: false},
-		"output_format":        &hcldec.AttrSpec{Name: "output_format", Type: cty.String, Required: false},
+		"name":                        &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"force":                       &hcldec.AttrSpec{Name: "force", Type: cty.Bool, Required: false},
+		"image_files":                 &hcldec.AttrSpec{Name: "image_files", Type: cty.Bool, Required: false},
+		"manifest":                    &hcldec.AttrSpec{Name: "manifest", Type: cty.String, Required: false},
+		"signing_key":                 &hcldec.AttrSpec{Name: "signing_key", Type: cty.String, Required: false},
+		"signing_certificate":         &hcldec.AttrSpec{Name: "signing_certificate", Type: cty.String, Required: false},
+		"output_directory":            &hcldec.AttrSpec{Name: "output_directory", Type: cty.String, Required: false},
+		"directory_permission":        &hcldec.AttrSpec{Name: "directory_permission", Type: cty.Bool, Required: false}, /* TODO(azr): could not find type */
+		"options":                     &hcldec.AttrSpec{Name: "options", Type: cty.List(cty.String), Required: false},
+		"include_extra_config":        &hcldec.AttrSpec{Name: "include_extra_config", Type: cty.Bool, Required: false},
+		"include_nvram":               &hcldec.AttrSpec{Name: "include_nvram", Type: cty.Bool, Required: false},
+		"exclude_devices":             &hcldec.AttrSpec{Name: "exclude_devices", Type: cty.List(cty.String), Required: false},
+		"output_format":               &hcldec.AttrSpec{Name: "output_format", Type: cty.String, Required: false},
+		"parallel_downloads":          &hcldec.AttrSpec{Name: "parallel_downloads", Type: cty.Number, Required: false},
+		"layout":                      &hcldec.AttrSpec{Name: "layout", Type: cty.String, Required: false},
+		"reproducible_export":         &hcldec.AttrSpec{Name: "reproducible_export", Type: cty.Bool, Required: false},
+		"differential_base_snapshot":  &hcldec.AttrSpec{Name: "differential_base_snapshot", Type: cty.String, Required: false},
+		"differential_base_directory": &hcldec.AttrSpec{Name: "differential_base_directory", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,398 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestExportConfig_PrepareParallelDownloads(t *testing.T) {
+	tc := []struct {
+		name     string
+		parallel int
+		expected int
+		fail     bool
+	}{
+		{
+			name:     "Default",
+			expected: 1,
+		},
+		{
+			name:     "Parallel",
+			parallel: 4,
+			expected: 4,
+		},
+		{
+			name:     "Negative",
+			parallel: -1,
+			fail:     true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			config := &ExportConfig{
+				OutputDir:         OutputConfig{OutputDir: t.TempDir()},
+				ParallelDownloads: c.parallel,
+			}
+			errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{})
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatal("unexpected success: expected failure")
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+			if config.ParallelDownloads != c.expected {
+				t.Fatalf("unexpected result: expected '%d', but returned '%d'", c.expected, config.ParallelDownloads)
+			}
+		})
+	}
+}
+
+func TestExportConfig_PrepareLayout(t *testing.T) {
+	config := &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.Layout != ExportLayoutDefault {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", ExportLayoutDefault, config.Layout)
+	}
+
+	config = &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, Layout: "vmware-workstation"}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestStepExport_VerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test-vm-disk-0.vmdk")
+	content := []byte("disk")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	sum := sha256.Sum256(content)
+
+	step := &StepExport{Manifest: "sha256"}
+	if err := step.verifyChecksum(path, sum[:]); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	other := sha256.Sum256([]byte("other"))
+	if err := step.verifyChecksum(path, other[:]); err == nil {
+		t.Fatal("unexpected success: expected a checksum mismatch")
+	}
+
+	step = &StepExport{Manifest: "none"}
+	if err := step.verifyChecksum(path, other[:]); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestStepExport_AddSum(t *testing.T) {
+	step := &StepExport{Manifest: "sha256"}
+	step.addSum("test-vm-disk-0.vmdk", []byte{0xab, 0xcd})
+	step.addSum("test-vm-disk-1.vmdk", []byte{0xef})
+
+	expected := "SHA256(test-vm-disk-0.vmdk)= abcd\nSHA256(test-vm-disk-1.vmdk)= ef\n"
+	if actual := step.mf.String(); actual != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, actual)
+	}
+}
+
+func TestVmwareISOPath(t *testing.T) {
+	tc := []struct {
+		path     string
+		n        int
+		expected string
+	}{
+		{path: "disk-0.vmdk", n: 1, expected: "example-disk1.vmdk"},
+		{path: "disk-1.vmdk", n: 2, expected: "example-disk2.vmdk"},
+		{path: "nvram", n: 1, expected: "example-file1.nvram"},
+		{path: "vmware.log", n: 2, expected: "example-file2.log"},
+	}
+
+	for _, c := range tc {
+		if actual := vmwareISOPath("example", c.path, c.n); actual != c.expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, actual)
+		}
+	}
+}
+
+func TestExportConfig_PrepareReproducible(t *testing.T) {
+	config := &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, Reproducible: true, Options: []string{"extraconfig"}}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+
+	config = &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, Reproducible: true, Options: []string{"uuid"}}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestNormalizeDescriptor(t *testing.T) {
+	desc := strings.Join([]string{
+		`<Disk ovf:capacity="40" ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:populatedSize="1234"/>`,
+		`<Item>`,
+		`  <rasd:InstanceID>3</rasd:InstanceID>`,
+		`</Item>`,
+		`<Item>`,
+		`  <rasd:InstanceID>7</rasd:InstanceID>`,
+		`  <rasd:Parent>3</rasd:Parent>`,
+		`</Item>`,
+		`  <vmw:ExtraConfig ovf:required="false" vmw:key="svga.present" vmw:value="TRUE"/>`,
+		`  <vmw:ExtraConfig ovf:required="false" vmw:key="vm.genid" vmw:value="-123"/>`,
+		`  <vmw:ExtraConfig ovf:required="false" vmw:key="nvram" vmw:value="test-vm.nvram"/>`,
+	}, "\n")
+	expected := strings.Join([]string{
+		`<Disk ovf:capacity="40" ovf:diskId="vmdisk1" ovf:fileRef="file1"/>`,
+		`<Item>`,
+		`  <rasd:InstanceID>1</rasd:InstanceID>`,
+		`</Item>`,
+		`<Item>`,
+		`  <rasd:InstanceID>2</rasd:InstanceID>`,
+		`  <rasd:Parent>1</rasd:Parent>`,
+		`</Item>`,
+		`  <vmw:ExtraConfig ovf:required="false" vmw:key="nvram" vmw:value="test-vm.nvram"/>`,
+		`  <vmw:ExtraConfig ovf:required="false" vmw:key="svga.present" vmw:value="TRUE"/>`,
+	}, "\n")
+
+	if actual := normalizeDescriptor(desc); actual != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, actual)
+	}
+}
+
+func TestSetModTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test-vm.ovf")
+	if err := os.WriteFile(path, []byte("ovf"), 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if err := setModTimes(path); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !info.ModTime().Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", time.Unix(1700000000, 0), info.ModTime())
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "invalid")
+	if err := setModTimes(path); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestExportConfig_PrepareSigning(t *testing.T) {
+	keyPath, certPath := writeSigningKey(t, t.TempDir())
+
+	config := &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, SigningKey: keyPath, SigningCertificate: certPath}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+
+	config = &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, SigningKey: keyPath, SigningCertificate: certPath, Manifest: "none"}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	config = &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, SigningCertificate: certPath}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestExportConfig_PrepareDifferential(t *testing.T) {
+	baseDir := t.TempDir()
+
+	tc := []struct {
+		name     string
+		snapshot string
+		dir      string
+		format   string
+		fail     bool
+	}{
+		{name: "Differential", snapshot: "base", dir: baseDir},
+		{name: "Missing directory", snapshot: "base", fail: true},
+		{name: "Missing snapshot", dir: baseDir, fail: true},
+		{name: "Nonexistent directory", snapshot: "base", dir: filepath.Join(baseDir, "missing"), fail: true},
+		{name: "OVA", snapshot: "base", dir: baseDir, format: "ova", fail: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			config := &ExportConfig{
+				OutputDir:                 OutputConfig{OutputDir: t.TempDir()},
+				DifferentialBaseSnapshot:  c.snapshot,
+				DifferentialBaseDirectory: c.dir,
+				Format:                    c.format,
+			}
+			errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{})
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+		})
+	}
+}
+
+func TestStepExport_BaseFile(t *testing.T) {
+	dir := t.TempDir()
+	baseDir := filepath.Join(dir, "base")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	content := []byte("disk")
+	if err := os.WriteFile(filepath.Join(baseDir, "test-vm-disk-1.vmdk"), content, 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	step := &StepExport{
+		Manifest:                  "sha256",
+		OutputDir:                 filepath.Join(dir, "output"),
+		DifferentialBaseDirectory: baseDir,
+	}
+	item := nfc.FileItem{OvfFileItem: types.OvfFileItem{DeviceId: "/vm-1/VirtualDisk:1", Path: "test-vm-disk-1.vmdk"}}
+	file, err := step.baseFile(item)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if file.Path != "../base/test-vm-disk-1.vmdk" {
+		t.Fatalf("unexpected result: expected '../base/test-vm-disk-1.vmdk', but returned '%s'", file.Path)
+	}
+	if file.Size != int64(len(content)) {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", len(content), file.Size)
+	}
+	if file.DeviceId != item.DeviceId {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", item.DeviceId, file.DeviceId)
+	}
+
+	expected := fmt.Sprintf("SHA256(../base/test-vm-disk-1.vmdk)= %x\n", sha256.Sum256(content))
+	if actual := step.mf.String(); actual != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, actual)
+	}
+
+	item.Path = "test-vm-disk-2.vmdk"
+	if _, err := step.baseFile(item); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestExportConfig_PrepareDevices(t *testing.T) {
+	config := &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, IncludeExtraConfig: true, ExcludeDevices: []string{"cdrom", "serial"}}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if len(config.Options) != 1 || config.Options[0] != "extraconfig" {
+		t.Fatalf("unexpected result: expected '[extraconfig]', but returned '%v'", config.Options)
+	}
+
+	config = &ExportConfig{OutputDir: OutputConfig{OutputDir: t.TempDir()}, ExcludeDevices: []string{"video"}}
+	if errs := config.Prepare(&interpolate.Context{}, &LocationConfig{VMName: "test-vm"}, &common.PackerConfig{}); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestExcludeDevices(t *testing.T) {
+	desc := strings.Join([]string{
+		`<VirtualHardwareSection>`,
+		`  <Item>`,
+		`    <rasd:InstanceID>3</rasd:InstanceID>`,
+		`    <rasd:ResourceType>5</rasd:ResourceType>`,
+		`  </Item>`,
+		`  <Item ovf:required="false">`,
+		`    <rasd:InstanceID>7</rasd:InstanceID>`,
+		`    <rasd:ResourceType>15</rasd:ResourceType>`,
+		`  </Item>`,
+		`  <Item ovf:required="false">`,
+		`    <rasd:InstanceID>8</rasd:InstanceID>`,
+		`    <rasd:ResourceSubType>vmware.soundcard.hdaudio</rasd:ResourceSubType>`,
+		`    <rasd:ResourceType>1</rasd:ResourceType>`,
+		`  </Item>`,
+		`  <Item ovf:required="false">`,
+		`    <rasd:InstanceID>9</rasd:InstanceID>`,
+		`    <rasd:ResourceType>21</rasd:ResourceType>`,
+		`  </Item>`,
+		`</VirtualHardwareSection>`,
+	}, "\n")
+	expected := strings.Join([]string{
+		`<VirtualHardwareSection>`,
+		`  <Item>`,
+		`    <rasd:InstanceID>3</rasd:InstanceID>`,
+		`    <rasd:ResourceType>5</rasd:ResourceType>`,
+		`  </Item>`,
+		`  <Item ovf:required="false">`,
+		`    <rasd:InstanceID>9</rasd:InstanceID>`,
+		`    <rasd:ResourceType>21</rasd:ResourceType>`,
+		`  </Item>`,
+		`</VirtualHardwareSection>`,
+	}, "\n")
+
+	if actual := excludeDevices(desc, []string{"cdrom", "sound"}); actual != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, actual)
+	}
+}
+
+func TestReferenceNvram(t *testing.T) {
+	desc := strings.Join([]string{
+		`<References>`,
+		`  <File ovf:href="test-vm-disk-0.vmdk" ovf:id="file1" ovf:size="1024"/>`,
+		`  <File ovf:href="test-vm-nvram" ovf:id="file2" ovf:size="8684"/>`,
+		`</References>`,
+		`  <VirtualHardwareSection>`,
+		`    <vmw:ExtraConfig ovf:required="false" vmw:key="svga.present" vmw:value="TRUE"/>`,
+		`  </VirtualHardwareSection>`,
+	}, "\n")
+	expected := strings.Join([]string{
+		`<References>`,
+		`  <File ovf:href="test-vm-disk-0.vmdk" ovf:id="file1" ovf:size="1024"/>`,
+		`  <File ovf:href="test-vm-nvram" ovf:id="file2" ovf:size="8684"/>`,
+		`</References>`,
+		`  <VirtualHardwareSection>`,
+		`    <vmw:ExtraConfig ovf:required="false" vmw:key="svga.present" vmw:value="TRUE"/>`,
+		`    <vmw:ExtraConfig ovf:required="false" vmw:key="nvram" vmw:value="ovf:/file/file2"/>`,
+		`  </VirtualHardwareSection>`,
+	}, "\n")
+
+	actual, err := referenceNvram(desc, "test-vm-nvram")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if actual != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, actual)
+	}
+
+	// The exported option is updated instead of adding another option.
+	desc = strings.Replace(desc, `vmw:key="svga.present" vmw:value="TRUE"`, `vmw:key="nvram" vmw:value="test-vm.nvram"`, 1)
+	actual, err = referenceNvram(desc, "test-vm-nvram")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if strings.Count(actual, `vmw:key="nvram"`) != 1 || !strings.Contains(actual, `vmw:key="nvram" vmw:value="ovf:/file/file2"`) {
+		t.Fatalf("unexpected result: '%s'", actual)
+	}
+
+	if _, err := referenceNvram(desc, "missing-nvram"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type FingerprintConfig
+
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"reflect"
+	"strings"
+
+	packerCommon "github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The name of the custom attribute that stores the build fingerprint.
+const fingerprintAttribute = "packer_build_fingerprint"
+
+// The configuration types that are excluded from the build fingerprint, since
+// they configure how the build connects to vCenter Server and to the guest
+// operating system, or how the build runs, rather than the virtual machine
+// that is built.
+var fingerprintExcludedTypes = map[reflect.Type]bool{
+	reflect.TypeOf(packerCommon.PackerConfig{}): true,
+	reflect.TypeOf(communicator.Config{}):       true,
+	reflect.TypeOf(ConnectConfig{}):             true,
+	reflect.TypeOf(PreflightConfig{}):           true,
+}
+
+// The build fingerprint is a SHA-256 checksum of the configuration of the
+// build after the defaults are applied, in the form `sha256:<checksum>`. The
+// fingerprint excludes the connection to vCenter Server, the communicator,
+// the values of sensitive options, such as passwords and `user_data`, and
+// options that are not set, so that a new option does not change the
+// fingerprint of an existing configuration.
+//
+// -> **Note:** The fingerprint covers the configuration of the builder only,
+// not the provisioners and post-processors of the build, the content of the
+// source virtual machine, the ISO files, or the files referenced by the
+// configuration, such as `cd_files` or `http_directory`. Add the inputs that
+// change the result of the build, such as the checksums of the provisioner
+// scripts, to `fingerprint_extra`, or use `-force` to rebuild.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	build_fingerprint           = true
+//	skip_if_fingerprint_matches = true
+//	fingerprint_extra = {
+//	  setup = filesha256("scripts/setup.sh")
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"build_fingerprint": true,
+//	"skip_if_fingerprint_matches": true,
+//	"fingerprint_extra": {
+//	  "setup": "v1.2.0"
+//	}
+//
+// ```
+type FingerprintConfig struct {
+	// Compute the build fingerprint and store it in the
+	// `packer_build_fingerprint` custom attribute of the virtual machine or
+	// template and in the artifact metadata as `build_fingerprint`. Requires
+	// vCenter Server. Defaults to `false`.
+	BuildFingerprint bool `mapstructure:"build_fingerprint"`
+	// Skip the build if the virtual machine or template `vm_name` exists in
+	// `folder` and was built with the same fingerprint. The existing virtual
+	// machine or template is returned as the artifact. Ignored with `-force`.
+	// Requires `build_fingerprint`. Defaults to `false`.
+	SkipIfFingerprintMatches bool `mapstructure:"skip_if_fingerprint_matches"`
+	// Additional values to include in the build fingerprint, such as the
+	// checksums of the provisioner scripts or the version of the
+	// configuration management code, since the fingerprint does not cover the
+	// provisioners or the referenced files.
+	FingerprintExtra map[string]string `mapstructure:"fingerprint_extra"`
+}
+
+func (c *FingerprintConfig) Prepare() []error {
+	var errs []error
+
+	if c.SkipIfFingerprintMatches && !c.BuildFingerprint {
+		errs = append(errs, fmt.Errorf("'skip_if_fingerprint_matches' requires 'build_fingerprint'"))
+	}
+	return errs
+}
+
+// Fingerprint returns the build fingerprint of a configuration struct.
+func Fingerprint(config interface{}) (string, error) {
+	b, err := json.Marshal(fingerprintValue(reflect.ValueOf(config)))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// fingerprintValue returns the value of a configuration for the build
+// fingerprint, or nil if the value is excluded or not set. Structs are
+// returned as maps by the names of the options, which are marshaled in a
+// stable order.
+func fingerprintValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return fingerprintValue(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		fingerprintFields(v, m)
+		if len(m) == 0 {
+			return nil
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = fingerprintValue(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = fingerprintValue(iter.Value())
+		}
+		return m
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Invalid:
+		return nil
+	}
+	if v.IsZero() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// fingerprintFields adds the values of the fields of a struct to the map by
+// the names of the options, including the fields of squashed structs.
+func fingerprintFields(v reflect.Value, m map[string]interface{}) {
+	if config, ok := v.Interface().(FingerprintConfig); ok {
+		// Only the additional values are part of the fingerprint.
+		if extra := fingerprintValue(reflect.ValueOf(config.FingerprintExtra)); extra != nil {
+			m["fingerprint_extra"] = extra
+		}
+		return
+	}
+	if fingerprintExcludedTypes[v.Type()] {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || sensitiveAttributes[name] {
+			continue
+		}
+		f := v.Field(i)
+		if opts == "squash" {
+			if f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					continue
+				}
+				f = f.Elem()
+			}
+			if f.Kind() == reflect.Struct {
+				fingerprintFields(f, m)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if fingerprintExcludedTypes[f.Type()] {
+			continue
+		}
+		if value := fingerprintValue(f); value != nil {
+			m[name] = value
+		}
+	}
+}
+
+// vmFingerprint returns the build fingerprint stored on the virtual machine,
+// or an empty string if the virtual machine has no fingerprint. The custom
+// attribute is not created, since the fingerprint is only read.
+func vmFingerprint(d driver.Driver, vm driver.VirtualMachine) (string, error) {
+	key, err := d.FindCustomAttributeKey(fingerprintAttribute)
+	if errors.Is(err, object.ErrKeyNameNotFound) {
+		// The attribute has not been created, so no fingerprint is stored.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	info, err := vm.Info("customValue")
+	if err != nil {
+		return "", err
+	}
+	for _, value := range info.CustomValue {
+		if v, ok := value.(*types.CustomFieldStringValue); ok && v.Key == key {
+			return v.Value, nil
+		}
+	}
+	return "", nil
+}
+
+type StepCheckFingerprint struct {
+	Config      *FingerprintConfig
+	Location    *LocationConfig
+	Fingerprint string
+	Force       bool
+}
+
+func (s *StepCheckFingerprint) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.SkipIfFingerprintMatches || s.Force {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	vmPath := path.Join(s.Location.Folder, s.Location.VMName)
+
+	vm, err := d.FindVM(vmPath)
+	if err != nil {
+		if _, ok := err.(*find.NotFoundError); ok {
+			log.Printf("[DEBUG] Virtual machine %s not found, building: %s", vmPath, err)
+			return multistep.ActionContinue
+		}
+		state.Put("error", fmt.Errorf("error finding virtual machine %s: %s", vmPath, err))
+		return multistep.ActionHalt
+	}
+	fingerprint, err := vmFingerprint(d, vm)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving the build fingerprint of %s: %s", vmPath, err))
+		return multistep.ActionHalt
+	}
+	if fingerprint != s.Fingerprint {
+		ui.Sayf("The build fingerprint of %s does not match the configuration.", vmPath)
+		return multistep.ActionContinue
+	}
+
+	// The existing virtual machine is the artifact of the build. The build is
+	// not halted, since a halted build is a failed build; the following steps
+	// are skipped instead.
+	ui.Sayf("Skipping the build: %s was built with the same configuration (%s).", vmPath, s.Fingerprint)
+	state.Put("vm", vm)
+	state.Put("build_fingerprint", s.Fingerprint)
+	state.Put("fingerprint_matched", true)
+	return multistep.ActionContinue
+}
+
+func (s *StepCheckFingerprint) Cleanup(multistep.StateBag) {}
+
+// SkipIfFingerprintMatched wraps the steps that follow StepCheckFingerprint so
+// that they are skipped, and are not cleaned up, if the build fingerprint of
+// the existing virtual machine matches the configuration.
+func SkipIfFingerprintMatched(steps []multistep.Step) []multistep.Step {
+	wrapped := make([]multistep.Step, 0, len(steps))
+	checked := false
+	for _, step := range steps {
+		if checked {
+			step = &stepSkipIfFingerprintMatched{step: step}
+		}
+		if _, ok := step.(*StepCheckFingerprint); ok {
+			checked = true
+		}
+		wrapped = append(wrapped, step)
+	}
+	return wrapped
+}
+
+// stepSkipIfFingerprintMatched runs the step unless the build fingerprint
+// matched.
+type stepSkipIfFingerprintMatched struct {
+	step multistep.Step
+	ran  bool
+}
+
+func (s *stepSkipIfFingerprintMatched) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if _, ok := state.GetOk("fingerprint_matched"); ok {
+		return multistep.ActionContinue
+	}
+	s.ran = true
+	return s.step.Run(ctx, state)
+}
+
+func (s *stepSkipIfFingerprintMatched) Cleanup(state multistep.StateBag) {
+	if s.ran {
+		s.step.Cleanup(state)
+	}
+}
+
+type StepSetFingerprint struct {
+	Config      *FingerprintConfig
+	Fingerprint string
+}
+
+func (s *StepSetFingerprint) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.BuildFingerprint {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Sayf("Setting the build fingerprint %s...", s.Fingerprint)
+	key, err := d.FindOrCreateCustomAttributeKey(fingerprintAttribute)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	if err := vm.SetCustomAttribute(key, s.Fingerprint); err != nil {
+		state.Put("error", fmt.Errorf("error setting the build fingerprint: %s", err))
+		return multistep.ActionHalt
+	}
+	state.Put("build_fingerprint", s.Fingerprint)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepSetFingerprint) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,304 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type fingerprintTestConfig struct {
+	ConnectConfig     `mapstructure:",squash"`
+	LocationConfig    `mapstructure:",squash"`
+	FingerprintConfig `mapstructure:",squash"`
+	SSHPassword       string            `mapstructure:"ssh_password"`
+	Notes             map[string]string `mapstructure:"notes"`
+}
+
+// fingerprintTestConfigV2 is a later version of the configuration with a new
+// option.
+type fingerprintTestConfigV2 struct {
+	Config  fingerprintTestConfig `mapstructure:",squash"`
+	Enabled bool                  `mapstructure:"enabled"`
+}
+
+func TestFingerprint(t *testing.T) {
+	config := fingerprintTestConfig{
+		ConnectConfig:  ConnectConfig{VCenterServer: "vcenter.example.com", Password: "secret"},
+		LocationConfig: LocationConfig{VMName: "vm", Folder: "templates"},
+		SSHPassword:    "secret",
+		Notes:          map[string]string{"b": "2", "a": "1"},
+	}
+	fingerprint, err := Fingerprint(&config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !strings.HasPrefix(fingerprint, "sha256:") {
+		t.Fatalf("unexpected fingerprint: '%s'", fingerprint)
+	}
+
+	// The connection, the sensitive values, and the options that are not set
+	// do not change the fingerprint.
+	same := config
+	same.ConnectConfig = ConnectConfig{VCenterServer: "other.example.com"}
+	same.SSHPassword = "other"
+	same.FingerprintConfig = FingerprintConfig{BuildFingerprint: true}
+	for _, v := range []interface{}{same, &fingerprintTestConfigV2{Config: config}} {
+		if f, _ := Fingerprint(v); f != fingerprint {
+			t.Fatalf("unexpected fingerprint change: '%s' and '%s'", fingerprint, f)
+		}
+	}
+
+	changed := config
+	changed.Folder = "other"
+	if f, _ := Fingerprint(changed); f == fingerprint {
+		t.Fatal("expected the fingerprint to change")
+	}
+
+	// The additional values change the fingerprint, since the provisioners
+	// are not part of the configuration.
+	extra := config
+	extra.FingerprintConfig = FingerprintConfig{FingerprintExtra: map[string]string{"setup": "sha256:1"}}
+	extraFingerprint, _ := Fingerprint(extra)
+	if extraFingerprint == fingerprint {
+		t.Fatal("expected the fingerprint to change")
+	}
+	extra.FingerprintConfig = FingerprintConfig{BuildFingerprint: true, FingerprintExtra: map[string]string{"setup": "sha256:1"}}
+	if f, _ := Fingerprint(extra); f != extraFingerprint {
+		t.Fatalf("unexpected fingerprint change: '%s' and '%s'", extraFingerprint, f)
+	}
+	extra.FingerprintExtra = map[string]string{"setup": "sha256:2"}
+	if f, _ := Fingerprint(extra); f == extraFingerprint {
+		t.Fatal("expected the fingerprint to change")
+	}
+}
+
+func TestStepCheckFingerprint_RunChangedInput(t *testing.T) {
+	config := fingerprintTestConfig{
+		LocationConfig:    LocationConfig{VMName: "vm", Folder: "templates"},
+		FingerprintConfig: FingerprintConfig{BuildFingerprint: true, SkipIfFingerprintMatches: true, FingerprintExtra: map[string]string{"setup": "sha256:1"}},
+	}
+	built, err := Fingerprint(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// A provisioner script changed since the virtual machine was built.
+	config.FingerprintExtra = map[string]string{"setup": "sha256:2"}
+	fingerprint, err := Fingerprint(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	d := driver.NewDriverMock()
+	d.VM = fingerprintVM(built)
+	d.FindCustomAttributeKeyResult = map[string]int32{fingerprintAttribute: 101}
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+
+	step := &StepCheckFingerprint{
+		Config:      &config.FingerprintConfig,
+		Location:    &config.LocationConfig,
+		Fingerprint: fingerprint,
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if _, ok := state.GetOk("vm"); ok {
+		t.Fatal("unexpected virtual machine in the state: expected the build to run")
+	}
+}
+
+func TestFingerprintConfig_Prepare(t *testing.T) {
+	config := &FingerprintConfig{BuildFingerprint: true, SkipIfFingerprintMatches: true}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+
+	config = &FingerprintConfig{SkipIfFingerprintMatches: true}
+	errs := config.Prepare()
+	if len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+	expected := "'skip_if_fingerprint_matches' requires 'build_fingerprint'"
+	if errs[0].Error() != expected {
+		t.Fatalf("unexpected error: expected '%s', but returned '%s'", expected, errs[0])
+	}
+}
+
+func fingerprintVM(fingerprint string) *driver.VirtualMachineMock {
+	info := new(mo.VirtualMachine)
+	info.CustomValue = []types.BaseCustomFieldValue{
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 101}, Value: fingerprint},
+	}
+	return &driver.VirtualMachineMock{InfoReturn: info}
+}
+
+func TestStepCheckFingerprint_Run(t *testing.T) {
+	tc := []struct {
+		name       string
+		vm         *driver.VirtualMachineMock
+		findErr    error
+		noKey      bool
+		force      bool
+		matched    bool
+		expected   multistep.StepAction
+		expectsErr bool
+	}{
+		{
+			name:     "Matching fingerprint",
+			vm:       fingerprintVM("sha256:1"),
+			matched:  true,
+			expected: multistep.ActionContinue,
+		},
+		{
+			name:     "Different fingerprint",
+			vm:       fingerprintVM("sha256:2"),
+			expected: multistep.ActionContinue,
+		},
+		{
+			name:     "Virtual machine not found",
+			vm:       fingerprintVM("sha256:1"),
+			findErr:  &find.NotFoundError{},
+			expected: multistep.ActionContinue,
+		},
+		{
+			name:       "Error finding the virtual machine",
+			vm:         fingerprintVM("sha256:1"),
+			findErr:    errors.New("permission denied"),
+			expected:   multistep.ActionHalt,
+			expectsErr: true,
+		},
+		{
+			name:     "Custom attribute not defined",
+			vm:       fingerprintVM("sha256:1"),
+			noKey:    true,
+			expected: multistep.ActionContinue,
+		},
+		{
+			name:     "Force",
+			vm:       fingerprintVM("sha256:1"),
+			force:    true,
+			expected: multistep.ActionContinue,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d := driver.NewDriverMock()
+			d.VM = c.vm
+			d.FindDatastoreErr = c.findErr
+			if !c.noKey {
+				d.FindCustomAttributeKeyResult = map[string]int32{fingerprintAttribute: 101}
+			}
+			state := basicStateBag(nil)
+			state.Put("driver", d)
+
+			step := &StepCheckFingerprint{
+				Config:      &FingerprintConfig{BuildFingerprint: true, SkipIfFingerprintMatches: true},
+				Location:    &LocationConfig{VMName: "vm", Folder: "templates"},
+				Fingerprint: "sha256:1",
+				Force:       c.force,
+			}
+			if action := step.Run(context.TODO(), state); action != c.expected {
+				t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", c.expected, action)
+			}
+			if err, ok := state.GetOk("error"); ok != c.expectsErr {
+				t.Fatalf("unexpected error: '%v'", err)
+			}
+			// The custom attribute is not created by the check.
+			if len(d.FindOrCreateCustomAttributeKeyNames) != 0 {
+				t.Fatalf("unexpected custom attribute creation: '%v'", d.FindOrCreateCustomAttributeKeyNames)
+			}
+			_, skipped := state.GetOk("vm")
+			if skipped != c.matched {
+				t.Fatalf("unexpected virtual machine in the state: '%v'", skipped)
+			}
+			if _, matched := state.GetOk("fingerprint_matched"); matched != c.matched {
+				t.Fatalf("unexpected result: expected 'fingerprint_matched' to be '%t'", c.matched)
+			}
+		})
+	}
+}
+
+func TestStepSetFingerprint_Run(t *testing.T) {
+	d := driver.NewDriverMock()
+	d.FindOrCreateCustomAttributeKeyResult = map[string]int32{fingerprintAttribute: 101}
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	state.Put("vm", vm)
+
+	step := &StepSetFingerprint{
+		Config:      &FingerprintConfig{BuildFingerprint: true},
+		Fingerprint: "sha256:1",
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.SetCustomAttributeValues[101] != "sha256:1" {
+		t.Fatalf("unexpected custom attributes: '%v'", vm.SetCustomAttributeValues)
+	}
+	if state.Get("build_fingerprint") != "sha256:1" {
+		t.Fatalf("unexpected build fingerprint: '%v'", state.Get("build_fingerprint"))
+	}
+}
+
+// recordingStep records whether it was run and cleaned up.
+type recordingStep struct {
+	ran, cleaned bool
+}
+
+func (s *recordingStep) Run(context.Context, multistep.StateBag) multistep.StepAction {
+	s.ran = true
+	return multistep.ActionContinue
+}
+
+func (s *recordingStep) Cleanup(multistep.StateBag) { s.cleaned = true }
+
+func TestSkipIfFingerprintMatched(t *testing.T) {
+	for _, fingerprint := range []string{"sha256:1", "sha256:2"} {
+		t.Run(fingerprint, func(t *testing.T) {
+			d := driver.NewDriverMock()
+			d.VM = fingerprintVM("sha256:1")
+			d.FindCustomAttributeKeyResult = map[string]int32{fingerprintAttribute: 101}
+			state := basicStateBag(nil)
+			state.Put("driver", d)
+
+			before, after := new(recordingStep), new(recordingStep)
+			steps := SkipIfFingerprintMatched([]multistep.Step{
+				before,
+				&StepCheckFingerprint{
+					Config:      &FingerprintConfig{BuildFingerprint: true, SkipIfFingerprintMatches: true},
+					Location:    &LocationConfig{VMName: "vm"},
+					Fingerprint: fingerprint,
+				},
+				after,
+			})
+			runner := &multistep.BasicRunner{Steps: steps}
+			runner.Run(context.TODO(), state)
+
+			// A matching fingerprint skips the following steps without
+			// halting the build.
+			if _, ok := state.GetOk(multistep.StateHalted); ok {
+				t.Fatal("unexpected result: expected the build not to be halted")
+			}
+			if !before.ran || !before.cleaned {
+				t.Fatal("unexpected result: expected the step before the check to run")
+			}
+			matched := fingerprint == "sha256:1"
+			if after.ran == matched || after.cleaned == matched {
+				t.Fatalf("unexpected result: expected the step after the check to run: '%t'", !matched)
+			}
+		})
+	}
+}
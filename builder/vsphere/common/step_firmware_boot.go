@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type FirmwareBootConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The boot devices that can be the target of a firmware boot, and the devices
+// that follow the target in the boot order.
+var firmwareBootOrders = map[string][]string{
+	"cdrom":    {"cdrom", "disk"},
+	"disk":     {"disk", "cdrom"},
+	"ethernet": {"ethernet", "disk"},
+	"floppy":   {"floppy", "disk"},
+}
+
+// The interval at which the virtual machine is checked for the firmware
+// setup.
+var firmwareSetupPollInterval = time.Second
+
+// FirmwareBootConfig powers on the virtual machine into the firmware setup,
+// waits until the firmware is ready, and then resets the virtual machine to
+// boot from the target device, instead of relying on `boot_wait` to catch the
+// boot window of the firmware.
+//
+// The virtual machine is power cycled if the firmware does not enter the
+// setup within the timeout. Boot retry is enabled, so that the firmware
+// retries the boot order if no bootable device is found, such as when the
+// prompt of an installer to boot from a CD-ROM times out. The `boot_wait` is
+// measured from the reset.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	firmware_boot {
+//	  target  = "cdrom"
+//	  retries = 3
+//	}
+//
+// ```
+//
+// -> **Note:** The boot order is set to the target device followed by `disk`,
+// or `cdrom` if the target is `disk`, for the duration of the build and
+// cleared upon build completion. This option cannot be used with
+// `boot_order`.
+type FirmwareBootConfig struct {
+	// The device to boot from. The available options are `cdrom`, `disk`,
+	// `ethernet`, and `floppy`.
+	Target string `mapstructure:"target" required:"true"`
+	// The number of times to power cycle the virtual machine if the firmware
+	// does not enter the setup within `timeout`. Defaults to `3`.
+	Retries int `mapstructure:"retries"`
+	// The amount of time to wait for the firmware to enter the setup after
+	// the virtual machine is powered on. Defaults to `30s`.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// The amount of time the firmware waits before it boots from the target
+	// device. Defaults to `0s`.
+	BootDelay time.Duration `mapstructure:"boot_delay"`
+	// The amount of time the firmware waits before it retries the boot order
+	// if no bootable device is found. Defaults to `10s`.
+	BootRetryDelay time.Duration `mapstructure:"boot_retry_delay"`
+}
+
+func (c *FirmwareBootConfig) Prepare() []error {
+	var errs []error
+
+	if _, ok := firmwareBootOrders[c.Target]; !ok {
+		errs = append(errs, fmt.Errorf("'firmware_boot' 'target' must be one of 'cdrom', 'disk', 'ethernet', or 'floppy'"))
+	}
+	if c.Retries < 0 {
+		errs = append(errs, fmt.Errorf("'firmware_boot' 'retries' must be greater than or equal to 0"))
+	}
+	if c.Retries == 0 {
+		c.Retries = 3
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("'firmware_boot' 'timeout' must be greater than or equal to 0"))
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.BootDelay < 0 {
+		errs = append(errs, fmt.Errorf("'firmware_boot' 'boot_delay' must be greater than or equal to 0"))
+	}
+	if c.BootRetryDelay < 0 {
+		errs = append(errs, fmt.Errorf("'firmware_boot' 'boot_retry_delay' must be greater than or equal to 0"))
+	}
+	if c.BootRetryDelay == 0 {
+		c.BootRetryDelay = 10 * time.Second
+	}
+
+	return errs
+}
+
+// firmwareBoot powers on the virtual machine into the firmware setup, power
+// cycling it until the firmware enters the setup, and then resets it to boot
+// from the target device.
+func firmwareBoot(ctx context.Context, ui packersdk.Ui, vm driver.VirtualMachine, c *FirmwareBootConfig) error {
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			ui.Sayf("Firmware did not enter the setup within %s; power cycling virtual machine (%d/%d)...", c.Timeout, attempt, c.Retries)
+			if err := vm.PowerOff(); err != nil {
+				return err
+			}
+		}
+
+		ui.Sayf("Setting boot options to enter the firmware setup and boot from %s...", c.Target)
+		err := vm.SetBootOptions(&driver.BootOptions{
+			Order:      firmwareBootOrders[c.Target],
+			EnterSetup: true,
+			Delay:      c.BootDelay,
+			RetryDelay: c.BootRetryDelay,
+		})
+		if err != nil {
+			return fmt.Errorf("error setting boot options: %s", err)
+		}
+
+		ui.Say("Powering on virtual machine...")
+		if err := vm.PowerOn(); err != nil {
+			return err
+		}
+
+		entered, err := waitForFirmwareSetup(ctx, vm, c.Timeout)
+		if err != nil {
+			return err
+		}
+		if entered {
+			ui.Sayf("Firmware entered the setup; resetting virtual machine to boot from %s...", c.Target)
+			return vm.Reset()
+		}
+	}
+
+	return fmt.Errorf("firmware did not enter the setup after %d power cycles", c.Retries)
+}
+
+// waitForFirmwareSetup waits until the firmware enters the setup, which clears
+// the flag to enter the setup, and reports whether it did within the timeout.
+func waitForFirmwareSetup(ctx context.Context, vm driver.VirtualMachine, timeout time.Duration) (bool, error) {
+	deadline := time.After(timeout)
+	for {
+		pending, err := vm.EnterSetupPending()
+		if err != nil {
+			return false, fmt.Errorf("error checking the firmware setup: %s", err)
+		}
+		if !pending {
+			return true, nil
+		}
+
+		select {
+		case <-deadline:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(firmwareSetupPollInterval):
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestFirmwareBootConfig_Prepare(t *testing.T) {
+	config := &FirmwareBootConfig{Target: "cdrom"}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	expected := &FirmwareBootConfig{Target: "cdrom", Retries: 3, Timeout: 30 * time.Second, BootRetryDelay: 10 * time.Second}
+	if diff := cmp.Diff(config, expected); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+
+	config = &FirmwareBootConfig{Target: "usb"}
+	if errs := config.Prepare(); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	run := &RunConfig{BootOrder: "disk,cdrom", FirmwareBoot: &FirmwareBootConfig{Target: "cdrom"}}
+	if errs := run.Prepare(); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+// firmwareBootMock is a virtual machine whose firmware enters the setup on
+// the given power on.
+type firmwareBootMock struct {
+	*driver.VirtualMachineMock
+	enterSetupOn int
+}
+
+func (vm *firmwareBootMock) EnterSetupPending() (bool, error) {
+	return vm.SetBootOptionsCalledTimes < vm.enterSetupOn, nil
+}
+
+func TestFirmwareBoot(t *testing.T) {
+	interval := firmwareSetupPollInterval
+	firmwareSetupPollInterval = time.Millisecond
+	defer func() { firmwareSetupPollInterval = interval }()
+
+	tc := []struct {
+		name          string
+		enterSetupOn  int
+		fail          bool
+		expectedCalls int
+		expectedReset int
+	}{
+		{
+			name:          "Firmware enters setup",
+			enterSetupOn:  1,
+			expectedCalls: 1,
+			expectedReset: 1,
+		},
+		{
+			name:          "Power cycle",
+			enterSetupOn:  2,
+			expectedCalls: 2,
+			expectedReset: 1,
+		},
+		{
+			name:          "Firmware does not enter setup",
+			enterSetupOn:  4,
+			fail:          true,
+			expectedCalls: 3,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			vm := &firmwareBootMock{VirtualMachineMock: new(driver.VirtualMachineMock), enterSetupOn: c.enterSetupOn}
+			config := &FirmwareBootConfig{Target: "cdrom", Retries: 2, Timeout: 20 * time.Millisecond, BootRetryDelay: 10 * time.Second}
+
+			err := firmwareBoot(context.TODO(), packersdk.TestUi(t), vm, config)
+			if c.fail {
+				if err == nil {
+					t.Fatal("unexpected success: expected failure")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+
+			if vm.SetBootOptionsCalledTimes != c.expectedCalls {
+				t.Fatalf("unexpected result: expected '%d', but returned '%d'", c.expectedCalls, vm.SetBootOptionsCalledTimes)
+			}
+			if vm.ResetCalledTimes != c.expectedReset {
+				t.Fatalf("unexpected result: expected '%d', but returned '%d'", c.expectedReset, vm.ResetCalledTimes)
+			}
+			expected := &driver.BootOptions{Order: []string{"cdrom", "disk"}, EnterSetup: true, RetryDelay: 10 * time.Second}
+			if diff := cmp.Diff(vm.SetBootOptionsOptions[0], expected); diff != "" {
+				t.Fatalf("unexpected result: '%s'", diff)
+			}
+		})
+	}
+}
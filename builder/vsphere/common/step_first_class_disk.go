@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// StepAttachFirstClassDisks attaches the First Class Disks to the virtual
+// machine. The identifiers of the attached disks are stored in the state as
+// "first_class_disks".
+type StepAttachFirstClassDisks struct {
+	Config *StorageConfig
+}
+
+func (s *StepAttachFirstClassDisks) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Config.FirstClassDisks) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	var attached []string
+	for _, disk := range s.Config.FirstClassDisks {
+		ui.Sayf("Attaching First Class Disk %s...", disk.ID)
+		if err := vm.AttachFirstClassDisk(disk.ID, disk.Datastore, disk.DiskControllerIndex); err != nil {
+			state.Put("first_class_disks", attached)
+			state.Put("error", fmt.Errorf("error attaching First Class Disk %s: %s", disk.ID, err))
+			return multistep.ActionHalt
+		}
+		attached = append(attached, disk.ID)
+	}
+	state.Put("first_class_disks", attached)
+
+	return multistep.ActionContinue
+}
+
+// Cleanup detaches the First Class Disks that are still attached if the build
+// failed or was cancelled, or if the virtual machine is destroyed, since the
+// disks would otherwise be deleted with the virtual machine.
+func (s *StepAttachFirstClassDisks) Cleanup(state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	_, destroy := state.GetOk("destroy_vm")
+	if !cancelled && !halted && !destroy {
+		return
+	}
+
+	attached, _ := state.Get("first_class_disks").([]string)
+	if len(attached) == 0 {
+		return
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+	for _, id := range attached {
+		ui.Sayf("Detaching First Class Disk %s...", id)
+		if err := vm.DetachFirstClassDisk(id); err != nil {
+			ui.Errorf("error detaching First Class Disk %s: %s", id, err)
+		}
+	}
+	state.Remove("first_class_disks")
+}
+
+// StepDetachFirstClassDisks detaches the First Class Disks with
+// `detach_before_template` from the virtual machine.
+type StepDetachFirstClassDisks struct {
+	Config *StorageConfig
+}
+
+func (s *StepDetachFirstClassDisks) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	attached, ok := state.Get("first_class_disks").([]string)
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	for _, disk := range s.Config.FirstClassDisks {
+		if !disk.DetachBeforeTemplate || !slices.Contains(attached, disk.ID) {
+			continue
+		}
+		ui.Sayf("Detaching First Class Disk %s...", disk.ID)
+		if err := vm.DetachFirstClassDisk(disk.ID); err != nil {
+			state.Put("error", fmt.Errorf("error detaching First Class Disk %s: %s", disk.ID, err))
+			return multistep.ActionHalt
+		}
+		attached = slices.DeleteFunc(attached, func(id string) bool { return id == disk.ID })
+		state.Put("first_class_disks", attached)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepDetachFirstClassDisks) Cleanup(multistep.StateBag) {}
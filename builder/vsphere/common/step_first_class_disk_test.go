@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepAttachFirstClassDisks(t *testing.T) {
+	config := &StorageConfig{
+		FirstClassDisks: []FirstClassDiskConfig{
+			{ID: "disk-1", Datastore: "datastore1"},
+			{ID: "disk-2", Datastore: "datastore1", DiskControllerIndex: 1, DetachBeforeTemplate: true},
+		},
+	}
+
+	state := basicStateBag(nil)
+	vm := new(driver.VirtualMachineMock)
+	state.Put("vm", vm)
+
+	attach := &StepAttachFirstClassDisks{Config: config}
+	if action := attach.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if diff := cmp.Diff(vm.AttachFirstClassDiskIDs, []string{"disk-1", "disk-2"}); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+	if diff := cmp.Diff(vm.AttachFirstClassDiskControllerIndex, []int{0, 1}); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+
+	detach := &StepDetachFirstClassDisks{Config: config}
+	if action := detach.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if diff := cmp.Diff(vm.DetachFirstClassDiskIDs, []string{"disk-2"}); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+
+	// The remaining disk is detached when the build fails.
+	state.Put(multistep.StateHalted, true)
+	attach.Cleanup(state)
+	if diff := cmp.Diff(vm.DetachFirstClassDiskIDs, []string{"disk-2", "disk-1"}); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+}
+
+func TestStepAttachFirstClassDisks_Error(t *testing.T) {
+	config := &StorageConfig{
+		FirstClassDisks: []FirstClassDiskConfig{
+			{ID: "disk-1", Datastore: "datastore1"},
+		},
+	}
+
+	state := basicStateBag(nil)
+	vm := &driver.VirtualMachineMock{AttachFirstClassDiskErr: fmt.Errorf("attach error")}
+	state.Put("vm", vm)
+
+	step := &StepAttachFirstClassDisks{Config: config}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	// No disks are detached since none were attached.
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	if len(vm.DetachFirstClassDiskIDs) != 0 {
+		t.Fatalf("unexpected result: expected no detached disks, but returned '%v'", vm.DetachFirstClassDiskIDs)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type GuestOperationsConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// GuestOperationsConfig runs the provisioners with the guest operations of
+// VMware Tools instead of SSH or WinRM, for guest operating systems that
+// cannot be reached over the network, such as air-gapped builds or guests
+// where remote access is blocked by policy. Requires `communicator` to be set
+// to `none` and VMware Tools to be running in the guest operating system.
+//
+// Commands are run with `/bin/sh` or `cmd.exe` and their output is reported
+// after the command completes. Files are transferred through the ESXi host
+// of the virtual machine, and `file` provisioners that download directories
+// from the guest are not supported.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	communicator = "none"
+//	guest_operations {
+//	  username = "root"
+//	  password = var.root_password
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"communicator": "none",
+//	"guest_operations": {
+//	  "username": "root",
+//	  "password": "{{ user `root_password` }}"
+//	}
+//
+// ```
+type GuestOperationsConfig struct {
+	// The username of the guest operating system account to run the
+	// provisioners.
+	Username string `mapstructure:"username" required:"true"`
+	// The password of the guest operating system account to run the
+	// provisioners.
+	Password string `mapstructure:"password" required:"true"`
+	// The amount of time to wait for the guest operations of VMware Tools to
+	// be ready. Defaults to `10m` (10 minutes).
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func (c *GuestOperationsConfig) Prepare(comm communicator.Config) []error {
+	var errs []error
+
+	if comm.Type != "none" {
+		errs = append(errs, fmt.Errorf("'guest_operations' requires 'communicator' to be set to 'none'"))
+	}
+	if c.Username == "" {
+		errs = append(errs, fmt.Errorf("'guest_operations' 'username' is required"))
+	}
+	if c.Password == "" {
+		errs = append(errs, fmt.Errorf("'guest_operations' 'password' is required"))
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Minute
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("'guest_operations' 'timeout' must be greater than or equal to 0"))
+	}
+	return errs
+}
+
+type StepGuestOperationsConnect struct {
+	Config *GuestOperationsConfig
+
+	// The interval between the checks of the guest operations.
+	pollInterval time.Duration
+}
+
+func (s *StepGuestOperationsConnect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config == nil {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	interval := s.pollInterval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	timeout := time.After(s.Config.Timeout)
+
+	ui.Say("Waiting for the guest operations of VMware Tools...")
+	for {
+		info, err := vm.Info("guest.guestOperationsReady", "guest.guestFamily")
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		if info.Guest != nil && info.Guest.GuestOperationsReady != nil && *info.Guest.GuestOperationsReady {
+			state.Put("communicator", &guestCommunicator{
+				vm:       vm,
+				username: s.Config.Username,
+				password: s.Config.Password,
+				windows:  info.Guest.GuestFamily == "windowsGuest",
+			})
+			ui.Say("Connected to the guest operations of VMware Tools.")
+			return multistep.ActionContinue
+		}
+
+		select {
+		case <-ctx.Done():
+			state.Put("error", fmt.Errorf("waiting for the guest operations cancelled: %s", ctx.Err()))
+			return multistep.ActionHalt
+		case <-timeout:
+			state.Put("error", fmt.Errorf("timeout while waiting for the guest operations of VMware Tools"))
+			return multistep.ActionHalt
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *StepGuestOperationsConnect) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestGuestOperationsConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name   string
+		config GuestOperationsConfig
+		comm   string
+		fail   bool
+	}{
+		{
+			name:   "Valid",
+			config: GuestOperationsConfig{Username: "root", Password: "secret"},
+			comm:   "none",
+		},
+		{
+			name:   "SSH communicator",
+			config: GuestOperationsConfig{Username: "root", Password: "secret"},
+			comm:   "ssh",
+			fail:   true,
+		},
+		{
+			name:   "Missing password",
+			config: GuestOperationsConfig{Username: "root"},
+			comm:   "none",
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(communicator.Config{Type: c.comm})
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+			if !c.fail && c.config.Timeout != 10*time.Minute {
+				t.Fatalf("unexpected result: expected '10m', but returned '%s'", c.config.Timeout)
+			}
+		})
+	}
+}
+
+func TestStepGuestOperationsConnect_Run(t *testing.T) {
+	vm := &driver.VirtualMachineMock{
+		InfoReturn: &mo.VirtualMachine{
+			Guest: &types.GuestInfo{GuestOperationsReady: types.NewBool(true), GuestFamily: "windowsGuest"},
+		},
+	}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepGuestOperationsConnect{Config: &GuestOperationsConfig{Username: "Administrator", Password: "secret", Timeout: time.Minute}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	comm, ok := state.Get("communicator").(*guestCommunicator)
+	if !ok || !comm.windows || comm.username != "Administrator" {
+		t.Fatalf("unexpected communicator: '%#v'", state.Get("communicator"))
+	}
+}
+
+func TestStepGuestOperationsConnect_RunTimeout(t *testing.T) {
+	vm := &driver.VirtualMachineMock{
+		InfoReturn: &mo.VirtualMachine{Guest: &types.GuestInfo{GuestOperationsReady: types.NewBool(false)}},
+	}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepGuestOperationsConnect{
+		Config:       &GuestOperationsConfig{Username: "root", Password: "secret", Timeout: 10 * time.Millisecond},
+		pollInterval: time.Millisecond,
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if _, ok := state.GetOk("communicator"); ok {
+		t.Fatal("unexpected result: expected no communicator")
+	}
+}
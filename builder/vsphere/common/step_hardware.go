@@ -2,14 +2,16 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type HardwareConfig,PCIPassthroughAllowedDevice
+//go:generate packer-sdc mapstructure-to-hcl2 -type HardwareConfig,PCIPassthroughAllowedDevice,PMemConfig,SerialPortConfig
 
 package common
 
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"reflect"
+	"slices"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -59,6 +61,59 @@ type PCIPassthroughAllowedDevice struct {
 	SubDeviceId string `mapstructure:"sub_device_id"`
 }
 
+// A persistent memory device backed by the PMem datastore of the host.
+type PMemConfig struct {
+	// The size of the persistent memory device in MB.
+	Size int64 `mapstructure:"size" required:"true"`
+	// The mode of the persistent memory device. Defaults to `direct`.
+	//
+	// The available options for this setting are:
+	//
+	// - `direct` - Attaches a virtual NVDIMM device. The guest operating
+	//   system must support NVDIMM devices.
+	// - `disk` - Attaches a virtual disk (vPMemDisk) on the PMem datastore,
+	//   which requires no guest operating system support.
+	Mode string `mapstructure:"mode"`
+}
+
+// A serial port of the virtual machine that writes to a file or connects over
+// the network, such as to capture the console output of an installer without
+// access to the console of the virtual machine.
+type SerialPortConfig struct {
+	// The backing of the serial port.
+	//
+	// The available options for this setting are:
+	//
+	// - `file` - Writes the output of the serial port to a file.
+	// - `network` - Connects the serial port over the network.
+	Type string `mapstructure:"type" required:"true"`
+	// For `file`, the datastore path of the file, such as
+	// `[datastore1] logs/console.log`, or the name of a file in the directory
+	// of the virtual machine. For `network`, the URI of the network service,
+	// such as `telnet://:23000` to listen on port 23000 of the host, or
+	// `telnet://192.0.2.10:23000` with the `client` direction.
+	Path string `mapstructure:"path" required:"true"`
+	// The direction of the network connection. Defaults to `server`.
+	//
+	// The available options for this setting are `server`, where the virtual
+	// machine listens for connections, and `client`, where the virtual
+	// machine connects to the URI.
+	Direction string `mapstructure:"direction"`
+	// The URI of a virtual serial port concentrator that proxies the network
+	// connection, such as `telnets://vspc.example.com:13370`.
+	ProxyURI string `mapstructure:"proxy_uri"`
+}
+
+// The number of serial ports of a virtual machine.
+const maxSerialPorts = 4
+
+// The minimum hardware of the `windows11` guest profile.
+const (
+	guestProfileWindows11 = "windows11"
+	windows11MinCPUs      = 2
+	windows11MinRAM       = 4096
+)
+
 type HardwareConfig struct {
 	// The number of virtual CPUs cores for the virtual machine.
 	CPUs int32 `mapstructure:"CPUs"`
@@ -96,6 +151,36 @@ type HardwareConfig struct {
 	// vGPU profile for accelerated graphics. Refer to the [NVIDIA GRID vGPU documentation](https://docs.nvidia.com/grid/latest/grid-vgpu-user-guide/index.html#configure-vmware-vsphere-vm-with-vgpu)
 	// for examples of profile names. Defaults to none.
 	VGPUProfile string `mapstructure:"vgpu_profile"`
+	// The vGPU profiles of multiple vGPU devices, one device for each
+	// profile. Cannot be used with `vgpu_profile`.
+	//
+	// The profiles are checked against the vGPU profiles that the host of the
+	// virtual machine provides before the virtual machine is reconfigured.
+	// The vGPU devices of the source of a clone are changed to the profiles,
+	// and the vGPU devices in excess of the profiles are removed.
+	VGPUProfiles []string `mapstructure:"vgpu_profiles"`
+	// The name of a vendor device group to add to the virtual machine, such
+	// as a group of GPUs that are connected with NVIDIA NVLink. The devices
+	// of the group are added together. Requires vSphere 8.0 Update 1 or later.
+	VGPUDeviceGroup string `mapstructure:"vgpu_device_group"`
+	// The addresses of the PCI devices of the host to pass through to the
+	// virtual machine with DirectPath I/O, in the `domain:bus:slot.function`
+	// form, such as `0000:3b:00.0`, or the `bus:slot.function` form. The
+	// devices must be enabled for passthrough on the host of the virtual
+	// machine, and are checked before the virtual machine is reconfigured.
+	//
+	// All memory of the virtual machine is reserved, as required for
+	// passthrough, which cannot be used with `RAM_reservation`. The
+	// `pciPassthru.use64bitMMIO` configuration parameter is set to map the
+	// memory of devices with large base address registers (BARs), such as
+	// GPUs, above 4 GB.
+	PCIPassthroughDevices []string `mapstructure:"pci_passthrough_devices"`
+	// The size in GB of the 64-bit memory-mapped I/O for the devices in
+	// `pci_passthrough_devices`, which sets `pciPassthru.64bitMMIOSizeGB`.
+	// Use at least the total memory of the GPUs, rounded up to the next power
+	// of two. Requires `efi` or `efi-secure` firmware. Defaults to the size
+	// chosen by vSphere.
+	PCIPassthroughMMIOSize int64 `mapstructure:"pci_passthrough_mmio_size"`
 	// Enable nested hardware virtualization for the virtual machine.
 	// Defaults to `false`.
 	NestedHV bool `mapstructure:"NestedHV"`
@@ -110,12 +195,81 @@ type HardwareConfig struct {
 	ForceBIOSSetup bool `mapstructure:"force_bios_setup"`
 	// Enable virtual trusted platform module (TPM) device for the virtual
 	// machine. Defaults to `false`.
+	//
+	// -> **Note:** Requires a default key provider, such as a native key
+	// provider, for the host or cluster or on vCenter Server. The key provider
+	// is verified before the virtual machine is created, unless it cannot be
+	// retrieved, such as without the `Cryptographer.ManageKeyServers`
+	// privilege.
 	VTPMEnabled bool `mapstructure:"vTPM"`
+	// Configure the virtual machine to meet the hardware requirements of a
+	// guest operating system. Available options include `windows11`.
+	//
+	// The `windows11` profile sets `firmware` to `efi-secure` and enables
+	// `vTPM`. If set, `CPUs` must be at least `2` and `RAM` must be at least
+	// `4096`.
+	GuestProfile string `mapstructure:"guest_profile"`
 	// The virtual precision clock device for the virtual machine.
 	// Defaults to `none`.
 	//
 	// The available options for this setting are: `none`, `ntp`, and `ptp`.
 	VirtualPrecisionClock string `mapstructure:"precision_clock"`
+	// Add a virtual watchdog timer device to the virtual machine.
+	// Defaults to `false`.
+	//
+	// -> **Note:** Requires virtual hardware version 17 or later.
+	WatchdogTimer bool `mapstructure:"watchdog_timer"`
+	// Start the virtual watchdog timer when the virtual machine boots, before
+	// the guest operating system starts. Requires `watchdog_timer`.
+	// Defaults to `false`.
+	WatchdogTimerRunOnBoot bool `mapstructure:"watchdog_timer_run_on_boot"`
+	// The size, in MB, of the virtual Intel Software Guard Extensions (SGX)
+	// enclave page cache. Setting a size enables virtual SGX for the virtual
+	// machine. Defaults to `0` (disabled).
+	//
+	// -> **Note:** Requires virtual hardware version 17 or later, `efi` or
+	// `efi-secure` firmware, and a host with SGX enabled.
+	SGXEpcSize int64 `mapstructure:"sgx_epc_size"`
+	// The flexible launch control mode for virtual SGX.
+	// Defaults to `unlocked`.
+	//
+	// The available options for this setting are: `locked` and `unlocked`.
+	SGXFlcMode string `mapstructure:"sgx_flc_mode"`
+	// The SHA-256 hash of the public key of the launch enclave for virtual
+	// SGX. Required when `sgx_flc_mode` is `locked`.
+	SGXLePubKeyHash string `mapstructure:"sgx_le_pubkey_hash"`
+	// The persistent memory devices to attach to the virtual machine. Refer
+	// to the [persistent memory configuration](#persistent-memory-configuration)
+	// section for more information.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	//   pmem {
+	//     size = 4096
+	//     mode = "direct"
+	//   }
+	// ```
+	//
+	// -> **Note:** Requires virtual hardware version 14 or later and a host
+	// with persistent memory and a PMem datastore with enough free space.
+	PMem []PMemConfig `mapstructure:"pmem"`
+	// The serial ports to add to the virtual machine. Refer to the
+	// [serial port configuration](#serial-port-configuration) section for
+	// more information.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	//   serial_ports {
+	//     type = "network"
+	//     path = "telnet://:23000"
+	//   }
+	// ```
+	//
+	// -> **Note:** A `network` serial port requires the VM serial port
+	// connected over network rule to be enabled in the firewall of the host.
+	SerialPorts []SerialPortConfig `mapstructure:"serial_ports"`
 }
 
 func (c *HardwareConfig) Prepare() []error {
@@ -129,6 +283,26 @@ func (c *HardwareConfig) Prepare() []error {
 		errs = append(errs, fmt.Errorf("'firmware' must be '', 'bios', 'efi' or 'efi-secure'"))
 	}
 
+	switch c.GuestProfile {
+	case "":
+	case guestProfileWindows11:
+		if c.Firmware == "" {
+			c.Firmware = "efi-secure"
+		}
+		if c.Firmware != "efi-secure" {
+			errs = append(errs, fmt.Errorf("'firmware' must be 'efi-secure' when 'guest_profile' is '%s'", c.GuestProfile))
+		}
+		c.VTPMEnabled = true
+		if c.CPUs != 0 && c.CPUs < windows11MinCPUs {
+			errs = append(errs, fmt.Errorf("'CPUs' must be at least %d when 'guest_profile' is '%s'", windows11MinCPUs, c.GuestProfile))
+		}
+		if c.RAM != 0 && c.RAM < windows11MinRAM {
+			errs = append(errs, fmt.Errorf("'RAM' must be at least %d when 'guest_profile' is '%s'", windows11MinRAM, c.GuestProfile))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("'guest_profile' must be '' or '%s'", guestProfileWindows11))
+	}
+
 	if c.VTPMEnabled && c.Firmware != "efi" && c.Firmware != "efi-secure" {
 		errs = append(errs, fmt.Errorf("'vTPM' could be enabled only when 'firmware' set to 'efi' or 'efi-secure'"))
 	}
@@ -137,6 +311,110 @@ func (c *HardwareConfig) Prepare() []error {
 		errs = append(errs, fmt.Errorf("'precision_clock' must be '', 'ptp', 'ntp', or 'none'"))
 	}
 
+	if c.WatchdogTimerRunOnBoot && !c.WatchdogTimer {
+		errs = append(errs, fmt.Errorf("'watchdog_timer' is required when 'watchdog_timer_run_on_boot' is set"))
+	}
+
+	if c.SGXEpcSize < 0 {
+		errs = append(errs, fmt.Errorf("'sgx_epc_size' must be greater than or equal to 0"))
+	}
+	if c.SGXEpcSize == 0 && (c.SGXFlcMode != "" || c.SGXLePubKeyHash != "") {
+		errs = append(errs, fmt.Errorf("'sgx_epc_size' is required when 'sgx_flc_mode' or 'sgx_le_pubkey_hash' is set"))
+	}
+	if c.SGXEpcSize > 0 && c.Firmware != "" && c.Firmware != "efi" && c.Firmware != "efi-secure" {
+		errs = append(errs, fmt.Errorf("'sgx_epc_size' could be set only when 'firmware' set to 'efi' or 'efi-secure'"))
+	}
+	if c.SGXEpcSize > 0 && c.SGXFlcMode == "" {
+		c.SGXFlcMode = "unlocked"
+	}
+	if c.SGXFlcMode != "" && c.SGXFlcMode != "locked" && c.SGXFlcMode != "unlocked" {
+		errs = append(errs, fmt.Errorf("'sgx_flc_mode' must be 'locked' or 'unlocked'"))
+	}
+	if c.SGXFlcMode == "locked" && c.SGXLePubKeyHash == "" {
+		errs = append(errs, fmt.Errorf("'sgx_le_pubkey_hash' is required when 'sgx_flc_mode' is 'locked'"))
+	}
+
+	for i := range c.PMem {
+		if c.PMem[i].Size <= 0 {
+			errs = append(errs, fmt.Errorf("'pmem[%d].size' must be greater than 0", i))
+		}
+		switch c.PMem[i].Mode {
+		case "":
+			c.PMem[i].Mode = driver.PMemModeDirect
+		case driver.PMemModeDirect, driver.PMemModeDisk:
+		default:
+			errs = append(errs, fmt.Errorf("'pmem[%d].mode' must be '%s' or '%s'", i, driver.PMemModeDirect, driver.PMemModeDisk))
+		}
+	}
+
+	if c.VGPUProfile != "" {
+		if len(c.VGPUProfiles) > 0 {
+			errs = append(errs, fmt.Errorf("'vgpu_profile' and 'vgpu_profiles' cannot be used together"))
+		} else {
+			c.VGPUProfiles = []string{c.VGPUProfile}
+		}
+	}
+	if slices.Contains(c.VGPUProfiles, "") {
+		errs = append(errs, fmt.Errorf("'vgpu_profiles' cannot contain empty profiles"))
+	}
+
+	if len(c.PCIPassthroughDevices) > 0 {
+		if slices.Contains(c.PCIPassthroughDevices, "") {
+			errs = append(errs, fmt.Errorf("'pci_passthrough_devices' cannot contain empty addresses"))
+		}
+		if c.RAMReservation > 0 {
+			errs = append(errs, fmt.Errorf("'pci_passthrough_devices' and 'RAM_reservation' cannot be used together"))
+		}
+		c.RAMReserveAll = true
+	}
+	if c.PCIPassthroughMMIOSize < 0 {
+		errs = append(errs, fmt.Errorf("'pci_passthrough_mmio_size' must be greater than or equal to 0"))
+	}
+	if c.PCIPassthroughMMIOSize > 0 {
+		if len(c.PCIPassthroughDevices) == 0 {
+			errs = append(errs, fmt.Errorf("'pci_passthrough_devices' is required when 'pci_passthrough_mmio_size' is set"))
+		}
+		if c.Firmware == "bios" {
+			errs = append(errs, fmt.Errorf("'pci_passthrough_mmio_size' could be set only when 'firmware' set to 'efi' or 'efi-secure'"))
+		}
+	}
+
+	if len(c.SerialPorts) > maxSerialPorts {
+		errs = append(errs, fmt.Errorf("'serial_ports' supports up to %d serial ports", maxSerialPorts))
+	}
+	for i := range c.SerialPorts {
+		errs = append(errs, c.SerialPorts[i].prepare(i)...)
+	}
+
+	return errs
+}
+
+func (c *SerialPortConfig) prepare(i int) []error {
+	var errs []error
+
+	if c.Path == "" {
+		errs = append(errs, fmt.Errorf("'serial_ports[%d].path' is required", i))
+	}
+	switch c.Type {
+	case driver.SerialPortTypeFile:
+		if c.Direction != "" || c.ProxyURI != "" {
+			errs = append(errs, fmt.Errorf("'serial_ports[%d].direction' and 'serial_ports[%d].proxy_uri' require the '%s' type", i, i, driver.SerialPortTypeNetwork))
+		}
+	case driver.SerialPortTypeNetwork:
+		if u, err := url.Parse(c.Path); c.Path != "" && (err != nil || u.Scheme == "") {
+			errs = append(errs, fmt.Errorf("'serial_ports[%d].path' must be a URI, such as 'telnet://:23000'", i))
+		}
+		switch c.Direction {
+		case "":
+			c.Direction = "server"
+		case "server", "client":
+		default:
+			errs = append(errs, fmt.Errorf("'serial_ports[%d].direction' must be 'server' or 'client'", i))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("'serial_ports[%d].type' must be '%s' or '%s'", i, driver.SerialPortTypeFile, driver.SerialPortTypeNetwork))
+	}
+
 	return errs
 }
 
@@ -156,25 +434,50 @@ func (s *StepConfigureHardware) Run(_ context.Context, state multistep.StateBag)
 			allowedDevices = append(allowedDevices, driver.PCIPassthroughAllowedDevice(device))
 		}
 
+		var pmem []driver.PMemDevice
+		for _, device := range s.Config.PMem {
+			pmem = append(pmem, driver.PMemDevice(device))
+		}
+
+		var serialPorts []driver.SerialPort
+		for _, port := range s.Config.SerialPorts {
+			serialPorts = append(serialPorts, driver.SerialPort{
+				Type:     port.Type,
+				Path:     port.Path,
+				Client:   port.Direction == "client",
+				ProxyURI: port.ProxyURI,
+			})
+		}
+
 		err := vm.Configure(&driver.HardwareConfig{
-			CPUs:                  s.Config.CPUs,
-			CpuCores:              s.Config.CpuCores,
-			CPUReservation:        s.Config.CPUReservation,
-			CPULimit:              s.Config.CPULimit,
-			RAM:                   s.Config.RAM,
-			RAMReservation:        s.Config.RAMReservation,
-			RAMReserveAll:         s.Config.RAMReserveAll,
-			NestedHV:              s.Config.NestedHV,
-			CpuHotAddEnabled:      s.Config.CpuHotAddEnabled,
-			MemoryHotAddEnabled:   s.Config.MemoryHotAddEnabled,
-			VideoRAM:              s.Config.VideoRAM,
-			Displays:              s.Config.Displays,
-			AllowedDevices:        allowedDevices,
-			VGPUProfile:           s.Config.VGPUProfile,
-			Firmware:              s.Config.Firmware,
-			ForceBIOSSetup:        s.Config.ForceBIOSSetup,
-			VTPMEnabled:           s.Config.VTPMEnabled,
-			VirtualPrecisionClock: s.Config.VirtualPrecisionClock,
+			CPUs:                     s.Config.CPUs,
+			CpuCores:                 s.Config.CpuCores,
+			CPUReservation:           s.Config.CPUReservation,
+			CPULimit:                 s.Config.CPULimit,
+			RAM:                      s.Config.RAM,
+			RAMReservation:           s.Config.RAMReservation,
+			RAMReserveAll:            s.Config.RAMReserveAll,
+			NestedHV:                 s.Config.NestedHV,
+			CpuHotAddEnabled:         s.Config.CpuHotAddEnabled,
+			MemoryHotAddEnabled:      s.Config.MemoryHotAddEnabled,
+			VideoRAM:                 s.Config.VideoRAM,
+			Displays:                 s.Config.Displays,
+			AllowedDevices:           allowedDevices,
+			VGPUProfiles:             s.Config.VGPUProfiles,
+			VGPUDeviceGroup:          s.Config.VGPUDeviceGroup,
+			PCIPassthroughDevices:    s.Config.PCIPassthroughDevices,
+			PCIPassthroughMMIOSizeGB: s.Config.PCIPassthroughMMIOSize,
+			Firmware:                 s.Config.Firmware,
+			ForceBIOSSetup:           s.Config.ForceBIOSSetup,
+			VTPMEnabled:              s.Config.VTPMEnabled,
+			VirtualPrecisionClock:    s.Config.VirtualPrecisionClock,
+			WatchdogTimer:            s.Config.WatchdogTimer,
+			WatchdogTimerRunOnBoot:   s.Config.WatchdogTimerRunOnBoot,
+			SGXEpcSize:               s.Config.SGXEpcSize,
+			SGXFlcMode:               s.Config.SGXFlcMode,
+			SGXLePubKeyHash:          s.Config.SGXLePubKeyHash,
+			PMem:                     pmem,
+			SerialPorts:              serialPorts,
 		})
 		if err != nil {
 			state.Put("error", err)
@@ -10,24 +10,36 @@ import (
 // FlatHardwareConfig is an auto-generated flat version of HardwareConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatHardwareConfig struct {
-	CPUs                  *int32                            `mapstructure:"CPUs" cty:"CPUs" hcl:"CPUs"`
-	CpuCores              *int32                            `mapstructure:"cpu_cores" cty:"cpu_cores" hcl:"cpu_cores"`
-	CPUReservation        *int64                            `mapstructure:"CPU_reservation" cty:"CPU_reservation" hcl:"CPU_reservation"`
-	CPULimit              *int64                            `mapstructure:"CPU_limit" cty:"CPU_limit" hcl:"CPU_limit"`
-	CpuHotAddEnabled      *bool                             `mapstructure:"CPU_hot_plug" cty:"CPU_hot_plug" hcl:"CPU_hot_plug"`
-	RAM                   *int64                            `mapstructure:"RAM" cty:"RAM" hcl:"RAM"`
-	RAMReservation        *int64                            `mapstructure:"RAM_reservation" cty:"RAM_reservation" hcl:"RAM_reservation"`
-	RAMReserveAll         *bool                             `mapstructure:"RAM_reserve_all" cty:"RAM_reserve_all" hcl:"RAM_reserve_all"`
-	MemoryHotAddEnabled   *bool                             `mapstructure:"RAM_hot_plug" cty:"RAM_hot_plug" hcl:"RAM_hot_plug"`
-	VideoRAM              *int64                            `mapstructure:"video_ram" cty:"video_ram" hcl:"video_ram"`
-	Displays              *int32                            `mapstructure:"displays" cty:"displays" hcl:"displays"`
-	AllowedDevices        []FlatPCIPassthroughAllowedDevice `mapstructure:"pci_passthrough_allowed_device" cty:"pci_passthrough_allowed_device" hcl:"pci_passthrough_allowed_device"`
-	VGPUProfile           *string                           `mapstructure:"vgpu_profile" cty:"vgpu_profile" hcl:"vgpu_profile"`
-	NestedHV              *bool                             `mapstructure:"NestedHV" cty:"NestedHV" hcl:"NestedHV"`
-	Firmware              *string                           `mapstructure:"firmware" cty:"firmware" hcl:"firmware"`
-	ForceBIOSSetup        *bool                             `mapstructure:"force_bios_setup" cty:"force_bios_setup" hcl:"force_bios_setup"`
-	VTPMEnabled           *bool                             `mapstructure:"vTPM" cty:"vTPM" hcl:"vTPM"`
-	VirtualPrecisionClock *string                           `mapstructure:"precision_clock" cty:"precision_clock" hcl:"precision_clock"`
+	CPUs                   *int32                            `mapstructure:"CPUs" cty:"CPUs" hcl:"CPUs"`
+	CpuCores               *int32                            `mapstructure:"cpu_cores" cty:"cpu_cores" hcl:"cpu_cores"`
+	CPUReservation         *int64                            `mapstructure:"CPU_reservation" cty:"CPU_reservation" hcl:"CPU_reservation"`
+	CPULimit               *int64                            `mapstructure:"CPU_limit" cty:"CPU_limit" hcl:"CPU_limit"`
+	CpuHotAddEnabled       *bool                             `mapstructure:"CPU_hot_plug" cty:"CPU_hot_plug" hcl:"CPU_hot_plug"`
+	RAM                    *int64                            `mapstructure:"RAM" cty:"RAM" hcl:"RAM"`
+	RAMReservation         *int64                            `mapstructure:"RAM_reservation" cty:"RAM_reservation" hcl:"RAM_reservation"`
+	RAMReserveAll          *bool                             `mapstructure:"RAM_reserve_all" cty:"RAM_reserve_all" hcl:"RAM_reserve_all"`
+	MemoryHotAddEnabled    *bool                             `mapstructure:"RAM_hot_plug" cty:"RAM_hot_plug" hcl:"RAM_hot_plug"`
+	VideoRAM               *int64                            `mapstructure:"video_ram" cty:"video_ram" hcl:"video_ram"`
+	Displays               *int32                            `mapstructure:"displays" cty:"displays" hcl:"displays"`
+	AllowedDevices         []FlatPCIPassthroughAllowedDevice `mapstructure:"pci_passthrough_allowed_device" cty:"pci_passthrough_allowed_device" hcl:"pci_passthrough_allowed_device"`
+	VGPUProfile            *string                           `mapstructure:"vgpu_profile" cty:"vgpu_profile" hcl:"vgpu_profile"`
+	VGPUProfiles           []string                          `mapstructure:"vgpu_profiles" cty:"vgpu_profiles" hcl:"vgpu_profiles"`
+	VGPUDeviceGroup        *string                           `mapstructure:"vgpu_device_group" cty:"vgpu_device_group" hcl:"vgpu_device_group"`
+	PCIPassthroughDevices  []string                          `mapstructure:"pci_passthrough_devices" cty:"pci_passthrough_devices" hcl:"pci_passthrough_devices"`
+	PCIPassthroughMMIOSize *int64                            `mapstructure:"pci_passthrough_mmio_size" cty:"pci_passthrough_mmio_size" hcl:"pci_passthrough_mmio_size"`
+	NestedHV               *bool                             `mapstructure:"NestedHV" cty:"NestedHV" hcl:"NestedHV"`
+	Firmware               *string                           `mapstructure:"firmware" cty:"firmware" hcl:"firmware"`
+	ForceBIOSSetup         *bool                             `mapstructure:"force_bios_setup" cty:"force_bios_setup" hcl:"force_bios_setup"`
+	VTPMEnabled            *bool                             `mapstructure:"vTPM" cty:"vTPM" hcl:"vTPM"`
+	GuestProfile           *string                           `mapstructure:"guest_profile" cty:"guest_profile" hcl:"guest_profile"`
+	VirtualPrecisionClock  *string                           `mapstructure:"precision_clock" cty:"precision_clock" hcl:"precision_clock"`
+	WatchdogTimer          *bool                             `mapstructure:"watchdog_timer" cty:"watchdog_timer" hcl:"watchdog_timer"`
+	WatchdogTimerRunOnBoot *bool                             `mapstructure:"watchdog_timer_run_on_boot" cty:"watchdog_timer_run_on_boot" hcl:"watchdog_timer_run_on_boot"`
+	SGXEpcSize             *int64                            `mapstructure:"sgx_epc_size" cty:"sgx_epc_size" hcl:"sgx_epc_size"`
+	SGXFlcMode             *string                           `mapstructure:"sgx_flc_mode" cty:"sgx_flc_mode" hcl:"sgx_flc_mode"`
+	SGXLePubKeyHash        *string                           `mapstructure:"sgx_le_pubkey_hash" cty:"sgx_le_pubkey_hash" hcl:"sgx_le_pubkey_hash"`
+	PMem                   []FlatPMemConfig                  `mapstructure:"pmem" cty:"pmem" hcl:"pmem"`
+	SerialPorts            []FlatSerialPortConfig            `mapstructure:"serial_ports" cty:"serial_ports" hcl:"serial_ports"`
 }
 
 // FlatMapstructure returns a new FlatHardwareConfig.
@@ -55,11 +67,23 @@ func (*FlatHardwareConfig) HCL2Spec() map[string]hcldec.Spec {
 		"displays":                       &hcldec.AttrSpec{Name: "displays", Type: cty.Number, Required: false},
 		"pci_passthrough_allowed_device": &hcldec.BlockListSpec{TypeName: "pci_passthrough_allowed_device", Nested: hcldec.ObjectSpec((*FlatPCIPassthroughAllowedDevice)(nil).HCL2Spec())},
 		"vgpu_profile":                   &hcldec.AttrSpec{Name: "vgpu_profile", Type: cty.String, Required: false},
+		"vgpu_profiles":                  &hcldec.AttrSpec{Name: "vgpu_profiles", Type: cty.List(cty.String), Required: false},
+		"vgpu_device_group":              &hcldec.AttrSpec{Name: "vgpu_device_group", Type: cty.String, Required: false},
+		"pci_passthrough_devices":        &hcldec.AttrSpec{Name: "pci_passthrough_devices", Type: cty.List(cty.String), Required: false},
+		"pci_passthrough_mmio_size":      &hcldec.AttrSpec{Name: "pci_passthrough_mmio_size", Type: cty.Number, Required: false},
 		"NestedHV":                       &hcldec.AttrSpec{Name: "NestedHV", Type: cty.Bool, Required: false},
 		"firmware":                       &hcldec.AttrSpec{Name: "firmware", Type: cty.String, Required: false},
 		"force_bios_setup":               &hcldec.AttrSpec{Name: "force_bios_setup", Type: cty.Bool, Required: false},
 		"vTPM":                           &hcldec.AttrSpec{Name: "vTPM", Type: cty.Bool, Required: false},
+		"guest_profile":                  &hcldec.AttrSpec{Name: "guest_profile", Type: cty.String, Required: false},
 		"precision_clock":                &hcldec.AttrSpec{Name: "precision_clock", Type: cty.String, Required: false},
+		"watchdog_timer":                 &hcldec.AttrSpec{Name: "watchdog_timer", Type: cty.Bool, Required: false},
+		"watchdog_timer_run_on_boot":     &hcldec.AttrSpec{Name: "watchdog_timer_run_on_boot", Type: cty.Bool, Required: false},
+		"sgx_epc_size":                   &hcldec.AttrSpec{Name: "sgx_epc_size", Type: cty.Number, Required: false},
+		"sgx_flc_mode":                   &hcldec.AttrSpec{Name: "sgx_flc_mode", Type: cty.String, Required: false},
+		"sgx_le_pubkey_hash":             &hcldec.AttrSpec{Name: "sgx_le_pubkey_hash", Type: cty.String, Required: false},
+		"pmem":                           &hcldec.BlockListSpec{TypeName: "pmem", Nested: hcldec.ObjectSpec((*FlatPMemConfig)(nil).HCL2Spec())},
+		"serial_ports":                   &hcldec.BlockListSpec{TypeName: "serial_ports", Nested: hcldec.ObjectSpec((*FlatSerialPortConfig)(nil).HCL2Spec())},
 	}
 	return s
 }
@@ -92,3 +116,57 @@ func (*FlatPCIPassthroughAllowedDevice) HCL2Spec() map[string]hcldec.Spec {
 	}
 	return s
 }
+
+// FlatPMemConfig is an auto-generated flat version of PMemConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatPMemConfig struct {
+	Size *int64  `mapstructure:"size" required:"true" cty:"size" hcl:"size"`
+	Mode *string `mapstructure:"mode" cty:"mode" hcl:"mode"`
+}
+
+// FlatMapstructure returns a new FlatPMemConfig.
+// FlatPMemConfig is an auto-generated flat version of PMemConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*PMemConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatPMemConfig)
+}
+
+// HCL2Spec returns the hcl spec of a PMemConfig.
+// This spec is used by HCL to read the fields of PMemConfig.
+// The decoded values from this spec will then be applied to a FlatPMemConfig.
+func (*FlatPMemConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"size": &hcldec.AttrSpec{Name: "size", Type: cty.Number, Required: false},
+		"mode": &hcldec.AttrSpec{Name: "mode", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatSerialPortConfig is an auto-generated flat version of SerialPortConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatSerialPortConfig struct {
+	Type      *string `mapstructure:"type" required:"true" cty:"type" hcl:"type"`
+	Path      *string `mapstructure:"path" required:"true" cty:"path" hcl:"path"`
+	Direction *string `mapstructure:"direction" cty:"direction" hcl:"direction"`
+	ProxyURI  *string `mapstructure:"proxy_uri" cty:"proxy_uri" hcl:"proxy_uri"`
+}
+
+// FlatMapstructure returns a new FlatSerialPortConfig.
+// FlatSerialPortConfig is an auto-generated flat version of SerialPortConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*SerialPortConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatSerialPortConfig)
+}
+
+// HCL2Spec returns the hcl spec of a SerialPortConfig.
+// This spec is used by HCL to read the fields of SerialPortConfig.
+// The decoded values from this spec will then be applied to a FlatSerialPortConfig.
+func (*FlatSerialPortConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"type":      &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"path":      &hcldec.AttrSpec{Name: "path", Type: cty.String, Required: false},
+		"direction": &hcldec.AttrSpec{Name: "direction", Type: cty.String, Required: false},
+		"proxy_uri": &hcldec.AttrSpec{Name: "proxy_uri", Type: cty.String, Required: false},
+	}
+	return s
+}
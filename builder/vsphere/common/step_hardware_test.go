@@ -112,6 +112,236 @@ func TestHardwareConfig_Prepare(t *testing.T) {
 			fail:           true,
 			expectedErrMsg: "'precision_clock' must be '', 'ptp', 'ntp', or 'none'",
 		},
+		{
+			name: "Validate 'watchdog_timer_run_on_boot'",
+			config: &HardwareConfig{
+				WatchdogTimer:          true,
+				WatchdogTimerRunOnBoot: true,
+			},
+			fail: false,
+		},
+		{
+			name: "Validate 'watchdog_timer_run_on_boot' without 'watchdog_timer'",
+			config: &HardwareConfig{
+				WatchdogTimerRunOnBoot: true,
+			},
+			fail:           true,
+			expectedErrMsg: "'watchdog_timer' is required when 'watchdog_timer_run_on_boot' is set",
+		},
+		{
+			name: "Validate 'sgx_epc_size' and 'efi' firmware",
+			config: &HardwareConfig{
+				Firmware:   "efi",
+				SGXEpcSize: 64,
+			},
+			fail: false,
+		},
+		{
+			name: "Validate 'sgx_epc_size' and unsupported firmware",
+			config: &HardwareConfig{
+				Firmware:   "bios",
+				SGXEpcSize: 64,
+			},
+			fail:           true,
+			expectedErrMsg: "'sgx_epc_size' could be set only when 'firmware' set to 'efi' or 'efi-secure'",
+		},
+		{
+			name: "Validate 'sgx_flc_mode' without 'sgx_epc_size'",
+			config: &HardwareConfig{
+				SGXFlcMode: "unlocked",
+			},
+			fail:           true,
+			expectedErrMsg: "'sgx_epc_size' is required when 'sgx_flc_mode' or 'sgx_le_pubkey_hash' is set",
+		},
+		{
+			name: "Validate 'sgx_flc_mode' and invalid option",
+			config: &HardwareConfig{
+				SGXEpcSize: 64,
+				SGXFlcMode: "invalid",
+			},
+			fail:           true,
+			expectedErrMsg: "'sgx_flc_mode' must be 'locked' or 'unlocked'",
+		},
+		{
+			name: "Validate locked 'sgx_flc_mode' without 'sgx_le_pubkey_hash'",
+			config: &HardwareConfig{
+				SGXEpcSize: 64,
+				SGXFlcMode: "locked",
+			},
+			fail:           true,
+			expectedErrMsg: "'sgx_le_pubkey_hash' is required when 'sgx_flc_mode' is 'locked'",
+		},
+		{
+			name: "Validate 'pmem' devices",
+			config: &HardwareConfig{
+				PMem: []PMemConfig{
+					{Size: 4096},
+					{Size: 1024, Mode: "disk"},
+				},
+			},
+			fail: false,
+		},
+		{
+			name: "Validate 'pmem' without size",
+			config: &HardwareConfig{
+				PMem: []PMemConfig{{Mode: "direct"}},
+			},
+			fail:           true,
+			expectedErrMsg: "'pmem[0].size' must be greater than 0",
+		},
+		{
+			name: "Validate 'pmem' and invalid mode",
+			config: &HardwareConfig{
+				PMem: []PMemConfig{{Size: 4096, Mode: "invalid"}},
+			},
+			fail:           true,
+			expectedErrMsg: "'pmem[0].mode' must be 'direct' or 'disk'",
+		},
+		{
+			name: "Validate 'serial_ports'",
+			config: &HardwareConfig{
+				SerialPorts: []SerialPortConfig{
+					{Type: "file", Path: "console.log"},
+					{Type: "network", Path: "telnet://:23000"},
+					{Type: "network", Path: "telnet://192.0.2.10:23000", Direction: "client", ProxyURI: "telnets://vspc.example.com:13370"},
+				},
+			},
+			fail: false,
+		},
+		{
+			name: "Validate 'serial_ports' and invalid type",
+			config: &HardwareConfig{
+				SerialPorts: []SerialPortConfig{{Type: "pipe", Path: "console"}},
+			},
+			fail:           true,
+			expectedErrMsg: "'serial_ports[0].type' must be 'file' or 'network'",
+		},
+		{
+			name: "Validate 'serial_ports' without path",
+			config: &HardwareConfig{
+				SerialPorts: []SerialPortConfig{{Type: "file"}},
+			},
+			fail:           true,
+			expectedErrMsg: "'serial_ports[0].path' is required",
+		},
+		{
+			name: "Validate 'serial_ports' and network path without scheme",
+			config: &HardwareConfig{
+				SerialPorts: []SerialPortConfig{{Type: "network", Path: "192.0.2.10:23000"}},
+			},
+			fail:           true,
+			expectedErrMsg: "'serial_ports[0].path' must be a URI, such as 'telnet://:23000'",
+		},
+		{
+			name: "Validate 'serial_ports' and file direction",
+			config: &HardwareConfig{
+				SerialPorts: []SerialPortConfig{{Type: "file", Path: "console.log", Direction: "client"}},
+			},
+			fail:           true,
+			expectedErrMsg: "'serial_ports[0].direction' and 'serial_ports[0].proxy_uri' require the 'network' type",
+		},
+		{
+			name: "Validate too many 'serial_ports'",
+			config: &HardwareConfig{
+				SerialPorts: []SerialPortConfig{
+					{Type: "file", Path: "1.log"},
+					{Type: "file", Path: "2.log"},
+					{Type: "file", Path: "3.log"},
+					{Type: "file", Path: "4.log"},
+					{Type: "file", Path: "5.log"},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "'serial_ports' supports up to 4 serial ports",
+		},
+		{
+			name: "Validate 'windows11' guest profile",
+			config: &HardwareConfig{
+				GuestProfile: "windows11",
+				CPUs:         2,
+				RAM:          4096,
+			},
+			fail: false,
+		},
+		{
+			name: "Validate 'windows11' guest profile and 'efi' firmware",
+			config: &HardwareConfig{
+				GuestProfile: "windows11",
+				Firmware:     "efi",
+			},
+			fail:           true,
+			expectedErrMsg: "'firmware' must be 'efi-secure' when 'guest_profile' is 'windows11'",
+		},
+		{
+			name: "Validate 'windows11' guest profile and insufficient 'RAM'",
+			config: &HardwareConfig{
+				GuestProfile: "windows11",
+				RAM:          2048,
+			},
+			fail:           true,
+			expectedErrMsg: "'RAM' must be at least 4096 when 'guest_profile' is 'windows11'",
+		},
+		{
+			name: "Validate invalid guest profile",
+			config: &HardwareConfig{
+				GuestProfile: "windows10",
+			},
+			fail:           true,
+			expectedErrMsg: "'guest_profile' must be '' or 'windows11'",
+		},
+		{
+			name: "Validate vGPU profiles",
+			config: &HardwareConfig{
+				VGPUProfiles:    []string{"grid_a100-20c", "grid_a100-20c"},
+				VGPUDeviceGroup: "NVIDIA 2x A100 NVLink",
+			},
+			fail: false,
+		},
+		{
+			name: "Validate vGPU profile and profiles cannot be used together",
+			config: &HardwareConfig{
+				VGPUProfile:  "grid_a100-20c",
+				VGPUProfiles: []string{"grid_a100-20c"},
+			},
+			fail:           true,
+			expectedErrMsg: "'vgpu_profile' and 'vgpu_profiles' cannot be used together",
+		},
+		{
+			name: "Validate PCI passthrough devices",
+			config: &HardwareConfig{
+				Firmware:               "efi",
+				PCIPassthroughDevices:  []string{"0000:3b:00.0", "5e:00.0"},
+				PCIPassthroughMMIOSize: 128,
+			},
+			fail: false,
+		},
+		{
+			name: "Validate PCI passthrough devices and RAMReservation cannot be used together",
+			config: &HardwareConfig{
+				RAMReservation:        1024,
+				PCIPassthroughDevices: []string{"0000:3b:00.0"},
+			},
+			fail:           true,
+			expectedErrMsg: "'pci_passthrough_devices' and 'RAM_reservation' cannot be used together",
+		},
+		{
+			name: "Validate PCI passthrough MMIO size requires devices",
+			config: &HardwareConfig{
+				PCIPassthroughMMIOSize: 128,
+			},
+			fail:           true,
+			expectedErrMsg: "'pci_passthrough_devices' is required when 'pci_passthrough_mmio_size' is set",
+		},
+		{
+			name: "Validate PCI passthrough MMIO size requires EFI firmware",
+			config: &HardwareConfig{
+				Firmware:               "bios",
+				PCIPassthroughDevices:  []string{"0000:3b:00.0"},
+				PCIPassthroughMMIOSize: 128,
+			},
+			fail:           true,
+			expectedErrMsg: "'pci_passthrough_mmio_size' could be set only when 'firmware' set to 'efi' or 'efi-secure'",
+		},
 	}
 	for _, c := range tc {
 		t.Run(c.name, func(t *testing.T) {
@@ -132,6 +362,29 @@ func TestHardwareConfig_Prepare(t *testing.T) {
 	}
 }
 
+func TestHardwareConfig_PrepareWindows11(t *testing.T) {
+	config := &HardwareConfig{GuestProfile: "windows11"}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.Firmware != "efi-secure" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "efi-secure", config.Firmware)
+	}
+	if !config.VTPMEnabled {
+		t.Fatalf("unexpected result: expected 'vTPM' to be enabled")
+	}
+}
+
+func TestHardwareConfig_PrepareSGX(t *testing.T) {
+	config := &HardwareConfig{Firmware: "efi", SGXEpcSize: 64}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.SGXFlcMode != "unlocked" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "unlocked", config.SGXFlcMode)
+	}
+}
+
 func TestStepConfigureHardware_Run(t *testing.T) {
 	tc := []struct {
 		name            string
@@ -234,20 +487,23 @@ func driverHardwareConfigFromConfig(config *HardwareConfig) *driver.HardwareConf
 	}
 
 	return &driver.HardwareConfig{
-		CPUs:                config.CPUs,
-		CpuCores:            config.CpuCores,
-		CPUReservation:      config.CPUReservation,
-		CPULimit:            config.CPULimit,
-		RAM:                 config.RAM,
-		RAMReservation:      config.RAMReservation,
-		RAMReserveAll:       config.RAMReserveAll,
-		NestedHV:            config.NestedHV,
-		CpuHotAddEnabled:    config.CpuHotAddEnabled,
-		MemoryHotAddEnabled: config.MemoryHotAddEnabled,
-		VideoRAM:            config.VideoRAM,
-		AllowedDevices:      allowedDevices,
-		VGPUProfile:         config.VGPUProfile,
-		Firmware:            config.Firmware,
-		ForceBIOSSetup:      config.ForceBIOSSetup,
+		CPUs:                     config.CPUs,
+		CpuCores:                 config.CpuCores,
+		CPUReservation:           config.CPUReservation,
+		CPULimit:                 config.CPULimit,
+		RAM:                      config.RAM,
+		RAMReservation:           config.RAMReservation,
+		RAMReserveAll:            config.RAMReserveAll,
+		NestedHV:                 config.NestedHV,
+		CpuHotAddEnabled:         config.CpuHotAddEnabled,
+		MemoryHotAddEnabled:      config.MemoryHotAddEnabled,
+		VideoRAM:                 config.VideoRAM,
+		AllowedDevices:           allowedDevices,
+		VGPUProfiles:             config.VGPUProfiles,
+		VGPUDeviceGroup:          config.VGPUDeviceGroup,
+		PCIPassthroughDevices:    config.PCIPassthroughDevices,
+		PCIPassthroughMMIOSizeGB: config.PCIPassthroughMMIOSize,
+		Firmware:                 config.Firmware,
+		ForceBIOSSetup:           config.ForceBIOSSetup,
 	}
 }
@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type HostAffinityConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Pin the virtual machine to specific ESXi hosts in a DRS cluster during the
+// build, such as the hosts that have access to the datastore of the ISO files
+// or that have the GPU devices. A temporary DRS virtual machine to host
+// affinity rule is created before the virtual machine is powered on, and the
+// rule and its groups are removed after the build. If `host` is not set, the
+// virtual machine is created on the first host in `affinity_hosts` that is
+// connected and not in maintenance mode.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	affinity_hosts = ["esxi-01.example.com", "esxi-02.example.com"]
+//
+// ```
+//
+// -> **Note:** Requires the `Host.Inventory.EditCluster` privilege on the
+// cluster.
+type HostAffinityConfig struct {
+	// The names of the ESXi hosts in the cluster of the virtual machine on
+	// which to run the virtual machine during the build.
+	AffinityHosts []string `mapstructure:"affinity_hosts"`
+	// Create a rule that the virtual machine should run on the hosts in
+	// `affinity_hosts`, which DRS can violate, rather than a rule that the
+	// virtual machine must run on the hosts. Defaults to `false`.
+	AffinityRulePreferred bool `mapstructure:"affinity_rule_preferred"`
+}
+
+func (c *HostAffinityConfig) Prepare(lc *LocationConfig) []error {
+	var errs []error
+
+	if slices.Contains(c.AffinityHosts, "") {
+		errs = append(errs, fmt.Errorf("'affinity_hosts' cannot contain empty host names"))
+	}
+	if c.AffinityRulePreferred && len(c.AffinityHosts) == 0 {
+		errs = append(errs, fmt.Errorf("'affinity_rule_preferred' requires 'affinity_hosts'"))
+	}
+	if len(c.AffinityHosts) > 0 && lc.Host != "" && !slices.Contains(c.AffinityHosts, lc.Host) {
+		errs = append(errs, fmt.Errorf("'host' must be one of 'affinity_hosts'"))
+	}
+
+	return errs
+}
+
+type StepSelectAffinityHost struct {
+	Config *HostAffinityConfig
+	// The locations of the virtual machines that are created on the
+	// selected host.
+	Locations []*LocationConfig
+}
+
+// Run selects the host on which the virtual machine is created from the
+// affinity hosts, so the virtual machine is not placed on another host of the
+// cluster before the affinity rule is added. The host is set in the location
+// configurations, since the steps that create the virtual machine are
+// configured before the host is selected.
+func (s *StepSelectAffinityHost) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Config.AffinityHosts) == 0 || len(s.Locations) == 0 || s.Locations[0].Host != "" {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	var unavailable []string
+	for _, name := range s.Config.AffinityHosts {
+		host, err := d.FindHost(name)
+		if err != nil {
+			state.Put("error", fmt.Errorf("error finding affinity host %s: %s", name, err))
+			return multistep.ActionHalt
+		}
+		info, err := host.Info("runtime.connectionState", "runtime.inMaintenanceMode")
+		if err != nil {
+			state.Put("error", fmt.Errorf("error retrieving the state of affinity host %s: %s", name, err))
+			return multistep.ActionHalt
+		}
+		if info.Runtime.ConnectionState != types.HostSystemConnectionStateConnected || info.Runtime.InMaintenanceMode {
+			unavailable = append(unavailable, name)
+			continue
+		}
+
+		ui.Sayf("Creating the virtual machine on affinity host %s...", name)
+		for _, location := range s.Locations {
+			location.Host = name
+		}
+		return multistep.ActionContinue
+	}
+
+	state.Put("error", fmt.Errorf("no affinity host is available: %s are disconnected or in maintenance mode", strings.Join(unavailable, ", ")))
+	return multistep.ActionHalt
+}
+
+func (s *StepSelectAffinityHost) Cleanup(multistep.StateBag) {}
+
+type StepAddHostAffinityRule struct {
+	Config *HostAffinityConfig
+
+	vm   driver.VirtualMachine
+	rule *driver.HostAffinityRule
+}
+
+func (s *StepAddHostAffinityRule) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Config.AffinityHosts) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Sayf("Adding DRS host affinity rule for hosts: %s", strings.Join(s.Config.AffinityHosts, ", "))
+	rule, err := vm.AddHostAffinityRule(s.Config.AffinityHosts, !s.Config.AffinityRulePreferred)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error adding DRS host affinity rule: %s", err))
+		return multistep.ActionHalt
+	}
+	// The virtual machine and the rule are kept, since the virtual machine
+	// in the state is replaced by the clone of a base template.
+	s.vm, s.rule = vm, rule
+
+	return multistep.ActionContinue
+}
+
+func (s *StepAddHostAffinityRule) Cleanup(state multistep.StateBag) {
+	if s.rule == nil {
+		return
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	ui.Sayf("Removing DRS host affinity rule %s...", s.rule.Name)
+	if err := s.vm.RemoveHostAffinityRule(s.rule); err != nil {
+		ui.Errorf("Error removing DRS host affinity rule %s: %s", s.rule.Name, err)
+	}
+}
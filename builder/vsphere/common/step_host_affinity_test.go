@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestHostAffinityConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name     string
+		config   HostAffinityConfig
+		location LocationConfig
+		fail     bool
+	}{
+		{
+			name:     "Affinity hosts",
+			config:   HostAffinityConfig{AffinityHosts: []string{"esxi-01", "esxi-02"}},
+			location: LocationConfig{Cluster: "cluster"},
+		},
+		{
+			name:     "Affinity hosts with host",
+			config:   HostAffinityConfig{AffinityHosts: []string{"esxi-01", "esxi-02"}},
+			location: LocationConfig{Cluster: "cluster", Host: "esxi-02"},
+		},
+		{
+			name:     "Host not in affinity hosts",
+			config:   HostAffinityConfig{AffinityHosts: []string{"esxi-01"}},
+			location: LocationConfig{Cluster: "cluster", Host: "esxi-02"},
+			fail:     true,
+		},
+		{
+			name:   "Empty host name",
+			config: HostAffinityConfig{AffinityHosts: []string{""}},
+			fail:   true,
+		},
+		{
+			name:   "Preferred rule without hosts",
+			config: HostAffinityConfig{AffinityRulePreferred: true},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(&c.location)
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+		})
+	}
+}
+
+func TestStepAddHostAffinityRule_Run(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepAddHostAffinityRule{Config: &HostAffinityConfig{AffinityHosts: []string{"esxi-01"}}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if len(vm.AddHostAffinityRuleHosts) != 1 || !vm.AddHostAffinityRuleMandatory {
+		t.Fatalf("unexpected result: expected a mandatory rule for 1 host, but returned hosts '%v' and mandatory '%t'",
+			vm.AddHostAffinityRuleHosts, vm.AddHostAffinityRuleMandatory)
+	}
+
+	// The rule is removed from the virtual machine of the step, even if the
+	// virtual machine in the state is replaced.
+	state.Put("vm", new(driver.VirtualMachineMock))
+	step.Cleanup(state)
+	if !vm.RemoveHostAffinityRuleCalled || vm.RemoveHostAffinityRuleName != "packer-vm-1" {
+		t.Fatal("unexpected result: expected the rule to be removed")
+	}
+}
+
+func TestStepAddHostAffinityRule_RunDisabled(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepAddHostAffinityRule{Config: &HostAffinityConfig{}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	step.Cleanup(state)
+	if vm.AddHostAffinityRuleHosts != nil || vm.RemoveHostAffinityRuleCalled {
+		t.Fatal("unexpected result: expected no changes to the cluster")
+	}
+}
+
+func TestStepAddHostAffinityRule_RunError(t *testing.T) {
+	vm := &driver.VirtualMachineMock{AddHostAffinityRuleErr: fmt.Errorf("the virtual machine is not in a cluster")}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepAddHostAffinityRule{Config: &HostAffinityConfig{AffinityHosts: []string{"esxi-01"}}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("unexpected result: expected an error in the state")
+	}
+	step.Cleanup(state)
+	if vm.RemoveHostAffinityRuleCalled {
+		t.Fatal("unexpected result: expected no rule to be removed")
+	}
+}
+
+func TestStepSelectAffinityHost_Run(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	for _, ref := range simulator.Map.All("HostSystem") {
+		host := simulator.Map.Get(ref.Reference()).(*simulator.HostSystem)
+		switch host.Name {
+		case "DC0_C0_H0":
+			host.Runtime.InMaintenanceMode = true
+		case "DC0_C0_H1":
+			host.Runtime.ConnectionState = types.HostSystemConnectionStateDisconnected
+		}
+	}
+
+	state := basicStateBag(nil)
+	state.Put("driver", sim.driver)
+
+	config := &HostAffinityConfig{AffinityHosts: []string{"DC0_C0_H0", "DC0_C0_H1", "DC0_C0_H2"}}
+	locations := []*LocationConfig{{Cluster: "DC0_C0"}, {Cluster: "DC0_C0"}}
+	step := &StepSelectAffinityHost{Config: config, Locations: locations}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%s'", state.Get("error"))
+	}
+	for _, location := range locations {
+		if location.Host != "DC0_C0_H2" {
+			t.Fatalf("unexpected result: expected host 'DC0_C0_H2', but returned '%s'", location.Host)
+		}
+	}
+
+	// A host that is set in the configuration is not replaced.
+	location := &LocationConfig{Cluster: "DC0_C0", Host: "DC0_C0_H0"}
+	step = &StepSelectAffinityHost{Config: config, Locations: []*LocationConfig{location}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%s'", state.Get("error"))
+	}
+	if location.Host != "DC0_C0_H0" {
+		t.Fatalf("unexpected result: expected host 'DC0_C0_H0', but returned '%s'", location.Host)
+	}
+
+	// The build fails if none of the affinity hosts is available.
+	config = &HostAffinityConfig{AffinityHosts: []string{"DC0_C0_H0", "DC0_C0_H1"}}
+	location = &LocationConfig{Cluster: "DC0_C0"}
+	step = &StepSelectAffinityHost{Config: config, Locations: []*LocationConfig{location}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("unexpected result: expected an error in the state")
+	}
+	if location.Host != "" {
+		t.Fatalf("unexpected result: expected no host, but returned '%s'", location.Host)
+	}
+}
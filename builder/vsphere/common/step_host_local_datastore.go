@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+type StepSelectHostLocalDatastore struct {
+	Location *LocationConfig
+}
+
+// Run selects the local datastore of the host with the most free space. The
+// datastore is set in the location configuration and in the state, since the
+// steps that upload files are configured before the datastore is selected.
+func (s *StepSelectHostLocalDatastore) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Location.HostLocalDatastore {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	summaries, err := d.HostLocalDatastores(s.Location.Host)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving the local datastores of host %s: %s", s.Location.Host, err))
+		return multistep.ActionHalt
+	}
+
+	var selected *driver.DatastoreSummary
+	for i, summary := range summaries {
+		if !summary.Accessible || (summary.MaintenanceMode != "" && summary.MaintenanceMode != "normal") {
+			continue
+		}
+		if selected == nil || summary.FreeSpace > selected.FreeSpace {
+			selected = &summaries[i]
+		}
+	}
+	if selected == nil {
+		state.Put("error", fmt.Errorf("host %s has no accessible local datastore", s.Location.Host))
+		return multistep.ActionHalt
+	}
+
+	ui.Sayf("Using local datastore %s of host %s with %s free...", selected.Name, s.Location.Host, formatBytes(selected.FreeSpace))
+	s.Location.Datastore = selected.Name
+	state.Put("datastore", selected.Name)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepSelectHostLocalDatastore) Cleanup(multistep.StateBag) {}
+
+// datastoreName returns the name of the datastore, or the name of the local
+// datastore selected by StepSelectHostLocalDatastore if the name is empty.
+func datastoreName(state multistep.StateBag, name string) string {
+	if name != "" {
+		return name
+	}
+	if selected, ok := state.GetOk("datastore"); ok {
+		return selected.(string)
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestLocationConfig_PrepareHostLocalDatastore(t *testing.T) {
+	tc := []struct {
+		name        string
+		config      *LocationConfig
+		export      *ExportConfig
+		library     *ContentLibraryDestinationConfig
+		expectedErr string
+	}{
+		{
+			name:   "Export",
+			config: &LocationConfig{HostLocalDatastore: true},
+			export: &ExportConfig{},
+		},
+		{
+			name:    "Content library OVF",
+			config:  &LocationConfig{HostLocalDatastore: true},
+			library: &ContentLibraryDestinationConfig{Ovf: true},
+		},
+		{
+			name:        "No export or content library",
+			config:      &LocationConfig{HostLocalDatastore: true},
+			expectedErr: "'host_local_datastore' requires 'export' or 'content_library_destination'",
+		},
+		{
+			name:        "Content library template without datastore",
+			config:      &LocationConfig{HostLocalDatastore: true},
+			library:     &ContentLibraryDestinationConfig{},
+			expectedErr: "'host_local_datastore' requires 'content_library_destination.ovf' or 'content_library_destination.datastore'",
+		},
+		{
+			name:   "Named datastore",
+			config: &LocationConfig{Datastore: "datastore1"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.PrepareHostLocalDatastore(c.export, c.library)
+			if c.expectedErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("unexpected error: '%s'", errs[0])
+				}
+				return
+			}
+			if len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if errs[0].Error() != c.expectedErr {
+				t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErr, errs[0])
+			}
+		})
+	}
+}
+
+func TestStepSelectHostLocalDatastore_Run(t *testing.T) {
+	d := driver.NewDriverMock()
+	d.HostLocalDatastoresResult = []driver.DatastoreSummary{
+		{Name: "local-ssd", FreeSpace: 200, Accessible: true, MaintenanceMode: "normal"},
+		{Name: "local-nvme", FreeSpace: 500, Accessible: true, MaintenanceMode: "normal"},
+		{Name: "local-maintenance", FreeSpace: 900, Accessible: true, MaintenanceMode: "inMaintenance"},
+		{Name: "local-inaccessible", FreeSpace: 900},
+	}
+
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	location := &LocationConfig{Host: "esxi-01", HostLocalDatastore: true}
+	step := &StepSelectHostLocalDatastore{Location: location}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	if d.HostLocalDatastoresHost != "esxi-01" {
+		t.Fatalf("unexpected result: expected 'esxi-01', but returned '%s'", d.HostLocalDatastoresHost)
+	}
+	if location.Datastore != "local-nvme" {
+		t.Fatalf("unexpected result: expected 'local-nvme', but returned '%s'", location.Datastore)
+	}
+	if name := datastoreName(state, ""); name != "local-nvme" {
+		t.Fatalf("unexpected result: expected 'local-nvme', but returned '%s'", name)
+	}
+	if name := datastoreName(state, "datastore1"); name != "datastore1" {
+		t.Fatalf("unexpected result: expected 'datastore1', but returned '%s'", name)
+	}
+}
+
+func TestStepSelectHostLocalDatastore_RunNoLocalDatastore(t *testing.T) {
+	d := driver.NewDriverMock()
+
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+	step := &StepSelectHostLocalDatastore{Location: &LocationConfig{Host: "esxi-01", HostLocalDatastore: true}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	err, ok := state.Get("error").(error)
+	if !ok {
+		t.Fatal("unexpected success: expected failure")
+	}
+	expected := "host esxi-01 has no accessible local datastore"
+	if err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+}
@@ -6,21 +6,31 @@ package common
 import (
 	"context"
 	"fmt"
+	"log"
 	"net"
+	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
 // Step to discover the http ip
 // which guests use to reach the vm host
 // To make sure the IP is set before boot command and http server steps
 type StepHTTPIPDiscover struct {
-	HTTPIP  string
-	Network *net.IPNet
+	HTTPIP    string
+	Network   *net.IPNet
+	Discovery string
 }
 
 func (s *StepHTTPIPDiscover) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	ip, err := getHostIP(s.HTTPIP, s.Network)
+	var ip string
+	var err error
+	if s.HTTPIP == "" && s.Discovery == HTTPIPDiscoveryHost {
+		ip, err = getRouteIP(state.Get("vm").(driver.VirtualMachine))
+	} else {
+		ip, err = getHostIP(s.HTTPIP, s.Network)
+	}
 	if err != nil {
 		state.Put("error", err)
 		return multistep.ActionHalt
@@ -70,3 +80,27 @@ func getHostIP(s string, network *net.IPNet) (string, error) {
 	}
 	return "", fmt.Errorf("IP not found")
 }
+
+// getRouteIP returns the IP address of the Packer host that is used to reach
+// the ESXi host of the virtual machine, as selected by the routing table.
+func getRouteIP(vm driver.VirtualMachine) (string, error) {
+	addresses, err := vm.HostAddresses()
+	if err != nil {
+		return "", fmt.Errorf("error finding the addresses of the host: %s", err)
+	}
+
+	var errs []string
+	for _, address := range addresses {
+		// Connecting a UDP socket selects the route without sending packets.
+		conn, err := net.Dial("udp", net.JoinHostPort(address, "443"))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		ip := conn.LocalAddr().(*net.UDPAddr).IP.String()
+		_ = conn.Close()
+		log.Printf("[INFO] Discovered HTTP server IP %s for host address %s", ip, address)
+		return ip, nil
+	}
+	return "", fmt.Errorf("no route to the host: %s", strings.Join(errs, "; "))
+}
@@ -5,10 +5,12 @@ package common
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"testing"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
 func TestStepHTTPIPDiscover_Run(t *testing.T) {
@@ -84,3 +86,22 @@ func TestStepHTTPIPDiscover_Run(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s', but returned '%s'", ip, httpIp)
 	}
 }
+
+func TestStepHTTPIPDiscover_RunHost(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("vm", &driver.VirtualMachineMock{HostAddressesReturn: []string{"127.0.0.1"}})
+
+	step := &StepHTTPIPDiscover{Discovery: HTTPIPDiscoveryHost}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if httpIP := state.Get("http_ip"); httpIP != "127.0.0.1" {
+		t.Fatalf("unexpected result: expected '127.0.0.1', but returned '%s'", httpIP)
+	}
+
+	state = new(multistep.BasicStateBag)
+	state.Put("vm", &driver.VirtualMachineMock{HostAddressesErr: fmt.Errorf("not found")})
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+}
@@ -8,6 +8,8 @@ package common
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -24,8 +26,31 @@ import (
 type ContentLibraryDestinationConfig struct {
 	// The name of the content library in which the new content library item
 	// containing the template will be created or updated. The content library
-	// must be of type Local to allow deploying virtual machines.
+	// must be of type Local to allow deploying virtual machines. Required
+	// unless `ephemeral_library` is set.
 	Library string `mapstructure:"library"`
+	// Create a uniquely named local content library for the build, in which
+	// the content library item is created, instead of using an existing
+	// library. The library is named `packer-<vm_name>-<timestamp>-<suffix>`.
+	// Its identifier and, if published, its subscription URL are included in
+	// the artifact as `content_library_id` and `content_library_url`. The
+	// library is deleted if the build fails. This option cannot be used with
+	// `library`. Defaults to `false`.
+	EphemeralLibrary bool `mapstructure:"ephemeral_library"`
+	// The datastore on which the ephemeral content library is created.
+	// Defaults to [`datastore`](#datastore).
+	EphemeralLibraryDatastore string `mapstructure:"ephemeral_library_datastore"`
+	// Publish the ephemeral content library without authentication, so that
+	// the library can be subscribed to using the URL in the artifact.
+	// Defaults to `false`.
+	EphemeralLibraryPublish bool `mapstructure:"ephemeral_library_publish"`
+	// The amount of time to retain the ephemeral content libraries of the
+	// virtual machine. When a build creates an ephemeral library, the
+	// ephemeral libraries of earlier builds of the same `vm_name` that were
+	// created more than this amount of time ago are deleted with their items.
+	// Defaults to `0s`, which retains the libraries until they are deleted
+	// manually.
+	EphemeralLibraryRetention time.Duration `mapstructure:"ephemeral_library_retention"`
 	// The name of the content library item that will be created or updated.
 	// For VM templates, the name of the item should be different from
 	// [vm_name](#vm_name) and the default is [vm_name](#vm_name) + timestamp
@@ -79,13 +104,73 @@ type ContentLibraryDestinationConfig struct {
 	// obtained using ExportFlag.list. If unset, no flags will be used.
 	// Known values: `EXTRA_CONFIG`, `PRESERVE_MAC`.
 	OvfFlags []string `mapstructure:"ovf_flags"`
+	// Stream the exported OVF template from the ESXi host directly to an
+	// update session of the content library item, instead of exporting the
+	// virtual machine to the Packer host and importing the files. The files
+	// are not staged on the Packer host and each file is transferred once. An
+	// active update session of the item, such as one left by an interrupted
+	// build, is reused. Requires `ovf`. Defaults to `false`.
+	//
+	// -> **Note:** The disks and the NVRAM of the virtual machine are
+	// included in the OVF template. The `ovf_flags` are applied as the
+	// equivalent OVF export options.
+	StreamExport bool `mapstructure:"stream_export"`
+	// The number of previous versions of the OVF template to keep when an
+	// existing content library item is updated. Before the item is updated,
+	// its content is copied to a new item named `<name>-v<n>`, where `<n>`
+	// increments with each version. After the item is updated, the oldest
+	// versions are deleted so that at most this number of versions remain. If
+	// the update fails, the new version is deleted. The item keeps its
+	// identifier and always has the latest template. Requires `ovf`.
+	// Defaults to `0`, which does not keep previous versions.
+	KeepVersions int `mapstructure:"keep_versions"`
+	// Publish the item to the subscribed libraries of the content library
+	// after the import and wait for the subscribed libraries to synchronize
+	// the item, so that the item is available at the sites of the subscribed
+	// libraries when the build is complete. The subscribed libraries in other
+	// vCenter Server instances are published to, but not waited for. Cannot
+	// be used with `ephemeral_library` or `skip_import`. Defaults to `false`.
+	SyncSubscribedLibraries bool `mapstructure:"sync_subscribed_libraries"`
+	// The names of the subscribed libraries to publish the item to. Defaults
+	// to all subscribed libraries of the content library. Requires
+	// `sync_subscribed_libraries`.
+	SubscribedLibraries []string `mapstructure:"subscribed_libraries"`
+	// The amount of time to wait for the subscribed libraries to synchronize
+	// the item. Defaults to `30m`.
+	SubscribedLibrariesTimeout time.Duration `mapstructure:"subscribed_libraries_timeout"`
+}
+
+const defaultSubscribedLibrariesTimeout = 30 * time.Minute
+
+// The OVF export options equivalent to the flags of the OVF package creation.
+var ovfFlagExportOptions = map[string]string{
+	"EXTRA_CONFIG": "extraconfig",
+	"PRESERVE_MAC": "mac",
 }
 
 func (c *ContentLibraryDestinationConfig) Prepare(lc *LocationConfig) []error {
 	var errs *packersdk.MultiError
 
-	if c.Library == "" {
-		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("a library name must be provided"))
+	if c.EphemeralLibrary {
+		if c.Library != "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'library' and 'ephemeral_library' cannot be used together"))
+		}
+		if c.EphemeralLibraryDatastore == "" {
+			c.EphemeralLibraryDatastore = lc.Datastore
+		}
+		if c.EphemeralLibraryDatastore == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'ephemeral_library_datastore' is required if 'datastore' is not set"))
+		}
+		if c.EphemeralLibraryRetention < 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'ephemeral_library_retention' must be greater than or equal to 0"))
+		}
+	} else {
+		if c.Library == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("a library name must be provided"))
+		}
+		if c.EphemeralLibraryDatastore != "" || c.EphemeralLibraryPublish || c.EphemeralLibraryRetention != 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("the 'ephemeral_library_*' options require 'ephemeral_library'"))
+		}
 	}
 
 	if c.Ovf {
@@ -117,6 +202,37 @@ func (c *ContentLibraryDestinationConfig) Prepare(lc *LocationConfig) []error {
 			c.ResourcePool = lc.ResourcePool
 		}
 	}
+	if c.StreamExport {
+		if !c.Ovf {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'stream_export' requires 'ovf'"))
+		}
+		for _, flag := range c.OvfFlags {
+			if _, ok := ovfFlagExportOptions[flag]; !ok {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("the OVF flag %s is not supported with 'stream_export'", flag))
+			}
+		}
+	}
+	if c.KeepVersions < 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'keep_versions' must be greater than or equal to 0"))
+	}
+	if c.KeepVersions > 0 && !c.Ovf {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'keep_versions' requires 'ovf'"))
+	}
+	if c.SyncSubscribedLibraries {
+		if c.EphemeralLibrary {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'sync_subscribed_libraries' cannot be used with 'ephemeral_library'"))
+		}
+		if c.SkipImport {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'sync_subscribed_libraries' cannot be used with 'skip_import'"))
+		}
+		if c.SubscribedLibrariesTimeout < 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'subscribed_libraries_timeout' must be greater than or equal to 0"))
+		} else if c.SubscribedLibrariesTimeout == 0 {
+			c.SubscribedLibrariesTimeout = defaultSubscribedLibrariesTimeout
+		}
+	} else if len(c.SubscribedLibraries) > 0 || c.SubscribedLibrariesTimeout != 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'subscribed_libraries' and 'subscribed_libraries_timeout' require 'sync_subscribed_libraries'"))
+	}
 	if c.Description == "" {
 		c.Description = fmt.Sprintf("Packer imported %s VM template", lc.VMName)
 	}
@@ -130,9 +246,15 @@ func (c *ContentLibraryDestinationConfig) Prepare(lc *LocationConfig) []error {
 
 type StepImportToContentLibrary struct {
 	ContentLibConfig *ContentLibraryDestinationConfig
+	// The name of the virtual machine, which is included in the name of the
+	// ephemeral content library.
+	VMName string
+
+	// The identifier of the ephemeral content library created by the step.
+	ephemeralLibraryID string
 }
 
-func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+func (s *StepImportToContentLibrary) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 	if s.ContentLibConfig.SkipImport {
 		ui.Say("Skipping import...")
@@ -149,6 +271,14 @@ func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.Stat
 		return multistep.ActionHalt
 	}
 
+	if s.ContentLibConfig.EphemeralLibrary {
+		if err := s.createEphemeralLibrary(ui, state, vm); err != nil {
+			ui.Errorf("Failed to create the ephemeral content library: %s", err)
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+
 	vmTypeLabel := "VM"
 	if s.ContentLibConfig.Ovf {
 		vmTypeLabel = "VM OVF"
@@ -156,18 +286,51 @@ func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.Stat
 	ui.Sayf("Importing %s template %s to Content Library '%s' as the item '%s' with the description '%s'...",
 		vmTypeLabel, s.ContentLibConfig.Name, s.ContentLibConfig.Library, s.ContentLibConfig.Name, s.ContentLibConfig.Description)
 
-	if s.ContentLibConfig.Ovf {
+	// The previous version is copied before the import, but the oldest
+	// versions are deleted only after the import succeeds, so that a failed
+	// import does not lose a version.
+	var version string
+	if s.ContentLibConfig.KeepVersions > 0 {
+		version, err = vm.KeepContentLibraryItemVersion(s.ContentLibConfig.Library, s.ContentLibConfig.Name)
+		if err != nil {
+			ui.Errorf("Failed to keep the previous version of %s: %s", s.ContentLibConfig.Name, err)
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		if version != "" {
+			ui.Sayf("Kept the previous version of %s as the item '%s'.", s.ContentLibConfig.Name, version)
+		}
+	}
+
+	switch {
+	case s.ContentLibConfig.StreamExport:
+		err = s.streamOvfTemplate(ctx, vm)
+	case s.ContentLibConfig.Ovf:
 		err = s.importOvfTemplate(vm)
-	} else {
+	default:
 		err = s.importVmTemplate(vm)
 	}
 
 	if err != nil {
 		ui.Errorf("Failed to import template %s: %s", s.ContentLibConfig.Name, err)
+		if version != "" {
+			ui.Sayf("Deleting the kept version '%s'...", version)
+			if err := vm.DeleteContentLibraryItemVersion(s.ContentLibConfig.Library, version); err != nil {
+				ui.Errorf("Failed to delete the kept version '%s': %s", version, err)
+			}
+		}
 		state.Put("error", err)
 		return multistep.ActionHalt
 	}
 
+	if s.ContentLibConfig.KeepVersions > 0 {
+		// The item is imported, so the build does not fail if the oldest
+		// versions cannot be deleted.
+		if err := vm.PruneContentLibraryItemVersions(s.ContentLibConfig.Library, s.ContentLibConfig.Name, s.ContentLibConfig.KeepVersions); err != nil {
+			ui.Errorf("Failed to delete the oldest versions of %s: %s", s.ContentLibConfig.Name, err)
+		}
+	}
+
 	// Add a tracer to the state to track if the Destroy parameter was used.
 	if s.ContentLibConfig.Destroy {
 		state.Put("destroy_vm", s.ContentLibConfig.Destroy)
@@ -193,9 +356,59 @@ func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.Stat
 		state.Put("content_library_datastore", datastores)
 	}
 
+	if s.ContentLibConfig.SyncSubscribedLibraries {
+		ui.Sayf("Publishing %s to the subscribed libraries of Content Library '%s'...", s.ContentLibConfig.Name, s.ContentLibConfig.Library)
+		err := vm.PublishContentLibraryItem(s.ContentLibConfig.Library, s.ContentLibConfig.Name, s.ContentLibConfig.SubscribedLibraries,
+			s.ContentLibConfig.SubscribedLibrariesTimeout, func(pending []string) {
+				ui.Sayf("Waiting for the subscribed libraries to synchronize %s: %s", s.ContentLibConfig.Name, strings.Join(pending, ", "))
+			})
+		if err != nil {
+			ui.Errorf("Failed to synchronize the subscribed libraries: %s", err)
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		ui.Sayf("The subscribed libraries have synchronized %s.", s.ContentLibConfig.Name)
+	}
+
 	return multistep.ActionContinue
 }
 
+// createEphemeralLibrary deletes the expired ephemeral content libraries of
+// earlier builds and creates the ephemeral content library of the build, to
+// which the template is imported.
+func (s *StepImportToContentLibrary) createEphemeralLibrary(ui packersdk.Ui, state multistep.StateBag, vm *driver.VirtualMachineDriver) error {
+	prefix := fmt.Sprintf("packer-%s-", s.VMName)
+	if s.ContentLibConfig.EphemeralLibraryRetention > 0 {
+		deleted, err := vm.DeleteExpiredEphemeralContentLibraries(prefix, s.ContentLibConfig.EphemeralLibraryRetention)
+		for _, name := range deleted {
+			ui.Sayf("Deleted the expired ephemeral content library '%s'.", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ui.Sayf("Creating an ephemeral content library on datastore '%s'...", s.ContentLibConfig.EphemeralLibraryDatastore)
+	l, err := vm.CreateEphemeralContentLibrary(&driver.EphemeralLibraryConfig{
+		Prefix:    prefix,
+		Datastore: s.ContentLibConfig.EphemeralLibraryDatastore,
+		Publish:   s.ContentLibConfig.EphemeralLibraryPublish,
+	})
+	if err != nil {
+		return err
+	}
+	s.ephemeralLibraryID = l.ID
+	s.ContentLibConfig.Library = l.Name
+	ui.Sayf("Created the ephemeral content library '%s' (%s).", l.Name, l.ID)
+
+	state.Put("content_library_id", l.ID)
+	if l.Publication != nil && l.Publication.PublishURL != "" {
+		ui.Sayf("The ephemeral content library is published at %s.", l.Publication.PublishURL)
+		state.Put("content_library_url", l.Publication.PublishURL)
+	}
+	return nil
+}
+
 func (s *StepImportToContentLibrary) importOvfTemplate(vm *driver.VirtualMachineDriver) error {
 	ovf := vcenter.OVF{
 		Spec: vcenter.CreateSpec{
@@ -210,6 +423,18 @@ func (s *StepImportToContentLibrary) importOvfTemplate(vm *driver.VirtualMachine
 	return vm.ImportOvfToContentLibrary(ovf)
 }
 
+func (s *StepImportToContentLibrary) streamOvfTemplate(ctx context.Context, vm *driver.VirtualMachineDriver) error {
+	config := &driver.StreamOvfConfig{
+		Library:     s.ContentLibConfig.Library,
+		Name:        s.ContentLibConfig.Name,
+		Description: s.ContentLibConfig.Description,
+	}
+	for _, flag := range s.ContentLibConfig.OvfFlags {
+		config.ExportOptions = append(config.ExportOptions, ovfFlagExportOptions[flag])
+	}
+	return vm.StreamOvfToContentLibrary(ctx, config)
+}
+
 func (s *StepImportToContentLibrary) importVmTemplate(vm *driver.VirtualMachineDriver) error {
 	template := vcenter.Template{
 		Name:        s.ContentLibConfig.Name,
@@ -232,5 +457,20 @@ func (s *StepImportToContentLibrary) importVmTemplate(vm *driver.VirtualMachineD
 	return vm.ImportToContentLibrary(template)
 }
 
-func (s *StepImportToContentLibrary) Cleanup(multistep.StateBag) {
+func (s *StepImportToContentLibrary) Cleanup(state multistep.StateBag) {
+	if s.ephemeralLibraryID == "" {
+		return
+	}
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(*driver.VirtualMachineDriver)
+	ui.Sayf("Deleting the ephemeral content library '%s'...", s.ContentLibConfig.Library)
+	if err := vm.DeleteContentLibrary(s.ephemeralLibraryID); err != nil {
+		ui.Errorf("Error deleting the ephemeral content library: %s", err)
+	}
 }
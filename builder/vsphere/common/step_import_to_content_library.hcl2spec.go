@@ -10,18 +10,27 @@ import (
 // FlatContentLibraryDestinationConfig is an auto-generated flat version of ContentLibraryDestinationConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatContentLibraryDestinationConfig struct {
-	Library      *string  `mapstructure:"library" cty:"library" hcl:"library"`
-	Name         *string  `mapstructure:"name" cty:"name" hcl:"name"`
-	Description  *string  `mapstructure:"description" cty:"description" hcl:"description"`
-	Cluster      *string  `mapstructure:"cluster" cty:"cluster" hcl:"cluster"`
-	Folder       *string  `mapstructure:"folder" cty:"folder" hcl:"folder"`
-	Host         *string  `mapstructure:"host" cty:"host" hcl:"host"`
-	ResourcePool *string  `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
-	Datastore    *string  `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
-	Destroy      *bool    `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
-	Ovf          *bool    `mapstructure:"ovf" cty:"ovf" hcl:"ovf"`
-	SkipImport   *bool    `mapstructure:"skip_import" cty:"skip_import" hcl:"skip_import"`
-	OvfFlags     []string `mapstructure:"ovf_flags" cty:"ovf_flags" hcl:"ovf_flags"`
+	Library                    *string  `mapstructure:"library" cty:"library" hcl:"library"`
+	EphemeralLibrary           *bool    `mapstructure:"ephemeral_library" cty:"ephemeral_library" hcl:"ephemeral_library"`
+	EphemeralLibraryDatastore  *string  `mapstructure:"ephemeral_library_datastore" cty:"ephemeral_library_datastore" hcl:"ephemeral_library_datastore"`
+	EphemeralLibraryPublish    *bool    `mapstructure:"ephemeral_library_publish" cty:"ephemeral_library_publish" hcl:"ephemeral_library_publish"`
+	EphemeralLibraryRetention  *string  `mapstructure:"ephemeral_library_retention" cty:"ephemeral_library_retention" hcl:"ephemeral_library_retention"`
+	Name                       *string  `mapstructure:"name" cty:"name" hcl:"name"`
+	Description                *string  `mapstructure:"description" cty:"description" hcl:"description"`
+	Cluster                    *string  `mapstructure:"cluster" cty:"cluster" hcl:"cluster"`
+	Folder                     *string  `mapstructure:"folder" cty:"folder" hcl:"folder"`
+	Host                       *string  `mapstructure:"host" cty:"host" hcl:"host"`
+	ResourcePool               *string  `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
+	Datastore                  *string  `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
+	Destroy                    *bool    `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
+	Ovf                        *bool    `mapstructure:"ovf" cty:"ovf" hcl:"ovf"`
+	SkipImport                 *bool    `mapstructure:"skip_import" cty:"skip_import" hcl:"skip_import"`
+	OvfFlags                   []string `mapstructure:"ovf_flags" cty:"ovf_flags" hcl:"ovf_flags"`
+	StreamExport               *bool    `mapstructure:"stream_export" cty:"stream_export" hcl:"stream_export"`
+	KeepVersions               *int     `mapstructure:"keep_versions" cty:"keep_versions" hcl:"keep_versions"`
+	SyncSubscribedLibraries    *bool    `mapstructure:"sync_subscribed_libraries" cty:"sync_subscribed_libraries" hcl:"sync_subscribed_libraries"`
+	SubscribedLibraries        []string `mapstructure:"subscribed_libraries" cty:"subscribed_libraries" hcl:"subscribed_libraries"`
+	SubscribedLibrariesTimeout *string  `mapstructure:"subscribed_libraries_timeout" cty:"subscribed_libraries_timeout" hcl:"subscribed_libraries_timeout"`
 }
 
 // FlatMapstructure returns a new FlatContentLibraryDestinationConfig.
@@ -36,18 +45,27 @@ func (*ContentLibraryDestinationConfig) FlatMapstructure() interface{ HCL2Spec()
 // The decoded values from this spec will then be applied to a FlatContentLibraryDestinationConfig.
 func (*FlatContentLibraryDestinationConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"library":       &hcldec.AttrSpec{Name: "library", Type: cty.String, Required: false},
-		"name":          &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
-		"description":   &hcldec.AttrSpec{Name: "description", Type: cty.String, Required: false},
-		"cluster":       &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
-		"folder":        &hcldec.AttrSpec{Name: "folder", Type: cty.String, Required: false},
-		"host":          &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
-		"resource_pool": &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
-		"datastore":     &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
-		"destroy":       &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
-		"ovf":           &hcldec.AttrSpec{Name: "ovf", Type: cty.Bool, Required: false},
-		"skip_import":   &hcldec.AttrSpec{Name: "skip_import", Type: cty.Bool, Required: false},
-		"ovf_flags":     &hcldec.AttrSpec{Name: "ovf_flags", Type: cty.List(cty.String), Required: false},
+		"library":                      &hcldec.AttrSpec{Name: "library", Type: cty.String, Required: false},
+		"ephemeral_library":            &hcldec.AttrSpec{Name: "ephemeral_library", Type: cty.Bool, Required: false},
+		"ephemeral_library_datastore":  &hcldec.AttrSpec{Name: "ephemeral_library_datastore", Type: cty.String, Required: false},
+		"ephemeral_library_publish":    &hcldec.AttrSpec{Name: "ephemeral_library_publish", Type: cty.Bool, Required: false},
+		"ephemeral_library_retention":  &hcldec.AttrSpec{Name: "ephemeral_library_retention", Type: cty.String, Required: false},
+		"name":                         &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"description":                  &hcldec.AttrSpec{Name: "description", Type: cty.String, Required: false},
+		"cluster":                      &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
+		"folder":                       &hcldec.AttrSpec{Name: "folder", Type: cty.String, Required: false},
+		"host":                         &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
+		"resource_pool":                &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
+		"datastore":                    &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
+		"destroy":                      &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
+		"ovf":                          &hcldec.AttrSpec{Name: "ovf", Type: cty.Bool, Required: false},
+		"skip_import":                  &hcldec.AttrSpec{Name: "skip_import", Type: cty.Bool, Required: false},
+		"ovf_flags":                    &hcldec.AttrSpec{Name: "ovf_flags", Type: cty.List(cty.String), Required: false},
+		"stream_export":                &hcldec.AttrSpec{Name: "stream_export", Type: cty.Bool, Required: false},
+		"keep_versions":                &hcldec.AttrSpec{Name: "keep_versions", Type: cty.Number, Required: false},
+		"sync_subscribed_libraries":    &hcldec.AttrSpec{Name: "sync_subscribed_libraries", Type: cty.Bool, Required: false},
+		"subscribed_libraries":         &hcldec.AttrSpec{Name: "subscribed_libraries", Type: cty.List(cty.String), Required: false},
+		"subscribed_libraries_timeout": &hcldec.AttrSpec{Name: "subscribed_libraries_timeout", Type: cty.String, Required: false},
 	}
 	return s
 }
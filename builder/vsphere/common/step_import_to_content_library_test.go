@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContentLibraryDestinationConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name           string
+		config         ContentLibraryDestinationConfig
+		fail           bool
+		expectedErrMsg string
+	}{
+		{
+			name:   "OVF template",
+			config: ContentLibraryDestinationConfig{Library: "library", Ovf: true},
+		},
+		{
+			name: "Stream export",
+			config: ContentLibraryDestinationConfig{
+				Library:      "library",
+				Ovf:          true,
+				StreamExport: true,
+				OvfFlags:     []string{"EXTRA_CONFIG", "PRESERVE_MAC"},
+			},
+		},
+		{
+			name:           "Stream export without OVF",
+			config:         ContentLibraryDestinationConfig{Library: "library", Name: "template", StreamExport: true},
+			fail:           true,
+			expectedErrMsg: "'stream_export' requires 'ovf'",
+		},
+		{
+			name: "Stream export with unsupported flag",
+			config: ContentLibraryDestinationConfig{
+				Library:      "library",
+				Ovf:          true,
+				StreamExport: true,
+				OvfFlags:     []string{"UNKNOWN"},
+			},
+			fail:           true,
+			expectedErrMsg: "the OVF flag UNKNOWN is not supported with 'stream_export'",
+		},
+		{
+			name:   "Keep versions",
+			config: ContentLibraryDestinationConfig{Library: "library", Ovf: true, KeepVersions: 3},
+		},
+		{
+			name:           "Keep versions without OVF",
+			config:         ContentLibraryDestinationConfig{Library: "library", Name: "template", KeepVersions: 3},
+			fail:           true,
+			expectedErrMsg: "'keep_versions' requires 'ovf'",
+		},
+		{
+			name:           "Negative keep versions",
+			config:         ContentLibraryDestinationConfig{Library: "library", Ovf: true, KeepVersions: -1},
+			fail:           true,
+			expectedErrMsg: "'keep_versions' must be greater than or equal to 0",
+		},
+		{
+			name: "Ephemeral library",
+			config: ContentLibraryDestinationConfig{
+				EphemeralLibrary:          true,
+				EphemeralLibraryDatastore: "datastore",
+				EphemeralLibraryPublish:   true,
+				EphemeralLibraryRetention: time.Hour,
+				Ovf:                       true,
+			},
+		},
+		{
+			name:           "Ephemeral library with library",
+			config:         ContentLibraryDestinationConfig{Library: "library", EphemeralLibrary: true, EphemeralLibraryDatastore: "datastore", Ovf: true},
+			fail:           true,
+			expectedErrMsg: "'library' and 'ephemeral_library' cannot be used together",
+		},
+		{
+			name:           "Ephemeral library without datastore",
+			config:         ContentLibraryDestinationConfig{EphemeralLibrary: true, Ovf: true},
+			fail:           true,
+			expectedErrMsg: "'ephemeral_library_datastore' is required if 'datastore' is not set",
+		},
+		{
+			name: "Negative ephemeral library retention",
+			config: ContentLibraryDestinationConfig{
+				EphemeralLibrary:          true,
+				EphemeralLibraryDatastore: "datastore",
+				EphemeralLibraryRetention: -time.Hour,
+				Ovf:                       true,
+			},
+			fail:           true,
+			expectedErrMsg: "'ephemeral_library_retention' must be greater than or equal to 0",
+		},
+		{
+			name:           "Ephemeral library options without ephemeral library",
+			config:         ContentLibraryDestinationConfig{Library: "library", EphemeralLibraryPublish: true, Ovf: true},
+			fail:           true,
+			expectedErrMsg: "the 'ephemeral_library_*' options require 'ephemeral_library'",
+		},
+		{
+			name: "Sync subscribed libraries",
+			config: ContentLibraryDestinationConfig{
+				Library:                 "library",
+				Ovf:                     true,
+				SyncSubscribedLibraries: true,
+				SubscribedLibraries:     []string{"site-a", "site-b"},
+			},
+		},
+		{
+			name:           "Sync subscribed libraries with skip import",
+			config:         ContentLibraryDestinationConfig{Library: "library", Ovf: true, SyncSubscribedLibraries: true, SkipImport: true},
+			fail:           true,
+			expectedErrMsg: "'sync_subscribed_libraries' cannot be used with 'skip_import'",
+		},
+		{
+			name:           "Subscribed libraries without sync",
+			config:         ContentLibraryDestinationConfig{Library: "library", Ovf: true, SubscribedLibraries: []string{"site-a"}},
+			fail:           true,
+			expectedErrMsg: "'subscribed_libraries' and 'subscribed_libraries_timeout' require 'sync_subscribed_libraries'",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(&LocationConfig{VMName: "vm"})
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatalf("unexpected success: expected failure")
+				}
+				if !strings.Contains(errs[0].Error(), c.expectedErrMsg) {
+					t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErrMsg, errs[0])
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+			if c.config.Name != "vm" {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", "vm", c.config.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type ManagedByConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The default type of the virtual machines managed by the extension.
+const defaultManagedByType = "build"
+
+type ManagedByConfig struct {
+	// The key of the vCenter Server extension to mark the virtual machine as
+	// managed by while it is being built, for example `com.example.packer`.
+	// The vSphere Client shows that the virtual machine is managed by the
+	// extension, and other tools can use the mark to skip or clean up the
+	// virtual machines of builds in progress or of builds that were
+	// interrupted. The mark is removed after the build completes, before the
+	// virtual machine is converted to a template, imported to a content
+	// library, or exported. Defaults to not marking the virtual machine.
+	//
+	// -> **Note:** The vSphere Client only shows the description and icon
+	// of an extension that is registered with vCenter Server.
+	ManagedByExtensionKey string `mapstructure:"managed_by_extension_key"`
+	// The type of the managed entity, as defined by the extension. Applies
+	// only if `managed_by_extension_key` is set. Defaults to `build`.
+	ManagedByType string `mapstructure:"managed_by_type"`
+}
+
+func (c *ManagedByConfig) Prepare() []error {
+	if c.ManagedByExtensionKey == "" {
+		if c.ManagedByType != "" {
+			return []error{fmt.Errorf("'managed_by_extension_key' is required when 'managed_by_type' is set")}
+		}
+		return nil
+	}
+
+	if c.ManagedByType == "" {
+		c.ManagedByType = defaultManagedByType
+	}
+	return nil
+}
+
+type StepSetManagedBy struct {
+	Config *ManagedByConfig
+}
+
+// Run marks the virtual machine as managed by the extension for the duration
+// of the build.
+func (s *StepSetManagedBy) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config.ManagedByExtensionKey == "" {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Sayf("Marking the virtual machine as managed by %s...", s.Config.ManagedByExtensionKey)
+	if err := vm.SetManagedBy(s.Config.ManagedByExtensionKey, s.Config.ManagedByType); err != nil {
+		state.Put("error", fmt.Errorf("error marking the virtual machine as managed by %s: %s", s.Config.ManagedByExtensionKey, err))
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepSetManagedBy) Cleanup(multistep.StateBag) {}
+
+type StepClearManagedBy struct {
+	Config *ManagedByConfig
+}
+
+// Run removes the mark from the virtual machine once the build is complete.
+// The mark is kept on the virtual machine of a build that fails before this
+// step and is not cleaned up, so that it can be found by other tools.
+func (s *StepClearManagedBy) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config.ManagedByExtensionKey == "" {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Removing the managed by mark from the virtual machine...")
+	if err := vm.SetManagedBy("", ""); err != nil {
+		state.Put("error", fmt.Errorf("error removing the managed by mark from the virtual machine: %s", err))
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepClearManagedBy) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestManagedByConfig_Prepare(t *testing.T) {
+	config := &ManagedByConfig{ManagedByExtensionKey: "com.example.packer"}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.ManagedByType != "build" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "build", config.ManagedByType)
+	}
+
+	config = &ManagedByConfig{ManagedByType: "build"}
+	if errs := config.Prepare(); len(errs) == 0 {
+		t.Fatalf("unexpected success: expected failure")
+	}
+}
+
+func TestStepSetManagedBy_Run(t *testing.T) {
+	tc := []struct {
+		name           string
+		config         *ManagedByConfig
+		vmMock         *driver.VirtualMachineMock
+		expectedAction multistep.StepAction
+		expectedCalls  int
+	}{
+		{
+			name:           "Not configured",
+			config:         &ManagedByConfig{},
+			vmMock:         new(driver.VirtualMachineMock),
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "Set managed by",
+			config:         &ManagedByConfig{ManagedByExtensionKey: "com.example.packer", ManagedByType: "build"},
+			vmMock:         new(driver.VirtualMachineMock),
+			expectedAction: multistep.ActionContinue,
+			expectedCalls:  1,
+		},
+		{
+			name:           "Error setting managed by",
+			config:         &ManagedByConfig{ManagedByExtensionKey: "com.example.packer", ManagedByType: "build"},
+			vmMock:         &driver.VirtualMachineMock{SetManagedByErr: fmt.Errorf("reconfigure failed")},
+			expectedAction: multistep.ActionHalt,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := basicStateBag(nil)
+			state.Put("vm", c.vmMock)
+
+			step := &StepSetManagedBy{Config: c.config}
+			if action := step.Run(context.TODO(), state); action != c.expectedAction {
+				t.Fatalf("unexpected result: expected '%#v', but returned '%#v'", c.expectedAction, action)
+			}
+			if c.vmMock.SetManagedByCalledTimes != c.expectedCalls {
+				t.Fatalf("unexpected result: expected '%d' calls, but returned '%d'", c.expectedCalls, c.vmMock.SetManagedByCalledTimes)
+			}
+			if c.expectedCalls > 0 && c.vmMock.SetManagedByExtensionKey != c.config.ManagedByExtensionKey {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.config.ManagedByExtensionKey, c.vmMock.SetManagedByExtensionKey)
+			}
+		})
+	}
+}
+
+func TestStepClearManagedBy_Run(t *testing.T) {
+	vmMock := &driver.VirtualMachineMock{SetManagedByExtensionKey: "com.example.packer"}
+	state := basicStateBag(nil)
+	state.Put("vm", vmMock)
+
+	step := &StepClearManagedBy{Config: &ManagedByConfig{ManagedByExtensionKey: "com.example.packer"}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected result: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if vmMock.SetManagedByCalledTimes != 1 || vmMock.SetManagedByExtensionKey != "" {
+		t.Fatalf("unexpected result: expected the managed by mark to be removed")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type PreflightConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The preflight checks run before the virtual machine is created and report
+// all failures together, rather than failing the build at the first step
+// that uses a missing inventory object or privilege.
+//
+// The checks verify that the cluster, host, resource pool, datastore,
+// networks, source virtual machine or template, and ISO files on datastores
+// exist, and that the user has the privileges to create the virtual machine
+// in the folder and resource pool, to allocate space on the datastore, to
+// assign the networks, and to clone the source. The folder is not required to
+// exist if the user has the privilege to create it.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	preflight_checks = true
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"preflight_checks": true
+//
+// ```
+type PreflightConfig struct {
+	// Verify the inventory objects and privileges of the build before the
+	// virtual machine is created. Privileges are not checked when connected
+	// directly to an ESXi host. Defaults to `false`.
+	PreflightChecks bool `mapstructure:"preflight_checks"`
+	// Print the privileges that the build requires and that the user does not
+	// have on the folder, resource pool, datastore, networks, and source of
+	// the virtual machine before it is created, as a warning, rather than
+	// failing the build with a permission fault from vSphere when the
+	// privilege is first used. Ignored with `preflight_checks`, which fails
+	// the build if privileges are missing, and when connected directly to an
+	// ESXi host. Defaults to `false`.
+	CheckPrivileges bool `mapstructure:"check_privileges"`
+}
+
+type StepPreflightChecks struct {
+	Config    *PreflightConfig
+	Location  *LocationConfig
+	Networks  []string
+	Template  string
+	ImportOVF bool
+	ISOPaths  []string
+}
+
+func (s *StepPreflightChecks) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.PreflightChecks {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Say("Running preflight checks...")
+	errs := d.Preflight(&driver.PreflightSpec{
+		Folder:       s.Location.Folder,
+		Cluster:      s.Location.Cluster,
+		Host:         s.Location.Host,
+		ResourcePool: s.Location.ResourcePool,
+		Datastore:    s.Location.Datastore,
+		Networks:     s.Networks,
+		Template:     s.Template,
+		ImportOVF:    s.ImportOVF,
+		ISOPaths:     s.ISOPaths,
+	})
+	if len(errs) > 0 {
+		state.Put("error", fmt.Errorf("preflight checks failed: %s", &packersdk.MultiError{Errors: errs}))
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Preflight checks passed.")
+	return multistep.ActionContinue
+}
+
+func (s *StepPreflightChecks) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepPreflightChecks_Run(t *testing.T) {
+	d := driver.NewDriverMock()
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+
+	step := &StepPreflightChecks{
+		Config:   &PreflightConfig{PreflightChecks: true},
+		Location: &LocationConfig{Folder: "templates", Cluster: "cluster", Datastore: "datastore"},
+		Networks: []string{"VM Network"},
+		ISOPaths: []string{"[datastore] ubuntu.iso"},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	spec := d.PreflightSpec
+	if spec == nil || spec.Folder != "templates" || spec.Cluster != "cluster" || spec.Datastore != "datastore" ||
+		len(spec.Networks) != 1 || len(spec.ISOPaths) != 1 {
+		t.Fatalf("unexpected preflight specification: '%#v'", spec)
+	}
+
+	// All the failures are reported together.
+	d.PreflightResult = []error{errors.New("network not found"), errors.New("missing privileges")}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	err := state.Get("error").(error)
+	if !strings.Contains(err.Error(), "network not found") || !strings.Contains(err.Error(), "missing privileges") {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestStepPreflightChecks_RunDisabled(t *testing.T) {
+	d := driver.NewDriverMock()
+	state := basicStateBag(nil)
+	state.Put("driver", d)
+
+	step := &StepPreflightChecks{
+		Config:   &PreflightConfig{},
+		Location: &LocationConfig{},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if d.PreflightCalled {
+		t.Fatal("unexpected preflight checks")
+	}
+}
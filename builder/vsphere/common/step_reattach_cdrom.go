@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 type ReattachCDRomConfig struct {
@@ -50,6 +51,17 @@ func (s *StepReattachCDRom) Run(_ context.Context, state multistep.StateBag) mul
 
 	ui.Say("Reattaching CD-ROM devices...")
 
+	// Keep the CD-ROM devices at the controller and unit numbers of the
+	// configured CD-ROM devices.
+	if len(s.CDRomConfig.CDRoms) > 0 {
+		nAttachableCdroms, err := s.reattachPlacedCdroms(ui, vm)
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		return s.addCdroms(ui, state, vm, nAttachableCdroms)
+	}
+
 	// Add the CD-ROM devices to the image based on the value of `reattach_cdroms`.
 	// A valid ISO path is required for this step. The media will subsequently be ejected.
 	cdroms, err := vm.CdromDevices()
@@ -80,6 +92,11 @@ func (s *StepReattachCDRom) Run(_ context.Context, state multistep.StateBag) mul
 		}
 	}
 
+	return s.addCdroms(ui, state, vm, nAttachableCdroms)
+}
+
+// addCdroms adds the number of CD-ROM devices without any attached media.
+func (s *StepReattachCDRom) addCdroms(ui packersdk.Ui, state multistep.StateBag, vm driver.VirtualMachine, nAttachableCdroms int) multistep.StepAction {
 	// Add CD-ROMs, if required.
 	if nAttachableCdroms > 0 {
 		// If the CD-ROM device type is SATA, make sure SATA controller is present.
@@ -105,6 +122,59 @@ func (s *StepReattachCDRom) Run(_ context.Context, state multistep.StateBag) mul
 	return multistep.ActionContinue
 }
 
+// reattachPlacedCdroms keeps the CD-ROM devices at the controller and unit
+// numbers of the first `reattach_cdroms` configured CD-ROM devices, adding
+// them if missing, and removes the other CD-ROM devices. Returns the number
+// of CD-ROM devices that remain to be added.
+func (s *StepReattachCDRom) reattachPlacedCdroms(ui packersdk.Ui, vm driver.VirtualMachine) (int, error) {
+	configured := s.CDRomConfig.CDRoms
+	if len(configured) > s.Config.ReattachCDRom {
+		configured = configured[:s.Config.ReattachCDRom]
+	}
+	wanted := make(map[driver.CdromPlacement]bool, len(configured))
+	for _, cdrom := range configured {
+		wanted[*cdrom.placement()] = true
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		return 0, fmt.Errorf("error listing devices: %v", err)
+	}
+	found := make(map[driver.CdromPlacement]bool)
+	var remove []types.BaseVirtualDevice
+	for _, cdrom := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		p := driver.CdromPlacementOf(devices, cdrom)
+		if p != nil && wanted[*p] && !found[*p] {
+			found[*p] = true
+			continue
+		}
+		remove = append(remove, cdrom)
+	}
+	if len(remove) > 0 {
+		if err := vm.RemoveDevice(true, remove...); err != nil {
+			return 0, fmt.Errorf("error removing cdrom prior to reattaching: %v", err)
+		}
+	}
+
+	ui.Say("Ejecting CD-ROM media...")
+	if err := vm.EjectCdroms(); err != nil {
+		return 0, fmt.Errorf("error ejecting cdrom media: %v", err)
+	}
+
+	for _, cdrom := range configured {
+		p := cdrom.placement()
+		if found[*p] {
+			continue
+		}
+		ui.Sayf("Adding CD-ROM device on %s controller %d unit %d...", p.ControllerType, p.ControllerIndex, p.UnitNumber)
+		if err := vm.AddCdromAt(p, ""); err != nil {
+			return 0, err
+		}
+	}
+
+	return s.Config.ReattachCDRom - len(configured), nil
+}
+
 func (s *StepReattachCDRom) Cleanup(state multistep.StateBag) {
 	// no cleanup
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func TestStepReattachCDRom_Run(t *testing.T) {
@@ -197,3 +198,64 @@ func TestStepReattachCDRom_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestStepReattachCDRom_RunPlaced(t *testing.T) {
+	unit := func(n int32) *int32 { return &n }
+	ide := &types.VirtualIDEController{}
+	ide.Key = 200
+	ide.BusNumber = 0
+	keep := &types.VirtualCdrom{}
+	keep.Key = 3000
+	keep.ControllerKey = 200
+	keep.UnitNumber = unit(0)
+	other := &types.VirtualCdrom{}
+	other.Key = 3001
+	other.ControllerKey = 200
+	other.UnitNumber = unit(1)
+
+	step := &StepReattachCDRom{
+		Config: &ReattachCDRomConfig{
+			ReattachCDRom: 3,
+		},
+		CDRomConfig: &CDRomConfig{
+			CDRoms: []CDRomDeviceConfig{
+				{Path: "[datastore] /iso/windows.iso", ControllerType: "ide"},
+				{Path: "[datastore] /iso/drivers.iso", ControllerType: "sata", ControllerIndex: 1},
+			},
+		},
+	}
+	vm := &driver.VirtualMachineMock{
+		DevicesReturn: object.VirtualDeviceList{ide, keep, other},
+	}
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packer.BasicUi{
+		Reader: os.Stdin,
+		Writer: os.Stdout,
+	})
+	state.Put("vm", vm)
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if err, ok := state.GetOk("error"); ok {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	expected := &driver.VirtualMachineMock{
+		DevicesReturn:         object.VirtualDeviceList{ide, keep, other},
+		RemoveDeviceCalled:    true,
+		RemoveDeviceKeepFiles: true,
+		RemoveDeviceDevices:   []types.BaseVirtualDevice{other},
+		EjectCdromsCalled:     true,
+		AddCdromAtPlacements: []*driver.CdromPlacement{
+			{ControllerType: "sata", ControllerIndex: 1},
+		},
+		AddCdromAtPaths:     []string{""},
+		AddCdromCalledTimes: 1,
+		AddCdromTypes:       []string{""},
+		CdromDevicesList:    object.VirtualDeviceList{nil, nil},
+	}
+	if diff := cmp.Diff(vm, expected, cmpopts.IgnoreInterfaces(struct{ error }{})); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type CapacityConfig
+
+package common
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+type CapacityConfig struct {
+	// Record the CPU, memory, and datastore capacity and usage of the cluster
+	// or host and the datastore where the virtual machine is placed, before
+	// and after the build, in the artifact metadata. The metadata includes the
+	// change in usage during the build, such as the datastore space used by
+	// the published template. If the capacity cannot be retrieved, the build
+	// continues without the metadata. Defaults to `false`.
+	RecordCapacity bool `mapstructure:"record_capacity"`
+}
+
+type StepRecordCapacity struct {
+	Config   *CapacityConfig
+	Location *LocationConfig
+	// Record the usage after the build and the change in usage since the
+	// usage was recorded before the build.
+	After bool
+}
+
+func (s *StepRecordCapacity) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.RecordCapacity {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+
+	capacity, err := d.PlacementCapacity(s.Location.Cluster, s.Location.Host, s.Location.Datastore)
+	if err != nil {
+		ui.Errorf("Unable to record the placement capacity: %s", err)
+		return multistep.ActionContinue
+	}
+
+	if !s.After {
+		state.Put("capacity_before", capacity)
+		return multistep.ActionContinue
+	}
+
+	before, ok := state.Get("capacity_before").(*driver.PlacementCapacity)
+	if !ok {
+		return multistep.ActionContinue
+	}
+	ui.Say("Recording placement capacity...")
+	state.Put("capacity", capacityMetadata(before, capacity))
+
+	return multistep.ActionContinue
+}
+
+func (s *StepRecordCapacity) Cleanup(multistep.StateBag) {}
+
+// capacityMetadata returns the artifact metadata for the placement capacity
+// before and after the build.
+func capacityMetadata(before, after *driver.PlacementCapacity) map[string]string {
+	metadata := map[string]string{}
+	usage := func(name string, b, a int64) {
+		metadata[name+"_before"] = strconv.FormatInt(b, 10)
+		metadata[name+"_after"] = strconv.FormatInt(a, 10)
+		metadata[name+"_delta"] = strconv.FormatInt(a-b, 10)
+	}
+
+	if after.Compute != "" {
+		metadata["capacity_compute"] = after.Compute
+		metadata["capacity_cpu_capacity_mhz"] = strconv.FormatInt(after.CPUCapacityMHz, 10)
+		metadata["capacity_memory_capacity_mb"] = strconv.FormatInt(after.MemoryCapacityMB, 10)
+		usage("capacity_cpu_usage_mhz", before.CPUUsageMHz, after.CPUUsageMHz)
+		usage("capacity_memory_usage_mb", before.MemoryUsageMB, after.MemoryUsageMB)
+	}
+	if after.Datastore != "" {
+		metadata["capacity_datastore"] = after.Datastore
+		metadata["capacity_datastore_capacity_bytes"] = strconv.FormatInt(after.DatastoreCapacity, 10)
+		usage("capacity_datastore_usage_bytes", before.DatastoreUsage, after.DatastoreUsage)
+	}
+	return metadata
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepRecordCapacity_Run(t *testing.T) {
+	before := &driver.PlacementCapacity{
+		Compute:           "cluster",
+		CPUCapacityMHz:    10000,
+		CPUUsageMHz:       2000,
+		MemoryCapacityMB:  65536,
+		MemoryUsageMB:     16384,
+		Datastore:         "datastore",
+		DatastoreCapacity: 1000 * gibibyte,
+		DatastoreUsage:    400 * gibibyte,
+	}
+	after := *before
+	after.CPUUsageMHz = 2500
+	after.DatastoreUsage = 440 * gibibyte
+
+	config := &CapacityConfig{RecordCapacity: true}
+	location := &LocationConfig{Cluster: "cluster", Datastore: "datastore"}
+
+	state := basicStateBag(nil)
+	d := driver.NewDriverMock()
+	d.PlacementCapacityResult = before
+	state.Put("driver", d)
+
+	step := &StepRecordCapacity{Config: config, Location: location}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+
+	d.PlacementCapacityResult = &after
+	step = &StepRecordCapacity{Config: config, Location: location, After: true}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+
+	expected := map[string]string{
+		"capacity_compute":                      "cluster",
+		"capacity_cpu_capacity_mhz":             "10000",
+		"capacity_memory_capacity_mb":           "65536",
+		"capacity_cpu_usage_mhz_before":         "2000",
+		"capacity_cpu_usage_mhz_after":          "2500",
+		"capacity_cpu_usage_mhz_delta":          "500",
+		"capacity_memory_usage_mb_before":       "16384",
+		"capacity_memory_usage_mb_after":        "16384",
+		"capacity_memory_usage_mb_delta":        "0",
+		"capacity_datastore":                    "datastore",
+		"capacity_datastore_capacity_bytes":     fmt.Sprint(1000 * gibibyte),
+		"capacity_datastore_usage_bytes_before": fmt.Sprint(400 * gibibyte),
+		"capacity_datastore_usage_bytes_after":  fmt.Sprint(440 * gibibyte),
+		"capacity_datastore_usage_bytes_delta":  fmt.Sprint(40 * gibibyte),
+	}
+	if diff := cmp.Diff(expected, state.Get("capacity")); diff != "" {
+		t.Fatalf("unexpected capacity metadata: %s", diff)
+	}
+}
+
+func TestStepRecordCapacity_RunError(t *testing.T) {
+	errorBuffer := &strings.Builder{}
+	state := basicStateBag(errorBuffer)
+	d := driver.NewDriverMock()
+	d.PlacementCapacityErr = fmt.Errorf("cluster not found")
+	state.Put("driver", d)
+
+	step := &StepRecordCapacity{
+		Config:   &CapacityConfig{RecordCapacity: true},
+		Location: &LocationConfig{Cluster: "cluster"},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatalf("unexpected error: expected the build to continue")
+	}
+	if !strings.Contains(errorBuffer.String(), "cluster not found") {
+		t.Fatalf("unexpected result: expected a warning, but returned '%s'", errorBuffer.String())
+	}
+}
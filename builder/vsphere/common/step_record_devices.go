@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type DeviceLabelsConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The network adapters and disks of the virtual machine are recorded in the
+// artifact metadata after the build completes, so that downstream automation
+// can find a device of the template by its label. For each device, the
+// following metadata is recorded, where `<name>` is the custom label of the
+// device or the label assigned by vSphere in lowercase with underscores, such
+// as `network_adapter_1` or `hard_disk_1`:
+//
+//   - `device_<name>_label` - The label assigned by vSphere.
+//   - `device_<name>_controller` - The label of the controller of the device.
+//   - `device_<name>_unit` - The unit number of the device on the controller.
+//   - `device_<name>_mac` - The MAC address of a network adapter.
+//   - `device_<name>_backing` - The network of a network adapter or the file of
+//     a disk.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	device_labels = {
+//	  "Network adapter 1" = "eth-mgmt"
+//	  "Hard disk 2"       = "data"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"device_labels": {
+//	  "Network adapter 1": "eth-mgmt",
+//	  "Hard disk 2": "data"
+//	}
+//
+// ```
+type DeviceLabelsConfig struct {
+	// Custom labels for the network adapters and disks of the virtual machine,
+	// by the label assigned by vSphere, such as `Network adapter 1`. The custom
+	// labels are used in the artifact metadata and are stored in the
+	// configuration parameters `packer.device_label.<key>` of the virtual
+	// machine or template, where `<key>` is the key of the device, since
+	// vSphere assigns the labels of the devices.
+	DeviceLabels map[string]string `mapstructure:"device_labels"`
+}
+
+// Matches a valid custom device label.
+var deviceLabelRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func (c *DeviceLabelsConfig) Prepare() []error {
+	var errs []error
+
+	seen := make(map[string]bool)
+	labels := make([]string, 0, len(c.DeviceLabels))
+	for label := range c.DeviceLabels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		custom := c.DeviceLabels[label]
+		if !deviceLabelRe.MatchString(custom) {
+			errs = append(errs, fmt.Errorf("'device_labels' value for %q must contain only letters, digits, '_', '.', and '-'", label))
+			continue
+		}
+		if seen[custom] {
+			errs = append(errs, fmt.Errorf("'device_labels' value %q must be unique", custom))
+		}
+		seen[custom] = true
+	}
+	return errs
+}
+
+type StepRecordDevices struct {
+	Config *DeviceLabelsConfig
+}
+
+func (s *StepRecordDevices) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	devices, err := vm.Devices()
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving the devices of the virtual machine: %s", err))
+		return multistep.ActionHalt
+	}
+
+	metadata, params, err := deviceMap(devices, s.Config.DeviceLabels)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	if len(params) > 0 {
+		ui.Say("Setting custom device labels...")
+		if err := vm.AddConfigParams(params, nil); err != nil {
+			state.Put("error", fmt.Errorf("error setting custom device labels: %s", err))
+			return multistep.ActionHalt
+		}
+	}
+	state.Put("devices", metadata)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepRecordDevices) Cleanup(multistep.StateBag) {}
+
+// deviceMap returns the metadata of the network adapters and disks and the
+// configuration parameters that store the custom labels. An error is returned
+// if a custom label is specified for a device that does not exist.
+func deviceMap(devices object.VirtualDeviceList, labels map[string]string) (map[string]string, map[string]string, error) {
+	metadata := make(map[string]string)
+	params := make(map[string]string)
+	found := make(map[string]bool)
+
+	for _, device := range devices {
+		d := device.GetVirtualDevice()
+		var backing string
+		switch device.(type) {
+		case types.BaseVirtualEthernetCard:
+			backing = networkBacking(d.Backing)
+		case *types.VirtualDisk:
+			if b, ok := d.Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+				backing = b.GetVirtualDeviceFileBackingInfo().FileName
+			}
+		default:
+			continue
+		}
+
+		label := devices.Name(device)
+		if d.DeviceInfo != nil {
+			label = d.DeviceInfo.GetDescription().Label
+		}
+		name := strings.ReplaceAll(strings.ToLower(label), " ", "_")
+		if custom, ok := labels[label]; ok {
+			name = custom
+			found[label] = true
+			params[fmt.Sprintf("packer.device_label.%d", d.Key)] = custom
+		}
+
+		prefix := "device_" + name + "_"
+		metadata[prefix+"label"] = label
+		if c := devices.FindByKey(d.ControllerKey); c != nil && c.GetVirtualDevice().DeviceInfo != nil {
+			metadata[prefix+"controller"] = c.GetVirtualDevice().DeviceInfo.GetDescription().Label
+		}
+		if d.UnitNumber != nil {
+			metadata[prefix+"unit"] = strconv.Itoa(int(*d.UnitNumber))
+		}
+		if nic, ok := device.(types.BaseVirtualEthernetCard); ok {
+			metadata[prefix+"mac"] = nic.GetVirtualEthernetCard().MacAddress
+		}
+		if backing != "" {
+			metadata[prefix+"backing"] = backing
+		}
+	}
+
+	var missing []string
+	for label := range labels {
+		if !found[label] {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, nil, fmt.Errorf("'device_labels' devices not found: %s", strings.Join(missing, ", "))
+	}
+	return metadata, params, nil
+}
+
+// networkBacking returns the network of the backing of a network adapter.
+func networkBacking(backing types.BaseVirtualDeviceBackingInfo) string {
+	switch b := backing.(type) {
+	case *types.VirtualEthernetCardNetworkBackingInfo:
+		return b.DeviceName
+	case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+		return b.Port.PortgroupKey
+	case *types.VirtualEthernetCardOpaqueNetworkBackingInfo:
+		return b.OpaqueNetworkId
+	}
+	return ""
+}
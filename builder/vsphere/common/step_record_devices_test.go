@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func testDevices() object.VirtualDeviceList {
+	unit := func(n int32) *int32 { return &n }
+	return object.VirtualDeviceList{
+		&types.ParaVirtualSCSIController{VirtualSCSIController: types.VirtualSCSIController{
+			VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{
+				Key:        1000,
+				DeviceInfo: &types.Description{Label: "SCSI controller 0"},
+			}},
+		}},
+		&types.VirtualDisk{VirtualDevice: types.VirtualDevice{
+			Key:           2000,
+			DeviceInfo:    &types.Description{Label: "Hard disk 1"},
+			ControllerKey: 1000,
+			UnitNumber:    unit(0),
+			Backing: &types.VirtualDiskFlatVer2BackingInfo{VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+				FileName: "[datastore1] vm/vm.vmdk",
+			}},
+		}},
+		&types.VirtualPCIController{VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{
+			Key:        100,
+			DeviceInfo: &types.Description{Label: "PCI controller 0"},
+		}}},
+		&types.VirtualVmxnet3{VirtualVmxnet: types.VirtualVmxnet{VirtualEthernetCard: types.VirtualEthernetCard{
+			VirtualDevice: types.VirtualDevice{
+				Key:           4000,
+				DeviceInfo:    &types.Description{Label: "Network adapter 1"},
+				ControllerKey: 100,
+				UnitNumber:    unit(7),
+				Backing: &types.VirtualEthernetCardNetworkBackingInfo{VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+					DeviceName: "VM Network",
+				}},
+			},
+			MacAddress: "00:50:56:00:00:01",
+		}}},
+	}
+}
+
+func TestDeviceLabelsConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name   string
+		labels map[string]string
+		fail   bool
+	}{
+		{
+			name:   "Valid labels",
+			labels: map[string]string{"Network adapter 1": "eth-mgmt", "Hard disk 1": "os.disk_0"},
+		},
+		{
+			name:   "Empty label",
+			labels: map[string]string{"Network adapter 1": ""},
+			fail:   true,
+		},
+		{
+			name:   "Invalid characters",
+			labels: map[string]string{"Network adapter 1": "eth mgmt"},
+			fail:   true,
+		},
+		{
+			name:   "Duplicate labels",
+			labels: map[string]string{"Network adapter 1": "eth0", "Network adapter 2": "eth0"},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			config := &DeviceLabelsConfig{DeviceLabels: c.labels}
+			errs := config.Prepare()
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+		})
+	}
+}
+
+func TestDeviceMap(t *testing.T) {
+	metadata, params, err := deviceMap(testDevices(), map[string]string{"Network adapter 1": "eth-mgmt"})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	expected := map[string]string{
+		"device_hard_disk_1_label":      "Hard disk 1",
+		"device_hard_disk_1_controller": "SCSI controller 0",
+		"device_hard_disk_1_unit":       "0",
+		"device_hard_disk_1_backing":    "[datastore1] vm/vm.vmdk",
+		"device_eth-mgmt_label":         "Network adapter 1",
+		"device_eth-mgmt_controller":    "PCI controller 0",
+		"device_eth-mgmt_unit":          "7",
+		"device_eth-mgmt_mac":           "00:50:56:00:00:01",
+		"device_eth-mgmt_backing":       "VM Network",
+	}
+	if diff := cmp.Diff(expected, metadata); diff != "" {
+		t.Fatalf("unexpected metadata: '%s'", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"packer.device_label.4000": "eth-mgmt"}, params); diff != "" {
+		t.Fatalf("unexpected configuration parameters: '%s'", diff)
+	}
+
+	if _, _, err := deviceMap(testDevices(), map[string]string{"Network adapter 2": "eth-data"}); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestStepRecordDevices_Run(t *testing.T) {
+	vm := &driver.VirtualMachineMock{DevicesReturn: testDevices()}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepRecordDevices{Config: &DeviceLabelsConfig{DeviceLabels: map[string]string{"Hard disk 1": "os"}}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if diff := cmp.Diff(map[string]string{"packer.device_label.2000": "os"}, vm.AddConfigParamsParams); diff != "" {
+		t.Fatalf("unexpected configuration parameters: '%s'", diff)
+	}
+	devices, ok := state.Get("devices").(map[string]string)
+	if !ok || devices["device_os_label"] != "Hard disk 1" {
+		t.Fatalf("unexpected devices: '%v'", state.Get("devices"))
+	}
+}
+
+func TestStepRecordDevices_RunWithoutLabels(t *testing.T) {
+	vm := &driver.VirtualMachineMock{DevicesReturn: testDevices()}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepRecordDevices{Config: &DeviceLabelsConfig{}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.AddConfigParamsParams != nil {
+		t.Fatalf("unexpected configuration parameters: '%v'", vm.AddConfigParamsParams)
+	}
+	if _, ok := state.Get("devices").(map[string]string)["device_network_adapter_1_mac"]; !ok {
+		t.Fatalf("unexpected devices: '%v'", state.Get("devices"))
+	}
+}
@@ -1,12 +1,16 @@
 // Copyright (c) HashiCorp, Inc.
 // SPDX-License-Identifier: MPL-2.0
 
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type CDImageConfig
+
 package common
 
 import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -15,6 +19,41 @@ import (
 
 const DefaultRemoteCachePath = "packer_cache"
 
+type CDImageConfig struct {
+	// The datastore to upload the image of `cd_files` and `cd_content` to.
+	// Defaults to the remote cache datastore, if set, or the datastore of the
+	// virtual machine.
+	CDImageDatastore string `mapstructure:"cd_image_datastore"`
+	// The directory path on the datastore to upload the image to. Defaults
+	// to the remote cache path, if set, or `packer_cache`.
+	CDImagePath string `mapstructure:"cd_image_path"`
+	// The file name of the image on the datastore, such as `seed.iso`.
+	// Defaults to the name of the image generated on the Packer host. An
+	// existing file with the same name is overwritten.
+	CDImageName string `mapstructure:"cd_image_name"`
+	// The number of times to upload the image again if the upload fails.
+	// Defaults to `0`.
+	CDImageUploadRetries int `mapstructure:"cd_image_upload_retries"`
+	// Remove the image from the datastore after the build completes.
+	// Defaults to `false`.
+	//
+	// -> **Note:** The image is always removed if the build fails or is
+	// cancelled, including an image that was partially uploaded.
+	CDImageCleanup bool `mapstructure:"cd_image_cleanup"`
+}
+
+func (c *CDImageConfig) Prepare() []error {
+	var errs []error
+
+	if c.CDImageUploadRetries < 0 {
+		errs = append(errs, fmt.Errorf("'cd_image_upload_retries' must be greater than or equal to 0"))
+	}
+	if c.CDImageName != "" && (strings.ContainsAny(c.CDImageName, "/\\") || filepath.Ext(c.CDImageName) != ".iso") {
+		errs = append(errs, fmt.Errorf("'cd_image_name' must be a file name with the '.iso' extension"))
+	}
+	return errs
+}
+
 type StepRemoteUpload struct {
 	Datastore                  string
 	Host                       string
@@ -23,12 +62,19 @@ type StepRemoteUpload struct {
 	RemoteCacheOverwrite       bool
 	RemoteCacheDatastore       string
 	RemoteCachePath            string
+	CDImage                    *CDImageConfig
 	UploadedCustomCD           bool
+
+	// The datastore and the path of the image of `cd_files`, set before the
+	// upload starts so that a partially uploaded image is removed.
+	cdDatastore driver.Datastore
+	cdPath      string
 }
 
-func (s *StepRemoteUpload) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+func (s *StepRemoteUpload) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 	d := state.Get("driver").(driver.Driver)
+	s.Datastore = datastoreName(state, s.Datastore)
 
 	if path, ok := state.GetOk("iso_path"); ok {
 		// user-supplied boot iso
@@ -41,17 +87,16 @@ func (s *StepRemoteUpload) Run(_ context.Context, state multistep.StateBag) mult
 	}
 	if cdPath, ok := state.GetOk("cd_path"); ok {
 		// Packer-created cd_files disk
-		fullRemotePath, err := s.uploadFile(cdPath.(string), d, ui)
+		fullRemotePath, err := s.uploadCD(ctx, cdPath.(string), d, ui)
 		if err != nil {
 			state.Put("error", err)
 			return multistep.ActionHalt
 		}
-		s.UploadedCustomCD = true
 		state.Put("cd_path", fullRemotePath)
 	}
 
-	if s.RemoteCacheCleanup {
-		state.Put("remote_cache_cleanup", s.RemoteCacheCleanup)
+	if s.RemoteCacheCleanup || (s.CDImage != nil && s.CDImage.CDImageCleanup) {
+		state.Put("remote_cache_cleanup", true)
 	}
 
 	return multistep.ActionContinue
@@ -121,6 +166,73 @@ func (s *StepRemoteUpload) uploadFile(path string, d driver.Driver, ui packersdk
 	return fullRemotePath, nil
 }
 
+// uploadCD uploads the image of `cd_files` and `cd_content` to the datastore,
+// overwriting an existing file with the same name, and uploads it again if the
+// upload fails, up to the number of retries.
+func (s *StepRemoteUpload) uploadCD(ctx context.Context, path string, d driver.Driver, ui packersdk.Ui) (string, error) {
+	config := s.CDImage
+	if config == nil {
+		config = &CDImageConfig{}
+	}
+
+	datastore := s.Datastore
+	switch {
+	case config.CDImageDatastore != "":
+		datastore = config.CDImageDatastore
+	case s.RemoteCacheDatastore != "":
+		datastore = s.RemoteCacheDatastore
+	}
+	ds, err := d.FindDatastore(datastore, s.Host)
+	if err != nil {
+		return "", fmt.Errorf("error finding the datastore for the cd_files image: %v", err)
+	}
+
+	directory := config.CDImagePath
+	if directory == "" {
+		directory = s.RemoteCachePath
+	}
+	if directory == "" {
+		directory = DefaultRemoteCachePath
+	}
+	filename := config.CDImageName
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	remotePath := fmt.Sprintf("%s/%s", directory, filename)
+	remoteDirectory := fmt.Sprintf("[%s] %s", ds.Name(), directory)
+	fullRemotePath := fmt.Sprintf("%s/%s", remoteDirectory, filename)
+
+	if ds.FileExists(remotePath) {
+		ui.Sayf("Overwriting %s in %s...", filename, remoteDirectory)
+		if err := ds.Delete(remotePath); err != nil {
+			return "", fmt.Errorf("error overwriting the cd_files image: %w", err)
+		}
+	}
+	if !ds.DirExists(remotePath) {
+		ui.Sayf("Directory does not exist; creating %s...", remoteDirectory)
+		if err := ds.MakeDirectory(remoteDirectory); err != nil {
+			return "", err
+		}
+	}
+
+	s.cdDatastore = ds
+	s.cdPath = remotePath
+	s.UploadedCustomCD = true
+
+	for attempt := 0; ; attempt++ {
+		ui.Sayf("Uploading %s to %s...", filename, remoteDirectory)
+		err := ds.UploadFile(path, remotePath, s.Host, s.SetHostForDatastoreUploads)
+		if err == nil {
+			return fullRemotePath, nil
+		}
+		if attempt >= config.CDImageUploadRetries || ctx.Err() != nil {
+			return "", fmt.Errorf("error uploading the cd_files image: %w", err)
+		}
+		ui.Errorf("error uploading %s: %s", filename, err)
+		ui.Sayf("Uploading %s again (%d/%d)...", filename, attempt+1, config.CDImageUploadRetries)
+	}
+}
+
 func (s *StepRemoteUpload) Cleanup(state multistep.StateBag) {
 	_, cancelled := state.GetOk(multistep.StateCancelled)
 	_, halted := state.GetOk(multistep.StateHalted)
@@ -130,28 +242,19 @@ func (s *StepRemoteUpload) Cleanup(state multistep.StateBag) {
 		return
 	}
 
-	if !s.UploadedCustomCD {
+	if !s.UploadedCustomCD || s.cdDatastore == nil {
 		return
 	}
 
-	UploadedCDPath, ok := state.GetOk("cd_path")
-	if !ok {
+	// The image does not exist if the build was cancelled before the upload
+	// created the file.
+	if !s.cdDatastore.FileExists(s.cdPath) {
 		return
 	}
 
 	ui := state.Get("ui").(packersdk.Ui)
-	d := state.Get("driver").(*driver.VCenterDriver)
-	ui.Sayf("Removing %s...", UploadedCDPath)
-
-	ds, err := d.FindDatastore(s.Datastore, s.Host)
-	if err != nil {
-		ui.Sayf("Unable to find the remote cache datastore. Please remove the item manually: %s", err)
-		return
-	}
-
-	err = ds.Delete(UploadedCDPath.(string))
-	if err != nil {
+	ui.Sayf("Removing [%s] %s...", s.cdDatastore.Name(), s.cdPath)
+	if err := s.cdDatastore.Delete(s.cdPath); err != nil {
 		ui.Sayf("Unable to remove item from the remote cache. Please remove the item manually: %s", err)
-		return
 	}
 }
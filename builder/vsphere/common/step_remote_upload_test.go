@@ -6,6 +6,7 @@ package common
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
@@ -72,3 +73,105 @@ func TestStepRemoteUpload_SkipRun(t *testing.T) {
 		t.Fatalf("unexpected state: '%s' should not be found", "iso_remote_path")
 	}
 }
+
+func TestCDImageConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name   string
+		config CDImageConfig
+		fail   bool
+	}{
+		{name: "Default", config: CDImageConfig{}},
+		{name: "Name", config: CDImageConfig{CDImageName: "seed.iso", CDImageUploadRetries: 2}},
+		{name: "Name with directory", config: CDImageConfig{CDImageName: "seeds/seed.iso"}, fail: true},
+		{name: "Name without extension", config: CDImageConfig{CDImageName: "seed"}, fail: true},
+		{name: "Negative retries", config: CDImageConfig{CDImageUploadRetries: -1}, fail: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare()
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+		})
+	}
+}
+
+func TestStepRemoteUpload_RunCDImage(t *testing.T) {
+	state := basicStateBag(new(strings.Builder))
+	dsMock := &driver.DatastoreMock{
+		FileExistsReturn: true,
+		UploadFileErrs:   []error{fmt.Errorf("connection reset")},
+	}
+	driverMock := driver.NewDriverMock()
+	driverMock.DatastoreMock = dsMock
+	state.Put("driver", driverMock)
+	state.Put("cd_path", "/tmp/packer123.iso")
+
+	step := &StepRemoteUpload{
+		Datastore: "datastore",
+		CDImage: &CDImageConfig{
+			CDImagePath:          "seeds",
+			CDImageName:          "seed.iso",
+			CDImageUploadRetries: 1,
+		},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+
+	// The existing image is overwritten and the failed upload is retried.
+	if dsMock.DeletePath != "seeds/seed.iso" {
+		t.Fatalf("unexpected result: expected 'seeds/seed.iso', but returned '%s'", dsMock.DeletePath)
+	}
+	if len(dsMock.UploadFileErrs) != 0 {
+		t.Fatal("unexpected result: expected the failed upload to be retried")
+	}
+	if dsMock.UploadFileDst != "seeds/seed.iso" {
+		t.Fatalf("unexpected result: expected 'seeds/seed.iso', but returned '%s'", dsMock.UploadFileDst)
+	}
+	expected := fmt.Sprintf("[%s] seeds/seed.iso", dsMock.Name())
+	if cdPath := state.Get("cd_path"); cdPath != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, cdPath)
+	}
+
+	// The image is kept after a successful build.
+	dsMock.DeleteCalled = false
+	step.Cleanup(state)
+	if dsMock.DeleteCalled {
+		t.Fatal("unexpected result: expected the image to be kept")
+	}
+}
+
+func TestStepRemoteUpload_CleanupCancelledUpload(t *testing.T) {
+	state := basicStateBag(new(strings.Builder))
+	dsMock := &driver.DatastoreMock{
+		UploadFileErrs: []error{fmt.Errorf("context canceled")},
+	}
+	driverMock := driver.NewDriverMock()
+	driverMock.DatastoreMock = dsMock
+	state.Put("driver", driverMock)
+	state.Put("cd_path", "/tmp/packer123.iso")
+
+	step := &StepRemoteUpload{
+		Datastore: "datastore",
+		CDImage:   &CDImageConfig{CDImageUploadRetries: 2},
+	}
+	// The upload is not retried after the build is cancelled.
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+
+	// The partially uploaded image is removed.
+	dsMock.FileExistsReturn = true
+	state.Put(multistep.StateCancelled, true)
+	step.Cleanup(state)
+	if dsMock.DeletePath != "packer_cache/packer123.iso" {
+		t.Fatalf("unexpected result: expected 'packer_cache/packer123.iso', but returned '%s'", dsMock.DeletePath)
+	}
+}
@@ -34,7 +34,7 @@ func (s *StepRemoveFloppy) Run(_ context.Context, state multistep.StateBag) mult
 
 	if UploadedFloppyPath, ok := state.GetOk("uploaded_floppy_path"); ok {
 		ui.Say("Deleting floppy image...")
-		ds, err := d.FindDatastore(s.Datastore, s.Host)
+		ds, err := d.FindDatastore(datastoreName(state, s.Datastore), s.Host)
 		if err != nil {
 			state.Put("error", err)
 			return multistep.ActionHalt
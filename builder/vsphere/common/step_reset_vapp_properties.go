@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// StepResetVAppProperties resets the vApp properties with build credentials,
+// such as the temporary SSH public key, so that the template carries no
+// credentials of the build.
+type StepResetVAppProperties struct {
+	Properties []string
+}
+
+func (s *StepResetVAppProperties) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	ids, _ := state.Get("temporary_vapp_properties").([]string)
+	for _, id := range s.Properties {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Resetting vApp properties...")
+	if err := vm.ResetVAppProperties(ids); err != nil {
+		state.Put("error", fmt.Errorf("error resetting vApp properties: %s", err))
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepResetVAppProperties) Cleanup(multistep.StateBag) {}
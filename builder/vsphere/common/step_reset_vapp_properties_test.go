@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepResetVAppProperties_Run(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+	state.Put("temporary_vapp_properties", []string{"public-keys"})
+
+	step := &StepResetVAppProperties{Properties: []string{"password", "public-keys"}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if diff := cmp.Diff([]string{"public-keys", "password"}, vm.ResetVAppPropertiesIDs); diff != "" {
+		t.Fatalf("unexpected vApp properties: '%s'", diff)
+	}
+}
+
+func TestStepResetVAppProperties_RunNone(t *testing.T) {
+	vm := &driver.VirtualMachineMock{ResetVAppPropertiesErr: fmt.Errorf("no vApp configuration found")}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := new(StepResetVAppProperties)
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.ResetVAppPropertiesIDs != nil {
+		t.Fatalf("unexpected vApp properties: '%v'", vm.ResetVAppPropertiesIDs)
+	}
+}
+
+func TestStepResetVAppProperties_RunError(t *testing.T) {
+	vm := &driver.VirtualMachineMock{ResetVAppPropertiesErr: fmt.Errorf("vApp properties not found: password")}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepResetVAppProperties{Properties: []string{"password"}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("expected state to contain an error")
+	}
+}
@@ -8,6 +8,7 @@ package common
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
@@ -25,28 +26,76 @@ type RunConfig struct {
 	// `disk,cdrom` for the duration of the build and then cleared upon
 	// build completion.
 	BootOrder string `mapstructure:"boot_order"`
+	// Power on the virtual machine into the firmware setup and boot from a
+	// target device. Refer to the [firmware boot configuration](#firmware-boot-configuration)
+	// for more information.
+	FirmwareBoot *FirmwareBootConfig `mapstructure:"firmware_boot"`
+	// The number of times to migrate the virtual machine to another host of
+	// the cluster and power it on again if the power on fails because of
+	// insufficient resources, such as insufficient memory on the host or the
+	// admission control of vSphere HA. The host with the most free memory
+	// that has not been tried is selected. Defaults to `0`.
+	//
+	// -> **Note:** The host of the virtual machine must be in a cluster, and
+	// the datastores of the virtual machine must be shared by the hosts.
+	PowerOnRetries int `mapstructure:"power_on_retries"`
+}
+
+func (c *RunConfig) Prepare() []error {
+	var errs []error
+
+	if c.PowerOnRetries < 0 {
+		errs = append(errs, fmt.Errorf("'power_on_retries' must be greater than or equal to 0"))
+	}
+	if c.FirmwareBoot == nil {
+		return errs
+	}
+
+	errs = append(errs, c.FirmwareBoot.Prepare()...)
+	if c.BootOrder != "" {
+		errs = append(errs, fmt.Errorf("'boot_order' cannot be used with 'firmware_boot'"))
+	}
+	return errs
 }
 
 type StepRun struct {
 	Config   *RunConfig
 	SetOrder bool
+	// Boot from the network instead of a CD-ROM if the disk is not bootable
+	// when the boot order is temporarily set.
+	NetworkBoot bool
+	// The type of the controller of the boot disk, such as `nvme`, whose
+	// disks are booted from first when the boot order includes `disk`.
+	BootDiskControllerType string
 }
 
-func (s *StepRun) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+func (s *StepRun) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
 
+	if s.Config.FirmwareBoot != nil {
+		if err := firmwareBoot(ctx, ui, vm, s.Config.FirmwareBoot); err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		return multistep.ActionContinue
+	}
+
 	if s.Config.BootOrder != "" {
 		ui.Say("Setting boot order...")
 		order := strings.Split(s.Config.BootOrder, ",")
-		if err := vm.SetBootOrder(order); err != nil {
+		if err := s.setBootOrder(vm, order); err != nil {
 			state.Put("error", err)
 			return multistep.ActionHalt
 		}
 	} else {
 		if s.SetOrder {
 			ui.Say("Setting temporary boot order...")
-			if err := vm.SetBootOrder([]string{"disk", "cdrom"}); err != nil {
+			order := []string{"disk", "cdrom"}
+			if s.NetworkBoot {
+				order = []string{"disk", "ethernet"}
+			}
+			if err := s.setBootOrder(vm, order); err != nil {
 				state.Put("error", err)
 				return multistep.ActionHalt
 			}
@@ -54,8 +103,7 @@ func (s *StepRun) Run(_ context.Context, state multistep.StateBag) multistep.Ste
 	}
 
 	ui.Say("Powering on virtual machine...")
-	err := vm.PowerOn()
-	if err != nil {
+	if err := powerOn(ui, vm, s.Config.PowerOnRetries); err != nil {
 		state.Put("error", err)
 		return multistep.ActionHalt
 	}
@@ -63,11 +111,60 @@ func (s *StepRun) Run(_ context.Context, state multistep.StateBag) multistep.Ste
 	return multistep.ActionContinue
 }
 
+// powerOn powers on the virtual machine. If the power on fails because of
+// insufficient resources, the virtual machine is migrated to another host of
+// the cluster and powered on again, up to the number of retries.
+func powerOn(ui packersdk.Ui, vm driver.VirtualMachine, retries int) error {
+	var tried []string
+	for attempt := 0; ; attempt++ {
+		err := vm.PowerOn()
+		if err == nil || attempt >= retries || !driver.IsInsufficientResourcesError(err) {
+			return err
+		}
+
+		ui.Errorf("error powering on virtual machine: %s", err)
+		from, to, migrateErr := vm.MigrateToAnotherHost(tried)
+		if migrateErr != nil {
+			return fmt.Errorf("%s; %s", err, migrateErr)
+		}
+		tried = append(tried, from)
+		ui.Sayf("Migrated virtual machine from host %s to host %s; powering on virtual machine again (%d/%d)...", from, to, attempt+1, retries)
+	}
+}
+
+// setBootOrder sets the boot order of the virtual machine, with the `disk`
+// boot device replaced by the disks in the order of the boot disk.
+func (s *StepRun) setBootOrder(vm driver.VirtualMachine, order []string) error {
+	if s.BootDiskControllerType == "" {
+		return vm.SetBootOrder(order)
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		return err
+	}
+	var bootOrder []string
+	for _, device := range order {
+		if device == "disk" {
+			bootOrder = append(bootOrder, driver.BootDiskNames(devices, s.BootDiskControllerType)...)
+			continue
+		}
+		bootOrder = append(bootOrder, device)
+	}
+	return vm.SetBootOrder(bootOrder)
+}
+
 func (s *StepRun) Cleanup(state multistep.StateBag) {
 	ui := state.Get("ui").(packersdk.Ui)
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
 
-	if s.Config.BootOrder == "" && s.SetOrder {
+	if s.Config.FirmwareBoot != nil {
+		ui.Say("Clearing boot options...")
+		if err := vm.SetBootOptions(&driver.BootOptions{Order: []string{"-"}}); err != nil {
+			state.Put("error", err)
+			return
+		}
+	} else if s.Config.BootOrder == "" && s.SetOrder {
 		ui.Say("Clearing boot order...")
 		if err := vm.SetBootOrder([]string{"-"}); err != nil {
 			state.Put("error", err)
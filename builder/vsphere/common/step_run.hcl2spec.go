@@ -10,7 +10,9 @@ import (
 // FlatRunConfig is an auto-generated flat version of RunConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatRunConfig struct {
-	BootOrder *string `mapstructure:"boot_order" cty:"boot_order" hcl:"boot_order"`
+	BootOrder      *string                 `mapstructure:"boot_order" cty:"boot_order" hcl:"boot_order"`
+	FirmwareBoot   *FlatFirmwareBootConfig `mapstructure:"firmware_boot" cty:"firmware_boot" hcl:"firmware_boot"`
+	PowerOnRetries *int                    `mapstructure:"power_on_retries" cty:"power_on_retries" hcl:"power_on_retries"`
 }
 
 // FlatMapstructure returns a new FlatRunConfig.
@@ -25,7 +27,9 @@ func (*RunConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spe
 // The decoded values from this spec will then be applied to a FlatRunConfig.
 func (*FlatRunConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"boot_order": &hcldec.AttrSpec{Name: "boot_order", Type: cty.String, Required: false},
+		"boot_order":       &hcldec.AttrSpec{Name: "boot_order", Type: cty.String, Required: false},
+		"firmware_boot":    &hcldec.BlockSpec{TypeName: "firmware_boot", Nested: hcldec.ObjectSpec((*FlatFirmwareBootConfig)(nil).HCL2Spec())},
+		"power_on_retries": &hcldec.AttrSpec{Name: "power_on_retries", Type: cty.Number, Required: false},
 	}
 	return s
 }
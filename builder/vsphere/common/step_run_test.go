@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestStepRun_setBootOrder(t *testing.T) {
+	devices := object.VirtualDeviceList{
+		&types.ParaVirtualSCSIController{VirtualSCSIController: types.VirtualSCSIController{
+			VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 1000}},
+		}},
+		&types.VirtualNVMEController{VirtualController: types.VirtualController{VirtualDevice: types.VirtualDevice{Key: 31000}}},
+		&types.VirtualDisk{VirtualDevice: types.VirtualDevice{Key: 2000, ControllerKey: 1000, UnitNumber: types.NewInt32(0)}},
+		&types.VirtualDisk{VirtualDevice: types.VirtualDevice{Key: 16000, ControllerKey: 31000, UnitNumber: types.NewInt32(0)}},
+	}
+
+	tc := []struct {
+		name           string
+		controllerType string
+		expected       []string
+	}{
+		{
+			name:     "Default",
+			expected: []string{"disk", "cdrom"},
+		},
+		{
+			name:           "NVMe boot disk",
+			controllerType: "nvme",
+			expected:       []string{"disk-31000-0", "disk-1000-0", "cdrom"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			vm := &driver.VirtualMachineMock{DevicesReturn: devices}
+			step := &StepRun{BootDiskControllerType: c.controllerType}
+			if err := step.setBootOrder(vm, []string{"disk", "cdrom"}); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if diff := cmp.Diff(c.expected, vm.SetBootOrderOrder); diff != "" {
+				t.Fatalf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPowerOn(t *testing.T) {
+	insufficient := task.Error{LocalizedMethodFault: &types.LocalizedMethodFault{
+		Fault:            &types.InsufficientFailoverResourcesFault{},
+		LocalizedMessage: "Insufficient resources to satisfy configured failover level for vSphere HA.",
+	}}
+
+	tc := []struct {
+		name             string
+		errs             []error
+		retries          int
+		fail             bool
+		expectedPowerOns int
+		expectedExcluded []string
+	}{
+		{
+			name:             "Power on",
+			retries:          2,
+			expectedPowerOns: 1,
+		},
+		{
+			name:             "Power on after migrations",
+			errs:             []error{insufficient, insufficient},
+			retries:          2,
+			expectedPowerOns: 3,
+			expectedExcluded: []string{"host-0"},
+		},
+		{
+			name:             "Insufficient resources without retries",
+			errs:             []error{insufficient},
+			fail:             true,
+			expectedPowerOns: 1,
+		},
+		{
+			name:             "Insufficient resources after retries",
+			errs:             []error{insufficient, insufficient},
+			retries:          1,
+			fail:             true,
+			expectedPowerOns: 2,
+		},
+		{
+			name:             "Other error",
+			errs:             []error{fmt.Errorf("invalid power state")},
+			retries:          2,
+			fail:             true,
+			expectedPowerOns: 1,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			vm := &driver.VirtualMachineMock{PowerOnErrs: c.errs}
+			err := powerOn(basicStateBag(&strings.Builder{}).Get("ui").(packersdk.Ui), vm, c.retries)
+			if c.fail && err == nil {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if vm.PowerOnCalledTimes != c.expectedPowerOns {
+				t.Fatalf("unexpected result: expected '%d' power ons, but returned '%d'", c.expectedPowerOns, vm.PowerOnCalledTimes)
+			}
+			if diff := cmp.Diff(c.expectedExcluded, vm.MigrateToAnotherHostExcluded); diff != "" {
+				t.Fatalf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunConfig_PreparePowerOnRetries(t *testing.T) {
+	config := &RunConfig{PowerOnRetries: -1}
+	errs := config.Prepare()
+	if len(errs) != 1 {
+		t.Fatalf("unexpected result: expected '1' error, but returned '%d'", len(errs))
+	}
+	expected := "'power_on_retries' must be greater than or equal to 0"
+	if errs[0].Error() != expected {
+		t.Fatalf("unexpected error: expected '%s', but returned '%s'", expected, errs[0])
+	}
+}
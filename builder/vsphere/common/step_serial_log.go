@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type SerialLogConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The name of the serial console log, both in the directory of the virtual
+// machine and in the output directory.
+const serialLogName = "serial.log"
+
+type SerialLogConfig struct {
+	// Capture the serial console output of the guest operating system for the
+	// duration of the build. Defaults to `false`.
+	//
+	// A serial port that writes to a file in the directory of the virtual
+	// machine is added before the virtual machine is powered on. The file is
+	// downloaded to `serial.log` in the output directory after the virtual
+	// machine is shut down, or if the build fails or is cancelled, and the
+	// serial port is removed before the virtual machine is converted to a
+	// template. The output directory is the `output_directory` of the
+	// [export configuration](#export-configuration), or `output-<buildName>`
+	// if the virtual machine is not exported.
+	//
+	// -> **Note:** The guest operating system must write its console output to
+	// the first serial port, for example by adding `console=ttyS0` to the
+	// kernel parameters in the `boot_command`.
+	SerialLog bool `mapstructure:"serial_log"`
+
+	outputDir string
+}
+
+func (c *SerialLogConfig) Prepare(export *ExportConfig, pc *common.PackerConfig) []error {
+	if !c.SerialLog {
+		return nil
+	}
+
+	if export != nil {
+		c.outputDir = export.OutputDir.OutputDir
+	} else {
+		c.outputDir = fmt.Sprintf("output-%s", pc.PackerBuildName)
+	}
+
+	return nil
+}
+
+// OutputDir returns the local directory of the serial console log.
+func (c *SerialLogConfig) OutputDir() string {
+	return c.outputDir
+}
+
+// Path returns the local path of the serial console log.
+func (c *SerialLogConfig) Path() string {
+	return filepath.Join(c.outputDir, serialLogName)
+}
+
+type StepAddSerialPort struct {
+	Config *SerialLogConfig
+}
+
+// Run adds a serial port backed by a file in the directory of the virtual
+// machine. The datastore path of the file is stored in the state as
+// "serial_log_path".
+func (s *StepAddSerialPort) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.SerialLog {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Adding serial port for console output...")
+	path, err := vm.AddSerialPortFile(serialLogName)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error adding serial port: %s", err))
+		return multistep.ActionHalt
+	}
+	state.Put("serial_log_path", path)
+
+	return multistep.ActionContinue
+}
+
+// Cleanup downloads the serial console log if the build failed or was
+// cancelled before the log was downloaded.
+func (s *StepAddSerialPort) Cleanup(state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+
+	path, ok := state.GetOk("serial_log_path")
+	if !ok {
+		return
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	if err := downloadDatastoreFile(d, path.(string), s.Config.Path()); err != nil {
+		ui.Errorf("error downloading serial console log: %s", err)
+		return
+	}
+	ui.Sayf("Serial console log saved to %s", s.Config.Path())
+}
+
+type StepRemoveSerialPort struct {
+	Config *SerialLogConfig
+}
+
+// Run downloads the serial console log and removes the serial port added by
+// StepAddSerialPort.
+func (s *StepRemoveSerialPort) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	path, ok := state.GetOk("serial_log_path")
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+	d := state.Get("driver").(driver.Driver)
+
+	ui.Sayf("Downloading serial console log to %s...", s.Config.Path())
+	if err := downloadDatastoreFile(d, path.(string), s.Config.Path()); err != nil {
+		state.Put("error", fmt.Errorf("error downloading serial console log: %s", err))
+		return multistep.ActionHalt
+	}
+	state.Remove("serial_log_path")
+
+	ui.Say("Removing serial port...")
+	devices, err := vm.Devices()
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	var ports []types.BaseVirtualDevice
+	for _, device := range devices.SelectByType((*types.VirtualSerialPort)(nil)) {
+		backing, ok := device.GetVirtualDevice().Backing.(*types.VirtualSerialPortFileBackingInfo)
+		if ok && backing.FileName == path.(string) {
+			ports = append(ports, device)
+		}
+	}
+	if len(ports) > 0 {
+		if err := vm.RemoveDevice(true, ports...); err != nil {
+			state.Put("error", fmt.Errorf("error removing serial port: %s", err))
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepRemoveSerialPort) Cleanup(multistep.StateBag) {}
+
+// downloadDatastoreFile downloads the file at the datastore path to the local
+// path, creating the directory of the local path as needed.
+func downloadDatastoreFile(d driver.Driver, path string, dst string) error {
+	var dsPath object.DatastorePath
+	if !dsPath.FromString(path) {
+		return fmt.Errorf("error parsing datastore path %q", path)
+	}
+
+	ds, err := d.FindDatastore(dsPath.Datastore, "")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	return ds.DownloadFile(dsPath.Path, dst)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestSerialLogConfig_Prepare(t *testing.T) {
+	config := &SerialLogConfig{SerialLog: true}
+	if errs := config.Prepare(nil, &common.PackerConfig{PackerBuildName: "example"}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if expected := filepath.Join("output-example", "serial.log"); config.Path() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, config.Path())
+	}
+
+	export := &ExportConfig{OutputDir: OutputConfig{OutputDir: "output-artifacts"}}
+	if errs := config.Prepare(export, &common.PackerConfig{PackerBuildName: "example"}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if expected := filepath.Join("output-artifacts", "serial.log"); config.Path() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, config.Path())
+	}
+}
+
+func TestStepSerialPort(t *testing.T) {
+	dir := t.TempDir()
+	config := &SerialLogConfig{SerialLog: true, outputDir: dir}
+	path := "[datastore1] example/serial.log"
+
+	state := basicStateBag(nil)
+	port := &types.VirtualSerialPort{
+		VirtualDevice: types.VirtualDevice{
+			Key: 9000,
+			Backing: &types.VirtualSerialPortFileBackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: path},
+			},
+		},
+	}
+	vm := &driver.VirtualMachineMock{
+		AddSerialPortFileResponse: path,
+		DevicesReturn:             object.VirtualDeviceList{port},
+	}
+	d := new(driver.DriverMock)
+	state.Put("vm", vm)
+	state.Put("driver", d)
+
+	add := &StepAddSerialPort{Config: config}
+	if action := add.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.AddSerialPortFileName != "serial.log" {
+		t.Fatalf("unexpected result: expected 'serial.log', but returned '%s'", vm.AddSerialPortFileName)
+	}
+
+	remove := &StepRemoveSerialPort{Config: config}
+	if action := remove.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if d.FindDatastoreName != "datastore1" {
+		t.Fatalf("unexpected result: expected 'datastore1', but returned '%s'", d.FindDatastoreName)
+	}
+	if d.DatastoreMock.DownloadFileSrc != "example/serial.log" {
+		t.Fatalf("unexpected result: expected 'example/serial.log', but returned '%s'", d.DatastoreMock.DownloadFileSrc)
+	}
+	if expected := filepath.Join(dir, "serial.log"); d.DatastoreMock.DownloadFileDst != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, d.DatastoreMock.DownloadFileDst)
+	}
+	if !vm.RemoveDeviceCalled || len(vm.RemoveDeviceDevices) != 1 {
+		t.Fatalf("unexpected result: expected the serial port to be removed")
+	}
+
+	// The log is not downloaded again when the build fails afterwards.
+	d.DatastoreMock.DownloadFileCalled = false
+	state.Put(multistep.StateHalted, true)
+	add.Cleanup(state)
+	if d.DatastoreMock.DownloadFileCalled {
+		t.Fatalf("unexpected result: expected the serial console log not to be downloaded again")
+	}
+}
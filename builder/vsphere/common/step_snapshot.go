@@ -1,35 +1,108 @@
 // Copyright (c) HashiCorp, Inc.
 // SPDX-License-Identifier: MPL-2.0
 
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type SnapshotConfig
+
 package common
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
+// The name of the snapshot when `snapshot_name` is not specified.
+const defaultSnapshotName = "Created By Packer"
+
+type SnapshotConfig struct {
+	// Create a snapshot of the virtual machine to use as a base for linked
+	// clones. Defaults to `false`.
+	CreateSnapshot bool `mapstructure:"create_snapshot"`
+	// The name of the snapshot when `create_snapshot` is `true`.
+	// Defaults to `Created By Packer`.
+	SnapshotName string `mapstructure:"snapshot_name"`
+	// The description of the snapshot when `create_snapshot` is `true`.
+	SnapshotDescription string `mapstructure:"snapshot_description"`
+	// Include the memory of the virtual machine in the snapshot when
+	// `create_snapshot` is `true`. Defaults to `false`.
+	//
+	// The snapshot is created before the virtual machine is shut down, so
+	// the virtual machines deployed from the snapshot resume from the
+	// running state of the virtual machine. The changes made to the virtual
+	// machine after the shutdown, such as removing the CD-ROM devices, are
+	// not included in the snapshot.
+	SnapshotMemory bool `mapstructure:"snapshot_memory"`
+	// Remove all existing snapshots of the virtual machine, such as the
+	// snapshots inherited from the source of the virtual machine, and
+	// consolidate the disks before the snapshot is created. Defaults to
+	// `false`.
+	//
+	// Cannot be used with `differential_base_snapshot`.
+	RemoveExistingSnapshots bool `mapstructure:"remove_existing_snapshots"`
+}
+
+func (c *SnapshotConfig) Prepare(export *ExportConfig) []error {
+	var errs []error
+
+	if !c.CreateSnapshot {
+		if c.SnapshotDescription != "" {
+			errs = append(errs, fmt.Errorf("'snapshot_description' requires 'create_snapshot'"))
+		}
+		if c.SnapshotMemory {
+			errs = append(errs, fmt.Errorf("'snapshot_memory' requires 'create_snapshot'"))
+		}
+	}
+	if c.RemoveExistingSnapshots && export != nil && export.DifferentialBaseSnapshot != "" {
+		errs = append(errs, fmt.Errorf("'remove_existing_snapshots' cannot be used with 'differential_base_snapshot'"))
+	}
+
+	if c.SnapshotName == "" {
+		c.SnapshotName = defaultSnapshotName
+	}
+
+	return errs
+}
+
 type StepCreateSnapshot struct {
-	CreateSnapshot bool
-	SnapshotName   string
+	Config *SnapshotConfig
+	// Run before the virtual machine is shut down.
+	BeforeShutdown bool
 }
 
 func (s *StepCreateSnapshot) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
-	ui := state.Get("ui").(packersdk.Ui)
-	vm := state.Get("vm").(*driver.VirtualMachineDriver)
-
-	if s.CreateSnapshot {
-		ui.Say("Creating snapshot...")
+	// A snapshot that includes the memory is created before the virtual
+	// machine is shut down, and any other snapshot after.
+	if s.Config.SnapshotMemory != s.BeforeShutdown {
+		return multistep.ActionContinue
+	}
 
-		snapshotName := "Created By Packer"
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
 
-		if s.SnapshotName != "" {
-			snapshotName = s.SnapshotName
+	if s.Config.RemoveExistingSnapshots {
+		names, err := vm.ListSnapshots()
+		if err != nil {
+			state.Put("error", fmt.Errorf("error listing snapshots: %s", err))
+			return multistep.ActionHalt
+		}
+		if len(names) > 0 {
+			ui.Sayf("Removing existing snapshots: %s", strings.Join(names, ", "))
+			if err := vm.RemoveAllSnapshots(); err != nil {
+				state.Put("error", fmt.Errorf("error removing snapshots: %s", err))
+				return multistep.ActionHalt
+			}
 		}
+	}
+
+	if s.Config.CreateSnapshot {
+		ui.Say("Creating snapshot...")
 
-		err := vm.CreateSnapshot(snapshotName)
+		err := vm.CreateSnapshotWithOptions(s.Config.SnapshotName, s.Config.SnapshotDescription, s.Config.SnapshotMemory)
 		if err != nil {
 			state.Put("error", err)
 			return multistep.ActionHalt
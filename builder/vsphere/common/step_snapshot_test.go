@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestSnapshotConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name   string
+		config SnapshotConfig
+		export *ExportConfig
+		fail   bool
+	}{
+		{
+			name:   "Snapshot",
+			config: SnapshotConfig{CreateSnapshot: true, SnapshotDescription: "Base for linked clones", SnapshotMemory: true},
+		},
+		{
+			name:   "Description without snapshot",
+			config: SnapshotConfig{SnapshotDescription: "Base for linked clones"},
+			fail:   true,
+		},
+		{
+			name:   "Memory without snapshot",
+			config: SnapshotConfig{SnapshotMemory: true},
+			fail:   true,
+		},
+		{
+			name:   "Remove existing snapshots",
+			config: SnapshotConfig{RemoveExistingSnapshots: true},
+			export: &ExportConfig{},
+		},
+		{
+			name:   "Remove existing snapshots with differential export",
+			config: SnapshotConfig{CreateSnapshot: true, RemoveExistingSnapshots: true},
+			export: &ExportConfig{DifferentialBaseSnapshot: "base"},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(c.export)
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+			if c.config.SnapshotName != defaultSnapshotName {
+				t.Fatalf("unexpected result: expected snapshot name '%s', but returned '%s'", defaultSnapshotName, c.config.SnapshotName)
+			}
+		})
+	}
+}
+
+func TestStepCreateSnapshot_Run(t *testing.T) {
+	vm := &driver.VirtualMachineMock{ListSnapshotsReturn: []string{"template"}}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepCreateSnapshot{
+		Config: &SnapshotConfig{
+			CreateSnapshot:          true,
+			SnapshotName:            "base",
+			SnapshotDescription:     "Base for linked clones",
+			RemoveExistingSnapshots: true,
+		},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if !vm.RemoveAllSnapshotsCalled {
+		t.Fatal("unexpected result: expected the existing snapshots to be removed")
+	}
+	if vm.CreateSnapshotWithOptionsName != "base" || vm.CreateSnapshotWithOptionsDescription != "Base for linked clones" || vm.CreateSnapshotWithOptionsMemory {
+		t.Fatalf("unexpected result: created snapshot '%s' with description '%s' and memory '%t'",
+			vm.CreateSnapshotWithOptionsName, vm.CreateSnapshotWithOptionsDescription, vm.CreateSnapshotWithOptionsMemory)
+	}
+}
+
+func TestStepCreateSnapshot_RunMemory(t *testing.T) {
+	config := &SnapshotConfig{CreateSnapshot: true, SnapshotName: "base", SnapshotMemory: true}
+
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepCreateSnapshot{Config: config}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.CreateSnapshotWithOptionsCalled {
+		t.Fatal("unexpected result: expected no snapshot after the shutdown")
+	}
+
+	step = &StepCreateSnapshot{Config: config, BeforeShutdown: true}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if !vm.CreateSnapshotWithOptionsMemory {
+		t.Fatal("unexpected result: expected a snapshot with the memory before the shutdown")
+	}
+}
+
+func TestStepCreateSnapshot_RunNoExistingSnapshots(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepCreateSnapshot{Config: &SnapshotConfig{RemoveExistingSnapshots: true}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.RemoveAllSnapshotsCalled || vm.CreateSnapshotWithOptionsCalled {
+		t.Fatal("unexpected result: expected no changes to the virtual machine")
+	}
+}
+
+func TestStepCreateSnapshot_RunError(t *testing.T) {
+	tc := []struct {
+		name string
+		vm   *driver.VirtualMachineMock
+	}{
+		{
+			name: "List error",
+			vm:   &driver.VirtualMachineMock{ListSnapshotsErr: fmt.Errorf("permission denied")},
+		},
+		{
+			name: "Remove error",
+			vm:   &driver.VirtualMachineMock{ListSnapshotsReturn: []string{"template"}, RemoveAllSnapshotsErr: fmt.Errorf("disk locked")},
+		},
+		{
+			name: "Create error",
+			vm:   &driver.VirtualMachineMock{CreateSnapshotWithOptionsErr: fmt.Errorf("insufficient disk space")},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := basicStateBag(nil)
+			state.Put("vm", c.vm)
+
+			step := &StepCreateSnapshot{
+				Config: &SnapshotConfig{CreateSnapshot: true, SnapshotName: "base", RemoveExistingSnapshots: true},
+			}
+			if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+				t.Fatalf("unexpected action: '%#v'", action)
+			}
+			if _, ok := state.GetOk("error"); !ok {
+				t.Fatal("unexpected result: expected an error in the state")
+			}
+		})
+	}
+}
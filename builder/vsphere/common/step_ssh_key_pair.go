@@ -89,6 +89,8 @@ func (s *StepSshKeyPair) Run(ctx context.Context, state multistep.StateBag) mult
 		state.Put("error", fmt.Errorf("error saving temporary key pair in the vm: %s", err))
 		return multistep.ActionHalt
 	}
+	// The public key is removed from the virtual machine after the build.
+	state.Put("temporary_vapp_properties", []string{"public-keys"})
 
 	// If we're in debug mode, output the private key to the working directory.
 	if s.Debug {
@@ -0,0 +1,238 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type SysprepConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// The default path of the sysprep executable in the guest.
+	defaultSysprepPath = `C:\Windows\System32\Sysprep\sysprep.exe`
+	// The log of the errors of sysprep in the guest.
+	sysprepErrorLogPath = `C:\Windows\System32\Sysprep\Panther\setuperr.log`
+	// The number of lines of the error log reported when sysprep fails.
+	sysprepErrorLogLines = 20
+)
+
+// SysprepConfig generalizes a Windows guest operating system with sysprep
+// after the provisioners have run. Sysprep is started with the guest
+// operations of VMware Tools and shuts down the virtual machine, which
+// replaces the shutdown of the virtual machine by `shutdown_command` or
+// VMware Tools.
+//
+// The build fails if sysprep exits with an error, in which case the last lines
+// of `setuperr.log` are reported, or if the guest operating system restarts
+// instead of shutting down, such as when the unattend file requests a restart.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	sysprep {
+//	  unattend_file = "C:\\Windows\\Panther\\unattend.xml"
+//	  mode_vm       = true
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"sysprep": {
+//	  "unattend_file": "C:\\Windows\\Panther\\unattend.xml",
+//	  "mode_vm": true
+//	}
+//
+// ```
+type SysprepConfig struct {
+	// The username of the guest operating system account to run sysprep.
+	// The account must be a member of the Administrators group. Defaults to
+	// the username of the communicator or of `guest_operations`.
+	Username string `mapstructure:"username"`
+	// The password of the guest operating system account to run sysprep.
+	// Defaults to the password of the communicator or of `guest_operations`.
+	Password string `mapstructure:"password"`
+	// The path of the sysprep executable in the guest operating system.
+	// Defaults to `C:\Windows\System32\Sysprep\sysprep.exe`.
+	Path string `mapstructure:"path"`
+	// The path of an answer file in the guest operating system to pass to
+	// sysprep with `/unattend`.
+	UnattendFile string `mapstructure:"unattend_file"`
+	// Start the guest operating system in audit mode with `/audit` instead of
+	// the out-of-box experience with `/oobe`. Defaults to `false`.
+	Audit bool `mapstructure:"audit"`
+	// Generalize the guest operating system with `/mode:vm`, which skips the
+	// hardware detection when the virtual machines deployed from the
+	// template use the same virtual hardware. Defaults to `false`.
+	ModeVM bool `mapstructure:"mode_vm"`
+	// The amount of time to wait for sysprep to shut down the virtual
+	// machine. Defaults to `30m` (30 minutes).
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func (c *SysprepConfig) Prepare(comm communicator.Config, guest *GuestOperationsConfig) []error {
+	var errs []error
+
+	username, password := comm.User(), comm.Password()
+	if guest != nil {
+		username, password = guest.Username, guest.Password
+	}
+	if c.Username == "" {
+		c.Username = username
+	}
+	if c.Password == "" {
+		c.Password = password
+	}
+	if c.Username == "" || c.Password == "" {
+		errs = append(errs, fmt.Errorf("'sysprep' 'username' and 'password' are required when the communicator has no password"))
+	}
+	if c.Path == "" {
+		c.Path = defaultSysprepPath
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 30 * time.Minute
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("'sysprep' 'timeout' must be greater than or equal to 0"))
+	}
+	return errs
+}
+
+// args returns the command-line arguments of sysprep.
+func (c *SysprepConfig) args() string {
+	args := []string{"/generalize"}
+	if c.Audit {
+		args = append(args, "/audit")
+	} else {
+		args = append(args, "/oobe")
+	}
+	args = append(args, "/shutdown", "/quiet")
+	if c.ModeVM {
+		args = append(args, "/mode:vm")
+	}
+	if c.UnattendFile != "" {
+		args = append(args, fmt.Sprintf(`/unattend:"%s"`, c.UnattendFile))
+	}
+	return strings.Join(args, " ")
+}
+
+type StepSysprep struct {
+	Config *SysprepConfig
+
+	// The interval between the checks of the state of the virtual machine.
+	pollInterval time.Duration
+}
+
+func (s *StepSysprep) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Config == nil {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	ui.Say("Running sysprep...")
+	args := s.Config.args()
+	log.Printf("Sysprep command: %s %s", s.Config.Path, args)
+	pid, err := vm.StartGuestProgram(s.Config.Username, s.Config.Password, s.Config.Path, args)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error starting sysprep: %s", err))
+		return multistep.ActionHalt
+	}
+
+	interval := s.pollInterval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timeout := time.After(s.Config.Timeout)
+
+	ui.Sayf("Waiting max %s for sysprep to shut down the virtual machine...", s.Config.Timeout)
+	var toolsStopped bool
+	for {
+		select {
+		case <-ctx.Done():
+			state.Put("error", fmt.Errorf("sysprep cancelled: %s", ctx.Err()))
+			return multistep.ActionHalt
+		case <-timeout:
+			state.Put("error", fmt.Errorf("timeout while waiting for sysprep to shut down the virtual machine"))
+			return multistep.ActionHalt
+		case <-ticker.C:
+		}
+
+		if off, err := vm.IsPoweredOff(); err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		} else if off {
+			ui.Say("Sysprep completed.")
+			return multistep.ActionContinue
+		}
+
+		// The guest operations fail while the guest operating system shuts
+		// down, so only a completed process with an exit code is reported.
+		if code, err := vm.GuestProgramExitCode(s.Config.Username, s.Config.Password, pid); err != nil {
+			log.Printf("[DEBUG] Error retrieving the exit code of sysprep: %s", err)
+		} else if code != nil && *code != 0 {
+			state.Put("error", s.sysprepError(vm, *code))
+			return multistep.ActionHalt
+		}
+
+		// VMware Tools stop when the guest operating system shuts down. If
+		// they start again while the virtual machine is powered on, the guest
+		// operating system restarted instead.
+		switch toolsRunningStatus(vm) {
+		case string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning):
+			toolsStopped = true
+		case string(types.VirtualMachineToolsRunningStatusGuestToolsRunning):
+			if toolsStopped {
+				state.Put("error", fmt.Errorf("the guest operating system restarted instead of shutting down after sysprep; "+
+					"ensure that the answer file and pending updates do not request a restart"))
+				return multistep.ActionHalt
+			}
+		}
+	}
+}
+
+// sysprepError returns the error of a sysprep process that exited with the
+// specified code, with the last lines of the error log of sysprep if the log
+// can be downloaded from the guest.
+func (s *StepSysprep) sysprepError(vm driver.VirtualMachine, code int32) error {
+	content, err := vm.DownloadGuestFile(s.Config.Username, s.Config.Password, sysprepErrorLogPath)
+	if err != nil || len(strings.TrimSpace(string(content))) == 0 {
+		return fmt.Errorf("sysprep exited with code %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(content), "\r\n", "\n")), "\n")
+	if len(lines) > sysprepErrorLogLines {
+		lines = lines[len(lines)-sysprepErrorLogLines:]
+	}
+	return fmt.Errorf("sysprep exited with code %d:\n%s", code, strings.Join(lines, "\n"))
+}
+
+// toolsRunningStatus returns the running status of VMware Tools in the guest
+// operating system, or an empty string if it is unknown.
+func toolsRunningStatus(vm driver.VirtualMachine) string {
+	info, err := vm.Info("guest.toolsRunningStatus")
+	if err != nil || info == nil || info.Guest == nil {
+		return ""
+	}
+	return info.Guest.ToolsRunningStatus
+}
+
+func (s *StepSysprep) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestSysprepConfig_Prepare(t *testing.T) {
+	config := &SysprepConfig{}
+	comm := communicator.Config{
+		Type:  "winrm",
+		WinRM: communicator.WinRM{WinRMUser: "Administrator", WinRMPassword: "secret"},
+	}
+	if errs := config.Prepare(comm, nil); len(errs) != 0 {
+		t.Fatalf("unexpected errors: '%v'", errs)
+	}
+	if config.Username != "Administrator" || config.Password != "secret" {
+		t.Fatalf("unexpected result: expected the credentials of the communicator, but returned '%s'", config.Username)
+	}
+	if config.Path != defaultSysprepPath {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", defaultSysprepPath, config.Path)
+	}
+	if config.Timeout != 30*time.Minute {
+		t.Fatalf("unexpected result: expected '30m', but returned '%s'", config.Timeout)
+	}
+
+	config = &SysprepConfig{}
+	guest := &GuestOperationsConfig{Username: "Administrator", Password: "secret"}
+	if errs := config.Prepare(communicator.Config{Type: "none"}, guest); len(errs) != 0 {
+		t.Fatalf("unexpected errors: '%v'", errs)
+	}
+	if config.Username != "Administrator" || config.Password != "secret" {
+		t.Fatalf("unexpected result: expected the credentials of the guest operations, but returned '%s'", config.Username)
+	}
+
+	config = &SysprepConfig{}
+	if errs := config.Prepare(communicator.Config{Type: "none"}, nil); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestSysprepConfig_Args(t *testing.T) {
+	config := &SysprepConfig{}
+	if args := config.args(); args != "/generalize /oobe /shutdown /quiet" {
+		t.Fatalf("unexpected result: '%s'", args)
+	}
+
+	config = &SysprepConfig{Audit: true, ModeVM: true, UnattendFile: `C:\Windows\Panther\unattend.xml`}
+	expected := `/generalize /audit /shutdown /quiet /mode:vm /unattend:"C:\Windows\Panther\unattend.xml"`
+	if args := config.args(); args != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, args)
+	}
+}
+
+func TestStepSysprep_Run(t *testing.T) {
+	vm := &driver.VirtualMachineMock{IsPoweredOffResults: []bool{false, true}}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepSysprep{
+		Config:       &SysprepConfig{Path: defaultSysprepPath, Timeout: time.Minute},
+		pollInterval: time.Millisecond,
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v', error: '%v'", action, state.Get("error"))
+	}
+	if vm.StartGuestProgramPath != defaultSysprepPath {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", defaultSysprepPath, vm.StartGuestProgramPath)
+	}
+}
+
+func TestStepSysprep_RunExitCode(t *testing.T) {
+	code := int32(1)
+	log := strings.Repeat("Info\r\n", 30) + "Error SYSPRP Package Microsoft.Example was installed for a user\r\n"
+	vm := &driver.VirtualMachineMock{
+		GuestProgramExitCodeReturn: &code,
+		DownloadGuestFileReturn:    []byte(log),
+	}
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := &StepSysprep{
+		Config:       &SysprepConfig{Path: defaultSysprepPath, Timeout: time.Minute},
+		pollInterval: time.Millisecond,
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	err := state.Get("error").(error).Error()
+	if !strings.Contains(err, "sysprep exited with code 1") || !strings.Contains(err, "Microsoft.Example") {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if lines := strings.Count(err, "\n"); lines != sysprepErrorLogLines {
+		t.Fatalf("unexpected result: expected '%d' lines of the error log, but returned '%d'", sysprepErrorLogLines, lines)
+	}
+}
+
+func TestStepSysprep_RunErrors(t *testing.T) {
+	tc := []struct {
+		name    string
+		vm      *driver.VirtualMachineMock
+		timeout time.Duration
+	}{
+		{
+			name:    "Start error",
+			vm:      &driver.VirtualMachineMock{StartGuestProgramErr: fmt.Errorf("invalid guest login")},
+			timeout: time.Minute,
+		},
+		{
+			name:    "Timeout",
+			vm:      &driver.VirtualMachineMock{GuestProgramExitCodeErr: fmt.Errorf("guest operations agent not running")},
+			timeout: 10 * time.Millisecond,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			state := basicStateBag(nil)
+			state.Put("vm", c.vm)
+
+			step := &StepSysprep{
+				Config:       &SysprepConfig{Path: defaultSysprepPath, Timeout: c.timeout},
+				pollInterval: time.Millisecond,
+			}
+			if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+				t.Fatalf("unexpected action: '%#v'", action)
+			}
+			if _, ok := state.GetOk("error"); !ok {
+				t.Fatal("expected state to contain an error")
+			}
+		})
+	}
+}
+
+func TestStepSysprep_RunDisabled(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+
+	step := new(StepSysprep)
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.StartGuestProgramPath != "" {
+		t.Fatal("unexpected result: expected sysprep not to run")
+	}
+}
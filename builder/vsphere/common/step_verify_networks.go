@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type NetworkVerificationConfig
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The interval at which the guest network adapters are checked during the
+// verification of the networks.
+var networkVerificationInterval = 5 * time.Second
+
+// Verify that the guest operating system obtains an IP address on the network
+// of each network adapter, such as for a template that must work on several
+// VLANs, to catch a misconfiguration of a port group or VLAN at build time.
+// After the provisioning, each connected network adapter in turn is
+// disconnected and reconnected, and VMware Tools must report an IP address
+// for the adapter within the timeout, which otherwise fails the build. The
+// network and the IP addresses of each adapter are recorded in the artifact
+// metadata.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	verify_networks         = true
+//	verify_networks_timeout = "3m"
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"verify_networks": true,
+//	"verify_networks_timeout": "3m",
+//
+// ```
+//
+// -> **Note:** The guest operating system must obtain the same IP address for
+// the network adapter of the communicator when it is reconnected, such as from
+// a DHCP lease, to run the `shutdown_command`.
+type NetworkVerificationConfig struct {
+	// Verify the network of each connected network adapter after the
+	// provisioning. Defaults to `false`.
+	VerifyNetworks bool `mapstructure:"verify_networks"`
+	// The amount of time to wait for the guest operating system to report an
+	// IP address for each network adapter. Defaults to `5m`.
+	VerifyNetworksTimeout time.Duration `mapstructure:"verify_networks_timeout"`
+}
+
+func (c *NetworkVerificationConfig) Prepare(comm communicator.Config) []error {
+	var errs []error
+
+	if !c.VerifyNetworks {
+		return nil
+	}
+	if comm.Type == "none" {
+		errs = append(errs, fmt.Errorf("'verify_networks' requires a communicator"))
+	}
+	if c.VerifyNetworksTimeout < 0 {
+		errs = append(errs, fmt.Errorf("'verify_networks_timeout' must be greater than or equal to 0"))
+	}
+	if c.VerifyNetworksTimeout == 0 {
+		c.VerifyNetworksTimeout = 5 * time.Minute
+	}
+
+	return errs
+}
+
+type StepVerifyNetworks struct {
+	Config *NetworkVerificationConfig
+}
+
+func (s *StepVerifyNetworks) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.VerifyNetworks {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vm := state.Get("vm").(driver.VirtualMachine)
+
+	devices, err := vm.Devices()
+	if err != nil {
+		state.Put("error", fmt.Errorf("error retrieving devices: %s", err))
+		return multistep.ActionHalt
+	}
+
+	metadata := map[string]string{}
+	for i, adapter := range devices.SelectByType((*types.VirtualEthernetCard)(nil)) {
+		device := adapter.GetVirtualDevice()
+		if device.Connectable == nil || !device.Connectable.Connected {
+			ui.Sayf("Skipping disconnected network adapter %d...", i)
+			continue
+		}
+
+		ui.Sayf("Verifying the network of network adapter %d...", i)
+		nic, err := s.verifyAdapter(ctx, vm, i, device.Key)
+		if err != nil {
+			err = fmt.Errorf("error verifying the network of network adapter %d: %s", i, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		addresses := strings.Join(guestAddresses(nic), ",")
+		ui.Sayf("Network adapter %d obtained %s on network %s.", i, addresses, nic.Network)
+		metadata[fmt.Sprintf("network_verification_%d_network", i)] = nic.Network
+		metadata[fmt.Sprintf("network_verification_%d_addresses", i)] = addresses
+	}
+	state.Put("network_verification", metadata)
+
+	return multistep.ActionContinue
+}
+
+// verifyAdapter disconnects and reconnects the network adapter at the index,
+// and waits until the guest operating system reports an IP address for it.
+func (s *StepVerifyNetworks) verifyAdapter(ctx context.Context, vm driver.VirtualMachine, index int, key int32) (*types.GuestNicInfo, error) {
+	if err := vm.SetNetworkAdaptersConnected([]int{index}, false); err != nil {
+		return nil, fmt.Errorf("error disconnecting network adapter: %s", err)
+	}
+	_, err := waitForGuestAdapter(ctx, vm, key, s.Config.VerifyNetworksTimeout, func(nic *types.GuestNicInfo) bool {
+		return !nic.Connected
+	})
+	if err != nil {
+		// Reconnect the network adapter for the communicator.
+		_ = vm.SetNetworkAdaptersConnected([]int{index}, true)
+		return nil, fmt.Errorf("network adapter not reported as disconnected: %s", err)
+	}
+
+	if err := vm.SetNetworkAdaptersConnected([]int{index}, true); err != nil {
+		return nil, fmt.Errorf("error connecting network adapter: %s", err)
+	}
+	nic, err := waitForGuestAdapter(ctx, vm, key, s.Config.VerifyNetworksTimeout, func(nic *types.GuestNicInfo) bool {
+		return nic.Connected && len(guestAddresses(nic)) > 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no IP address obtained: %s", err)
+	}
+	return nic, nil
+}
+
+// waitForGuestAdapter waits until the guest network adapter of the virtual
+// device with the key satisfies the condition.
+func waitForGuestAdapter(ctx context.Context, vm driver.VirtualMachine, key int32, timeout time.Duration, cond func(*types.GuestNicInfo) bool) (*types.GuestNicInfo, error) {
+	deadline := time.After(timeout)
+	for {
+		nics, err := vm.GuestNetworkAdapters()
+		if err != nil {
+			return nil, err
+		}
+		for i := range nics {
+			if nics[i].DeviceConfigId == key && cond(&nics[i]) {
+				return &nics[i], nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, fmt.Errorf("timeout after %s", timeout)
+		case <-time.After(networkVerificationInterval):
+		}
+	}
+}
+
+// guestAddresses returns the IP addresses of the guest network adapter that
+// are routable, which excludes link-local addresses.
+func guestAddresses(nic *types.GuestNicInfo) []string {
+	var addresses []string
+	for _, address := range nic.IpAddress {
+		if ip := net.ParseIP(address); ip != nil && ip.IsGlobalUnicast() {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+func (s *StepVerifyNetworks) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// networkVM is a virtual machine whose guest network adapters follow the
+// connection state of the network adapters, and obtain the addresses of the
+// network of the adapter when they are connected.
+type networkVM struct {
+	*driver.VirtualMachineMock
+
+	devices object.VirtualDeviceList
+	// The addresses obtained by the network adapters, by key.
+	addresses map[int32][]string
+	networks  map[int32]string
+	// The number of times each network adapter was disconnected, by key.
+	disconnects map[int32]int
+}
+
+func newNetworkVM(connected ...bool) *networkVM {
+	vm := &networkVM{
+		VirtualMachineMock: &driver.VirtualMachineMock{},
+		addresses:          map[int32][]string{},
+		networks:           map[int32]string{},
+		disconnects:        map[int32]int{},
+	}
+	for i, c := range connected {
+		key := int32(4000 + i)
+		vm.devices = append(vm.devices, &types.VirtualVmxnet3{VirtualVmxnet: types.VirtualVmxnet{VirtualEthernetCard: types.VirtualEthernetCard{
+			VirtualDevice: types.VirtualDevice{Key: key, Connectable: &types.VirtualDeviceConnectInfo{Connected: c}},
+		}}})
+	}
+	return vm
+}
+
+func (vm *networkVM) Devices() (object.VirtualDeviceList, error) {
+	return vm.devices, nil
+}
+
+func (vm *networkVM) SetNetworkAdaptersConnected(indices []int, connected bool) error {
+	for _, i := range indices {
+		device := vm.devices[i].GetVirtualDevice()
+		device.Connectable.Connected = connected
+		if !connected {
+			vm.disconnects[device.Key]++
+		}
+	}
+	return nil
+}
+
+func (vm *networkVM) GuestNetworkAdapters() ([]types.GuestNicInfo, error) {
+	var nics []types.GuestNicInfo
+	for _, d := range vm.devices {
+		device := d.GetVirtualDevice()
+		nic := types.GuestNicInfo{
+			DeviceConfigId: device.Key,
+			Network:        vm.networks[device.Key],
+			Connected:      device.Connectable.Connected,
+		}
+		if nic.Connected {
+			nic.IpAddress = vm.addresses[device.Key]
+		}
+		nics = append(nics, nic)
+	}
+	return nics, nil
+}
+
+func TestNetworkVerificationConfig_Prepare(t *testing.T) {
+	config := &NetworkVerificationConfig{VerifyNetworks: true}
+	if errs := config.Prepare(communicator.Config{Type: "ssh"}); len(errs) != 0 {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+	if config.VerifyNetworksTimeout != 5*time.Minute {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", 5*time.Minute, config.VerifyNetworksTimeout)
+	}
+
+	config = &NetworkVerificationConfig{VerifyNetworks: true, VerifyNetworksTimeout: -time.Second}
+	errs := config.Prepare(communicator.Config{Type: "none"})
+	if len(errs) != 2 {
+		t.Fatalf("unexpected result: expected '2' errors, but returned '%d'", len(errs))
+	}
+	if errs[0].Error() != "'verify_networks' requires a communicator" {
+		t.Fatalf("unexpected error: '%s'", errs[0])
+	}
+}
+
+func TestStepVerifyNetworks_Run(t *testing.T) {
+	defer func(interval time.Duration) { networkVerificationInterval = interval }(networkVerificationInterval)
+	networkVerificationInterval = time.Millisecond
+
+	vm := newNetworkVM(true, false, true)
+	vm.networks[4000] = "VLAN 10"
+	vm.addresses[4000] = []string{"fe80::1", "10.0.10.5"}
+	vm.networks[4002] = "VLAN 20"
+	vm.addresses[4002] = []string{"10.0.20.5", "2001:db8::5"}
+
+	state := basicStateBag(nil)
+	state.Put("vm", vm)
+	step := &StepVerifyNetworks{Config: &NetworkVerificationConfig{VerifyNetworks: true, VerifyNetworksTimeout: time.Second}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	expected := map[string]string{
+		"network_verification_0_network":   "VLAN 10",
+		"network_verification_0_addresses": "10.0.10.5",
+		"network_verification_2_network":   "VLAN 20",
+		"network_verification_2_addresses": "10.0.20.5,2001:db8::5",
+	}
+	if diff := cmp.Diff(expected, state.Get("network_verification")); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+	if diff := cmp.Diff(map[int32]int{4000: 1, 4002: 1}, vm.disconnects); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+	for i, d := range vm.devices {
+		if connected := d.GetVirtualDevice().Connectable.Connected; connected != (i != 1) {
+			t.Fatalf("unexpected result: network adapter %d connected is '%t'", i, connected)
+		}
+	}
+}
+
+func TestStepVerifyNetworks_RunNoAddress(t *testing.T) {
+	defer func(interval time.Duration) { networkVerificationInterval = interval }(networkVerificationInterval)
+	networkVerificationInterval = time.Millisecond
+
+	vm := newNetworkVM(true)
+	vm.addresses[4000] = []string{"169.254.10.5"}
+
+	errorBuffer := &strings.Builder{}
+	state := basicStateBag(errorBuffer)
+	state.Put("vm", vm)
+	step := &StepVerifyNetworks{Config: &NetworkVerificationConfig{VerifyNetworks: true, VerifyNetworksTimeout: 50 * time.Millisecond}}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+
+	err, ok := state.Get("error").(error)
+	if !ok {
+		t.Fatal("unexpected success: expected failure")
+	}
+	expected := "error verifying the network of network adapter 0: no IP address obtained: timeout after 50ms"
+	if err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+}
@@ -8,10 +8,12 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
-	"strings"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
@@ -33,13 +35,58 @@ type WaitIpConfig struct {
 	// documentation for full details.
 	SettleTimeout time.Duration `mapstructure:"ip_settle_timeout"`
 	// Set this to a CIDR address to cause the service to wait for an address that is contained in
-	// this network range. Defaults to `0.0.0.0/0` for any IPv4 address. Examples include:
+	// this network range. Defaults to `0.0.0.0/0` for any IPv4 address, or to `::/0` for any IPv6
+	// address if `ip_version` is `6`. Examples include:
 	//
-	// * empty string ("") - remove all filters
+	// * empty string ("") - remove all filters except `ip_version`
 	// * `0:0:0:0:0:0:0:0/0` - allow only ipv6 addresses
 	// * `192.168.1.0/24` - only allow ipv4 addresses from 192.168.1.1 to 192.168.1.254
 	WaitAddress *string `mapstructure:"ip_wait_address"`
 	ipnet       *net.IPNet
+	// The IP version of the address used to connect to the virtual machine:
+	// `4`, `6`, or `any`. Set this to `6` for IPv6-only networks, or to `any`
+	// for dual-stack networks to use an IPv6 address if the virtual machine
+	// has no routable IPv4 address. Defaults to `4`, or to the IP version of
+	// `ip_wait_address` if set.
+	//
+	// -> **Note:** An IPv6 address is passed to the SSH communicator as is
+	// and to the WinRM communicator in brackets, as expected by each
+	// communicator.
+	IPVersion string `mapstructure:"ip_version"`
+	// The index of the network adapter of the virtual machine, starting at
+	// `0`, whose IP addresses are used. Use this option if the virtual
+	// machine is connected to several networks and only one of them is
+	// reachable from the Packer host. Defaults to the IP addresses of all
+	// network adapters.
+	NICIndex *int `mapstructure:"ip_wait_nic_index"`
+	// A list of CIDR addresses whose IP addresses are never used, even if they
+	// are in the `ip_wait_address` range. Use this option to ignore the
+	// addresses of container bridges reported by VMware Tools, for example
+	// `["172.17.0.0/16"]` for the default Docker bridge.
+	//
+	// -> **Note:** Routable IPv4 addresses are always preferred over other
+	// addresses, and link-local addresses, such as `169.254.0.0/16`, are used
+	// only if no other address is reported.
+	ExcludeCIDRs []string `mapstructure:"ip_wait_exclude_cidrs"`
+	exclude      []*net.IPNet
+	// Check that the IP address reported by VMware Tools is reachable from
+	// the Packer host before connecting the communicator. The check opens a
+	// TCP connection to the communicator port and fails fast if the address
+	// cannot be reached, for example if the virtual machine is connected to
+	// a network that is not routed to the Packer host, rather than waiting
+	// for the communicator to time out. Defaults to `false`.
+	//
+	// -> **Note:** Do not enable this option if the communicator connects
+	// through a bastion host or a proxy.
+	ReachabilityCheck bool `mapstructure:"ip_reachability_check"`
+	// Amount of time to wait for a response from the IP address when checking
+	// whether it is reachable. Defaults to `5s` (5 seconds).
+	ReachabilityTimeout time.Duration `mapstructure:"ip_reachability_timeout"`
+	// If the IP address reported by VMware Tools is not reachable, use the
+	// first other reported IP address in the `ip_wait_address` range that is
+	// reachable from the Packer host. Requires `ip_reachability_check`.
+	// Defaults to `false`.
+	SelectReachableIP bool `mapstructure:"ip_select_reachable"`
 
 	// WaitTimeout is a total timeout. If the virtual machine changes IP frequently, and does not settle down, wait
 	// until the timeout expires.
@@ -47,6 +94,9 @@ type WaitIpConfig struct {
 
 type StepWaitForIp struct {
 	Config *WaitIpConfig
+	// The port of the communicator, used to check that the IP address is
+	// reachable.
+	Port int
 }
 
 func (c *WaitIpConfig) Prepare() []error {
@@ -58,8 +108,28 @@ func (c *WaitIpConfig) Prepare() []error {
 	if c.WaitTimeout == 0 {
 		c.WaitTimeout = 30 * time.Minute
 	}
+	if c.ReachabilityTimeout == 0 {
+		c.ReachabilityTimeout = 5 * time.Second
+	}
+	if c.SelectReachableIP && !c.ReachabilityCheck {
+		errs = append(errs, fmt.Errorf("'ip_select_reachable' requires 'ip_reachability_check'"))
+	}
+
+	version := c.IPVersion
+	switch version {
+	case "", driver.IPVersion4, driver.IPVersion6, driver.IPVersionAny:
+	default:
+		errs = append(errs, fmt.Errorf("'ip_version' must be one of '%s', '%s', or '%s'",
+			driver.IPVersion4, driver.IPVersion6, driver.IPVersionAny))
+	}
 	if c.WaitAddress == nil {
 		addr := "0.0.0.0/0"
+		switch version {
+		case driver.IPVersion6:
+			addr = "::/0"
+		case driver.IPVersionAny:
+			addr = ""
+		}
 		c.WaitAddress = &addr
 	}
 
@@ -70,6 +140,33 @@ func (c *WaitIpConfig) Prepare() []error {
 			errs = append(errs, fmt.Errorf("unable to parse \"ip_wait_address\": %w", err))
 		}
 	}
+	if c.ipnet != nil {
+		addressVersion := driver.IPVersion4
+		if c.ipnet.IP.To4() == nil {
+			addressVersion = driver.IPVersion6
+		}
+		if version == "" {
+			version = addressVersion
+		} else if version != driver.IPVersionAny && version != addressVersion {
+			errs = append(errs, fmt.Errorf("'ip_wait_address' %q is not an IPv%s network range", *c.WaitAddress, version))
+		}
+	}
+	if version == "" {
+		version = driver.IPVersion4
+	}
+	c.IPVersion = version
+	if c.NICIndex != nil && *c.NICIndex < 0 {
+		errs = append(errs, fmt.Errorf("'ip_wait_nic_index' must be greater than or equal to 0"))
+	}
+	c.exclude = nil
+	for _, cidr := range c.ExcludeCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to parse \"ip_wait_exclude_cidrs\": %w", err))
+			continue
+		}
+		c.exclude = append(c.exclude, network)
+	}
 
 	return errs
 }
@@ -78,6 +175,16 @@ func (c *WaitIpConfig) GetIPNet() *net.IPNet {
 	return c.ipnet
 }
 
+// ipFilter returns the filter of the IP addresses reported by VMware Tools.
+func (c *WaitIpConfig) ipFilter() *driver.IPFilter {
+	return &driver.IPFilter{
+		Network: c.ipnet,
+		Version: c.IPVersion,
+		Exclude: c.exclude,
+		NIC:     c.NICIndex,
+	}
+}
+
 func (s *StepWaitForIp) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
@@ -118,6 +225,9 @@ func (s *StepWaitForIp) Run(ctx context.Context, state multistep.StateBag) multi
 			log.Println("[WARN] Interrupt detected, quitting waiting for IP.")
 			return multistep.ActionHalt
 		case <-waitDone:
+			if err == nil && s.Config.ReachabilityCheck {
+				ip, err = s.reachableIP(ctx, ui, vm, ip)
+			}
 			if err != nil {
 				state.Put("error", err)
 				return multistep.ActionHalt
@@ -147,7 +257,7 @@ func doGetIp(vm *driver.VirtualMachineDriver, ctx context.Context, c *WaitIpConf
 		interval = 1 * time.Second
 	}
 loop:
-	ip, err := vm.WaitForIP(ctx, c.ipnet)
+	ip, err := vm.WaitForIP(ctx, c.ipFilter())
 	if err != nil {
 		return "", err
 	}
@@ -171,12 +281,6 @@ loop:
 	} else {
 		log.Printf("VM IP is still the same: %s", prevIp)
 		if time.Now().After(stopTime) {
-			if strings.Contains(ip, ":") {
-				// To use a literal IPv6 address in a URL the literal address should be enclosed in
-				// "[" and "]" characters. Refer to https://www.ietf.org/rfc/rfc2732.
-				// Example: ssh example@[2010:836B:4179::836B:4179]
-				ip = "[" + ip + "]"
-			}
 			log.Printf("VM IP seems stable enough: %s", ip)
 			return ip, nil
 		}
@@ -190,4 +294,56 @@ loop:
 
 }
 
+// reachableIP checks that the IP address is reachable from the Packer host. If
+// it is not and selecting another IP address is enabled, the first other IP
+// address reported by VMware Tools that is reachable is returned instead.
+func (s *StepWaitForIp) reachableIP(ctx context.Context, ui packersdk.Ui, vm *driver.VirtualMachineDriver, ip string) (string, error) {
+	ui.Sayf("Checking that IP address %s is reachable...", ip)
+	err := probeAddress(ctx, ip, s.Port, s.Config.ReachabilityTimeout)
+	if err == nil {
+		return ip, nil
+	}
+	log.Printf("[WARN] IP %s is not reachable: %s", ip, err)
+	if !s.Config.SelectReachableIP {
+		return "", fmt.Errorf("IP %s reported by Tools is not reachable from Packer host: %s; "+
+			"check the network of the virtual machine, or set 'ip_wait_address' or 'ip_select_reachable' "+
+			"to use another IP address", ip, err)
+	}
+
+	ips, err := vm.WaitForIPs(ctx, s.Config.ipFilter())
+	if err != nil {
+		return "", fmt.Errorf("error listing the IP addresses of the virtual machine: %s", err)
+	}
+	for _, other := range ips {
+		if other == ip {
+			continue
+		}
+		if err := probeAddress(ctx, other, s.Port, s.Config.ReachabilityTimeout); err != nil {
+			log.Printf("[WARN] IP %s is not reachable: %s", other, err)
+			continue
+		}
+		ui.Sayf("IP address %s is not reachable; using IP address %s instead.", ip, other)
+		return other, nil
+	}
+	return "", fmt.Errorf("IP %s reported by Tools is not reachable from Packer host, "+
+		"and none of the other reported IP addresses %v are reachable", ip, ips)
+}
+
+// probeAddress opens a TCP connection to the port of the IP address to check
+// that the address is reachable. A refused connection is a response from the
+// host, so the address is reachable even if the service on the port is not
+// ready yet.
+func probeAddress(ctx context.Context, ip string, port int, timeout time.Duration) error {
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil
+		}
+		return err
+	}
+	return conn.Close()
+}
+
 func (s *StepWaitForIp) Cleanup(state multistep.StateBag) {}
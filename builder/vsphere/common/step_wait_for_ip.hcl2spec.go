@@ -10,9 +10,15 @@ import (
 // FlatWaitIpConfig is an auto-generated flat version of WaitIpConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatWaitIpConfig struct {
-	WaitTimeout   *string `mapstructure:"ip_wait_timeout" cty:"ip_wait_timeout" hcl:"ip_wait_timeout"`
-	SettleTimeout *string `mapstructure:"ip_settle_timeout" cty:"ip_settle_timeout" hcl:"ip_settle_timeout"`
-	WaitAddress   *string `mapstructure:"ip_wait_address" cty:"ip_wait_address" hcl:"ip_wait_address"`
+	WaitTimeout         *string  `mapstructure:"ip_wait_timeout" cty:"ip_wait_timeout" hcl:"ip_wait_timeout"`
+	SettleTimeout       *string  `mapstructure:"ip_settle_timeout" cty:"ip_settle_timeout" hcl:"ip_settle_timeout"`
+	WaitAddress         *string  `mapstructure:"ip_wait_address" cty:"ip_wait_address" hcl:"ip_wait_address"`
+	IPVersion           *string  `mapstructure:"ip_version" cty:"ip_version" hcl:"ip_version"`
+	NICIndex            *int     `mapstructure:"ip_wait_nic_index" cty:"ip_wait_nic_index" hcl:"ip_wait_nic_index"`
+	ExcludeCIDRs        []string `mapstructure:"ip_wait_exclude_cidrs" cty:"ip_wait_exclude_cidrs" hcl:"ip_wait_exclude_cidrs"`
+	ReachabilityCheck   *bool    `mapstructure:"ip_reachability_check" cty:"ip_reachability_check" hcl:"ip_reachability_check"`
+	ReachabilityTimeout *string  `mapstructure:"ip_reachability_timeout" cty:"ip_reachability_timeout" hcl:"ip_reachability_timeout"`
+	SelectReachableIP   *bool    `mapstructure:"ip_select_reachable" cty:"ip_select_reachable" hcl:"ip_select_reachable"`
 }
 
 // FlatMapstructure returns a new FlatWaitIpConfig.
@@ -27,9 +33,15 @@ func (*WaitIpConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.
 // The decoded values from this spec will then be applied to a FlatWaitIpConfig.
 func (*FlatWaitIpConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"ip_wait_timeout":   &hcldec.AttrSpec{Name: "ip_wait_timeout", Type: cty.String, Required: false},
-		"ip_settle_timeout": &hcldec.AttrSpec{Name: "ip_settle_timeout", Type: cty.String, Required: false},
-		"ip_wait_address":   &hcldec.AttrSpec{Name: "ip_wait_address", Type: cty.String, Required: false},
+		"ip_wait_timeout":         &hcldec.AttrSpec{Name: "ip_wait_timeout", Type: cty.String, Required: false},
+		"ip_settle_timeout":       &hcldec.AttrSpec{Name: "ip_settle_timeout", Type: cty.String, Required: false},
+		"ip_wait_address":         &hcldec.AttrSpec{Name: "ip_wait_address", Type: cty.String, Required: false},
+		"ip_version":              &hcldec.AttrSpec{Name: "ip_version", Type: cty.String, Required: false},
+		"ip_wait_nic_index":       &hcldec.AttrSpec{Name: "ip_wait_nic_index", Type: cty.Number, Required: false},
+		"ip_wait_exclude_cidrs":   &hcldec.AttrSpec{Name: "ip_wait_exclude_cidrs", Type: cty.List(cty.String), Required: false},
+		"ip_reachability_check":   &hcldec.AttrSpec{Name: "ip_reachability_check", Type: cty.Bool, Required: false},
+		"ip_reachability_timeout": &hcldec.AttrSpec{Name: "ip_reachability_timeout", Type: cty.String, Required: false},
+		"ip_select_reachable":     &hcldec.AttrSpec{Name: "ip_select_reachable", Type: cty.Bool, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWaitIpConfig_Prepare(t *testing.T) {
+	index, negative := 1, -1
+	ipv4, ipv6 := "10.0.0.0/8", "2001:db8::/32"
+	tc := []struct {
+		name   string
+		config WaitIpConfig
+		fail   bool
+	}{
+		{
+			name: "Defaults",
+		},
+		{
+			name:   "Reachability check",
+			config: WaitIpConfig{ReachabilityCheck: true, SelectReachableIP: true},
+		},
+		{
+			name:   "Select reachable IP without reachability check",
+			config: WaitIpConfig{SelectReachableIP: true},
+			fail:   true,
+		},
+		{
+			name:   "Network adapter and excluded network ranges",
+			config: WaitIpConfig{NICIndex: &index, ExcludeCIDRs: []string{"172.17.0.0/16"}},
+		},
+		{
+			name:   "Negative network adapter index",
+			config: WaitIpConfig{NICIndex: &negative},
+			fail:   true,
+		},
+		{
+			name:   "IPv6",
+			config: WaitIpConfig{IPVersion: "6"},
+		},
+		{
+			name:   "IPv6 network range",
+			config: WaitIpConfig{WaitAddress: &ipv6},
+		},
+		{
+			name:   "IPv4 network range with IPv6",
+			config: WaitIpConfig{IPVersion: "6", WaitAddress: &ipv4},
+			fail:   true,
+		},
+		{
+			name:   "Invalid IP version",
+			config: WaitIpConfig{IPVersion: "5"},
+			fail:   true,
+		},
+		{
+			name:   "Invalid excluded network range",
+			config: WaitIpConfig{ExcludeCIDRs: []string{"172.17.0.0"}},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare()
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatalf("unexpected success: expected failure")
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+			if c.config.ReachabilityTimeout != 5*time.Second {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", 5*time.Second, c.config.ReachabilityTimeout)
+			}
+			if c.config.IPVersion == "" {
+				t.Fatal("unexpected empty IP version")
+			}
+			if len(c.config.ipFilter().Exclude) != len(c.config.ExcludeCIDRs) {
+				t.Fatalf("unexpected excluded network ranges: '%v'", c.config.ipFilter().Exclude)
+			}
+		})
+	}
+}
+
+func TestProbeAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if err := probeAddress(context.TODO(), "127.0.0.1", port, time.Second); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// A refused connection is a response from the host, which is reachable
+	// even if the communicator is not ready yet.
+	if err := listener.Close(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := probeAddress(context.TODO(), "127.0.0.1", port, time.Second); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// An address that does not respond before the probe is canceled is not
+	// reachable.
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if err := probeAddress(ctx, "127.0.0.1", port, time.Second); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type WaitForPortsConfig,PortCheckConfig
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// The interval at which the ports are checked until they are ready.
+var portCheckInterval = 5 * time.Second
+
+// The amount of time to wait for a single check of a port.
+var portCheckTimeout = 5 * time.Second
+
+// An RDP connection request with a negotiation request for TLS and CredSSP,
+// which is the first message of an RDP client.
+var rdpConnectionRequest = []byte{
+	// TPKT header, with the length of the request.
+	0x03, 0x00, 0x00, 0x13,
+	// X.224 connection request.
+	0x0e, 0xe0, 0x00, 0x00, 0x00, 0x00, 0x00,
+	// RDP negotiation request.
+	0x01, 0x00, 0x08, 0x00, 0x03, 0x00, 0x00, 0x00,
+}
+
+// Check that services of the virtual machine respond after the provisioning,
+// such as Remote Desktop, before the virtual machine is shut down and the
+// artifact is created. The ports are checked on the address of the
+// communicator until all of them are ready or the timeout expires, which
+// fails the build.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	wait_for_ports = [443]
+//
+//	wait_for_port {
+//	  port     = 3389
+//	  protocol = "rdp"
+//	}
+//
+//	wait_for_port {
+//	  port   = 22
+//	  banner = "^SSH-2\\.0-"
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"wait_for_ports": [443],
+//	"wait_for_port": [
+//	  {
+//	    "port": 3389,
+//	    "protocol": "rdp"
+//	  },
+//	  {
+//	    "port": 22,
+//	    "banner": "^SSH-2\\.0-"
+//	  }
+//	],
+//
+// ```
+type WaitForPortsConfig struct {
+	// The TCP ports that must accept connections.
+	WaitForPorts []int `mapstructure:"wait_for_ports"`
+	// The ports that must respond to a protocol. Refer to the
+	// [Port Check Configuration](#port-check-configuration) section for
+	// additional information.
+	WaitForPortChecks []PortCheckConfig `mapstructure:"wait_for_port"`
+	// The amount of time to wait for the ports to be ready. Defaults to `5m`.
+	WaitForPortsTimeout time.Duration `mapstructure:"wait_for_ports_timeout"`
+}
+
+// A port of the virtual machine that must respond to a protocol.
+type PortCheckConfig struct {
+	// The TCP port.
+	Port int `mapstructure:"port" required:"true"`
+	// The protocol that the service must respond to. The available options
+	// are `tcp`, which requires that the port accepts connections, `rdp`,
+	// which requires that the Remote Desktop service responds to a connection
+	// request, and `tls`, which requires that the service completes a TLS
+	// handshake. The certificate of the service is not verified. Defaults to
+	// `tcp`.
+	Protocol string `mapstructure:"protocol"`
+	// A regular expression that the banner of the service must match, which
+	// is the data, up to 1024 bytes, that the service sends when a connection
+	// is established, such as `^SSH-2\\.0-` or `^220 `. Requires the `tcp`
+	// protocol.
+	Banner string `mapstructure:"banner"`
+
+	banner *regexp.Regexp
+}
+
+func (c *WaitForPortsConfig) Prepare(comm communicator.Config) []error {
+	var errs []error
+
+	if len(c.WaitForPorts) == 0 && len(c.WaitForPortChecks) == 0 {
+		return nil
+	}
+	if comm.Type == "none" {
+		errs = append(errs, fmt.Errorf("'wait_for_ports' and 'wait_for_port' require a communicator"))
+	}
+
+	for i, port := range c.WaitForPorts {
+		if port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("wait_for_ports[%d] must be between 1 and 65535", i))
+		}
+	}
+	for i := range c.WaitForPortChecks {
+		check := &c.WaitForPortChecks[i]
+		if check.Port < 1 || check.Port > 65535 {
+			errs = append(errs, fmt.Errorf("wait_for_port[%d].'port' must be between 1 and 65535", i))
+		}
+		if check.Protocol == "" {
+			check.Protocol = "tcp"
+		}
+		switch check.Protocol {
+		case "tcp":
+		case "rdp", "tls":
+			if check.Banner != "" {
+				errs = append(errs, fmt.Errorf("wait_for_port[%d].'banner' requires the 'tcp' protocol", i))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("wait_for_port[%d].'protocol' must be one of 'tcp', 'rdp', or 'tls'", i))
+		}
+		if check.Banner != "" {
+			var err error
+			if check.banner, err = regexp.Compile(check.Banner); err != nil {
+				errs = append(errs, fmt.Errorf("wait_for_port[%d].'banner' is not a valid regular expression: %s", i, err))
+			}
+		}
+	}
+
+	if c.WaitForPortsTimeout < 0 {
+		errs = append(errs, fmt.Errorf("'wait_for_ports_timeout' must be greater than or equal to 0"))
+	}
+	if c.WaitForPortsTimeout == 0 {
+		c.WaitForPortsTimeout = 5 * time.Minute
+	}
+
+	return errs
+}
+
+// checks returns the checks of the ports, with the TCP ports first.
+func (c *WaitForPortsConfig) checks() []PortCheckConfig {
+	var checks []PortCheckConfig
+	for _, port := range c.WaitForPorts {
+		checks = append(checks, PortCheckConfig{Port: port, Protocol: "tcp"})
+	}
+	return append(checks, c.WaitForPortChecks...)
+}
+
+func (c *PortCheckConfig) String() string {
+	return fmt.Sprintf("%d/%s", c.Port, c.Protocol)
+}
+
+// check connects to the port of the host and checks that the service
+// responds to the protocol.
+func (c *PortCheckConfig) check(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, portCheckTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(c.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	switch c.Protocol {
+	case "rdp":
+		return checkRDP(conn)
+	case "tls":
+		// Only the handshake is checked, since the certificate of a service
+		// of an image is commonly self-signed.
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true}) //nolint:gosec
+		return tlsConn.HandshakeContext(ctx)
+	}
+	if c.banner != nil {
+		return checkBanner(conn, c.banner)
+	}
+	return nil
+}
+
+// checkRDP sends a connection request and checks that the response is an
+// RDP connection confirm.
+func checkRDP(conn net.Conn) error {
+	if _, err := conn.Write(rdpConnectionRequest); err != nil {
+		return err
+	}
+	res := make([]byte, 6)
+	if _, err := io.ReadFull(conn, res); err != nil {
+		return fmt.Errorf("no response to the RDP connection request: %s", err)
+	}
+	if res[0] != 0x03 || res[5]&0xf0 != 0xd0 {
+		return fmt.Errorf("unexpected response to the RDP connection request")
+	}
+	return nil
+}
+
+// checkBanner reads the banner of the service and checks that it matches the
+// regular expression.
+func checkBanner(conn net.Conn, re *regexp.Regexp) error {
+	buf := make([]byte, 1024)
+	var n int
+	for n < len(buf) {
+		m, err := conn.Read(buf[n:])
+		n += m
+		if re.Match(buf[:n]) {
+			return nil
+		}
+		if err != nil {
+			break
+		}
+	}
+	banner := strings.TrimSpace(string(bytes.ToValidUTF8(buf[:n], nil)))
+	return fmt.Errorf("banner %q does not match %q", banner, re)
+}
+
+type StepWaitForPorts struct {
+	Config *WaitForPortsConfig
+	// The address of the communicator.
+	Host func(multistep.StateBag) (string, error)
+}
+
+func (s *StepWaitForPorts) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	checks := s.Config.checks()
+	if len(checks) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	host, err := s.Host(state)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	var names []string
+	for _, c := range checks {
+		names = append(names, c.String())
+	}
+	ui.Sayf("Waiting for ports %s on %s to be ready...", strings.Join(names, ", "), host)
+
+	if err := waitForPorts(ctx, host, checks, s.Config.WaitForPortsTimeout); err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	ui.Say("Ports are ready.")
+	return multistep.ActionContinue
+}
+
+// waitForPorts checks the ports until all of them are ready, and returns an
+// error with the last failure of each port that is not ready if the timeout
+// expires first.
+func waitForPorts(ctx context.Context, host string, checks []PortCheckConfig, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	pending := checks
+	for {
+		var failed []PortCheckConfig
+		var failures []string
+		for _, c := range pending {
+			if err := c.check(ctx, host); err != nil {
+				failed = append(failed, c)
+				failures = append(failures, fmt.Sprintf("%s (%s)", c.String(), err))
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		pending = failed
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("ports not ready after %s: %s", timeout, strings.Join(failures, ", "))
+		case <-time.After(portCheckInterval):
+		}
+	}
+}
+
+func (s *StepWaitForPorts) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+// listen starts a TCP service on the loopback address that handles each
+// connection, and returns its port.
+func listen(t *testing.T, handle func(net.Conn)) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a port on the loopback address that refuses connections.
+func closedPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestWaitForPortsConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name           string
+		config         WaitForPortsConfig
+		comm           communicator.Config
+		fail           bool
+		expectedErrMsg string
+	}{
+		{
+			name: "Valid",
+			config: WaitForPortsConfig{
+				WaitForPorts:      []int{443},
+				WaitForPortChecks: []PortCheckConfig{{Port: 3389, Protocol: "rdp"}, {Port: 22, Banner: "^SSH-"}},
+			},
+			comm: communicator.Config{Type: "ssh"},
+		},
+		{
+			name:           "No communicator",
+			config:         WaitForPortsConfig{WaitForPorts: []int{3389}},
+			comm:           communicator.Config{Type: "none"},
+			fail:           true,
+			expectedErrMsg: "'wait_for_ports' and 'wait_for_port' require a communicator",
+		},
+		{
+			name:           "Invalid port",
+			config:         WaitForPortsConfig{WaitForPorts: []int{65536}},
+			comm:           communicator.Config{Type: "ssh"},
+			fail:           true,
+			expectedErrMsg: "wait_for_ports[0] must be between 1 and 65535",
+		},
+		{
+			name:           "Invalid protocol",
+			config:         WaitForPortsConfig{WaitForPortChecks: []PortCheckConfig{{Port: 80, Protocol: "http"}}},
+			comm:           communicator.Config{Type: "ssh"},
+			fail:           true,
+			expectedErrMsg: "wait_for_port[0].'protocol' must be one of 'tcp', 'rdp', or 'tls'",
+		},
+		{
+			name:           "Banner with RDP",
+			config:         WaitForPortsConfig{WaitForPortChecks: []PortCheckConfig{{Port: 3389, Protocol: "rdp", Banner: "RDP"}}},
+			comm:           communicator.Config{Type: "ssh"},
+			fail:           true,
+			expectedErrMsg: "wait_for_port[0].'banner' requires the 'tcp' protocol",
+		},
+		{
+			name:           "Invalid banner",
+			config:         WaitForPortsConfig{WaitForPortChecks: []PortCheckConfig{{Port: 22, Banner: "("}}},
+			comm:           communicator.Config{Type: "ssh"},
+			fail:           true,
+			expectedErrMsg: "wait_for_port[0].'banner' is not a valid regular expression",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(c.comm)
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatal("unexpected success: expected failure")
+				}
+				if !strings.Contains(errs[0].Error(), c.expectedErrMsg) {
+					t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErrMsg, errs[0])
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+			if c.config.WaitForPortsTimeout != 5*time.Minute {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", 5*time.Minute, c.config.WaitForPortsTimeout)
+			}
+		})
+	}
+}
+
+func TestPortCheckConfig_check(t *testing.T) {
+	tcpPort := listen(t, func(net.Conn) {})
+	sshPort := listen(t, func(conn net.Conn) {
+		_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+	})
+	rdpPort := listen(t, func(conn net.Conn) {
+		req := make([]byte, len(rdpConnectionRequest))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{0x03, 0x00, 0x00, 0x13, 0x0e, 0xd0, 0x00, 0x00, 0x12, 0x34, 0x00, 0x02, 0x00, 0x08, 0x00, 0x02, 0x00, 0x00, 0x00})
+	})
+	tlsServer := httptest.NewTLSServer(nil)
+	defer tlsServer.Close()
+	tlsPort := tlsServer.Listener.Addr().(*net.TCPAddr).Port
+
+	tc := []struct {
+		name  string
+		check PortCheckConfig
+		fail  bool
+	}{
+		{name: "TCP", check: PortCheckConfig{Port: tcpPort, Protocol: "tcp"}},
+		{name: "TCP refused", check: PortCheckConfig{Port: closedPort(t), Protocol: "tcp"}, fail: true},
+		{name: "Banner", check: PortCheckConfig{Port: sshPort, Protocol: "tcp", Banner: `^SSH-2\.0-`}},
+		{name: "Banner mismatch", check: PortCheckConfig{Port: sshPort, Protocol: "tcp", Banner: `^220 `}, fail: true},
+		{name: "RDP", check: PortCheckConfig{Port: rdpPort, Protocol: "rdp"}},
+		{name: "RDP not RDP", check: PortCheckConfig{Port: sshPort, Protocol: "rdp"}, fail: true},
+		{name: "TLS", check: PortCheckConfig{Port: tlsPort, Protocol: "tls"}},
+		{name: "TLS not TLS", check: PortCheckConfig{Port: rdpPort, Protocol: "tls"}, fail: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			config := WaitForPortsConfig{WaitForPortChecks: []PortCheckConfig{c.check}}
+			if errs := config.Prepare(communicator.Config{Type: "ssh"}); len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+			check := config.checks()[0]
+			err := check.check(context.Background(), "127.0.0.1")
+			if c.fail {
+				if err == nil {
+					t.Fatal("unexpected success: expected failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+}
+
+func TestStepWaitForPorts_Run(t *testing.T) {
+	defer func(interval time.Duration) { portCheckInterval = interval }(portCheckInterval)
+	portCheckInterval = 10 * time.Millisecond
+
+	ready := listen(t, func(net.Conn) {})
+	host := func(multistep.StateBag) (string, error) { return "127.0.0.1", nil }
+
+	state := basicStateBag(nil)
+	step := &StepWaitForPorts{
+		Config: &WaitForPortsConfig{WaitForPorts: []int{ready}, WaitForPortsTimeout: time.Second},
+		Host:   host,
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%s'", state.Get("error"))
+	}
+
+	closed := closedPort(t)
+	state = basicStateBag(&strings.Builder{})
+	step.Config = &WaitForPortsConfig{WaitForPorts: []int{ready, closed}, WaitForPortsTimeout: 50 * time.Millisecond}
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatal("unexpected success: expected failure")
+	}
+	err := state.Get("error").(error)
+	expected := "ports not ready after 50ms: " + strconv.Itoa(closed) + "/tcp"
+	if !strings.HasPrefix(err.Error(), expected) {
+		t.Fatalf("unexpected error: expected '%s', but returned '%s'", expected, err)
+	}
+}
@@ -2,12 +2,30 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type StorageConfig,DiskConfig
+//go:generate packer-sdc mapstructure-to-hcl2 -type StorageConfig,DiskConfig,FirstClassDiskConfig
 
 package common
 
 import (
 	"fmt"
+	"path"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The number of NVMe controllers of a virtual machine and the number of disks
+// of an NVMe controller.
+const (
+	maxNVMeControllers = 4
+	maxNVMeDisks       = 15
+)
+
+// The values of the `disk_sharing` option.
+const (
+	diskSharingNone        = "none"
+	diskSharingMultiWriter = "multi-writer"
 )
 
 // The following example that will create a 15GB and a 20GB disk on the virtual
@@ -107,6 +125,89 @@ type DiskConfig struct {
 	// The assigned disk controller for the disk.
 	// Defaults to the first controller, `(0)`.
 	DiskControllerIndex int `mapstructure:"disk_controller_index"`
+	// The datastore path of the virtual disk file. For example,
+	// `[datastore1] packer-disks/cache.vmdk`. The directory is created if it
+	// does not exist. Required when `disk_keep_on_destroy` or
+	// `disk_reuse_existing` is set. Defaults to a path in the virtual machine
+	// directory.
+	DiskPath string `mapstructure:"disk_path"`
+	// Detach and preserve the virtual disk file when the virtual machine is
+	// destroyed, for example when the build fails or is cancelled, or when
+	// `destroy` is set for the content library import. Requires `disk_path`.
+	// Defaults to `false`.
+	DiskKeepOnDestroy bool `mapstructure:"disk_keep_on_destroy"`
+	// Attach the existing virtual disk file at `disk_path` instead of creating
+	// a new disk. If the file does not exist, a new disk is created. Use with
+	// `disk_keep_on_destroy` to reuse a disk populated by a previous build.
+	// Requires `disk_path`. Defaults to `false`.
+	DiskReuseExisting bool `mapstructure:"disk_reuse_existing"`
+	// The name of the VM storage policy to apply to the disk, such as a vSAN
+	// storage policy. Defaults to the `storage_policy` of the virtual
+	// machine.
+	DiskStoragePolicy string `mapstructure:"disk_storage_policy"`
+	// The disk mode. One of `persistent`, `independent_persistent`, or
+	// `independent_nonpersistent`. Independent disks are not included in
+	// snapshots, and changes to an `independent_nonpersistent` disk are
+	// discarded when the virtual machine is powered off. Defaults to
+	// `persistent`.
+	DiskMode string `mapstructure:"disk_mode"`
+	// The sharing mode of the disk. One of `none` or `multi-writer`. A
+	// `multi-writer` disk can be opened by multiple virtual machines at the
+	// same time, as required by clustered applications such as Oracle RAC,
+	// and must be eagerly scrubbed and not thin provisioned. Defaults to
+	// `none`.
+	//
+	// -> **Note:** Snapshots are not supported for virtual machines with
+	// `multi-writer` disks. Do not use with `create_snapshot`, `enable_cbt`,
+	// or `linked_clone`.
+	DiskSharing string `mapstructure:"disk_sharing"`
+}
+
+// The following example attaches an existing First Class Disk, also known as
+// an improved virtual disk, to the virtual machine during the build and
+// detaches it before the virtual machine is converted to a template:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	first_class_disk {
+//	    id                     = "6ea4d2f5-3a53-4f8a-8b0b-1a3d7e6c4f21"
+//	    datastore              = "datastore1"
+//	    detach_before_template = true
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"first_class_disk": [
+//	  {
+//	    "id": "6ea4d2f5-3a53-4f8a-8b0b-1a3d7e6c4f21",
+//	    "datastore": "datastore1",
+//	    "detach_before_template": true
+//	  }
+//	],
+//
+// ```
+type FirstClassDiskConfig struct {
+	// The identifier of the First Class Disk.
+	ID string `mapstructure:"id" required:"true"`
+	// The name of the datastore where the First Class Disk is located.
+	Datastore string `mapstructure:"datastore" required:"true"`
+	// The disk controller of the virtual machine to attach the disk to.
+	// Defaults to the first controller, `(0)`.
+	DiskControllerIndex int `mapstructure:"disk_controller_index"`
+	// Detach the disk after the virtual machine is shut down and before a
+	// snapshot is created, the virtual machine is converted to a template,
+	// imported to a content library, or exported. Defaults to `false`.
+	//
+	// -> **Note:** The disk is always detached if the build fails or is
+	// cancelled, or if the virtual machine is destroyed, to prevent the disk
+	// from being deleted with the virtual machine.
+	DetachBeforeTemplate bool `mapstructure:"detach_before_template"`
 }
 
 type StorageConfig struct {
@@ -115,16 +216,51 @@ type StorageConfig struct {
 	// additional controllers. Refer to [SCSI, SATA, and NVMe Storage Controller
 	// Conditions, Limitations, and Compatibility](https://techdocs.broadcom.com/us/en/vmware-cis/vsphere/vsphere/8-0/vsphere-virtual-machine-administration-guide-8-0/configuring-virtual-machine-hardwarevsphere-vm-admin/scsi-controller-configurationvsphere-vm-admin.html)
 	// for additional information.
+	//
+	// A virtual machine supports up to 4 `nvme` controllers with up to 15
+	// disks each. For the `vsphere-iso` builder, the first disk of `storage`
+	// is the boot disk, which the `disk` boot device boots from first,
+	// followed by the other disks of the controllers of the same type.
 	DiskControllerType []string `mapstructure:"disk_controller_type"`
 	// A collection of one or more disks to be provisioned.
 	// Refer to the [Storage Configuration](#storage-configuration) section for additional information.
 	Storage []DiskConfig `mapstructure:"storage"`
+	// A collection of one or more existing First Class Disks to attach to the
+	// virtual machine. Refer to the [First Class Disk Configuration](#first-class-disk-configuration)
+	// section for additional information.
+	FirstClassDisks []FirstClassDiskConfig `mapstructure:"first_class_disk"`
+	// The name of the VM storage policy to apply to the home of the virtual
+	// machine and to the disks without a `disk_storage_policy`, such as a
+	// vSAN storage policy. For the `vsphere-clone` builder, the policy is also
+	// applied to the disks of the source. Defaults to the default storage
+	// policy of the datastore.
+	StoragePolicy string `mapstructure:"storage_policy"`
 }
 
 func (c *StorageConfig) Prepare() []error {
 	var errs []error
 
+	var nvmeControllers int
+	for _, controllerType := range c.DiskControllerType {
+		if controllerType == "nvme" {
+			nvmeControllers++
+		}
+	}
+	if nvmeControllers > maxNVMeControllers {
+		errs = append(errs, fmt.Errorf("'disk_controller_type' supports up to %d 'nvme' controllers", maxNVMeControllers))
+	}
+
 	if len(c.Storage) > 0 {
+		disks := make(map[int]int)
+		for _, storage := range c.Storage {
+			disks[storage.DiskControllerIndex]++
+		}
+		for i, controllerType := range c.DiskControllerType {
+			if controllerType == "nvme" && disks[i] > maxNVMeDisks {
+				errs = append(errs, fmt.Errorf("disk_controller_type[%d] supports up to %d disks", i, maxNVMeDisks))
+			}
+		}
+
 		for i, storage := range c.Storage {
 			if storage.DiskSize == 0 {
 				errs = append(errs, fmt.Errorf("storage[%d].'disk_size' is required", i))
@@ -132,8 +268,145 @@ func (c *StorageConfig) Prepare() []error {
 			if storage.DiskControllerIndex >= len(c.DiskControllerType) {
 				errs = append(errs, fmt.Errorf("storage[%d].'disk_controller_index' references an unknown disk controller", i))
 			}
+			switch storage.DiskMode {
+			case "", string(types.VirtualDiskModePersistent), string(types.VirtualDiskModeIndependent_persistent), string(types.VirtualDiskModeIndependent_nonpersistent):
+			default:
+				errs = append(errs, fmt.Errorf("storage[%d].'disk_mode' must be one of 'persistent', 'independent_persistent', or 'independent_nonpersistent'", i))
+			}
+			switch storage.DiskSharing {
+			case "", diskSharingNone:
+			case diskSharingMultiWriter:
+				if storage.DiskThinProvisioned {
+					errs = append(errs, fmt.Errorf("storage[%d].'disk_thin_provisioned' cannot be used with 'disk_sharing' set to 'multi-writer'", i))
+				}
+				if !storage.DiskEagerlyScrub && !storage.DiskReuseExisting {
+					errs = append(errs, fmt.Errorf("storage[%d].'disk_eagerly_scrub' is required when 'disk_sharing' is set to 'multi-writer'", i))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("storage[%d].'disk_sharing' must be one of 'none' or 'multi-writer'", i))
+			}
+			if storage.DiskPath == "" {
+				if storage.DiskKeepOnDestroy {
+					errs = append(errs, fmt.Errorf("storage[%d].'disk_path' is required when 'disk_keep_on_destroy' is set", i))
+				}
+				if storage.DiskReuseExisting {
+					errs = append(errs, fmt.Errorf("storage[%d].'disk_path' is required when 'disk_reuse_existing' is set", i))
+				}
+			} else {
+				var dsPath object.DatastorePath
+				if !dsPath.FromString(storage.DiskPath) || dsPath.Path == "" {
+					errs = append(errs, fmt.Errorf("storage[%d].'disk_path' must be a datastore path, for example '[datastore1] packer-disks/disk.vmdk'", i))
+				}
+			}
+		}
+	}
+
+	for i, disk := range c.FirstClassDisks {
+		if disk.ID == "" {
+			errs = append(errs, fmt.Errorf("first_class_disk[%d].'id' is required", i))
+		}
+		if disk.Datastore == "" {
+			errs = append(errs, fmt.Errorf("first_class_disk[%d].'datastore' is required", i))
+		}
+		if disk.DiskControllerIndex < 0 {
+			errs = append(errs, fmt.Errorf("first_class_disk[%d].'disk_controller_index' must not be negative", i))
 		}
 	}
 
 	return errs
 }
+
+// Disks returns the disks to add to the virtual machine. The directories of
+// disks with a `disk_path` are created as needed, and disks with
+// `disk_reuse_existing` are attached if the virtual disk file exists.
+func (c *StorageConfig) Disks(d driver.Driver, host string) ([]driver.Disk, error) {
+	var disks []driver.Disk
+	policies := make(map[string]string)
+	for _, disk := range c.Storage {
+		dd := driver.Disk{
+			DiskSize:            disk.DiskSize,
+			DiskEagerlyScrub:    disk.DiskEagerlyScrub,
+			DiskThinProvisioned: disk.DiskThinProvisioned,
+			ControllerIndex:     disk.DiskControllerIndex,
+			FileName:            disk.DiskPath,
+			DiskMode:            disk.DiskMode,
+		}
+		switch disk.DiskSharing {
+		case diskSharingNone:
+			dd.DiskSharing = string(types.VirtualDiskSharingSharingNone)
+		case diskSharingMultiWriter:
+			dd.DiskSharing = string(types.VirtualDiskSharingSharingMultiWriter)
+		}
+
+		if name := disk.DiskStoragePolicy; name != "" {
+			if _, ok := policies[name]; !ok {
+				id, err := d.FindStoragePolicy(name)
+				if err != nil {
+					return nil, err
+				}
+				policies[name] = id
+			}
+			dd.StoragePolicyID = policies[name]
+		}
+
+		if disk.DiskPath != "" {
+			var dsPath object.DatastorePath
+			dsPath.FromString(disk.DiskPath)
+			ds, err := d.FindDatastore(dsPath.Datastore, host)
+			if err != nil {
+				return nil, fmt.Errorf("error finding the datastore for disk %s: %s", disk.DiskPath, err)
+			}
+
+			if ds.FileExists(dsPath.Path) {
+				if !disk.DiskReuseExisting {
+					return nil, fmt.Errorf("disk %s already exists, set 'disk_reuse_existing' to attach it", disk.DiskPath)
+				}
+				dd.AttachExisting = true
+			} else if dir := path.Dir(dsPath.Path); dir != "." && !ds.DirExists(dir) {
+				if err := ds.MakeDirectory(fmt.Sprintf("[%s] %s", dsPath.Datastore, dir)); err != nil {
+					return nil, fmt.Errorf("error creating the directory for disk %s: %s", disk.DiskPath, err)
+				}
+			}
+		}
+
+		disks = append(disks, dd)
+	}
+	return disks, nil
+}
+
+// StoragePolicyID returns the identifier of the storage policy of the virtual
+// machine, or an empty string if `storage_policy` is not set.
+func (c *StorageConfig) StoragePolicyID(d driver.Driver) (string, error) {
+	if c.StoragePolicy == "" {
+		return "", nil
+	}
+	return d.FindStoragePolicy(c.StoragePolicy)
+}
+
+// BootDiskControllerType returns the type of the controller of the boot disk,
+// which is the first disk, or an empty string if there are no disks.
+func (c *StorageConfig) BootDiskControllerType() string {
+	if len(c.Storage) == 0 {
+		return ""
+	}
+	i := c.Storage[0].DiskControllerIndex
+	if i < 0 || i >= len(c.DiskControllerType) {
+		return ""
+	}
+	if c.DiskControllerType[i] == "" {
+		return "lsilogic"
+	}
+	return c.DiskControllerType[i]
+}
+
+// KeepOnDestroy returns the datastore paths of the disks to preserve when the
+// virtual machine is destroyed.
+func (c *StorageConfig) KeepOnDestroy() []string {
+	var paths []string
+	for _, disk := range c.Storage {
+		if disk.DiskKeepOnDestroy {
+			paths = append(paths, disk.DiskPath)
+		}
+	}
+	return paths
+}
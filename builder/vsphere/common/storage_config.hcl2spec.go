@@ -10,10 +10,16 @@ import (
 // FlatDiskConfig is an auto-generated flat version of DiskConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatDiskConfig struct {
-	DiskSize            *int64 `mapstructure:"disk_size" required:"true" cty:"disk_size" hcl:"disk_size"`
-	DiskThinProvisioned *bool  `mapstructure:"disk_thin_provisioned" cty:"disk_thin_provisioned" hcl:"disk_thin_provisioned"`
-	DiskEagerlyScrub    *bool  `mapstructure:"disk_eagerly_scrub" cty:"disk_eagerly_scrub" hcl:"disk_eagerly_scrub"`
-	DiskControllerIndex *int   `mapstructure:"disk_controller_index" cty:"disk_controller_index" hcl:"disk_controller_index"`
+	DiskSize            *int64  `mapstructure:"disk_size" required:"true" cty:"disk_size" hcl:"disk_size"`
+	DiskThinProvisioned *bool   `mapstructure:"disk_thin_provisioned" cty:"disk_thin_provisioned" hcl:"disk_thin_provisioned"`
+	DiskEagerlyScrub    *bool   `mapstructure:"disk_eagerly_scrub" cty:"disk_eagerly_scrub" hcl:"disk_eagerly_scrub"`
+	DiskControllerIndex *int    `mapstructure:"disk_controller_index" cty:"disk_controller_index" hcl:"disk_controller_index"`
+	DiskPath            *string `mapstructure:"disk_path" cty:"disk_path" hcl:"disk_path"`
+	DiskKeepOnDestroy   *bool   `mapstructure:"disk_keep_on_destroy" cty:"disk_keep_on_destroy" hcl:"disk_keep_on_destroy"`
+	DiskReuseExisting   *bool   `mapstructure:"disk_reuse_existing" cty:"disk_reuse_existing" hcl:"disk_reuse_existing"`
+	DiskStoragePolicy   *string `mapstructure:"disk_storage_policy" cty:"disk_storage_policy" hcl:"disk_storage_policy"`
+	DiskMode            *string `mapstructure:"disk_mode" cty:"disk_mode" hcl:"disk_mode"`
+	DiskSharing         *string `mapstructure:"disk_sharing" cty:"disk_sharing" hcl:"disk_sharing"`
 }
 
 // FlatMapstructure returns a new FlatDiskConfig.
@@ -32,6 +38,41 @@ func (*FlatDiskConfig) HCL2Spec() map[string]hcldec.Spec {
 		"disk_thin_provisioned": &hcldec.AttrSpec{Name: "disk_thin_provisioned", Type: cty.Bool, Required: false},
 		"disk_eagerly_scrub":    &hcldec.AttrSpec{Name: "disk_eagerly_scrub", Type: cty.Bool, Required: false},
 		"disk_controller_index": &hcldec.AttrSpec{Name: "disk_controller_index", Type: cty.Number, Required: false},
+		"disk_path":             &hcldec.AttrSpec{Name: "disk_path", Type: cty.String, Required: false},
+		"disk_keep_on_destroy":  &hcldec.AttrSpec{Name: "disk_keep_on_destroy", Type: cty.Bool, Required: false},
+		"disk_reuse_existing":   &hcldec.AttrSpec{Name: "disk_reuse_existing", Type: cty.Bool, Required: false},
+		"disk_storage_policy":   &hcldec.AttrSpec{Name: "disk_storage_policy", Type: cty.String, Required: false},
+		"disk_mode":             &hcldec.AttrSpec{Name: "disk_mode", Type: cty.String, Required: false},
+		"disk_sharing":          &hcldec.AttrSpec{Name: "disk_sharing", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatFirstClassDiskConfig is an auto-generated flat version of FirstClassDiskConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatFirstClassDiskConfig struct {
+	ID                   *string `mapstructure:"id" required:"true" cty:"id" hcl:"id"`
+	Datastore            *string `mapstructure:"datastore" required:"true" cty:"datastore" hcl:"datastore"`
+	DiskControllerIndex  *int    `mapstructure:"disk_controller_index" cty:"disk_controller_index" hcl:"disk_controller_index"`
+	DetachBeforeTemplate *bool   `mapstructure:"detach_before_template" cty:"detach_before_template" hcl:"detach_before_template"`
+}
+
+// FlatMapstructure returns a new FlatFirstClassDiskConfig.
+// FlatFirstClassDiskConfig is an auto-generated flat version of FirstClassDiskConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*FirstClassDiskConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatFirstClassDiskConfig)
+}
+
+// HCL2Spec returns the hcl spec of a FirstClassDiskConfig.
+// This spec is used by HCL to read the fields of FirstClassDiskConfig.
+// The decoded values from this spec will then be applied to a FlatFirstClassDiskConfig.
+func (*FlatFirstClassDiskConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"id":                     &hcldec.AttrSpec{Name: "id", Type: cty.String, Required: false},
+		"datastore":              &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
+		"disk_controller_index":  &hcldec.AttrSpec{Name: "disk_controller_index", Type: cty.Number, Required: false},
+		"detach_before_template": &hcldec.AttrSpec{Name: "detach_before_template", Type: cty.Bool, Required: false},
 	}
 	return s
 }
@@ -39,8 +80,10 @@ func (*FlatDiskConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatStorageConfig is an auto-generated flat version of StorageConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatStorageConfig struct {
-	DiskControllerType []string         `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
-	Storage            []FlatDiskConfig `mapstructure:"storage" cty:"storage" hcl:"storage"`
+	DiskControllerType []string                   `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
+	Storage            []FlatDiskConfig           `mapstructure:"storage" cty:"storage" hcl:"storage"`
+	FirstClassDisks    []FlatFirstClassDiskConfig `mapstructure:"first_class_disk" cty:"first_class_disk" hcl:"first_class_disk"`
+	StoragePolicy      *string                    `mapstructure:"storage_policy" cty:"storage_policy" hcl:"storage_policy"`
 }
 
 // FlatMapstructure returns a new FlatStorageConfig.
@@ -57,6 +100,8 @@ func (*FlatStorageConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
 		"disk_controller_type": &hcldec.AttrSpec{Name: "disk_controller_type", Type: cty.List(cty.String), Required: false},
 		"storage":              &hcldec.BlockListSpec{TypeName: "storage", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"first_class_disk":     &hcldec.BlockListSpec{TypeName: "first_class_disk", Nested: hcldec.ObjectSpec((*FlatFirstClassDiskConfig)(nil).HCL2Spec())},
+		"storage_policy":       &hcldec.AttrSpec{Name: "storage_policy", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStorageConfig_StoragePolicies(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"pvscsi"},
+		Storage: []DiskConfig{
+			{DiskSize: 1024},
+			{DiskSize: 2048, DiskStoragePolicy: "gold"},
+			{DiskSize: 4096, DiskStoragePolicy: "gold"},
+		},
+		StoragePolicy: "silver",
+	}
+	d := &driver.DriverMock{
+		FindStoragePolicyResult: map[string]string{"gold": "gold-id", "silver": "silver-id"},
+	}
+
+	disks, err := config.Disks(d, "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var ids []string
+	for _, disk := range disks {
+		ids = append(ids, disk.StoragePolicyID)
+	}
+	// Disks without a storage policy use the storage policy of the virtual
+	// machine, which is applied by the driver.
+	if diff := cmp.Diff([]string{"", "gold-id", "gold-id"}, ids); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+
+	id, err := config.StoragePolicyID(d)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if id != "silver-id" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "silver-id", id)
+	}
+	if diff := cmp.Diff([]string{"gold", "silver"}, d.FindStoragePolicyNames); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+}
+
+func TestStorageConfig_PrepareNVMe(t *testing.T) {
+	tc := []struct {
+		name  string
+		types []string
+		disks int
+		fail  bool
+	}{
+		{
+			name:  "Maximum controllers and disks",
+			types: []string{"nvme", "nvme", "nvme", "nvme"},
+			disks: 15,
+		},
+		{
+			name:  "Too many controllers",
+			types: []string{"nvme", "nvme", "nvme", "nvme", "nvme"},
+			disks: 1,
+			fail:  true,
+		},
+		{
+			name:  "Too many disks",
+			types: []string{"nvme"},
+			disks: 16,
+			fail:  true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			config := &StorageConfig{DiskControllerType: c.types}
+			for i := 0; i < c.disks; i++ {
+				config.Storage = append(config.Storage, DiskConfig{DiskSize: 1024})
+			}
+			errs := config.Prepare()
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected error: '%s'", errs[0])
+			}
+		})
+	}
+}
+
+func TestStorageConfig_BootDiskControllerType(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"pvscsi", "nvme"},
+		Storage:            []DiskConfig{{DiskSize: 1024, DiskControllerIndex: 1}, {DiskSize: 1024}},
+	}
+	if controllerType := config.BootDiskControllerType(); controllerType != "nvme" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "nvme", controllerType)
+	}
+
+	config = &StorageConfig{DiskControllerType: []string{""}, Storage: []DiskConfig{{DiskSize: 1024}}}
+	if controllerType := config.BootDiskControllerType(); controllerType != "lsilogic" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "lsilogic", controllerType)
+	}
+}
+
+func TestStorageConfig_PrepareDiskModeAndSharing(t *testing.T) {
+	tc := []struct {
+		name string
+		disk DiskConfig
+		fail bool
+	}{
+		{
+			name: "Independent persistent disk",
+			disk: DiskConfig{DiskSize: 1024, DiskMode: "independent_persistent"},
+		},
+		{
+			name: "Invalid disk mode",
+			disk: DiskConfig{DiskSize: 1024, DiskMode: "undoable"},
+			fail: true,
+		},
+		{
+			name: "Multi-writer disk",
+			disk: DiskConfig{DiskSize: 1024, DiskEagerlyScrub: true, DiskSharing: "multi-writer"},
+		},
+		{
+			name: "Multi-writer thin provisioned disk",
+			disk: DiskConfig{DiskSize: 1024, DiskEagerlyScrub: true, DiskThinProvisioned: true, DiskSharing: "multi-writer"},
+			fail: true,
+		},
+		{
+			name: "Multi-writer lazily zeroed disk",
+			disk: DiskConfig{DiskSize: 1024, DiskSharing: "multi-writer"},
+			fail: true,
+		},
+		{
+			name: "Invalid sharing mode",
+			disk: DiskConfig{DiskSize: 1024, DiskSharing: "sharingMultiWriter"},
+			fail: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			config := &StorageConfig{DiskControllerType: []string{""}, Storage: []DiskConfig{c.disk}}
+			errs := config.Prepare()
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+		})
+	}
+}
+
+func TestStorageConfig_DisksSharing(t *testing.T) {
+	config := &StorageConfig{
+		Storage: []DiskConfig{
+			{DiskSize: 1024},
+			{DiskSize: 1024, DiskMode: "independent_persistent", DiskSharing: "multi-writer"},
+		},
+	}
+
+	disks, err := config.Disks(new(driver.DriverMock), "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if disks[0].DiskMode != "" || disks[0].DiskSharing != "" {
+		t.Fatalf("unexpected result: expected the default disk mode and sharing, but returned '%s' and '%s'", disks[0].DiskMode, disks[0].DiskSharing)
+	}
+	if disks[1].DiskMode != "independent_persistent" || disks[1].DiskSharing != "sharingMultiWriter" {
+		t.Fatalf("unexpected result: expected 'independent_persistent' and 'sharingMultiWriter', but returned '%s' and '%s'", disks[1].DiskMode, disks[1].DiskSharing)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The number of bytes in a gibibyte.
+const bytesPerGB = 1 << 30
+
+// VSphereTemplateValues resolves the values of the `vsphere` template
+// function from vCenter Server when a template is rendered, such as
+// `{{ vsphere "datastore.free_gb" }}`. The values are resolved from the
+// virtual machine if it exists, or else from the datastore, host, and network
+// of the configuration. Each value is resolved at most once.
+type VSphereTemplateValues struct {
+	Driver driver.Driver
+	// The virtual machine of the build, if it has been created.
+	VM        driver.VirtualMachine
+	Datastore string
+	Host      string
+	Network   string
+
+	values map[string]string
+}
+
+// WithVSphereFunc returns a copy of the interpolation context with the
+// `vsphere` template function.
+func WithVSphereFunc(ctx interpolate.Context, v *VSphereTemplateValues) interpolate.Context {
+	funcs := make(map[string]interface{}, len(ctx.Funcs)+1)
+	for name, f := range ctx.Funcs {
+		funcs[name] = f
+	}
+	funcs["vsphere"] = v.Resolve
+	ctx.Funcs = funcs
+	return ctx
+}
+
+// Resolve returns the value of the key. The available keys are:
+//
+//   - `datastore.name`, `datastore.free_gb`, and `datastore.capacity_gb` -
+//     The name, free space, and capacity of the datastore, in whole GB.
+//   - `host.name`, `host.version`, and `host.build` - The name, ESXi version,
+//     and ESXi build number of the host.
+//   - `dvs.name` - The name of the distributed virtual switch of the network,
+//     or an empty string if the network is not a distributed port group.
+func (v *VSphereTemplateValues) Resolve(key string) (string, error) {
+	if value, ok := v.values[key]; ok {
+		return value, nil
+	}
+
+	var value string
+	var err error
+	switch key {
+	case "datastore.name", "datastore.free_gb", "datastore.capacity_gb":
+		value, err = v.datastoreValue(key)
+	case "host.name", "host.version", "host.build":
+		value, err = v.hostValue(key)
+	case "dvs.name":
+		value, err = v.switchName()
+	default:
+		return "", fmt.Errorf("unknown vsphere value %q", key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error resolving vsphere value %q: %s", key, err)
+	}
+
+	if v.values == nil {
+		v.values = make(map[string]string)
+	}
+	v.values[key] = value
+	return value, nil
+}
+
+func (v *VSphereTemplateValues) datastoreValue(key string) (string, error) {
+	var ds driver.Datastore
+	if v.VM != nil {
+		info, err := v.VM.Info("datastore")
+		if err != nil {
+			return "", err
+		}
+		if len(info.Datastore) == 0 {
+			return "", fmt.Errorf("virtual machine has no datastore")
+		}
+		ds = v.Driver.NewDatastore(&info.Datastore[0])
+	} else {
+		var err error
+		if ds, err = v.Driver.FindDatastore(v.Datastore, v.Host); err != nil {
+			return "", err
+		}
+	}
+
+	info, err := ds.Info("name", "summary")
+	if err != nil {
+		return "", err
+	}
+	switch key {
+	case "datastore.free_gb":
+		return strconv.FormatInt(info.Summary.FreeSpace/bytesPerGB, 10), nil
+	case "datastore.capacity_gb":
+		return strconv.FormatInt(info.Summary.Capacity/bytesPerGB, 10), nil
+	}
+	return info.Name, nil
+}
+
+func (v *VSphereTemplateValues) hostValue(key string) (string, error) {
+	var host *driver.Host
+	switch {
+	case v.VM != nil:
+		info, err := v.VM.Info("runtime.host")
+		if err != nil {
+			return "", err
+		}
+		if info.Runtime.Host == nil {
+			return "", fmt.Errorf("virtual machine has no host")
+		}
+		host = v.Driver.NewHost(info.Runtime.Host)
+	case v.Host != "":
+		var err error
+		if host, err = v.Driver.FindHost(v.Host); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("'host' is required before the virtual machine is created")
+	}
+
+	info, err := host.Info("name", "summary.config.product")
+	if err != nil {
+		return "", err
+	}
+	product := info.Summary.Config.Product
+	switch key {
+	case "host.version", "host.build":
+		if product == nil {
+			return "", fmt.Errorf("host %s has no product information", info.Name)
+		}
+		if key == "host.version" {
+			return product.Version, nil
+		}
+		return product.Build, nil
+	}
+	return info.Name, nil
+}
+
+func (v *VSphereTemplateValues) switchName() (string, error) {
+	var network *driver.Network
+	switch {
+	case v.VM != nil:
+		info, err := v.VM.Info("network")
+		if err != nil {
+			return "", err
+		}
+		if len(info.Network) == 0 {
+			return "", fmt.Errorf("virtual machine has no network")
+		}
+		network = v.Driver.NewNetwork(&info.Network[0])
+	case v.Network != "":
+		var err error
+		if network, err = v.Driver.FindNetwork(v.Network); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("'network' is required before the virtual machine is created")
+	}
+	return network.SwitchName()
+}
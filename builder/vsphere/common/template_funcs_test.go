@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestWithVSphereFunc(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	values := &VSphereTemplateValues{
+		Driver:    sim.driver,
+		Datastore: "LocalDS_0",
+		Host:      "DC0_H0",
+		Network:   "DC0_DVPG0",
+	}
+	ctx := WithVSphereFunc(interpolate.Context{}, values)
+
+	tc := []struct {
+		template string
+		expected string
+	}{
+		{template: `{{ vsphere "datastore.name" }}`, expected: "LocalDS_0"},
+		{template: `{{ vsphere "host.name" }}`, expected: "DC0_H0"},
+		{template: `{{ vsphere "dvs.name" }}`, expected: "DVS0"},
+	}
+	for _, c := range tc {
+		actual, err := interpolate.Render(c.template, &ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if actual != c.expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, actual)
+		}
+	}
+
+	for _, key := range []string{"datastore.free_gb", "datastore.capacity_gb", "host.version", "host.build"} {
+		actual, err := values.Resolve(key)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if actual == "" {
+			t.Fatalf("unexpected result: expected a value for '%s'", key)
+		}
+	}
+
+	if _, err := interpolate.Render(`{{ vsphere "cluster.name" }}`, &ctx); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestWithVSphereFunc_VirtualMachine(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	ctx := WithVSphereFunc(interpolate.Context{}, &VSphereTemplateValues{Driver: sim.driver, VM: vm})
+
+	actual, err := interpolate.Render(`{{ vsphere "host.name" }}`, &ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	host := sim.driver.NewHost(machine.Runtime.Host)
+	info, err := host.Info("name")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if actual != info.Name {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", info.Name, actual)
+	}
+
+	if _, err := interpolate.Render(`{{ vsphere "datastore.name" }}`, &ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	vimxml "github.com/vmware/govmomi/vim25/xml"
+)
+
+// The value that replaces the content of the sensitive elements in the log.
+const redactedValue = "********"
+
+// The substrings of the names of the elements whose content is redacted from
+// the log, such as the password of the Login call or of a guest
+// customization and the ticket of a virtual machine console, in lower case.
+var sensitiveElementNames = []string{"password", "passphrase", "secret", "token", "ticket", "assertion"}
+
+// apiLog is a round tripper that writes the request and the response of each
+// vSphere API call to a file as XML, with the content of sensitive elements
+// redacted. Unlike the debug tracing of govmomi, which is global to the
+// process, it is scoped to the client of a build.
+type apiLog struct {
+	next soap.RoundTripper
+
+	mu sync.Mutex
+	w  io.WriteCloser
+	// The number of the last call, which identifies the entries of a call.
+	calls int
+}
+
+// newAPILog returns a round tripper that logs the calls of the round tripper
+// to a new file at the path.
+func newAPILog(next soap.RoundTripper, path string) (*apiLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the vCenter Server API log: %s", err)
+	}
+	return &apiLog{next: next, w: f}, nil
+}
+
+func (l *apiLog) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Body")
+
+	l.mu.Lock()
+	l.calls++
+	call := l.calls
+	l.write(fmt.Sprintf("=== %s #%d %s request", time.Now().UTC().Format(time.RFC3339Nano), call, method), req)
+	l.mu.Unlock()
+
+	start := time.Now()
+	err := l.next.RoundTrip(ctx, req, res)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.write(fmt.Sprintf("=== %s #%d %s error (%s): %s", time.Now().UTC().Format(time.RFC3339Nano), call, method, elapsed, err), nil)
+		return err
+	}
+	l.write(fmt.Sprintf("=== %s #%d %s response (%s)", time.Now().UTC().Format(time.RFC3339Nano), call, method, elapsed), res)
+	return nil
+}
+
+// write writes the header and the body of an entry. Errors are ignored, so
+// that the log does not fail the calls.
+func (l *apiLog) write(header string, body interface{}) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.WriteByte('\n')
+	if body != nil {
+		b, err := vimxml.MarshalIndent(body, "", "  ")
+		if err != nil {
+			fmt.Fprintf(&buf, "error marshaling the body: %s\n", err)
+		} else {
+			buf.Write(redactXML(b))
+			buf.WriteByte('\n')
+		}
+	}
+	_, _ = l.w.Write(buf.Bytes())
+}
+
+// Close closes the log file.
+func (l *apiLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Close()
+}
+
+// isSensitiveElement reports whether the content of the element with the
+// name is redacted from the log.
+func isSensitiveElement(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveElementNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactXML returns the XML document with the character data of the
+// sensitive elements, and of the elements within them, replaced. The rest of
+// the document is unchanged. If the document cannot be parsed, the content
+// after the point of the error is omitted.
+func redactXML(b []byte) []byte {
+	return redactXMLWith(b, redactedValue)
+}
+
+// redactXMLWith is redactXML with the value that replaces the character data.
+func redactXMLWith(b []byte, value string) []byte {
+	var out bytes.Buffer
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var last int64
+	// The depth of the current element within a sensitive element.
+	depth := 0
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			out.Write(b[last:start])
+			fmt.Fprintf(&out, "[content omitted: %s]", err)
+			return out.Bytes()
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth > 0 || isSensitiveElement(t.Name.Local) {
+				depth++
+			}
+		case xml.EndElement:
+			if depth > 0 {
+				depth--
+			}
+		case xml.CharData:
+			if depth > 0 && len(bytes.TrimSpace(t)) > 0 {
+				out.Write(b[last:start])
+				out.WriteString(value)
+				last = dec.InputOffset()
+			}
+		}
+	}
+	out.Write(b[last:])
+	return out.Bytes()
+}
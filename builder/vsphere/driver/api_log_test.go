@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// loginVCenter is a round tripper of a vCenter Server that accepts a single
+// password.
+type loginVCenter struct{}
+
+func (loginVCenter) RoundTrip(_ context.Context, req, res soap.HasFault) error {
+	login := req.(*methods.LoginBody).Req
+	if login.Password != "VMware1!" {
+		return errors.New("incorrect user name or password")
+	}
+	res.(*methods.LoginBody).Res = &types.LoginResponse{
+		Returnval: types.UserSession{UserName: login.UserName, FullName: "Administrator"},
+	}
+	return nil
+}
+
+func TestRedactXML(t *testing.T) {
+	tc := []struct {
+		name     string
+		xml      string
+		expected string
+	}{
+		{
+			name:     "Sensitive element",
+			xml:      `<Login><userName>root</userName><password>VMware1!</password></Login>`,
+			expected: `<Login><userName>root</userName><password>********</password></Login>`,
+		},
+		{
+			name:     "Nested element",
+			xml:      `<adminPassword><value>VMware1!</value><plainText>true</plainText></adminPassword><name>vm</name>`,
+			expected: `<adminPassword><value>********</value><plainText>********</plainText></adminPassword><name>vm</name>`,
+		},
+		{
+			name:     "Empty element",
+			xml:      `<password></password><password/>`,
+			expected: `<password></password><password/>`,
+		},
+		{
+			name:     "Malformed",
+			xml:      `<name>vm</name><password <value>VMware1!</value></password>`,
+			expected: `<name>vm</name>[content omitted: XML syntax error on line 1: expected attribute name in element]`,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := string(redactXML([]byte(c.xml))); actual != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAPILog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	l, err := newAPILog(loginVCenter{}, path)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	ctx := context.Background()
+	req := &methods.LoginBody{Req: &types.Login{UserName: "administrator@vsphere.local", Password: "VMware1!"}}
+	if err := l.RoundTrip(ctx, req, &methods.LoginBody{}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	req = &methods.LoginBody{Req: &types.Login{UserName: "administrator@vsphere.local", Password: "password"}}
+	if err := l.RoundTrip(ctx, req, &methods.LoginBody{}); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	log := string(b)
+	for _, expected := range []string{
+		"#1 Login request",
+		"#1 Login response",
+		"<fullName>Administrator</fullName>",
+		"#2 Login request",
+		"#2 Login error",
+		"incorrect user name or password",
+		"<userName>administrator@vsphere.local</userName>",
+		"<password>********</password>",
+	} {
+		if !strings.Contains(log, expected) {
+			t.Fatalf("unexpected result: expected '%s' in the log, but returned '%s'", expected, log)
+		}
+	}
+	if strings.Contains(log, "VMware1!") {
+		t.Fatalf("unexpected result: expected the password to be redacted, but returned '%s'", log)
+	}
+}
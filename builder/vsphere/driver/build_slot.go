@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// BuildInProgressAttribute is the name of the custom attribute used to mark
+// virtual machines that are being built by Packer.
+const BuildInProgressAttribute = "packer_build_in_progress"
+
+// CountBuildsInProgress returns the number of virtual machines in the provided
+// list that are marked as in-progress builds. The marks that are older than
+// staleAge are ignored, since the builds that were killed or crashed do not
+// remove their marks. If staleAge is 0, no marks are ignored.
+func (d *VCenterDriver) CountBuildsInProgress(vms []types.ManagedObjectReference, staleAge time.Duration) (int, error) {
+	if len(vms) == 0 {
+		return 0, nil
+	}
+
+	m, err := object.GetCustomFieldsManager(d.vimClient)
+	if err != nil {
+		return 0, err
+	}
+	key, err := m.FindKey(d.ctx, BuildInProgressAttribute)
+	if err != nil {
+		if errors.Is(err, object.ErrKeyNameNotFound) {
+			// The attribute has not been created, so no builds are marked.
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var infos []mo.VirtualMachine
+	pc := property.DefaultCollector(d.vimClient)
+	if err := pc.Retrieve(d.ctx, vms, []string{"name", "customValue"}, &infos); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, info := range infos {
+		for _, v := range info.CustomValue {
+			value, ok := v.(*types.CustomFieldStringValue)
+			if !ok || value.Key != key || value.Value == "" {
+				continue
+			}
+			// The marks with an unknown timestamp are counted, so that the
+			// limits are not exceeded.
+			marked, err := time.Parse(time.RFC3339, value.Value)
+			if err == nil && staleAge > 0 && time.Since(marked) > staleAge {
+				log.Printf("Ignoring the stale %s mark of virtual machine %s from %s", BuildInProgressAttribute, info.Name, value.Value)
+				continue
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// SetBuildInProgress marks or unmarks the virtual machine as an in-progress
+// build. The custom attribute is created if it does not exist.
+func (vm *VirtualMachineDriver) SetBuildInProgress(inProgress bool) error {
+	m, err := object.GetCustomFieldsManager(vm.driver.vimClient)
+	if err != nil {
+		return err
+	}
+
+	key, err := m.FindKey(vm.driver.ctx, BuildInProgressAttribute)
+	if err != nil {
+		if !errors.Is(err, object.ErrKeyNameNotFound) {
+			return err
+		}
+		if !inProgress {
+			return nil
+		}
+		def, err := m.Add(vm.driver.ctx, BuildInProgressAttribute, "VirtualMachine", nil, nil)
+		if err != nil {
+			// Another build may have created the attribute concurrently.
+			if key, err = m.FindKey(vm.driver.ctx, BuildInProgressAttribute); err != nil {
+				return err
+			}
+		} else {
+			key = def.Key
+		}
+	}
+
+	value := ""
+	if inProgress {
+		value = time.Now().UTC().Format(time.RFC3339)
+	}
+	return m.Set(vm.driver.ctx, vm.vm.Reference(), key, value)
+}
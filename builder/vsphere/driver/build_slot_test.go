@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVCenterDriver_CountBuildsInProgressStaleMark(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	ref := machine.Reference()
+	vm := sim.driver.NewVM(&ref).(*VirtualMachineDriver)
+	vms := []types.ManagedObjectReference{ref}
+
+	if err := vm.SetBuildInProgress(true); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if count, err := sim.driver.CountBuildsInProgress(vms, time.Hour); err != nil || count != 1 {
+		t.Fatalf("unexpected result: expected '1', but returned '%d' (%v)", count, err)
+	}
+
+	// The mark of a build that did not remove it, such as a killed build.
+	m, err := object.GetCustomFieldsManager(sim.driver.vimClient)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	key, err := m.FindKey(sim.driver.ctx, BuildInProgressAttribute)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	stale := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	if err := m.Set(sim.driver.ctx, ref, key, stale); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name     string
+		value    string
+		staleAge time.Duration
+		expected int
+	}{
+		{name: "Stale mark is ignored", value: stale, staleAge: time.Hour, expected: 0},
+		{name: "Stale mark is counted without a stale age", value: stale, staleAge: 0, expected: 1},
+		{name: "Stale mark within the stale age is counted", value: stale, staleAge: 3 * time.Hour, expected: 1},
+		{name: "Mark with an unknown timestamp is counted", value: "unknown", staleAge: time.Hour, expected: 1},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if err := m.Set(sim.driver.ctx, ref, key, c.value); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			count, err := sim.driver.CountBuildsInProgress(vms, c.staleAge)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if count != c.expected {
+				t.Fatalf("unexpected result: expected '%d', but returned '%d'", c.expected, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+)
+
+// PlacementCapacity is the capacity and usage of the compute resource and the
+// datastore where a virtual machine is placed.
+type PlacementCapacity struct {
+	// The name of the cluster or host.
+	Compute          string
+	CPUCapacityMHz   int64
+	CPUUsageMHz      int64
+	MemoryCapacityMB int64
+	MemoryUsageMB    int64
+
+	// The name of the datastore. The datastore capacity and usage are in bytes.
+	Datastore         string
+	DatastoreCapacity int64
+	DatastoreUsage    int64
+}
+
+// PlacementCapacity retrieves the capacity and usage of the specified cluster,
+// or host if no cluster is specified, and datastore. The usage of a cluster is
+// the sum of the usage of its hosts.
+func (d *VCenterDriver) PlacementCapacity(cluster string, host string, datastore string) (*PlacementCapacity, error) {
+	capacity := &PlacementCapacity{}
+
+	var hosts []*object.HostSystem
+	switch {
+	case cluster != "":
+		c, err := d.FindCluster(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("error finding cluster %s: %s", cluster, err)
+		}
+		hosts, err = c.cluster.Hosts(d.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the hosts of cluster %s: %s", cluster, err)
+		}
+		capacity.Compute = cluster
+	case host != "":
+		h, err := d.FindHost(host)
+		if err != nil {
+			return nil, fmt.Errorf("error finding host %s: %s", host, err)
+		}
+		hosts = append(hosts, h.host)
+		capacity.Compute = host
+	}
+
+	for _, h := range hosts {
+		info, err := (&Host{host: h, driver: d}).Info("summary.hardware", "summary.quickStats")
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the usage of host %s: %s", h.Name(), err)
+		}
+		if hw := info.Summary.Hardware; hw != nil {
+			capacity.CPUCapacityMHz += int64(hw.CpuMhz) * int64(hw.NumCpuCores)
+			capacity.MemoryCapacityMB += hw.MemorySize / (1024 * 1024)
+		}
+		capacity.CPUUsageMHz += int64(info.Summary.QuickStats.OverallCpuUsage)
+		capacity.MemoryUsageMB += int64(info.Summary.QuickStats.OverallMemoryUsage)
+	}
+
+	if datastore == "" && host == "" {
+		return capacity, nil
+	}
+	ds, err := d.FindDatastore(datastore, host)
+	if err != nil {
+		return nil, err
+	}
+	info, err := ds.Info("name", "summary.capacity", "summary.freeSpace")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the usage of datastore %s: %s", ds.Name(), err)
+	}
+	capacity.Datastore = info.Name
+	capacity.DatastoreCapacity = info.Summary.Capacity
+	capacity.DatastoreUsage = info.Summary.Capacity - info.Summary.FreeSpace
+
+	return capacity, nil
+}
@@ -0,0 +1,33 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+)
+
+func TestVCenterDriver_PlacementCapacity(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	capacity, err := sim.driver.PlacementCapacity("DC0_C0", "", "LocalDS_0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if capacity.Compute != "DC0_C0" {
+		t.Fatalf("unexpected result: expected 'DC0_C0', but returned '%s'", capacity.Compute)
+	}
+	if capacity.CPUCapacityMHz == 0 || capacity.MemoryCapacityMB == 0 {
+		t.Fatalf("unexpected result: expected the cluster capacity, but returned '%+v'", capacity)
+	}
+	if capacity.Datastore != "LocalDS_0" || capacity.DatastoreCapacity == 0 {
+		t.Fatalf("unexpected result: expected the datastore capacity, but returned '%+v'", capacity)
+	}
+	if capacity.DatastoreUsage < 0 || capacity.DatastoreUsage > capacity.DatastoreCapacity {
+		t.Fatalf("unexpected result: expected a datastore usage within the capacity, but returned '%d'", capacity.DatastoreUsage)
+	}
+}
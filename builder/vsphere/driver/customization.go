@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// LatestCustomizationEvent returns the most recent event that reports the
+// completion of a guest customization of the virtual machine, or nil if no
+// such event with a key greater than afterKey exists.
+func (vm *VirtualMachineDriver) LatestCustomizationEvent(afterKey int32) (types.BaseEvent, error) {
+	m := event.NewManager(vm.driver.vimClient)
+	events, err := m.QueryEvents(vm.driver.ctx, types.EventFilterSpec{
+		Entity: &types.EventFilterSpecByEntity{
+			Entity:    vm.vm.Reference(),
+			Recursion: types.EventFilterSpecRecursionOptionSelf,
+		},
+		EventTypeId: []string{"CustomizationSucceeded", "CustomizationFailed"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest types.BaseEvent
+	for _, e := range events {
+		key := e.GetEvent().Key
+		if key > afterKey && (latest == nil || key > latest.GetEvent().Key) {
+			latest = e
+		}
+	}
+	return latest, nil
+}
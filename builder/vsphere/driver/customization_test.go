@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVirtualMachineDriver_LatestCustomizationEvent(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	vmDriver := vm.(*VirtualMachineDriver)
+
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	e, err := vmDriver.LatestCustomizationEvent(0)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if e != nil {
+		t.Fatalf("unexpected result: expected no customization event, but returned '%T'", e)
+	}
+
+	nics := make([]types.CustomizationAdapterMapping, len(machine.Guest.Net))
+	for i := range nics {
+		nics[i].Adapter.Ip = &types.CustomizationDhcpIpGenerator{}
+	}
+	spec := types.CustomizationSpec{
+		Identity: &types.CustomizationLinuxPrep{
+			HostName: &types.CustomizationFixedName{Name: "packer"},
+			Domain:   "example.com",
+		},
+		NicSettingMap: nics,
+	}
+	if err := vm.Customize(spec); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.PowerOn(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	e, err = vmDriver.LatestCustomizationEvent(0)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, ok := e.(*types.CustomizationSucceeded); !ok {
+		t.Fatalf("unexpected result: expected '*types.CustomizationSucceeded', but returned '%T'", e)
+	}
+
+	e, err = vmDriver.LatestCustomizationEvent(e.GetEvent().Key)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if e != nil {
+		t.Fatalf("unexpected result: expected no customization event, but returned '%T'", e)
+	}
+}
@@ -23,6 +23,7 @@ type Datastore interface {
 	Name() string
 	ResolvePath(path string) string
 	UploadFile(src, dst, host string, setHost bool) error
+	DownloadFile(src, dst string) error
 	Delete(path string) error
 	MakeDirectory(path string) error
 	Reference() types.ManagedObjectReference
@@ -78,6 +79,102 @@ func (d *VCenterDriver) FindDatastore(name string, host string) (Datastore, erro
 	}, nil
 }
 
+// DatastoreSummary is the capacity, type, and accessibility of a datastore.
+// The capacity and free space are in bytes.
+type DatastoreSummary struct {
+	ID              string
+	Name            string
+	Type            string
+	Capacity        int64
+	FreeSpace       int64
+	Accessible      bool
+	MaintenanceMode string
+	// Whether the datastore is mounted on more than one host.
+	Shared bool
+}
+
+// DatastoreSummary retrieves the summary of the datastore with the specified
+// name.
+func (d *VCenterDriver) DatastoreSummary(name string) (*DatastoreSummary, error) {
+	ds, err := d.finder.Datastore(d.ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("error finding datastore with name %s: %s", name, err)
+	}
+	summaries, err := d.datastoreSummaries([]types.ManagedObjectReference{ds.Reference()})
+	if err != nil {
+		return nil, err
+	}
+	return &summaries[0], nil
+}
+
+// ClusterDatastoreSummaries retrieves the summaries of the datastores of the
+// specified cluster.
+func (d *VCenterDriver) ClusterDatastoreSummaries(cluster string) ([]DatastoreSummary, error) {
+	c, err := d.FindCluster(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error finding cluster %s: %s", cluster, err)
+	}
+	var info mo.ClusterComputeResource
+	if err := c.cluster.Properties(d.ctx, c.cluster.Reference(), []string{"datastore"}, &info); err != nil {
+		return nil, fmt.Errorf("error retrieving the datastores of cluster %s: %s", cluster, err)
+	}
+	if len(info.Datastore) == 0 {
+		return nil, nil
+	}
+	return d.datastoreSummaries(info.Datastore)
+}
+
+// HostLocalDatastores retrieves the summaries of the datastores of the
+// specified host that are not shared with other hosts.
+func (d *VCenterDriver) HostLocalDatastores(host string) ([]DatastoreSummary, error) {
+	h, err := d.FindHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("error finding host %s: %s", host, err)
+	}
+	info, err := h.Info("datastore")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the datastores of host %s: %s", host, err)
+	}
+	if len(info.Datastore) == 0 {
+		return nil, nil
+	}
+	summaries, err := d.datastoreSummaries(info.Datastore)
+	if err != nil {
+		return nil, err
+	}
+
+	var local []DatastoreSummary
+	for _, summary := range summaries {
+		if !summary.Shared {
+			local = append(local, summary)
+		}
+	}
+	return local, nil
+}
+
+func (d *VCenterDriver) datastoreSummaries(refs []types.ManagedObjectReference) ([]DatastoreSummary, error) {
+	var infos []mo.Datastore
+	pc := property.DefaultCollector(d.vimClient)
+	if err := pc.Retrieve(d.ctx, refs, []string{"summary", "host"}, &infos); err != nil {
+		return nil, fmt.Errorf("error retrieving the summary of datastores: %s", err)
+	}
+
+	summaries := make([]DatastoreSummary, 0, len(infos))
+	for _, info := range infos {
+		summaries = append(summaries, DatastoreSummary{
+			ID:              info.Reference().Value,
+			Name:            info.Summary.Name,
+			Type:            info.Summary.Type,
+			Capacity:        info.Summary.Capacity,
+			FreeSpace:       info.Summary.FreeSpace,
+			Accessible:      info.Summary.Accessible,
+			MaintenanceMode: info.Summary.MaintenanceMode,
+			Shared:          len(info.Host) > 1 || (info.Summary.MultipleHostAccess != nil && *info.Summary.MultipleHostAccess),
+		})
+	}
+	return summaries, nil
+}
+
 // GetDatastoreName retrieves the name of a datastore by its ID.
 // Returns the name of the datastore or an error if the retrieval process
 // fails.
@@ -197,6 +294,13 @@ func (ds *DatastoreDriver) UploadFile(src, dst, host string, setHost bool) error
 	return ds.ds.UploadFile(ctx, src, dst, &p)
 }
 
+// DownloadFile downloads a file from the source path in the datastore to the
+// local destination path.
+func (ds *DatastoreDriver) DownloadFile(src, dst string) error {
+	p := soap.DefaultDownload
+	return ds.ds.DownloadFile(ds.driver.ctx, src, dst, &p)
+}
+
 // Delete deletes a file from a datastore by a path.
 func (ds *DatastoreDriver) Delete(path string) error {
 	dc, err := ds.driver.finder.Datacenter(ds.driver.ctx, ds.ds.DatacenterPath)
@@ -32,6 +32,13 @@ type DatastoreMock struct {
 	UploadFileHost    string
 	UploadFileSetHost bool
 	UploadFileErr     error
+	// The errors of the next uploads, in order, before UploadFileErr.
+	UploadFileErrs []error
+
+	DownloadFileCalled bool
+	DownloadFileSrc    string
+	DownloadFileDst    string
+	DownloadFileErr    error
 }
 
 func (ds *DatastoreMock) Info(params ...string) (*mo.Datastore, error) {
@@ -69,9 +76,21 @@ func (ds *DatastoreMock) UploadFile(src, dst, host string, setHost bool) error {
 	ds.UploadFileDst = dst
 	ds.UploadFileHost = host
 	ds.UploadFileSetHost = setHost
+	if len(ds.UploadFileErrs) > 0 {
+		err := ds.UploadFileErrs[0]
+		ds.UploadFileErrs = ds.UploadFileErrs[1:]
+		return err
+	}
 	return ds.UploadFileErr
 }
 
+func (ds *DatastoreMock) DownloadFile(src, dst string) error {
+	ds.DownloadFileCalled = true
+	ds.DownloadFileSrc = src
+	ds.DownloadFileDst = dst
+	return ds.DownloadFileErr
+}
+
 func (ds *DatastoreMock) Delete(path string) error {
 	ds.DeleteCalled = true
 	ds.DeletePath = path
@@ -175,3 +175,59 @@ func TestVCenterDriver_MultipleDatastoreError(t *testing.T) {
 		t.Fatalf("unexpected error: '%s'", err)
 	}
 }
+
+func TestVCenterDriver_DatastoreSummary(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	summary, err := sim.driver.DatastoreSummary("LocalDS_0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if summary.Name != "LocalDS_0" || summary.ID == "" {
+		t.Fatalf("unexpected result: expected datastore 'LocalDS_0', but returned '%+v'", summary)
+	}
+	if summary.Capacity == 0 || summary.FreeSpace > summary.Capacity || !summary.Accessible {
+		t.Fatalf("unexpected result: expected the datastore capacity, but returned '%+v'", summary)
+	}
+
+	summaries, err := sim.driver.ClusterDatastoreSummaries("DC0_C0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(summaries) == 0 {
+		t.Fatal("unexpected result: expected the datastores of the cluster")
+	}
+
+	if _, err := sim.driver.DatastoreSummary("unknown"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestVCenterDriver_HostLocalDatastores(t *testing.T) {
+	model := simulator.ESX()
+	model.Datastore = 2
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, host := sim.ChooseSimulatorPreCreatedHost()
+
+	summaries, err := sim.driver.HostLocalDatastores(host.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("unexpected result: expected '2' datastores, but returned '%d'", len(summaries))
+	}
+	for _, summary := range summaries {
+		if summary.Shared {
+			t.Fatalf("unexpected result: expected a local datastore, but returned '%+v'", summary)
+		}
+	}
+}
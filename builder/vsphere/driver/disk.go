@@ -5,6 +5,8 @@ package driver
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
@@ -15,11 +17,30 @@ type Disk struct {
 	DiskEagerlyScrub    bool
 	DiskThinProvisioned bool
 	ControllerIndex     int
+	// The datastore path of the virtual disk file. If empty, the disk is
+	// created in the virtual machine directory.
+	FileName string
+	// Attach the existing virtual disk file at FileName instead of creating
+	// a new disk.
+	AttachExisting bool
+	// The identifier of the storage policy of the disk. Defaults to the
+	// storage policy of the virtual machine.
+	StoragePolicyID string
+	// The disk mode, such as `independent_persistent`. Defaults to
+	// `persistent`.
+	DiskMode string
+	// The sharing mode of the disk, such as `sharingMultiWriter`. Defaults
+	// to no sharing.
+	DiskSharing string
 }
 
 type StorageConfig struct {
 	DiskControllerType []string
 	Storage            []Disk
+	// The identifier of the storage policy of the virtual machine home and of
+	// the disks without a storage policy. The default policy of the datastore
+	// applies if empty.
+	StoragePolicyID string
 }
 
 // AddStorageDevices adds virtual storage devices to an existing device list
@@ -35,6 +56,9 @@ func (c *StorageConfig) AddStorageDevices(existingDevices object.VirtualDeviceLi
 		switch controllerType {
 		case "nvme":
 			device, err = existingDevices.CreateNVMEController()
+			if err == nil && device.(*types.VirtualNVMEController).BusNumber < 0 {
+				err = fmt.Errorf("error creating nvme controller: a virtual machine supports up to 4 nvme controllers")
+			}
 		case "sata":
 			device, err = existingDevices.CreateSATAController()
 		default:
@@ -52,11 +76,15 @@ func (c *StorageConfig) AddStorageDevices(existingDevices object.VirtualDeviceLi
 		controllers = append(controllers, controller)
 	}
 
-	for _, dc := range c.Storage {
+	policies := make(map[int32]string)
+	for i, dc := range c.Storage {
 		disk := &types.VirtualDisk{
 			VirtualDevice: types.VirtualDevice{
 				Key: existingDevices.NewKey(),
 				Backing: &types.VirtualDiskFlatVer2BackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+						FileName: dc.FileName,
+					},
 					DiskMode:        string(types.VirtualDiskModePersistent),
 					ThinProvisioned: types.NewBool(dc.DiskThinProvisioned),
 					EagerlyScrub:    types.NewBool(dc.DiskEagerlyScrub),
@@ -64,13 +92,109 @@ func (c *StorageConfig) AddStorageDevices(existingDevices object.VirtualDeviceLi
 			},
 			CapacityInKB: dc.DiskSize * 1024,
 		}
+		if dc.DiskMode != "" {
+			disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo).DiskMode = dc.DiskMode
+		}
+		if dc.DiskSharing != "" {
+			disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo).Sharing = dc.DiskSharing
+		}
+		if dc.AttachExisting {
+			// A disk without a capacity is attached rather than created.
+			disk.CapacityInKB = 0
+		}
 
-		existingDevices.AssignController(disk, controllers[dc.ControllerIndex])
+		controller := controllers[dc.ControllerIndex]
+		existingDevices.AssignController(disk, controller)
+		if !hasUnitNumber(controller, *disk.UnitNumber) {
+			return nil, fmt.Errorf("error adding storage[%d]: disk controller %s has no free unit number", i, existingDevices.Name(controller.(types.BaseVirtualDevice)))
+		}
 		existingDevices = append(existingDevices, disk)
 		newDevices = append(newDevices, disk)
+		if dc.StoragePolicyID != "" {
+			policies[disk.Key] = dc.StoragePolicyID
+		}
 	}
 
-	return newDevices.ConfigSpec(types.VirtualDeviceConfigSpecOperationAdd)
+	changes, err := newDevices.ConfigSpec(types.VirtualDeviceConfigSpecOperationAdd)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		spec := change.GetVirtualDeviceConfigSpec()
+		if id, ok := policies[spec.Device.GetVirtualDevice().Key]; ok {
+			spec.Profile = storageProfileSpec(id)
+		}
+	}
+	applyDiskStoragePolicy(changes, c.StoragePolicyID)
+	return changes, nil
+}
+
+// The number of disks of an NVMe controller, with the unit numbers 0 to 14.
+// The unit numbers of other controllers are limited by the device list.
+const nvmeControllerUnits = 15
+
+// hasUnitNumber reports whether the unit number assigned to a device of the
+// controller is valid. A negative unit number is assigned if the controller
+// has no free unit number.
+func hasUnitNumber(c types.BaseVirtualController, unit int32) bool {
+	if _, ok := c.(*types.VirtualNVMEController); ok && unit >= nvmeControllerUnits {
+		return false
+	}
+	return unit >= 0
+}
+
+// isControllerType reports whether the controller is of the type of the
+// `disk_controller_type` option. Any SCSI controller matches a SCSI type.
+func isControllerType(c types.BaseVirtualController, controllerType string) bool {
+	switch controllerType {
+	case "nvme":
+		_, ok := c.(*types.VirtualNVMEController)
+		return ok
+	case "sata":
+		_, ok := c.(types.BaseVirtualSATAController)
+		return ok
+	}
+	_, ok := c.(types.BaseVirtualSCSIController)
+	return ok
+}
+
+// BootDiskNames returns the names of the disks in the order in which they are
+// booted from: the disks of the controllers of the type of the boot disk, by
+// bus and unit number, followed by the other disks. The `disk` boot device
+// otherwise orders the disks by device key, which places the disks of NVMe
+// and SATA controllers after those of SCSI controllers.
+func BootDiskNames(devices object.VirtualDeviceList, controllerType string) []string {
+	type bootDisk struct {
+		name      string
+		bus, unit int32
+	}
+	var first, rest []bootDisk
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		d := device.GetVirtualDevice()
+		disk := bootDisk{name: devices.Name(device)}
+		if d.UnitNumber != nil {
+			disk.unit = *d.UnitNumber
+		}
+		c, ok := devices.FindByKey(d.ControllerKey).(types.BaseVirtualController)
+		if !ok || !isControllerType(c, controllerType) {
+			rest = append(rest, disk)
+			continue
+		}
+		disk.bus = c.GetVirtualController().BusNumber
+		first = append(first, disk)
+	}
+	sort.SliceStable(first, func(i, j int) bool {
+		if first[i].bus != first[j].bus {
+			return first[i].bus < first[j].bus
+		}
+		return first[i].unit < first[j].unit
+	})
+
+	var names []string
+	for _, disk := range append(first, rest...) {
+		names = append(names, disk.name)
+	}
+	return names
 }
 
 // findDisk scans a list of virtual devices and retrieves a single virtual disk
@@ -96,3 +220,67 @@ func findDisk(devices object.VirtualDeviceList) (*types.VirtualDisk, error) {
 	// Multiple disks found.
 	return nil, errors.New("more than one virtual disk found, only a single disk is allowed")
 }
+
+// DiskResize is the new size of an existing disk of the source virtual
+// machine of a clone. The disk is identified by its label, such as
+// `Hard disk 2`, or else by its index among the disks of the virtual machine.
+type DiskResize struct {
+	Index int
+	Label string
+	// The size of the disk in MiB.
+	Size int64
+}
+
+// Matches reports whether the disk at the index among the disks of the
+// virtual machine is the disk to resize.
+func (r DiskResize) Matches(index int, disk *types.VirtualDisk) bool {
+	if r.Label != "" {
+		info := disk.GetVirtualDevice().DeviceInfo
+		return info != nil && info.GetDescription().Label == r.Label
+	}
+	return index == r.Index
+}
+
+func (r DiskResize) String() string {
+	if r.Label != "" {
+		return r.Label
+	}
+	return fmt.Sprintf("at index %d", r.Index)
+}
+
+// resizeDisks returns the device changes that resize the disks. A disk cannot
+// be shrunk or resized more than once.
+func resizeDisks(devices object.VirtualDeviceList, resizes []DiskResize) ([]types.BaseVirtualDeviceConfigSpec, error) {
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	resized := make(map[int32]bool)
+
+	var changes []types.BaseVirtualDeviceConfigSpec
+	for _, r := range resizes {
+		var disk *types.VirtualDisk
+		for i, device := range disks {
+			if d := device.(*types.VirtualDisk); r.Matches(i, d) {
+				disk = d
+				break
+			}
+		}
+		if disk == nil {
+			return nil, fmt.Errorf("disk %s not found", r)
+		}
+		if resized[disk.Key] {
+			return nil, fmt.Errorf("disk %s is resized more than once", r)
+		}
+		resized[disk.Key] = true
+
+		capacity := r.Size * 1024
+		if capacity < disk.CapacityInKB {
+			return nil, fmt.Errorf("disk %s cannot be shrunk from %d MiB to %d MiB", r, disk.CapacityInKB/1024, r.Size)
+		}
+		disk.CapacityInKB = capacity
+		disk.CapacityInBytes = capacity * 1024
+		changes = append(changes, &types.VirtualDeviceConfigSpec{
+			Device:    disk,
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+		})
+	}
+	return changes, nil
+}
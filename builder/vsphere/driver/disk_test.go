@@ -4,9 +4,11 @@
 package driver
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func TestAddStorageDevices(t *testing.T) {
@@ -50,3 +52,280 @@ func TestAddStorageDevices(t *testing.T) {
 		t.Fatalf("unexpected result: expected '3', but returned '%d'", len(storageConfigSpec))
 	}
 }
+
+func TestAddStorageDevicesWithFileName(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"pvscsi"},
+		Storage: []Disk{
+			{
+				DiskSize: 3072,
+				FileName: "[datastore1] packer-disks/new.vmdk",
+			},
+			{
+				DiskSize:       20480,
+				FileName:       "[datastore1] packer-disks/existing.vmdk",
+				AttachExisting: true,
+			},
+		},
+	}
+
+	storageConfigSpec, err := config.AddStorageDevices(object.VirtualDeviceList{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(storageConfigSpec) != 3 {
+		t.Fatalf("unexpected result: expected '3', but returned '%d'", len(storageConfigSpec))
+	}
+
+	created := storageConfigSpec[1].GetVirtualDeviceConfigSpec()
+	if created.FileOperation != types.VirtualDeviceConfigSpecFileOperationCreate {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", types.VirtualDeviceConfigSpecFileOperationCreate, created.FileOperation)
+	}
+	backing := created.Device.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if backing.FileName != "[datastore1] packer-disks/new.vmdk" {
+		t.Fatalf("unexpected result: expected '[datastore1] packer-disks/new.vmdk', but returned '%s'", backing.FileName)
+	}
+
+	attached := storageConfigSpec[2].GetVirtualDeviceConfigSpec()
+	if attached.FileOperation != "" {
+		t.Fatalf("unexpected result: expected no file operation, but returned '%s'", attached.FileOperation)
+	}
+}
+
+func TestAddStorageDevicesWithDiskModeAndSharing(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"pvscsi"},
+		Storage: []Disk{
+			{DiskSize: 1024},
+			{
+				DiskSize:         1024,
+				DiskEagerlyScrub: true,
+				DiskMode:         string(types.VirtualDiskModeIndependent_persistent),
+				DiskSharing:      string(types.VirtualDiskSharingSharingMultiWriter),
+			},
+		},
+	}
+
+	storageConfigSpec, err := config.AddStorageDevices(object.VirtualDeviceList{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	backing := storageConfigSpec[1].GetVirtualDeviceConfigSpec().Device.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if backing.DiskMode != string(types.VirtualDiskModePersistent) || backing.Sharing != "" {
+		t.Fatalf("unexpected result: expected '%s' and no sharing, but returned '%s' and '%s'", types.VirtualDiskModePersistent, backing.DiskMode, backing.Sharing)
+	}
+	backing = storageConfigSpec[2].GetVirtualDeviceConfigSpec().Device.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if backing.DiskMode != string(types.VirtualDiskModeIndependent_persistent) || backing.Sharing != string(types.VirtualDiskSharingSharingMultiWriter) {
+		t.Fatalf("unexpected result: expected '%s' and '%s', but returned '%s' and '%s'",
+			types.VirtualDiskModeIndependent_persistent, types.VirtualDiskSharingSharingMultiWriter, backing.DiskMode, backing.Sharing)
+	}
+}
+
+func TestAddStorageDevicesWithStoragePolicy(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"pvscsi"},
+		Storage: []Disk{
+			{
+				DiskSize: 3072,
+			},
+			{
+				DiskSize:        20480,
+				StoragePolicyID: "disk-policy",
+			},
+		},
+		StoragePolicyID: "vm-policy",
+	}
+
+	storageConfigSpec, err := config.AddStorageDevices(object.VirtualDeviceList{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(storageConfigSpec) != 3 {
+		t.Fatalf("unexpected result: expected '3', but returned '%d'", len(storageConfigSpec))
+	}
+
+	controller := storageConfigSpec[0].GetVirtualDeviceConfigSpec()
+	if len(controller.Profile) != 0 {
+		t.Fatalf("unexpected result: expected no storage policy for the controller")
+	}
+	for i, expected := range []string{"vm-policy", "disk-policy"} {
+		spec := storageConfigSpec[i+1].GetVirtualDeviceConfigSpec()
+		if len(spec.Profile) != 1 {
+			t.Fatalf("unexpected result: expected a storage policy for disk %d", i)
+		}
+		if id := spec.Profile[0].(*types.VirtualMachineDefinedProfileSpec).ProfileId; id != expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, id)
+		}
+	}
+}
+
+// testDisks returns the disks of a virtual machine with the sizes in MiB,
+// labeled `Hard disk <n>`.
+func testDisks(sizes ...int64) object.VirtualDeviceList {
+	var devices object.VirtualDeviceList
+	for i, size := range sizes {
+		devices = append(devices, &types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Key:        int32(2000 + i),
+				DeviceInfo: &types.Description{Label: fmt.Sprintf("Hard disk %d", i+1)},
+			},
+			CapacityInKB: size * 1024,
+		})
+	}
+	return devices
+}
+
+func TestResizeDisks(t *testing.T) {
+	tc := []struct {
+		name     string
+		resizes  []DiskResize
+		expected map[int32]int64
+		fail     bool
+	}{
+		{
+			name:     "Index and label",
+			resizes:  []DiskResize{{Index: 1, Size: 20480}, {Label: "Hard disk 3", Size: 40960}},
+			expected: map[int32]int64{2001: 20480 * 1024, 2002: 40960 * 1024},
+		},
+		{
+			name:    "Index not found",
+			resizes: []DiskResize{{Index: 3, Size: 20480}},
+			fail:    true,
+		},
+		{
+			name:    "Label not found",
+			resizes: []DiskResize{{Label: "Hard disk 4", Size: 20480}},
+			fail:    true,
+		},
+		{
+			name:    "Resized more than once",
+			resizes: []DiskResize{{Index: 0, Size: 20480}, {Label: "Hard disk 1", Size: 40960}},
+			fail:    true,
+		},
+		{
+			name:    "Shrunk",
+			resizes: []DiskResize{{Index: 2, Size: 10240}},
+			fail:    true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			changes, err := resizeDisks(testDisks(10240, 10240, 20480), c.resizes)
+			if c.fail {
+				if err == nil {
+					t.Fatal("unexpected success: expected failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if len(changes) != len(c.expected) {
+				t.Fatalf("unexpected result: expected '%d' changes, but returned '%d'", len(c.expected), len(changes))
+			}
+			for _, change := range changes {
+				spec := change.GetVirtualDeviceConfigSpec()
+				if spec.Operation != types.VirtualDeviceConfigSpecOperationEdit {
+					t.Fatalf("unexpected result: expected '%s', but returned '%s'", types.VirtualDeviceConfigSpecOperationEdit, spec.Operation)
+				}
+				disk := spec.Device.(*types.VirtualDisk)
+				if disk.CapacityInKB != c.expected[disk.Key] {
+					t.Fatalf("unexpected result: expected '%d', but returned '%d'", c.expected[disk.Key], disk.CapacityInKB)
+				}
+			}
+		})
+	}
+}
+
+func TestAddStorageDevicesNVMe(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"nvme", "nvme"},
+	}
+	for i := 0; i < 15; i++ {
+		config.Storage = append(config.Storage, Disk{DiskSize: 1024, ControllerIndex: 0})
+	}
+	config.Storage = append(config.Storage, Disk{DiskSize: 1024, ControllerIndex: 1})
+
+	changes, err := config.AddStorageDevices(object.VirtualDeviceList{})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var buses []int32
+	for _, change := range changes {
+		switch d := change.GetVirtualDeviceConfigSpec().Device.(type) {
+		case *types.VirtualNVMEController:
+			buses = append(buses, d.BusNumber)
+		case *types.VirtualDisk:
+			if *d.UnitNumber < 0 || *d.UnitNumber >= nvmeControllerUnits {
+				t.Fatalf("unexpected result: unit number '%d' is out of range", *d.UnitNumber)
+			}
+		}
+	}
+	if fmt.Sprint(buses) != "[0 1]" {
+		t.Fatalf("unexpected result: expected '[0 1]', but returned '%v'", buses)
+	}
+
+	config.Storage = append(config.Storage, Disk{DiskSize: 1024, ControllerIndex: 0})
+	if _, err := config.AddStorageDevices(object.VirtualDeviceList{}); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	config = &StorageConfig{DiskControllerType: []string{"nvme", "nvme", "nvme", "nvme", "nvme"}}
+	if _, err := config.AddStorageDevices(object.VirtualDeviceList{}); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestBootDiskNames(t *testing.T) {
+	config := &StorageConfig{
+		DiskControllerType: []string{"pvscsi", "nvme"},
+		Storage: []Disk{
+			{DiskSize: 1024, ControllerIndex: 1},
+			{DiskSize: 1024, ControllerIndex: 0},
+			{DiskSize: 1024, ControllerIndex: 1},
+		},
+	}
+	changes, err := config.AddStorageDevices(object.VirtualDeviceList{})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var devices object.VirtualDeviceList
+	for _, change := range changes {
+		devices = append(devices, change.GetVirtualDeviceConfigSpec().Device)
+	}
+	scsi := devices.Name(devices.SelectByType((*types.ParaVirtualSCSIController)(nil))[0])
+	nvme := devices.Name(devices.SelectByType((*types.VirtualNVMEController)(nil))[0])
+	scsiKey := devices.Find(scsi).GetVirtualDevice().Key
+	nvmeKey := devices.Find(nvme).GetVirtualDevice().Key
+
+	tc := []struct {
+		controllerType string
+		expected       []string
+	}{
+		{
+			controllerType: "nvme",
+			expected: []string{
+				fmt.Sprintf("disk-%d-0", nvmeKey),
+				fmt.Sprintf("disk-%d-1", nvmeKey),
+				fmt.Sprintf("disk-%d-0", scsiKey),
+			},
+		},
+		{
+			controllerType: "pvscsi",
+			expected: []string{
+				fmt.Sprintf("disk-%d-0", scsiKey),
+				fmt.Sprintf("disk-%d-0", nvmeKey),
+				fmt.Sprintf("disk-%d-1", nvmeKey),
+			},
+		},
+	}
+
+	for _, c := range tc {
+		names := BootDiskNames(devices, c.controllerType)
+		if fmt.Sprint(names) != fmt.Sprint(c.expected) {
+			t.Fatalf("unexpected result: expected '%v', but returned '%v'", c.expected, names)
+		}
+	}
+}
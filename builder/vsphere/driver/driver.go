@@ -6,7 +6,10 @@ package driver
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -27,11 +30,14 @@ type Driver interface {
 	FindCluster(name string) (*Cluster, error)
 	PreCleanVM(ui packersdk.Ui, vmPath string, force bool, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error
 	CreateVM(config *CreateConfig) (VirtualMachine, error)
+	ImportOvf(ctx context.Context, config *ImportOvfConfig) (VirtualMachine, error)
+	DeployLibraryItem(ctx context.Context, config *DeployLibraryItemConfig) (VirtualMachine, error)
 
 	NewDatastore(ref *types.ManagedObjectReference) Datastore
 	FindDatastore(name string, host string) (Datastore, error)
 	GetDatastoreName(id string) (string, error)
 	GetDatastoreFilePath(datastoreID, dir, filename string) (string, error)
+	HostLocalDatastores(host string) ([]DatastoreSummary, error)
 
 	NewFolder(ref *types.ManagedObjectReference) *Folder
 	FindFolder(name string) (*Folder, error)
@@ -42,6 +48,16 @@ type Driver interface {
 	FindNetworks(name string) ([]*Network, error)
 	NewResourcePool(ref *types.ManagedObjectReference) *ResourcePool
 	FindResourcePool(cluster string, host string, name string) (*ResourcePool, error)
+	PlacementCapacity(cluster string, host string, datastore string) (*PlacementCapacity, error)
+	DefaultKeyProvider(cluster string, host string) (string, error)
+	CheckEncryption(keyProvider string, cluster string, host string, resourcePool string) (string, error)
+	RemoveEncryptionKey(keyProvider string, keyID string) error
+	FindStoragePolicy(name string) (string, error)
+	FindOrCreateTag(categoryName string, tagName string, create bool) (string, error)
+	FindCustomAttributeKey(name string) (int32, error)
+	FindOrCreateCustomAttributeKey(name string) (int32, error)
+	Preflight(spec *PreflightSpec) []error
+	MissingBuildPrivileges(spec *PreflightSpec) ([]MissingPrivileges, error)
 
 	FindContentLibraryByName(name string) (*Library, error)
 	FindContentLibraryItem(libraryId string, name string) (*library.Item, error)
@@ -58,6 +74,20 @@ type VCenterDriver struct {
 	restClient *RestClient
 	finder     *find.Finder
 	datacenter *object.Datacenter
+	// Connected directly to a standalone ESXi host instead of vCenter Server.
+	standaloneHost bool
+	// The sessions are reused from the session cache and are not logged out.
+	cachedSession bool
+	// The number of retries and the initial delay between retries of tasks
+	// that fail with a transient error.
+	taskRetryCount int
+	taskRetryDelay time.Duration
+	// Aborts the calls in progress when vCenter Server becomes unreachable.
+	watchdog *watchdog
+	// Logs the vSphere API calls, if enabled.
+	apiLog *apiLog
+	// Records the interactions with vCenter Server, if enabled.
+	recorder *recorder
 }
 
 func NewVCenterDriver(ctx context.Context, client *govmomi.Client, vimClient *vim25.Client, user *url.Userinfo, finder *find.Finder, datacenter *object.Datacenter) *VCenterDriver {
@@ -80,6 +110,33 @@ type ConnectConfig struct {
 	Password           string
 	InsecureConnection bool
 	Datacenter         string
+	// Reuse the sessions in the session cache and save new sessions to it.
+	SessionCache    bool
+	SessionCacheDir string
+	// Retry tasks that fail with a transient error.
+	TaskRetryCount int
+	TaskRetryDelay time.Duration
+	// Abort the calls in progress after vCenter Server has been unreachable
+	// for the amount of time. Disabled if zero.
+	UnreachableTimeout time.Duration
+	// The path of the file to which the vSphere API calls are logged.
+	// Disabled if empty.
+	APILogPath string
+	// The PEM file and the PEM-encoded certificates of the certificate
+	// authorities to trust in addition to the system certificate pool.
+	CACertFile string
+	CACertPEM  string
+	// The client certificate and private key files for mutual TLS. The
+	// certificate is also used to log in if no username is provided.
+	ClientCertFile string
+	ClientKeyFile  string
+	// The SAML token issued by vCenter Single Sign-On, or the path of a file
+	// that contains it, to log in instead of the username and password.
+	SAMLToken     string
+	SAMLTokenFile string
+	// The clone ticket of an existing session to log in instead of the
+	// username and password.
+	SessionTicket string
 }
 
 func NewDriver(config *ConnectConfig) (Driver, error) {
@@ -92,21 +149,79 @@ func NewDriver(config *ConnectConfig) (Driver, error) {
 	credentials := url.UserPassword(config.Username, config.Password)
 	vcenterUrl.User = credentials
 
-	soapClient := soap.NewClient(vcenterUrl, config.InsecureConnection)
-	vimClient, err := vim25.NewClient(ctx, soapClient)
+	configureTLS, err := config.configureTLS()
 	if err != nil {
 		return nil, err
 	}
 
+	// The transport that records or replays the interactions with vCenter
+	// Server, if enabled.
+	var recording http.RoundTripper
+	if config.SessionCache && (os.Getenv(EnvRecord) != "" || os.Getenv(EnvReplay) != "") {
+		return nil, fmt.Errorf("%s and %s cannot be used with 'session_cache'", EnvRecord, EnvReplay)
+	}
+
+	var sessionCache *sessionCache
+	vimClient := new(vim25.Client)
+	if config.SessionCache {
+		sessionCache, err = newSessionCache(vcenterUrl, config.InsecureConnection, config.SessionCacheDir, configureTLS)
+		if err != nil {
+			return nil, err
+		}
+		if err := sessionCache.Login(ctx, vimClient); err != nil {
+			return nil, err
+		}
+	} else {
+		soapClient := soap.NewClient(vcenterUrl, config.InsecureConnection)
+		if err := configureTLS(soapClient); err != nil {
+			return nil, err
+		}
+		recording, err = recordingTransport(soapClient.Client.Transport)
+		if err != nil {
+			return nil, err
+		}
+		if recording != nil {
+			soapClient.Client.Transport = recording
+		}
+		if r, ok := recording.(*recorder); ok {
+			// The recording is closed by Cleanup, or here if the driver
+			// cannot be created.
+			defer func() {
+				if err != nil {
+					_ = r.Close()
+				}
+			}()
+		}
+		vimClient, err = vim25.NewClient(ctx, soapClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	vimClient.RoundTripper = session.KeepAlive(vimClient.RoundTripper, 10*time.Minute)
+	var l *apiLog
+	if config.APILogPath != "" {
+		if l, err = newAPILog(vimClient.RoundTripper, config.APILogPath); err != nil {
+			return nil, err
+		}
+		vimClient.RoundTripper = l
+	}
+	var w *watchdog
+	if config.UnreachableTimeout > 0 {
+		w = newWatchdog(vimClient.RoundTripper, config.UnreachableTimeout)
+		vimClient.RoundTripper = w
+	}
 	client := &govmomi.Client{
 		Client:         vimClient,
 		SessionManager: session.NewManager(vimClient),
 	}
 
-	err = client.SessionManager.Login(ctx, credentials)
-	if err != nil {
-		return nil, err
+	var restLogin func(context.Context, *rest.Client) error
+	if sessionCache == nil {
+		restLogin, err = config.login(ctx, client, credentials)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	finder := find.NewFinder(client.Client, false)
@@ -116,34 +231,98 @@ func NewDriver(config *ConnectConfig) (Driver, error) {
 	}
 	finder.SetDatacenter(datacenter)
 
+	// A standalone ESXi host has no REST API, content libraries, or clusters.
+	standaloneHost := !vimClient.IsVC()
+	if standaloneHost {
+		log.Printf("[INFO] Connected to standalone ESXi host %s; features that require vCenter Server are not available.", config.VCenterServer)
+	}
+
+	restClient := rest.NewClient(vimClient)
+	if recording != nil {
+		restClient.Client.Client.Transport = recording
+	}
+
 	d := &VCenterDriver{
 		ctx:       ctx,
 		client:    client,
 		vimClient: vimClient,
 		restClient: &RestClient{
-			client:      rest.NewClient(vimClient),
-			credentials: credentials,
+			client:         restClient,
+			credentials:    credentials,
+			standaloneHost: standaloneHost,
+			sessionCache:   sessionCache,
+			login:          restLogin,
 		},
-		datacenter: datacenter,
-		finder:     finder,
+		datacenter:     datacenter,
+		finder:         finder,
+		standaloneHost: standaloneHost,
+		cachedSession:  sessionCache != nil,
+		taskRetryCount: config.TaskRetryCount,
+		taskRetryDelay: config.TaskRetryDelay,
+		watchdog:       w,
+		apiLog:         l,
+	}
+	if r, ok := recording.(*recorder); ok {
+		d.recorder = r
+	}
+	if w != nil {
+		w.start()
 	}
 	return d, nil
 }
 
 func (d *VCenterDriver) Cleanup() (error, error) {
-	return d.restClient.client.Logout(d.ctx), d.client.SessionManager.Logout(d.ctx)
+	if d.recorder != nil {
+		// The recording is closed last to include the calls to log out.
+		defer d.recorder.Close()
+	}
+	if d.apiLog != nil {
+		// The log is closed last to include the calls to log out.
+		defer d.apiLog.Close()
+	}
+	if d.watchdog != nil {
+		defer d.watchdog.Stop()
+	}
+	if d.cachedSession {
+		// The cached sessions remain valid for other builds.
+		return nil, nil
+	}
+	return d.restClient.Logout(d.ctx), d.client.SessionManager.Logout(d.ctx)
+}
+
+// errVCenterRequired returns an error for a feature that is not available
+// when connected directly to a standalone ESXi host.
+func errVCenterRequired(feature string) error {
+	return fmt.Errorf("%s requires vCenter Server and is not supported on a standalone ESXi host", feature)
 }
 
 // RestClient manages RESTful interactions with vCenter, handling client initialization and credential storage.
 type RestClient struct {
-	client      *rest.Client
-	credentials *url.Userinfo
+	client         *rest.Client
+	credentials    *url.Userinfo
+	standaloneHost bool
+	sessionCache   *sessionCache
+	// Logs in instead of the credentials if the session is not created from
+	// the username and password.
+	login func(context.Context, *rest.Client) error
 }
 
 func (r *RestClient) Login(ctx context.Context) error {
+	if r.standaloneHost {
+		return errVCenterRequired("the vSphere Automation API")
+	}
+	if r.sessionCache != nil {
+		return r.sessionCache.Login(ctx, r.client)
+	}
+	if r.login != nil {
+		return r.login(ctx, r.client)
+	}
 	return r.client.Login(ctx, r.credentials)
 }
 
 func (r *RestClient) Logout(ctx context.Context) error {
+	if r.standaloneHost || r.sessionCache != nil {
+		return nil
+	}
 	return r.client.Logout(ctx)
 }
@@ -4,9 +4,11 @@
 package driver
 
 import (
+	"context"
 	"fmt"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -18,6 +20,10 @@ type DriverMock struct {
 	FindDatastoreHost   string
 	FindDatastoreErr    error
 
+	HostLocalDatastoresHost   string
+	HostLocalDatastoresResult []DatastoreSummary
+	HostLocalDatastoresErr    error
+
 	PreCleanShouldFail bool
 	PreCleanVMCalled   bool
 	PreCleanForce      bool
@@ -28,8 +34,59 @@ type DriverMock struct {
 	CreateConfig       *CreateConfig
 	VM                 VirtualMachine
 
+	ImportOvfCalled bool
+	ImportOvfConfig *ImportOvfConfig
+	ImportOvfErr    error
+
+	DeployLibraryItemCalled bool
+	DeployLibraryItemConfig *DeployLibraryItemConfig
+	DeployLibraryItemErr    error
+
 	FindVMCalled bool
 	FindVMName   string
+
+	PlacementCapacityCalled bool
+	PlacementCapacityResult *PlacementCapacity
+	PlacementCapacityErr    error
+
+	DefaultKeyProviderCalled bool
+	DefaultKeyProviderResult string
+	DefaultKeyProviderErr    error
+
+	CheckEncryptionCalled      bool
+	CheckEncryptionKeyProvider string
+	CheckEncryptionResult      string
+	CheckEncryptionErr         error
+
+	RemoveEncryptionKeyCalled      bool
+	RemoveEncryptionKeyKeyProvider string
+	RemoveEncryptionKeyKeyID       string
+	RemoveEncryptionKeyErr         error
+
+	FindStoragePolicyNames  []string
+	FindStoragePolicyResult map[string]string
+	FindStoragePolicyErr    error
+
+	FindOrCreateTagNames  []string
+	FindOrCreateTagCreate bool
+	FindOrCreateTagResult map[string]string
+	FindOrCreateTagErr    error
+
+	FindCustomAttributeKeyNames  []string
+	FindCustomAttributeKeyResult map[string]int32
+	FindCustomAttributeKeyErr    error
+
+	FindOrCreateCustomAttributeKeyNames  []string
+	FindOrCreateCustomAttributeKeyResult map[string]int32
+	FindOrCreateCustomAttributeKeyErr    error
+
+	PreflightCalled bool
+	PreflightSpec   *PreflightSpec
+	PreflightResult []error
+
+	MissingBuildPrivilegesSpec   *PreflightSpec
+	MissingBuildPrivilegesResult []MissingPrivileges
+	MissingBuildPrivilegesErr    error
 }
 
 func NewDriverMock() *DriverMock {
@@ -83,10 +140,39 @@ func (d *DriverMock) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 	return d.VM, nil
 }
 
+func (d *DriverMock) ImportOvf(ctx context.Context, config *ImportOvfConfig) (VirtualMachine, error) {
+	d.ImportOvfCalled = true
+	d.ImportOvfConfig = config
+	if d.ImportOvfErr != nil {
+		return nil, d.ImportOvfErr
+	}
+	if d.VM == nil {
+		d.VM = new(VirtualMachineMock)
+	}
+	return d.VM, nil
+}
+
+func (d *DriverMock) DeployLibraryItem(ctx context.Context, config *DeployLibraryItemConfig) (VirtualMachine, error) {
+	d.DeployLibraryItemCalled = true
+	d.DeployLibraryItemConfig = config
+	if d.DeployLibraryItemErr != nil {
+		return nil, d.DeployLibraryItemErr
+	}
+	if d.VM == nil {
+		d.VM = new(VirtualMachineMock)
+	}
+	return d.VM, nil
+}
+
 func (d *DriverMock) NewDatastore(ref *types.ManagedObjectReference) Datastore { return nil }
 
 func (d *DriverMock) GetDatastoreName(id string) (string, error) { return "", nil }
 
+func (d *DriverMock) HostLocalDatastores(host string) ([]DatastoreSummary, error) {
+	d.HostLocalDatastoresHost = host
+	return d.HostLocalDatastoresResult, d.HostLocalDatastoresErr
+}
+
 func (d *DriverMock) GetDatastoreFilePath(datastoreID, dir, filename string) (string, error) {
 	return "", nil
 }
@@ -111,6 +197,78 @@ func (d *DriverMock) FindResourcePool(cluster string, host string, name string)
 	return nil, nil
 }
 
+func (d *DriverMock) PlacementCapacity(cluster string, host string, datastore string) (*PlacementCapacity, error) {
+	d.PlacementCapacityCalled = true
+	return d.PlacementCapacityResult, d.PlacementCapacityErr
+}
+
+func (d *DriverMock) DefaultKeyProvider(cluster string, host string) (string, error) {
+	d.DefaultKeyProviderCalled = true
+	return d.DefaultKeyProviderResult, d.DefaultKeyProviderErr
+}
+
+func (d *DriverMock) CheckEncryption(keyProvider string, cluster string, host string, resourcePool string) (string, error) {
+	d.CheckEncryptionCalled = true
+	d.CheckEncryptionKeyProvider = keyProvider
+	return d.CheckEncryptionResult, d.CheckEncryptionErr
+}
+
+func (d *DriverMock) RemoveEncryptionKey(keyProvider string, keyID string) error {
+	d.RemoveEncryptionKeyCalled = true
+	d.RemoveEncryptionKeyKeyProvider = keyProvider
+	d.RemoveEncryptionKeyKeyID = keyID
+	return d.RemoveEncryptionKeyErr
+}
+
+func (d *DriverMock) FindStoragePolicy(name string) (string, error) {
+	d.FindStoragePolicyNames = append(d.FindStoragePolicyNames, name)
+	if d.FindStoragePolicyErr != nil {
+		return "", d.FindStoragePolicyErr
+	}
+	return d.FindStoragePolicyResult[name], nil
+}
+
+func (d *DriverMock) FindOrCreateTag(categoryName string, tagName string, create bool) (string, error) {
+	name := categoryName + "/" + tagName
+	d.FindOrCreateTagNames = append(d.FindOrCreateTagNames, name)
+	d.FindOrCreateTagCreate = create
+	if d.FindOrCreateTagErr != nil {
+		return "", d.FindOrCreateTagErr
+	}
+	return d.FindOrCreateTagResult[name], nil
+}
+
+func (d *DriverMock) FindCustomAttributeKey(name string) (int32, error) {
+	d.FindCustomAttributeKeyNames = append(d.FindCustomAttributeKeyNames, name)
+	if d.FindCustomAttributeKeyErr != nil {
+		return 0, d.FindCustomAttributeKeyErr
+	}
+	key, ok := d.FindCustomAttributeKeyResult[name]
+	if !ok {
+		return 0, object.ErrKeyNameNotFound
+	}
+	return key, nil
+}
+
+func (d *DriverMock) FindOrCreateCustomAttributeKey(name string) (int32, error) {
+	d.FindOrCreateCustomAttributeKeyNames = append(d.FindOrCreateCustomAttributeKeyNames, name)
+	if d.FindOrCreateCustomAttributeKeyErr != nil {
+		return 0, d.FindOrCreateCustomAttributeKeyErr
+	}
+	return d.FindOrCreateCustomAttributeKeyResult[name], nil
+}
+
+func (d *DriverMock) Preflight(spec *PreflightSpec) []error {
+	d.PreflightCalled = true
+	d.PreflightSpec = spec
+	return d.PreflightResult
+}
+
+func (d *DriverMock) MissingBuildPrivileges(spec *PreflightSpec) ([]MissingPrivileges, error) {
+	d.MissingBuildPrivilegesSpec = spec
+	return d.MissingBuildPrivilegesResult, d.MissingBuildPrivilegesErr
+}
+
 func (d *DriverMock) FindContentLibraryByName(name string) (*Library, error) { return nil, nil }
 
 func (d *DriverMock) FindContentLibraryItem(libraryId string, name string) (*library.Item, error) {
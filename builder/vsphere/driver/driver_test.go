@@ -151,16 +151,64 @@ func (s *VCenterSimulator) NewSimulatorDriver() (*VCenterDriver, error) {
 	}
 	finder.SetDatacenter(datacenter)
 
+	standaloneHost := !vimClient.IsVC()
 	d := &VCenterDriver{
 		ctx:       ctx,
 		client:    client,
 		vimClient: vimClient,
 		restClient: &RestClient{
-			client:      rest.NewClient(vimClient),
-			credentials: user,
+			client:         rest.NewClient(vimClient),
+			credentials:    user,
+			standaloneHost: standaloneHost,
 		},
-		datacenter: datacenter,
-		finder:     finder,
+		datacenter:     datacenter,
+		finder:         finder,
+		standaloneHost: standaloneHost,
 	}
 	return d, nil
 }
+
+func TestVCenterDriver_StandaloneHost(t *testing.T) {
+	sim, err := NewCustomVCenterSimulator(simulator.ESX())
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	if !sim.driver.standaloneHost {
+		t.Fatal("unexpected result: expected a standalone ESXi host")
+	}
+
+	host, err := sim.driver.FindHost("esxi-01.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if host == nil {
+		t.Fatal("unexpected result: expected the standalone ESXi host")
+	}
+
+	pool, err := sim.driver.FindResourcePool("", "esxi-01.example.com", "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := pool.Info("parent")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Parent == nil || info.Parent.Type != "ComputeResource" {
+		t.Fatalf("unexpected result: expected the root resource pool, but returned '%v'", info.Parent)
+	}
+
+	if _, err := sim.driver.FindContentLibraryByName("library"); err == nil {
+		t.Fatal("unexpected success: expected content libraries to require vCenter Server")
+	}
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if _, err := vm.Clone(context.TODO(), &CloneConfig{Name: "clone"}); err == nil {
+		t.Fatal("unexpected success: expected cloning to require vCenter Server")
+	}
+
+	if errRest, errSoap := sim.driver.Cleanup(); errRest != nil || errSoap != nil {
+		t.Fatalf("unexpected error: '%v', '%v'", errRest, errSoap)
+	}
+}
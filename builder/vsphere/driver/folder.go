@@ -30,12 +30,22 @@ func (d *VCenterDriver) NewFolder(ref *types.ManagedObjectReference) *Folder {
 // on the provided folder name. Returns a pointer to the Folder object or an
 // error if the operation fails.
 func (d *VCenterDriver) FindFolder(name string) (*Folder, error) {
+	folder, _, err := d.FindOrCreateFolder(name)
+	return folder, err
+}
+
+// FindOrCreateFolder locates or creates a folder structure like FindFolder,
+// and also returns the folders that were created, from the outermost to the
+// innermost, so that they can be removed if the operation that required them
+// fails. The folders created before an error are returned with the error.
+func (d *VCenterDriver) FindOrCreateFolder(name string) (*Folder, []*Folder, error) {
+	var created []*Folder
 	if name != "" {
 		// If the folder does not exist, create it.
 		parent := ""
 		parentFolder, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm"))
 		if err != nil {
-			return nil, err
+			return nil, created, err
 		}
 		folders := strings.Split(name, "/")
 		for _, folder := range folders {
@@ -43,9 +53,12 @@ func (d *VCenterDriver) FindFolder(name string) (*Folder, error) {
 			f, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm", parent))
 			if _, ok := err.(*find.NotFoundError); ok {
 				f, err = parentFolder.CreateFolder(d.ctx, folder)
+				if err == nil {
+					created = append(created, &Folder{folder: f, driver: d})
+				}
 			}
 			if err != nil {
-				return nil, err
+				return nil, created, err
 			}
 			parentFolder = f
 		}
@@ -53,13 +66,22 @@ func (d *VCenterDriver) FindFolder(name string) (*Folder, error) {
 
 	f, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm", name))
 	if err != nil {
-		return nil, err
+		return nil, created, err
 	}
 
 	return &Folder{
 		folder: f,
 		driver: d,
-	}, nil
+	}, created, nil
+}
+
+// Destroy removes the folder from the inventory.
+func (f *Folder) Destroy() error {
+	task, err := f.folder.Destroy(f.driver.ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(f.driver.ctx)
 }
 
 // Info retrieves properties of the folder object with optional filters specified
@@ -4,6 +4,8 @@
 package driver
 
 import (
+	"log"
+
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -25,8 +27,16 @@ func (d *VCenterDriver) NewHost(ref *types.ManagedObjectReference) *Host {
 
 // FindHost locates a host within the vCenter environment by its name. Returns
 // a Host object or an error if not found or if the retrieval process fails.
+// When connected to a standalone ESXi host, the host itself is returned if no
+// host matches the name.
 func (d *VCenterDriver) FindHost(name string) (*Host, error) {
 	h, err := d.finder.HostSystem(d.ctx, name)
+	if err != nil && d.standaloneHost {
+		// The inventory name of a standalone ESXi host is often not the
+		// name or address used to connect to it.
+		log.Printf("[WARN] Host %s not found. Using the standalone ESXi host.", name)
+		h, err = d.finder.DefaultHostSystem(d.ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
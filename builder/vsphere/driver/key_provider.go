@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"github.com/vmware/govmomi/crypto"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// DefaultKeyProvider returns the identifier of the default key provider of
+// the host, or of the cluster if no host is specified, or an empty string if
+// no default key provider is configured. The default key provider of vCenter
+// Server is used if the host or the cluster has no default key provider or if
+// neither is specified. A default key provider is required to add a virtual
+// trusted platform module to a virtual machine.
+func (d *VCenterDriver) DefaultKeyProvider(cluster string, host string) (string, error) {
+	m, err := crypto.GetManagerKmip(d.vimClient)
+	if err != nil {
+		return "", err
+	}
+
+	entity, err := d.keyProviderEntity(cluster, host)
+	if err != nil {
+		return "", err
+	}
+	return m.GetDefaultKmsClusterID(d.ctx, entity, true)
+}
+
+// keyProviderEntity returns the host or the cluster whose default key provider
+// is used, or nil for the default key provider of vCenter Server.
+func (d *VCenterDriver) keyProviderEntity(cluster string, host string) (*types.ManagedObjectReference, error) {
+	switch {
+	case host != "":
+		h, err := d.FindHost(host)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewReference(h.host.Reference()), nil
+	case cluster != "":
+		c, err := d.FindCluster(cluster)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewReference(c.cluster.Reference()), nil
+	}
+	return nil, nil
+}
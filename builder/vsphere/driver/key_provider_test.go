@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/crypto"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVCenterDriver_DefaultKeyProvider(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	// The simulator returns a fault if no default key provider is configured.
+	if provider, _ := sim.driver.DefaultKeyProvider("", ""); provider != "" {
+		t.Fatalf("unexpected result: expected no default key provider, but returned '%s'", provider)
+	}
+
+	m, err := crypto.GetManagerKmip(sim.driver.vimClient)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	err = m.RegisterKmsCluster(sim.driver.ctx, "native-provider", types.KmipClusterInfoKmsManagementTypeNativeProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := m.SetDefaultKmsClusterId(sim.driver.ctx, "native-provider", nil); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	provider, err := sim.driver.DefaultKeyProvider("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if provider != "native-provider" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "native-provider", provider)
+	}
+
+	// The default key provider of the cluster is used for the cluster.
+	cluster, err := sim.driver.FindCluster("DC0_C0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	err = m.RegisterKmsCluster(sim.driver.ctx, "cluster-provider", types.KmipClusterInfoKmsManagementTypeNativeProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	ref := cluster.cluster.Reference()
+	if err := m.SetDefaultKmsClusterId(sim.driver.ctx, "cluster-provider", &ref); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	provider, err = sim.driver.DefaultKeyProvider("DC0_C0", "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if provider != "cluster-provider" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "cluster-provider", provider)
+	}
+}
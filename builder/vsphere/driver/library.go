@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 type Library struct {
@@ -20,6 +22,9 @@ type Library struct {
 // FindContentLibraryByName retrieves a content library by its name. Returns a
 // Library object or an error if the library is not found.
 func (d *VCenterDriver) FindContentLibraryByName(name string) (*Library, error) {
+	if d.standaloneHost {
+		return nil, errVCenterRequired("content libraries")
+	}
 	lm := library.NewManager(d.restClient.client)
 	l, err := lm.GetLibraryByName(d.ctx, name)
 	if err != nil {
@@ -35,6 +40,9 @@ func (d *VCenterDriver) FindContentLibraryByName(name string) (*Library, error)
 // the specified library ID.  Returns the library item if found or an error if
 // the item is not found or the retrieval process fails.
 func (d *VCenterDriver) FindContentLibraryItem(libraryId string, name string) (*library.Item, error) {
+	if d.standaloneHost {
+		return nil, errVCenterRequired("content libraries")
+	}
 	lm := library.NewManager(d.restClient.client)
 	items, err := lm.GetLibraryItems(d.ctx, libraryId)
 	if err != nil {
@@ -123,6 +131,121 @@ func (d *VCenterDriver) UpdateContentLibraryItem(item *library.Item, name string
 	return lm.UpdateLibraryItem(d.ctx, item)
 }
 
+// ContentLibraryItem is a content library item with the library it belongs to
+// and the tags attached to it.
+type ContentLibraryItem struct {
+	Item    library.Item
+	Library library.Library
+	Tags    []ContentLibraryItemTag
+}
+
+// ContentLibraryItemTag is a tag attached to a content library item.
+type ContentLibraryItemTag struct {
+	Category string
+	Name     string
+}
+
+// FindContentLibraryItems retrieves the items of the content library with the
+// specified name, or of all content libraries if the name is empty. The tags
+// attached to each item are retrieved if withTags is true.
+func (d *VCenterDriver) FindContentLibraryItems(libraryName string, withTags bool) ([]ContentLibraryItem, error) {
+	if err := d.restClient.Login(d.ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = d.restClient.Logout(d.ctx)
+	}()
+
+	lm := library.NewManager(d.restClient.client)
+
+	var libraries []library.Library
+	if libraryName != "" {
+		l, err := lm.GetLibraryByName(d.ctx, libraryName)
+		if err != nil {
+			return nil, err
+		}
+		libraries = append(libraries, *l)
+	} else {
+		l, err := lm.GetLibraries(d.ctx)
+		if err != nil {
+			return nil, err
+		}
+		libraries = l
+	}
+
+	tm := tags.NewManager(d.restClient.client)
+	categories := map[string]string{}
+
+	var items []ContentLibraryItem
+	for _, l := range libraries {
+		libraryItems, err := lm.GetLibraryItems(d.ctx, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range libraryItems {
+			i := ContentLibraryItem{Item: item, Library: l}
+			if withTags {
+				ref := types.ManagedObjectReference{Type: "com.vmware.content.library.Item", Value: item.ID}
+				attached, err := tm.GetAttachedTags(d.ctx, ref)
+				if err != nil {
+					return nil, err
+				}
+				for _, tag := range attached {
+					category, ok := categories[tag.CategoryID]
+					if !ok {
+						c, err := tm.GetCategory(d.ctx, tag.CategoryID)
+						if err != nil {
+							return nil, err
+						}
+						category = c.Name
+						categories[tag.CategoryID] = category
+					}
+					i.Tags = append(i.Tags, ContentLibraryItemTag{Category: category, Name: tag.Name})
+				}
+			}
+			items = append(items, i)
+		}
+	}
+	return items, nil
+}
+
+// FindContentLibraryItemPaths returns the content library path and the
+// datastore path of a content library item. For an item with an ISO file,
+// the paths refer to the ISO file, and the content library path can be used
+// in `iso_paths`. Otherwise, the paths refer to the item.
+func (d *VCenterDriver) FindContentLibraryItemPaths(item ContentLibraryItem) (string, string, error) {
+	if err := d.restClient.Login(d.ctx); err != nil {
+		return "", "", err
+	}
+	lm := library.NewManager(d.restClient.client)
+	files, err := lm.ListLibraryItemFiles(d.ctx, item.Item.ID)
+	_ = d.restClient.Logout(d.ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	libraryPath := path.Join(item.Library.Name, item.Item.Name)
+	for _, f := range files {
+		if strings.EqualFold(path.Ext(f.Name), ".iso") {
+			libraryPath = path.Join(libraryPath, f.Name)
+			datastorePath, err := d.FindContentLibraryFileDatastorePath(libraryPath)
+			if err != nil {
+				return "", "", err
+			}
+			return libraryPath, datastorePath, nil
+		}
+	}
+
+	if len(item.Library.Storage) == 0 {
+		return libraryPath, "", nil
+	}
+	datastoreName, err := d.GetDatastoreName(item.Library.Storage[0].DatastoreID)
+	if err != nil {
+		return "", "", err
+	}
+	return libraryPath, fmt.Sprintf("[%s] contentlib-%s/%s", datastoreName, item.Library.ID, item.Item.ID), nil
+}
+
 type LibraryFilePath struct {
 	path string
 }
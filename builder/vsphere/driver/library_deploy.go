@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/vcenter"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// DeployLibraryItemConfig is the configuration to deploy a content library
+// item as a virtual machine.
+type DeployLibraryItemConfig struct {
+	// The name of the content library.
+	Library string
+	// The name of the OVF or VM template item in the content library, or of
+	// the VM image in the namespace.
+	Item string
+	// The vSphere Namespace of the VM image. If set, the item is resolved
+	// from the VM images of the namespace instead of the content library.
+	Namespace    string
+	Name         string
+	Folder       string
+	Cluster      string
+	Host         string
+	ResourcePool string
+	Datastore    string
+	// The network to connect the networks of the item to. The networks of the
+	// item are kept if empty.
+	Network string
+	// The distributed virtual switch that owns the network, and the host
+	// that must have access to the network, if more than one network
+	// resolves to the name.
+	NetworkSwitch string
+	NetworkHost   string
+	Annotation    string
+	// The values of the vApp properties of the item.
+	Properties map[string]string
+	// The identifier of the storage policy of the virtual machine home and of
+	// the disks. The default policy of the datastore applies if empty.
+	StoragePolicyID   string
+	ConvertToTemplate bool
+}
+
+// DeployLibraryItem deploys an OVF or VM template item of a content library as
+// a virtual machine. The disks and the home of the virtual machine are placed
+// on the datastore of the configuration, and the networks of the item are
+// mapped to the network of the configuration.
+func (d *VCenterDriver) DeployLibraryItem(ctx context.Context, config *DeployLibraryItemConfig) (VirtualMachine, error) {
+	if d.standaloneHost {
+		return nil, errVCenterRequired("deploying from a content library")
+	}
+	if err := d.restClient.Login(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = d.restClient.Logout(ctx)
+	}()
+
+	item, err := d.findDeployItem(config)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := d.FindFolder(config.Folder)
+	if err != nil {
+		return nil, err
+	}
+	resourcePool, err := d.FindResourcePool(config.Cluster, config.Host, config.ResourcePool)
+	if err != nil {
+		return nil, err
+	}
+	if resourcePool.IsVApp() && config.ConvertToTemplate {
+		return nil, errVAppTemplate(config.ResourcePool)
+	}
+	var hostID string
+	if config.Host != "" {
+		h, err := d.FindHost(config.Host)
+		if err != nil {
+			return nil, err
+		}
+		hostID = h.host.Reference().Value
+	}
+	datastore, err := d.FindDatastore(config.Datastore, config.Host)
+	if err != nil {
+		return nil, err
+	}
+	var network *Network
+	if config.Network != "" {
+		host := config.Host
+		if config.NetworkHost != "" {
+			host = config.NetworkHost
+		}
+		ref, err := findNetwork(config.Network, host, config.NetworkSwitch, d)
+		if err != nil {
+			return nil, fmt.Errorf("error finding network: %s", err)
+		}
+		network = &Network{network: ref, driver: d}
+	}
+
+	vcm := vcenter.NewManager(d.restClient.client)
+	var ref *types.ManagedObjectReference
+	switch item.Type {
+	case library.ItemTypeOVF:
+		target := vcenter.Target{
+			ResourcePoolID: resourcePool.pool.Reference().Value,
+			HostID:         hostID,
+			FolderID:       folder.folder.Reference().Value,
+		}
+		deploy := vcenter.Deploy{
+			DeploymentSpec: vcenter.DeploymentSpec{
+				Name:               config.Name,
+				Annotation:         config.Annotation,
+				AcceptAllEULA:      true,
+				DefaultDatastoreID: datastore.Reference().Value,
+				StorageProfileID:   config.StoragePolicyID,
+			},
+			Target: target,
+		}
+		if network != nil {
+			filter, err := vcm.FilterLibraryItem(ctx, item.ID, vcenter.FilterRequest{Target: target})
+			if err != nil {
+				return nil, fmt.Errorf("error retrieving the networks of %s: %s", config.Item, err)
+			}
+			for _, name := range filter.Networks {
+				deploy.NetworkMappings = append(deploy.NetworkMappings, vcenter.NetworkMapping{
+					Key:   name,
+					Value: network.network.Reference().Value,
+				})
+			}
+		}
+		ref, err = vcm.DeployLibraryItem(ctx, item.ID, deploy)
+	case library.ItemTypeVMTX:
+		storage := &vcenter.DiskStorage{Datastore: datastore.Reference().Value}
+		if config.StoragePolicyID != "" {
+			storage.StoragePolicy = &vcenter.StoragePolicy{
+				Policy: config.StoragePolicyID,
+				Type:   "USE_SPECIFIED_POLICY",
+			}
+		}
+		ref, err = vcm.DeployTemplateLibraryItem(ctx, item.ID, vcenter.DeployTemplate{
+			Name:        config.Name,
+			Description: config.Annotation,
+			Placement: &vcenter.Placement{
+				ResourcePool: resourcePool.pool.Reference().Value,
+				Host:         hostID,
+				Folder:       folder.folder.Reference().Value,
+			},
+			VMHomeStorage: storage,
+			DiskStorage:   storage,
+		})
+	default:
+		return nil, fmt.Errorf("cannot deploy content library item %s of type %s; "+
+			"the item must be of type %s or %s", config.Item, item.Type, library.ItemTypeOVF, library.ItemTypeVMTX)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error deploying content library item %s: %s", config.Item, err)
+	}
+
+	vm := d.NewVM(ref).(*VirtualMachineDriver)
+	if err := configureDeployedVM(ctx, vm, item.Type, network, config.Properties); err != nil {
+		if destroyErr := vm.Destroy(); destroyErr != nil {
+			log.Printf("[WARN] error destroying the deployed virtual machine: %s", destroyErr)
+		}
+		return nil, err
+	}
+	return vm, nil
+}
+
+// findDeployItem returns the content library item of the configuration, which
+// is either an item of the content library or a VM image of the namespace.
+func (d *VCenterDriver) findDeployItem(config *DeployLibraryItemConfig) (*library.Item, error) {
+	if config.Namespace != "" {
+		return d.FindNamespaceImage(config.Namespace, config.Item)
+	}
+	l, err := d.FindContentLibraryByName(config.Library)
+	if err != nil {
+		return nil, fmt.Errorf("error finding content library %s: %s", config.Library, err)
+	}
+	return d.FindContentLibraryItem(l.library.ID, config.Item)
+}
+
+// configureDeployedVM sets the network and the vApp properties of a virtual
+// machine deployed from a content library item. The networks of a VM template
+// item cannot be mapped during the deployment, so the network adapter is
+// reconfigured afterward, the same as for a clone.
+func configureDeployedVM(ctx context.Context, vm *VirtualMachineDriver, itemType string, network *Network, properties map[string]string) error {
+	var configSpec types.VirtualMachineConfigSpec
+	if network != nil && itemType == library.ItemTypeVMTX {
+		backing, err := network.network.EthernetCardBackingInfo(ctx)
+		if err != nil {
+			return fmt.Errorf("error finding ethernet card backing info: %s", err)
+		}
+		devices, err := vm.vm.Device(ctx)
+		if err != nil {
+			return fmt.Errorf("error finding virtual machine devices: %s", err)
+		}
+		adapter, err := findNetworkAdapter(devices)
+		if err != nil {
+			return fmt.Errorf("error finding network adapter: %s", err)
+		}
+		adapter.GetVirtualEthernetCard().Backing = backing
+		configSpec.DeviceChange = append(configSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+			Device:    adapter.(types.BaseVirtualDevice),
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+		})
+	}
+	vAppConfig, err := vm.updateVAppConfig(ctx, properties)
+	if err != nil {
+		return fmt.Errorf("error updating VAppConfig: %s", err)
+	}
+	configSpec.VAppConfig = vAppConfig
+	if configSpec.VAppConfig != nil || len(configSpec.DeviceChange) > 0 {
+		if err := vm.Reconfigure(configSpec); err != nil {
+			return fmt.Errorf("error reconfiguring the deployed virtual machine: %s", err)
+		}
+	}
+	return nil
+}
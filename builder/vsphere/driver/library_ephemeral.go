@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The description of the ephemeral content libraries, which distinguishes
+// them from other libraries with the same name prefix when the expired
+// libraries are deleted.
+const ephemeralLibraryDescription = "Ephemeral content library created by Packer"
+
+// The suffix of the names of the ephemeral content libraries: the creation
+// time and the random hexadecimal suffix.
+const ephemeralLibrarySuffixPattern = `\d{14}-[0-9a-f]{8}`
+
+// EphemeralLibraryConfig is the configuration of a content library that is
+// created for a single build.
+type EphemeralLibraryConfig struct {
+	// The prefix of the name of the library, which is followed by the
+	// creation time and a random suffix to make the name unique.
+	Prefix string
+	// The name of the datastore that backs the library.
+	Datastore string
+	// Publish the library, so that it can be subscribed to.
+	Publish bool
+}
+
+// ephemeralLibraryName returns a unique name for an ephemeral library.
+func ephemeralLibraryName(prefix string, now time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s-%s", prefix, now.UTC().Format("20060102150405"), hex.EncodeToString(suffix)), nil
+}
+
+// isExpiredEphemeralLibrary reports whether the library is an ephemeral
+// library with the name prefix that was created before the deadline. The
+// whole name must match, so that the prefix does not match the libraries of
+// a longer prefix, such as `packer-web-prod-` for `packer-web-`.
+func isExpiredEphemeralLibrary(l library.Library, prefix string, deadline time.Time) bool {
+	matched, _ := regexp.MatchString("^"+regexp.QuoteMeta(prefix)+ephemeralLibrarySuffixPattern+"$", l.Name)
+	if !matched || l.Description == nil || *l.Description != ephemeralLibraryDescription {
+		return false
+	}
+	return l.CreationTime != nil && l.CreationTime.Before(deadline)
+}
+
+// CreateEphemeralContentLibrary creates a uniquely named local content
+// library on the datastore and returns it.
+func (vm *VirtualMachineDriver) CreateEphemeralContentLibrary(config *EphemeralLibraryConfig) (*library.Library, error) {
+	if vm.driver.standaloneHost {
+		return nil, errVCenterRequired("content libraries")
+	}
+	ds, err := vm.driver.FindDatastore(config.Datastore, "")
+	if err != nil {
+		return nil, err
+	}
+	name, err := ephemeralLibraryName(config.Prefix, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return nil, err
+	}
+	defer vm.logout()
+
+	spec := library.Library{
+		Name:        name,
+		Description: types.New(ephemeralLibraryDescription),
+		Type:        "LOCAL",
+		Storage: []library.StorageBacking{{
+			DatastoreID: ds.Reference().Value,
+			Type:        "DATASTORE",
+		}},
+	}
+	if config.Publish {
+		spec.Publication = &library.Publication{
+			AuthenticationMethod: "NONE",
+			Published:            &config.Publish,
+		}
+	}
+
+	lm := library.NewManager(vm.driver.restClient.client)
+	id, err := lm.CreateLibrary(vm.driver.ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("error creating content library %s: %s", name, err)
+	}
+	return lm.GetLibraryByID(vm.driver.ctx, id)
+}
+
+// DeleteContentLibrary deletes the content library and its items.
+func (vm *VirtualMachineDriver) DeleteContentLibrary(id string) error {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	lm := library.NewManager(vm.driver.restClient.client)
+	return lm.DeleteLibrary(vm.driver.ctx, &library.Library{ID: id})
+}
+
+// DeleteExpiredEphemeralContentLibraries deletes the ephemeral content
+// libraries with the name prefix that were created more than the retention
+// period ago. Returns the names of the deleted libraries.
+func (vm *VirtualMachineDriver) DeleteExpiredEphemeralContentLibraries(prefix string, retention time.Duration) ([]string, error) {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return nil, err
+	}
+	defer vm.logout()
+
+	lm := library.NewManager(vm.driver.restClient.client)
+	libraries, err := lm.GetLibraries(vm.driver.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	deadline := time.Now().Add(-retention)
+	for i := range libraries {
+		l := &libraries[i]
+		if !isExpiredEphemeralLibrary(*l, prefix, deadline) {
+			continue
+		}
+		log.Printf("Deleting expired ephemeral content library %s", l.Name)
+		if err := lm.DeleteLibrary(vm.driver.ctx, l); err != nil {
+			return deleted, fmt.Errorf("error deleting content library %s: %s", l.Name, err)
+		}
+		deleted = append(deleted, l.Name)
+	}
+	return deleted, nil
+}
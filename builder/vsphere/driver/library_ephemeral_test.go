@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestIsExpiredEphemeralLibrary(t *testing.T) {
+	now := time.Now()
+	created := types.NewTime(now.Add(-2 * time.Hour))
+
+	tc := []struct {
+		name     string
+		library  library.Library
+		expected bool
+	}{
+		{
+			name:     "Expired",
+			library:  library.Library{Name: "packer-vm-20240101000000-0a1b2c3d", Description: types.New(ephemeralLibraryDescription), CreationTime: created},
+			expected: true,
+		},
+		{
+			name:    "Not expired",
+			library: library.Library{Name: "packer-vm-20240101000000-0a1b2c3d", Description: types.New(ephemeralLibraryDescription), CreationTime: types.NewTime(now)},
+		},
+		{
+			name:    "Other prefix",
+			library: library.Library{Name: "packer-other-20240101000000-0a1b2c3d", Description: types.New(ephemeralLibraryDescription), CreationTime: created},
+		},
+		{
+			name:    "Longer prefix",
+			library: library.Library{Name: "packer-vm-prod-20240101000000-0a1b2c3d", Description: types.New(ephemeralLibraryDescription), CreationTime: created},
+		},
+		{
+			name:    "Other suffix",
+			library: library.Library{Name: "packer-vm-templates", Description: types.New(ephemeralLibraryDescription), CreationTime: created},
+		},
+		{
+			name:    "Not ephemeral",
+			library: library.Library{Name: "packer-vm-20240101000000-0a1b2c3d", Description: types.New("Templates"), CreationTime: created},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := isExpiredEphemeralLibrary(c.library, "packer-vm-", now.Add(-time.Hour)); actual != c.expected {
+				t.Fatalf("unexpected result: expected '%t', but returned '%t'", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineDriver_EphemeralContentLibrary(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.driver.restClient.credentials = simulator.DefaultLogin
+
+	ds, simDs := sim.ChooseSimulatorPreCreatedDatastore()
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	vmDriver := vm.(*VirtualMachineDriver)
+
+	l, err := vmDriver.CreateEphemeralContentLibrary(&EphemeralLibraryConfig{
+		Prefix:    "packer-vm-",
+		Datastore: simDs.Name,
+		Publish:   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !strings.HasPrefix(l.Name, "packer-vm-") {
+		t.Fatalf("unexpected result: expected the prefix 'packer-vm-', but returned '%s'", l.Name)
+	}
+	if l.Publication == nil || l.Publication.PublishURL == "" {
+		t.Fatal("unexpected result: expected the publish URL of the library")
+	}
+
+	// A library with the prefix that was not created as ephemeral is kept.
+	ctx := context.TODO()
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	lm := library.NewManager(sim.driver.restClient.client)
+	if _, err := lm.CreateLibrary(ctx, library.Library{
+		Name:    "packer-vm-templates",
+		Type:    "LOCAL",
+		Storage: []library.StorageBacking{{DatastoreID: ds.Reference().Value, Type: "DATASTORE"}},
+	}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The ephemeral library of another build with a longer prefix is kept.
+	other, err := vmDriver.CreateEphemeralContentLibrary(&EphemeralLibraryConfig{
+		Prefix:    "packer-vm-prod-",
+		Datastore: simDs.Name,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	deleted, err := vmDriver.DeleteExpiredEphemeralContentLibraries("packer-vm-", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("unexpected result: expected no deleted libraries, but returned '%v'", deleted)
+	}
+
+	deleted, err = vmDriver.DeleteExpiredEphemeralContentLibraries("packer-vm-", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(deleted) != 1 || deleted[0] != l.Name {
+		t.Fatalf("unexpected result: expected '[%s]', but returned '%v'", l.Name, deleted)
+	}
+
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	kept, err := lm.GetLibraryByName(ctx, "packer-vm-templates")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vmDriver.DeleteContentLibrary(kept.ID); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := lm.GetLibraryByName(ctx, "packer-vm-templates"); err == nil {
+		t.Fatal("unexpected success: expected the library to be deleted")
+	}
+
+	deleted, err = vmDriver.DeleteExpiredEphemeralContentLibraries("packer-vm-prod-", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(deleted) != 1 || deleted[0] != other.Name {
+		t.Fatalf("unexpected result: expected '[%s]', but returned '%v'", other.Name, deleted)
+	}
+}
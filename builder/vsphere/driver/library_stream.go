@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// StreamOvfConfig is the configuration to stream the export of a virtual
+// machine to an OVF template in a content library item.
+type StreamOvfConfig struct {
+	Library     string
+	Name        string
+	Description string
+	// The OVF export options, such as `mac` and `extraconfig`.
+	ExportOptions []string
+}
+
+// StreamOvfToContentLibrary exports the virtual machine to an OVF template and
+// streams the exported files to an update session of the content library
+// item, without staging the files on the Packer host. The item is created if
+// it does not exist. An active update session of the item, such as one left
+// by an interrupted build, is reused instead of failing to create a new one.
+func (vm *VirtualMachineDriver) StreamOvfToContentLibrary(ctx context.Context, config *StreamOvfConfig) error {
+	if err := vm.driver.restClient.Login(ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	l, err := vm.driver.FindContentLibraryByName(config.Library)
+	if err != nil {
+		return err
+	}
+	if l.library.Type != "LOCAL" {
+		return fmt.Errorf("cannot stream an OVF template to the content library %s of type %s; "+
+			"the content library must be of type LOCAL", config.Library, l.library.Type)
+	}
+
+	lm := library.NewManager(vm.driver.restClient.client)
+	itemID, err := vm.driver.ensureOvfLibraryItem(ctx, lm, l.library.ID, config.Name, config.Description)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := libraryItemUpdateSession(ctx, lm, itemID)
+	if err != nil {
+		return fmt.Errorf("error creating an update session for content library item %s: %s", config.Name, err)
+	}
+
+	if err := vm.streamOvf(ctx, lm, sessionID, config); err != nil {
+		if failErr := lm.FailLibraryItemUpdateSession(context.TODO(), sessionID); failErr != nil {
+			log.Printf("[WARN] Error failing the update session %s: %s", sessionID, failErr)
+		}
+		return err
+	}
+
+	if err := lm.CompleteLibraryItemUpdateSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("error completing the update session of content library item %s: %s", config.Name, err)
+	}
+	return lm.WaitOnLibraryItemUpdateSession(ctx, sessionID, 3*time.Second, nil)
+}
+
+// ensureOvfLibraryItem returns the identifier of the content library item,
+// and creates an OVF template item if it does not exist. The description of an
+// existing item is updated if it differs.
+func (d *VCenterDriver) ensureOvfLibraryItem(ctx context.Context, lm *library.Manager, libraryID string, name string, description string) (string, error) {
+	item, err := d.FindContentLibraryItem(libraryID, name)
+	if err == nil {
+		if item.Description == nil || *item.Description != description {
+			if err := d.UpdateContentLibraryItem(item, name, description); err != nil {
+				return "", err
+			}
+		}
+		return item.ID, nil
+	}
+
+	return lm.CreateLibraryItem(ctx, library.Item{
+		Name:        name,
+		Description: &description,
+		Type:        library.ItemTypeOVF,
+		LibraryID:   libraryID,
+	})
+}
+
+// libraryItemUpdateSession returns the active update session of the content
+// library item, or creates one if there is none. The files of a reused
+// session are removed, so that the session only contains the files that are
+// added next.
+func libraryItemUpdateSession(ctx context.Context, lm *library.Manager, itemID string) (string, error) {
+	ids, err := lm.ListLibraryItemUpdateSession(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range ids {
+		session, err := lm.GetLibraryItemUpdateSession(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if session.LibraryItemID != itemID || session.State != "ACTIVE" {
+			continue
+		}
+
+		log.Printf("[INFO] Reusing the active update session %s of content library item %s", session.ID, itemID)
+		files, err := lm.ListLibraryItemUpdateSessionFile(ctx, session.ID)
+		if err != nil {
+			return "", err
+		}
+		for _, f := range files {
+			if err := lm.RemoveLibraryItemUpdateSessionFile(ctx, session.ID, f.Name); err != nil {
+				return "", err
+			}
+		}
+		return session.ID, nil
+	}
+
+	return lm.CreateLibraryItemUpdateSession(ctx, library.Session{LibraryItemID: itemID})
+}
+
+// streamOvf exports the virtual machine and adds the disks, the NVRAM, and
+// the OVF descriptor to the update session.
+func (vm *VirtualMachineDriver) streamOvf(ctx context.Context, lm *library.Manager, sessionID string, config *StreamOvfConfig) error {
+	lease, err := vm.vm.Export(ctx)
+	if err != nil {
+		return fmt.Errorf("error exporting virtual machine: %s", err)
+	}
+	info, err := lease.Wait(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	u := lease.StartUpdater(ctx, info)
+	defer u.Done()
+
+	cdp := types.OvfCreateDescriptorParams{
+		Name:         config.Name,
+		ExportOption: config.ExportOptions,
+	}
+	for _, item := range info.Items {
+		if !isStreamedExportFile(item.Path) {
+			continue
+		}
+		if !strings.HasPrefix(item.Path, config.Name) {
+			item.Path = config.Name + "-" + item.Path
+		}
+
+		log.Printf("[INFO] Streaming %s to content library item %s", item.Path, config.Name)
+		size, err := vm.streamFile(ctx, lm, sessionID, item)
+		if err != nil {
+			_ = lease.Abort(context.TODO(), nil)
+			return fmt.Errorf("error streaming %s to content library item %s: %s", item.Path, config.Name, err)
+		}
+
+		file := item.File()
+		file.Size = size
+		cdp.OvfFiles = append(cdp.OvfFiles, file)
+	}
+
+	if err := lease.Complete(ctx); err != nil {
+		return fmt.Errorf("unable to complete lease: %s", err)
+	}
+
+	desc, err := vm.CreateDescriptor(vm.NewOvfManager(), cdp)
+	if err != nil {
+		return fmt.Errorf("unable to create descriptor: %s", err)
+	}
+	if desc.Error != nil {
+		return fmt.Errorf("unable to create descriptor: %s", desc.Error[0].LocalizedMessage)
+	}
+
+	r := strings.NewReader(desc.OvfDescriptor)
+	return uploadLibraryItemFile(ctx, lm, sessionID, config.Name+".ovf", r, r.Size(), nil)
+}
+
+// streamFile downloads a file of the export lease and uploads it to the update
+// session as it is downloaded. Returns the number of bytes streamed.
+func (vm *VirtualMachineDriver) streamFile(ctx context.Context, lm *library.Manager, sessionID string, item nfc.FileItem) (int64, error) {
+	download := soap.DefaultDownload
+	r, size, err := vm.vm.Client().Download(ctx, item.URL, &download)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	counter := &countingReader{r: r}
+	if err := uploadLibraryItemFile(ctx, lm, sessionID, item.Path, counter, size, item); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
+
+// uploadLibraryItemFile adds a file to the update session and uploads the
+// content of the file. The size is -1 if it is not known in advance. The
+// progress of the upload is reported to the sink, if any, such as the item of
+// the export lease, so that the lease does not time out.
+func uploadLibraryItemFile(ctx context.Context, lm *library.Manager, sessionID string, name string, r io.Reader, size int64, s progress.Sinker) error {
+	spec := library.UpdateFile{
+		Name:       filepath.Base(name),
+		SourceType: "PUSH",
+	}
+	if size > 0 {
+		spec.Size = size
+	}
+	file, err := lm.AddLibraryItemFile(ctx, sessionID, spec)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(file.UploadEndpoint.URI)
+	if err != nil {
+		return err
+	}
+	upload := soap.DefaultUpload
+	upload.ContentLength = size
+	upload.Progress = s
+	return lm.Client.Upload(ctx, r, u, &upload)
+}
+
+// isStreamedExportFile reports whether an exported file is part of the OVF
+// template. Only the disks and the NVRAM are needed to deploy the template;
+// the log files are not streamed.
+func isStreamedExportFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".vmdk", ".nvram":
+		return true
+	}
+	return filepath.Base(path) == "nvram"
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func TestLibraryItemUpdateSession(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.driver.restClient.credentials = simulator.DefaultLogin
+
+	ctx := context.TODO()
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, ds := sim.ChooseSimulatorPreCreatedDatastore()
+	lm := library.NewManager(sim.driver.restClient.client)
+	libraryID, err := lm.CreateLibrary(ctx, library.Library{
+		Name:    "library",
+		Type:    "LOCAL",
+		Storage: []library.StorageBacking{{DatastoreID: ds.Reference().Value, Type: "DATASTORE"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	itemID, err := lm.CreateLibraryItem(ctx, library.Item{Name: "template", Type: library.ItemTypeOVF, LibraryID: libraryID})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	sessionID, err := libraryItemUpdateSession(ctx, lm, itemID)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The active session of an interrupted build is reused.
+	reused, err := libraryItemUpdateSession(ctx, lm, itemID)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if reused != sessionID {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", sessionID, reused)
+	}
+
+	// A session that is no longer active is not reused.
+	if err := lm.FailLibraryItemUpdateSession(ctx, sessionID); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	created, err := libraryItemUpdateSession(ctx, lm, itemID)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if created == sessionID {
+		t.Fatalf("unexpected result: expected a new update session")
+	}
+}
+
+func TestIsStreamedExportFile(t *testing.T) {
+	var streamed []string
+	for _, path := range []string{"disk-0.vmdk", "disk-1.vmdk", "nvram", "vmware.log"} {
+		if isStreamedExportFile(path) {
+			streamed = append(streamed, path)
+		}
+	}
+	if diff := cmp.Diff([]string{"disk-0.vmdk", "disk-1.vmdk", "nvram"}, streamed); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+}
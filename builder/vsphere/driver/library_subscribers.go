@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+// The interval between the checks of the synchronization of the subscribed
+// libraries.
+var subscriberSyncInterval = 10 * time.Second
+
+// subscribedLibrary is a subscribed library of a published content library
+// that synchronizes a published item.
+type subscribedLibrary struct {
+	summary  library.SubscriberSummary
+	local    bool
+	onDemand bool
+	// The last synchronization time of the item before it is published.
+	lastSync *time.Time
+}
+
+// PublishContentLibraryItem publishes the content library item to the
+// subscribed libraries of the content library, or to the subscribed libraries
+// with the names if specified, and waits until the subscribed libraries in the
+// same vCenter Server instance have synchronized the item. The subscribed
+// libraries in other vCenter Server instances are not waited for. The names of
+// the subscribed libraries that have not synchronized the item are reported
+// at each check.
+func (vm *VirtualMachineDriver) PublishContentLibraryItem(libraryName string, name string, subscribed []string, timeout time.Duration, report func(pending []string)) error {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	l, err := vm.driver.FindContentLibraryByName(libraryName)
+	if err != nil {
+		return err
+	}
+	item, err := vm.driver.FindContentLibraryItem(l.library.ID, name)
+	if err != nil {
+		return err
+	}
+
+	lm := library.NewManager(vm.driver.restClient.client)
+	summaries, err := lm.ListSubscribers(vm.driver.ctx, l.library)
+	if err != nil {
+		return fmt.Errorf("error listing the subscribed libraries of %s: %s", libraryName, err)
+	}
+
+	var libraries []*subscribedLibrary
+	var subscriptions []string
+	for _, summary := range summaries {
+		if len(subscribed) > 0 && !slices.Contains(subscribed, summary.LibraryName) {
+			continue
+		}
+		s := &subscribedLibrary{summary: summary}
+		subscriber, err := lm.GetSubscriber(vm.driver.ctx, l.library, summary.SubscriptionID)
+		if err != nil {
+			return fmt.Errorf("error getting the subscription of %s: %s", summary.LibraryName, err)
+		}
+		s.local = subscriber.LibraryLocation == "LOCAL"
+		if s.local {
+			sl, err := lm.GetLibraryByID(vm.driver.ctx, summary.LibraryID)
+			if err != nil {
+				return fmt.Errorf("error getting the subscribed library %s: %s", summary.LibraryName, err)
+			}
+			s.onDemand = sl.Subscription != nil && sl.Subscription.OnDemand != nil && *sl.Subscription.OnDemand
+			items, err := lm.GetLibraryItems(vm.driver.ctx, summary.LibraryID)
+			if err != nil {
+				return fmt.Errorf("error listing the items of the subscribed library %s: %s", summary.LibraryName, err)
+			}
+			if i := subscribedItem(items, item.ID); i != nil {
+				s.lastSync = i.LastSyncTime
+			}
+		}
+		libraries = append(libraries, s)
+		subscriptions = append(subscriptions, summary.SubscriptionID)
+	}
+
+	for _, name := range subscribed {
+		if !slices.ContainsFunc(libraries, func(s *subscribedLibrary) bool { return s.summary.LibraryName == name }) {
+			return fmt.Errorf("content library %s has no subscribed library %s", libraryName, name)
+		}
+	}
+	if len(libraries) == 0 {
+		return fmt.Errorf("content library %s has no subscribed libraries", libraryName)
+	}
+
+	log.Printf("Publishing content library item %s to %d subscribed libraries", name, len(subscriptions))
+	if err := lm.PublishLibraryItem(vm.driver.ctx, item, false, subscriptions); err != nil {
+		return fmt.Errorf("error publishing content library item %s: %s", name, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var pending []string
+		for _, s := range libraries {
+			if !s.local {
+				continue
+			}
+			items, err := lm.GetLibraryItems(vm.driver.ctx, s.summary.LibraryID)
+			if err != nil {
+				return fmt.Errorf("error listing the items of the subscribed library %s: %s", s.summary.LibraryName, err)
+			}
+			if !subscribedItemSynced(subscribedItem(items, item.ID), s.lastSync, s.onDemand) {
+				pending = append(pending, s.summary.LibraryName)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if report != nil {
+			report(pending)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the subscribed libraries to synchronize %s: %s", timeout, name, strings.Join(pending, ", "))
+		}
+		select {
+		case <-vm.driver.ctx.Done():
+			return vm.driver.ctx.Err()
+		case <-time.After(subscriberSyncInterval):
+		}
+	}
+}
+
+// subscribedItem returns the item of a subscribed library that is
+// synchronized from the published item, or nil if the item does not exist.
+func subscribedItem(items []library.Item, sourceID string) *library.Item {
+	for i := range items {
+		if items[i].SourceID == sourceID {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// subscribedItemSynced returns whether the item of a subscribed library has
+// been synchronized since the last synchronization before the item was
+// published. The content of the item must be cached, unless the subscribed
+// library synchronizes the content on demand.
+func subscribedItemSynced(item *library.Item, lastSync *time.Time, onDemand bool) bool {
+	if item == nil || item.LastSyncTime == nil {
+		return false
+	}
+	if lastSync != nil && !item.LastSyncTime.After(*lastSync) {
+		return false
+	}
+	return item.Cached || onDemand
+}
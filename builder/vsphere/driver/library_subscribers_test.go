@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+func TestSubscribedItemSynced(t *testing.T) {
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := before.Add(time.Minute)
+
+	items := []library.Item{
+		{ID: "other", SourceID: "source-other", LastSyncTime: &after, Cached: true},
+		{ID: "item", SourceID: "source", LastSyncTime: &after},
+	}
+	item := subscribedItem(items, "source")
+	if item == nil || item.ID != "item" {
+		t.Fatalf("unexpected result: expected the item synchronized from 'source', but returned '%v'", item)
+	}
+	if subscribedItem(items, "missing") != nil {
+		t.Fatal("unexpected result: expected no item")
+	}
+
+	tc := []struct {
+		name     string
+		item     *library.Item
+		lastSync *time.Time
+		onDemand bool
+		expected bool
+	}{
+		{name: "Missing item", item: nil},
+		{name: "Not synchronized", item: &library.Item{Cached: true}},
+		{name: "New item", item: &library.Item{LastSyncTime: &after, Cached: true}, expected: true},
+		{name: "New item without content", item: &library.Item{LastSyncTime: &after}},
+		{name: "New item on demand", item: &library.Item{LastSyncTime: &after}, onDemand: true, expected: true},
+		{name: "Previous synchronization", item: &library.Item{LastSyncTime: &before, Cached: true}, lastSync: &before},
+		{name: "Updated item", item: &library.Item{LastSyncTime: &after, Cached: true}, lastSync: &before, expected: true},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := subscribedItemSynced(c.item, c.lastSync, c.onDemand); actual != c.expected {
+				t.Fatalf("unexpected result: expected '%t', but returned '%t'", c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+// UploadLibraryItemConfig is the configuration to upload an OVF or OVA on the
+// Packer host to an OVF template in a content library item.
+type UploadLibraryItemConfig struct {
+	Library     string
+	Name        string
+	Description string
+	// The path of the `.ovf` or `.ova` file.
+	Source string
+}
+
+// UploadToContentLibrary uploads the OVF template of an OVF or OVA on the
+// Packer host to the content library item through an update session, and
+// returns the identifier of the item. The item is created if it does not
+// exist, or updated to a new version of the template if it does.
+func (d *VCenterDriver) UploadToContentLibrary(ctx context.Context, config *UploadLibraryItemConfig) (string, error) {
+	if err := d.restClient.Login(ctx); err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = d.restClient.Logout(ctx)
+	}()
+
+	l, err := d.FindContentLibraryByName(config.Library)
+	if err != nil {
+		return "", err
+	}
+	if l.library.Type != "LOCAL" {
+		return "", fmt.Errorf("cannot upload an OVF template to the content library %s of type %s; "+
+			"the content library must be of type LOCAL", config.Library, l.library.Type)
+	}
+
+	lm := library.NewManager(d.restClient.client)
+	itemID, err := d.ensureOvfLibraryItem(ctx, lm, l.library.ID, config.Name, config.Description)
+	if err != nil {
+		return "", err
+	}
+
+	sessionID, err := libraryItemUpdateSession(ctx, lm, itemID)
+	if err != nil {
+		return "", fmt.Errorf("error creating an update session for content library item %s: %s", config.Name, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(config.Source), ".ova") {
+		err = uploadOva(ctx, lm, sessionID, config.Source)
+	} else {
+		err = uploadOvf(ctx, lm, sessionID, config.Source)
+	}
+	if err != nil {
+		if failErr := lm.FailLibraryItemUpdateSession(context.TODO(), sessionID); failErr != nil {
+			log.Printf("[WARN] Error failing the update session %s: %s", sessionID, failErr)
+		}
+		return "", err
+	}
+
+	if err := lm.CompleteLibraryItemUpdateSession(ctx, sessionID); err != nil {
+		return "", fmt.Errorf("error completing the update session of content library item %s: %s", config.Name, err)
+	}
+	if err := lm.WaitOnLibraryItemUpdateSession(ctx, sessionID, 3*time.Second, nil); err != nil {
+		return "", err
+	}
+	return itemID, nil
+}
+
+// ovfFiles returns the paths of the OVF descriptor, the files referenced by
+// the descriptor, and the manifest, if any.
+func ovfFiles(source string) ([]string, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	envelope, err := ovf.Unmarshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the OVF descriptor %s: %s", source, err)
+	}
+
+	dir := filepath.Dir(source)
+	files := []string{source}
+	for _, ref := range envelope.References {
+		files = append(files, filepath.Join(dir, ref.Href))
+	}
+	manifest := strings.TrimSuffix(source, filepath.Ext(source)) + ".mf"
+	if _, err := os.Stat(manifest); err == nil {
+		files = append(files, manifest)
+	}
+	return files, nil
+}
+
+// uploadOvf uploads the OVF descriptor and the files it references to the
+// update session.
+func uploadOvf(ctx context.Context, lm *library.Manager, sessionID string, source string) error {
+	files, err := ovfFiles(source)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := uploadLocalFile(ctx, lm, sessionID, path); err != nil {
+			return fmt.Errorf("error uploading %s: %s", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// uploadLocalFile uploads a file on the Packer host to the update session.
+func uploadLocalFile(ctx context.Context, lm *library.Manager, sessionID string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Uploading %s to the update session %s", path, sessionID)
+	return uploadLibraryItemFile(ctx, lm, sessionID, filepath.Base(path), f, info.Size(), nil)
+}
+
+// uploadOva uploads the files of the OVA archive to the update session as they
+// are read from the archive, without extracting the archive on the Packer
+// host.
+func uploadOva(ctx context.Context, lm *library.Manager, sessionID string, source string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := tar.NewReader(f)
+	for {
+		h, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading the OVA %s: %s", source, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		log.Printf("[INFO] Uploading %s from %s to the update session %s", h.Name, source, sessionID)
+		if err := uploadLibraryItemFile(ctx, lm, sessionID, h.Name, r, h.Size, nil); err != nil {
+			return fmt.Errorf("error uploading %s: %s", h.Name, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+const testOvfDescriptor = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:href="template-disk-0.vmdk" ovf:id="file1" ovf:size="4"/>
+  </References>
+</Envelope>
+`
+
+func writeTestOvf(t *testing.T, dir string) string {
+	source := filepath.Join(dir, "template.ovf")
+	files := map[string]string{
+		source: testOvfDescriptor,
+		filepath.Join(dir, "template-disk-0.vmdk"): "disk",
+		filepath.Join(dir, "template.mf"):          "SHA256(template.ovf)= 00\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	return source
+}
+
+func TestOvfFiles(t *testing.T) {
+	dir := t.TempDir()
+	source := writeTestOvf(t, dir)
+
+	files, err := ovfFiles(source)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected := []string{
+		source,
+		filepath.Join(dir, "template-disk-0.vmdk"),
+		filepath.Join(dir, "template.mf"),
+	}
+	if diff := cmp.Diff(expected, files); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+}
+
+func TestVCenterDriver_UploadToContentLibrary(t *testing.T) {
+	dir := t.TempDir()
+	ovfSource := writeTestOvf(t, dir)
+
+	// Create an OVA with the same files.
+	ovaSource := filepath.Join(dir, "template.ova")
+	ova, err := os.Create(ovaSource)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	w := tar.NewWriter(ova)
+	for _, name := range []string{"template.ovf", "template-disk-0.vmdk"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := ova.Close(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.driver.restClient.credentials = simulator.DefaultLogin
+
+	ctx := context.TODO()
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, ds := sim.ChooseSimulatorPreCreatedDatastore()
+	lm := library.NewManager(sim.driver.restClient.client)
+	_, err = lm.CreateLibrary(ctx, library.Library{
+		Name:    "library",
+		Type:    "LOCAL",
+		Storage: []library.StorageBacking{{DatastoreID: ds.Reference().Value, Type: "DATASTORE"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{"OVF", ovfSource, []string{"template-disk-0.vmdk", "template.mf", "template.ovf"}},
+		{"OVA", ovaSource, []string{"template-disk-0.vmdk", "template.ovf"}},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			itemID, err := sim.driver.UploadToContentLibrary(ctx, &UploadLibraryItemConfig{
+				Library:     "library",
+				Name:        c.name,
+				Description: "Packer uploaded template",
+				Source:      c.source,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+
+			if err := sim.driver.restClient.Login(ctx); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			files, err := lm.ListLibraryItemFiles(ctx, itemID)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			var names []string
+			for _, f := range files {
+				names = append(names, f.Name)
+			}
+			sort.Strings(names)
+			if diff := cmp.Diff(c.expected, names); diff != "" {
+				t.Fatalf("unexpected files: '%s'", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+// libraryItemVersion is a previous version of a content library item, which
+// is a copy of the item named `<name>-v<version>`.
+type libraryItemVersion struct {
+	item    library.Item
+	version int
+}
+
+// libraryItemVersionName returns the name of the version of the item.
+func libraryItemVersionName(name string, version int) string {
+	return fmt.Sprintf("%s-v%d", name, version)
+}
+
+// libraryItemVersions returns the previous versions of the item among the
+// items of the content library, from the newest to the oldest.
+func libraryItemVersions(items []library.Item, name string) []libraryItemVersion {
+	var versions []libraryItemVersion
+	prefix := name + "-v"
+	for _, item := range items {
+		if !strings.HasPrefix(item.Name, prefix) {
+			continue
+		}
+		version, err := strconv.Atoi(strings.TrimPrefix(item.Name, prefix))
+		if err != nil || version < 1 {
+			continue
+		}
+		versions = append(versions, libraryItemVersion{item: item, version: version})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].version > versions[j].version
+	})
+	return versions
+}
+
+// contentLibraryItems returns the library manager and the items of the content
+// library. The REST client must be logged in.
+func (vm *VirtualMachineDriver) contentLibraryItems(libraryName string) (*library.Manager, string, []library.Item, error) {
+	l, err := vm.driver.FindContentLibraryByName(libraryName)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	lm := library.NewManager(vm.driver.restClient.client)
+	items, err := lm.GetLibraryItems(vm.driver.ctx, l.library.ID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return lm, l.library.ID, items, nil
+}
+
+// KeepContentLibraryItemVersion copies the current content of the content
+// library item to a new version of the item before the item is updated.
+// Returns the name of the new version, or an empty string if the item does
+// not exist. The oldest versions are deleted with
+// PruneContentLibraryItemVersions once the item is updated.
+func (vm *VirtualMachineDriver) KeepContentLibraryItemVersion(libraryName string, name string) (string, error) {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return "", err
+	}
+	defer vm.logout()
+
+	lm, libraryID, items, err := vm.contentLibraryItems(libraryName)
+	if err != nil {
+		return "", err
+	}
+
+	var current *library.Item
+	for i := range items {
+		if items[i].Name == name {
+			current = &items[i]
+			break
+		}
+	}
+	if current == nil {
+		return "", nil
+	}
+
+	versions := libraryItemVersions(items, name)
+	next := 1
+	if len(versions) > 0 {
+		next = versions[0].version + 1
+	}
+	versionName := libraryItemVersionName(name, next)
+	id, err := lm.CopyLibraryItem(vm.driver.ctx, current, library.Item{
+		Name:        versionName,
+		Description: current.Description,
+		LibraryID:   libraryID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error copying content library item %s to %s: %s", name, versionName, err)
+	}
+	log.Printf("Copied content library item %s to %s (%s)", name, versionName, id)
+
+	return versionName, nil
+}
+
+// PruneContentLibraryItemVersions deletes the oldest versions of the content
+// library item so that at most keep versions remain.
+func (vm *VirtualMachineDriver) PruneContentLibraryItemVersions(libraryName string, name string, keep int) error {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	lm, _, items, err := vm.contentLibraryItems(libraryName)
+	if err != nil {
+		return err
+	}
+
+	versions := libraryItemVersions(items, name)
+	if keep <= 0 || len(versions) <= keep {
+		return nil
+	}
+	for _, v := range versions[keep:] {
+		log.Printf("Deleting content library item %s", v.item.Name)
+		if err := lm.DeleteLibraryItem(vm.driver.ctx, &v.item); err != nil {
+			return fmt.Errorf("error deleting content library item %s: %s", v.item.Name, err)
+		}
+	}
+	return nil
+}
+
+// DeleteContentLibraryItemVersion deletes a version of a content library
+// item, such as the version that was kept for an update that failed.
+func (vm *VirtualMachineDriver) DeleteContentLibraryItemVersion(libraryName string, versionName string) error {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	lm, _, items, err := vm.contentLibraryItems(libraryName)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].Name == versionName {
+			log.Printf("Deleting content library item %s", versionName)
+			if err := lm.DeleteLibraryItem(vm.driver.ctx, &items[i]); err != nil {
+				return fmt.Errorf("error deleting content library item %s: %s", versionName, err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
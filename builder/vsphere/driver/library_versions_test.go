@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func TestLibraryItemVersions(t *testing.T) {
+	items := []library.Item{
+		{Name: "template"},
+		{Name: "template-v2"},
+		{Name: "template-v10"},
+		{Name: "template-v1"},
+		{Name: "template-vnext"},
+		{Name: "template-v0"},
+		{Name: "other-v3"},
+	}
+
+	var actual []string
+	for _, v := range libraryItemVersions(items, "template") {
+		actual = append(actual, v.item.Name)
+	}
+	expected := []string{"template-v10", "template-v2", "template-v1"}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("unexpected result: '%s'", diff)
+	}
+}
+
+func TestVirtualMachineDriver_KeepContentLibraryItemVersion(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.driver.restClient.credentials = simulator.DefaultLogin
+
+	ctx := context.TODO()
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, ds := sim.ChooseSimulatorPreCreatedDatastore()
+	lm := library.NewManager(sim.driver.restClient.client)
+	libraryID, err := lm.CreateLibrary(ctx, library.Library{
+		Name:    "library",
+		Type:    "LOCAL",
+		Storage: []library.StorageBacking{{DatastoreID: ds.Reference().Value, Type: "DATASTORE"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	vmDriver := vm.(*VirtualMachineDriver)
+
+	// There is nothing to keep before the item is created.
+	name, err := vmDriver.KeepContentLibraryItemVersion("library", "template")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if name != "" {
+		t.Fatalf("unexpected result: expected no version, but returned '%s'", name)
+	}
+
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	for _, name := range []string{"template", "template-v1", "template-v2", "template-v5"} {
+		if _, err := lm.CreateLibraryItem(ctx, library.Item{Name: name, Type: library.ItemTypeOVF, LibraryID: libraryID}); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	name, err = vmDriver.KeepContentLibraryItemVersion("library", "template")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if expected := "template-v6"; name != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, name)
+	}
+
+	// The simulator copies an item with the name of the source item, so the
+	// new version is created by name.
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := lm.CreateLibraryItem(ctx, library.Item{Name: name, Type: library.ItemTypeOVF, LibraryID: libraryID}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	itemNames := func() map[string]bool {
+		t.Helper()
+		if err := sim.driver.restClient.Login(ctx); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		items, err := lm.GetLibraryItems(ctx, libraryID)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		names := make(map[string]bool)
+		for _, item := range items {
+			names[item.Name] = true
+		}
+		return names
+	}
+
+	// No version is deleted until the item is updated.
+	remaining := itemNames()
+	for _, name := range []string{"template-v1", "template-v2", "template-v5", "template-v6"} {
+		if !remaining[name] {
+			t.Fatalf("unexpected result: expected '%s' to be kept", name)
+		}
+	}
+
+	// The oldest versions are deleted, keeping the new version and the
+	// latest existing version.
+	if err := vmDriver.PruneContentLibraryItemVersions("library", "template", 2); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	remaining = itemNames()
+	for _, name := range []string{"template-v1", "template-v2"} {
+		if remaining[name] {
+			t.Fatalf("unexpected result: expected '%s' to be deleted", name)
+		}
+	}
+	for _, name := range []string{"template", "template-v5", "template-v6"} {
+		if !remaining[name] {
+			t.Fatalf("unexpected result: expected '%s' to be kept", name)
+		}
+	}
+
+	// The version kept for a failed update is deleted.
+	if err := vmDriver.DeleteContentLibraryItemVersion("library", "template-v6"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	remaining = itemNames()
+	if remaining["template-v6"] || !remaining["template-v5"] || !remaining["template"] {
+		t.Fatalf("unexpected result: expected only 'template-v6' to be deleted, but returned '%v'", remaining)
+	}
+}
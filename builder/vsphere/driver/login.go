@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/sts"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// login logs in to vCenter Server or the ESXi host with the credentials of
+// the configuration. If the session is not created from the username and
+// password, the function that logs in to the vSphere Automation API is
+// returned.
+func (c *ConnectConfig) login(ctx context.Context, client *govmomi.Client, credentials *url.Userinfo) (func(context.Context, *rest.Client) error, error) {
+	vc := client.Client
+	switch {
+	case c.SAMLToken != "" || c.SAMLTokenFile != "":
+		if !vc.IsVC() {
+			return nil, errVCenterRequired("logging in with a SAML token")
+		}
+		token, err := c.samlToken()
+		if err != nil {
+			return nil, err
+		}
+		// The token is a bearer token, or a holder-of-key token for the
+		// client certificate.
+		signer := &sts.Signer{Token: token, Certificate: vc.Certificate()}
+		if err := loginBySigner(ctx, client, signer); err != nil {
+			return nil, fmt.Errorf("error logging in with the SAML token: %s", err)
+		}
+		return func(ctx context.Context, rc *rest.Client) error {
+			return rc.LoginByToken(rc.WithSigner(ctx, signer))
+		}, nil
+	case c.SessionTicket != "":
+		if err := client.SessionManager.CloneSession(ctx, c.SessionTicket); err != nil {
+			return nil, fmt.Errorf("error logging in with the session ticket: %s", err)
+		}
+		// The session of the vSphere Automation API is created from the
+		// cookie of the cloned session.
+		return func(ctx context.Context, rc *rest.Client) error {
+			return rc.Login(ctx, nil)
+		}, nil
+	case c.Username == "" && c.ClientCertFile != "":
+		if !vc.IsVC() {
+			return nil, errVCenterRequired("logging in with a client certificate")
+		}
+		signer, err := issueToken(ctx, vc)
+		if err != nil {
+			return nil, err
+		}
+		if err := loginBySigner(ctx, client, signer); err != nil {
+			return nil, fmt.Errorf("error logging in with the client certificate: %s", err)
+		}
+		return func(ctx context.Context, rc *rest.Client) error {
+			signer, err := issueToken(ctx, vc)
+			if err != nil {
+				return err
+			}
+			return rc.LoginByToken(rc.WithSigner(ctx, signer))
+		}, nil
+	default:
+		return nil, client.SessionManager.Login(ctx, credentials)
+	}
+}
+
+// samlToken returns the SAML token of the configuration.
+func (c *ConnectConfig) samlToken() (string, error) {
+	if c.SAMLTokenFile == "" {
+		return c.SAMLToken, nil
+	}
+	b, err := os.ReadFile(c.SAMLTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading the SAML token: %s", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// issueToken issues a holder-of-key token for the client certificate of the
+// client from the vCenter Single Sign-On service. The token is short-lived and
+// a new token is issued for each login.
+func issueToken(ctx context.Context, c *vim25.Client) (*sts.Signer, error) {
+	stsClient, err := sts.NewClient(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := stsClient.Issue(ctx, sts.TokenRequest{
+		Certificate: c.Certificate(),
+		Delegatable: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error issuing a token for the client certificate: %s", err)
+	}
+	return signer, nil
+}
+
+// loginBySigner logs in to vCenter Server with the token of the signer.
+func loginBySigner(ctx context.Context, client *govmomi.Client, signer *sts.Signer) error {
+	// The login requires the version of the service in the SOAP action,
+	// rather than the version of the client.
+	vc := client.Client
+	if vc.Version == vim25.Version {
+		_ = vc.UseServiceVersion()
+	}
+	header := soap.Header{Security: signer}
+	return client.SessionManager.LoginByToken(vc.WithHeader(ctx, header))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewDriver_SessionTicket(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	ticket, err := sim.driver.client.SessionManager.AcquireCloneTicket(sim.driver.ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	caFile, err := sim.server.CertificateFile()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	config := &ConnectConfig{
+		VCenterServer: sim.server.URL.Host,
+		CACertFile:    caFile,
+		SessionTicket: ticket,
+	}
+	d, err := NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	session, err := d.(*VCenterDriver).client.SessionManager.UserSession(sim.driver.ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if session == nil {
+		t.Fatal("expected the session to be cloned")
+	}
+
+	// A ticket can be used only once.
+	if _, err := NewDriver(config); err == nil {
+		t.Fatal("unexpected success: expected the ticket to be used")
+	}
+}
+
+func TestConnectConfig_samlToken(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token.xml")
+	if err := os.WriteFile(file, []byte("<saml2:Assertion/>\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	config := &ConnectConfig{SAMLTokenFile: file}
+	token, err := config.samlToken()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if token != "<saml2:Assertion/>" {
+		t.Errorf("unexpected token: %q", token)
+	}
+
+	config.SAMLTokenFile = filepath.Join(t.TempDir(), "missing.xml")
+	if _, err := config.samlToken(); err == nil {
+		t.Fatal("unexpected success: expected the token file to be missing")
+	}
+}
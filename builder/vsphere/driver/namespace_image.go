@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/namespace"
+)
+
+// vmImageName returns the name of the VirtualMachineImage that VM Service
+// publishes in a namespace for a content library item, which is derived from
+// the identifier of the item, for example `vmi-0a0044d7c690bc9b8`.
+func vmImageName(itemID string) string {
+	id := strings.ReplaceAll(itemID, "-", "")
+	if len(id) > 17 {
+		id = id[:17]
+	}
+	return "vmi-" + id
+}
+
+// FindNamespaceImage returns the content library item of a VM image that is
+// available in a vSphere Namespace. The image is either the name of the
+// VirtualMachineImage, for example `vmi-0a0044d7c690bc9b8`, or the name of the
+// item in one of the content libraries of the namespace.
+func (d *VCenterDriver) FindNamespaceImage(namespaceName string, image string) (*library.Item, error) {
+	if d.standaloneHost {
+		return nil, errVCenterRequired("vSphere Namespaces")
+	}
+
+	ns, err := namespace.NewManager(d.restClient.client).GetNamespace(d.ctx, namespaceName)
+	if err != nil {
+		return nil, fmt.Errorf("error finding namespace %s: %s", namespaceName, err)
+	}
+	if len(ns.VmServiceSpec.ContentLibraries) == 0 {
+		return nil, fmt.Errorf("namespace %s has no content libraries for VM images", namespaceName)
+	}
+
+	lm := library.NewManager(d.restClient.client)
+	var matches []library.Item
+	for _, id := range ns.VmServiceSpec.ContentLibraries {
+		items, err := lm.GetLibraryItems(d.ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the items of content library %s: %s", id, err)
+		}
+		for _, item := range items {
+			if vmImageName(item.ID) == image {
+				return &item, nil
+			}
+			if item.Name == image {
+				matches = append(matches, item)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("VM image %s not found in namespace %s", image, namespaceName)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("more than one VM image named %s found in namespace %s; "+
+		"specify the name of the VirtualMachineImage instead", image, namespaceName)
+}
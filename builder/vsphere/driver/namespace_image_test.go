@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/namespace"
+	_ "github.com/vmware/govmomi/vapi/namespace/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func TestVmImageName(t *testing.T) {
+	actual := vmImageName("0a0044d7-c690-bc9b-8a8e-1b7c2e4f0a11")
+	expected := "vmi-0a0044d7c690bc9b8"
+	if actual != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, actual)
+	}
+}
+
+func TestVCenterDriver_FindNamespaceImage(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.driver.restClient.credentials = simulator.DefaultLogin
+
+	ctx := context.TODO()
+	if err := sim.driver.restClient.Login(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, ds := sim.ChooseSimulatorPreCreatedDatastore()
+	lm := library.NewManager(sim.driver.restClient.client)
+	var libraryIDs []string
+	for _, name := range []string{"images", "other"} {
+		id, err := lm.CreateLibrary(ctx, library.Library{
+			Name:    name,
+			Type:    "LOCAL",
+			Storage: []library.StorageBacking{{DatastoreID: ds.Reference().Value, Type: "DATASTORE"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		libraryIDs = append(libraryIDs, id)
+	}
+	itemID, err := lm.CreateLibraryItem(ctx, library.Item{Name: "ubuntu", Type: library.ItemTypeOVF, LibraryID: libraryIDs[0]})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	for _, id := range libraryIDs {
+		if _, err := lm.CreateLibraryItem(ctx, library.Item{Name: "photon", Type: library.ItemTypeOVF, LibraryID: id}); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	nm := namespace.NewManager(sim.driver.restClient.client)
+	err = nm.CreateNamespace(ctx, namespace.NamespacesInstanceCreateSpec{
+		Namespace:     "packer",
+		VmServiceSpec: namespace.VmServiceSpec{ContentLibraries: libraryIDs},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer func() {
+		_ = nm.DeleteNamespace(ctx, "packer")
+	}()
+
+	for _, image := range []string{vmImageName(itemID), "ubuntu"} {
+		item, err := sim.driver.FindNamespaceImage("packer", image)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if item.ID != itemID {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", itemID, item.ID)
+		}
+	}
+
+	// The item name is ambiguous across the content libraries of the namespace.
+	if _, err := sim.driver.FindNamespaceImage("packer", "photon"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	if _, err := sim.driver.FindNamespaceImage("packer", "missing"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	if _, err := sim.driver.FindNamespaceImage("missing", "ubuntu"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
@@ -79,6 +79,33 @@ func (n *Network) Info(params ...string) (*mo.Network, error) {
 	return &info, nil
 }
 
+// SwitchName retrieves the name of the distributed virtual switch that owns
+// the network. Returns an empty string if the network is not a distributed
+// port group.
+func (n *Network) SwitchName() (string, error) {
+	ref := n.network.Reference()
+	if ref.Type != "DistributedVirtualPortgroup" {
+		return "", nil
+	}
+	portgroup := object.NewDistributedVirtualPortgroup(n.driver.client.Client, ref)
+
+	var pg mo.DistributedVirtualPortgroup
+	err := portgroup.Properties(n.driver.ctx, portgroup.Reference(), []string{"config.distributedVirtualSwitch"}, &pg)
+	if err != nil {
+		return "", err
+	}
+	if pg.Config.DistributedVirtualSwitch == nil {
+		return "", nil
+	}
+
+	var dvs mo.ManagedEntity
+	err = portgroup.Properties(n.driver.ctx, *pg.Config.DistributedVirtualSwitch, []string{"name"}, &dvs)
+	if err != nil {
+		return "", err
+	}
+	return dvs.Name, nil
+}
+
 type MultipleNetworkFoundError struct {
 	path   string
 	append string
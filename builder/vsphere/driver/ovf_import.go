@@ -0,0 +1,270 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ImportOvfConfig is the configuration to import an OVF or OVA that is stored
+// on a datastore as a virtual machine.
+type ImportOvfConfig struct {
+	Name         string
+	Folder       string
+	Cluster      string
+	Host         string
+	ResourcePool string
+	Datastore    string
+	// The datastore path of the OVF descriptor or the OVA. For example,
+	// `[datastore1] images/base.ova`.
+	DatastorePath string
+	// The network to connect the networks of the OVF to. The networks are
+	// chosen by vSphere if empty.
+	Network string
+	// The distributed virtual switch that owns the network, and the host
+	// that must have access to the network, if more than one network
+	// resolves to the name.
+	NetworkSwitch string
+	NetworkHost   string
+	Annotation    string
+	// The values of the vApp properties of the OVF.
+	Properties map[string]string
+	// The identifier of the storage policy of the virtual machine home and of
+	// the disks. The default policy of the datastore applies if empty.
+	StoragePolicyID string
+	// Virtual machines in a vApp cannot be converted to a template.
+	ConvertToTemplate bool
+}
+
+// ImportOvf imports an OVF or OVA that is stored on a datastore as a virtual
+// machine. The files are transferred with a pull-mode import, where the host
+// downloads the files from the datastore with short-lived service tickets,
+// rather than through the Packer host.
+func (d *VCenterDriver) ImportOvf(ctx context.Context, config *ImportOvfConfig) (VirtualMachine, error) {
+	if d.standaloneHost {
+		return nil, errVCenterRequired("importing an OVF or OVA from a datastore")
+	}
+
+	var dsPath object.DatastorePath
+	if !dsPath.FromString(config.DatastorePath) || dsPath.Path == "" {
+		return nil, fmt.Errorf("error parsing datastore path %q", config.DatastorePath)
+	}
+	source, err := d.finder.Datastore(ctx, dsPath.Datastore)
+	if err != nil {
+		return nil, fmt.Errorf("error finding datastore %s: %s", dsPath.Datastore, err)
+	}
+	ova := strings.EqualFold(path.Ext(dsPath.Path), ".ova")
+
+	descriptor, err := readOvfDescriptor(ctx, source, dsPath.Path, ova)
+	if err != nil {
+		return nil, fmt.Errorf("error reading OVF descriptor from %s: %s", config.DatastorePath, err)
+	}
+	envelope, err := ovf.Unmarshal(strings.NewReader(descriptor))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing OVF descriptor from %s: %s", config.DatastorePath, err)
+	}
+
+	folder, err := d.FindFolder(config.Folder)
+	if err != nil {
+		return nil, err
+	}
+	resourcePool, err := d.FindResourcePool(config.Cluster, config.Host, config.ResourcePool)
+	if err != nil {
+		return nil, err
+	}
+	if resourcePool.IsVApp() && config.ConvertToTemplate {
+		return nil, errVAppTemplate(config.ResourcePool)
+	}
+	var host *object.HostSystem
+	if config.Host != "" {
+		h, err := d.FindHost(config.Host)
+		if err != nil {
+			return nil, err
+		}
+		host = h.host
+	}
+	datastore, err := d.FindDatastore(config.Datastore, config.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	cisp := types.OvfCreateImportSpecParams{
+		EntityName: config.Name,
+		OvfManagerCommonParams: types.OvfManagerCommonParams{
+			Locale: "US",
+		},
+	}
+	for key, value := range config.Properties {
+		cisp.PropertyMapping = append(cisp.PropertyMapping, types.KeyValue{Key: key, Value: value})
+	}
+	if config.Network != "" && envelope.Network != nil {
+		host := config.Host
+		if config.NetworkHost != "" {
+			host = config.NetworkHost
+		}
+		network, err := findNetwork(config.Network, host, config.NetworkSwitch, d)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range envelope.Network.Networks {
+			cisp.NetworkMapping = append(cisp.NetworkMapping, types.OvfNetworkMapping{
+				Name:    n.Name,
+				Network: network.Reference(),
+			})
+		}
+	}
+
+	m := ovf.NewManager(d.vimClient)
+	spec, err := m.CreateImportSpec(ctx, descriptor, resourcePool.pool, datastore.(*DatastoreDriver).ds, &cisp)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Error != nil {
+		return nil, errors.New(spec.Error[0].LocalizedMessage)
+	}
+	if s, ok := spec.ImportSpec.(*types.VirtualMachineImportSpec); ok {
+		if config.Annotation != "" {
+			s.ConfigSpec.Annotation = config.Annotation
+		}
+		if config.StoragePolicyID != "" {
+			s.ConfigSpec.VmProfile = storageProfileSpec(config.StoragePolicyID)
+			applyDiskStoragePolicy(s.ConfigSpec.DeviceChange, config.StoragePolicyID)
+		}
+	}
+
+	// The import is not retried, since an import that appears to fail may
+	// have created the virtual machine.
+	lease, err := resourcePool.pool.ImportVApp(ctx, spec.ImportSpec, folder.folder, host)
+	if err != nil {
+		return nil, err
+	}
+	info, err := lease.Wait(ctx, spec.FileItem)
+	if err != nil {
+		_ = lease.Abort(ctx, nil)
+		return nil, err
+	}
+
+	u := lease.StartUpdater(ctx, info)
+	defer u.Done()
+
+	files, err := d.ovfSourceFiles(ctx, source, dsPath.Path, ova, info.Items)
+	if err != nil {
+		_ = lease.Abort(ctx, nil)
+		return nil, err
+	}
+
+	res, err := methods.HttpNfcLeasePullFromUrls_Task(ctx, d.vimClient, &types.HttpNfcLeasePullFromUrls_Task{
+		This:  lease.Reference(),
+		Files: files,
+	})
+	if err != nil {
+		_ = lease.Abort(ctx, nil)
+		return nil, err
+	}
+	if err := object.NewTask(d.vimClient, res.Returnval).Wait(ctx); err != nil {
+		_ = lease.Abort(ctx, nil)
+		return nil, err
+	}
+
+	if err := lease.Complete(ctx); err != nil {
+		return nil, err
+	}
+	return d.NewVM(&info.Entity), nil
+}
+
+// readOvfDescriptor returns the OVF descriptor at the path in the datastore.
+// The descriptor of an OVA is the first member of the archive.
+func readOvfDescriptor(ctx context.Context, ds *object.Datastore, p string, ova bool) (string, error) {
+	download := soap.DefaultDownload
+	r, _, err := ds.Download(ctx, p, &download)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	if ova {
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err != nil {
+				if err == io.EOF {
+					return "", fmt.Errorf("no OVF descriptor found in the OVA")
+				}
+				return "", err
+			}
+			if path.Ext(h.Name) == ".ovf" {
+				r = io.NopCloser(tr)
+				break
+			}
+		}
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ovfSourceFiles returns the datastore URLs of the files to import. The URLs
+// are served by a host attached to the datastore and authenticated with
+// service tickets. The files of an OVA are members of the archive.
+func (d *VCenterDriver) ovfSourceFiles(ctx context.Context, ds *object.Datastore, p string, ova bool, items []nfc.FileItem) ([]types.HttpNfcLeaseSourceFile, error) {
+	hosts, err := ds.AttachedHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts attached to datastore %s", ds.Name())
+	}
+	host := hosts[0]
+	ctx = ds.HostContext(ctx, host)
+
+	var thumbprint string
+	cm, err := host.ConfigManager().CertificateManager(ctx)
+	if err == nil && cm != nil {
+		if cert, err := cm.CertificateInfo(ctx); err == nil {
+			thumbprint = cert.ThumbprintSHA1
+		}
+	}
+
+	var files []types.HttpNfcLeaseSourceFile
+	for _, item := range items {
+		src, member := path.Join(path.Dir(p), item.Path), ""
+		if ova {
+			src, member = p, item.Path
+		}
+
+		u, cookie, err := ds.ServiceTicket(ctx, src, "GET")
+		if err != nil {
+			return nil, fmt.Errorf("error acquiring service ticket for %s: %s", src, err)
+		}
+		file := types.HttpNfcLeaseSourceFile{
+			TargetDeviceId: item.DeviceId,
+			Url:            u.String(),
+			MemberName:     member,
+			Create:         item.Create,
+			SslThumbprint:  thumbprint,
+			Size:           item.Size,
+		}
+		if cookie != nil {
+			file.HttpHeaders = []types.KeyValue{{Key: "Cookie", Value: cookie.String()}}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+)
+
+// PreflightSpec is the inventory objects that a build uses. Empty values are
+// not checked.
+type PreflightSpec struct {
+	Folder       string
+	Cluster      string
+	Host         string
+	ResourcePool string
+	Datastore    string
+	Networks     []string
+	// The virtual machine or template that is cloned.
+	Template string
+	// Whether the virtual machine is imported from an OVF template.
+	ImportOVF bool
+	// The paths of ISO files on datastores, in the form `[datastore] path`.
+	ISOPaths []string
+}
+
+// Preflight verifies that the inventory objects of the specification exist
+// and that the current session has the privileges that a build requires on
+// them. All failures are returned, rather than the first one.
+func (d *VCenterDriver) Preflight(spec *PreflightSpec) []error {
+	targets, errs := d.privilegeTargets(spec)
+
+	for _, isoPath := range spec.ISOPaths {
+		var dsPath object.DatastorePath
+		if !dsPath.FromString(isoPath) {
+			// The path is not on a datastore, such as a content library
+			// file, and is resolved by the build.
+			continue
+		}
+		ds, err := d.FindDatastore(dsPath.Datastore, spec.Host)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ISO file %q not found: %s", isoPath, err))
+			continue
+		}
+		if !ds.FileExists(dsPath.Path) {
+			errs = append(errs, fmt.Errorf("ISO file %q not found", isoPath))
+		}
+	}
+
+	missing, err := d.missingTargetPrivileges(targets)
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, m := range missing {
+		errs = append(errs, fmt.Errorf("missing privileges on %s: %s", m.Entity, strings.Join(m.Privileges, ", ")))
+	}
+	return errs
+}
+
+// preflightResourcePool returns the resource pool of the specification. A
+// resource pool that is specified by name must exist, rather than falling
+// back to the default resource pool.
+func (d *VCenterDriver) preflightResourcePool(spec *PreflightSpec) (*ResourcePool, error) {
+	pool, err := d.FindResourcePool(spec.Cluster, spec.Host, spec.ResourcePool)
+	if err != nil {
+		return nil, fmt.Errorf("resource pool %q not found: %s", spec.ResourcePool, err)
+	}
+	if spec.ResourcePool == "" || pool.IsVApp() {
+		return pool, nil
+	}
+	p, err := pool.Path()
+	if err != nil {
+		return nil, fmt.Errorf("error finding resource pool %q: %s", spec.ResourcePool, err)
+	}
+	if p != strings.Trim(spec.ResourcePool, "/") {
+		return nil, fmt.Errorf("resource pool %q not found", spec.ResourcePool)
+	}
+	return pool, nil
+}
+
+// closestFolder returns the virtual machine folder with the name or, if it
+// does not exist, its closest existing parent folder.
+func (d *VCenterDriver) closestFolder(name string) (*object.Folder, bool, error) {
+	missing := false
+	for name = strings.Trim(name, "/"); ; name = path.Dir(name) {
+		if name == "." {
+			name = ""
+		}
+		f, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm", name))
+		if err == nil {
+			return f, missing, nil
+		}
+		if _, ok := err.(*find.NotFoundError); !ok || name == "" {
+			return nil, false, err
+		}
+		missing = true
+	}
+}
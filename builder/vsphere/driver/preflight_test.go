@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+)
+
+func TestVCenterDriver_Preflight(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	spec := &PreflightSpec{
+		Folder:    "new/folder",
+		Cluster:   "DC0_C0",
+		Datastore: "LocalDS_0",
+		Networks:  []string{"VM Network"},
+		Template:  machine.Name,
+	}
+	if errs := sim.driver.Preflight(spec); len(errs) != 0 {
+		t.Fatalf("unexpected errors: '%v'", errs)
+	}
+	if _, err := sim.driver.finder.Folder(sim.driver.ctx, "/DC0/vm/new"); err == nil {
+		t.Fatal("unexpected folder created by the preflight checks")
+	}
+
+	// All the missing inventory objects are reported.
+	spec = &PreflightSpec{
+		Cluster:      "missing-cluster",
+		ResourcePool: "missing-pool",
+		Datastore:    "missing-datastore",
+		Networks:     []string{"missing-network"},
+		Template:     "missing-template",
+		ISOPaths:     []string{"[LocalDS_0] missing.iso"},
+	}
+	if errs := sim.driver.Preflight(spec); len(errs) != 6 {
+		t.Fatalf("unexpected errors: expected 6, but returned '%v'", errs)
+	}
+}
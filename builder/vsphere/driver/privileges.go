@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// MissingPrivileges is the privileges that a build requires on an inventory
+// object and that the current session does not have.
+type MissingPrivileges struct {
+	// A description of the inventory object, such as `folder "/dc/vm/templates"`.
+	Entity     string
+	Privileges []string
+}
+
+// privilegeTarget is an inventory object of a build and the privileges that
+// the build requires on it.
+type privilegeTarget struct {
+	entity     string
+	ref        types.ManagedObjectReference
+	privileges []string
+}
+
+// MissingBuildPrivileges returns the privileges that a build requires on the
+// inventory objects of the specification and that the current session does
+// not have. Inventory objects that do not exist are not checked. No privileges
+// are returned when connected directly to an ESXi host, which does not check
+// privileges on entities.
+func (d *VCenterDriver) MissingBuildPrivileges(spec *PreflightSpec) ([]MissingPrivileges, error) {
+	targets, _ := d.privilegeTargets(spec)
+	return d.missingTargetPrivileges(targets)
+}
+
+// privilegeTargets returns the inventory objects of the specification and
+// the privileges that a build requires on them, and an error for each
+// inventory object that does not exist.
+func (d *VCenterDriver) privilegeTargets(spec *PreflightSpec) ([]privilegeTarget, []error) {
+	var targets []privilegeTarget
+	var errs []error
+	add := func(entity string, ref types.ManagedObjectReference, privileges ...string) {
+		targets = append(targets, privilegeTarget{entity: entity, ref: ref, privileges: privileges})
+	}
+
+	if spec.Template != "" {
+		vm, err := d.FindVM(spec.Template)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %q not found: %s", spec.Template, err))
+		} else {
+			add(fmt.Sprintf("template %q", spec.Template), vm.(*VirtualMachineDriver).vm.Reference(),
+				"VirtualMachine.Provisioning.Clone")
+		}
+	}
+
+	if spec.Cluster != "" {
+		if _, err := d.FindCluster(spec.Cluster); err != nil {
+			errs = append(errs, fmt.Errorf("cluster %q not found: %s", spec.Cluster, err))
+		}
+	}
+	if spec.Host != "" {
+		if _, err := d.FindHost(spec.Host); err != nil {
+			errs = append(errs, fmt.Errorf("host %q not found: %s", spec.Host, err))
+		}
+	}
+	if spec.Cluster != "" || spec.Host != "" || d.standaloneHost {
+		if pool, err := d.preflightResourcePool(spec); err != nil {
+			errs = append(errs, err)
+		} else {
+			privileges := []string{"Resource.AssignVMToPool"}
+			if spec.ImportOVF {
+				privileges = append(privileges, "VApp.Import")
+			}
+			add("the resource pool", pool.pool.Reference(), privileges...)
+		}
+	}
+
+	// The folder is created by the build if it does not exist, which
+	// requires the privilege to create folders in the closest existing
+	// parent folder.
+	folder, missingFolder, err := d.closestFolder(spec.Folder)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error finding folder %q: %s", spec.Folder, err))
+	} else {
+		var privileges []string
+		switch {
+		case spec.ImportOVF:
+			privileges = []string{"VApp.Import"}
+		case spec.Template != "":
+			privileges = []string{"VirtualMachine.Inventory.CreateFromExisting"}
+		default:
+			privileges = []string{"VirtualMachine.Inventory.Create"}
+		}
+		if missingFolder {
+			privileges = append(privileges, "Folder.Create")
+		}
+		add(fmt.Sprintf("folder %q", folder.InventoryPath), folder.Reference(), privileges...)
+	}
+
+	if spec.Datastore != "" {
+		ds, err := d.FindDatastore(spec.Datastore, spec.Host)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			add(fmt.Sprintf("datastore %q", ds.Name()), ds.Reference(), "Datastore.AllocateSpace")
+		}
+	}
+
+	for _, name := range spec.Networks {
+		if name == "" {
+			continue
+		}
+		network, err := findNetwork(name, spec.Host, "", d)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("network %q not found: %s", name, err))
+			continue
+		}
+		add(fmt.Sprintf("network %q", name), network.Reference(), "Network.Assign")
+	}
+
+	return targets, errs
+}
+
+// missingTargetPrivileges returns the privileges that the current session
+// does not have on the inventory objects.
+func (d *VCenterDriver) missingTargetPrivileges(targets []privilegeTarget) ([]MissingPrivileges, error) {
+	if d.standaloneHost {
+		return nil, nil
+	}
+
+	var missing []MissingPrivileges
+	for _, target := range targets {
+		privileges, err := d.missingPrivileges(target.ref, target.privileges...)
+		if err != nil {
+			return nil, fmt.Errorf("error checking privileges on %s: %s", target.entity, err)
+		}
+		if len(privileges) > 0 {
+			missing = append(missing, MissingPrivileges{Entity: target.entity, Privileges: privileges})
+		}
+	}
+	return missing, nil
+}
+
+// missingPrivileges returns the privileges, of the specified privileges, that
+// the current session does not have on the managed object.
+func (d *VCenterDriver) missingPrivileges(ref types.ManagedObjectReference, privileges ...string) ([]string, error) {
+	session, err := d.client.SessionManager.UserSession(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no active session")
+	}
+
+	am := object.NewAuthorizationManager(d.vimClient)
+	granted, err := am.HasPrivilegeOnEntity(d.ctx, ref, session.Key, privileges)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for i, privilege := range privileges {
+		if i >= len(granted) || !granted[i] {
+			missing = append(missing, privilege)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+)
+
+func TestVCenterDriver_MissingBuildPrivileges(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	missing, err := sim.driver.MissingBuildPrivileges(&PreflightSpec{
+		Folder:    "templates",
+		Cluster:   "DC0_C0",
+		Datastore: "LocalDS_0",
+		Networks:  []string{"VM Network", "missing-network"},
+		ImportOVF: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(missing) != 0 {
+		t.Fatalf("unexpected missing privileges: '%v'", missing)
+	}
+}
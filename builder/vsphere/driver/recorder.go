@@ -0,0 +1,288 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvRecord is the environment variable with the path of a file to
+	// record the interactions with vCenter Server to.
+	EnvRecord = "PACKER_VSPHERE_RECORD"
+	// EnvReplay is the environment variable with the path of a file of
+	// recorded interactions to replay instead of connecting to vCenter
+	// Server.
+	EnvReplay = "PACKER_VSPHERE_REPLAY"
+)
+
+// redacted replaces the sensitive values in recorded interactions.
+const redacted = "REDACTED"
+
+var (
+	// Matches the credentials in the body of a SOAP login request, and the
+	// session identifiers and clone tickets in the body of a SOAP request.
+	credentialsRe = regexp.MustCompile(`<(password|userName|username|token|cloneTicket|sessionID|sessionId)>[^<]*</(password|userName|username|token|cloneTicket|sessionID|sessionId)>`)
+	// Matches the key of a SOAP user session, such as in the response of a
+	// login, which is the session identifier.
+	sessionKeyRe = regexp.MustCompile(`<key>[^<]*</key>(\s*<userName>)`)
+	// Matches the ticket in the response of a request for a clone ticket or
+	// a generic service ticket.
+	ticketRe = regexp.MustCompile(`(<AcquireCloneTicketResponse[^>]*>\s*<returnval>|<AcquireGenericServiceTicketResponse[^>]*>\s*<returnval>\s*<id>)[^<]*`)
+	// Matches the SOAP session cookie.
+	sessionCookieRe = regexp.MustCompile(`vmware_soap_session=("[^"]*"|[^;\s<&]*)`)
+	// The path of the REST session resource, whose response is the session
+	// identifier.
+	restSessionPath = regexp.MustCompile(`/(rest/com/vmware/cis|api)/session$`)
+)
+
+// interaction is a recorded request to vCenter Server and its response. The
+// host is not recorded, so that a recording can be replayed against any
+// endpoint.
+type interaction struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Request     string `json:"request,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Response    string `json:"response,omitempty"`
+	replayed    bool
+}
+
+// sanitize removes the credentials and session identifiers from the
+// interaction.
+func (i *interaction) sanitize() {
+	i.Request = sanitizeBody(i.Request)
+	i.Response = sanitizeBody(i.Response)
+	if restSessionPath.MatchString(i.URL) && i.Method == http.MethodPost {
+		i.Response = fmt.Sprintf("{%q:%q}", "value", redacted)
+	}
+}
+
+// sanitizeBody removes the credentials, the session identifiers, the session
+// cookies, and the tickets from the body of a request or a response. The
+// content of the sensitive elements of an XML body, and of the elements
+// within them, such as the password of a guest customization or the SAML
+// assertion of a token login, is redacted as in the API log.
+func sanitizeBody(body string) string {
+	if strings.HasPrefix(strings.TrimSpace(body), "<") {
+		body = string(redactXMLWith([]byte(body), redacted))
+	}
+	body = credentialsRe.ReplaceAllString(body, "<$1>"+redacted+"</$2>")
+	body = sessionKeyRe.ReplaceAllString(body, "<key>"+redacted+"</key>$1")
+	body = ticketRe.ReplaceAllString(body, "${1}"+redacted)
+	return sessionCookieRe.ReplaceAllString(body, "vmware_soap_session="+redacted)
+}
+
+// requestURL returns the path and query of the request URL.
+func requestURL(r *http.Request) string {
+	return r.URL.RequestURI()
+}
+
+// isRecordedContent reports whether a body of the content type is recorded.
+// The files that are uploaded and downloaded, such as disks, are not recorded.
+func isRecordedContent(contentType string) bool {
+	for _, s := range []string{"xml", "json", "text"} {
+		if strings.Contains(contentType, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads and restores the body of a request.
+func readBody(r *http.Request) (string, error) {
+	if r.Body == nil || r.Body == http.NoBody || !isRecordedContent(r.Header.Get("Content-Type")) {
+		return "", nil
+	}
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
+
+// recorder is a transport that records the interactions with vCenter Server
+// to a file, with one sanitized interaction per line. The interactions are
+// appended to the file, since the data sources, builders, and post-processors
+// of a build each connect to vCenter Server in their own plugin process.
+type recorder struct {
+	transport http.RoundTripper
+	mu        sync.Mutex
+	file      *os.File
+}
+
+func newRecorder(transport http.RoundTripper, path string) (*recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the recording %s: %s", path, err)
+	}
+	log.Printf("[INFO] Recording the interactions with vCenter Server to %s", path)
+	return &recorder{transport: transport, file: f}, nil
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	i := interaction{
+		Method:      req.Method,
+		URL:         requestURL(req),
+		Request:     body,
+		Status:      res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if isRecordedContent(i.ContentType) {
+		b, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(b))
+		i.Response = string(b)
+	}
+	i.sanitize()
+	line, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[WARN] Error recording the interaction with vCenter Server: %s", err)
+	}
+	return res, nil
+}
+
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// replayer is a transport that responds to requests with the recorded
+// interactions instead of connecting to vCenter Server. A request is matched
+// to the first interaction that has not been replayed with the same method,
+// URL, and body, or, failing that, with the same method and URL, so that
+// requests that are repeated, such as waiting for a task, are replayed in the
+// order they were recorded.
+type replayer struct {
+	mu           sync.Mutex
+	interactions []*interaction
+}
+
+func newReplayer(path string) (*replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the recording %s: %s", path, err)
+	}
+	defer f.Close()
+
+	r := &replayer{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var i interaction
+		if err := json.Unmarshal([]byte(line), &i); err != nil {
+			return nil, fmt.Errorf("error reading the recording %s: %s", path, err)
+		}
+		r.interactions = append(r.interactions, &i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading the recording %s: %s", path, err)
+	}
+	log.Printf("[INFO] Replaying %d interactions with vCenter Server from %s", len(r.interactions), path)
+	return r, nil
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	// Compare the sanitized request, since the credentials are not recorded.
+	key := interaction{Method: req.Method, URL: requestURL(req), Request: body}
+	key.sanitize()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.match(&key, true)
+	if i == nil {
+		i = r.match(&key, false)
+	}
+	if i == nil {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, key.URL)
+	}
+	i.replayed = true
+
+	header := http.Header{}
+	if i.ContentType != "" {
+		header.Set("Content-Type", i.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(i.Response)),
+		ContentLength: int64(len(i.Response)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the first interaction that has not been replayed with the
+// same method and URL as the key and, if body is true, the same body.
+func (r *replayer) match(key *interaction, body bool) *interaction {
+	for _, i := range r.interactions {
+		if i.replayed || i.Method != key.Method || i.URL != key.URL {
+			continue
+		}
+		if body && i.Request != key.Request {
+			continue
+		}
+		return i
+	}
+	return nil
+}
+
+// recordingTransport returns a transport that records or replays the
+// interactions with vCenter Server if the EnvRecord or EnvReplay environment
+// variable is set, or nil if neither is set.
+func recordingTransport(transport http.RoundTripper) (http.RoundTripper, error) {
+	record, replay := os.Getenv(EnvRecord), os.Getenv(EnvReplay)
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("%s and %s cannot be used together", EnvRecord, EnvReplay)
+	case record != "":
+		return newRecorder(transport, record)
+	case replay != "":
+		return newReplayer(replay)
+	}
+	return nil, nil
+}
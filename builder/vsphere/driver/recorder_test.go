@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+)
+
+func TestRecordingTransport_ReplaysRecording(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	// The simulator accepts the credentials of the server URL.
+	sim.server.URL.User = simulator.DefaultLogin
+	password, _ := simulator.DefaultLogin.Password()
+	config := &ConnectConfig{
+		VCenterServer:      sim.server.URL.Host,
+		Username:           simulator.DefaultLogin.Username(),
+		Password:           password,
+		InsecureConnection: true,
+	}
+	recording := filepath.Join(t.TempDir(), "recording.jsonl")
+
+	t.Setenv(EnvRecord, recording)
+	d, err := NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	folder, err := d.FindFolder("")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected, err := folder.Path()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The recording is closed by the cleanup of the driver.
+	recorder := d.(*VCenterDriver).recorder
+	d.Cleanup()
+	if _, err := recorder.file.Write([]byte("\n")); err == nil {
+		t.Fatalf("unexpected success: expected the recording to be closed")
+	}
+
+	first, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The interactions of another driver of the build, such as that of a
+	// post-processor, are appended to the recording.
+	d, err = NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	d.Cleanup()
+
+	b, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !strings.HasPrefix(string(b), string(first)) || len(b) == len(first) {
+		t.Fatalf("unexpected result: expected the interactions to be appended to the recording")
+	}
+	if strings.Contains(string(b), "<password>"+password+"</password>") {
+		t.Fatalf("unexpected result: the recording contains the password")
+	}
+
+	// The recording is replayed without connecting to vCenter Server.
+	sim.Close()
+	t.Setenv(EnvRecord, "")
+	t.Setenv(EnvReplay, recording)
+	d, err = NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	folder, err = d.FindFolder("")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	path, err := folder.Path()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if path != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, path)
+	}
+}
+
+func TestRecordingTransport_Exclusive(t *testing.T) {
+	t.Setenv(EnvRecord, "recording.jsonl")
+	t.Setenv(EnvReplay, "recording.jsonl")
+	if _, err := recordingTransport(nil); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+}
+
+func TestInteraction_Sanitize(t *testing.T) {
+	i := interaction{
+		Method:   "POST",
+		URL:      "/rest/com/vmware/cis/session",
+		Request:  "<Login><userName>admin</userName><password>secret</password></Login>",
+		Response: `{"value":"session-id"}`,
+	}
+	i.sanitize()
+	expected := interaction{
+		Method:   "POST",
+		URL:      "/rest/com/vmware/cis/session",
+		Request:  "<Login><userName>REDACTED</userName><password>REDACTED</password></Login>",
+		Response: `{"value":"REDACTED"}`,
+	}
+	if i != expected {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, i)
+	}
+}
+
+func TestInteraction_SanitizeLogin(t *testing.T) {
+	tc := []struct {
+		name     string
+		i        interaction
+		expected interaction
+	}{
+		{
+			name: "Login",
+			i: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Request:  `<Login><_this type="SessionManager">SessionManager</_this><userName>admin</userName><password>secret</password></Login>`,
+				Response: `<LoginResponse><returnval><key>52b3e8f1-4a1c-bd8b-7f05-3b8d6d3b5a4e</key><userName>VSPHERE.LOCAL\\admin</userName><fullName>admin</fullName></returnval></LoginResponse>`,
+			},
+			expected: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Request:  `<Login><_this type="SessionManager">SessionManager</_this><userName>REDACTED</userName><password>REDACTED</password></Login>`,
+				Response: `<LoginResponse><returnval><key>REDACTED</key><userName>REDACTED</userName><fullName>admin</fullName></returnval></LoginResponse>`,
+			},
+		},
+		{
+			name: "Current session",
+			i: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Response: `<val xsi:type="UserSession"><key>52b3e8f1-4a1c-bd8b-7f05-3b8d6d3b5a4e</key><userName>admin</userName></val><val xsi:type="OptionValue"><key>config.vpxd.name</key></val>`,
+			},
+			expected: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Response: `<val xsi:type="UserSession"><key>REDACTED</key><userName>REDACTED</userName></val><val xsi:type="OptionValue"><key>config.vpxd.name</key></val>`,
+			},
+		},
+		{
+			name: "Clone ticket",
+			i: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Request:  `<CloneSession><cloneTicket>cst-VCT-52f4-cf1c</cloneTicket></CloneSession>`,
+				Response: `<AcquireCloneTicketResponse xmlns="urn:vim25"><returnval>cst-VCT-52f4-cf1c</returnval></AcquireCloneTicketResponse>`,
+			},
+			expected: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Request:  `<CloneSession><cloneTicket>REDACTED</cloneTicket></CloneSession>`,
+				Response: `<AcquireCloneTicketResponse xmlns="urn:vim25"><returnval>REDACTED</returnval></AcquireCloneTicketResponse>`,
+			},
+		},
+		{
+			name: "Session cookie",
+			i: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Response: `<returnval>vmware_soap_session="5ad3c2b8f1e0"; Path=/; HttpOnly</returnval>`,
+			},
+			expected: interaction{
+				Method:   "POST",
+				URL:      "/sdk",
+				Response: `<returnval>vmware_soap_session=REDACTED; Path=/; HttpOnly</returnval>`,
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			c.i.sanitize()
+			if c.i != c.expected {
+				t.Fatalf("unexpected result: expected '%v', but returned '%v'", c.expected, c.i)
+			}
+		})
+	}
+}
+
+func TestInteraction_SanitizeNested(t *testing.T) {
+	tc := []struct {
+		name     string
+		request  string
+		secrets  []string
+		expected []string
+	}{
+		{
+			name: "CustomizeVM_Task",
+			request: `<?xml version="1.0" encoding="UTF-8"?><Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body><CustomizeVM_Task xmlns="urn:vim25"><_this type="VirtualMachine">vm-42</_this>` +
+				`<spec><identity xsi:type="CustomizationSysprep"><guiUnattended><password><value>admin-secret</value><plainText>true</plainText></password></guiUnattended>` +
+				`<identification><joinDomain>example.com</joinDomain><domainAdmin>joiner</domainAdmin><domainAdminPassword><value>domain-secret</value><plainText>true</plainText></domainAdminPassword></identification></identity>` +
+				`<adminPassword><value>linux-secret</value><plainText>true</plainText></adminPassword></spec></CustomizeVM_Task></Body></Envelope>`,
+			secrets:  []string{"admin-secret", "domain-secret", "linux-secret"},
+			expected: []string{"<domainAdmin>joiner</domainAdmin>", "<joinDomain>example.com</joinDomain>", "<value>REDACTED</value>"},
+		},
+		{
+			name: "LoginByToken",
+			request: `<?xml version="1.0" encoding="UTF-8"?><Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Header><Security xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">` +
+				`<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="_assertion-id"><saml2:Issuer>https://sso.example.com</saml2:Issuer><saml2:Subject><saml2:NameID>admin@vsphere.local</saml2:NameID></saml2:Subject>` +
+				`<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:SignatureValue>c2lnbmF0dXJl</ds:SignatureValue></ds:Signature></saml2:Assertion></Security></Header>` +
+				`<Body><LoginByToken xmlns="urn:vim25"><_this type="SessionManager">SessionManager</_this></LoginByToken></Body></Envelope>`,
+			secrets:  []string{"https://sso.example.com", "admin@vsphere.local", "c2lnbmF0dXJl"},
+			expected: []string{"<saml2:NameID>REDACTED</saml2:NameID>", `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="_assertion-id">`},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			i := interaction{Method: "POST", URL: "/sdk", Request: c.request}
+			i.sanitize()
+			for _, s := range c.secrets {
+				if strings.Contains(i.Request, s) {
+					t.Fatalf("unexpected result: the recording contains '%s': '%s'", s, i.Request)
+				}
+			}
+			for _, s := range c.expected {
+				if !strings.Contains(i.Request, s) {
+					t.Fatalf("unexpected result: expected the recording to contain '%s': '%s'", s, i.Request)
+				}
+			}
+		})
+	}
+}
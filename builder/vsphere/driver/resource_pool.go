@@ -6,8 +6,8 @@ package driver
 import (
 	"fmt"
 	"log"
+	"path"
 
-	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -15,6 +15,7 @@ import (
 
 type ResourcePool struct {
 	pool   *object.ResourcePool
+	vapp   *object.VirtualApp
 	driver *VCenterDriver
 }
 
@@ -28,12 +29,27 @@ func (d *VCenterDriver) NewResourcePool(ref *types.ManagedObjectReference) *Reso
 }
 
 // FindResourcePool locates a resource pool by its name within a specified
-// cluster or host context in vCenter. It falls back to the default resource
-// pool or a vApp if the specified pool is not found. Returns a ResourcePool
-// object or an error if neither the specified nor default pool is accessible.
+// cluster or host context in vCenter. If the specified pool is not found, it
+// looks for a vApp with the specified name and falls back to the default
+// resource pool. Returns a ResourcePool object or an error if neither the
+// specified pool, a vApp, nor the default pool is accessible.
 func (d *VCenterDriver) FindResourcePool(cluster string, host string, name string) (*ResourcePool, error) {
+	if d.standaloneHost && name == "" {
+		// A standalone ESXi host has a single root resource pool.
+		p, err := d.finder.DefaultResourcePool(d.ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &ResourcePool{
+			pool:   p,
+			driver: d,
+		}, nil
+	}
+
 	var res string
-	if cluster != "" {
+	if d.standaloneHost {
+		res = "*"
+	} else if cluster != "" {
 		res = cluster
 	} else {
 		res = host
@@ -41,27 +57,46 @@ func (d *VCenterDriver) FindResourcePool(cluster string, host string, name strin
 
 	resourcePath := fmt.Sprintf("%v/Resources/%v", res, name)
 	p, err := d.finder.ResourcePool(d.ctx, resourcePath)
-	if err != nil {
-		log.Printf("[WARN] %s not found. Looking for default resource pool.", resourcePath)
-		dp, dperr := d.finder.DefaultResourcePool(d.ctx)
-		if _, ok := dperr.(*find.NotFoundError); ok {
-			vapp, verr := d.finder.VirtualApp(d.ctx, name)
-			if verr != nil {
-				return nil, err
+	if err == nil {
+		return &ResourcePool{
+			pool:   p,
+			driver: d,
+		}, nil
+	}
+
+	if name != "" {
+		// A vApp is found by its path in the resource pool hierarchy or by its
+		// path in the virtual machine folder.
+		vappPath := path.Join(d.datacenter.InventoryPath, "host", resourcePath)
+		for _, p := range []string{vappPath, name} {
+			if vapp, verr := d.finder.VirtualApp(d.ctx, p); verr == nil {
+				log.Printf("[INFO] Using vApp %s as the resource pool.", p)
+				return &ResourcePool{
+					pool:   vapp.ResourcePool,
+					vapp:   vapp,
+					driver: d,
+				}, nil
 			}
-			dp = vapp.ResourcePool
-		} else if dperr != nil {
-			return nil, err
 		}
-		p = dp
+	}
+
+	log.Printf("[WARN] %s not found. Looking for default resource pool.", resourcePath)
+	dp, dperr := d.finder.DefaultResourcePool(d.ctx)
+	if dperr != nil {
+		return nil, err
 	}
 
 	return &ResourcePool{
-		pool:   p,
+		pool:   dp,
 		driver: d,
 	}, nil
 }
 
+// IsVApp returns true if the resource pool is a vApp.
+func (p *ResourcePool) IsVApp() bool {
+	return p.vapp != nil
+}
+
 // Info retrieves the properties of the ResourcePool object with optional
 // filters specified as parameters. If no parameters are provided, all
 // properties are returned.
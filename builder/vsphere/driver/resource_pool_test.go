@@ -72,3 +72,35 @@ func TestVCenterDriver_FindResourcePoolStandaloneESX(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expectedResourcePool, res.pool.Name())
 	}
 }
+
+func TestVCenterDriver_FindResourcePoolVApp(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 1
+	model.App = 1
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vapp := simulator.Map.Any("VirtualApp").(*simulator.VirtualApp)
+
+	res, err := sim.driver.FindResourcePool("DC0_C0", "", vapp.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !res.IsVApp() {
+		t.Fatalf("unexpected result: expected '%s' to be a vApp", vapp.Name)
+	}
+	if res.pool.Reference() != vapp.Reference() {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", vapp.Reference(), res.pool.Reference())
+	}
+
+	res, err = sim.driver.FindResourcePool("DC0_C0", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if res.IsVApp() {
+		t.Fatalf("unexpected result: expected the root resource pool, but returned vApp '%s'", res.pool.Name())
+	}
+}
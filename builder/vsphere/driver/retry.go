@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// isTransientError reports whether an error of a vSphere operation is likely
+// to be resolved by running the operation again: another task holds a lock on
+// the object, the network connection failed, or vCenter Server is
+// temporarily unavailable.
+func isTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if fault.Is(err, &types.TaskInProgress{}) || fault.Is(err, &types.HostCommunication{}) {
+		return true
+	}
+	if soap.IsSoapFault(err) || soap.IsVimFault(err) {
+		return false
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && isTransientStatus(urlErr.Err.Error()) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isTransientStatus reports whether an HTTP status, such as
+// `503 Service Unavailable`, indicates that the server is temporarily unable
+// to handle the request.
+func isTransientStatus(status string) bool {
+	if len(status) < 3 {
+		return false
+	}
+	code, err := strconv.Atoi(status[:3])
+	if err != nil {
+		return false
+	}
+	switch code {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retry runs the operation and runs it again with exponential backoff while it
+// fails with a transient error, up to the number of task retries of the
+// driver.
+func (d *VCenterDriver) retry(ctx context.Context, name string, op func() error) error {
+	delay := d.taskRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt > d.taskRetryCount || !isTransientError(err) {
+			return err
+		}
+		log.Printf("[WARN] %s failed with a transient error; retrying in %s (%d/%d): %s",
+			name, delay, attempt, d.taskRetryCount, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// runTask starts a task and waits for the result, with the retries of retry.
+// A task that fails is started again, while a task that cannot be waited for
+// because of a network error is waited for again, so that the operation is
+// not run twice. Use runTaskOnce for the tasks that are not idempotent.
+func (d *VCenterDriver) runTask(ctx context.Context, name string, start func() (*object.Task, error)) (*types.TaskInfo, error) {
+	var t *object.Task
+	var info *types.TaskInfo
+	err := d.retry(ctx, name, func() error {
+		if t == nil {
+			var err error
+			if t, err = start(); err != nil {
+				return err
+			}
+		}
+		var err error
+		info, err = t.WaitForResult(ctx, nil)
+		var taskErr task.Error
+		if errors.As(err, &taskErr) {
+			t = nil
+		}
+		return err
+	})
+	return info, err
+}
+
+// runTaskOnce starts a task that is not idempotent, such as a task that creates
+// a virtual machine, and waits for the result. The task is not started again,
+// since a task that fails or that cannot be started because of a network error
+// may have completed on the server, and running it again leaves a duplicate or
+// orphaned virtual machine. Only waiting for the result is retried.
+func (d *VCenterDriver) runTaskOnce(ctx context.Context, name string, start func() (*object.Task, error)) (*types.TaskInfo, error) {
+	t, err := start()
+	if err != nil {
+		return nil, err
+	}
+
+	var info *types.TaskInfo
+	var taskErr error
+	err = d.retry(ctx, name, func() error {
+		var err error
+		info, err = t.WaitForResult(ctx, nil)
+		if errors.As(err, new(task.Error)) {
+			// The task failed, so waiting for the result again returns the
+			// same error.
+			taskErr = err
+			return nil
+		}
+		return err
+	})
+	if taskErr != nil {
+		return nil, taskErr
+	}
+	return info, err
+}
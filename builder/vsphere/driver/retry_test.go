@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func taskError(fault types.BaseMethodFault) error {
+	return task.Error{
+		LocalizedMethodFault: &types.LocalizedMethodFault{Fault: fault, LocalizedMessage: "task failed"},
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tc := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"Task in progress", taskError(&types.TaskInProgress{}), true},
+		{"Host communication", taskError(&types.HostCommunication{}), true},
+		{"Wrapped task in progress", fmt.Errorf("error reconfiguring: %w", taskError(&types.TaskInProgress{})), true},
+		{"Service unavailable", &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: errors.New("503 Service Unavailable")}, true},
+		{"Connection reset", &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: syscall.ECONNRESET}, true},
+		{"Unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"Not found", &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: errors.New("404 Not Found")}, false},
+		{"Invalid argument", taskError(&types.InvalidArgument{}), false},
+		{"Duplicate name", taskError(&types.DuplicateName{}), false},
+		{"Canceled", context.Canceled, false},
+		{"Deadline exceeded", &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: context.DeadlineExceeded}, false},
+		{"Other error", errors.New("permission denied"), false},
+		{"No error", nil, false},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if transient := isTransientError(c.err); transient != c.transient {
+				t.Fatalf("unexpected result: expected '%t', but returned '%t'", c.transient, transient)
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_Retry(t *testing.T) {
+	d := &VCenterDriver{taskRetryCount: 3, taskRetryDelay: time.Millisecond}
+
+	attempts := 0
+	err := d.retry(context.TODO(), "test", func() error {
+		attempts++
+		if attempts < 3 {
+			return taskError(&types.TaskInProgress{})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("unexpected result: expected '%d' attempts, but returned '%d'", 3, attempts)
+	}
+}
+
+func TestVCenterDriver_RetryExhausted(t *testing.T) {
+	d := &VCenterDriver{taskRetryCount: 2, taskRetryDelay: time.Millisecond}
+
+	attempts := 0
+	err := d.retry(context.TODO(), "test", func() error {
+		attempts++
+		return taskError(&types.TaskInProgress{})
+	})
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	// The first attempt and two retries.
+	if attempts != 3 {
+		t.Fatalf("unexpected result: expected '%d' attempts, but returned '%d'", 3, attempts)
+	}
+}
+
+func TestVCenterDriver_RetryPermanentError(t *testing.T) {
+	d := &VCenterDriver{taskRetryCount: 3, taskRetryDelay: time.Millisecond}
+
+	attempts := 0
+	err := d.retry(context.TODO(), "test", func() error {
+		attempts++
+		return taskError(&types.InvalidArgument{})
+	})
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if attempts != 1 {
+		t.Fatalf("unexpected result: expected '%d' attempt, but returned '%d'", 1, attempts)
+	}
+}
+
+func TestVCenterDriver_RetryCanceled(t *testing.T) {
+	d := &VCenterDriver{taskRetryCount: 3, taskRetryDelay: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	attempts := 0
+	err := d.retry(ctx, "test", func() error {
+		attempts++
+		cancel()
+		return taskError(&types.TaskInProgress{})
+	})
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if attempts != 1 {
+		t.Fatalf("unexpected result: expected '%d' attempt, but returned '%d'", 1, attempts)
+	}
+}
+
+func TestVirtualMachineDriver_PowerOnRetry(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	sim.driver.taskRetryCount = 3
+	sim.driver.taskRetryDelay = time.Millisecond
+
+	if err := vm.PowerOn(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	// Powering on a virtual machine that is already powered on is not a
+	// transient error and is not retried.
+	start := time.Now()
+	if err := vm.PowerOn(); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("unexpected result: expected no retries, but took '%s'", elapsed)
+	}
+}
+
+func TestVCenterDriver_RunTaskOnce(t *testing.T) {
+	d := &VCenterDriver{taskRetryCount: 3, taskRetryDelay: time.Millisecond}
+
+	// A task that may have been started on the server is not started again.
+	starts := 0
+	_, err := d.runTaskOnce(context.TODO(), "test", func() (*object.Task, error) {
+		starts++
+		return nil, io.ErrUnexpectedEOF
+	})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", io.ErrUnexpectedEOF, err)
+	}
+	if starts != 1 {
+		t.Fatalf("unexpected result: expected '%d' start, but returned '%d'", 1, starts)
+	}
+}
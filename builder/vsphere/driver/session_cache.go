@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/vmware/govmomi/session/cache"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// sessionCacheLockTimeout is the maximum time to wait for another process to
+// finish logging in with the session cache.
+const sessionCacheLockTimeout = 2 * time.Minute
+
+// sessionCache stores authenticated SOAP and REST sessions in files, so that
+// concurrent and subsequent builds reuse a session instead of each logging
+// in. The cache uses the same format as govc.
+type sessionCache struct {
+	session *cache.Session
+	lock    *flock.Flock
+	// Configures the TLS of the clients that log in.
+	configure func(*soap.Client) error
+}
+
+// newSessionCache returns a session cache for the endpoint in the specified
+// directory. The default directory of govc, `$GOVMOMI_HOME` or
+// `$HOME/.govmomi`, is used if the directory is empty.
+func newSessionCache(u *url.URL, insecure bool, dir string, configure func(*soap.Client) error) (*sessionCache, error) {
+	if dir == "" {
+		dir = os.Getenv("GOVMOMI_HOME")
+	}
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("error finding the session cache directory: %s", err)
+		}
+		dir = filepath.Join(home, ".govmomi")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating the session cache directory: %s", err)
+	}
+
+	return &sessionCache{
+		session: &cache.Session{
+			URL:      u,
+			Insecure: insecure,
+			DirSOAP:  filepath.Join(dir, "sessions"),
+			DirREST:  filepath.Join(dir, "rest_sessions"),
+		},
+		lock:      flock.New(filepath.Join(dir, "sessions.lock")),
+		configure: configure,
+	}, nil
+}
+
+// Login loads a valid session for the client from the cache, or logs in and
+// saves the new session to the cache if there is none or the cached session
+// has expired. Logins are serialized across processes with a file lock, so
+// that concurrent builds reuse the session created by the first build.
+func (c *sessionCache) Login(ctx context.Context, client cache.Client) error {
+	lockCtx, cancel := context.WithTimeout(ctx, sessionCacheLockTimeout)
+	defer cancel()
+	locked, err := c.lock.TryLockContext(lockCtx, 100*time.Millisecond)
+	if err != nil || !locked {
+		return fmt.Errorf("error locking the session cache %s: %v", c.lock.Path(), err)
+	}
+	defer func() {
+		_ = c.lock.Unlock()
+	}()
+
+	return c.session.Login(ctx, client, c.configure)
+}
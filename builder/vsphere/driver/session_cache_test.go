@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+)
+
+func TestNewDriver_SessionCache(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.server.URL.User = simulator.DefaultLogin
+
+	dir := t.TempDir()
+	config := &ConnectConfig{
+		VCenterServer:      sim.server.URL.Host,
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+		SessionCache:       true,
+		SessionCacheDir:    dir,
+	}
+
+	first, err := NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	second, err := NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	ctx := context.TODO()
+	firstSession, err := first.(*VCenterDriver).client.SessionManager.UserSession(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	secondSession, err := second.(*VCenterDriver).client.SessionManager.UserSession(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if firstSession == nil || secondSession == nil || firstSession.Key != secondSession.Key {
+		t.Fatalf("unexpected result: expected the cached session to be reused")
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, "sessions"))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("unexpected result: expected '%d' cached session, but found '%d'", 1, len(files))
+	}
+
+	// The cached session is not logged out, so that it remains valid for
+	// the other driver.
+	if restErr, soapErr := first.Cleanup(); restErr != nil || soapErr != nil {
+		t.Fatalf("unexpected error: '%v', '%v'", restErr, soapErr)
+	}
+	if _, err := second.FindFolder(""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestNewDriver_SessionCacheExpired(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.server.URL.User = simulator.DefaultLogin
+
+	config := &ConnectConfig{
+		VCenterServer:      sim.server.URL.Host,
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+		SessionCache:       true,
+		SessionCacheDir:    t.TempDir(),
+	}
+
+	first, err := NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	ctx := context.TODO()
+	expired, err := first.(*VCenterDriver).client.SessionManager.UserSession(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := first.(*VCenterDriver).client.SessionManager.Logout(ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	second, err := NewDriver(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	renewed, err := second.(*VCenterDriver).client.SessionManager.UserSession(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if renewed == nil || renewed.Key == expired.Key {
+		t.Fatalf("unexpected result: expected a new session to replace the expired session")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/pbm"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// FindStoragePolicy returns the identifier of the VM storage policy with the
+// given name.
+func (d *VCenterDriver) FindStoragePolicy(name string) (string, error) {
+	if d.standaloneHost {
+		return "", errVCenterRequired("storage policies")
+	}
+
+	c, err := pbm.NewClient(d.ctx, d.vimClient)
+	if err != nil {
+		return "", fmt.Errorf("error connecting to the storage policy service: %s", err)
+	}
+	id, err := c.ProfileIDByName(d.ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("error finding storage policy %s: %s", name, err)
+	}
+	return id, nil
+}
+
+// storageProfileSpec returns the profile specification that applies the
+// storage policy, or nil if the identifier is empty, so that the default
+// policy of the datastore applies.
+func storageProfileSpec(id string) []types.BaseVirtualMachineProfileSpec {
+	if id == "" {
+		return nil
+	}
+	return []types.BaseVirtualMachineProfileSpec{
+		&types.VirtualMachineDefinedProfileSpec{ProfileId: id},
+	}
+}
+
+// applyDiskStoragePolicy applies the storage policy to the disks that are
+// created or attached by the device changes and have no storage policy.
+func applyDiskStoragePolicy(changes []types.BaseVirtualDeviceConfigSpec, id string) {
+	if id == "" {
+		return
+	}
+	for _, change := range changes {
+		spec := change.GetVirtualDeviceConfigSpec()
+		if _, ok := spec.Device.(*types.VirtualDisk); !ok || len(spec.Profile) > 0 {
+			continue
+		}
+		if spec.Operation != types.VirtualDeviceConfigSpecOperationAdd {
+			continue
+		}
+		spec.Profile = storageProfileSpec(id)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	_ "github.com/vmware/govmomi/pbm/simulator"
+)
+
+func TestVCenterDriver_FindStoragePolicy(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	id, err := sim.driver.FindStoragePolicy("vSAN Default Storage Policy")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if id == "" {
+		t.Fatalf("unexpected result: expected a storage policy identifier")
+	}
+
+	if _, err := sim.driver.FindStoragePolicy("unknown"); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+}
+
+func TestVCenterDriver_CreateVMWithStoragePolicy(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	id, err := sim.driver.FindStoragePolicy("vSAN Default Storage Policy")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	_, err = sim.driver.CreateVM(&CreateConfig{
+		Name:      "vm-storage-policy",
+		Host:      "DC0_H0",
+		Datastore: "LocalDS_0",
+		StorageConfig: StorageConfig{
+			DiskControllerType: []string{"pvscsi"},
+			Storage:            []Disk{{DiskSize: 1024, DiskThinProvisioned: true}},
+			StoragePolicyID:    id,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vapi/tags"
+)
+
+// Tag is a tag with the category it belongs to and the objects it is
+// attached to.
+type Tag struct {
+	ID           string
+	Name         string
+	CategoryID   string
+	CategoryName string
+	Objects      []TaggedObject
+}
+
+// TaggedObject is an inventory object a tag is attached to.
+type TaggedObject struct {
+	Type string
+	ID   string
+	Name string
+	Path string
+}
+
+// FindTag retrieves the tag with the specified name in the specified
+// category and the inventory objects the tag is attached to.
+func (d *VCenterDriver) FindTag(categoryName string, tagName string) (*Tag, error) {
+	if err := d.restClient.Login(d.ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = d.restClient.Logout(d.ctx)
+	}()
+
+	tm := tags.NewManager(d.restClient.client)
+	category, err := tm.GetCategory(d.ctx, categoryName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving tag category %s: %s", categoryName, err)
+	}
+	tag, err := tm.GetTagForCategory(d.ctx, tagName, category.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving tag %s: %s", tagName, err)
+	}
+	refs, err := tm.ListAttachedObjects(d.ctx, tag.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the objects attached to tag %s: %s", tagName, err)
+	}
+
+	t := &Tag{
+		ID:           tag.ID,
+		Name:         tag.Name,
+		CategoryID:   category.ID,
+		CategoryName: category.Name,
+	}
+	finder := find.NewFinder(d.vimClient, false)
+	for _, ref := range refs {
+		r := ref.Reference()
+		o := TaggedObject{Type: r.Type, ID: r.Value}
+		// Objects the inventory path cannot be retrieved for, such as content
+		// library items, are listed without a name and path.
+		if e, err := finder.Element(d.ctx, r); err == nil {
+			o.Name = path.Base(e.Path)
+			o.Path = e.Path
+		}
+		t.Objects = append(t.Objects, o)
+	}
+	return t, nil
+}
+
+// FindOrCreateTag returns the identifier of the tag with the specified name
+// in the specified category. If create is true, the category and the tag are
+// created if they do not exist. A created category allows multiple tags per
+// object and is associable with virtual machines.
+func (d *VCenterDriver) FindOrCreateTag(categoryName string, tagName string, create bool) (string, error) {
+	if err := d.restClient.Login(d.ctx); err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = d.restClient.Logout(d.ctx)
+	}()
+
+	tm := tags.NewManager(d.restClient.client)
+	categoryID, err := findOrCreateCategory(d.ctx, tm, categoryName, create)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := tm.GetTagForCategory(d.ctx, tagName, categoryID)
+	if err == nil {
+		return tag.ID, nil
+	}
+	if !create {
+		return "", fmt.Errorf("error retrieving tag %s: %s", tagName, err)
+	}
+	id, err := tm.CreateTag(d.ctx, &tags.Tag{Name: tagName, CategoryID: categoryID})
+	if err != nil {
+		return "", fmt.Errorf("error creating tag %s: %s", tagName, err)
+	}
+	return id, nil
+}
+
+func findOrCreateCategory(ctx context.Context, tm *tags.Manager, name string, create bool) (string, error) {
+	category, err := tm.GetCategory(ctx, name)
+	if err == nil {
+		return category.ID, nil
+	}
+	if !create {
+		return "", fmt.Errorf("error retrieving tag category %s: %s", name, err)
+	}
+	id, err := tm.CreateCategory(ctx, &tags.Category{
+		Name:            name,
+		Cardinality:     "MULTIPLE",
+		AssociableTypes: []string{"VirtualMachine"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creating tag category %s: %s", name, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func TestVCenterDriver_FindOrCreateTag(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.driver.restClient.credentials = simulator.DefaultLogin
+
+	if _, err := sim.driver.FindOrCreateTag("os", "linux", false); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	id, err := sim.driver.FindOrCreateTag("os", "linux", true)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	found, err := sim.driver.FindOrCreateTag("os", "linux", false)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if found != id {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", id, found)
+	}
+	other, err := sim.driver.FindOrCreateTag("os", "windows", true)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if other == id {
+		t.Fatalf("unexpected result: expected a new tag, but returned '%s'", other)
+	}
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.AttachTag(id); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	attached, err := vm.Tags()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(attached) != 1 || attached[0] != id {
+		t.Fatalf("unexpected result: expected '[%s]', but returned '%v'", id, attached)
+	}
+}
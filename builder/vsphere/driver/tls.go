@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// configureTLS returns a function that configures the certificate authorities
+// and the client certificate of a SOAP client, which are shared by the
+// clients that are created from it, such as the REST client.
+func (c *ConnectConfig) configureTLS() (func(*soap.Client) error, error) {
+	var pool *x509.CertPool
+	if c.CACertFile != "" || c.CACertPEM != "" {
+		var err error
+		pool, err = x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if c.CACertFile != "" {
+			pem, err := os.ReadFile(c.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("error reading the CA certificates: %s", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no valid CA certificates found in %s", c.CACertFile)
+			}
+		}
+		if c.CACertPEM != "" && !pool.AppendCertsFromPEM([]byte(c.CACertPEM)) {
+			return nil, fmt.Errorf("no valid CA certificates found in the PEM-encoded certificates")
+		}
+	}
+
+	var cert *tls.Certificate
+	if c.ClientCertFile != "" {
+		keyPair, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the client certificate: %s", err)
+		}
+		cert = &keyPair
+	}
+
+	return func(sc *soap.Client) error {
+		if pool != nil {
+			sc.DefaultTransport().TLSClientConfig.RootCAs = pool
+		}
+		if cert != nil {
+			sc.SetCertificate(*cert)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+)
+
+func TestNewDriver_CACertificates(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	sim.server.URL.User = simulator.DefaultLogin
+
+	config := &ConnectConfig{
+		VCenterServer: sim.server.URL.Host,
+		Username:      "user",
+		Password:      "pass",
+	}
+	if _, err := NewDriver(config); err == nil {
+		t.Fatal("unexpected success: expected the certificate to be untrusted")
+	}
+
+	caFile, err := sim.server.CertificateFile()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	config.CACertFile = caFile
+	if _, err := NewDriver(config); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	config.CACertFile = ""
+	config.CACertPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: sim.server.Certificate().Raw}))
+	if _, err := NewDriver(config); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestConnectConfig_configureTLS(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name     string
+		config   ConnectConfig
+		expected string
+	}{
+		{"Invalid CA certificate file", ConnectConfig{CACertFile: invalid}, "no valid CA certificates found"},
+		{"Invalid CA certificates", ConnectConfig{CACertPEM: "invalid"}, "no valid CA certificates found"},
+		{"Invalid client certificate", ConnectConfig{ClientCertFile: invalid, ClientKeyFile: invalid}, "error loading the client certificate"},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.config.configureTLS()
+			if err == nil || !strings.Contains(err.Error(), c.expected) {
+				t.Fatalf("unexpected error: expected '%s', but returned '%v'", c.expected, err)
+			}
+		})
+	}
+}
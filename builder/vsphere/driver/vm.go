@@ -7,9 +7,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -33,28 +36,58 @@ type VirtualMachine interface {
 	Clone(ctx context.Context, config *CloneConfig) (VirtualMachine, error)
 	updateVAppConfig(ctx context.Context, newProps map[string]string) (*types.VmConfigSpec, error)
 	AddPublicKeys(ctx context.Context, publicKeys string) error
+	ResetVAppProperties(ids []string) error
 	Properties(ctx context.Context) (*mo.VirtualMachine, error)
 	Destroy() error
 	Configure(config *HardwareConfig) error
 	Reconfigure(spec types.VirtualMachineConfigSpec) error
+	SetManagedBy(extensionKey string, managedType string) error
+	UpgradeHardwareVersion(version uint) error
+	Encrypt(spec *EncryptionSpec) (string, error)
+	Tags() ([]string, error)
+	AttachTag(id string) error
+	SetCustomAttribute(key int32, value string) error
+	Notes() (string, error)
+	SetNotes(notes string) error
 	Customize(spec types.CustomizationSpec) error
 	ResizeDisk(diskSize int64) ([]types.BaseVirtualDeviceConfigSpec, error)
-	WaitForIP(ctx context.Context, ipNet *net.IPNet) (string, error)
+	WaitForIP(ctx context.Context, filter *IPFilter) (string, error)
+	WaitForIPs(ctx context.Context, filter *IPFilter) ([]string, error)
 	PowerOn() error
+	MigrateToAnotherHost(excluded []string) (string, string, error)
+	HostAddresses() ([]string, error)
 	PowerOff() error
 	IsPoweredOff() (bool, error)
 	StartShutdown() error
 	WaitForShutdown(ctx context.Context, timeout time.Duration) error
 	CreateSnapshot(name string) error
+	CreateMemorySnapshot(name string) error
+	CreateSnapshotWithOptions(name string, description string, memory bool) error
+	RemoveSnapshot(name string) error
+	ListSnapshots() ([]string, error)
+	RemoveAllSnapshots() error
+	AddHostAffinityRule(hosts []string, mandatory bool) (*HostAffinityRule, error)
+	RemoveHostAffinityRule(rule *HostAffinityRule) error
+	EnableChangeTracking() error
+	ChangedDisks(snapshotName string) ([]bool, error)
+	DiagnosticFiles() ([]string, error)
+	MissingPrivileges(privileges ...string) ([]string, error)
 	ConvertToTemplate() error
 	IsTemplate() (bool, error)
 	ConvertToVirtualMachine(vsphereCluster string, vsphereHost string, vsphereResourcePool string) error
 	ImportOvfToContentLibrary(ovf vcenter.OVF) error
+	StreamOvfToContentLibrary(ctx context.Context, config *StreamOvfConfig) error
 	ImportToContentLibrary(template vcenter.Template) error
 	GetDir() (string, error)
 	AddFloppy(imgPath string) error
 	SetBootOrder(order []string) error
+	SetBootOptions(options *BootOptions) error
+	EnterSetupPending() (bool, error)
+	Reset() error
 	RemoveDevice(keepFiles bool, device ...types.BaseVirtualDevice) error
+	AttachFirstClassDisk(id string, datastore string, controllerIndex int) error
+	DetachFirstClassDisk(id string) error
+	AddSerialPortFile(name string) (string, error)
 	addDevice(device types.BaseVirtualDevice) error
 	AddConfigParams(params map[string]string, info *types.ToolsConfigInfo) error
 	AddFlag(ctx context.Context, info *types.VirtualMachineFlagInfo) error
@@ -65,6 +98,7 @@ type VirtualMachine interface {
 	Datacenter() *object.Datacenter
 
 	AddCdrom(controllerType string, datastoreIsoPath string) error
+	AddCdromAt(placement *CdromPlacement, datastoreIsoPath string) error
 	CreateCdrom(c *types.VirtualController) (*types.VirtualCdrom, error)
 	RemoveCdroms() error
 	RemoveNCdroms(nCdroms int) error
@@ -73,6 +107,16 @@ type VirtualMachine interface {
 	FindSATAController() (*types.VirtualAHCIController, error)
 
 	RemoveNetworkAdapters() error
+	SetNetworkAdaptersConnected(indices []int, connected bool) error
+	GuestNetworkAdapters() ([]types.GuestNicInfo, error)
+
+	StartGuestProgram(username, password, path, args string) (int64, error)
+	GuestProgramExitCode(username, password string, pid int64) (*int32, error)
+	DownloadGuestFile(username, password, path string) ([]byte, error)
+	UploadGuestFile(username, password, path string, r io.Reader, size int64) error
+	MakeGuestDirectory(username, password, path string) error
+	CreateGuestTempFile(username, password, suffix string) (string, error)
+	DeleteGuestFile(username, password, path string) error
 }
 
 type VirtualMachineDriver struct {
@@ -81,19 +125,31 @@ type VirtualMachineDriver struct {
 }
 
 type CloneConfig struct {
-	Name            string
-	Folder          string
-	Cluster         string
-	Host            string
-	ResourcePool    string
-	Datastore       string
-	LinkedClone     bool
-	Network         string
+	Name         string
+	Folder       string
+	Cluster      string
+	Host         string
+	ResourcePool string
+	Datastore    string
+	LinkedClone  bool
+	// The name, path, or managed object ID of the snapshot for a linked
+	// clone. The current snapshot is used if empty.
+	LinkedCloneSnapshot string
+	Network             string
+	// The distributed virtual switch that owns the network, and the host
+	// that must have access to the network, if more than one network
+	// resolves to the name.
+	NetworkSwitch   string
+	NetworkHost     string
 	MacAddress      string
 	Annotation      string
 	VAppProperties  map[string]string
 	PrimaryDiskSize int64
-	StorageConfig   StorageConfig
+	// The new sizes of the disks of the source.
+	DiskResizes   []DiskResize
+	StorageConfig StorageConfig
+	// Virtual machines in a vApp cannot be converted to a template.
+	ConvertToTemplate bool
 }
 
 type PCIPassthroughAllowedDevice struct {
@@ -104,31 +160,45 @@ type PCIPassthroughAllowedDevice struct {
 }
 
 type HardwareConfig struct {
-	CPUs                  int32
-	CpuCores              int32
-	CPUReservation        int64
-	CPULimit              int64
-	RAM                   int64
-	RAMReservation        int64
-	RAMReserveAll         bool
-	NestedHV              bool
-	CpuHotAddEnabled      bool
-	MemoryHotAddEnabled   bool
-	VideoRAM              int64
-	Displays              int32
-	AllowedDevices        []PCIPassthroughAllowedDevice
-	VGPUProfile           string
-	Firmware              string
-	ForceBIOSSetup        bool
-	VTPMEnabled           bool
-	VirtualPrecisionClock string
+	CPUs                     int32
+	CpuCores                 int32
+	CPUReservation           int64
+	CPULimit                 int64
+	RAM                      int64
+	RAMReservation           int64
+	RAMReserveAll            bool
+	NestedHV                 bool
+	CpuHotAddEnabled         bool
+	MemoryHotAddEnabled      bool
+	VideoRAM                 int64
+	Displays                 int32
+	AllowedDevices           []PCIPassthroughAllowedDevice
+	VGPUProfiles             []string
+	VGPUDeviceGroup          string
+	PCIPassthroughDevices    []string
+	PCIPassthroughMMIOSizeGB int64
+	Firmware                 string
+	ForceBIOSSetup           bool
+	VTPMEnabled              bool
+	VirtualPrecisionClock    string
+	WatchdogTimer            bool
+	WatchdogTimerRunOnBoot   bool
+	SGXEpcSize               int64
+	SGXFlcMode               string
+	SGXLePubKeyHash          string
+	PMem                     []PMemDevice
+	SerialPorts              []SerialPort
 }
 
 type NIC struct {
-	Network     string
-	NetworkCard string
-	MacAddress  string
-	Passthrough *bool
+	Network           string
+	NetworkCard       string
+	MacAddress        string
+	Passthrough       *bool
+	NetworkSwitch     string
+	NetworkHost       string
+	StartDisconnected bool
+	PhysicalFunction  string
 }
 
 type CreateConfig struct {
@@ -144,6 +214,16 @@ type CreateConfig struct {
 	USBController []string
 	Version       uint
 	StorageConfig StorageConfig
+	// The datastore paths of existing virtual disk files to attach to the
+	// first disk controller, and whether they are copied or linked.
+	AttachDisks    []string
+	AttachDiskMode string
+	// Virtual machines in a vApp cannot be converted to a template.
+	ConvertToTemplate bool
+}
+
+func errVAppTemplate(vapp string) error {
+	return fmt.Errorf("virtual machines in vApp %s cannot be converted to a template; set 'convert_to_template' to false", vapp)
 }
 
 // NewVM creates a new virtual machine object.
@@ -212,6 +292,7 @@ func (d *VCenterDriver) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 		Name:       config.Name,
 		Annotation: config.Annotation,
 		GuestId:    config.GuestOS,
+		VmProfile:  storageProfileSpec(config.StorageConfig.StoragePolicyID),
 	}
 	if config.Version != 0 {
 		createSpec.Version = fmt.Sprintf("%s%d", "vmx-", config.Version)
@@ -226,6 +307,9 @@ func (d *VCenterDriver) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 	if err != nil {
 		return nil, err
 	}
+	if resourcePool.IsVApp() && config.ConvertToTemplate {
+		return nil, errVAppTemplate(config.ResourcePool)
+	}
 
 	var host *object.HostSystem
 	if config.Cluster != "" && config.Host != "" {
@@ -248,7 +332,17 @@ func (d *VCenterDriver) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 	}
 	createSpec.DeviceChange = append(createSpec.DeviceChange, storageConfigSpec...)
 
-	devices, err = addNetwork(d, devices, config)
+	// The SR-IOV network adapters are validated against the devices of the
+	// host, since the virtual machine fails to power on otherwise.
+	var target *types.ConfigTarget
+	if HasSRIOVNICs(config.NICs) {
+		target, err = d.computeConfigTarget(resourcePool, host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	devices, err = addNetwork(d, devices, config, target)
 	if err != nil {
 		return nil, err
 	}
@@ -281,11 +375,13 @@ func (d *VCenterDriver) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 		VmPathName: fmt.Sprintf("[%s]", datastore.Name()),
 	}
 
-	task, err := folder.folder.CreateVM(d.ctx, createSpec, resourcePool.pool, host)
-	if err != nil {
-		return nil, err
-	}
-	taskInfo, err := task.WaitForResult(d.ctx, nil)
+	taskInfo, err := d.runTaskOnce(d.ctx, "create virtual machine", func() (*object.Task, error) {
+		if resourcePool.IsVApp() {
+			// Virtual machines in a vApp are placed in the vApp rather than in a folder.
+			return resourcePool.vapp.CreateChildVM(d.ctx, createSpec, host)
+		}
+		return folder.folder.CreateVM(d.ctx, createSpec, resourcePool.pool, host)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -295,7 +391,17 @@ func (d *VCenterDriver) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 		return nil, fmt.Errorf("something went wrong when creating the VM")
 	}
 
-	return d.NewVM(&vmRef), nil
+	vm := d.NewVM(&vmRef)
+	if len(config.AttachDisks) > 0 {
+		if err := vm.(*VirtualMachineDriver).attachDisks(config.AttachDisks, config.AttachDiskMode); err != nil {
+			// The virtual machine is not returned and would otherwise remain.
+			if destroyErr := vm.Destroy(); destroyErr != nil {
+				log.Printf("[WARN] Failed to destroy the virtual machine %s: %s", config.Name, destroyErr)
+			}
+			return nil, err
+		}
+	}
+	return vm, nil
 }
 
 // Info retrieves properties of the virtual machine object with optional filters
@@ -350,6 +456,10 @@ func (vm *VirtualMachineDriver) CdromDevices() (object.VirtualDeviceList, error)
 
 // Clone creates a new virtual machine by cloning an existing one.
 func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig) (VirtualMachine, error) {
+	if vm.driver.standaloneHost {
+		return nil, errVCenterRequired("cloning a virtual machine")
+	}
+
 	folder, err := vm.driver.FindFolder(config.Folder)
 	if err != nil {
 		return nil, fmt.Errorf("error finding folder: %s", err)
@@ -361,6 +471,9 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error finding resource pool: %s", err)
 	}
+	if pool.IsVApp() && config.ConvertToTemplate {
+		return nil, errVAppTemplate(config.ResourcePool)
+	}
 	poolRef := pool.pool.Reference()
 	relocateSpec.Pool = &poolRef
 
@@ -370,6 +483,7 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 	}
 	datastoreRef := datastore.Reference()
 	relocateSpec.Datastore = &datastoreRef
+	relocateSpec.Profile = storageProfileSpec(config.StorageConfig.StoragePolicyID)
 
 	if config.Cluster != "" && config.Host != "" {
 		h, err := vm.driver.FindHost(config.Host)
@@ -396,6 +510,20 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 			return nil, err
 		}
 		cloneSpec.Snapshot = tpl.Snapshot.CurrentSnapshot
+
+		if config.LinkedCloneSnapshot != "" {
+			snapshot, err := vm.vm.FindSnapshot(vm.driver.ctx, config.LinkedCloneSnapshot)
+			if err != nil {
+				// List the snapshots in the tree, since the name of a
+				// snapshot is easily mistyped when a source has many.
+				names, listErr := vm.ListSnapshots()
+				if listErr != nil {
+					return nil, fmt.Errorf("error finding snapshot for linked clone: %s", err)
+				}
+				return nil, fmt.Errorf("error finding snapshot for linked clone: %s (available snapshots: %s)", err, strings.Join(names, ", "))
+			}
+			cloneSpec.Snapshot = snapshot
+		}
 	}
 
 	var configSpec types.VirtualMachineConfigSpec
@@ -418,9 +546,29 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 		configSpec.DeviceChange = append(configSpec.DeviceChange, deviceResizeSpec...)
 	}
 
+	if len(config.DiskResizes) > 0 {
+		resizeSpec, err := resizeDisks(devices, config.DiskResizes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resize disks: %s", err)
+		}
+		configSpec.DeviceChange = append(configSpec.DeviceChange, resizeSpec...)
+	}
+
 	virtualDisks := devices.SelectByType((*types.VirtualDisk)(nil))
 	virtualControllers := devices.SelectByType((*types.VirtualController)(nil))
 
+	// Apply the storage policy to the disks of the source, which otherwise
+	// keep the storage policy of the source.
+	if config.StorageConfig.StoragePolicyID != "" {
+		for _, disk := range virtualDisks {
+			cloneSpec.Location.Disk = append(cloneSpec.Location.Disk, types.VirtualMachineRelocateSpecDiskLocator{
+				DiskId:    disk.GetVirtualDevice().Key,
+				Datastore: datastoreRef,
+				Profile:   storageProfileSpec(config.StorageConfig.StoragePolicyID),
+			})
+		}
+	}
+
 	// Use existing devices to avoid overlapping configuration.
 	existingDevices := object.VirtualDeviceList{}
 	existingDevices = append(existingDevices, virtualDisks...)
@@ -433,11 +581,15 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 	configSpec.DeviceChange = append(configSpec.DeviceChange, storageConfigSpec...)
 
 	if config.Network != "" {
-		net, err := vm.driver.FindNetwork(config.Network)
+		host := config.Host
+		if config.NetworkHost != "" {
+			host = config.NetworkHost
+		}
+		network, err := findNetwork(config.Network, host, config.NetworkSwitch, vm.driver)
 		if err != nil {
 			return nil, fmt.Errorf("error finding network: %s", err)
 		}
-		backing, err := net.network.EthernetCardBackingInfo(ctx)
+		backing, err := network.EthernetCardBackingInfo(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("error finding ethernet card backing info: %s", err)
 		}
@@ -474,17 +626,28 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 	}
 	configSpec.VAppConfig = vAppConfig
 
-	task, err := vm.vm.Clone(vm.driver.ctx, folder.folder, config.Name, cloneSpec)
-	if err != nil {
-		return nil, fmt.Errorf("error calling vm.vm.Clone task: %s", err)
-	}
-
-	info, err := task.WaitForResult(ctx, nil)
+	// The last clone task is kept to cancel it if the build is canceled or
+	// times out.
+	var task *object.Task
+	info, err := vm.driver.runTaskOnce(ctx, "clone virtual machine", func() (*object.Task, error) {
+		var err error
+		task, err = vm.vm.Clone(vm.driver.ctx, folder.folder, config.Name, cloneSpec)
+		return task, err
+	})
 	if err != nil {
+		if task == nil {
+			return nil, fmt.Errorf("error calling vm.vm.Clone task: %s", err)
+		}
 		if ctx.Err() == context.Canceled {
 			err = task.Cancel(context.TODO())
 			return nil, err
 		}
+		if ctx.Err() == context.DeadlineExceeded {
+			if err := task.Cancel(context.TODO()); err != nil {
+				log.Printf("[WARN] error canceling the clone task: %s", err)
+			}
+			return nil, fmt.Errorf("timed out waiting for virtual machine clone to complete")
+		}
 
 		return nil, fmt.Errorf("error waiting for virtual machine clone to complete: %s", err)
 	}
@@ -567,6 +730,61 @@ func (vm *VirtualMachineDriver) AddPublicKeys(ctx context.Context, publicKeys st
 	return err
 }
 
+// ResetVAppProperties clears the values of the vApp properties with the
+// specified identifiers, which resets the properties to their default values.
+func (vm *VirtualMachineDriver) ResetVAppProperties(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	info, err := vm.Info("config.vAppConfig")
+	if err != nil {
+		return err
+	}
+	if info.Config == nil || info.Config.VAppConfig == nil {
+		return fmt.Errorf("no vApp configuration found; cannot reset vApp properties")
+	}
+
+	reset := make(map[string]bool)
+	for _, id := range ids {
+		reset[id] = true
+	}
+
+	var props []types.VAppPropertySpec
+	for _, p := range info.Config.VAppConfig.GetVmConfigInfo().Property {
+		if !reset[p.Id] {
+			continue
+		}
+		delete(reset, p.Id)
+		if p.Value == "" {
+			continue
+		}
+		prop := p
+		prop.Value = ""
+		props = append(props, types.VAppPropertySpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{
+				Operation: types.ArrayUpdateOperationEdit,
+			},
+			Info: &prop,
+		})
+	}
+	if len(reset) > 0 {
+		var missing []string
+		for id := range reset {
+			missing = append(missing, id)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("vApp properties not found: %s", strings.Join(missing, ", "))
+	}
+	if len(props) == 0 {
+		return nil
+	}
+
+	return vm.Reconfigure(types.VirtualMachineConfigSpec{
+		VAppConfig: &types.VmConfigSpec{Property: props},
+	})
+}
+
 // Properties retrieves the properties of a virtual machine.
 func (vm *VirtualMachineDriver) Properties(ctx context.Context) (*mo.VirtualMachine, error) {
 	log.Printf("fetching properties for VM %q", vm.vm.InventoryPath)
@@ -635,30 +853,22 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 		confSpec.DeviceChange = append(confSpec.DeviceChange, spec)
 	}
 
-	if config.VGPUProfile != "" {
-		devices, err := vm.vm.Device(vm.driver.ctx)
+	if len(config.VGPUProfiles) > 0 || config.VGPUDeviceGroup != "" {
+		changes, groups, err := vm.vgpuDeviceChanges(config.VGPUProfiles, config.VGPUDeviceGroup)
 		if err != nil {
 			return err
 		}
+		confSpec.DeviceChange = append(confSpec.DeviceChange, changes...)
+		confSpec.DeviceGroups = groups
+	}
 
-		pciDevices := devices.SelectByType((*types.VirtualPCIPassthrough)(nil))
-		vGPUDevices := pciDevices.SelectByBackingInfo((*types.VirtualPCIPassthroughVmiopBackingInfo)(nil))
-		var operation types.VirtualDeviceConfigSpecOperation
-		if len(vGPUDevices) > 1 {
+	if len(config.PCIPassthroughDevices) > 0 {
+		changes, params, err := vm.pciPassthroughChanges(config.PCIPassthroughDevices, config.PCIPassthroughMMIOSizeGB)
+		if err != nil {
 			return err
-		} else if len(pciDevices) == 1 {
-			operation = types.VirtualDeviceConfigSpecOperationEdit
-		} else if len(pciDevices) == 0 {
-			operation = types.VirtualDeviceConfigSpecOperationAdd
 		}
-
-		vGPUProfile := newVGPUProfile(config.VGPUProfile)
-		spec := &types.VirtualDeviceConfigSpec{
-			Device:    &vGPUProfile,
-			Operation: operation,
-		}
-		log.Printf("Adding vGPU device with profile '%s'", config.VGPUProfile)
-		confSpec.DeviceChange = append(confSpec.DeviceChange, spec)
+		confSpec.DeviceChange = append(confSpec.DeviceChange, changes...)
+		confSpec.ExtraConfig = append(confSpec.ExtraConfig, params...)
 	}
 
 	if len(config.AllowedDevices) > 0 {
@@ -674,6 +884,30 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 		confSpec.DeviceChange = append(confSpec.DeviceChange, spec)
 	}
 
+	if err := vm.checkHardwareCapabilities(config); err != nil {
+		return err
+	}
+
+	pmemChanges, err := vm.pmemDeviceChanges(config.PMem)
+	if err != nil {
+		return err
+	}
+	confSpec.DeviceChange = append(confSpec.DeviceChange, pmemChanges...)
+
+	serialPortChanges, err := vm.serialPortChanges(config.SerialPorts)
+	if err != nil {
+		return err
+	}
+	confSpec.DeviceChange = append(confSpec.DeviceChange, serialPortChanges...)
+
+	if config.SGXEpcSize > 0 {
+		confSpec.SgxInfo = &types.VirtualMachineSgxInfo{
+			EpcSize:      config.SGXEpcSize,
+			FlcMode:      config.SGXFlcMode,
+			LePubKeyHash: config.SGXLePubKeyHash,
+		}
+	}
+
 	efiSecureBootEnabled := false
 	firmware := config.Firmware
 
@@ -730,19 +964,146 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 		}
 	}
 
+	if config.WatchdogTimer {
+		watchdogs := devices.SelectByType((*types.VirtualWDT)(nil))
+		if len(watchdogs) == 0 {
+			err = vm.addDevice(&types.VirtualWDT{RunOnBoot: config.WatchdogTimerRunOnBoot})
+		} else {
+			watchdog := watchdogs[0].(*types.VirtualWDT)
+			watchdog.RunOnBoot = config.WatchdogTimerRunOnBoot
+			err = vm.vm.EditDevice(vm.driver.ctx, watchdog)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	return err
 }
 
+// The minimum virtual hardware version for the watchdog timer and SGX
+// devices, which are available with vSphere 7.0 and later.
+const minWatchdogSGXHardwareVersion = 17
+
+// checkHardwareCapabilities verifies that the virtual machine and its host
+// support the requested watchdog timer and SGX devices.
+func (vm *VirtualMachineDriver) checkHardwareCapabilities(config *HardwareConfig) error {
+	var features []string
+	if config.WatchdogTimer {
+		features = append(features, "the virtual watchdog timer")
+	}
+	if config.SGXEpcSize > 0 {
+		features = append(features, "virtual SGX")
+	}
+	if len(features) == 0 {
+		return nil
+	}
+
+	info, err := vm.Info("config.version", "config.firmware", "runtime.host")
+	if err != nil {
+		return err
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(info.Config.Version, "vmx-"))
+	if err != nil {
+		return fmt.Errorf("error parsing the virtual hardware version %q: %s", info.Config.Version, err)
+	}
+	if version < minWatchdogSGXHardwareVersion {
+		return fmt.Errorf("%s requires virtual hardware version %d or later, but the virtual machine uses version %d",
+			strings.Join(features, " and "), minWatchdogSGXHardwareVersion, version)
+	}
+
+	if config.SGXEpcSize == 0 {
+		return nil
+	}
+
+	firmware := config.Firmware
+	if firmware == "" {
+		firmware = info.Config.Firmware
+	}
+	if firmware != "efi" && firmware != "efi-secure" {
+		return fmt.Errorf("virtual SGX requires 'efi' or 'efi-secure' firmware")
+	}
+
+	if info.Runtime.Host == nil {
+		return fmt.Errorf("error finding the host of the virtual machine")
+	}
+	host, err := vm.driver.NewHost(info.Runtime.Host).Info("name", "hardware.sgxInfo")
+	if err != nil {
+		return err
+	}
+	if host.Hardware == nil || host.Hardware.SgxInfo == nil ||
+		host.Hardware.SgxInfo.SgxState != string(types.HostSgxInfoSgxStatesEnabled) {
+		return fmt.Errorf("virtual SGX requires SGX to be enabled on host %s", host.Name)
+	}
+
+	return nil
+}
+
 // Reconfigure modifies the configuration of an existing virtual machine based
 // on the provided configuration specification.
 func (vm *VirtualMachineDriver) Reconfigure(confSpec types.VirtualMachineConfigSpec) error {
-	task, err := vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+	_, err := vm.driver.runTask(vm.driver.ctx, "reconfigure virtual machine", func() (*object.Task, error) {
+		return vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+	})
+	return err
+}
+
+// UpgradeHardwareVersion upgrades the virtual hardware version of the powered
+// off virtual machine. The version must be supported by the host of the
+// virtual machine and cannot be lower than the current version. The virtual
+// machine is not changed if it already uses the version.
+func (vm *VirtualMachineDriver) UpgradeHardwareVersion(version uint) error {
+	info, err := vm.Info("config.version", "environmentBrowser", "runtime.host")
 	if err != nil {
 		return err
 	}
+	current, err := strconv.Atoi(strings.TrimPrefix(info.Config.Version, "vmx-"))
+	if err != nil {
+		return fmt.Errorf("error parsing the virtual hardware version %q: %s", info.Config.Version, err)
+	}
+	if uint(current) == version {
+		return nil
+	}
+	if uint(current) > version {
+		return fmt.Errorf("the virtual hardware version cannot be downgraded from %d to %d", current, version)
+	}
 
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
-	return err
+	browser := object.NewEnvironmentBrowser(vm.driver.client.Client, info.EnvironmentBrowser)
+	descriptors, err := browser.QueryConfigOptionDescriptor(vm.driver.ctx)
+	if err != nil {
+		return fmt.Errorf("error retrieving the virtual hardware versions supported by the host: %s", err)
+	}
+	key := fmt.Sprintf("vmx-%d", version)
+	var supported []string
+	for _, descriptor := range descriptors {
+		if descriptor.RunSupported != nil && !*descriptor.RunSupported {
+			continue
+		}
+		if info.Runtime.Host != nil && len(descriptor.Host) > 0 && !slices.Contains(descriptor.Host, *info.Runtime.Host) {
+			continue
+		}
+		if descriptor.Key == key {
+			_, err := vm.driver.runTask(vm.driver.ctx, "upgrade virtual hardware", func() (*object.Task, error) {
+				return vm.vm.UpgradeVM(vm.driver.ctx, key)
+			})
+			return err
+		}
+		supported = append(supported, descriptor.Key)
+	}
+	return fmt.Errorf("virtual hardware version %d is not supported by the host of the virtual machine; supported versions: %s",
+		version, strings.Join(supported, ", "))
+}
+
+// SetManagedBy sets the extension that manages the virtual machine, or clears
+// it if the extension key is empty.
+func (vm *VirtualMachineDriver) SetManagedBy(extensionKey string, managedType string) error {
+	info := &types.ManagedByInfo{}
+	if extensionKey != "" {
+		info.ExtensionKey = extensionKey
+		info.Type = managedType
+	}
+	return vm.Reconfigure(types.VirtualMachineConfigSpec{ManagedBy: info})
 }
 
 // Customize applies the given CustomizationSpec to the virtual machine.
@@ -754,10 +1115,10 @@ func (vm *VirtualMachineDriver) Customize(spec types.CustomizationSpec) error {
 	return task.Wait(vm.driver.ctx)
 }
 
-// ResizeDisk adjusts the size of the virtual disk to the specified diskSize in
-// KB. Returns a slice of configuration specifications to apply the change or
-// an error if the operation fails.
-// TODO: This method should be refactored to support resizing multiple disks.
+// ResizeDisk adjusts the size of the only virtual disk to the specified
+// diskSize in MiB. Returns a slice of configuration specifications to apply
+// the change or an error if the operation fails. The disks of a virtual
+// machine with more than one disk are resized by the DiskResizes of a clone.
 func (vm *VirtualMachineDriver) ResizeDisk(diskSize int64) ([]types.BaseVirtualDeviceConfigSpec, error) {
 	devices, err := vm.vm.Device(vm.driver.ctx)
 	if err != nil {
@@ -782,38 +1143,164 @@ func (vm *VirtualMachineDriver) ResizeDisk(diskSize int64) ([]types.BaseVirtualD
 
 // PowerOn starts the virtual machine and waits for the operation to complete.
 func (vm *VirtualMachineDriver) PowerOn() error {
-	task, err := vm.vm.PowerOn(vm.driver.ctx)
-	if err != nil {
-		return err
-	}
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err := vm.driver.runTask(vm.driver.ctx, "power on virtual machine", func() (*object.Task, error) {
+		return vm.vm.PowerOn(vm.driver.ctx)
+	})
 	return err
 }
 
+// The IP versions of the addresses selected by an IPFilter.
+const (
+	IPVersion4   = "4"
+	IPVersion6   = "6"
+	IPVersionAny = "any"
+)
+
+// IPFilter selects the IP addresses reported by VMware Tools that are used to
+// connect to the virtual machine.
+type IPFilter struct {
+	// The network range of the IP addresses. The addresses of the IP version
+	// are selected if nil.
+	Network *net.IPNet
+	// The IP version of the addresses if no network range is set:
+	// IPVersion4, IPVersion6, or IPVersionAny. Defaults to IPVersion4.
+	Version string
+	// The network ranges of the IP addresses that are never selected, such as
+	// the addresses of container bridges in the guest operating system.
+	Exclude []*net.IPNet
+	// The index of the network adapter of the virtual machine whose IP
+	// addresses are selected. The IP addresses of all network adapters are
+	// selected if nil.
+	NIC *int
+}
+
 // WaitForIP waits for the virtual machine to obtain an IP address.
-func (vm *VirtualMachineDriver) WaitForIP(ctx context.Context, ipNet *net.IPNet) (string, error) {
-	netIP, err := vm.vm.WaitForNetIP(ctx, false)
+func (vm *VirtualMachineDriver) WaitForIP(ctx context.Context, filter *IPFilter) (string, error) {
+	ips, err := vm.WaitForIPs(ctx, filter)
+	if err != nil || len(ips) == 0 {
+		// Unable to find an IP address.
+		return "", err
+	}
+	return ips[0], nil
+}
+
+// WaitForIPs waits for the virtual machine to obtain an IP address and returns
+// all IP addresses reported by VMware Tools that are selected by the filter.
+// Routable IPv4 addresses are returned first and link-local addresses last.
+func (vm *VirtualMachineDriver) WaitForIPs(ctx context.Context, filter *IPFilter) ([]string, error) {
+	if filter == nil {
+		filter = &IPFilter{}
+	}
+
+	var devices []string
+	if filter.NIC != nil {
+		mac, err := vm.networkAdapterMAC(*filter.NIC)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, mac)
+	}
+
+	netIP, err := vm.vm.WaitForNetIP(ctx, false, devices...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort the network adapters by MAC address, so that the IP addresses are
+	// returned in a stable order.
+	macs := make([]string, 0, len(netIP))
+	for mac := range netIP {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+
+	var ips []string
+	for _, mac := range macs {
+		ips = append(ips, netIP[mac]...)
+	}
+	return filterIPs(ips, filter), nil
+}
+
+// networkAdapterMAC returns the MAC address of the network adapter of the
+// virtual machine at the index.
+func (vm *VirtualMachineDriver) networkAdapterMAC(index int) (string, error) {
+	devices, err := vm.Devices()
 	if err != nil {
 		return "", err
 	}
+	adapters := devices.SelectByType((*types.VirtualEthernetCard)(nil))
+	if index < 0 || index >= len(adapters) {
+		return "", fmt.Errorf("network adapter %d not found: the virtual machine has %d network adapters", index, len(adapters))
+	}
+	card := adapters[index].(types.BaseVirtualEthernetCard).GetVirtualEthernetCard()
+	if card.MacAddress == "" {
+		return "", fmt.Errorf("network adapter %d has no MAC address", index)
+	}
+	return card.MacAddress, nil
+}
 
-	for _, ips := range netIP {
-		for _, ip := range ips {
-			parseIP := net.ParseIP(ip)
-			if ipNet != nil && !ipNet.Contains(parseIP) {
-				// IP address is not in the expected range.
-				continue
-			}
-			// Default to IPv4 if no IPNet is provided.
-			if ipNet == nil && parseIP.To4() == nil {
-				continue
-			}
-			return ip, nil
+// filterIPs returns the IP addresses that are selected by the filter, with the
+// routable IPv4 addresses first, then the other routable addresses, and the
+// link-local addresses last. The order is otherwise preserved.
+func filterIPs(ips []string, filter *IPFilter) []string {
+	var filtered []string
+	for _, ip := range ips {
+		parseIP := net.ParseIP(ip)
+		if parseIP == nil {
+			continue
+		}
+		if filter.Network != nil && !filter.Network.Contains(parseIP) {
+			// IP address is not in the expected range.
+			continue
+		}
+		if filter.Network == nil && !ipVersionMatches(parseIP, filter.Version) {
+			continue
+		}
+		if ipExcluded(parseIP, filter.Exclude) {
+			continue
+		}
+		filtered = append(filtered, ip)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return ipRank(filtered[i]) < ipRank(filtered[j])
+	})
+	return filtered
+}
+
+// ipVersionMatches reports whether the IP address is of the IP version,
+// defaulting to IPv4.
+func ipVersionMatches(ip net.IP, version string) bool {
+	switch version {
+	case IPVersionAny:
+		return true
+	case IPVersion6:
+		return ip.To4() == nil
+	default:
+		return ip.To4() != nil
+	}
+}
+
+func ipExcluded(ip net.IP, exclude []*net.IPNet) bool {
+	for _, network := range exclude {
+		if network.Contains(ip) {
+			return true
 		}
 	}
+	return false
+}
 
-	// Unable to find an IP address.
-	return "", nil
+// ipRank returns the preference of an IP address for connecting to the
+// virtual machine, lowest first.
+func ipRank(ip string) int {
+	parseIP := net.ParseIP(ip)
+	switch {
+	case parseIP.IsLinkLocalUnicast():
+		return 2
+	case parseIP.To4() == nil:
+		return 1
+	default:
+		return 0
+	}
 }
 
 // PowerOff stops the virtual machine and waits for the operation to complete.
@@ -878,7 +1365,49 @@ func (vm *VirtualMachineDriver) WaitForShutdown(ctx context.Context, timeout tim
 
 // CreateSnapshot creates a snapshot of the virtual machine.
 func (vm *VirtualMachineDriver) CreateSnapshot(name string) error {
-	task, err := vm.vm.CreateSnapshot(vm.driver.ctx, name, "", false, false)
+	return vm.CreateSnapshotWithOptions(name, "", false)
+}
+
+// CreateSnapshotWithOptions creates a snapshot of the virtual machine with
+// the description. If memory is true, the snapshot includes the memory of the
+// virtual machine when the virtual machine is powered on.
+func (vm *VirtualMachineDriver) CreateSnapshotWithOptions(name string, description string, memory bool) error {
+	task, err := vm.vm.CreateSnapshot(vm.driver.ctx, name, description, memory, false)
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	return err
+}
+
+// ListSnapshots returns the names of all snapshots in the snapshot tree of the
+// virtual machine, with each parent snapshot before its children.
+func (vm *VirtualMachineDriver) ListSnapshots() ([]string, error) {
+	info, err := vm.Info("snapshot")
+	if err != nil {
+		return nil, err
+	}
+	if info.Snapshot == nil {
+		return nil, nil
+	}
+
+	var names []string
+	var walk func(trees []types.VirtualMachineSnapshotTree)
+	walk = func(trees []types.VirtualMachineSnapshotTree) {
+		for _, tree := range trees {
+			names = append(names, tree.Name)
+			walk(tree.ChildSnapshotList)
+		}
+	}
+	walk(info.Snapshot.RootSnapshotList)
+	return names, nil
+}
+
+// RemoveAllSnapshots removes all snapshots of the virtual machine and
+// consolidates the disks.
+func (vm *VirtualMachineDriver) RemoveAllSnapshots() error {
+	consolidate := true
+	task, err := vm.vm.RemoveAllSnapshot(vm.driver.ctx, &consolidate)
 	if err != nil {
 		return err
 	}
@@ -886,8 +1415,68 @@ func (vm *VirtualMachineDriver) CreateSnapshot(name string) error {
 	return err
 }
 
+// RemoveSnapshot removes the snapshot of the virtual machine with the
+// specified name and consolidates the disks.
+func (vm *VirtualMachineDriver) RemoveSnapshot(name string) error {
+	consolidate := true
+	task, err := vm.vm.RemoveSnapshot(vm.driver.ctx, name, false, &consolidate)
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	return err
+}
+
+// CreateMemorySnapshot creates a snapshot of the virtual machine that
+// includes the memory of the virtual machine, such as for the analysis of a
+// crash of the guest operating system.
+func (vm *VirtualMachineDriver) CreateMemorySnapshot(name string) error {
+	return vm.CreateSnapshotWithOptions(name, "", true)
+}
+
+// The types of the files of a virtual machine that are used to analyze a
+// crash, which are the snapshot state and memory, the suspended state and
+// memory, the core dumps, and the logs.
+var diagnosticFileTypes = []string{
+	string(types.VirtualMachineFileLayoutExFileTypeSnapshotData),
+	string(types.VirtualMachineFileLayoutExFileTypeSnapshotMemory),
+	string(types.VirtualMachineFileLayoutExFileTypeSuspend),
+	string(types.VirtualMachineFileLayoutExFileTypeSuspendMemory),
+	string(types.VirtualMachineFileLayoutExFileTypeCore),
+	string(types.VirtualMachineFileLayoutExFileTypeLog),
+}
+
+// DiagnosticFiles returns the datastore paths of the files of the virtual
+// machine that are used to analyze a crash of the guest operating system.
+func (vm *VirtualMachineDriver) DiagnosticFiles() ([]string, error) {
+	info, err := vm.Info("layoutEx.file")
+	if err != nil {
+		return nil, err
+	}
+	if info.LayoutEx == nil {
+		return nil, nil
+	}
+
+	var files []string
+	for _, file := range info.LayoutEx.File {
+		if slices.Contains(diagnosticFileTypes, file.Type) {
+			files = append(files, file.Name)
+		}
+	}
+	return files, nil
+}
+
+// MissingPrivileges returns the privileges, of the specified privileges,
+// that the current session does not have on the virtual machine.
+func (vm *VirtualMachineDriver) MissingPrivileges(privileges ...string) ([]string, error) {
+	return vm.driver.missingPrivileges(vm.vm.Reference(), privileges...)
+}
+
 // ConvertToTemplate converts the virtual machine to a template.
 func (vm *VirtualMachineDriver) ConvertToTemplate() error {
+	if vm.driver.standaloneHost {
+		return errVCenterRequired("converting a virtual machine to a template")
+	}
 	return vm.vm.MarkAsTemplate(vm.driver.ctx)
 }
 
@@ -1061,9 +1650,13 @@ func (vm *VirtualMachineDriver) GetDir() (string, error) {
 
 // addNetwork adds a network to the virtual machine. Returns a list of devices
 // with the network added or an error if the  operation fails.
-func addNetwork(d *VCenterDriver, devices object.VirtualDeviceList, config *CreateConfig) (object.VirtualDeviceList, error) {
+func addNetwork(d *VCenterDriver, devices object.VirtualDeviceList, config *CreateConfig, target *types.ConfigTarget) (object.VirtualDeviceList, error) {
 	for _, nic := range config.NICs {
-		network, err := findNetwork(nic.Network, config.Host, d)
+		host := config.Host
+		if nic.NetworkHost != "" {
+			host = nic.NetworkHost
+		}
+		network, err := findNetwork(nic.Network, host, nic.NetworkSwitch, d)
 		if err != nil {
 			return nil, err
 		}
@@ -1073,7 +1666,12 @@ func addNetwork(d *VCenterDriver, devices object.VirtualDeviceList, config *Crea
 			return nil, err
 		}
 
-		device, err := object.EthernetCardTypes().CreateEthernetCard(nic.NetworkCard, backing)
+		var device types.BaseVirtualDevice
+		if nic.NetworkCard == NetworkCardSRIOV {
+			device, err = newSRIOVEthernetCard(target, nic.PhysicalFunction, backing)
+		} else {
+			device, err = object.EthernetCardTypes().CreateEthernetCard(nic.NetworkCard, backing)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -1084,20 +1682,49 @@ func addNetwork(d *VCenterDriver, devices object.VirtualDeviceList, config *Crea
 			card.MacAddress = nic.MacAddress
 		}
 		card.UptCompatibilityEnabled = nic.Passthrough
+		if nic.StartDisconnected {
+			card.Connectable = &types.VirtualDeviceConnectInfo{
+				StartConnected:    false,
+				Connected:         false,
+				AllowGuestControl: true,
+			}
+		}
 
 		devices = append(devices, device)
 	}
 	return devices, nil
 }
 
-// findNetwork finds a network based on the network name and host.
-func findNetwork(network string, host string, d *VCenterDriver) (object.NetworkReference, error) {
+// findNetwork finds a network based on the network name, host, and the
+// distributed virtual switch that owns the network.
+func findNetwork(network string, host string, networkSwitch string, d *VCenterDriver) (object.NetworkReference, error) {
 	if network != "" {
 		var err error
 		networks, err := d.FindNetworks(network)
 		if err != nil {
 			return nil, err
 		}
+
+		// If a distributed virtual switch is specified, only consider the
+		// networks owned by that switch.
+		if networkSwitch != "" {
+			var matched []*Network
+			for _, n := range networks {
+				name, err := n.SwitchName()
+				if err != nil {
+					log.Printf("[WARN] Unable to determine the switch for network %s: %s", network, err)
+					continue
+				}
+				if name == networkSwitch {
+					matched = append(matched, n)
+				}
+			}
+			if len(matched) == 0 {
+				return nil, fmt.Errorf("unable to match network %s to the distributed virtual switch %s", network, networkSwitch)
+			}
+			networks = matched
+		}
+
 		if len(networks) == 1 {
 			return networks[0].network, nil
 		}
@@ -1122,7 +1749,7 @@ func findNetwork(network string, host string, d *VCenterDriver) (object.NetworkR
 			return nil, &MultipleNetworkFoundError{network, fmt.Sprintf("unable to match a network to the host %s", host)}
 		}
 
-		return nil, &MultipleNetworkFoundError{network, "specify the inventory path or id of the network"}
+		return nil, &MultipleNetworkFoundError{network, "specify the inventory path or id of the network, or the 'network_switch'"}
 	}
 
 	if host != "" {
@@ -1310,6 +1937,60 @@ func (vm *VirtualMachineDriver) SetBootOrder(order []string) error {
 	return vm.vm.SetBootOptions(vm.driver.ctx, &bootOptions)
 }
 
+// BootOptions are the boot order and the firmware boot options of a virtual
+// machine.
+type BootOptions struct {
+	Order []string
+	// Enter the firmware setup on the next boot.
+	EnterSetup bool
+	// The delay before the firmware boots from the boot order.
+	Delay time.Duration
+	// The delay before the firmware retries the boot order if no bootable
+	// device is found. Boot retry is disabled if zero.
+	RetryDelay time.Duration
+}
+
+// SetBootOptions sets the boot order and the firmware boot options of the
+// virtual machine.
+func (vm *VirtualMachineDriver) SetBootOptions(options *BootOptions) error {
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return err
+	}
+
+	bootOptions := types.VirtualMachineBootOptions{
+		BootOrder:        devices.BootOrder(options.Order),
+		EnterBIOSSetup:   types.NewBool(options.EnterSetup),
+		BootDelay:        options.Delay.Milliseconds(),
+		BootRetryEnabled: types.NewBool(options.RetryDelay > 0),
+		BootRetryDelay:   options.RetryDelay.Milliseconds(),
+	}
+
+	return vm.vm.SetBootOptions(vm.driver.ctx, &bootOptions)
+}
+
+// EnterSetupPending reports whether the virtual machine enters the firmware
+// setup on the next boot. vSphere clears the flag once the firmware of the
+// virtual machine enters the setup.
+func (vm *VirtualMachineDriver) EnterSetupPending() (bool, error) {
+	info, err := vm.Info("config.bootOptions")
+	if err != nil {
+		return false, err
+	}
+	if info.Config == nil || info.Config.BootOptions == nil || info.Config.BootOptions.EnterBIOSSetup == nil {
+		return false, nil
+	}
+	return *info.Config.BootOptions.EnterBIOSSetup, nil
+}
+
+// Reset resets the virtual machine and waits for the operation to complete.
+func (vm *VirtualMachineDriver) Reset() error {
+	_, err := vm.driver.runTask(vm.driver.ctx, "reset virtual machine", func() (*object.Task, error) {
+		return vm.vm.Reset(vm.driver.ctx)
+	})
+	return err
+}
+
 // RemoveDevice removes a device from the virtual machine.
 func (vm *VirtualMachineDriver) RemoveDevice(keepFiles bool, device ...types.BaseVirtualDevice) error {
 	return vm.vm.RemoveDevice(vm.driver.ctx, keepFiles, device...)
@@ -1553,3 +2234,57 @@ func (vm *VirtualMachineDriver) RemoveNetworkAdapters() error {
 
 	return nil
 }
+
+// SetNetworkAdaptersConnected connects or disconnects the network adapters of
+// the virtual machine at the specified indices, in the order of the adapters
+// on the virtual machine. The adapters are also set to connect, or not, when
+// the virtual machine is powered on.
+func (vm *VirtualMachineDriver) SetNetworkAdaptersConnected(indices []int, connected bool) error {
+	devices, err := vm.Devices()
+	if err != nil {
+		return fmt.Errorf("error retrieving devices: %s", err)
+	}
+	networkAdapters := devices.SelectByType((*types.VirtualEthernetCard)(nil))
+
+	var deviceChange []types.BaseVirtualDeviceConfigSpec
+	for _, i := range indices {
+		if i < 0 || i >= len(networkAdapters) {
+			return fmt.Errorf("network adapter %d not found", i)
+		}
+		adapter := networkAdapters[i].GetVirtualDevice()
+		if adapter.Connectable == nil {
+			adapter.Connectable = &types.VirtualDeviceConnectInfo{AllowGuestControl: true}
+		}
+		adapter.Connectable.StartConnected = connected
+		adapter.Connectable.Connected = connected
+		deviceChange = append(deviceChange, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Device:    networkAdapters[i],
+		})
+	}
+	if len(deviceChange) == 0 {
+		return nil
+	}
+
+	task, err := vm.vm.Reconfigure(vm.driver.ctx, types.VirtualMachineConfigSpec{DeviceChange: deviceChange})
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	return err
+}
+
+// GuestNetworkAdapters returns the network adapters of the guest operating
+// system as reported by VMware Tools, such as the IP addresses of each
+// adapter. The device configuration identifier of an adapter is the key of
+// its virtual device.
+func (vm *VirtualMachineDriver) GuestNetworkAdapters() ([]types.GuestNicInfo, error) {
+	info, err := vm.Info("guest.net")
+	if err != nil {
+		return nil, err
+	}
+	if info.Guest == nil {
+		return nil, nil
+	}
+	return info.Guest.Net, nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// HostAffinityRule is a DRS virtual machine to host rule, and the virtual
+// machine and host groups of the rule, in a cluster.
+type HostAffinityRule struct {
+	Cluster types.ManagedObjectReference
+	Name    string
+}
+
+func (r *HostAffinityRule) vmGroup() string {
+	return r.Name + "-vms"
+}
+
+func (r *HostAffinityRule) hostGroup() string {
+	return r.Name + "-hosts"
+}
+
+// AddHostAffinityRule creates a DRS virtual machine to host rule in the
+// cluster of the virtual machine that runs the virtual machine on the hosts
+// with the names. If mandatory is true, the virtual machine must run on the
+// hosts, otherwise it should run on the hosts.
+func (vm *VirtualMachineDriver) AddHostAffinityRule(hosts []string, mandatory bool) (*HostAffinityRule, error) {
+	info, err := vm.Info("runtime.host")
+	if err != nil {
+		return nil, err
+	}
+	if info.Runtime.Host == nil {
+		return nil, fmt.Errorf("the virtual machine is not on a host")
+	}
+	hostInfo, err := vm.driver.NewHost(info.Runtime.Host).Info("parent")
+	if err != nil {
+		return nil, err
+	}
+	if hostInfo.Parent == nil || hostInfo.Parent.Type != "ClusterComputeResource" {
+		return nil, fmt.Errorf("the virtual machine is not in a cluster")
+	}
+	cluster := object.NewClusterComputeResource(vm.driver.client.Client, *hostInfo.Parent)
+
+	var refs []types.ManagedObjectReference
+	for _, name := range hosts {
+		h, err := vm.driver.FindHost(name)
+		if err != nil {
+			return nil, fmt.Errorf("error finding host %s: %s", name, err)
+		}
+		i, err := h.Info("parent")
+		if err != nil {
+			return nil, err
+		}
+		if i.Parent == nil || *i.Parent != cluster.Reference() {
+			return nil, fmt.Errorf("host %s is not in the cluster of the virtual machine", name)
+		}
+		refs = append(refs, h.host.Reference())
+	}
+
+	// The name of the rule is unique to the virtual machine, so that the
+	// rules of concurrent builds do not conflict.
+	rule := &HostAffinityRule{
+		Cluster: cluster.Reference(),
+		Name:    fmt.Sprintf("packer-%s", vm.vm.Reference().Value),
+	}
+	spec := &types.ClusterConfigSpecEx{
+		GroupSpec: []types.ClusterGroupSpec{
+			{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info: &types.ClusterVmGroup{
+					ClusterGroupInfo: types.ClusterGroupInfo{Name: rule.vmGroup()},
+					Vm:               []types.ManagedObjectReference{vm.vm.Reference()},
+				},
+			},
+			{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info: &types.ClusterHostGroup{
+					ClusterGroupInfo: types.ClusterGroupInfo{Name: rule.hostGroup()},
+					Host:             refs,
+				},
+			},
+		},
+		RulesSpec: []types.ClusterRuleSpec{
+			{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info: &types.ClusterVmHostRuleInfo{
+					ClusterRuleInfo: types.ClusterRuleInfo{
+						Name:      rule.Name,
+						Enabled:   types.NewBool(true),
+						Mandatory: types.NewBool(mandatory),
+					},
+					VmGroupName:         rule.vmGroup(),
+					AffineHostGroupName: rule.hostGroup(),
+				},
+			},
+		},
+	}
+
+	task, err := cluster.Reconfigure(vm.driver.ctx, spec, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := task.WaitForResult(vm.driver.ctx, nil); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// RemoveHostAffinityRule removes the DRS virtual machine to host rule and the
+// groups of the rule from the cluster. The groups and the rule that no longer
+// exist are ignored.
+func (vm *VirtualMachineDriver) RemoveHostAffinityRule(rule *HostAffinityRule) error {
+	cluster := object.NewClusterComputeResource(vm.driver.client.Client, rule.Cluster)
+
+	var info mo.ClusterComputeResource
+	if err := cluster.Properties(vm.driver.ctx, cluster.Reference(), []string{"configurationEx"}, &info); err != nil {
+		return err
+	}
+	config, ok := info.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok {
+		return fmt.Errorf("error getting the configuration of the cluster")
+	}
+
+	spec := &types.ClusterConfigSpecEx{}
+	for _, r := range config.Rule {
+		if r.GetClusterRuleInfo().Name == rule.Name {
+			spec.RulesSpec = append(spec.RulesSpec, types.ClusterRuleSpec{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{
+					Operation: types.ArrayUpdateOperationRemove,
+					RemoveKey: r.GetClusterRuleInfo().Key,
+				},
+			})
+		}
+	}
+	groups := []string{rule.vmGroup(), rule.hostGroup()}
+	for _, g := range config.Group {
+		if name := g.GetClusterGroupInfo().Name; slices.Contains(groups, name) {
+			spec.GroupSpec = append(spec.GroupSpec, types.ClusterGroupSpec{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{
+					Operation: types.ArrayUpdateOperationRemove,
+					RemoveKey: name,
+				},
+			})
+		}
+	}
+	if len(spec.RulesSpec) == 0 && len(spec.GroupSpec) == 0 {
+		return nil
+	}
+
+	task, err := cluster.Reconfigure(vm.driver.ctx, spec, true)
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	return err
+}
@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func clusterConfig(t *testing.T, sim *VCenterSimulator, ref types.ManagedObjectReference) *types.ClusterConfigInfoEx {
+	cluster := object.NewClusterComputeResource(sim.driver.client.Client, ref)
+	var info mo.ClusterComputeResource
+	if err := cluster.Properties(sim.driver.ctx, ref, []string{"configurationEx"}, &info); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return info.ConfigurationEx.(*types.ClusterConfigInfoEx)
+}
+
+func TestVirtualMachineDriver_HostAffinityRule(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, err := sim.driver.FindVM("DC0_C0_RP0_VM0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	rule, err := vm.AddHostAffinityRule([]string{"DC0_C0_H0", "DC0_C0_H1"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	config := clusterConfig(t, sim, rule.Cluster)
+	if len(config.Rule) != 1 || len(config.Group) != 2 {
+		t.Fatalf("unexpected result: expected 1 rule and 2 groups, but returned %d rules and %d groups", len(config.Rule), len(config.Group))
+	}
+	info, ok := config.Rule[0].(*types.ClusterVmHostRuleInfo)
+	if !ok {
+		t.Fatalf("unexpected result: expected a virtual machine to host rule, but returned '%T'", config.Rule[0])
+	}
+	if info.Name != rule.Name || info.Mandatory == nil || !*info.Mandatory {
+		t.Fatalf("unexpected result: expected mandatory rule '%s', but returned '%#v'", rule.Name, info)
+	}
+	for _, g := range config.Group {
+		if hosts, ok := g.(*types.ClusterHostGroup); ok && len(hosts.Host) != 2 {
+			t.Fatalf("unexpected result: expected 2 hosts in the host group, but returned %d", len(hosts.Host))
+		}
+	}
+
+	if err := vm.RemoveHostAffinityRule(rule); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	config = clusterConfig(t, sim, rule.Cluster)
+	if len(config.Rule) != 0 || len(config.Group) != 0 {
+		t.Fatalf("unexpected result: expected no rules and groups, but returned %d rules and %d groups", len(config.Rule), len(config.Group))
+	}
+
+	// The rule is removed only once.
+	if err := vm.RemoveHostAffinityRule(rule); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestVirtualMachineDriver_HostAffinityRuleError(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, err := sim.driver.FindVM("DC0_C0_RP0_VM0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := vm.AddHostAffinityRule([]string{"DC0_H0"}, true); err == nil {
+		t.Fatal("unexpected success: expected an error for a host outside the cluster")
+	}
+
+	standalone, err := sim.driver.FindVM("DC0_H0_VM0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := standalone.AddHostAffinityRule([]string{"DC0_C0_H0"}, true); err == nil {
+		t.Fatal("unexpected success: expected an error for a virtual machine outside a cluster")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"time"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// BootEvents returns the number of power-on, reset, and guest reboot events
+// of the virtual machine with a key greater than afterKey, and the key of the
+// most recent of these events.
+func (vm *VirtualMachineDriver) BootEvents(afterKey int32) (int, int32, error) {
+	m := event.NewManager(vm.driver.vimClient)
+	events, err := m.QueryEvents(vm.driver.ctx, types.EventFilterSpec{
+		Entity: &types.EventFilterSpecByEntity{
+			Entity:    vm.vm.Reference(),
+			Recursion: types.EventFilterSpecRecursionOptionSelf,
+		},
+		EventTypeId: []string{"VmPoweredOnEvent", "VmResettingEvent", "VmGuestRebootEvent"},
+	})
+	if err != nil {
+		return 0, afterKey, err
+	}
+
+	count := 0
+	latestKey := afterKey
+	for _, e := range events {
+		key := e.GetEvent().Key
+		if key <= afterKey {
+			continue
+		}
+		count++
+		if key > latestKey {
+			latestKey = key
+		}
+	}
+	return count, latestKey, nil
+}
+
+// BootState is the state of a virtual machine that changes when the virtual
+// machine or its guest operating system boots.
+type BootState struct {
+	// The time of the most recent power on of the virtual machine, if any.
+	BootTime *time.Time
+	// Whether VMware Tools is running in the guest operating system.
+	ToolsRunning bool
+}
+
+// BootState returns the power on time of the virtual machine and the running
+// status of VMware Tools in the guest operating system.
+func (vm *VirtualMachineDriver) BootState() (*BootState, error) {
+	info, err := vm.Info("runtime.bootTime", "guest.toolsRunningStatus")
+	if err != nil {
+		return nil, err
+	}
+	state := &BootState{BootTime: info.Runtime.BootTime}
+	if info.Guest != nil {
+		state.ToolsRunning = info.Guest.ToolsRunningStatus == string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)
+	}
+	return state, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVirtualMachineDriver_BootEvents(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	vmDriver := vm.(*VirtualMachineDriver)
+
+	_, key, err := vmDriver.BootEvents(0)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.PowerOn(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	count, latestKey, err := vmDriver.BootEvents(key)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if count != 1 {
+		t.Fatalf("unexpected result: expected '1', but returned '%d'", count)
+	}
+	if latestKey <= key {
+		t.Fatalf("unexpected result: expected a key greater than '%d', but returned '%d'", key, latestKey)
+	}
+
+	count, _, err = vmDriver.BootEvents(latestKey)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if count != 0 {
+		t.Fatalf("unexpected result: expected '0', but returned '%d'", count)
+	}
+}
+
+func TestVirtualMachineDriver_BootState(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	vmDriver := vm.(*VirtualMachineDriver)
+
+	bootTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	machine.Runtime.BootTime = &bootTime
+	machine.Guest.ToolsRunningStatus = string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)
+
+	state, err := vmDriver.BootState()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if state.BootTime == nil || !state.BootTime.Equal(bootTime) {
+		t.Fatalf("unexpected result: expected boot time '%s', but returned '%v'", bootTime, state.BootTime)
+	}
+	if !state.ToolsRunning {
+		t.Fatal("unexpected result: expected VMware Tools to be running")
+	}
+
+	machine.Guest.ToolsRunningStatus = string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning)
+	state, err = vmDriver.BootState()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if state.ToolsRunning {
+		t.Fatal("unexpected result: expected VMware Tools not to be running")
+	}
+}
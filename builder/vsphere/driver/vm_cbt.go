@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// EnableChangeTracking enables changed block tracking (CBT) for the disks of
+// the virtual machine. The change identifiers of the disks are assigned when
+// the next snapshot is created.
+func (vm *VirtualMachineDriver) EnableChangeTracking() error {
+	return vm.Reconfigure(types.VirtualMachineConfigSpec{
+		ChangeTrackingEnabled: types.NewBool(true),
+	})
+}
+
+// ChangedDisks reports, for each disk of the powered off virtual machine in
+// device order, whether the disk changed since the snapshot with the specified
+// name was created. Disks that were added or resized since the snapshot are
+// reported as changed.
+func (vm *VirtualMachineDriver) ChangedDisks(snapshotName string) ([]bool, error) {
+	info, err := vm.Info("config.changeTrackingEnabled", "config.hardware.device")
+	if err != nil {
+		return nil, err
+	}
+	if info.Config.ChangeTrackingEnabled == nil || !*info.Config.ChangeTrackingEnabled {
+		return nil, fmt.Errorf("changed block tracking is not enabled for the virtual machine")
+	}
+
+	snapshot, err := vm.vm.FindSnapshot(vm.driver.ctx, snapshotName)
+	if err != nil {
+		return nil, fmt.Errorf("error finding snapshot %s: %s", snapshotName, err)
+	}
+
+	var base mo.VirtualMachineSnapshot
+	if err := vm.vm.Properties(vm.driver.ctx, snapshot.Reference(), []string{"config.hardware"}, &base); err != nil {
+		return nil, fmt.Errorf("error retrieving the configuration of snapshot %s: %s", snapshotName, err)
+	}
+	baseCapacity := make(map[int32]int64)
+	for _, device := range base.Config.Hardware.Device {
+		if disk, ok := device.(*types.VirtualDisk); ok {
+			baseCapacity[disk.Key] = disk.CapacityInBytes
+		}
+	}
+
+	var changed []bool
+	for _, device := range info.Config.Hardware.Device {
+		disk, ok := device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+		if capacity, ok := baseCapacity[disk.Key]; !ok || capacity != disk.CapacityInBytes {
+			changed = append(changed, true)
+			continue
+		}
+		c, err := diskChanged(disk.CapacityInBytes, func(offset int64) (types.DiskChangeInfo, error) {
+			return vm.vm.QueryChangedDiskAreas(vm.driver.ctx, snapshot, nil, disk, offset)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error querying the changed areas of disk %d: %s", disk.Key, err)
+		}
+		changed = append(changed, c)
+	}
+	return changed, nil
+}
+
+// diskChanged queries the changed areas of a disk of the specified capacity
+// from the start of the disk until a changed area is found. The changed areas
+// are returned in chunks, each covering the returned length of the disk.
+func diskChanged(capacity int64, query func(offset int64) (types.DiskChangeInfo, error)) (bool, error) {
+	for offset := int64(0); offset < capacity; {
+		info, err := query(offset)
+		if err != nil {
+			return false, err
+		}
+		if len(info.ChangedArea) > 0 {
+			return true, nil
+		}
+		if info.Length <= 0 {
+			break
+		}
+		offset = info.StartOffset + info.Length
+	}
+	return false, nil
+}
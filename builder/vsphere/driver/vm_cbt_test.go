@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVirtualMachineDriver_ChangedDisks(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.CreateSnapshot("base"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// Changed block tracking must be enabled before the base snapshot.
+	if _, err := vm.(*VirtualMachineDriver).ChangedDisks("base"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	if err := vm.(*VirtualMachineDriver).EnableChangeTracking(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := vm.(*VirtualMachineDriver).ChangedDisks("missing"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	info, err := vm.Info("config.changeTrackingEnabled")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Config.ChangeTrackingEnabled == nil || !*info.Config.ChangeTrackingEnabled {
+		t.Fatal("unexpected result: expected changed block tracking to be enabled")
+	}
+}
+
+func TestDiskChanged(t *testing.T) {
+	tc := []struct {
+		name     string
+		areas    []types.DiskChangeInfo
+		expected bool
+	}{
+		{
+			name: "unchanged",
+			areas: []types.DiskChangeInfo{
+				{StartOffset: 0, Length: 512},
+				{StartOffset: 512, Length: 512},
+			},
+			expected: false,
+		},
+		{
+			name: "changed after the first chunk",
+			areas: []types.DiskChangeInfo{
+				{StartOffset: 0, Length: 512},
+				{StartOffset: 512, Length: 512, ChangedArea: []types.DiskChangeExtent{{Start: 768, Length: 64}}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var offsets []int64
+			changed, err := diskChanged(1024, func(offset int64) (types.DiskChangeInfo, error) {
+				offsets = append(offsets, offset)
+				return c.areas[len(offsets)-1], nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if changed != c.expected {
+				t.Fatalf("unexpected result: expected '%t', but returned '%t'", c.expected, changed)
+			}
+			if diff := cmp.Diff([]int64{0, 512}, offsets); diff != "" {
+				t.Fatalf("unexpected offsets: '%s'", diff)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineDriver_RemoveSnapshot(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.CreateSnapshot("packer-cbt"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.RemoveSnapshot("packer-cbt"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.RemoveSnapshot("packer-cbt"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
@@ -6,7 +6,9 @@ package driver
 import (
 	"errors"
 	"fmt"
+	"log"
 
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -127,3 +129,98 @@ func (vm *VirtualMachineDriver) EjectCdroms() error {
 
 	return nil
 }
+
+// CdromPlacement is the controller and unit number of a CD-ROM device.
+type CdromPlacement struct {
+	// The type of the controller, either `ide` or `sata`.
+	ControllerType string
+	// The bus number of the controller.
+	ControllerIndex int32
+	// The unit number of the device on the controller.
+	UnitNumber int32
+}
+
+// AddCdromAt adds a CD-ROM device to the virtual machine at the controller
+// and unit number of the placement, and mounts the ISO file if the path is
+// not empty. A SATA controller with the bus number is added if it does not
+// exist. Returns an error if the unit number is in use.
+func (vm *VirtualMachineDriver) AddCdromAt(placement *CdromPlacement, datastoreIsoPath string) error {
+	devices, err := vm.Devices()
+	if err != nil {
+		return err
+	}
+
+	controller := findCdromController(devices, placement.ControllerType, placement.ControllerIndex)
+	if controller == nil && placement.ControllerType == "sata" {
+		sata := &types.VirtualAHCIController{}
+		sata.BusNumber = placement.ControllerIndex
+		if err := vm.addDevice(sata); err != nil {
+			return fmt.Errorf("error adding SATA controller %d: %s", placement.ControllerIndex, err)
+		}
+		if devices, err = vm.Devices(); err != nil {
+			return err
+		}
+		controller = findCdromController(devices, placement.ControllerType, placement.ControllerIndex)
+	}
+	if controller == nil {
+		return fmt.Errorf("no %s controller %d found", placement.ControllerType, placement.ControllerIndex)
+	}
+
+	for _, device := range devices {
+		d := device.GetVirtualDevice()
+		if d.ControllerKey == controller.Key && d.UnitNumber != nil && *d.UnitNumber == placement.UnitNumber {
+			return fmt.Errorf("unit %d of %s controller %d is in use", placement.UnitNumber, placement.ControllerType, placement.ControllerIndex)
+		}
+	}
+
+	cdrom, err := vm.CreateCdrom(controller)
+	if err != nil {
+		return err
+	}
+	unit := placement.UnitNumber
+	cdrom.UnitNumber = &unit
+
+	if datastoreIsoPath == "" {
+		cdrom.Backing = &types.VirtualCdromRemotePassthroughBackingInfo{}
+		cdrom.Connectable = &types.VirtualDeviceConnectInfo{}
+	} else if err := vm.MountCdrom(placement.ControllerType, datastoreIsoPath, cdrom); err != nil {
+		return err
+	}
+
+	log.Printf("Creating CD-ROM on %s controller %d unit %d with iso '%v'", placement.ControllerType, placement.ControllerIndex, placement.UnitNumber, datastoreIsoPath)
+	return vm.addDevice(cdrom)
+}
+
+// findCdromController returns the IDE or SATA controller with the bus number,
+// or nil if the virtual machine has no such controller.
+func findCdromController(devices object.VirtualDeviceList, controllerType string, index int32) *types.VirtualController {
+	for _, device := range devices {
+		switch c := device.(type) {
+		case *types.VirtualIDEController:
+			if controllerType == "ide" && c.BusNumber == index {
+				return c.GetVirtualController()
+			}
+		case types.BaseVirtualSATAController:
+			if controllerType == "sata" && c.GetVirtualSATAController().BusNumber == index {
+				return c.GetVirtualSATAController().GetVirtualController()
+			}
+		}
+	}
+	return nil
+}
+
+// CdromPlacementOf returns the placement of the CD-ROM device, or nil if the
+// device is not attached to an IDE or SATA controller.
+func CdromPlacementOf(devices object.VirtualDeviceList, cdrom types.BaseVirtualDevice) *CdromPlacement {
+	d := cdrom.GetVirtualDevice()
+	if d.UnitNumber == nil {
+		return nil
+	}
+	switch c := devices.FindByKey(d.ControllerKey).(type) {
+	case *types.VirtualIDEController:
+		return &CdromPlacement{ControllerType: "ide", ControllerIndex: c.BusNumber, UnitNumber: *d.UnitNumber}
+	case types.BaseVirtualSATAController:
+		return &CdromPlacement{ControllerType: "sata", ControllerIndex: c.GetVirtualSATAController().BusNumber, UnitNumber: *d.UnitNumber}
+	}
+	return nil
+}
@@ -164,3 +164,52 @@ func TestVirtualMachineDriver_EjectCdrom(t *testing.T) {
 		t.Fatalf("unexpected result: '%s'", diff)
 	}
 }
+
+func TestVirtualMachineDriver_AddCdromAt(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	placements := []*CdromPlacement{
+		{ControllerType: "ide", ControllerIndex: 1, UnitNumber: 1},
+		{ControllerType: "sata", ControllerIndex: 2, UnitNumber: 5},
+	}
+	for _, p := range placements {
+		if err := vm.AddCdromAt(p, ""); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var actual []*CdromPlacement
+	for _, cdrom := range devices.SelectByType((*types.VirtualCdrom)(nil)) {
+		actual = append(actual, CdromPlacementOf(devices, cdrom))
+	}
+	for _, p := range placements {
+		found := false
+		for _, a := range actual {
+			if cmp.Equal(a, p) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("unexpected result: expected '%v' in '%v'", p, actual)
+		}
+	}
+
+	// The unit number is in use.
+	if err := vm.AddCdromAt(placements[1], ""); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	// IDE controllers are not added.
+	if err := vm.AddCdromAt(&CdromPlacement{ControllerType: "ide", ControllerIndex: 2}, ""); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
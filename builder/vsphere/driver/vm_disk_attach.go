@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The modes of attaching an existing virtual disk file to a new virtual
+// machine.
+const (
+	// The file is copied to the directory of the virtual machine and the
+	// copy is attached.
+	AttachDiskModeCopy = "copy"
+	// A delta disk in the directory of the virtual machine is attached, which
+	// is backed by the file and records the changes to it.
+	AttachDiskModeLink = "link"
+)
+
+// attachDisks attaches the existing virtual disk files at the datastore paths
+// to the first disk controller of the virtual machine. The files are not
+// modified.
+func (vm *VirtualMachineDriver) attachDisks(paths []string, mode string) error {
+	info, err := vm.Info("config.files.vmPathName")
+	if err != nil {
+		return err
+	}
+	var vmxPath object.DatastorePath
+	if !vmxPath.FromString(info.Config.Files.VmPathName) {
+		return fmt.Errorf("error parsing the path of the virtual machine %q", info.Config.Files.VmPathName)
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		return err
+	}
+	var controller types.BaseVirtualController
+	for _, device := range devices {
+		switch c := device.(type) {
+		case types.BaseVirtualSCSIController, *types.VirtualAHCIController, *types.VirtualNVMEController:
+			controller = c.(types.BaseVirtualController)
+		}
+		if controller != nil {
+			break
+		}
+	}
+	if controller == nil {
+		return fmt.Errorf("error attaching disks: the virtual machine has no disk controller")
+	}
+
+	var disks object.VirtualDeviceList
+	for _, p := range paths {
+		backing := &types.VirtualDiskFlatVer2BackingInfo{
+			DiskMode: string(types.VirtualDiskModePersistent),
+		}
+		switch mode {
+		case AttachDiskModeLink:
+			backing.FileName = fmt.Sprintf("[%s]", vmxPath.Datastore)
+			backing.Parent = &types.VirtualDiskFlatVer2BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+					FileName: p,
+				},
+				DiskMode: string(types.VirtualDiskModePersistent),
+			}
+		default:
+			dst, err := vm.copyDisk(p, vmxPath)
+			if err != nil {
+				return err
+			}
+			backing.FileName = dst
+		}
+
+		// A disk without a capacity is attached rather than created, and a
+		// delta disk is created for its parent.
+		disk := &types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Key:     devices.NewKey(),
+				Backing: backing,
+			},
+		}
+		devices.AssignController(disk, controller)
+		if !hasUnitNumber(controller, *disk.UnitNumber) {
+			return fmt.Errorf("error attaching disk %s: disk controller %s has no free unit number", p, devices.Name(controller.(types.BaseVirtualDevice)))
+		}
+		devices = append(devices, disk)
+		disks = append(disks, disk)
+	}
+
+	changes, err := disks.ConfigSpec(types.VirtualDeviceConfigSpecOperationAdd)
+	if err != nil {
+		return err
+	}
+	if err := vm.Reconfigure(types.VirtualMachineConfigSpec{DeviceChange: changes}); err != nil {
+		return fmt.Errorf("error attaching disks: %s", err)
+	}
+	return nil
+}
+
+// copyDisk copies the virtual disk file at the datastore path to the
+// directory of the virtual machine and returns the path of the copy.
+func (vm *VirtualMachineDriver) copyDisk(src string, vmxPath object.DatastorePath) (string, error) {
+	var srcPath object.DatastorePath
+	if !srcPath.FromString(src) {
+		return "", fmt.Errorf("error parsing the datastore path %q", src)
+	}
+	dst := object.DatastorePath{
+		Datastore: vmxPath.Datastore,
+		Path:      path.Join(path.Dir(vmxPath.Path), path.Base(srcPath.Path)),
+	}
+
+	m := object.NewVirtualDiskManager(vm.driver.client.Client)
+	dc := vm.driver.datacenter
+	_, err := vm.driver.runTaskOnce(vm.driver.ctx, "copy virtual disk", func() (*object.Task, error) {
+		return m.CopyVirtualDisk(vm.driver.ctx, src, dc, dst.String(), dc, nil, false)
+	})
+	if err != nil {
+		return "", fmt.Errorf("error copying disk %s: %s", src, err)
+	}
+	return dst.String(), nil
+}
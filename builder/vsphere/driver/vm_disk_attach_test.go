@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVCenterDriver_CreateVMWithAttachedDisks(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, datastore := sim.ChooseSimulatorPreCreatedDatastore()
+	base := fmt.Sprintf("[%s] images/base.vmdk", datastore.Name)
+	ds, err := sim.driver.FindDatastore(datastore.Name, "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := ds.MakeDirectory(fmt.Sprintf("[%s] images", datastore.Name)); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	m := object.NewVirtualDiskManager(sim.driver.client.Client)
+	task, err := m.CreateVirtualDisk(sim.driver.ctx, base, sim.driver.datacenter, &types.FileBackedVirtualDiskSpec{
+		VirtualDiskSpec: types.VirtualDiskSpec{
+			DiskType:    string(types.VirtualDiskTypeThin),
+			AdapterType: string(types.VirtualDiskAdapterTypeLsiLogic),
+		},
+		CapacityKb: 1024,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := task.Wait(sim.driver.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	for _, mode := range []string{AttachDiskModeCopy, AttachDiskModeLink} {
+		t.Run(mode, func(t *testing.T) {
+			vm, err := sim.driver.CreateVM(&CreateConfig{
+				Name:      "attach-" + mode,
+				Host:      "DC0_H0",
+				Datastore: datastore.Name,
+				StorageConfig: StorageConfig{
+					DiskControllerType: []string{"pvscsi"},
+					Storage: []Disk{
+						{DiskSize: 3072, DiskThinProvisioned: true},
+					},
+				},
+				AttachDisks:    []string{base},
+				AttachDiskMode: mode,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			devices, err := vm.Devices()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			disks := devices.SelectByType((*types.VirtualDisk)(nil))
+			if len(disks) != 2 {
+				t.Fatalf("unexpected result: expected '2' disks, but returned %d", len(disks))
+			}
+			backing := disks[1].GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if backing.FileName == base {
+				t.Errorf("unexpected result: the disk %s is attached directly", base)
+			}
+			if mode == AttachDiskModeLink && (backing.Parent == nil || backing.Parent.FileName != base) {
+				t.Errorf("unexpected result: expected a delta disk backed by %s", base)
+			}
+		})
+	}
+
+	_, err = sim.driver.CreateVM(&CreateConfig{
+		Name:      "attach-missing",
+		Host:      "DC0_H0",
+		Datastore: datastore.Name,
+		StorageConfig: StorageConfig{
+			DiskControllerType: []string{"pvscsi"},
+		},
+		AttachDisks:    []string{fmt.Sprintf("[%s] images/missing.vmdk", datastore.Name)},
+		AttachDiskMode: AttachDiskModeCopy,
+	})
+	if err == nil {
+		t.Fatal("unexpected success: expected the disk to be missing")
+	}
+	if _, err := sim.driver.FindVM("attach-missing"); err == nil {
+		t.Error("unexpected result: expected the virtual machine to be destroyed")
+	}
+}
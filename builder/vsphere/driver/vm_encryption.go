@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/vmware/govmomi/crypto"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// EncryptionSpec is the key provider and the VM encryption storage policy
+// with which the virtual machine home and disks are encrypted.
+type EncryptionSpec struct {
+	KeyProvider     string
+	StoragePolicyID string
+}
+
+// CheckEncryption verifies that the key provider exists, or that a default
+// key provider is configured if it is empty, and that the host, or the hosts
+// of the compute resource of the resource pool if no host is specified,
+// support encryption. The identifier of the key provider is returned, or an
+// empty string if the default key provider cannot be retrieved.
+func (d *VCenterDriver) CheckEncryption(keyProvider string, cluster string, host string, resourcePool string) (string, error) {
+	if d.standaloneHost {
+		return "", errVCenterRequired("virtual machine encryption")
+	}
+	m, err := crypto.GetManagerKmip(d.vimClient)
+	if err != nil {
+		return "", err
+	}
+
+	if keyProvider == "" {
+		// The default key provider cannot be retrieved without the
+		// Cryptographer.ManageKeyServers privilege. The key is then generated
+		// by the default key provider of the target, which is not known until
+		// the virtual machine is encrypted.
+		provider, err := d.DefaultKeyProvider(cluster, host)
+		if err != nil {
+			log.Printf("[WARN] Unable to retrieve the default key provider: %s", err)
+		} else if provider == "" {
+			return "", fmt.Errorf("no default key provider is configured on vCenter Server; " +
+				"add a key provider, such as a native key provider, and set it as the default or set 'key_provider'")
+		}
+		keyProvider = provider
+	} else {
+		valid, err := m.IsValidProvider(d.ctx, keyProvider)
+		if err != nil {
+			return "", err
+		}
+		if !valid {
+			return "", fmt.Errorf("key provider %s not found", keyProvider)
+		}
+	}
+
+	var hosts []*object.HostSystem
+	if host != "" {
+		h, err := d.FindHost(host)
+		if err != nil {
+			return "", err
+		}
+		hosts = append(hosts, h.host)
+	} else {
+		// The virtual machine can be placed on any host of the compute
+		// resource of the resource pool.
+		pool, err := d.FindResourcePool(cluster, host, resourcePool)
+		if err != nil {
+			return "", err
+		}
+		owner, err := pool.pool.Owner(d.ctx)
+		if err != nil {
+			return "", err
+		}
+		compute := object.NewComputeResource(d.client.Client, owner.Reference())
+		if hosts, err = compute.Hosts(d.ctx); err != nil {
+			return "", err
+		}
+	}
+	if len(hosts) == 0 {
+		return "", fmt.Errorf("no hosts found to check for virtual machine encryption support")
+	}
+	for _, h := range hosts {
+		info, err := d.NewHost(types.NewReference(h.Reference())).Info("name", "capability", "runtime.cryptoState")
+		if err != nil {
+			return "", err
+		}
+		if info.Capability == nil || info.Capability.CryptoSupported == nil || !*info.Capability.CryptoSupported {
+			return "", fmt.Errorf("host %s does not support virtual machine encryption", info.Name)
+		}
+		switch info.Runtime.CryptoState {
+		case string(types.HostCryptoStateIncapable), string(types.HostCryptoStatePendingIncapable):
+			return "", fmt.Errorf("host %s is not capable of virtual machine encryption: the crypto state of the host is %s", info.Name, info.Runtime.CryptoState)
+		}
+	}
+	return keyProvider, nil
+}
+
+// RemoveEncryptionKey removes the key from the key cache of vCenter Server,
+// unless the key is in use. The keys of a native key provider are derived on
+// the hosts and are not removed.
+func (d *VCenterDriver) RemoveEncryptionKey(keyProvider string, keyID string) error {
+	m, err := crypto.GetManagerKmip(d.vimClient)
+	if err != nil {
+		return err
+	}
+	native, err := m.IsNativeProvider(d.ctx, keyProvider)
+	if err != nil {
+		return err
+	}
+	if native {
+		return nil
+	}
+	return m.RemoveKeys(d.ctx, []types.CryptoKeyId{{
+		KeyId:      keyID,
+		ProviderId: &types.KeyProviderId{Id: keyProvider},
+	}}, false)
+}
+
+// Encrypt encrypts the home and the disks of the powered off virtual machine
+// with a new key of the key provider, and applies the VM encryption storage
+// policy. The virtual machine must not have snapshots. The identifier of the
+// key is returned. If the key provider of the specification is empty, the key
+// is generated by the default key provider, whose identifier is set in the
+// specification.
+func (vm *VirtualMachineDriver) Encrypt(spec *EncryptionSpec) (string, error) {
+	info, err := vm.Info("config.keyId")
+	if err != nil {
+		return "", err
+	}
+	if info.Config != nil && info.Config.KeyId != nil {
+		return "", fmt.Errorf("the virtual machine is already encrypted")
+	}
+
+	// The key is generated by the key provider, or by the default key provider
+	// if the key provider is not known.
+	keyID := types.CryptoKeyId{}
+	if spec.KeyProvider != "" {
+		keyID.ProviderId = &types.KeyProviderId{Id: spec.KeyProvider}
+	}
+	profile := storageProfileSpec(spec.StoragePolicyID)
+
+	devices, err := vm.Devices()
+	if err != nil {
+		return "", err
+	}
+	confSpec := types.VirtualMachineConfigSpec{
+		VmProfile: profile,
+		Crypto:    &types.CryptoSpecEncrypt{CryptoKeyId: keyID},
+	}
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		confSpec.DeviceChange = append(confSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			Device:    device,
+			Profile:   profile,
+			Backing: &types.VirtualDeviceConfigSpecBackingSpec{
+				Crypto: &types.CryptoSpecEncrypt{CryptoKeyId: keyID},
+			},
+		})
+	}
+
+	if err := vm.Reconfigure(confSpec); err != nil {
+		return "", err
+	}
+
+	info, err = vm.Info("config.keyId")
+	if err != nil {
+		return "", err
+	}
+	if info.Config == nil || info.Config.KeyId == nil {
+		return "", fmt.Errorf("the virtual machine was not encrypted")
+	}
+	if spec.KeyProvider == "" && info.Config.KeyId.ProviderId != nil {
+		spec.KeyProvider = info.Config.KeyId.ProviderId.Id
+	}
+	return info.Config.KeyId.KeyId, nil
+}
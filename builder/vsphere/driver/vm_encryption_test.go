@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/crypto"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVCenterDriver_CheckEncryption(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	if _, err := sim.driver.CheckEncryption("", "", "", ""); err == nil {
+		t.Fatal("unexpected success: expected no default key provider")
+	}
+	if _, err := sim.driver.CheckEncryption("native-provider", "", "", ""); err == nil {
+		t.Fatal("unexpected success: expected the key provider to be missing")
+	}
+
+	m, err := crypto.GetManagerKmip(sim.driver.vimClient)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	err = m.RegisterKmsCluster(sim.driver.ctx, "native-provider", types.KmipClusterInfoKmsManagementTypeNativeProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := m.SetDefaultKmsClusterId(sim.driver.ctx, "native-provider", nil); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	host := simulator.Map.Any("HostSystem").(*simulator.HostSystem)
+	host.Capability.CryptoSupported = types.NewBool(true)
+	host.Runtime.CryptoState = string(types.HostCryptoStateSafe)
+	// The simulator does not fall back to the default key provider of vCenter
+	// Server for a host without a default key provider.
+	ref := host.Reference()
+	if err := m.SetDefaultKmsClusterId(sim.driver.ctx, "native-provider", &ref); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	provider, err := sim.driver.CheckEncryption("", "", host.Name, "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if provider != "native-provider" {
+		t.Errorf("unexpected result: expected 'native-provider', but returned %q", provider)
+	}
+
+	host.Runtime.CryptoState = string(types.HostCryptoStateIncapable)
+	if _, err := sim.driver.CheckEncryption("native-provider", "", host.Name, ""); err == nil {
+		t.Fatal("unexpected success: expected the host to be incapable of encryption")
+	}
+
+	// The hosts of the cluster of the resource pool are checked if no host is
+	// specified.
+	cluster := simulator.Map.Any("ClusterComputeResource").(*simulator.ClusterComputeResource)
+	for _, ref := range cluster.Host {
+		h := simulator.Map.Get(ref).(*simulator.HostSystem)
+		h.Capability.CryptoSupported = types.NewBool(true)
+		h.Runtime.CryptoState = string(types.HostCryptoStateSafe)
+	}
+	if _, err := sim.driver.CheckEncryption("native-provider", cluster.Name, "", ""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	last := simulator.Map.Get(cluster.Host[len(cluster.Host)-1]).(*simulator.HostSystem)
+	last.Capability.CryptoSupported = types.NewBool(false)
+	_, err = sim.driver.CheckEncryption("native-provider", cluster.Name, "", "")
+	if err == nil || !strings.Contains(err.Error(), "does not support virtual machine encryption") {
+		t.Fatalf("unexpected result: expected a host without encryption support, but returned '%v'", err)
+	}
+}
+
+func TestVirtualMachineDriver_Encrypt(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	m, err := crypto.GetManagerKmip(sim.driver.vimClient)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	err = m.RegisterKmsCluster(sim.driver.ctx, "native-provider", types.KmipClusterInfoKmsManagementTypeNativeProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	key, err := vm.Encrypt(&EncryptionSpec{KeyProvider: "native-provider"})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if key == "" {
+		t.Fatal("unexpected result: expected a key")
+	}
+
+	info, err := vm.Info("config.keyId")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Config.KeyId == nil || info.Config.KeyId.ProviderId.Id != "native-provider" {
+		t.Errorf("unexpected result: expected the virtual machine to be encrypted with 'native-provider'")
+	}
+
+	if _, err := vm.Encrypt(&EncryptionSpec{KeyProvider: "native-provider"}); err == nil {
+		t.Fatal("unexpected success: expected the virtual machine to be encrypted")
+	}
+
+	if err := sim.driver.RemoveEncryptionKey("native-provider", key); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// AttachFirstClassDisk attaches an existing First Class Disk, also known as an
+// improved virtual disk, to the disk controller of the virtual machine at the
+// provided index. The disk controllers are indexed in the order of the devices
+// of the virtual machine.
+func (vm *VirtualMachineDriver) AttachFirstClassDisk(id string, datastore string, controllerIndex int) error {
+	ds, err := vm.driver.finder.Datastore(vm.driver.ctx, datastore)
+	if err != nil {
+		return fmt.Errorf("error finding datastore %s: %s", datastore, err)
+	}
+
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return err
+	}
+
+	var controllers []types.BaseVirtualController
+	for _, device := range devices {
+		switch c := device.(type) {
+		case types.BaseVirtualSCSIController, *types.VirtualAHCIController, *types.VirtualNVMEController:
+			controllers = append(controllers, c.(types.BaseVirtualController))
+		}
+	}
+	if controllerIndex >= len(controllers) {
+		return fmt.Errorf("error finding disk controller %d: the virtual machine has %d disk controllers", controllerIndex, len(controllers))
+	}
+	key := controllers[controllerIndex].GetVirtualController().Key
+
+	return vm.vm.AttachDisk(vm.driver.ctx, id, ds, key, nil)
+}
+
+// DetachFirstClassDisk detaches a First Class Disk from the virtual machine.
+// The disk is preserved.
+func (vm *VirtualMachineDriver) DetachFirstClassDisk(id string) error {
+	return vm.vm.DetachDisk(vm.driver.ctx, id)
+}
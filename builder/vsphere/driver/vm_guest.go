@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/vmware/govmomi/guest"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// guestAuth returns the credentials of a guest operating system account for
+// the guest operations of VMware Tools.
+func guestAuth(username, password string) *types.NamePasswordAuthentication {
+	return &types.NamePasswordAuthentication{
+		Username: username,
+		Password: password,
+	}
+}
+
+func (vm *VirtualMachineDriver) guestFileManager() (*guest.FileManager, error) {
+	om := guest.NewOperationsManager(vm.driver.vimClient, vm.vm.Reference())
+	return om.FileManager(vm.driver.ctx)
+}
+
+func (vm *VirtualMachineDriver) guestProcessManager() (*guest.ProcessManager, error) {
+	om := guest.NewOperationsManager(vm.driver.vimClient, vm.vm.Reference())
+	return om.ProcessManager(vm.driver.ctx)
+}
+
+// StartGuestProgram starts a program in the guest operating system of the
+// virtual machine using the guest operations of VMware Tools and returns the
+// process identifier of the program.
+func (vm *VirtualMachineDriver) StartGuestProgram(username, password, path, args string) (int64, error) {
+	pm, err := vm.guestProcessManager()
+	if err != nil {
+		return 0, err
+	}
+	return pm.StartProgram(vm.driver.ctx, guestAuth(username, password), &types.GuestProgramSpec{
+		ProgramPath: path,
+		Arguments:   args,
+	})
+}
+
+// GuestProgramExitCode returns the exit code of a program started in the
+// guest operating system of the virtual machine, or nil if the program is
+// still running.
+func (vm *VirtualMachineDriver) GuestProgramExitCode(username, password string, pid int64) (*int32, error) {
+	pm, err := vm.guestProcessManager()
+	if err != nil {
+		return nil, err
+	}
+	processes, err := pm.ListProcesses(vm.driver.ctx, guestAuth(username, password), []int64{pid})
+	if err != nil {
+		return nil, err
+	}
+	if len(processes) == 0 {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+	if processes[0].EndTime == nil {
+		return nil, nil
+	}
+	return &processes[0].ExitCode, nil
+}
+
+// DownloadGuestFile downloads a file from the guest operating system of the
+// virtual machine using the guest operations of VMware Tools.
+func (vm *VirtualMachineDriver) DownloadGuestFile(username, password, path string) ([]byte, error) {
+	fm, err := vm.guestFileManager()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := fm.InitiateFileTransferFromGuest(vm.driver.ctx, guestAuth(username, password), path)
+	if err != nil {
+		return nil, err
+	}
+	u, err := fm.TransferURL(vm.driver.ctx, info.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	f, _, err := vm.driver.vimClient.Download(vm.driver.ctx, u, &soap.DefaultDownload)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// UploadGuestFile uploads the content of the reader with the specified size
+// to a file in the guest operating system of the virtual machine, replacing
+// the file if it exists.
+func (vm *VirtualMachineDriver) UploadGuestFile(username, password, path string, r io.Reader, size int64) error {
+	fm, err := vm.guestFileManager()
+	if err != nil {
+		return err
+	}
+
+	url, err := fm.InitiateFileTransferToGuest(vm.driver.ctx, guestAuth(username, password), path, &types.GuestFileAttributes{}, size, true)
+	if err != nil {
+		return err
+	}
+	u, err := fm.TransferURL(vm.driver.ctx, url)
+	if err != nil {
+		return err
+	}
+
+	p := soap.DefaultUpload
+	p.ContentLength = size
+	return vm.driver.vimClient.Upload(vm.driver.ctx, r, u, &p)
+}
+
+// MakeGuestDirectory creates a directory and its parent directories in the
+// guest operating system of the virtual machine.
+func (vm *VirtualMachineDriver) MakeGuestDirectory(username, password, path string) error {
+	fm, err := vm.guestFileManager()
+	if err != nil {
+		return err
+	}
+	err = fm.MakeDirectory(vm.driver.ctx, guestAuth(username, password), path, true)
+	if soap.IsSoapFault(err) {
+		if _, ok := soap.ToSoapFault(err).VimFault().(types.FileAlreadyExists); ok {
+			return nil
+		}
+	}
+	return err
+}
+
+// CreateGuestTempFile creates an empty temporary file with the specified
+// suffix in the guest operating system of the virtual machine and returns the
+// path of the file.
+func (vm *VirtualMachineDriver) CreateGuestTempFile(username, password, suffix string) (string, error) {
+	fm, err := vm.guestFileManager()
+	if err != nil {
+		return "", err
+	}
+	return fm.CreateTemporaryFile(vm.driver.ctx, guestAuth(username, password), "packer", suffix, "")
+}
+
+// DeleteGuestFile deletes a file in the guest operating system of the
+// virtual machine.
+func (vm *VirtualMachineDriver) DeleteGuestFile(username, password, path string) error {
+	fm, err := vm.guestFileManager()
+	if err != nil {
+		return err
+	}
+	return fm.DeleteFile(vm.driver.ctx, guestAuth(username, password), path)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// FindTagID returns the identifier of the tag with the specified name in the
+// specified category.
+func (d *VCenterDriver) FindTagID(categoryName string, tagName string) (string, error) {
+	if err := d.restClient.Login(d.ctx); err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = d.restClient.Logout(d.ctx)
+	}()
+
+	tm := tags.NewManager(d.restClient.client)
+	category, err := tm.GetCategory(d.ctx, categoryName)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving tag category %s: %s", categoryName, err)
+	}
+	tag, err := tm.GetTagForCategory(d.ctx, tagName, category.ID)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving tag %s: %s", tagName, err)
+	}
+	return tag.ID, nil
+}
+
+// FindCustomAttributeKey returns the key of the custom attribute with the
+// specified name. The error wraps object.ErrKeyNameNotFound if the custom
+// attribute does not exist.
+func (d *VCenterDriver) FindCustomAttributeKey(name string) (int32, error) {
+	m, err := object.GetCustomFieldsManager(d.vimClient)
+	if err != nil {
+		return 0, err
+	}
+	key, err := m.FindKey(d.ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving custom attribute %s: %w", name, err)
+	}
+	return key, nil
+}
+
+// FindOrCreateCustomAttributeKey returns the key of the custom attribute
+// with the specified name. The custom attribute is defined for virtual
+// machines if it does not exist.
+func (d *VCenterDriver) FindOrCreateCustomAttributeKey(name string) (int32, error) {
+	m, err := object.GetCustomFieldsManager(d.vimClient)
+	if err != nil {
+		return 0, err
+	}
+	key, err := m.FindKey(d.ctx, name)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, object.ErrKeyNameNotFound) {
+		return 0, fmt.Errorf("error retrieving custom attribute %s: %s", name, err)
+	}
+	field, err := m.Add(d.ctx, name, "VirtualMachine", nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating custom attribute %s: %s", name, err)
+	}
+	return field.Key, nil
+}
+
+// Reference returns the managed object reference of the virtual machine.
+func (vm *VirtualMachineDriver) Reference() types.ManagedObjectReference {
+	return vm.vm.Reference()
+}
+
+// Tags returns the identifiers of the tags attached to the virtual machine.
+func (vm *VirtualMachineDriver) Tags() ([]string, error) {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return nil, err
+	}
+	defer vm.logout()
+
+	tm := tags.NewManager(vm.driver.restClient.client)
+	return tm.ListAttachedTags(vm.driver.ctx, vm.vm.Reference())
+}
+
+// AttachTag attaches the tag with the specified identifier to the virtual
+// machine.
+func (vm *VirtualMachineDriver) AttachTag(id string) error {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	tm := tags.NewManager(vm.driver.restClient.client)
+	return tm.AttachTag(vm.driver.ctx, id, vm.vm.Reference())
+}
+
+// DetachTag detaches the tag with the specified identifier from the virtual
+// machine.
+func (vm *VirtualMachineDriver) DetachTag(id string) error {
+	if err := vm.driver.restClient.Login(vm.driver.ctx); err != nil {
+		return err
+	}
+	defer vm.logout()
+
+	tm := tags.NewManager(vm.driver.restClient.client)
+	return tm.DetachTag(vm.driver.ctx, id, vm.vm.Reference())
+}
+
+// CustomAttributes returns the values of the custom attributes of the virtual
+// machine by key.
+func (vm *VirtualMachineDriver) CustomAttributes() (map[int32]string, error) {
+	info, err := vm.Info("customValue")
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[int32]string)
+	for _, v := range info.CustomValue {
+		if value, ok := v.(*types.CustomFieldStringValue); ok {
+			values[value.Key] = value.Value
+		}
+	}
+	return values, nil
+}
+
+// SetCustomAttribute sets the value of the custom attribute with the
+// specified key. An empty value clears the custom attribute.
+func (vm *VirtualMachineDriver) SetCustomAttribute(key int32, value string) error {
+	m, err := object.GetCustomFieldsManager(vm.driver.vimClient)
+	if err != nil {
+		return err
+	}
+	return m.Set(vm.driver.ctx, vm.vm.Reference(), key, value)
+}
+
+// Notes returns the notes of the virtual machine.
+func (vm *VirtualMachineDriver) Notes() (string, error) {
+	info, err := vm.Info("config.annotation")
+	if err != nil {
+		return "", err
+	}
+	if info.Config == nil {
+		return "", nil
+	}
+	return info.Config.Annotation, nil
+}
+
+// SetNotes replaces the notes of the virtual machine. Empty notes clear the
+// notes of the virtual machine.
+func (vm *VirtualMachineDriver) SetNotes(notes string) error {
+	if notes == "" {
+		return vm.clearNotes()
+	}
+	return vm.Reconfigure(types.VirtualMachineConfigSpec{Annotation: notes})
+}
+
+// clearNotesSpec is a virtual machine configuration specification with only
+// the annotation, which is sent even if it is empty. The annotation of
+// types.VirtualMachineConfigSpec is omitted when it is empty, so it cannot
+// clear the notes.
+type clearNotesSpec struct {
+	Annotation string `xml:"annotation"`
+}
+
+type clearNotesRequest struct {
+	This types.ManagedObjectReference `xml:"_this"`
+	Spec clearNotesSpec               `xml:"spec"`
+}
+
+// clearNotesBody is the body of a ReconfigVM_Task call with a clearNotesSpec.
+type clearNotesBody struct {
+	Req    *clearNotesRequest             `xml:"urn:vim25 ReconfigVM_Task,omitempty"`
+	Res    *types.ReconfigVM_TaskResponse `xml:"ReconfigVM_TaskResponse,omitempty"`
+	Fault_ *soap.Fault                    `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault,omitempty"`
+}
+
+func (b *clearNotesBody) Fault() *soap.Fault { return b.Fault_ }
+
+// clearNotes removes the notes of the virtual machine.
+func (vm *VirtualMachineDriver) clearNotes() error {
+	_, err := vm.driver.runTask(vm.driver.ctx, "clear virtual machine notes", func() (*object.Task, error) {
+		var req, res clearNotesBody
+		req.Req = &clearNotesRequest{This: vm.vm.Reference()}
+		if err := vm.driver.vimClient.RoundTrip(vm.driver.ctx, &req, &res); err != nil {
+			return nil, err
+		}
+		return object.NewTask(vm.driver.vimClient, res.Res.Returnval), nil
+	})
+	return err
+}
+
+// Folder returns the folder that contains the virtual machine.
+func (vm *VirtualMachineDriver) Folder() (*Folder, error) {
+	info, err := vm.Info("parent")
+	if err != nil {
+		return nil, err
+	}
+	if info.Parent == nil {
+		return nil, fmt.Errorf("virtual machine is not in a folder")
+	}
+	return vm.driver.NewFolder(info.Parent), nil
+}
+
+// MoveToFolder moves the virtual machine to the specified folder.
+func (vm *VirtualMachineDriver) MoveToFolder(folder *Folder) error {
+	task, err := folder.folder.MoveInto(vm.driver.ctx, []types.ManagedObjectReference{vm.vm.Reference()})
+	if err != nil {
+		return err
+	}
+	return task.Wait(vm.driver.ctx)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+)
+
+func TestVCenterDriver_FindOrCreateCustomAttributeKey(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	if _, err := sim.driver.FindCustomAttributeKey("owner"); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	key, err := sim.driver.FindOrCreateCustomAttributeKey("owner")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	found, err := sim.driver.FindOrCreateCustomAttributeKey("owner")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if found != key {
+		t.Fatalf("unexpected result: expected '%d', but returned '%d'", key, found)
+	}
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.SetCustomAttribute(key, "platform-team"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	values, err := vm.(*VirtualMachineDriver).CustomAttributes()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if values[key] != "platform-team" {
+		t.Fatalf("unexpected result: expected 'platform-team', but returned '%s'", values[key])
+	}
+}
+
+func TestVirtualMachineDriver_SetNotes(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	d := vm.(*VirtualMachineDriver)
+	if err := d.SetNotes("Built by Packer."); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	notes, err := d.Notes()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if notes != "Built by Packer." {
+		t.Fatalf("unexpected result: expected 'Built by Packer.', but returned '%s'", notes)
+	}
+	// The notes are cleared with a reconfiguration of the annotation only.
+	if err := d.SetNotes(""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
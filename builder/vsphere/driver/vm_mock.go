@@ -5,7 +5,8 @@ package driver
 
 import (
 	"context"
-	"net"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/vmware/govmomi/nfc"
@@ -20,6 +21,44 @@ type VirtualMachineMock struct {
 	DestroyError  error
 	DestroyCalled bool
 
+	PowerOnCalledTimes int
+	// The errors of the calls to PowerOn, in order.
+	PowerOnErrs []error
+
+	MigrateToAnotherHostCalledTimes int
+	MigrateToAnotherHostExcluded    []string
+	MigrateToAnotherHostErr         error
+
+	HostAddressesReturn []string
+	HostAddressesErr    error
+
+	AddConfigParamsParams map[string]string
+	AddConfigParamsErr    error
+
+	ResetVAppPropertiesIDs []string
+	ResetVAppPropertiesErr error
+
+	IsPoweredOffResults []bool
+
+	StartGuestProgramPath string
+	StartGuestProgramArgs string
+	StartGuestProgramErr  error
+
+	GuestProgramExitCodeReturn *int32
+	GuestProgramExitCodeErr    error
+
+	DownloadGuestFileReturn []byte
+	DownloadGuestFileErr    error
+
+	// The files in the guest operating system by path. Files are added by
+	// UploadGuestFile and CreateGuestTempFile, and returned by
+	// DownloadGuestFile if present.
+	GuestFiles             map[string][]byte
+	UploadGuestFileErr     error
+	GuestDirectories       []string
+	CreateGuestTempFileErr error
+	GuestTempFileCount     int
+
 	ConfigureError          error
 	ConfigureCalled         bool
 	ConfigureHardwareConfig *HardwareConfig
@@ -35,6 +74,10 @@ type VirtualMachineMock struct {
 	AddCdromTypes       []string
 	AddCdromPaths       []string
 
+	AddCdromAtErr        error
+	AddCdromAtPlacements []*CdromPlacement
+	AddCdromAtPaths      []string
+
 	AddFlagCalled            bool
 	AddFlagCalledTimes       int
 	AddFlagErr               error
@@ -49,6 +92,8 @@ type VirtualMachineMock struct {
 	AddFloppyImagePath string
 	AddFloppyErr       error
 
+	DevicesReturn object.VirtualDeviceList
+
 	FloppyDevicesErr    error
 	FloppyDevicesReturn object.VirtualDeviceList
 	FloppyDevicesCalled bool
@@ -62,9 +107,33 @@ type VirtualMachineMock struct {
 	RemoveDeviceKeepFiles bool
 	RemoveDeviceDevices   []types.BaseVirtualDevice
 
+	AttachFirstClassDiskErr             error
+	AttachFirstClassDiskIDs             []string
+	AttachFirstClassDiskControllerIndex []int
+
+	DetachFirstClassDiskErr error
+	DetachFirstClassDiskIDs []string
+
+	AddSerialPortFileCalled   bool
+	AddSerialPortFileName     string
+	AddSerialPortFileResponse string
+	AddSerialPortFileErr      error
+
 	EjectCdromsCalled bool
 	EjectCdromsErr    error
 
+	SetBootOrderOrder []string
+
+	SetBootOptionsCalledTimes int
+	SetBootOptionsOptions     []*BootOptions
+	SetBootOptionsErr         error
+
+	EnterSetupPendingResult bool
+	EnterSetupPendingErr    error
+
+	ResetCalledTimes int
+	ResetErr         error
+
 	RemoveCdromsCalled bool
 	RemoveCdromsErr    error
 
@@ -78,16 +147,102 @@ type VirtualMachineMock struct {
 	NetworkAdaptersList         object.VirtualDeviceList
 	RemoveNetworkAdaptersErr    error
 
+	SetNetworkAdaptersConnectedCalled    bool
+	SetNetworkAdaptersConnectedIndices   []int
+	SetNetworkAdaptersConnectedConnected bool
+	SetNetworkAdaptersConnectedErr       error
+
+	GuestNetworkAdaptersReturn []types.GuestNicInfo
+	GuestNetworkAdaptersErr    error
+
 	CloneCalled bool
 	CloneConfig *CloneConfig
 	CloneError  error
+
+	InfoReturn *mo.VirtualMachine
+
+	CreateSnapshotCalled bool
+	CreateSnapshotName   string
+	CreateSnapshotErr    error
+
+	CreateMemorySnapshotCalled bool
+	CreateMemorySnapshotName   string
+	CreateMemorySnapshotErr    error
+
+	CreateSnapshotWithOptionsCalled      bool
+	CreateSnapshotWithOptionsName        string
+	CreateSnapshotWithOptionsDescription string
+	CreateSnapshotWithOptionsMemory      bool
+	CreateSnapshotWithOptionsErr         error
+
+	RemoveSnapshotName string
+	RemoveSnapshotErr  error
+
+	ListSnapshotsReturn []string
+	ListSnapshotsErr    error
+
+	RemoveAllSnapshotsCalled bool
+	RemoveAllSnapshotsErr    error
+
+	AddHostAffinityRuleHosts     []string
+	AddHostAffinityRuleMandatory bool
+	AddHostAffinityRuleErr       error
+
+	RemoveHostAffinityRuleCalled bool
+	RemoveHostAffinityRuleName   string
+	RemoveHostAffinityRuleErr    error
+
+	ConvertToTemplateCalled bool
+	IsTemplateReturn        bool
+
+	EnableChangeTrackingCalled bool
+	EnableChangeTrackingErr    error
+
+	ChangedDisksSnapshotName string
+	ChangedDisksResult       []bool
+	ChangedDisksErr          error
+
+	DiagnosticFilesReturn []string
+	DiagnosticFilesErr    error
+
+	MissingPrivilegesReturn []string
+
+	SetManagedByCalledTimes  int
+	SetManagedByExtensionKey string
+	SetManagedByType         string
+	SetManagedByErr          error
+
+	UpgradeHardwareVersionCalled  bool
+	UpgradeHardwareVersionVersion uint
+	UpgradeHardwareVersionErr     error
+
+	EncryptCalled bool
+	EncryptSpec   *EncryptionSpec
+	EncryptResult string
+	EncryptErr    error
+
+	TagsReturn   []string
+	TagsErr      error
+	AttachTagIDs []string
+	AttachTagErr error
+
+	SetCustomAttributeValues map[int32]string
+	SetCustomAttributeErr    error
+
+	// The notes of the virtual machine. The notes are replaced by SetNotes.
+	NotesValue     string
+	SetNotesCalled bool
+	SetNotesErr    error
 }
 
 func (vm *VirtualMachineMock) Info(params ...string) (*mo.VirtualMachine, error) {
-	return nil, nil
+	return vm.InfoReturn, nil
 }
 
 func (vm *VirtualMachineMock) Devices() (object.VirtualDeviceList, error) {
+	if vm.DevicesReturn != nil {
+		return vm.DevicesReturn, nil
+	}
 	return object.VirtualDeviceList{}, nil
 }
 
@@ -115,6 +270,11 @@ func (vm *VirtualMachineMock) AddPublicKeys(ctx context.Context, publicKeys stri
 	return nil
 }
 
+func (vm *VirtualMachineMock) ResetVAppProperties(ids []string) error {
+	vm.ResetVAppPropertiesIDs = ids
+	return vm.ResetVAppPropertiesErr
+}
+
 func (vm *VirtualMachineMock) Properties(ctx context.Context) (*mo.VirtualMachine, error) {
 	return nil, nil
 }
@@ -140,6 +300,61 @@ func (vm *VirtualMachineMock) Reconfigure(confSpec types.VirtualMachineConfigSpe
 	return nil
 }
 
+func (vm *VirtualMachineMock) SetManagedBy(extensionKey string, managedType string) error {
+	vm.SetManagedByCalledTimes++
+	vm.SetManagedByExtensionKey = extensionKey
+	vm.SetManagedByType = managedType
+	return vm.SetManagedByErr
+}
+
+func (vm *VirtualMachineMock) UpgradeHardwareVersion(version uint) error {
+	vm.UpgradeHardwareVersionCalled = true
+	vm.UpgradeHardwareVersionVersion = version
+	return vm.UpgradeHardwareVersionErr
+}
+
+func (vm *VirtualMachineMock) Encrypt(spec *EncryptionSpec) (string, error) {
+	vm.EncryptCalled = true
+	vm.EncryptSpec = spec
+	return vm.EncryptResult, vm.EncryptErr
+}
+
+func (vm *VirtualMachineMock) Tags() ([]string, error) {
+	return vm.TagsReturn, vm.TagsErr
+}
+
+func (vm *VirtualMachineMock) AttachTag(id string) error {
+	if vm.AttachTagErr != nil {
+		return vm.AttachTagErr
+	}
+	vm.AttachTagIDs = append(vm.AttachTagIDs, id)
+	return nil
+}
+
+func (vm *VirtualMachineMock) SetCustomAttribute(key int32, value string) error {
+	if vm.SetCustomAttributeErr != nil {
+		return vm.SetCustomAttributeErr
+	}
+	if vm.SetCustomAttributeValues == nil {
+		vm.SetCustomAttributeValues = make(map[int32]string)
+	}
+	vm.SetCustomAttributeValues[key] = value
+	return nil
+}
+
+func (vm *VirtualMachineMock) Notes() (string, error) {
+	return vm.NotesValue, nil
+}
+
+func (vm *VirtualMachineMock) SetNotes(notes string) error {
+	vm.SetNotesCalled = true
+	if vm.SetNotesErr != nil {
+		return vm.SetNotesErr
+	}
+	vm.NotesValue = notes
+	return nil
+}
+
 func (vm *VirtualMachineMock) Customize(spec types.CustomizationSpec) error {
 	return nil
 }
@@ -149,19 +364,45 @@ func (vm *VirtualMachineMock) ResizeDisk(diskSize int64) ([]types.BaseVirtualDev
 }
 
 func (vm *VirtualMachineMock) PowerOn() error {
+	vm.PowerOnCalledTimes++
+	if len(vm.PowerOnErrs) >= vm.PowerOnCalledTimes {
+		return vm.PowerOnErrs[vm.PowerOnCalledTimes-1]
+	}
 	return nil
 }
 
-func (vm *VirtualMachineMock) WaitForIP(ctx context.Context, ipNet *net.IPNet) (string, error) {
+func (vm *VirtualMachineMock) MigrateToAnotherHost(excluded []string) (string, string, error) {
+	vm.MigrateToAnotherHostCalledTimes++
+	vm.MigrateToAnotherHostExcluded = excluded
+	if vm.MigrateToAnotherHostErr != nil {
+		return "", "", vm.MigrateToAnotherHostErr
+	}
+	return fmt.Sprintf("host-%d", vm.MigrateToAnotherHostCalledTimes-1), fmt.Sprintf("host-%d", vm.MigrateToAnotherHostCalledTimes), nil
+}
+
+func (vm *VirtualMachineMock) HostAddresses() ([]string, error) {
+	return vm.HostAddressesReturn, vm.HostAddressesErr
+}
+
+func (vm *VirtualMachineMock) WaitForIP(ctx context.Context, filter *IPFilter) (string, error) {
 	return "", nil
 }
 
+func (vm *VirtualMachineMock) WaitForIPs(ctx context.Context, filter *IPFilter) ([]string, error) {
+	return nil, nil
+}
+
 func (vm *VirtualMachineMock) PowerOff() error {
 	return nil
 }
 
 func (vm *VirtualMachineMock) IsPoweredOff() (bool, error) {
-	return false, nil
+	if len(vm.IsPoweredOffResults) == 0 {
+		return false, nil
+	}
+	off := vm.IsPoweredOffResults[0]
+	vm.IsPoweredOffResults = vm.IsPoweredOffResults[1:]
+	return off, nil
 }
 
 func (vm *VirtualMachineMock) StartShutdown() error {
@@ -173,15 +414,79 @@ func (vm *VirtualMachineMock) WaitForShutdown(ctx context.Context, timeout time.
 }
 
 func (vm *VirtualMachineMock) CreateSnapshot(name string) error {
-	return nil
+	vm.CreateSnapshotCalled = true
+	vm.CreateSnapshotName = name
+	return vm.CreateSnapshotErr
+}
+
+func (vm *VirtualMachineMock) CreateMemorySnapshot(name string) error {
+	vm.CreateMemorySnapshotCalled = true
+	vm.CreateMemorySnapshotName = name
+	return vm.CreateMemorySnapshotErr
+}
+
+func (vm *VirtualMachineMock) CreateSnapshotWithOptions(name string, description string, memory bool) error {
+	vm.CreateSnapshotWithOptionsCalled = true
+	vm.CreateSnapshotWithOptionsName = name
+	vm.CreateSnapshotWithOptionsDescription = description
+	vm.CreateSnapshotWithOptionsMemory = memory
+	return vm.CreateSnapshotWithOptionsErr
+}
+
+func (vm *VirtualMachineMock) RemoveSnapshot(name string) error {
+	vm.RemoveSnapshotName = name
+	return vm.RemoveSnapshotErr
+}
+
+func (vm *VirtualMachineMock) ListSnapshots() ([]string, error) {
+	return vm.ListSnapshotsReturn, vm.ListSnapshotsErr
+}
+
+func (vm *VirtualMachineMock) RemoveAllSnapshots() error {
+	vm.RemoveAllSnapshotsCalled = true
+	return vm.RemoveAllSnapshotsErr
+}
+
+func (vm *VirtualMachineMock) AddHostAffinityRule(hosts []string, mandatory bool) (*HostAffinityRule, error) {
+	vm.AddHostAffinityRuleHosts = hosts
+	vm.AddHostAffinityRuleMandatory = mandatory
+	if vm.AddHostAffinityRuleErr != nil {
+		return nil, vm.AddHostAffinityRuleErr
+	}
+	return &HostAffinityRule{Name: "packer-vm-1"}, nil
+}
+
+func (vm *VirtualMachineMock) RemoveHostAffinityRule(rule *HostAffinityRule) error {
+	vm.RemoveHostAffinityRuleCalled = true
+	vm.RemoveHostAffinityRuleName = rule.Name
+	return vm.RemoveHostAffinityRuleErr
+}
+
+func (vm *VirtualMachineMock) EnableChangeTracking() error {
+	vm.EnableChangeTrackingCalled = true
+	return vm.EnableChangeTrackingErr
+}
+
+func (vm *VirtualMachineMock) ChangedDisks(snapshotName string) ([]bool, error) {
+	vm.ChangedDisksSnapshotName = snapshotName
+	return vm.ChangedDisksResult, vm.ChangedDisksErr
+}
+
+func (vm *VirtualMachineMock) DiagnosticFiles() ([]string, error) {
+	return vm.DiagnosticFilesReturn, vm.DiagnosticFilesErr
+}
+
+func (vm *VirtualMachineMock) MissingPrivileges(privileges ...string) ([]string, error) {
+	return vm.MissingPrivilegesReturn, nil
 }
 
 func (vm *VirtualMachineMock) ConvertToTemplate() error {
+	vm.ConvertToTemplateCalled = true
 	return nil
 }
 
 func (vm *VirtualMachineMock) IsTemplate() (bool, error) {
-	return false, nil
+	return vm.IsTemplateReturn, nil
 }
 
 func (vm *VirtualMachineMock) ConvertToVirtualMachine(vsphereCluster string, vsphereHost string, vsphereResourcePool string) error {
@@ -192,6 +497,10 @@ func (vm *VirtualMachineMock) ImportOvfToContentLibrary(ovf vcenter.OVF) error {
 	return nil
 }
 
+func (vm *VirtualMachineMock) StreamOvfToContentLibrary(ctx context.Context, config *StreamOvfConfig) error {
+	return nil
+}
+
 func (vm *VirtualMachineMock) ImportToContentLibrary(template vcenter.Template) error {
 	return nil
 }
@@ -211,6 +520,13 @@ func (vm *VirtualMachineMock) AddCdrom(controllerType string, isoPath string) er
 	return vm.AddCdromErr
 }
 
+func (vm *VirtualMachineMock) AddCdromAt(placement *CdromPlacement, isoPath string) error {
+	vm.AddCdromAtPlacements = append(vm.AddCdromAtPlacements, placement)
+	vm.AddCdromAtPaths = append(vm.AddCdromAtPaths, isoPath)
+	vm.CdromDevicesList = append(vm.CdromDevicesList, nil)
+	return vm.AddCdromAtErr
+}
+
 func (vm *VirtualMachineMock) AddFloppy(imgPath string) error {
 	vm.AddFloppyCalled = true
 	vm.AddFloppyImagePath = imgPath
@@ -218,9 +534,25 @@ func (vm *VirtualMachineMock) AddFloppy(imgPath string) error {
 }
 
 func (vm *VirtualMachineMock) SetBootOrder(order []string) error {
+	vm.SetBootOrderOrder = order
 	return nil
 }
 
+func (vm *VirtualMachineMock) SetBootOptions(options *BootOptions) error {
+	vm.SetBootOptionsCalledTimes++
+	vm.SetBootOptionsOptions = append(vm.SetBootOptionsOptions, options)
+	return vm.SetBootOptionsErr
+}
+
+func (vm *VirtualMachineMock) EnterSetupPending() (bool, error) {
+	return vm.EnterSetupPendingResult, vm.EnterSetupPendingErr
+}
+
+func (vm *VirtualMachineMock) Reset() error {
+	vm.ResetCalledTimes++
+	return vm.ResetErr
+}
+
 func (vm *VirtualMachineMock) RemoveDevice(keepFiles bool, device ...types.BaseVirtualDevice) error {
 	vm.RemoveDeviceCalled = true
 	vm.RemoveDeviceKeepFiles = keepFiles
@@ -228,12 +560,30 @@ func (vm *VirtualMachineMock) RemoveDevice(keepFiles bool, device ...types.BaseV
 	return vm.RemoveDeviceErr
 }
 
+func (vm *VirtualMachineMock) AttachFirstClassDisk(id string, datastore string, controllerIndex int) error {
+	vm.AttachFirstClassDiskIDs = append(vm.AttachFirstClassDiskIDs, id)
+	vm.AttachFirstClassDiskControllerIndex = append(vm.AttachFirstClassDiskControllerIndex, controllerIndex)
+	return vm.AttachFirstClassDiskErr
+}
+
+func (vm *VirtualMachineMock) DetachFirstClassDisk(id string) error {
+	vm.DetachFirstClassDiskIDs = append(vm.DetachFirstClassDiskIDs, id)
+	return vm.DetachFirstClassDiskErr
+}
+
+func (vm *VirtualMachineMock) AddSerialPortFile(name string) (string, error) {
+	vm.AddSerialPortFileCalled = true
+	vm.AddSerialPortFileName = name
+	return vm.AddSerialPortFileResponse, vm.AddSerialPortFileErr
+}
+
 func (vm *VirtualMachineMock) addDevice(device types.BaseVirtualDevice) error {
 	return nil
 }
 
 func (vm *VirtualMachineMock) AddConfigParams(params map[string]string, info *types.ToolsConfigInfo) error {
-	return nil
+	vm.AddConfigParamsParams = params
+	return vm.AddConfigParamsErr
 }
 
 func (vm *VirtualMachineMock) AddFlag(ctx context.Context, info *types.VirtualMachineFlagInfo) error {
@@ -312,3 +662,71 @@ func (vm *VirtualMachineMock) RemoveNetworkAdapters() error {
 func (vm *VirtualMachineMock) Datacenter() *object.Datacenter {
 	return nil
 }
+
+func (vm *VirtualMachineMock) SetNetworkAdaptersConnected(indices []int, connected bool) error {
+	vm.SetNetworkAdaptersConnectedCalled = true
+	vm.SetNetworkAdaptersConnectedIndices = indices
+	vm.SetNetworkAdaptersConnectedConnected = connected
+	return vm.SetNetworkAdaptersConnectedErr
+}
+
+func (vm *VirtualMachineMock) GuestNetworkAdapters() ([]types.GuestNicInfo, error) {
+	return vm.GuestNetworkAdaptersReturn, vm.GuestNetworkAdaptersErr
+}
+
+func (vm *VirtualMachineMock) StartGuestProgram(username, password, path, args string) (int64, error) {
+	vm.StartGuestProgramPath = path
+	vm.StartGuestProgramArgs = args
+	return 1, vm.StartGuestProgramErr
+}
+
+func (vm *VirtualMachineMock) GuestProgramExitCode(username, password string, pid int64) (*int32, error) {
+	return vm.GuestProgramExitCodeReturn, vm.GuestProgramExitCodeErr
+}
+
+func (vm *VirtualMachineMock) DownloadGuestFile(username, password, path string) ([]byte, error) {
+	if content, ok := vm.GuestFiles[path]; ok {
+		return content, nil
+	}
+	return vm.DownloadGuestFileReturn, vm.DownloadGuestFileErr
+}
+
+func (vm *VirtualMachineMock) UploadGuestFile(username, password, path string, r io.Reader, size int64) error {
+	if vm.UploadGuestFileErr != nil {
+		return vm.UploadGuestFileErr
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if vm.GuestFiles == nil {
+		vm.GuestFiles = make(map[string][]byte)
+	}
+	vm.GuestFiles[path] = content
+	return nil
+}
+
+func (vm *VirtualMachineMock) MakeGuestDirectory(username, password, path string) error {
+	vm.GuestDirectories = append(vm.GuestDirectories, path)
+	return nil
+}
+
+func (vm *VirtualMachineMock) CreateGuestTempFile(username, password, suffix string) (string, error) {
+	if vm.CreateGuestTempFileErr != nil {
+		return "", vm.CreateGuestTempFileErr
+	}
+	vm.GuestTempFileCount++
+	path := fmt.Sprintf("/tmp/packer%d%s", vm.GuestTempFileCount, suffix)
+	if vm.GuestFiles == nil {
+		vm.GuestFiles = make(map[string][]byte)
+	}
+	if _, ok := vm.GuestFiles[path]; !ok {
+		vm.GuestFiles[path] = []byte{}
+	}
+	return path, nil
+}
+
+func (vm *VirtualMachineMock) DeleteGuestFile(username, password, path string) error {
+	delete(vm.GuestFiles, path)
+	return nil
+}
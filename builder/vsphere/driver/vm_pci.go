@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The configuration parameters that map the memory of the PCI passthrough
+// devices above 4 GB, as required by the GPUs with large base address
+// registers (BARs).
+const (
+	use64bitMMIOParam = "pciPassthru.use64bitMMIO"
+	mmioSizeGBParam   = "pciPassthru.64bitMMIOSizeGB"
+)
+
+// The PCI domain of the addresses that do not specify a domain.
+const defaultPCIDomainPrefix = "0000:"
+
+// configTarget returns the devices and the other resources that the host of
+// the virtual machine provides to the virtual machine.
+func (vm *VirtualMachineDriver) configTarget() (*types.ConfigTarget, error) {
+	info, err := vm.Info("environmentBrowser", "runtime.host")
+	if err != nil {
+		return nil, err
+	}
+	var host *object.HostSystem
+	if info.Runtime.Host != nil {
+		host = object.NewHostSystem(vm.driver.client.Client, *info.Runtime.Host)
+	}
+	browser := object.NewEnvironmentBrowser(vm.driver.client.Client, info.EnvironmentBrowser)
+	target, err := browser.QueryConfigTarget(vm.driver.ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the devices available on the host: %s", err)
+	}
+	return target, nil
+}
+
+// pciPassthroughChanges returns the device changes that pass through the
+// PCI devices of the host with the addresses to the virtual machine, and the
+// configuration parameters that enable the 64-bit memory-mapped I/O of the
+// devices. The size of the memory-mapped I/O is set if mmioSizeGB is greater
+// than 0.
+func (vm *VirtualMachineDriver) pciPassthroughChanges(addresses []string, mmioSizeGB int64) ([]types.BaseVirtualDeviceConfigSpec, []types.BaseOptionValue, error) {
+	target, err := vm.configTarget()
+	if err != nil {
+		return nil, nil, err
+	}
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []types.BaseVirtualDeviceConfigSpec
+	for _, address := range addresses {
+		info, err := findPCIPassthroughDevice(target, address)
+		if err != nil {
+			return nil, nil, err
+		}
+		device := newPCIPassthroughDevice(info)
+		device.Key = devices.NewKey()
+		devices = append(devices, device)
+		changes = append(changes, &types.VirtualDeviceConfigSpec{
+			Device:    device,
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+		})
+		log.Printf("Adding PCI passthrough device %s (%s)", info.PciDevice.Id, info.PciDevice.DeviceName)
+	}
+
+	params := []types.BaseOptionValue{
+		&types.OptionValue{Key: use64bitMMIOParam, Value: "TRUE"},
+	}
+	if mmioSizeGB > 0 {
+		params = append(params, &types.OptionValue{Key: mmioSizeGBParam, Value: strconv.FormatInt(mmioSizeGB, 10)})
+	}
+	return changes, params, nil
+}
+
+// findPCIPassthroughDevice returns the PCI device of the configuration target
+// with the address, in the `domain:bus:slot.function` form or the
+// `bus:slot.function` form of the first domain.
+func findPCIPassthroughDevice(target *types.ConfigTarget, address string) (*types.VirtualMachinePciPassthroughInfo, error) {
+	id := strings.ToLower(address)
+	if strings.Count(id, ":") == 1 {
+		id = defaultPCIDomainPrefix + id
+	}
+
+	var available []string
+	for _, p := range target.PciPassthrough {
+		info, ok := p.(*types.VirtualMachinePciPassthroughInfo)
+		if !ok {
+			continue
+		}
+		if strings.ToLower(info.PciDevice.Id) == id {
+			return info, nil
+		}
+		available = append(available, info.PciDevice.Id)
+	}
+
+	if len(available) == 0 {
+		return nil, fmt.Errorf("PCI device %s is not available for passthrough, since the host has no devices enabled for passthrough", address)
+	}
+	return nil, fmt.Errorf("PCI device %s is not available for passthrough on the host (available devices: %s)", address, strings.Join(available, ", "))
+}
+
+// newPCIPassthroughDevice creates a DirectPath I/O passthrough device for the
+// PCI device of the host.
+func newPCIPassthroughDevice(info *types.VirtualMachinePciPassthroughInfo) *types.VirtualPCIPassthrough {
+	return &types.VirtualPCIPassthrough{
+		VirtualDevice: types.VirtualDevice{
+			DeviceInfo: &types.Description{
+				Label:   fmt.Sprintf("PCI device %s", info.PciDevice.Id),
+				Summary: info.PciDevice.DeviceName,
+			},
+			Backing: &types.VirtualPCIPassthroughDeviceBackingInfo{
+				Id:       info.PciDevice.Id,
+				DeviceId: fmt.Sprintf("%x", uint16(info.PciDevice.DeviceId)),
+				SystemId: info.SystemId,
+				VendorId: info.PciDevice.VendorId,
+			},
+			Connectable: &types.VirtualDeviceConnectInfo{
+				StartConnected: true,
+				Connected:      true,
+			},
+		},
+	}
+}
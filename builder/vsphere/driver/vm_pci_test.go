@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVirtualMachineDriver_ConfigurePCIPassthroughDevices(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+
+	config := &HardwareConfig{
+		RAMReserveAll:            true,
+		PCIPassthroughDevices:    []string{"3B:00.0"},
+		PCIPassthroughMMIOSizeGB: 128,
+	}
+	err = vm.Configure(config)
+	if err == nil || !strings.Contains(err.Error(), "the host has no devices enabled for passthrough") {
+		t.Fatalf("unexpected result: expected a missing PCI device error, but returned '%v'", err)
+	}
+
+	setSimulatorConfigTarget(machine, &types.ConfigTarget{
+		PciPassthrough: []types.BaseVirtualMachinePciPassthroughInfo{
+			&types.VirtualMachinePciPassthroughInfo{
+				PciDevice: types.HostPciDevice{
+					Id:         "0000:3b:00.0",
+					DeviceId:   0x20b5,
+					VendorId:   0x10de,
+					DeviceName: "GA100 [A100 PCIe 80GB]",
+				},
+				SystemId: "5f0c7a2e-1b5d-4c39-8d9f-0cc47a6b1e2a",
+			},
+		},
+	})
+	if err := vm.Configure(config); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	passthrough := devices.SelectByBackingInfo((*types.VirtualPCIPassthroughDeviceBackingInfo)(nil))
+	if len(passthrough) != 1 {
+		t.Fatalf("unexpected result: expected 1 PCI passthrough device, but returned %d", len(passthrough))
+	}
+	backing := passthrough[0].GetVirtualDevice().Backing.(*types.VirtualPCIPassthroughDeviceBackingInfo)
+	if backing.Id != "0000:3b:00.0" || backing.DeviceId != "20b5" || backing.VendorId != 0x10de {
+		t.Fatalf("unexpected result: expected device '0000:3b:00.0', but returned '%#v'", backing)
+	}
+
+	params := map[string]string{}
+	for _, o := range machine.Config.ExtraConfig {
+		params[o.GetOptionValue().Key] = o.GetOptionValue().Value.(string)
+	}
+	if params[use64bitMMIOParam] != "TRUE" || params[mmioSizeGBParam] != "128" {
+		t.Fatalf("unexpected result: expected 64-bit MMIO parameters, but returned '%v'", params)
+	}
+
+	config.PCIPassthroughDevices = []string{"0000:5e:00.0"}
+	err = vm.Configure(config)
+	if err == nil || !strings.Contains(err.Error(), "available devices: 0000:3b:00.0") {
+		t.Fatalf("unexpected result: expected a missing PCI device error, but returned '%v'", err)
+	}
+}
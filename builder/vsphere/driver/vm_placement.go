@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// IsInsufficientResourcesError reports whether the error is caused by
+// insufficient resources of the host or cluster, such as insufficient memory
+// or a failure of the admission control of vSphere HA.
+func IsInsufficientResourcesError(err error) bool {
+	var f types.BaseInsufficientResourcesFault
+	_, ok := fault.As(err, &f)
+	return ok
+}
+
+// MigrateToAnotherHost migrates the powered off virtual machine to the host
+// of its cluster with the most free memory, other than its current host and
+// the excluded hosts. Returns the names of the previous and the new host.
+func (vm *VirtualMachineDriver) MigrateToAnotherHost(excluded []string) (string, string, error) {
+	info, err := vm.Info("runtime.host")
+	if err != nil {
+		return "", "", err
+	}
+	if info.Runtime.Host == nil {
+		return "", "", fmt.Errorf("virtual machine is not on a host")
+	}
+	current, err := vm.driver.NewHost(info.Runtime.Host).Info("name", "parent")
+	if err != nil {
+		return "", "", err
+	}
+	if current.Parent == nil || current.Parent.Type != "ClusterComputeResource" {
+		return "", "", fmt.Errorf("host %s is not in a cluster", current.Name)
+	}
+
+	pc := property.DefaultCollector(vm.driver.vimClient)
+	var cluster mo.ClusterComputeResource
+	if err := pc.RetrieveOne(vm.driver.ctx, *current.Parent, []string{"host"}, &cluster); err != nil {
+		return "", "", fmt.Errorf("error retrieving the hosts of the cluster: %s", err)
+	}
+	var hosts []mo.HostSystem
+	if err := pc.Retrieve(vm.driver.ctx, cluster.Host, []string{"name", "runtime", "summary.hardware", "summary.quickStats"}, &hosts); err != nil {
+		return "", "", fmt.Errorf("error retrieving the hosts of the cluster: %s", err)
+	}
+
+	var target *mo.HostSystem
+	var targetFree int64
+	for i, host := range hosts {
+		if host.Name == current.Name || slices.Contains(excluded, host.Name) {
+			continue
+		}
+		if host.Runtime.ConnectionState != types.HostSystemConnectionStateConnected || host.Runtime.InMaintenanceMode {
+			continue
+		}
+		var free int64
+		if host.Summary.Hardware != nil {
+			free = host.Summary.Hardware.MemorySize/1024/1024 - int64(host.Summary.QuickStats.OverallMemoryUsage)
+		}
+		if target == nil || free > targetFree {
+			target = &hosts[i]
+			targetFree = free
+		}
+	}
+	if target == nil {
+		return current.Name, "", fmt.Errorf("no other host available in the cluster of host %s", current.Name)
+	}
+
+	ref := target.Reference()
+	_, err = vm.driver.runTask(vm.driver.ctx, "migrate virtual machine", func() (*object.Task, error) {
+		return vm.vm.Relocate(vm.driver.ctx, types.VirtualMachineRelocateSpec{Host: &ref}, types.VirtualMachineMovePriorityDefaultPriority)
+	})
+	if err != nil {
+		return current.Name, "", fmt.Errorf("error migrating virtual machine to host %s: %s", target.Name, err)
+	}
+	return current.Name, target.Name, nil
+}
+
+// HostAddresses returns the IP addresses of the VMkernel network adapters of
+// the host of the virtual machine, or the name of the host if no address is
+// configured.
+func (vm *VirtualMachineDriver) HostAddresses() ([]string, error) {
+	info, err := vm.Info("runtime.host")
+	if err != nil {
+		return nil, err
+	}
+	if info.Runtime.Host == nil {
+		return nil, fmt.Errorf("virtual machine is not on a host")
+	}
+	host, err := vm.driver.NewHost(info.Runtime.Host).Info("name", "config.network.vnic")
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	if host.Config != nil && host.Config.Network != nil {
+		for _, vnic := range host.Config.Network.Vnic {
+			if vnic.Spec.Ip != nil && vnic.Spec.Ip.IpAddress != "" {
+				addresses = append(addresses, vnic.Spec.Ip.IpAddress)
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		addresses = append(addresses, host.Name)
+	}
+	return addresses, nil
+}
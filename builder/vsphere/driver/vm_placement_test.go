@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestIsInsufficientResourcesError(t *testing.T) {
+	tc := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "Insufficient memory",
+			err:      task.Error{LocalizedMethodFault: &types.LocalizedMethodFault{Fault: &types.InsufficientMemoryResourcesFault{}}},
+			expected: true,
+		},
+		{
+			name:     "Admission control",
+			err:      task.Error{LocalizedMethodFault: &types.LocalizedMethodFault{Fault: &types.InsufficientFailoverResourcesFault{}}},
+			expected: true,
+		},
+		{
+			name: "Invalid power state",
+			err:  task.Error{LocalizedMethodFault: &types.LocalizedMethodFault{Fault: &types.InvalidPowerState{}}},
+		},
+		{
+			name: "Other error",
+			err:  fmt.Errorf("connection refused"),
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := IsInsufficientResourcesError(c.err); actual != c.expected {
+				t.Fatalf("unexpected result: expected '%t', but returned '%t'", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineDriver_MigrateToAnotherHost(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, err := sim.driver.FindVM("DC0_C0_RP0_VM0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	from, to, err := vm.MigrateToAnotherHost(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if from == to {
+		t.Fatalf("unexpected result: expected another host than '%s'", from)
+	}
+	info, err := vm.Info("runtime.host")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	host, err := sim.driver.NewHost(info.Runtime.Host).Info("name")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if host.Name != to {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", to, host.Name)
+	}
+
+	// The cluster of the simulator has three hosts.
+	second, third, err := vm.MigrateToAnotherHost([]string{from})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if second != to || third == from || third == to {
+		t.Fatalf("unexpected result: expected a third host, but returned '%s'", third)
+	}
+
+	if _, _, err := vm.MigrateToAnotherHost([]string{from, to}); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestVirtualMachineDriver_HostAddresses(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	addresses, err := vm.HostAddresses()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(addresses) != 1 || addresses[0] != "127.0.0.1" {
+		t.Fatalf("unexpected result: expected '[127.0.0.1]', but returned '%v'", addresses)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// PMemModeDirect attaches persistent memory as a virtual NVDIMM device.
+	PMemModeDirect = "direct"
+	// PMemModeDisk attaches persistent memory as a virtual disk (vPMemDisk)
+	// placed on the PMem datastore of the host.
+	PMemModeDisk = "disk"
+
+	// The identifier of the built-in "Host-local PMem Default Storage Policy",
+	// which is required for devices backed by persistent memory.
+	pmemStoragePolicyID = "c268da1b-b343-49f7-a468-b1deeb7078e0"
+
+	// The minimum virtual hardware version for virtual NVDIMM devices, which
+	// are available with vSphere 6.7 and later.
+	minPMemHardwareVersion = 14
+)
+
+// PMemDevice is a persistent memory device to attach to a virtual machine.
+type PMemDevice struct {
+	// The size of the device in MB.
+	Size int64
+	// The mode of the device. Either PMemModeDirect or PMemModeDisk.
+	Mode string
+}
+
+// pmemDeviceChanges returns the device changes to attach the persistent
+// memory devices to the virtual machine. The host of the virtual machine must
+// support persistent memory and have a PMem datastore with enough free space.
+func (vm *VirtualMachineDriver) pmemDeviceChanges(pmem []PMemDevice) ([]types.BaseVirtualDeviceConfigSpec, error) {
+	if len(pmem) == 0 {
+		return nil, nil
+	}
+
+	ds, err := vm.pmemDatastore(pmem)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := []types.BaseVirtualMachineProfileSpec{
+		&types.VirtualMachineDefinedProfileSpec{ProfileId: pmemStoragePolicyID},
+	}
+	dsPath := fmt.Sprintf("[%s]", ds.Name)
+	dsRef := ds.Reference()
+
+	var changes []types.BaseVirtualDeviceConfigSpec
+	var nvdimmController types.BaseVirtualController
+	for _, device := range pmem {
+		switch device.Mode {
+		case PMemModeDisk:
+			controller, err := devices.FindDiskController("")
+			if err != nil {
+				return nil, fmt.Errorf("error finding a disk controller for persistent memory: %s", err)
+			}
+			disk := devices.CreateDisk(controller, dsRef, "")
+			disk.CapacityInKB = device.Size * 1024
+			backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			backing.FileName = dsPath
+			backing.ThinProvisioned = types.NewBool(false)
+			devices = append(devices, disk)
+			changes = append(changes, &types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+				FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+				Device:        disk,
+				Profile:       profile,
+			})
+		default:
+			if nvdimmController == nil {
+				controllers := devices.SelectByType((*types.VirtualNVDIMMController)(nil))
+				if len(controllers) > 0 {
+					nvdimmController = controllers[0].(types.BaseVirtualController)
+				} else {
+					controller := &types.VirtualNVDIMMController{}
+					controller.Key = devices.NewKey()
+					devices = append(devices, controller)
+					changes = append(changes, &types.VirtualDeviceConfigSpec{
+						Operation: types.VirtualDeviceConfigSpecOperationAdd,
+						Device:    controller,
+					})
+					nvdimmController = controller
+				}
+			}
+			nvdimm := &types.VirtualNVDIMM{
+				VirtualDevice: types.VirtualDevice{
+					Key: devices.NewKey(),
+					Backing: &types.VirtualNVDIMMBackingInfo{
+						VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+							FileName:  dsPath,
+							Datastore: &dsRef,
+						},
+					},
+				},
+				CapacityInMB: device.Size,
+			}
+			devices.AssignController(nvdimm, nvdimmController)
+			devices = append(devices, nvdimm)
+			changes = append(changes, &types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+				FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+				Device:        nvdimm,
+				Profile:       profile,
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+// pmemDatastore verifies that the virtual machine and its host support
+// persistent memory, and returns the PMem datastore of the host.
+func (vm *VirtualMachineDriver) pmemDatastore(pmem []PMemDevice) (*mo.Datastore, error) {
+	info, err := vm.Info("config.version", "runtime.host")
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(info.Config.Version, "vmx-"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the virtual hardware version %q: %s", info.Config.Version, err)
+	}
+	if version < minPMemHardwareVersion {
+		return nil, fmt.Errorf("persistent memory requires virtual hardware version %d or later, but the virtual machine uses version %d",
+			minPMemHardwareVersion, version)
+	}
+
+	if info.Runtime.Host == nil {
+		return nil, fmt.Errorf("error finding the host of the virtual machine")
+	}
+	host, err := vm.driver.NewHost(info.Runtime.Host).Info("name", "capability", "datastore")
+	if err != nil {
+		return nil, err
+	}
+	if host.Capability == nil || host.Capability.PMemSupported == nil || !*host.Capability.PMemSupported {
+		return nil, fmt.Errorf("persistent memory is not supported by host %s", host.Name)
+	}
+
+	var datastores []mo.Datastore
+	if len(host.Datastore) > 0 {
+		pc := property.DefaultCollector(vm.driver.vimClient)
+		if err := pc.Retrieve(vm.driver.ctx, host.Datastore, []string{"name", "summary"}, &datastores); err != nil {
+			return nil, err
+		}
+	}
+
+	var required int64
+	for _, device := range pmem {
+		required += device.Size * 1024 * 1024
+	}
+	for _, ds := range datastores {
+		if ds.Summary.Type != string(types.HostFileSystemVolumeFileSystemTypePMEM) {
+			continue
+		}
+		if ds.Summary.FreeSpace < required {
+			return nil, fmt.Errorf("persistent memory requires %d MB, but the PMem datastore %s of host %s has %d MB available",
+				required/1024/1024, ds.Name, host.Name, ds.Summary.FreeSpace/1024/1024)
+		}
+		return &ds, nil
+	}
+
+	return nil, fmt.Errorf("error finding a PMem datastore on host %s", host.Name)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// SerialPortTypeFile writes the output of the serial port to a file.
+	SerialPortTypeFile = "file"
+	// SerialPortTypeNetwork connects the serial port over the network.
+	SerialPortTypeNetwork = "network"
+)
+
+// SerialPort is a serial port to add to a virtual machine.
+type SerialPort struct {
+	// The backing of the serial port. Either SerialPortTypeFile or
+	// SerialPortTypeNetwork.
+	Type string
+	// The datastore path of the file, or the name of a file in the directory
+	// of the virtual machine, or the URI of the network service.
+	Path string
+	// Connect to the URI of the network service instead of listening on it.
+	Client bool
+	// The URI of a virtual serial port concentrator.
+	ProxyURI string
+}
+
+// AddSerialPortFile adds a serial port to the virtual machine that writes the
+// output of the guest operating system to a file with the provided name in
+// the directory of the virtual machine. Returns the datastore path of the
+// file.
+func (vm *VirtualMachineDriver) AddSerialPortFile(name string) (string, error) {
+	filePath, err := vm.pathInDir(name)
+	if err != nil {
+		return "", err
+	}
+
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return "", err
+	}
+	port, err := devices.CreateSerialPort()
+	if err != nil {
+		return "", err
+	}
+	devices.ConnectSerialPort(port, filePath, false, "")
+
+	return filePath, vm.addDevice(port)
+}
+
+// pathInDir returns the datastore path of the file with the provided name in
+// the directory of the virtual machine.
+func (vm *VirtualMachineDriver) pathInDir(name string) (string, error) {
+	info, err := vm.Info("config.files.vmPathName")
+	if err != nil {
+		return "", err
+	}
+
+	var dsPath object.DatastorePath
+	if !dsPath.FromString(info.Config.Files.VmPathName) {
+		return "", fmt.Errorf("error parsing the path of the virtual machine %q", info.Config.Files.VmPathName)
+	}
+	dsPath.Path = path.Join(path.Dir(dsPath.Path), name)
+	return dsPath.String(), nil
+}
+
+// serialPortChanges returns the device changes to add the serial ports to the
+// virtual machine. The serial ports are connected when the virtual machine is
+// powered on.
+func (vm *VirtualMachineDriver) serialPortChanges(ports []SerialPort) ([]types.BaseVirtualDeviceConfigSpec, error) {
+	if len(ports) == 0 {
+		return nil, nil
+	}
+
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []types.BaseVirtualDeviceConfigSpec
+	for i, p := range ports {
+		uri := p.Path
+		if p.Type == SerialPortTypeFile && !strings.HasPrefix(uri, "[") {
+			if uri, err = vm.pathInDir(uri); err != nil {
+				return nil, err
+			}
+		}
+
+		port, err := devices.CreateSerialPort()
+		if err != nil {
+			return nil, fmt.Errorf("error creating serial port %d: %s", i, err)
+		}
+		devices.ConnectSerialPort(port, uri, p.Client, p.ProxyURI)
+		port.Connectable = &types.VirtualDeviceConnectInfo{
+			StartConnected:    true,
+			AllowGuestControl: true,
+		}
+		devices = append(devices, port)
+		changes = append(changes, &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			Device:    port,
+		})
+	}
+	return changes, nil
+}
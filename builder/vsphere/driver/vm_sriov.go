@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// NetworkCardSRIOV is the network card type of the network adapters that are
+// backed by a virtual function of an SR-IOV capable physical adapter of the
+// host.
+const NetworkCardSRIOV = "sriov"
+
+// HasSRIOVNICs returns true if a network adapter is an SR-IOV network adapter.
+func HasSRIOVNICs(nics []NIC) bool {
+	return slices.ContainsFunc(nics, func(nic NIC) bool {
+		return nic.NetworkCard == NetworkCardSRIOV
+	})
+}
+
+// computeConfigTarget returns the devices and the other resources that the
+// compute resource of the resource pool provides to a new virtual machine. If
+// host is not nil, only the devices of the host are returned.
+func (d *VCenterDriver) computeConfigTarget(pool *ResourcePool, host *object.HostSystem) (*types.ConfigTarget, error) {
+	owner, err := pool.pool.Owner(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+	compute := object.NewComputeResource(d.client.Client, owner.Reference())
+	browser, err := compute.EnvironmentBrowser(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, err := browser.QueryConfigTarget(d.ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the devices available on the host: %s", err)
+	}
+	return target, nil
+}
+
+// newSRIOVEthernetCard creates an SR-IOV network adapter that is connected to
+// the network of the backing. If physicalFunction is empty, the physical
+// function is assigned from the SR-IOV device pool of the network when the
+// virtual machine is powered on.
+func newSRIOVEthernetCard(target *types.ConfigTarget, physicalFunction string, backing types.BaseVirtualDeviceBackingInfo) (*types.VirtualSriovEthernetCard, error) {
+	functions := sriovPhysicalFunctions(target)
+	if len(functions) == 0 {
+		return nil, fmt.Errorf("SR-IOV network adapters are not available, since the host has no physical adapters with SR-IOV enabled")
+	}
+
+	card := &types.VirtualSriovEthernetCard{}
+	card.Backing = backing
+	if physicalFunction == "" {
+		return card, nil
+	}
+
+	info, err := findSRIOVPhysicalFunction(functions, physicalFunction)
+	if err != nil {
+		return nil, err
+	}
+	card.SriovBacking = &types.VirtualSriovEthernetCardSriovBackingInfo{
+		PhysicalFunctionBacking: &types.VirtualPCIPassthroughDeviceBackingInfo{
+			Id:       info.PciDevice.Id,
+			DeviceId: fmt.Sprintf("%x", uint16(info.PciDevice.DeviceId)),
+			SystemId: info.SystemId,
+			VendorId: info.PciDevice.VendorId,
+		},
+	}
+	return card, nil
+}
+
+// sriovPhysicalFunctions returns the SR-IOV physical functions of the
+// configuration target. The virtual functions are ignored, since a network
+// adapter is backed by a physical function.
+func sriovPhysicalFunctions(target *types.ConfigTarget) []types.VirtualMachineSriovInfo {
+	var functions []types.VirtualMachineSriovInfo
+	for _, info := range target.Sriov {
+		if !info.VirtualFunction {
+			functions = append(functions, info)
+		}
+	}
+	return functions
+}
+
+// findSRIOVPhysicalFunction returns the SR-IOV physical function with the name
+// of the physical adapter, such as `vmnic4`, or with the PCI address, in the
+// `domain:bus:slot.function` form or the `bus:slot.function` form of the first
+// domain.
+func findSRIOVPhysicalFunction(functions []types.VirtualMachineSriovInfo, physicalFunction string) (*types.VirtualMachineSriovInfo, error) {
+	id := strings.ToLower(physicalFunction)
+	if strings.Count(id, ":") == 1 {
+		id = defaultPCIDomainPrefix + id
+	}
+
+	var available []string
+	for i, info := range functions {
+		if info.Pnic == physicalFunction || strings.ToLower(info.PciDevice.Id) == id {
+			return &functions[i], nil
+		}
+		if info.Pnic != "" {
+			available = append(available, fmt.Sprintf("%s (%s)", info.Pnic, info.PciDevice.Id))
+			continue
+		}
+		available = append(available, info.PciDevice.Id)
+	}
+	return nil, fmt.Errorf("SR-IOV physical function %s is not available on the host (available physical functions: %s)", physicalFunction, strings.Join(available, ", "))
+}
@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVirtualMachineDriver_CreateVMWithSRIOVNetworkAdapter(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, datastore := sim.ChooseSimulatorPreCreatedDatastore()
+
+	config := &CreateConfig{
+		Name:      "mock name",
+		Host:      "DC0_H0",
+		Datastore: datastore.Name,
+		NICs: []NIC{
+			{
+				Network:          "VM Network",
+				NetworkCard:      NetworkCardSRIOV,
+				PhysicalFunction: "vmnic4",
+			},
+		},
+		StorageConfig: StorageConfig{
+			DiskControllerType: []string{"pvscsi"},
+			Storage: []Disk{
+				{
+					DiskSize:            3072,
+					DiskThinProvisioned: true,
+					ControllerIndex:     0,
+				},
+			},
+		},
+	}
+
+	_, err = sim.driver.CreateVM(config)
+	if err == nil || !strings.Contains(err.Error(), "the host has no physical adapters with SR-IOV enabled") {
+		t.Fatalf("unexpected result: expected a missing SR-IOV physical adapter error, but returned '%v'", err)
+	}
+
+	host, err := sim.driver.FindHost("DC0_H0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := host.Info("parent")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	compute := simulator.Map.Get(*info.Parent).(*mo.ComputeResource)
+	browser := simulator.Map.Get(*compute.EnvironmentBrowser).(*simulator.EnvironmentBrowser)
+	browser.QueryConfigTargetResponse.Returnval = &types.ConfigTarget{
+		Sriov: []types.VirtualMachineSriovInfo{
+			{
+				VirtualMachinePciPassthroughInfo: types.VirtualMachinePciPassthroughInfo{
+					PciDevice: types.HostPciDevice{
+						Id:       "0000:3b:00.0",
+						DeviceId: 0x1017,
+						VendorId: 0x15b3,
+					},
+					SystemId: "5f0c7a2e-1b5d-4c39-8d9f-0cc47a6b1e2a",
+				},
+				Pnic: "vmnic4",
+			},
+			{
+				VirtualMachinePciPassthroughInfo: types.VirtualMachinePciPassthroughInfo{
+					PciDevice: types.HostPciDevice{Id: "0000:3b:02.0"},
+				},
+				VirtualFunction: true,
+			},
+		},
+	}
+
+	vm, err := sim.driver.CreateVM(config)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	cards := devices.SelectByType((*types.VirtualSriovEthernetCard)(nil))
+	if len(cards) != 1 {
+		t.Fatalf("unexpected result: expected 1 SR-IOV network adapter, but returned %d", len(cards))
+	}
+	card := cards[0].(*types.VirtualSriovEthernetCard)
+	if card.SriovBacking == nil || card.SriovBacking.PhysicalFunctionBacking == nil ||
+		card.SriovBacking.PhysicalFunctionBacking.Id != "0000:3b:00.0" {
+		t.Fatalf("unexpected result: expected physical function '0000:3b:00.0', but returned '%#v'", card.SriovBacking)
+	}
+}
+
+func TestFindSRIOVPhysicalFunction(t *testing.T) {
+	functions := sriovPhysicalFunctions(&types.ConfigTarget{
+		Sriov: []types.VirtualMachineSriovInfo{
+			{
+				VirtualMachinePciPassthroughInfo: types.VirtualMachinePciPassthroughInfo{
+					PciDevice: types.HostPciDevice{Id: "0000:3b:00.0"},
+				},
+				Pnic: "vmnic4",
+			},
+			{
+				VirtualMachinePciPassthroughInfo: types.VirtualMachinePciPassthroughInfo{
+					PciDevice: types.HostPciDevice{Id: "0000:3b:00.1"},
+				},
+			},
+			{
+				VirtualMachinePciPassthroughInfo: types.VirtualMachinePciPassthroughInfo{
+					PciDevice: types.HostPciDevice{Id: "0000:3b:02.0"},
+				},
+				VirtualFunction: true,
+			},
+		},
+	})
+
+	for _, physicalFunction := range []string{"vmnic4", "0000:3b:00.0", "3B:00.0"} {
+		info, err := findSRIOVPhysicalFunction(functions, physicalFunction)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if info.PciDevice.Id != "0000:3b:00.0" {
+			t.Fatalf("unexpected result: expected physical function '0000:3b:00.0' for '%s', but returned '%s'", physicalFunction, info.PciDevice.Id)
+		}
+	}
+
+	_, err := findSRIOVPhysicalFunction(functions, "0000:3b:02.0")
+	if err == nil || !strings.Contains(err.Error(), "available physical functions: vmnic4 (0000:3b:00.0), 0000:3b:00.1") {
+		t.Fatalf("unexpected result: expected a missing physical function error, but returned '%v'", err)
+	}
+}
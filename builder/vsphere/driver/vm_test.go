@@ -5,8 +5,12 @@ package driver
 
 import (
 	"context"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -17,7 +21,10 @@ func TestVirtualMachineDriver_Configure(t *testing.T) {
 	}
 	defer sim.Close()
 
-	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	setSimulatorConfigTarget(machine, &types.ConfigTarget{
+		SharedGpuPassthroughTypes: []types.VirtualMachinePciSharedGpuPassthroughInfo{{Vgpu: "grid_m10-8q"}},
+	})
 
 	// Happy test
 	hardwareConfig := &HardwareConfig{
@@ -28,7 +35,7 @@ func TestVirtualMachineDriver_Configure(t *testing.T) {
 		RAM:                   1024,
 		RAMReserveAll:         true,
 		VideoRAM:              512,
-		VGPUProfile:           "grid_m10-8q",
+		VGPUProfiles:          []string{"grid_m10-8q"},
 		Firmware:              "efi-secure",
 		ForceBIOSSetup:        true,
 		VTPMEnabled:           true,
@@ -39,6 +46,87 @@ func TestVirtualMachineDriver_Configure(t *testing.T) {
 	}
 }
 
+func TestVirtualMachineDriver_ConfigureWatchdogTimer(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+
+	hardwareConfig := &HardwareConfig{
+		WatchdogTimer:          true,
+		WatchdogTimerRunOnBoot: true,
+	}
+	err = vm.Configure(hardwareConfig)
+	if err == nil || !strings.Contains(err.Error(), "requires virtual hardware version 17 or later") {
+		t.Fatalf("unexpected result: expected a virtual hardware version error, but returned '%v'", err)
+	}
+
+	machine.Config.Version = "vmx-17"
+	if err = vm.Configure(hardwareConfig); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	watchdogs := devices.SelectByType((*types.VirtualWDT)(nil))
+	if len(watchdogs) != 1 {
+		t.Fatalf("unexpected result: expected '1' watchdog timer, but returned '%d'", len(watchdogs))
+	}
+	if !watchdogs[0].(*types.VirtualWDT).RunOnBoot {
+		t.Fatalf("unexpected result: expected the watchdog timer to run on boot")
+	}
+}
+
+func TestVirtualMachineDriver_ConfigureSGXWithoutHostSupport(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	machine.Config.Version = "vmx-17"
+
+	err = vm.Configure(&HardwareConfig{
+		Firmware:   "efi",
+		SGXEpcSize: 64,
+	})
+	if err == nil || !strings.Contains(err.Error(), "requires SGX to be enabled on host") {
+		t.Fatalf("unexpected result: expected a host SGX error, but returned '%v'", err)
+	}
+}
+
+func TestVirtualMachineDriver_ConfigurePMemWithoutHostSupport(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+
+	machine.Config.Version = "vmx-13"
+	err = vm.Configure(&HardwareConfig{
+		PMem: []PMemDevice{{Size: 4096, Mode: PMemModeDirect}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "requires virtual hardware version 14 or later") {
+		t.Fatalf("unexpected result: expected a hardware version error, but returned '%v'", err)
+	}
+
+	machine.Config.Version = "vmx-14"
+	err = vm.Configure(&HardwareConfig{
+		PMem: []PMemDevice{{Size: 4096, Mode: PMemModeDirect}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "persistent memory is not supported by host") {
+		t.Fatalf("unexpected result: expected a host persistent memory error, but returned '%v'", err)
+	}
+}
+
 func TestVirtualMachineDriver_CreateVMWithMultipleDisks(t *testing.T) {
 	sim, err := NewVCenterSimulator()
 	if err != nil {
@@ -221,3 +309,436 @@ func TestVirtualMachineDriver_CloneWithMacAddress(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s', but returned '%s'", newMacAddress, network.MacAddress)
 	}
 }
+
+func TestVirtualMachineDriver_FindNetworkWithSwitch(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	network, err := findNetwork("DC0_DVPG0", "", "DVS0", sim.driver)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if network.Reference().Type != "DistributedVirtualPortgroup" {
+		t.Fatalf("unexpected result: expected 'DistributedVirtualPortgroup', but returned '%s'", network.Reference().Type)
+	}
+
+	if _, err = findNetwork("DC0_DVPG0", "", "missing-switch", sim.driver); err == nil {
+		t.Fatalf("expected an error when the network is not owned by the switch")
+	}
+
+	if _, err = findNetwork("VM Network", "", "DVS0", sim.driver); err == nil {
+		t.Fatalf("expected an error when a standard network is scoped to a switch")
+	}
+}
+
+func TestVirtualMachineDriver_SetNetworkAdaptersConnected(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	if err := vm.SetNetworkAdaptersConnected([]int{0}, false); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	adapter := devices.SelectByType((*types.VirtualEthernetCard)(nil))[0].GetVirtualDevice()
+	if adapter.Connectable == nil || adapter.Connectable.StartConnected || adapter.Connectable.Connected {
+		t.Fatalf("unexpected result: expected the network adapter to be disconnected, but returned '%+v'", adapter.Connectable)
+	}
+
+	if err := vm.SetNetworkAdaptersConnected([]int{1}, true); err == nil {
+		t.Fatalf("unexpected success: expected an error for a missing network adapter")
+	}
+}
+
+func TestVirtualMachineDriver_MissingPrivileges(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	missing, err := vm.MissingPrivileges("VirtualMachine.State.CreateSnapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(missing) != 0 {
+		t.Fatalf("unexpected result: expected no missing privileges, but returned '%v'", missing)
+	}
+}
+
+func TestVirtualMachineDriver_CloneWithLinkedCloneSnapshot(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, datastore := sim.ChooseSimulatorPreCreatedDatastore()
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	for _, name := range []string{"base", "updated"} {
+		if err := vm.CreateSnapshot(name); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	config := &CloneConfig{
+		Name:                "mock name",
+		Host:                "DC0_H0",
+		Datastore:           datastore.Name,
+		LinkedClone:         true,
+		LinkedCloneSnapshot: "base",
+	}
+	if _, err := vm.Clone(context.TODO(), config); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	config.Name = "mock name 2"
+	config.LinkedCloneSnapshot = "missing"
+	_, err = vm.Clone(context.TODO(), config)
+	if err == nil {
+		t.Fatalf("unexpected success: expected an error for a missing snapshot")
+	}
+	if !strings.Contains(err.Error(), "available snapshots: base, updated") {
+		t.Fatalf("unexpected error: expected the available snapshots to be listed, but returned '%s'", err)
+	}
+}
+
+func TestVirtualMachineDriver_RemoveAllSnapshots(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	names, err := vm.ListSnapshots()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(names) != 0 {
+		t.Fatalf("unexpected result: expected no snapshots, but returned '%v'", names)
+	}
+
+	for _, name := range []string{"base", "updated"} {
+		if err := vm.CreateSnapshotWithOptions(name, "Created by the test", false); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	names, err = vm.ListSnapshots()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !cmp.Equal(names, []string{"base", "updated"}) {
+		t.Fatalf("unexpected result: expected snapshots '[base updated]', but returned '%v'", names)
+	}
+
+	if err := vm.RemoveAllSnapshots(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	names, err = vm.ListSnapshots()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(names) != 0 {
+		t.Fatalf("unexpected result: expected no snapshots, but returned '%v'", names)
+	}
+}
+
+func TestFilterIPs(t *testing.T) {
+	ips := []string{"fe80::250:56ff:fe8a:1", "169.254.10.1", "10.0.0.5", "2001:db8::5", "172.17.0.1", "192.168.1.10"}
+	_, ipNet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, ipv6Net, err := net.ParseCIDR("::/0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, bridgeNet, err := net.ParseCIDR("172.17.0.0/16")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name     string
+		filter   *IPFilter
+		expected []string
+	}{
+		{"No network range", &IPFilter{}, []string{"10.0.0.5", "172.17.0.1", "192.168.1.10", "169.254.10.1"}},
+		{"IPv4 network range", &IPFilter{Network: ipNet}, []string{"192.168.1.10"}},
+		{"IPv6 network range", &IPFilter{Network: ipv6Net}, []string{"2001:db8::5", "fe80::250:56ff:fe8a:1"}},
+		{"IPv6", &IPFilter{Version: IPVersion6}, []string{"2001:db8::5", "fe80::250:56ff:fe8a:1"}},
+		{"Any IP version", &IPFilter{Version: IPVersionAny}, []string{"10.0.0.5", "172.17.0.1", "192.168.1.10", "2001:db8::5", "fe80::250:56ff:fe8a:1", "169.254.10.1"}},
+		{"Excluded network range", &IPFilter{Exclude: []*net.IPNet{bridgeNet}}, []string{"10.0.0.5", "192.168.1.10", "169.254.10.1"}},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if diff := cmp.Diff(c.expected, filterIPs(ips, c.filter)); diff != "" {
+				t.Fatalf("unexpected result: '%s'", diff)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineDriver_SetManagedBy(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.SetManagedBy("com.example.packer", "build"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := vm.Info("config.managedBy")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Config.ManagedBy == nil || info.Config.ManagedBy.ExtensionKey != "com.example.packer" || info.Config.ManagedBy.Type != "build" {
+		t.Fatalf("unexpected result: expected the virtual machine to be managed by '%s'", "com.example.packer")
+	}
+
+	if err := vm.SetManagedBy("", ""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err = vm.Info("config.managedBy")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Config != nil && info.Config.ManagedBy != nil && info.Config.ManagedBy.ExtensionKey != "" {
+		t.Fatalf("unexpected result: expected the managed by mark to be removed")
+	}
+}
+
+func TestVirtualMachineDriver_SetBootOptions(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	options := &BootOptions{Order: []string{"cdrom", "disk"}, EnterSetup: true, Delay: 2 * time.Second, RetryDelay: 10 * time.Second}
+	if err := vm.SetBootOptions(options); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := vm.Info("config.bootOptions")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	bootOptions := info.Config.BootOptions
+	if bootOptions.BootDelay != 2000 || bootOptions.BootRetryDelay != 10000 || !*bootOptions.BootRetryEnabled {
+		t.Fatalf("unexpected result: expected a boot delay of '2000' and a boot retry delay of '10000', but returned '%d' and '%d'", bootOptions.BootDelay, bootOptions.BootRetryDelay)
+	}
+	if len(bootOptions.BootOrder) != 2 {
+		t.Fatalf("unexpected result: expected '2' boot devices, but returned '%d'", len(bootOptions.BootOrder))
+	}
+
+	pending, err := vm.EnterSetupPending()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !pending {
+		t.Fatal("unexpected result: expected the firmware setup to be pending")
+	}
+
+	// The virtual machine is powered on in the simulator.
+	if err := vm.Reset(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestVirtualMachineDriver_ConfigureSerialPorts(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	err = vm.Configure(&HardwareConfig{
+		SerialPorts: []SerialPort{
+			{Type: SerialPortTypeFile, Path: "console.log"},
+			{Type: SerialPortTypeNetwork, Path: "telnet://:23000"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	ports := devices.SelectByType((*types.VirtualSerialPort)(nil))
+	if len(ports) != 2 {
+		t.Fatalf("unexpected result: expected '2' serial ports, but returned '%d'", len(ports))
+	}
+	file, ok := ports[0].GetVirtualDevice().Backing.(*types.VirtualSerialPortFileBackingInfo)
+	if !ok || !strings.HasSuffix(file.FileName, "/console.log") {
+		t.Fatalf("unexpected result: expected a file in the directory of the virtual machine, but returned '%#v'", ports[0].GetVirtualDevice().Backing)
+	}
+	network, ok := ports[1].GetVirtualDevice().Backing.(*types.VirtualSerialPortURIBackingInfo)
+	if !ok || network.ServiceURI != "telnet://:23000" || network.Direction != "server" {
+		t.Fatalf("unexpected result: expected a network backing, but returned '%#v'", ports[1].GetVirtualDevice().Backing)
+	}
+}
+
+func TestVirtualMachineDriver_ResetVAppProperties(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	err = vm.Reconfigure(types.VirtualMachineConfigSpec{
+		VAppConfig: &types.VmConfigSpec{
+			Property: []types.VAppPropertySpec{
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info:            &types.VAppPropertyInfo{Key: 1, Id: "public-keys", Value: "ssh-rsa AAAA", UserConfigurable: types.NewBool(true)},
+				},
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info:            &types.VAppPropertyInfo{Key: 2, Id: "hostname", Value: "packer", UserConfigurable: types.NewBool(true)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	if err := vm.ResetVAppProperties([]string{"public-keys"}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := vm.Info("config.vAppConfig")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	values := make(map[string]string)
+	for _, p := range info.Config.VAppConfig.GetVmConfigInfo().Property {
+		values[p.Id] = p.Value
+	}
+	if diff := cmp.Diff(map[string]string{"public-keys": "", "hostname": "packer"}, values); diff != "" {
+		t.Fatalf("unexpected vApp properties: '%s'", diff)
+	}
+
+	if err := vm.ResetVAppProperties([]string{"password"}); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestVirtualMachineDriver_networkAdapterMAC(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	vmDriver := vm.(*VirtualMachineDriver)
+	mac, err := vmDriver.networkAdapterMAC(0)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if mac == "" {
+		t.Fatal("unexpected empty MAC address")
+	}
+	if _, err := vmDriver.networkAdapterMAC(10); err == nil {
+		t.Fatal("unexpected success: expected an error for a missing network adapter")
+	}
+}
+
+func TestVirtualMachineDriver_UpgradeHardwareVersion(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+	if err := vm.PowerOff(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	machine.Config.Version = "vmx-13"
+
+	if err := vm.UpgradeHardwareVersion(11); err == nil {
+		t.Fatal("unexpected success: expected the downgrade to fail")
+	}
+	if err := vm.UpgradeHardwareVersion(99); err == nil {
+		t.Fatal("unexpected success: expected the version to be unsupported")
+	}
+	if err := vm.UpgradeHardwareVersion(13); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	if err := vm.UpgradeHardwareVersion(19); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := vm.Info("config.version")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Config.Version != "vmx-19" {
+		t.Errorf("unexpected result: expected 'vmx-19', but returned %q", info.Config.Version)
+	}
+}
+
+func TestVirtualMachineDriver_CloneWithNetworkSwitch(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, datastore := sim.ChooseSimulatorPreCreatedDatastore()
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	config := &CloneConfig{
+		Name:          "mock name",
+		Host:          "DC0_H0",
+		Datastore:     datastore.Name,
+		Network:       "DC0_DVPG0",
+		NetworkSwitch: "DVS0",
+	}
+	clonedVM, err := vm.Clone(context.TODO(), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	devices, err := clonedVM.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	adapter, err := findNetworkAdapter(devices)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := adapter.GetVirtualEthernetCard().Backing.(*types.VirtualEthernetCardDistributedVirtualPortBackingInfo); !ok {
+		t.Fatalf("unexpected result: expected a distributed port group backing, but returned '%T'", adapter.GetVirtualEthernetCard().Backing)
+	}
+
+	config.Name = "mock name 2"
+	config.NetworkSwitch = "missing-switch"
+	if _, err = vm.Clone(context.TODO(), config); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// vgpuDeviceChanges returns the device changes that set the vGPU devices of
+// the virtual machine to the profiles and that add the devices of the vendor
+// device group, and the device group to add. The profiles and the device group
+// are checked against the host of the virtual machine first, since the
+// reconfiguration fails with a generic error if the host does not provide
+// them.
+func (vm *VirtualMachineDriver) vgpuDeviceChanges(profiles []string, deviceGroup string) ([]types.BaseVirtualDeviceConfigSpec, *types.VirtualMachineVirtualDeviceGroups, error) {
+	target, err := vm.configTarget()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkVGPUProfiles(target, profiles); err != nil {
+		return nil, nil, err
+	}
+
+	devices, err := vm.vm.Device(vm.driver.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, devices := vgpuProfileChanges(devices, profiles)
+	if deviceGroup == "" {
+		return changes, nil, nil
+	}
+
+	groupChanges, groups, err := vendorDeviceGroupChanges(target, devices, deviceGroup)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(changes, groupChanges...), groups, nil
+}
+
+// checkVGPUProfiles returns an error if a profile is not one of the vGPU
+// profiles of the shared GPU passthrough devices in the configuration target.
+func checkVGPUProfiles(target *types.ConfigTarget, profiles []string) error {
+	var available []string
+	for _, t := range target.SharedGpuPassthroughTypes {
+		available = append(available, t.Vgpu)
+	}
+	for _, profile := range profiles {
+		if slices.Contains(available, profile) {
+			continue
+		}
+		if len(available) == 0 {
+			return fmt.Errorf("vGPU profile %s is not available, since the host provides no vGPU profiles", profile)
+		}
+		return fmt.Errorf("vGPU profile %s is not available on the host (available profiles: %s)", profile, strings.Join(available, ", "))
+	}
+	return nil
+}
+
+// vgpuProfileChanges returns the device changes that set the vGPU devices to
+// the profiles, in order, and the devices with the added vGPU devices. The
+// existing vGPU devices, such as those of the source of a clone, are edited
+// and the vGPU devices in excess of the profiles are removed.
+func vgpuProfileChanges(devices object.VirtualDeviceList, profiles []string) ([]types.BaseVirtualDeviceConfigSpec, object.VirtualDeviceList) {
+	existing := devices.SelectByType((*types.VirtualPCIPassthrough)(nil)).
+		SelectByBackingInfo((*types.VirtualPCIPassthroughVmiopBackingInfo)(nil))
+
+	var changes []types.BaseVirtualDeviceConfigSpec
+	for i, profile := range profiles {
+		if i < len(existing) {
+			device := existing[i].(*types.VirtualPCIPassthrough)
+			device.Backing = &types.VirtualPCIPassthroughVmiopBackingInfo{Vgpu: profile}
+			changes = append(changes, &types.VirtualDeviceConfigSpec{
+				Device:    device,
+				Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			})
+			log.Printf("Changing vGPU device to profile '%s'", profile)
+			continue
+		}
+
+		device := newVGPUProfile(profile)
+		device.Key = devices.NewKey()
+		devices = append(devices, &device)
+		changes = append(changes, &types.VirtualDeviceConfigSpec{
+			Device:    &device,
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+		})
+		log.Printf("Adding vGPU device with profile '%s'", profile)
+	}
+	for i := len(profiles); i < len(existing); i++ {
+		changes = append(changes, &types.VirtualDeviceConfigSpec{
+			Device:    existing[i],
+			Operation: types.VirtualDeviceConfigSpecOperationRemove,
+		})
+	}
+	return changes, devices
+}
+
+// vendorDeviceGroupChanges returns the device changes that add the component
+// devices of the vendor device group with the name, such as the GPUs that are
+// connected with NVIDIA NVLink, and the device group to add. All devices of a
+// device group are added in the same reconfiguration.
+func vendorDeviceGroupChanges(target *types.ConfigTarget, devices object.VirtualDeviceList, name string) ([]types.BaseVirtualDeviceConfigSpec, *types.VirtualMachineVirtualDeviceGroups, error) {
+	var available []string
+	for _, info := range target.VendorDeviceGroupInfo {
+		if info.DeviceGroupName != name {
+			available = append(available, info.DeviceGroupName)
+			continue
+		}
+
+		// The temporary key of the group is replaced by the server.
+		group := &types.VirtualMachineVirtualDeviceGroupsVendorDeviceGroup{
+			VirtualMachineVirtualDeviceGroupsDeviceGroup: types.VirtualMachineVirtualDeviceGroupsDeviceGroup{
+				GroupInstanceKey: -1,
+				DeviceInfo: &types.Description{
+					Label:   name,
+					Summary: info.DeviceGroupDescription,
+				},
+			},
+			DeviceGroupName: name,
+		}
+
+		var changes []types.BaseVirtualDeviceConfigSpec
+		for _, component := range info.ComponentDeviceInfo {
+			if component.Device == nil {
+				continue
+			}
+			device := component.Device.GetVirtualDevice()
+			var sequence int32
+			if device.DeviceGroupInfo != nil {
+				sequence = device.DeviceGroupInfo.SequenceId
+			}
+			device.Key = devices.NewKey()
+			device.DeviceGroupInfo = &types.VirtualDeviceDeviceGroupInfo{
+				GroupInstanceKey: group.GroupInstanceKey,
+				SequenceId:       sequence,
+			}
+			devices = append(devices, component.Device)
+			changes = append(changes, &types.VirtualDeviceConfigSpec{
+				Device:    component.Device,
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			})
+		}
+		if len(changes) == 0 {
+			return nil, nil, fmt.Errorf("vendor device group %s has no devices", name)
+		}
+		log.Printf("Adding vendor device group '%s' with %d devices", name, len(changes))
+
+		return changes, &types.VirtualMachineVirtualDeviceGroups{
+			DeviceGroup: []types.BaseVirtualMachineVirtualDeviceGroupsDeviceGroup{group},
+		}, nil
+	}
+
+	if len(available) == 0 {
+		return nil, nil, fmt.Errorf("vendor device group %s is not available, since the host provides no vendor device groups", name)
+	}
+	return nil, nil, fmt.Errorf("vendor device group %s is not available on the host (available device groups: %s)", name, strings.Join(available, ", "))
+}
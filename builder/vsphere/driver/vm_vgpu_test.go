@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// setSimulatorConfigTarget sets the configuration target that the environment
+// browser of the simulated virtual machine returns, such as the vGPU profiles
+// and the vendor device groups of the host.
+func setSimulatorConfigTarget(machine *simulator.VirtualMachine, target *types.ConfigTarget) {
+	browser := simulator.Map.Get(machine.EnvironmentBrowser).(*simulator.EnvironmentBrowser)
+	browser.QueryConfigTargetResponse.Returnval = target
+}
+
+func vgpuProfiles(t *testing.T, vm VirtualMachine) []string {
+	devices, err := vm.Devices()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var profiles []string
+	for _, d := range devices.SelectByType((*types.VirtualPCIPassthrough)(nil)) {
+		if backing, ok := d.GetVirtualDevice().Backing.(*types.VirtualPCIPassthroughVmiopBackingInfo); ok {
+			profiles = append(profiles, backing.Vgpu)
+		}
+	}
+	return profiles
+}
+
+func TestVirtualMachineDriver_ConfigureVGPUProfiles(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, machine := sim.ChooseSimulatorPreCreatedVM()
+
+	err = vm.Configure(&HardwareConfig{VGPUProfiles: []string{"grid_a100-20c"}})
+	if err == nil || !strings.Contains(err.Error(), "the host provides no vGPU profiles") {
+		t.Fatalf("unexpected result: expected a missing vGPU profile error, but returned '%v'", err)
+	}
+
+	setSimulatorConfigTarget(machine, &types.ConfigTarget{
+		SharedGpuPassthroughTypes: []types.VirtualMachinePciSharedGpuPassthroughInfo{
+			{Vgpu: "grid_a100-20c"},
+			{Vgpu: "grid_a100-40c"},
+		},
+	})
+	err = vm.Configure(&HardwareConfig{VGPUProfiles: []string{"grid_a100-80c"}})
+	if err == nil || !strings.Contains(err.Error(), "available profiles: grid_a100-20c, grid_a100-40c") {
+		t.Fatalf("unexpected result: expected a missing vGPU profile error, but returned '%v'", err)
+	}
+
+	if err := vm.Configure(&HardwareConfig{VGPUProfiles: []string{"grid_a100-20c", "grid_a100-20c"}}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if profiles := vgpuProfiles(t, vm); len(profiles) != 2 {
+		t.Fatalf("unexpected result: expected 2 vGPU devices, but returned '%v'", profiles)
+	}
+
+	// The existing vGPU devices are changed, and the devices in excess of the
+	// profiles are removed.
+	if err := vm.Configure(&HardwareConfig{VGPUProfiles: []string{"grid_a100-40c"}}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if profiles := vgpuProfiles(t, vm); len(profiles) != 1 || profiles[0] != "grid_a100-40c" {
+		t.Fatalf("unexpected result: expected vGPU profile '[grid_a100-40c]', but returned '%v'", profiles)
+	}
+}
+
+func TestVendorDeviceGroupChanges(t *testing.T) {
+	target := &types.ConfigTarget{
+		VendorDeviceGroupInfo: []types.VirtualMachineVendorDeviceGroupInfo{
+			{
+				DeviceGroupName:        "NVIDIA 2x A100 NVLink",
+				DeviceGroupDescription: "2 GPUs connected with NVLink",
+				ComponentDeviceInfo: []types.VirtualMachineVendorDeviceGroupInfoComponentDeviceInfo{
+					{
+						Type: "pciPassthru",
+						Device: &types.VirtualPCIPassthrough{
+							VirtualDevice: types.VirtualDevice{
+								Backing:         &types.VirtualPCIPassthroughVmiopBackingInfo{Vgpu: "grid_a100-40c"},
+								DeviceGroupInfo: &types.VirtualDeviceDeviceGroupInfo{SequenceId: 1},
+							},
+						},
+					},
+					{
+						Type: "pciPassthru",
+						Device: &types.VirtualPCIPassthrough{
+							VirtualDevice: types.VirtualDevice{
+								Backing:         &types.VirtualPCIPassthroughVmiopBackingInfo{Vgpu: "grid_a100-40c"},
+								DeviceGroupInfo: &types.VirtualDeviceDeviceGroupInfo{SequenceId: 2},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	changes, groups, err := vendorDeviceGroupChanges(target, object.VirtualDeviceList{}, "NVIDIA 2x A100 NVLink")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(changes) != 2 || groups == nil || len(groups.DeviceGroup) != 1 {
+		t.Fatalf("unexpected result: expected 2 devices in 1 group, but returned %d devices and groups '%#v'", len(changes), groups)
+	}
+	group := groups.DeviceGroup[0].(*types.VirtualMachineVirtualDeviceGroupsVendorDeviceGroup)
+	keys := map[int32]bool{}
+	for i, change := range changes {
+		device := change.GetVirtualDeviceConfigSpec().Device.GetVirtualDevice()
+		if device.DeviceGroupInfo.GroupInstanceKey != group.GroupInstanceKey || device.DeviceGroupInfo.SequenceId != int32(i+1) {
+			t.Fatalf("unexpected result: expected device %d in group %d, but returned '%#v'", i+1, group.GroupInstanceKey, device.DeviceGroupInfo)
+		}
+		keys[device.Key] = true
+	}
+	if len(keys) != 2 {
+		t.Fatalf("unexpected result: expected unique device keys, but returned '%v'", keys)
+	}
+
+	_, _, err = vendorDeviceGroupChanges(target, object.VirtualDeviceList{}, "NVIDIA 4x H100 NVLink")
+	if err == nil || !strings.Contains(err.Error(), "available device groups: NVIDIA 2x A100 NVLink") {
+		t.Fatalf("unexpected result: expected a missing device group error, but returned '%v'", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/websocket"
+)
+
+// The RFB protocol version of the WebMKS console.
+const rfbVersion = "RFB 003.008\n"
+
+// The RFB security type without authentication, since the connection is
+// authenticated by the ticket.
+const rfbSecurityNone = 1
+
+// The RFB message type of a key event.
+const rfbKeyEvent = 4
+
+// WebMKS is a connection to the WebMKS console of a virtual machine, which is
+// the console of the web client. Key events are sent as RFB key events with
+// X11 keysyms, like the keyboard of the console.
+type WebMKS struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// AcquireWebMKSTicket acquires a ticket for a connection to the WebMKS
+// console of the virtual machine. The virtual machine must be powered on.
+func (vm *VirtualMachineDriver) AcquireWebMKSTicket() (*types.VirtualMachineTicket, error) {
+	return vm.vm.AcquireTicket(vm.driver.ctx, string(types.VirtualMachineTicketTypeWebmks))
+}
+
+// DialWebMKS connects to the WebMKS console of the ticket and completes the
+// RFB handshake. The certificate of the host is verified against the
+// thumbprint of the ticket, if any.
+func DialWebMKS(ctx context.Context, ticket *types.VirtualMachineTicket) (*WebMKS, error) {
+	port := ticket.Port
+	if port == 0 {
+		port = 443
+	}
+	host := net.JoinHostPort(ticket.Host, fmt.Sprint(port))
+
+	config, err := websocket.NewConfig(fmt.Sprintf("wss://%s/ticket/%s", host, ticket.Ticket), fmt.Sprintf("https://%s", host))
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{"binary"}
+	config.TlsConfig = &tls.Config{ServerName: ticket.Host}
+	if ticket.SslThumbprint != "" {
+		config.TlsConfig.InsecureSkipVerify = true //nolint:gosec
+		config.TlsConfig.VerifyPeerCertificate = verifyThumbprint(ticket.SslThumbprint)
+	}
+
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the WebMKS console: %s", err)
+	}
+	conn.PayloadType = websocket.BinaryFrame
+
+	if err := rfbHandshake(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error connecting to the WebMKS console: %s", err)
+	}
+
+	// The messages of the console, such as bells, are discarded, so that
+	// the console does not block on a full connection.
+	go func() { _, _ = io.Copy(io.Discard, conn) }()
+
+	return &WebMKS{conn: conn}, nil
+}
+
+// verifyThumbprint returns a function that verifies that the SHA-1
+// thumbprint of the certificate of the peer, such as `AB:CD:...`, matches the
+// thumbprint.
+func verifyThumbprint(thumbprint string) func([][]byte, [][]*x509.Certificate) error {
+	return func(certs [][]byte, _ [][]*x509.Certificate) error {
+		if len(certs) == 0 {
+			return fmt.Errorf("no certificate")
+		}
+		sum := sha1.Sum(certs[0]) //nolint:gosec
+		var hex []string
+		for _, b := range sum {
+			hex = append(hex, fmt.Sprintf("%02X", b))
+		}
+		if !strings.EqualFold(strings.Join(hex, ":"), thumbprint) {
+			return fmt.Errorf("certificate thumbprint does not match %s", thumbprint)
+		}
+		return nil
+	}
+}
+
+// rfbHandshake negotiates the protocol version and the security type, and
+// initializes a shared session.
+func rfbHandshake(rw io.ReadWriter) error {
+	version := make([]byte, len(rfbVersion))
+	if _, err := io.ReadFull(rw, version); err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(version, []byte("RFB ")) {
+		return fmt.Errorf("unexpected protocol version %q", version)
+	}
+	if _, err := rw.Write([]byte(rfbVersion)); err != nil {
+		return err
+	}
+
+	var count uint8
+	if err := binary.Read(rw, binary.BigEndian, &count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("connection refused: %s", rfbReason(rw))
+	}
+	securityTypes := make([]byte, count)
+	if _, err := io.ReadFull(rw, securityTypes); err != nil {
+		return err
+	}
+	if !bytes.Contains(securityTypes, []byte{rfbSecurityNone}) {
+		return fmt.Errorf("unsupported security types %v", securityTypes)
+	}
+	if _, err := rw.Write([]byte{rfbSecurityNone}); err != nil {
+		return err
+	}
+	var result uint32
+	if err := binary.Read(rw, binary.BigEndian, &result); err != nil {
+		return err
+	}
+	if result != 0 {
+		return fmt.Errorf("authentication failed: %s", rfbReason(rw))
+	}
+
+	// Share the console with other clients, such as the web client.
+	if _, err := rw.Write([]byte{1}); err != nil {
+		return err
+	}
+	// The server initialization message is the size and the pixel format of
+	// the framebuffer, followed by the name of the desktop.
+	serverInit := make([]byte, 20)
+	if _, err := io.ReadFull(rw, serverInit); err != nil {
+		return err
+	}
+	var nameLength uint32
+	if err := binary.Read(rw, binary.BigEndian, &nameLength); err != nil {
+		return err
+	}
+	_, err := io.CopyN(io.Discard, rw, int64(nameLength))
+	return err
+}
+
+// rfbReason reads the reason of a failure.
+func rfbReason(r io.Reader) string {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "unknown reason"
+	}
+	reason := make([]byte, length)
+	if _, err := io.ReadFull(r, reason); err != nil {
+		return "unknown reason"
+	}
+	return string(reason)
+}
+
+// KeyEvent presses or releases the key of the X11 keysym.
+func (w *WebMKS) KeyEvent(keysym uint32, down bool) error {
+	msg := make([]byte, 8)
+	msg[0] = rfbKeyEvent
+	if down {
+		msg[1] = 1
+	}
+	binary.BigEndian.PutUint32(msg[4:], keysym)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// Close closes the connection to the console.
+func (w *WebMKS) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/websocket"
+)
+
+type webmksKeyEvent struct {
+	Keysym uint32
+	Down   bool
+}
+
+// newWebMKSServer returns a WebMKS console that records the key events, and
+// a ticket for a connection to it.
+func newWebMKSServer(t *testing.T, events chan<- webmksKeyEvent) (*httptest.Server, *types.VirtualMachineTicket) {
+	server := httptest.NewTLSServer(websocket.Handler(func(ws *websocket.Conn) {
+		ws.PayloadType = websocket.BinaryFrame
+		if ws.Request().URL.Path != "/ticket/secret" {
+			return
+		}
+
+		_, _ = ws.Write([]byte(rfbVersion))
+		version := make([]byte, 12)
+		if _, err := io.ReadFull(ws, version); err != nil {
+			return
+		}
+		_, _ = ws.Write([]byte{1, rfbSecurityNone})
+		securityType := make([]byte, 1)
+		if _, err := io.ReadFull(ws, securityType); err != nil {
+			return
+		}
+		_, _ = ws.Write([]byte{0, 0, 0, 0})
+		shared := make([]byte, 1)
+		if _, err := io.ReadFull(ws, shared); err != nil {
+			return
+		}
+		serverInit := make([]byte, 24)
+		binary.BigEndian.PutUint32(serverInit[20:], 2)
+		_, _ = ws.Write(append(serverInit, "vm"...))
+
+		for {
+			msg := make([]byte, 8)
+			if _, err := io.ReadFull(ws, msg); err != nil {
+				return
+			}
+			if msg[0] == rfbKeyEvent {
+				events <- webmksKeyEvent{Keysym: binary.BigEndian.Uint32(msg[4:]), Down: msg[1] == 1}
+			}
+		}
+	}))
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	p, _ := strconv.Atoi(port)
+
+	sum := sha1.Sum(server.Certificate().Raw) //nolint:gosec
+	var hex []string
+	for _, b := range sum {
+		hex = append(hex, fmt.Sprintf("%02x", b))
+	}
+
+	return server, &types.VirtualMachineTicket{
+		Ticket:        "secret",
+		Host:          host,
+		Port:          int32(p),
+		SslThumbprint: strings.Join(hex, ":"),
+	}
+}
+
+func TestDialWebMKS(t *testing.T) {
+	events := make(chan webmksKeyEvent, 2)
+	server, ticket := newWebMKSServer(t, events)
+	defer server.Close()
+
+	console, err := DialWebMKS(context.TODO(), ticket)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer console.Close()
+
+	if err := console.KeyEvent(0xff0d, true); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := console.KeyEvent(0xff0d, false); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	expected := []webmksKeyEvent{{Keysym: 0xff0d, Down: true}, {Keysym: 0xff0d, Down: false}}
+	received := []webmksKeyEvent{<-events, <-events}
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+}
+
+func TestDialWebMKS_Thumbprint(t *testing.T) {
+	server, ticket := newWebMKSServer(t, make(chan webmksKeyEvent))
+	defer server.Close()
+
+	ticket.SslThumbprint = strings.Repeat("00:", 19) + "00"
+	if _, err := DialWebMKS(context.TODO(), ticket); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// The maximum interval at which the watchdog checks that vCenter Server is
+// reachable.
+const maxWatchdogInterval = 30 * time.Second
+
+// watchdog checks in the background that vCenter Server is reachable, so that
+// a call does not hang until the connection times out when vCenter Server
+// becomes unreachable during a build. It is a round tripper of the vSphere
+// API calls.
+//
+// After vCenter Server has been unreachable for the timeout, the calls in
+// progress are aborted with an error that reports since when vCenter Server
+// has been unreachable. A later call, such as one to clean up after the
+// failed step, waits for vCenter Server to be reachable again for up to the
+// timeout, after which the later calls fail until it is.
+type watchdog struct {
+	next     soap.RoundTripper
+	timeout  time.Duration
+	interval time.Duration
+
+	mu sync.Mutex
+	// The time of the first failed check since vCenter Server was last
+	// reachable, and the error of the last failed check.
+	since   time.Time
+	lastErr error
+	// Whether vCenter Server has been unreachable for the timeout.
+	tripped bool
+	// Whether a call has waited for the timeout for vCenter Server to be
+	// reachable again.
+	gaveUp bool
+	// Closed when the watchdog trips, to abort the calls in progress.
+	abort chan struct{}
+	// Closed when vCenter Server is reachable again after the watchdog trips.
+	restored chan struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newWatchdog returns a watchdog of the round tripper that trips after
+// vCenter Server has been unreachable for the timeout.
+func newWatchdog(next soap.RoundTripper, timeout time.Duration) *watchdog {
+	interval := timeout / 4
+	if interval > maxWatchdogInterval {
+		interval = maxWatchdogInterval
+	}
+	return &watchdog{
+		next:     next,
+		timeout:  timeout,
+		interval: interval,
+		abort:    make(chan struct{}),
+		restored: make(chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// start checks that vCenter Server is reachable at each interval until the
+// watchdog is stopped.
+func (w *watchdog) start() {
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+// Stop stops the background checks.
+func (w *watchdog) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// check requests the current time of vCenter Server, which also keeps the
+// session alive, and records whether it is reachable.
+func (w *watchdog) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.interval)
+	defer cancel()
+	_, err := methods.GetCurrentTime(ctx, w.next)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err == nil {
+		if w.tripped {
+			log.Printf("[INFO] vCenter Server is reachable again after being unreachable since %s", w.since.Format("15:04"))
+			w.tripped = false
+			w.gaveUp = false
+			w.abort = make(chan struct{})
+			close(w.restored)
+		}
+		w.since = time.Time{}
+		w.lastErr = nil
+		return
+	}
+
+	if w.since.IsZero() {
+		w.since = time.Now()
+	}
+	w.lastErr = err
+	if !w.tripped && time.Since(w.since) >= w.timeout {
+		log.Printf("[WARN] vCenter Server unreachable for %s; aborting the calls in progress: %s", w.timeout, err)
+		w.tripped = true
+		w.restored = make(chan struct{})
+		close(w.abort)
+	}
+}
+
+// unreachableErr returns the error of the calls that are aborted because
+// vCenter Server is unreachable. The caller must hold the lock.
+func (w *watchdog) unreachableErr() error {
+	return fmt.Errorf("vCenter Server unreachable since %s: %s", w.since.Format("15:04"), w.lastErr)
+}
+
+func (w *watchdog) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	w.mu.Lock()
+	tripped, gaveUp, restored := w.tripped, w.gaveUp, w.restored
+	w.mu.Unlock()
+
+	if tripped {
+		if gaveUp {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.unreachableErr()
+		}
+		select {
+		case <-restored:
+		case <-time.After(w.timeout):
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.gaveUp = w.tripped
+			return w.unreachableErr()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	w.mu.Lock()
+	abort := w.abort
+	w.mu.Unlock()
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-abort:
+			cancel()
+		case <-callCtx.Done():
+		}
+	}()
+
+	err := w.next.RoundTrip(callCtx, req, res)
+	if err != nil {
+		select {
+		case <-abort:
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.unreachableErr()
+		default:
+		}
+	}
+	return err
+}
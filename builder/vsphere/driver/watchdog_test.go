@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// unreachableVCenter is a round tripper of a vCenter Server that can become
+// unreachable, where the current time fails and other calls hang until they
+// are canceled.
+type unreachableVCenter struct {
+	mu          sync.Mutex
+	unreachable bool
+}
+
+func (v *unreachableVCenter) setUnreachable(unreachable bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.unreachable = unreachable
+}
+
+func (v *unreachableVCenter) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	v.mu.Lock()
+	unreachable := v.unreachable
+	v.mu.Unlock()
+
+	if _, ok := req.(*methods.CurrentTimeBody); ok {
+		if unreachable {
+			return errors.New("connection refused")
+		}
+		res.(*methods.CurrentTimeBody).Res = &types.CurrentTimeResponse{Returnval: time.Now()}
+		return nil
+	}
+	if unreachable {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestWatchdog(t *testing.T) {
+	vc := &unreachableVCenter{}
+	w := newWatchdog(vc, 100*time.Millisecond)
+	w.interval = 10 * time.Millisecond
+	w.start()
+	defer w.Stop()
+
+	ctx := context.Background()
+	req := &methods.RetrieveServiceContentBody{}
+	if err := w.RoundTrip(ctx, req, req); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// A call in progress is aborted after the timeout.
+	vc.setUnreachable(true)
+	start := time.Now()
+	err := w.RoundTrip(ctx, req, req)
+	if err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+	if !strings.Contains(err.Error(), "vCenter Server unreachable since") {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("unexpected result: the call was aborted after %s", elapsed)
+	}
+
+	// A later call waits for vCenter Server to be reachable again.
+	errs := make(chan error)
+	go func() {
+		errs <- w.RoundTrip(ctx, req, req)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	vc.setUnreachable(false)
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestWatchdog_GiveUp(t *testing.T) {
+	vc := &unreachableVCenter{unreachable: true}
+	w := newWatchdog(vc, 50*time.Millisecond)
+	w.interval = 10 * time.Millisecond
+	w.start()
+	defer w.Stop()
+
+	ctx := context.Background()
+	req := &methods.RetrieveServiceContentBody{}
+	if err := w.RoundTrip(ctx, req, req); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+
+	// The first later call waits for the timeout, and the next ones fail
+	// immediately.
+	for i := 0; i < 2; i++ {
+		err := w.RoundTrip(ctx, req, req)
+		if err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Fatalf("unexpected error: '%v'", err)
+		}
+	}
+}
@@ -37,12 +37,59 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	state.Put("hook", hook)
 	state.Put("ui", ui)
 
+	var source string
+	if len(b.config.ISOUrls) > 0 {
+		source = b.config.ISOUrls[0]
+	} else if len(b.config.ISOPaths) > 0 {
+		source = b.config.ISOPaths[0]
+	}
+
+	// With base_template, the guest operating system is installed on the
+	// virtual machine of the base template in the first phase of the build.
+	location, create := b.config.LocationConfig, b.config.CreateConfig
+	if b.config.BaseTemplate != nil {
+		location.VMName = b.config.BaseTemplate.Name
+		create.Destroy = false
+	}
+
 	var steps []multistep.Step
 
 	steps = append(steps,
 		&common.StepConnect{
 			Config: &b.config.ConnectConfig,
 		},
+		&common.StepCheckFingerprint{
+			Config:      &b.config.FingerprintConfig,
+			Location:    &b.config.LocationConfig,
+			Fingerprint: b.config.fingerprint,
+			Force:       b.config.PackerConfig.PackerForce,
+		},
+		&common.StepSelectAffinityHost{
+			Config:    &b.config.HostAffinityConfig,
+			Locations: []*common.LocationConfig{&b.config.LocationConfig, &location},
+		},
+		&common.StepSelectHostLocalDatastore{
+			Location: &b.config.LocationConfig,
+		},
+		&common.StepPreflightChecks{
+			Config:   &b.config.PreflightConfig,
+			Location: &b.config.LocationConfig,
+			Networks: b.config.Networks(),
+			ISOPaths: b.config.ISOPaths,
+		},
+		&common.StepCheckPrivileges{
+			Config:   &b.config.PreflightConfig,
+			Location: &b.config.LocationConfig,
+			Networks: b.config.Networks(),
+		},
+		&common.StepCheckKeyProvider{
+			Config:   &b.config.HardwareConfig,
+			Location: &b.config.LocationConfig,
+		},
+		&common.StepCheckEncryption{
+			Config:   b.config.Encryption,
+			Location: &b.config.LocationConfig,
+		},
 		&common.StepDownload{
 			DownloadStep: &commonsteps.StepDownload{
 				Checksum:    b.config.ISOChecksum,
@@ -66,6 +113,14 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Content: b.config.CDConfig.CDContent,
 			Label:   b.config.CDConfig.CDLabel,
 		},
+		&common.StepCheckDatastoreSpace{
+			Config:             &b.config.DatastoreSpaceConfig,
+			Location:           &b.config.LocationConfig,
+			Hardware:           &b.config.HardwareConfig,
+			Storage:            &b.config.StorageConfig,
+			ContentLibrary:     b.config.ContentLibraryDestinationConfig,
+			UploadsToDatastore: b.config.RemoteCacheDatastore == "" || b.config.RemoteCacheDatastore == b.config.Datastore,
+		},
 		&common.StepRemoteUpload{
 			Datastore:                  b.config.Datastore,
 			Host:                       b.config.Host,
@@ -74,15 +129,49 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			RemoteCacheOverwrite:       b.config.RemoteCacheOverwrite,
 			RemoteCacheDatastore:       b.config.RemoteCacheDatastore,
 			RemoteCachePath:            b.config.RemoteCachePath,
+			CDImage:                    &b.config.CDImageConfig,
 		},
-		&StepCreateVM{
-			Config:   &b.config.CreateConfig,
+		&common.StepWaitForBuildSlot{
+			Config:   &b.config.BuildSlotConfig,
 			Location: &b.config.LocationConfig,
+		},
+		&common.StepRecordCapacity{
+			Config:   &b.config.CapacityConfig,
+			Location: &b.config.LocationConfig,
+		},
+	)
+
+	install := []multistep.Step{
+		&StepCreateVM{
+			Config:   &create,
+			Location: &location,
 			Force:    b.config.PackerConfig.PackerForce,
+			Ctx:      b.config.ctx,
+			Source:   source,
+
+			ConvertToTemplate: b.config.ConvertToTemplate,
+		},
+		&common.StepMarkBuildInProgress{
+			Config: &b.config.BuildSlotConfig,
+		},
+		&common.StepSetManagedBy{
+			Config: &b.config.ManagedByConfig,
+		},
+		&common.StepAddHostAffinityRule{
+			Config: &b.config.HostAffinityConfig,
 		},
 		&common.StepConfigureHardware{
 			Config: &b.config.HardwareConfig,
 		},
+		&common.StepEncryptVM{
+			Config: b.config.Encryption,
+		},
+		&common.StepAttachFirstClassDisks{
+			Config: &b.config.StorageConfig,
+		},
+		&common.StepAddSerialPort{
+			Config: &b.config.SerialLogConfig,
+		},
 		&common.StepAddFlag{
 			FlagConfig: b.config.FlagConfig,
 		},
@@ -92,6 +181,9 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&common.StepConfigParams{
 			Config: &b.config.ConfigParamsConfig,
 		},
+		&common.StepCloudInitGuestinfo{
+			Config: b.config.CloudInitGuestinfo,
+		},
 		&commonsteps.StepCreateFloppy{
 			Files:       b.config.FloppyFiles,
 			Directories: b.config.FloppyDirectories,
@@ -104,7 +196,13 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Host:                       b.config.Host,
 			SetHostForDatastoreUploads: b.config.SetHostForDatastoreUploads,
 		},
-	)
+	}
+
+	if b.config.Export != nil && b.config.BaseTemplate == nil {
+		install = append(install, &common.StepCreateDifferentialBase{
+			SnapshotName: b.config.Export.DifferentialBaseSnapshot,
+		})
+	}
 
 	// Set the address for the HTTP server based on the configuration
 	// provided by the user.
@@ -122,17 +220,24 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	} else {
 		// Use IP discovery if neither HTTPAddress nor HTTPInterface
 		// is specified.
-		steps = append(steps, &common.StepHTTPIPDiscover{
-			HTTPIP:  b.config.BootConfig.HTTPIP,
-			Network: b.config.WaitIpConfig.GetIPNet(),
+		install = append(install, &common.StepHTTPIPDiscover{
+			HTTPIP:    b.config.BootConfig.HTTPIP,
+			Network:   b.config.WaitIpConfig.GetIPNet(),
+			Discovery: b.config.BootConfig.HTTPIPDiscovery,
 		})
 	}
 
-	steps = append(steps,
+	install = append(install,
 		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
 		&common.StepRun{
-			Config:   &b.config.RunConfig,
-			SetOrder: true,
+			Config:      &b.config.RunConfig,
+			SetOrder:    true,
+			NetworkBoot: b.config.HTTPBootURL != "",
+			// The disks are created with the virtual machine.
+			BootDiskControllerType: b.config.StorageConfig.BootDiskControllerType(),
+		},
+		&common.StepCollectCrashDump{
+			Config: &b.config.CrashDumpConfig,
 		},
 		&common.StepBootCommand{
 			Config: &b.config.BootConfig,
@@ -141,24 +246,140 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		},
 	)
 
+	if b.config.BaseTemplate != nil {
+		// The first phase waits for the installation to complete without
+		// running the provisioners and prepares the base template for
+		// cloning.
+		if b.config.Comm.Type != "none" {
+			install = append(install,
+				&common.StepConnectNetworkAdapters{
+					Adapters: b.config.DisconnectedNICs(),
+					Connect:  true,
+				},
+				&common.StepWaitForIp{
+					Config: &b.config.WaitIpConfig,
+					Port:   b.config.Comm.Port(),
+				},
+				&communicator.StepConnect{
+					Config:    &b.config.Comm,
+					Host:      common.CommConnectHost(&b.config.Comm),
+					SSHConfig: b.config.Comm.SSHConfigFunc(),
+				},
+			)
+		}
+		install = append(install,
+			&common.StepShutdown{
+				Config: &b.config.ShutdownConfig,
+			},
+			&common.StepConnectNetworkAdapters{
+				Adapters: b.config.DisconnectedNICs(),
+				Connect:  false,
+			},
+			&common.StepRemoveFloppy{
+				Datastore: b.config.Datastore,
+				Host:      b.config.Host,
+			},
+			&common.StepRemoveCDRom{
+				Config: &common.RemoveCDRomConfig{},
+			},
+			&common.StepClearManagedBy{
+				Config: &b.config.ManagedByConfig,
+			},
+		)
+
+		// The boot of the installation is not repeated on the clone.
+		run := b.config.RunConfig
+		run.FirmwareBoot = nil
+
+		steps = append(steps,
+			&StepInstallBaseTemplate{
+				Config:   b.config.BaseTemplate,
+				Location: &b.config.LocationConfig,
+				Force:    b.config.PackerConfig.PackerForce,
+				Runner:   commonsteps.NewRunnerWithPauseFn(install, b.config.PackerConfig, ui, state),
+			},
+			&StepCloneBaseTemplate{
+				Config:            b.config.BaseTemplate,
+				CreateConfig:      &b.config.CreateConfig,
+				Location:          &b.config.LocationConfig,
+				Force:             b.config.PackerConfig.PackerForce,
+				Ctx:               b.config.ctx,
+				Source:            source,
+				ConvertToTemplate: b.config.ConvertToTemplate,
+			},
+			&common.StepMarkBuildInProgress{
+				Config: &b.config.BuildSlotConfig,
+			},
+			&common.StepSetManagedBy{
+				Config: &b.config.ManagedByConfig,
+			},
+			&common.StepAddHostAffinityRule{
+				Config: &b.config.HostAffinityConfig,
+			},
+		)
+		if b.config.Export != nil {
+			steps = append(steps, &common.StepCreateDifferentialBase{
+				SnapshotName: b.config.Export.DifferentialBaseSnapshot,
+			})
+		}
+		steps = append(steps,
+			&common.StepRun{
+				Config:   &run,
+				SetOrder: false,
+			},
+		)
+	} else {
+		steps = append(steps, install...)
+	}
+
 	if b.config.Comm.Type != "none" {
 		steps = append(steps,
+			&common.StepConnectNetworkAdapters{
+				Adapters: b.config.DisconnectedNICs(),
+				Connect:  true,
+			},
 			&common.StepWaitForIp{
 				Config: &b.config.WaitIpConfig,
+				Port:   b.config.Comm.Port(),
 			},
 			&communicator.StepConnect{
 				Config:    &b.config.Comm,
-				Host:      common.CommHost(b.config.Comm.Host()),
+				Host:      common.CommConnectHost(&b.config.Comm),
 				SSHConfig: b.config.Comm.SSHConfigFunc(),
 			},
 			&commonsteps.StepProvision{},
+			&common.StepWaitForPorts{
+				Config: &b.config.WaitForPortsConfig,
+				Host:   common.CommHost(b.config.Comm.Host()),
+			},
+			&common.StepVerifyNetworks{
+				Config: &b.config.NetworkVerificationConfig,
+			},
+		)
+	} else if b.config.GuestOperations != nil {
+		steps = append(steps,
+			&common.StepGuestOperationsConnect{
+				Config: b.config.GuestOperations,
+			},
+			&commonsteps.StepProvision{},
 		)
 	}
 
 	steps = append(steps,
+		&common.StepSysprep{
+			Config: b.config.Sysprep,
+		},
+		&common.StepCreateSnapshot{
+			Config:         &b.config.SnapshotConfig,
+			BeforeShutdown: true,
+		},
 		&common.StepShutdown{
 			Config: &b.config.ShutdownConfig,
 		},
+		&common.StepConnectNetworkAdapters{
+			Adapters: b.config.DisconnectedNICs(),
+			Connect:  false,
+		},
 		&common.StepRemoveFloppy{
 			Datastore: b.config.Datastore,
 			Host:      b.config.Host,
@@ -173,33 +394,78 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&common.StepRemoveNetworkAdapter{
 			Config: &b.config.RemoveNetworkAdapterConfig,
 		},
+		&common.StepGenerateConfigSnippet{
+			Config: &b.config.ConfigSnippetConfig,
+		},
+		&common.StepDetachFirstClassDisks{
+			Config: &b.config.StorageConfig,
+		},
+		&common.StepRemoveSerialPort{
+			Config: &b.config.SerialLogConfig,
+		},
+		&common.StepClearManagedBy{
+			Config: &b.config.ManagedByConfig,
+		},
+		&common.StepEnableChangeTracking{
+			Config: &b.config.ChangeTrackingConfig,
+		},
 		&common.StepCreateSnapshot{
-			CreateSnapshot: b.config.CreateSnapshot,
-			SnapshotName:   b.config.SnapshotName,
+			Config: &b.config.SnapshotConfig,
+		},
+		&common.StepRecordDevices{
+			Config: &b.config.DeviceLabelsConfig,
 		},
 		&common.StepConvertToTemplate{
 			ConvertToTemplate: b.config.ConvertToTemplate,
 		},
+		&common.StepAttachTags{
+			Config: &b.config.TagsConfig,
+		},
+		&common.StepSetCustomAttributes{
+			Config: &b.config.CustomAttributesConfig,
+		},
+		&common.StepSetFingerprint{
+			Config:      &b.config.FingerprintConfig,
+			Fingerprint: b.config.fingerprint,
+		},
 	)
 
 	if b.config.ContentLibraryDestinationConfig != nil {
 		steps = append(steps, &common.StepImportToContentLibrary{
 			ContentLibConfig: b.config.ContentLibraryDestinationConfig,
+			VMName:           b.config.VMName,
 		})
 	}
 
 	if b.config.Export != nil {
 		steps = append(steps, &common.StepExport{
-			Name:       b.config.Export.Name,
-			Force:      b.config.Export.Force,
-			ImageFiles: b.config.Export.ImageFiles,
-			Manifest:   b.config.Export.Manifest,
-			OutputDir:  b.config.Export.OutputDir.OutputDir,
-			Options:    b.config.Export.Options,
-			Format:     b.config.Export.Format,
+			Name:                      b.config.Export.Name,
+			Force:                     b.config.Export.Force,
+			ImageFiles:                b.config.Export.ImageFiles,
+			Manifest:                  b.config.Export.Manifest,
+			OutputDir:                 b.config.Export.OutputDir.OutputDir,
+			Options:                   b.config.Export.Options,
+			IncludeNvram:              b.config.Export.IncludeNvram,
+			ExcludeDevices:            b.config.Export.ExcludeDevices,
+			Format:                    b.config.Export.Format,
+			ParallelDownloads:         b.config.Export.ParallelDownloads,
+			Layout:                    b.config.Export.Layout,
+			Reproducible:              b.config.Export.Reproducible,
+			SigningKey:                b.config.Export.SigningKey,
+			SigningCertificate:        b.config.Export.SigningCertificate,
+			Timeout:                   b.config.Timeouts.Export,
+			DifferentialBaseSnapshot:  b.config.Export.DifferentialBaseSnapshot,
+			DifferentialBaseDirectory: b.config.Export.DifferentialBaseDirectory,
 		})
 	}
 
+	steps = append(steps, &common.StepRecordCapacity{
+		Config:   &b.config.CapacityConfig,
+		Location: &b.config.LocationConfig,
+		After:    true,
+	})
+
+	steps = common.SkipIfFingerprintMatched(steps)
 	b.runner = commonsteps.NewRunnerWithPauseFn(steps, b.config.PackerConfig, ui, state)
 	b.runner.Run(ctx, state)
 
@@ -219,15 +485,25 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		ContentLibraryConfig: b.config.ContentLibraryDestinationConfig,
 		VM:                   vm,
 		StateData: map[string]interface{}{
-			"generated_data": state.Get("generated_data"),
-			"metadata":       state.Get("metadata"),
-			"SourceImageURL": state.Get("SourceImageURL"),
-			"iso_path":       state.Get("iso_path"),
+			"generated_data":            state.Get("generated_data"),
+			"metadata":                  state.Get("metadata"),
+			"SourceImageURL":            state.Get("SourceImageURL"),
+			"iso_path":                  state.Get("iso_path"),
+			"capacity":                  state.Get("capacity"),
+			"network_verification":      state.Get("network_verification"),
+			"devices":                   state.Get("devices"),
+			"build_fingerprint":         state.Get("build_fingerprint"),
+			"vm_id":                     vm.Reference().Value,
+			"content_library_id":        state.Get("content_library_id"),
+			"content_library_url":       state.Get("content_library_url"),
+			"content_library_item_uuid": state.Get("content_library_item_uuid"),
 		},
 	}
 
 	if b.config.Export != nil {
 		artifact.Outconfig = &b.config.Export.OutputDir
+	} else if b.config.SerialLog {
+		artifact.Outconfig = &common.OutputConfig{OutputDir: b.config.SerialLogConfig.OutputDir()}
 	}
 	return artifact, nil
 }
@@ -7,6 +7,10 @@
 package iso
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+
 	packerCommon "github.com/hashicorp/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
@@ -14,12 +18,20 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The configuration parameters for UEFI HTTP boot.
+const (
+	networkBootProtocolKey = "networkBootProtocol"
+	networkBootURIKey      = "networkBootUri"
 )
 
 type Config struct {
 	packerCommon.PackerConfig `mapstructure:",squash"`
 	commonsteps.HTTPConfig    `mapstructure:",squash"`
 	commonsteps.CDConfig      `mapstructure:",squash"`
+	common.CDImageConfig      `mapstructure:",squash"`
 
 	common.ConnectConfig              `mapstructure:",squash"`
 	CreateConfig                      `mapstructure:",squash"`
@@ -36,16 +48,46 @@ type Config struct {
 	common.RunConfig                  `mapstructure:",squash"`
 	common.BootConfig                 `mapstructure:",squash"`
 	common.WaitIpConfig               `mapstructure:",squash"`
+	common.WaitForPortsConfig         `mapstructure:",squash"`
+	common.NetworkVerificationConfig  `mapstructure:",squash"`
 	Comm                              communicator.Config `mapstructure:",squash"`
 
-	common.ShutdownConfig `mapstructure:",squash"`
+	common.ShutdownConfig         `mapstructure:",squash"`
+	common.ConfigSnippetConfig    `mapstructure:",squash"`
+	common.SerialLogConfig        `mapstructure:",squash"`
+	common.CrashDumpConfig        `mapstructure:",squash"`
+	common.BuildSlotConfig        `mapstructure:",squash"`
+	common.HostAffinityConfig     `mapstructure:",squash"`
+	common.ManagedByConfig        `mapstructure:",squash"`
+	common.TagsConfig             `mapstructure:",squash"`
+	common.CustomAttributesConfig `mapstructure:",squash"`
+	common.ChangeTrackingConfig   `mapstructure:",squash"`
+	common.SnapshotConfig         `mapstructure:",squash"`
+	common.DeviceLabelsConfig     `mapstructure:",squash"`
+	common.DatastoreSpaceConfig   `mapstructure:",squash"`
+	common.CapacityConfig         `mapstructure:",squash"`
+	common.FingerprintConfig      `mapstructure:",squash"`
+	common.PreflightConfig        `mapstructure:",squash"`
+
+	// The URL of an EFI boot image, such as the boot loader of an installer,
+	// to boot the virtual machine from over HTTP or HTTPS with UEFI HTTP boot
+	// instead of an ISO image. For example,
+	// `http://192.168.1.10/ubuntu/grubx64.efi`. Requires `firmware` to be set
+	// to `efi` or `efi-secure`, and a network adapter connected to a network
+	// with DHCP.
+	//
+	// The boot image is loaded by the virtual machine firmware, so the URL
+	// must be reachable from the network of the virtual machine. If
+	// `boot_order` is not set, the boot order is temporarily set to
+	// `disk,ethernet` for the duration of the build, so the virtual machine
+	// boots from the disk after the installation.
+	//
+	// -> **Note:** UEFI HTTP boot for virtual machines requires vSphere 7.0
+	// Update 2 or later. The `networkBootProtocol` and `networkBootUri`
+	// configuration parameters are set on the virtual machine and cannot be
+	// set in `configuration_parameters`.
+	HTTPBootURL string `mapstructure:"http_boot_url"`
 
-	// Create a snapshot of the virtual machine to use as a base for linked clones.
-	// Defaults to `false`.
-	CreateSnapshot bool `mapstructure:"create_snapshot"`
-	// The name of the snapshot when `create_snapshot` is `true`.
-	// Defaults to `Created By Packer`.
-	SnapshotName string `mapstructure:"snapshot_name"`
 	// Convert the virtual machine to a template after the build is complete.
 	// Defaults to `false`.
 	// If set to `true`, the virtual machine can not be imported into a content library.
@@ -57,6 +99,35 @@ type Config struct {
 	// The template will not be imported if no [content library import configuration](#content-library-import-configuration) is specified.
 	// If set, `convert_to_template` must be set to `false`.
 	ContentLibraryDestinationConfig *common.ContentLibraryDestinationConfig `mapstructure:"content_library_destination"`
+	// The timeouts of the long-running steps of the build. Refer to the
+	// [timeouts configuration](#timeouts-configuration) section for more
+	// information.
+	Timeouts common.TimeoutsConfig `mapstructure:"timeouts"`
+	// The cloud-init user data and metadata to pass to the guest operating
+	// system in the `guestinfo` configuration parameters. Refer to the
+	// [cloud-init guestinfo configuration](#cloud-init-guestinfo-configuration)
+	// section for more information.
+	CloudInitGuestinfo *common.CloudInitGuestinfoConfig `mapstructure:"cloud_init_guestinfo"`
+	// The configuration for generalizing a Windows guest operating system
+	// with sysprep before the virtual machine is shut down. Refer to the
+	// [sysprep configuration](#sysprep-configuration) section for more
+	// information.
+	Sysprep *common.SysprepConfig `mapstructure:"sysprep"`
+	// The configuration for encrypting the virtual machine with a key
+	// provider. Refer to the [encryption configuration](#encryption-configuration)
+	// section for more information.
+	Encryption *common.EncryptionConfig `mapstructure:"encryption"`
+	// The configuration for running the provisioners with the guest
+	// operations of VMware Tools when `communicator` is set to `none`. Refer
+	// to the [guest operations configuration](#guest-operations-configuration)
+	// section for more information.
+	GuestOperations *common.GuestOperationsConfig `mapstructure:"guest_operations"`
+	// The configuration for installing the guest operating system to an
+	// intermediate base template and running the provisioners on a linked
+	// clone of the base template. Refer to the
+	// [base template configuration](#base-template-configuration) section for
+	// more information.
+	BaseTemplate *BaseTemplateConfig `mapstructure:"base_template"`
 	// Overwrite files in the local cache if they already exist.
 	// Defaults to `false`.
 	LocalCacheOverwrite bool `mapstructure:"local_cache_overwrite"`
@@ -78,6 +149,8 @@ type Config struct {
 	RemoteCachePath string `mapstructure:"remote_cache_path"`
 
 	ctx interpolate.Context
+	// The build fingerprint of the configuration.
+	fingerprint string
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
@@ -88,6 +161,9 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		InterpolateFilter: &interpolate.RenderFilter{
 			Exclude: []string{
 				"boot_command",
+				"boot_commands",
+				"notes",
+				"cloud_init_guestinfo",
 			},
 		},
 	}, raws...)
@@ -95,6 +171,8 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		return nil, err
 	}
 
+	common.RegisterSensitiveValues(c)
+
 	warnings := make([]string, 0)
 	errs := new(packersdk.MultiError)
 
@@ -104,17 +182,57 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		errs = packersdk.MultiErrorAppend(errs, isoErrs...)
 	}
 
+	errs = packersdk.MultiErrorAppend(errs, c.Timeouts.Prepare(&c.ShutdownConfig, &c.WaitIpConfig)...)
+	if c.Timeouts.Clone != 0 || c.Timeouts.CustomizeWait != 0 {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'timeouts.clone' and 'timeouts.customize_wait' are not supported by this builder"))
+	}
+
 	errs = packersdk.MultiErrorAppend(errs, c.ConnectConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.CreateConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.LocationConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.HardwareConfig.Prepare()...)
+
+	// The memory of a virtual machine with SR-IOV network adapters must be
+	// reserved to power on the virtual machine.
+	if driver.HasSRIOVNICs(c.CreateConfig.driverNICs()) {
+		if c.RAMReservation > 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("SR-IOV network adapters and 'RAM_reservation' cannot be used together"))
+		}
+		if !c.RAMReserveAll {
+			warnings = append(warnings, "'RAM_reserve_all' is set to 'true', since SR-IOV network adapters require all memory to be reserved")
+		}
+		c.RAMReserveAll = true
+	}
+
+	// The configuration parameters are checked before the parameters of the
+	// UEFI HTTP boot are added.
+	configParamsWarnings, configParamsErrs := c.ConfigParamsConfig.Prepare(&c.HardwareConfig, &c.FlagConfig,
+		common.ConfigParamOption{Key: "guestOS", Option: "guest_os_type", Set: c.GuestOSType != ""},
+		common.ConfigParamOption{Key: "virtualHW.version", Option: "vm_version", Set: c.Version != 0},
+	)
+	warnings = append(warnings, configParamsWarnings...)
+	errs = packersdk.MultiErrorAppend(errs, configParamsErrs...)
+	errs = packersdk.MultiErrorAppend(errs, c.prepareHTTPBoot()...)
 	errs = packersdk.MultiErrorAppend(errs, c.FlagConfig.Prepare(&c.HardwareConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.CDRomConfig.Prepare(&c.ReattachCDRomConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, c.CDConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.CDImageConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.RunConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.WaitIpConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.WaitForPortsConfig.Prepare(c.Comm)...)
+	errs = packersdk.MultiErrorAppend(errs, c.NetworkVerificationConfig.Prepare(c.Comm)...)
 	errs = packersdk.MultiErrorAppend(errs, c.Comm.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ConfigSnippetConfig.Prepare(&c.LocationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.BuildSlotConfig.Prepare(&c.LocationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.HostAffinityConfig.Prepare(&c.LocationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ManagedByConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.TagsConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.CustomAttributesConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.DeviceLabelsConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.DatastoreSpaceConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.FingerprintConfig.Prepare()...)
 
 	shutdownWarnings, shutdownErrs := c.ShutdownConfig.Prepare(c.Comm)
 	warnings = append(warnings, shutdownWarnings...)
@@ -123,9 +241,38 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	if c.Export != nil {
 		errs = packersdk.MultiErrorAppend(errs, c.Export.Prepare(&c.ctx, &c.LocationConfig, &c.PackerConfig)...)
 	}
+	errs = packersdk.MultiErrorAppend(errs, c.SnapshotConfig.Prepare(c.Export)...)
+	errs = packersdk.MultiErrorAppend(errs, c.SerialLogConfig.Prepare(c.Export, &c.PackerConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.PrepareSerialLog(&c.SerialLogConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.CrashDumpConfig.Prepare(c.Export, &c.PackerConfig)...)
 	if c.ContentLibraryDestinationConfig != nil {
 		errs = packersdk.MultiErrorAppend(errs, c.ContentLibraryDestinationConfig.Prepare(&c.LocationConfig)...)
 	}
+	errs = packersdk.MultiErrorAppend(errs, c.LocationConfig.PrepareHostLocalDatastore(c.Export, c.ContentLibraryDestinationConfig)...)
+	if c.GuestOperations != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.GuestOperations.Prepare(c.Comm)...)
+	}
+	if c.Sysprep != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.Sysprep.Prepare(c.Comm, c.GuestOperations)...)
+	}
+	if c.Encryption != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.Encryption.Prepare(&c.CreateConfig.StorageConfig)...)
+	}
+	if c.BaseTemplate != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.BaseTemplate.Prepare(&c.LocationConfig, &c.CreateConfig.StorageConfig)...)
+	}
+	if c.CloudInitGuestinfo != nil {
+		errs = packersdk.MultiErrorAppend(errs, c.CloudInitGuestinfo.Prepare(&c.ctx, &c.LocationConfig, &c.ConfigParamsConfig)...)
+	}
+
+	// The fingerprint is computed after the defaults are applied.
+	if c.BuildFingerprint {
+		fingerprint, err := common.Fingerprint(c)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("error computing the build fingerprint: %s", err))
+		}
+		c.fingerprint = fingerprint
+	}
 
 	if len(errs.Errors) > 0 {
 		return warnings, errs
@@ -133,3 +280,46 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	return warnings, nil
 }
+
+// prepareHTTPBoot validates the UEFI HTTP boot URL and sets the configuration
+// parameters that point the firmware of the virtual machine to the URL.
+func (c *Config) prepareHTTPBoot() []error {
+	if c.HTTPBootURL == "" {
+		return nil
+	}
+
+	var errs []error
+
+	u, err := url.Parse(c.HTTPBootURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("'http_boot_url' must be an HTTP or HTTPS URL"))
+	}
+	if c.Firmware != "efi" && c.Firmware != "efi-secure" {
+		errs = append(errs, fmt.Errorf("'http_boot_url' requires 'firmware' to be set to 'efi' or 'efi-secure'"))
+	}
+	for _, key := range []string{networkBootProtocolKey, networkBootURIKey} {
+		if _, ok := c.ConfigParams[key]; ok {
+			errs = append(errs, fmt.Errorf("'configuration_parameters' cannot set '%s' when 'http_boot_url' is set", key))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	protocol := "httpv4"
+	if ip := net.ParseIP(u.Hostname()); ip != nil && ip.To4() == nil {
+		protocol = "httpv6"
+	}
+
+	if c.ConfigParams == nil {
+		c.ConfigParams = make(map[string]string)
+	}
+	c.ConfigParams[networkBootProtocolKey] = protocol
+	c.ConfigParams[networkBootURIKey] = c.HTTPBootURL
+
+	return nil
+}
+
+func (c Config) GoString() string { return common.GoString(c) }
+
+func (c FlatConfig) GoString() string { return common.GoString(c) }
@@ -28,18 +28,41 @@ type FlatConfig struct {
 	CDFiles                         []string                                    `mapstructure:"cd_files" cty:"cd_files" hcl:"cd_files"`
 	CDContent                       map[string]string                           `mapstructure:"cd_content" cty:"cd_content" hcl:"cd_content"`
 	CDLabel                         *string                                     `mapstructure:"cd_label" cty:"cd_label" hcl:"cd_label"`
+	CDImageDatastore                *string                                     `mapstructure:"cd_image_datastore" cty:"cd_image_datastore" hcl:"cd_image_datastore"`
+	CDImagePath                     *string                                     `mapstructure:"cd_image_path" cty:"cd_image_path" hcl:"cd_image_path"`
+	CDImageName                     *string                                     `mapstructure:"cd_image_name" cty:"cd_image_name" hcl:"cd_image_name"`
+	CDImageUploadRetries            *int                                        `mapstructure:"cd_image_upload_retries" cty:"cd_image_upload_retries" hcl:"cd_image_upload_retries"`
+	CDImageCleanup                  *bool                                       `mapstructure:"cd_image_cleanup" cty:"cd_image_cleanup" hcl:"cd_image_cleanup"`
 	VCenterServer                   *string                                     `mapstructure:"vcenter_server" cty:"vcenter_server" hcl:"vcenter_server"`
 	Username                        *string                                     `mapstructure:"username" cty:"username" hcl:"username"`
 	Password                        *string                                     `mapstructure:"password" cty:"password" hcl:"password"`
 	InsecureConnection              *bool                                       `mapstructure:"insecure_connection" cty:"insecure_connection" hcl:"insecure_connection"`
+	CACertFile                      *string                                     `mapstructure:"vcenter_ca_cert_file" cty:"vcenter_ca_cert_file" hcl:"vcenter_ca_cert_file"`
+	CACertPEM                       *string                                     `mapstructure:"vcenter_ca_cert_pem" cty:"vcenter_ca_cert_pem" hcl:"vcenter_ca_cert_pem"`
+	ClientCertFile                  *string                                     `mapstructure:"vcenter_client_cert_file" cty:"vcenter_client_cert_file" hcl:"vcenter_client_cert_file"`
+	ClientKeyFile                   *string                                     `mapstructure:"vcenter_client_key_file" cty:"vcenter_client_key_file" hcl:"vcenter_client_key_file"`
+	SAMLToken                       *string                                     `mapstructure:"vcenter_saml_token" cty:"vcenter_saml_token" hcl:"vcenter_saml_token"`
+	SAMLTokenFile                   *string                                     `mapstructure:"vcenter_saml_token_file" cty:"vcenter_saml_token_file" hcl:"vcenter_saml_token_file"`
+	SessionTicket                   *string                                     `mapstructure:"vcenter_session_ticket" cty:"vcenter_session_ticket" hcl:"vcenter_session_ticket"`
 	Datacenter                      *string                                     `mapstructure:"datacenter" cty:"datacenter" hcl:"datacenter"`
+	SessionCache                    *bool                                       `mapstructure:"session_cache" cty:"session_cache" hcl:"session_cache"`
+	SessionCacheDir                 *string                                     `mapstructure:"session_cache_directory" cty:"session_cache_directory" hcl:"session_cache_directory"`
+	TaskRetryCount                  *int                                        `mapstructure:"task_retry_count" cty:"task_retry_count" hcl:"task_retry_count"`
+	TaskRetryDelay                  *string                                     `mapstructure:"task_retry_delay" cty:"task_retry_delay" hcl:"task_retry_delay"`
+	UnreachableTimeout              *string                                     `mapstructure:"unreachable_timeout" cty:"unreachable_timeout" hcl:"unreachable_timeout"`
+	VCenterAPILogPath               *string                                     `mapstructure:"vcenter_api_log_path" cty:"vcenter_api_log_path" hcl:"vcenter_api_log_path"`
 	Version                         *uint                                       `mapstructure:"vm_version" cty:"vm_version" hcl:"vm_version"`
 	GuestOSType                     *string                                     `mapstructure:"guest_os_type" cty:"guest_os_type" hcl:"guest_os_type"`
 	DiskControllerType              []string                                    `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
 	Storage                         []common.FlatDiskConfig                     `mapstructure:"storage" cty:"storage" hcl:"storage"`
+	FirstClassDisks                 []common.FlatFirstClassDiskConfig           `mapstructure:"first_class_disk" cty:"first_class_disk" hcl:"first_class_disk"`
+	StoragePolicy                   *string                                     `mapstructure:"storage_policy" cty:"storage_policy" hcl:"storage_policy"`
+	AttachDisks                     []string                                    `mapstructure:"attach_disks" cty:"attach_disks" hcl:"attach_disks"`
+	AttachDiskMode                  *string                                     `mapstructure:"attach_disk_mode" cty:"attach_disk_mode" hcl:"attach_disk_mode"`
 	NICs                            []FlatNIC                                   `mapstructure:"network_adapters" cty:"network_adapters" hcl:"network_adapters"`
 	USBController                   []string                                    `mapstructure:"usb_controller" cty:"usb_controller" hcl:"usb_controller"`
 	Notes                           *string                                     `mapstructure:"notes" cty:"notes" hcl:"notes"`
+	AppendNotes                     *bool                                       `mapstructure:"append_notes" cty:"append_notes" hcl:"append_notes"`
 	Destroy                         *bool                                       `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
 	VMName                          *string                                     `mapstructure:"vm_name" cty:"vm_name" hcl:"vm_name"`
 	Folder                          *string                                     `mapstructure:"folder" cty:"folder" hcl:"folder"`
@@ -47,6 +70,7 @@ type FlatConfig struct {
 	Host                            *string                                     `mapstructure:"host" cty:"host" hcl:"host"`
 	ResourcePool                    *string                                     `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
 	Datastore                       *string                                     `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
+	HostLocalDatastore              *bool                                       `mapstructure:"host_local_datastore" cty:"host_local_datastore" hcl:"host_local_datastore"`
 	SetHostForDatastoreUploads      *bool                                       `mapstructure:"set_host_for_datastore_uploads" cty:"set_host_for_datastore_uploads" hcl:"set_host_for_datastore_uploads"`
 	CPUs                            *int32                                      `mapstructure:"CPUs" cty:"CPUs" hcl:"CPUs"`
 	CpuCores                        *int32                                      `mapstructure:"cpu_cores" cty:"cpu_cores" hcl:"cpu_cores"`
@@ -61,12 +85,25 @@ type FlatConfig struct {
 	Displays                        *int32                                      `mapstructure:"displays" cty:"displays" hcl:"displays"`
 	AllowedDevices                  []common.FlatPCIPassthroughAllowedDevice    `mapstructure:"pci_passthrough_allowed_device" cty:"pci_passthrough_allowed_device" hcl:"pci_passthrough_allowed_device"`
 	VGPUProfile                     *string                                     `mapstructure:"vgpu_profile" cty:"vgpu_profile" hcl:"vgpu_profile"`
+	VGPUProfiles                    []string                                    `mapstructure:"vgpu_profiles" cty:"vgpu_profiles" hcl:"vgpu_profiles"`
+	VGPUDeviceGroup                 *string                                     `mapstructure:"vgpu_device_group" cty:"vgpu_device_group" hcl:"vgpu_device_group"`
+	PCIPassthroughDevices           []string                                    `mapstructure:"pci_passthrough_devices" cty:"pci_passthrough_devices" hcl:"pci_passthrough_devices"`
+	PCIPassthroughMMIOSize          *int64                                      `mapstructure:"pci_passthrough_mmio_size" cty:"pci_passthrough_mmio_size" hcl:"pci_passthrough_mmio_size"`
 	NestedHV                        *bool                                       `mapstructure:"NestedHV" cty:"NestedHV" hcl:"NestedHV"`
 	Firmware                        *string                                     `mapstructure:"firmware" cty:"firmware" hcl:"firmware"`
 	ForceBIOSSetup                  *bool                                       `mapstructure:"force_bios_setup" cty:"force_bios_setup" hcl:"force_bios_setup"`
 	VTPMEnabled                     *bool                                       `mapstructure:"vTPM" cty:"vTPM" hcl:"vTPM"`
+	GuestProfile                    *string                                     `mapstructure:"guest_profile" cty:"guest_profile" hcl:"guest_profile"`
 	VirtualPrecisionClock           *string                                     `mapstructure:"precision_clock" cty:"precision_clock" hcl:"precision_clock"`
+	WatchdogTimer                   *bool                                       `mapstructure:"watchdog_timer" cty:"watchdog_timer" hcl:"watchdog_timer"`
+	WatchdogTimerRunOnBoot          *bool                                       `mapstructure:"watchdog_timer_run_on_boot" cty:"watchdog_timer_run_on_boot" hcl:"watchdog_timer_run_on_boot"`
+	SGXEpcSize                      *int64                                      `mapstructure:"sgx_epc_size" cty:"sgx_epc_size" hcl:"sgx_epc_size"`
+	SGXFlcMode                      *string                                     `mapstructure:"sgx_flc_mode" cty:"sgx_flc_mode" hcl:"sgx_flc_mode"`
+	SGXLePubKeyHash                 *string                                     `mapstructure:"sgx_le_pubkey_hash" cty:"sgx_le_pubkey_hash" hcl:"sgx_le_pubkey_hash"`
+	PMem                            []common.FlatPMemConfig                     `mapstructure:"pmem" cty:"pmem" hcl:"pmem"`
+	SerialPorts                     []common.FlatSerialPortConfig               `mapstructure:"serial_ports" cty:"serial_ports" hcl:"serial_ports"`
 	ConfigParams                    map[string]string                           `mapstructure:"configuration_parameters" cty:"configuration_parameters" hcl:"configuration_parameters"`
+	ConfigParamsStrict              *bool                                       `mapstructure:"configuration_parameters_strict" cty:"configuration_parameters_strict" hcl:"configuration_parameters_strict"`
 	ToolsSyncTime                   *bool                                       `mapstructure:"tools_sync_time" cty:"tools_sync_time" hcl:"tools_sync_time"`
 	ToolsUpgradePolicy              *bool                                       `mapstructure:"tools_upgrade_policy" cty:"tools_upgrade_policy" hcl:"tools_upgrade_policy"`
 	VbsEnabled                      *bool                                       `mapstructure:"vbs_enabled" cty:"vbs_enabled" hcl:"vbs_enabled"`
@@ -78,6 +115,7 @@ type FlatConfig struct {
 	TargetExtension                 *string                                     `mapstructure:"iso_target_extension" cty:"iso_target_extension" hcl:"iso_target_extension"`
 	CdromType                       *string                                     `mapstructure:"cdrom_type" cty:"cdrom_type" hcl:"cdrom_type"`
 	ISOPaths                        []string                                    `mapstructure:"iso_paths" cty:"iso_paths" hcl:"iso_paths"`
+	CDRoms                          []common.FlatCDRomDeviceConfig              `mapstructure:"cdroms" cty:"cdroms" hcl:"cdroms"`
 	RemoveCdrom                     *bool                                       `mapstructure:"remove_cdrom" cty:"remove_cdrom" hcl:"remove_cdrom"`
 	ReattachCDRom                   *int                                        `mapstructure:"reattach_cdroms" cty:"reattach_cdroms" hcl:"reattach_cdroms"`
 	RemoveNetworkAdapter            *bool                                       `mapstructure:"remove_network_adapter" cty:"remove_network_adapter" hcl:"remove_network_adapter"`
@@ -87,13 +125,31 @@ type FlatConfig struct {
 	FloppyContent                   map[string]string                           `mapstructure:"floppy_content" cty:"floppy_content" hcl:"floppy_content"`
 	FloppyLabel                     *string                                     `mapstructure:"floppy_label" cty:"floppy_label" hcl:"floppy_label"`
 	BootOrder                       *string                                     `mapstructure:"boot_order" cty:"boot_order" hcl:"boot_order"`
+	FirmwareBoot                    *common.FlatFirmwareBootConfig              `mapstructure:"firmware_boot" cty:"firmware_boot" hcl:"firmware_boot"`
+	PowerOnRetries                  *int                                        `mapstructure:"power_on_retries" cty:"power_on_retries" hcl:"power_on_retries"`
 	BootGroupInterval               *string                                     `mapstructure:"boot_keygroup_interval" cty:"boot_keygroup_interval" hcl:"boot_keygroup_interval"`
 	BootWait                        *string                                     `mapstructure:"boot_wait" cty:"boot_wait" hcl:"boot_wait"`
 	BootCommand                     []string                                    `mapstructure:"boot_command" cty:"boot_command" hcl:"boot_command"`
+	BootCommands                    []common.FlatBootCommandEntry               `mapstructure:"boot_commands" cty:"boot_commands" hcl:"boot_commands"`
+	BootKeyboard                    *string                                     `mapstructure:"boot_keyboard" cty:"boot_keyboard" hcl:"boot_keyboard"`
+	BootKeyInterval                 *string                                     `mapstructure:"boot_key_interval" cty:"boot_key_interval" hcl:"boot_key_interval"`
+	BootKeyRetries                  *int                                        `mapstructure:"boot_key_retries" cty:"boot_key_retries" hcl:"boot_key_retries"`
 	HTTPIP                          *string                                     `mapstructure:"http_ip" cty:"http_ip" hcl:"http_ip"`
+	HTTPIPDiscovery                 *string                                     `mapstructure:"http_ip_discovery" cty:"http_ip_discovery" hcl:"http_ip_discovery"`
 	WaitTimeout                     *string                                     `mapstructure:"ip_wait_timeout" cty:"ip_wait_timeout" hcl:"ip_wait_timeout"`
 	SettleTimeout                   *string                                     `mapstructure:"ip_settle_timeout" cty:"ip_settle_timeout" hcl:"ip_settle_timeout"`
 	WaitAddress                     *string                                     `mapstructure:"ip_wait_address" cty:"ip_wait_address" hcl:"ip_wait_address"`
+	IPVersion                       *string                                     `mapstructure:"ip_version" cty:"ip_version" hcl:"ip_version"`
+	NICIndex                        *int                                        `mapstructure:"ip_wait_nic_index" cty:"ip_wait_nic_index" hcl:"ip_wait_nic_index"`
+	ExcludeCIDRs                    []string                                    `mapstructure:"ip_wait_exclude_cidrs" cty:"ip_wait_exclude_cidrs" hcl:"ip_wait_exclude_cidrs"`
+	ReachabilityCheck               *bool                                       `mapstructure:"ip_reachability_check" cty:"ip_reachability_check" hcl:"ip_reachability_check"`
+	ReachabilityTimeout             *string                                     `mapstructure:"ip_reachability_timeout" cty:"ip_reachability_timeout" hcl:"ip_reachability_timeout"`
+	SelectReachableIP               *bool                                       `mapstructure:"ip_select_reachable" cty:"ip_select_reachable" hcl:"ip_select_reachable"`
+	WaitForPorts                    []int                                       `mapstructure:"wait_for_ports" cty:"wait_for_ports" hcl:"wait_for_ports"`
+	WaitForPortChecks               []common.FlatPortCheckConfig                `mapstructure:"wait_for_port" cty:"wait_for_port" hcl:"wait_for_port"`
+	WaitForPortsTimeout             *string                                     `mapstructure:"wait_for_ports_timeout" cty:"wait_for_ports_timeout" hcl:"wait_for_ports_timeout"`
+	VerifyNetworks                  *bool                                       `mapstructure:"verify_networks" cty:"verify_networks" hcl:"verify_networks"`
+	VerifyNetworksTimeout           *string                                     `mapstructure:"verify_networks_timeout" cty:"verify_networks_timeout" hcl:"verify_networks_timeout"`
 	Type                            *string                                     `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
 	PauseBeforeConnect              *string                                     `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
 	SSHHost                         *string                                     `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
@@ -146,11 +202,46 @@ type FlatConfig struct {
 	Command                         *string                                     `mapstructure:"shutdown_command" cty:"shutdown_command" hcl:"shutdown_command"`
 	Timeout                         *string                                     `mapstructure:"shutdown_timeout" cty:"shutdown_timeout" hcl:"shutdown_timeout"`
 	DisableShutdown                 *bool                                       `mapstructure:"disable_shutdown" cty:"disable_shutdown" hcl:"disable_shutdown"`
+	GenerateConfigSnippet           *bool                                       `mapstructure:"generate_config_snippet" cty:"generate_config_snippet" hcl:"generate_config_snippet"`
+	ConfigSnippetPath               *string                                     `mapstructure:"config_snippet_path" cty:"config_snippet_path" hcl:"config_snippet_path"`
+	SerialLog                       *bool                                       `mapstructure:"serial_log" cty:"serial_log" hcl:"serial_log"`
+	CrashDump                       *bool                                       `mapstructure:"crash_dump" cty:"crash_dump" hcl:"crash_dump"`
+	MaxBuildsPerHost                *int                                        `mapstructure:"max_builds_per_host" cty:"max_builds_per_host" hcl:"max_builds_per_host"`
+	MaxBuildsPerDatastore           *int                                        `mapstructure:"max_builds_per_datastore" cty:"max_builds_per_datastore" hcl:"max_builds_per_datastore"`
+	BuildSlotTimeout                *string                                     `mapstructure:"build_slot_timeout" cty:"build_slot_timeout" hcl:"build_slot_timeout"`
+	BuildSlotStaleAge               *string                                     `mapstructure:"build_slot_stale_age" cty:"build_slot_stale_age" hcl:"build_slot_stale_age"`
+	AffinityHosts                   []string                                    `mapstructure:"affinity_hosts" cty:"affinity_hosts" hcl:"affinity_hosts"`
+	AffinityRulePreferred           *bool                                       `mapstructure:"affinity_rule_preferred" cty:"affinity_rule_preferred" hcl:"affinity_rule_preferred"`
+	ManagedByExtensionKey           *string                                     `mapstructure:"managed_by_extension_key" cty:"managed_by_extension_key" hcl:"managed_by_extension_key"`
+	ManagedByType                   *string                                     `mapstructure:"managed_by_type" cty:"managed_by_type" hcl:"managed_by_type"`
+	Tags                            []common.FlatTagConfig                      `mapstructure:"tags" cty:"tags" hcl:"tags"`
+	CreateTags                      *bool                                       `mapstructure:"create_tags" cty:"create_tags" hcl:"create_tags"`
+	CustomAttributes                map[string]string                           `mapstructure:"custom_attributes" cty:"custom_attributes" hcl:"custom_attributes"`
+	EnableCBT                       *bool                                       `mapstructure:"enable_cbt" cty:"enable_cbt" hcl:"enable_cbt"`
 	CreateSnapshot                  *bool                                       `mapstructure:"create_snapshot" cty:"create_snapshot" hcl:"create_snapshot"`
 	SnapshotName                    *string                                     `mapstructure:"snapshot_name" cty:"snapshot_name" hcl:"snapshot_name"`
+	SnapshotDescription             *string                                     `mapstructure:"snapshot_description" cty:"snapshot_description" hcl:"snapshot_description"`
+	SnapshotMemory                  *bool                                       `mapstructure:"snapshot_memory" cty:"snapshot_memory" hcl:"snapshot_memory"`
+	RemoveExistingSnapshots         *bool                                       `mapstructure:"remove_existing_snapshots" cty:"remove_existing_snapshots" hcl:"remove_existing_snapshots"`
+	DeviceLabels                    map[string]string                           `mapstructure:"device_labels" cty:"device_labels" hcl:"device_labels"`
+	CheckDatastoreSpace             *bool                                       `mapstructure:"check_datastore_space" cty:"check_datastore_space" hcl:"check_datastore_space"`
+	DatastoreSpaceHeadroom          *int                                        `mapstructure:"datastore_space_headroom" cty:"datastore_space_headroom" hcl:"datastore_space_headroom"`
+	RecordCapacity                  *bool                                       `mapstructure:"record_capacity" cty:"record_capacity" hcl:"record_capacity"`
+	BuildFingerprint                *bool                                       `mapstructure:"build_fingerprint" cty:"build_fingerprint" hcl:"build_fingerprint"`
+	SkipIfFingerprintMatches        *bool                                       `mapstructure:"skip_if_fingerprint_matches" cty:"skip_if_fingerprint_matches" hcl:"skip_if_fingerprint_matches"`
+	FingerprintExtra                map[string]string                           `mapstructure:"fingerprint_extra" cty:"fingerprint_extra" hcl:"fingerprint_extra"`
+	PreflightChecks                 *bool                                       `mapstructure:"preflight_checks" cty:"preflight_checks" hcl:"preflight_checks"`
+	CheckPrivileges                 *bool                                       `mapstructure:"check_privileges" cty:"check_privileges" hcl:"check_privileges"`
+	HTTPBootURL                     *string                                     `mapstructure:"http_boot_url" cty:"http_boot_url" hcl:"http_boot_url"`
 	ConvertToTemplate               *bool                                       `mapstructure:"convert_to_template" cty:"convert_to_template" hcl:"convert_to_template"`
 	Export                          *common.FlatExportConfig                    `mapstructure:"export" cty:"export" hcl:"export"`
 	ContentLibraryDestinationConfig *common.FlatContentLibraryDestinationConfig `mapstructure:"content_library_destination" cty:"content_library_destination" hcl:"content_library_destination"`
+	Timeouts                        *common.FlatTimeoutsConfig                  `mapstructure:"timeouts" cty:"timeouts" hcl:"timeouts"`
+	CloudInitGuestinfo              *common.FlatCloudInitGuestinfoConfig        `mapstructure:"cloud_init_guestinfo" cty:"cloud_init_guestinfo" hcl:"cloud_init_guestinfo"`
+	Sysprep                         *common.FlatSysprepConfig                   `mapstructure:"sysprep" cty:"sysprep" hcl:"sysprep"`
+	Encryption                      *common.FlatEncryptionConfig                `mapstructure:"encryption" cty:"encryption" hcl:"encryption"`
+	GuestOperations                 *common.FlatGuestOperationsConfig           `mapstructure:"guest_operations" cty:"guest_operations" hcl:"guest_operations"`
+	BaseTemplate                    *FlatBaseTemplateConfig                     `mapstructure:"base_template" cty:"base_template" hcl:"base_template"`
 	LocalCacheOverwrite             *bool                                       `mapstructure:"local_cache_overwrite" cty:"local_cache_overwrite" hcl:"local_cache_overwrite"`
 	RemoteCacheCleanup              *bool                                       `mapstructure:"remote_cache_cleanup" cty:"remote_cache_cleanup" hcl:"remote_cache_cleanup"`
 	RemoteCacheOverwrite            *bool                                       `mapstructure:"remote_cache_overwrite" cty:"remote_cache_overwrite" hcl:"remote_cache_overwrite"`
@@ -170,151 +261,242 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":              &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":            &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":            &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":                   &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":                   &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":                &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":          &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables":     &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"http_directory":                 &hcldec.AttrSpec{Name: "http_directory", Type: cty.String, Required: false},
-		"http_content":                   &hcldec.AttrSpec{Name: "http_content", Type: cty.Map(cty.String), Required: false},
-		"http_port_min":                  &hcldec.AttrSpec{Name: "http_port_min", Type: cty.Number, Required: false},
-		"http_port_max":                  &hcldec.AttrSpec{Name: "http_port_max", Type: cty.Number, Required: false},
-		"http_bind_address":              &hcldec.AttrSpec{Name: "http_bind_address", Type: cty.String, Required: false},
-		"http_interface":                 &hcldec.AttrSpec{Name: "http_interface", Type: cty.String, Required: false},
-		"cd_files":                       &hcldec.AttrSpec{Name: "cd_files", Type: cty.List(cty.String), Required: false},
-		"cd_content":                     &hcldec.AttrSpec{Name: "cd_content", Type: cty.Map(cty.String), Required: false},
-		"cd_label":                       &hcldec.AttrSpec{Name: "cd_label", Type: cty.String, Required: false},
-		"vcenter_server":                 &hcldec.AttrSpec{Name: "vcenter_server", Type: cty.String, Required: false},
-		"username":                       &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
-		"password":                       &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
-		"insecure_connection":            &hcldec.AttrSpec{Name: "insecure_connection", Type: cty.Bool, Required: false},
-		"datacenter":                     &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
-		"vm_version":                     &hcldec.AttrSpec{Name: "vm_version", Type: cty.Number, Required: false},
-		"guest_os_type":                  &hcldec.AttrSpec{Name: "guest_os_type", Type: cty.String, Required: false},
-		"disk_controller_type":           &hcldec.AttrSpec{Name: "disk_controller_type", Type: cty.List(cty.String), Required: false},
-		"storage":                        &hcldec.BlockListSpec{TypeName: "storage", Nested: hcldec.ObjectSpec((*common.FlatDiskConfig)(nil).HCL2Spec())},
-		"network_adapters":               &hcldec.BlockListSpec{TypeName: "network_adapters", Nested: hcldec.ObjectSpec((*FlatNIC)(nil).HCL2Spec())},
-		"usb_controller":                 &hcldec.AttrSpec{Name: "usb_controller", Type: cty.List(cty.String), Required: false},
-		"notes":                          &hcldec.AttrSpec{Name: "notes", Type: cty.String, Required: false},
-		"destroy":                        &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
-		"vm_name":                        &hcldec.AttrSpec{Name: "vm_name", Type: cty.String, Required: false},
-		"folder":                         &hcldec.AttrSpec{Name: "folder", Type: cty.String, Required: false},
-		"cluster":                        &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
-		"host":                           &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
-		"resource_pool":                  &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
-		"datastore":                      &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
-		"set_host_for_datastore_uploads": &hcldec.AttrSpec{Name: "set_host_for_datastore_uploads", Type: cty.Bool, Required: false},
-		"CPUs":                           &hcldec.AttrSpec{Name: "CPUs", Type: cty.Number, Required: false},
-		"cpu_cores":                      &hcldec.AttrSpec{Name: "cpu_cores", Type: cty.Number, Required: false},
-		"CPU_reservation":                &hcldec.AttrSpec{Name: "CPU_reservation", Type: cty.Number, Required: false},
-		"CPU_limit":                      &hcldec.AttrSpec{Name: "CPU_limit", Type: cty.Number, Required: false},
-		"CPU_hot_plug":                   &hcldec.AttrSpec{Name: "CPU_hot_plug", Type: cty.Bool, Required: false},
-		"RAM":                            &hcldec.AttrSpec{Name: "RAM", Type: cty.Number, Required: false},
-		"RAM_reservation":                &hcldec.AttrSpec{Name: "RAM_reservation", Type: cty.Number, Required: false},
-		"RAM_reserve_all":                &hcldec.AttrSpec{Name: "RAM_reserve_all", Type: cty.Bool, Required: false},
-		"RAM_hot_plug":                   &hcldec.AttrSpec{Name: "RAM_hot_plug", Type: cty.Bool, Required: false},
-		"video_ram":                      &hcldec.AttrSpec{Name: "video_ram", Type: cty.Number, Required: false},
-		"displays":                       &hcldec.AttrSpec{Name: "displays", Type: cty.Number, Required: false},
-		"pci_passthrough_allowed_device": &hcldec.BlockListSpec{TypeName: "pci_passthrough_allowed_device", Nested: hcldec.ObjectSpec((*common.FlatPCIPassthroughAllowedDevice)(nil).HCL2Spec())},
-		"vgpu_profile":                   &hcldec.AttrSpec{Name: "vgpu_profile", Type: cty.String, Required: false},
-		"NestedHV":                       &hcldec.AttrSpec{Name: "NestedHV", Type: cty.Bool, Required: false},
-		"firmware":                       &hcldec.AttrSpec{Name: "firmware", Type: cty.String, Required: false},
-		"force_bios_setup":               &hcldec.AttrSpec{Name: "force_bios_setup", Type: cty.Bool, Required: false},
-		"vTPM":                           &hcldec.AttrSpec{Name: "vTPM", Type: cty.Bool, Required: false},
-		"precision_clock":                &hcldec.AttrSpec{Name: "precision_clock", Type: cty.String, Required: false},
-		"configuration_parameters":       &hcldec.AttrSpec{Name: "configuration_parameters", Type: cty.Map(cty.String), Required: false},
-		"tools_sync_time":                &hcldec.AttrSpec{Name: "tools_sync_time", Type: cty.Bool, Required: false},
-		"tools_upgrade_policy":           &hcldec.AttrSpec{Name: "tools_upgrade_policy", Type: cty.Bool, Required: false},
-		"vbs_enabled":                    &hcldec.AttrSpec{Name: "vbs_enabled", Type: cty.Bool, Required: false},
-		"vvtd_enabled":                   &hcldec.AttrSpec{Name: "vvtd_enabled", Type: cty.Bool, Required: false},
-		"iso_checksum":                   &hcldec.AttrSpec{Name: "iso_checksum", Type: cty.String, Required: false},
-		"iso_url":                        &hcldec.AttrSpec{Name: "iso_url", Type: cty.String, Required: false},
-		"iso_urls":                       &hcldec.AttrSpec{Name: "iso_urls", Type: cty.List(cty.String), Required: false},
-		"iso_target_path":                &hcldec.AttrSpec{Name: "iso_target_path", Type: cty.String, Required: false},
-		"iso_target_extension":           &hcldec.AttrSpec{Name: "iso_target_extension", Type: cty.String, Required: false},
-		"cdrom_type":                     &hcldec.AttrSpec{Name: "cdrom_type", Type: cty.String, Required: false},
-		"iso_paths":                      &hcldec.AttrSpec{Name: "iso_paths", Type: cty.List(cty.String), Required: false},
-		"remove_cdrom":                   &hcldec.AttrSpec{Name: "remove_cdrom", Type: cty.Bool, Required: false},
-		"reattach_cdroms":                &hcldec.AttrSpec{Name: "reattach_cdroms", Type: cty.Number, Required: false},
-		"remove_network_adapter":         &hcldec.AttrSpec{Name: "remove_network_adapter", Type: cty.Bool, Required: false},
-		"floppy_img_path":                &hcldec.AttrSpec{Name: "floppy_img_path", Type: cty.String, Required: false},
-		"floppy_files":                   &hcldec.AttrSpec{Name: "floppy_files", Type: cty.List(cty.String), Required: false},
-		"floppy_dirs":                    &hcldec.AttrSpec{Name: "floppy_dirs", Type: cty.List(cty.String), Required: false},
-		"floppy_content":                 &hcldec.AttrSpec{Name: "floppy_content", Type: cty.Map(cty.String), Required: false},
-		"floppy_label":                   &hcldec.AttrSpec{Name: "floppy_label", Type: cty.String, Required: false},
-		"boot_order":                     &hcldec.AttrSpec{Name: "boot_order", Type: cty.String, Required: false},
-		"boot_keygroup_interval":         &hcldec.AttrSpec{Name: "boot_keygroup_interval", Type: cty.String, Required: false},
-		"boot_wait":                      &hcldec.AttrSpec{Name: "boot_wait", Type: cty.String, Required: false},
-		"boot_command":                   &hcldec.AttrSpec{Name: "boot_command", Type: cty.List(cty.String), Required: false},
-		"http_ip":                        &hcldec.AttrSpec{Name: "http_ip", Type: cty.String, Required: false},
-		"ip_wait_timeout":                &hcldec.AttrSpec{Name: "ip_wait_timeout", Type: cty.String, Required: false},
-		"ip_settle_timeout":              &hcldec.AttrSpec{Name: "ip_settle_timeout", Type: cty.String, Required: false},
-		"ip_wait_address":                &hcldec.AttrSpec{Name: "ip_wait_address", Type: cty.String, Required: false},
-		"communicator":                   &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
-		"pause_before_connecting":        &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
-		"ssh_host":                       &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
-		"ssh_port":                       &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
-		"ssh_username":                   &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
-		"ssh_password":                   &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
-		"ssh_keypair_name":               &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_name":        &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_type":        &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
-		"temporary_key_pair_bits":        &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
-		"ssh_ciphers":                    &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
-		"ssh_clear_authorized_keys":      &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
-		"ssh_key_exchange_algorithms":    &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
-		"ssh_private_key_file":           &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
-		"ssh_certificate_file":           &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
-		"ssh_pty":                        &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
-		"ssh_timeout":                    &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
-		"ssh_wait_timeout":               &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
-		"ssh_agent_auth":                 &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_disable_agent_forwarding":   &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
-		"ssh_handshake_attempts":         &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
-		"ssh_bastion_host":               &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
-		"ssh_bastion_port":               &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
-		"ssh_bastion_agent_auth":         &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_bastion_username":           &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
-		"ssh_bastion_password":           &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
-		"ssh_bastion_interactive":        &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
-		"ssh_bastion_private_key_file":   &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
-		"ssh_bastion_certificate_file":   &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
-		"ssh_file_transfer_method":       &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
-		"ssh_proxy_host":                 &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
-		"ssh_proxy_port":                 &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
-		"ssh_proxy_username":             &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
-		"ssh_proxy_password":             &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
-		"ssh_keep_alive_interval":        &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
-		"ssh_read_write_timeout":         &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
-		"ssh_remote_tunnels":             &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_local_tunnels":              &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_public_key":                 &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
-		"ssh_private_key":                &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
-		"winrm_username":                 &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
-		"winrm_password":                 &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
-		"winrm_host":                     &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
-		"winrm_no_proxy":                 &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
-		"winrm_port":                     &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
-		"winrm_timeout":                  &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
-		"winrm_use_ssl":                  &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
-		"winrm_insecure":                 &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
-		"winrm_use_ntlm":                 &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
-		"shutdown_command":               &hcldec.AttrSpec{Name: "shutdown_command", Type: cty.String, Required: false},
-		"shutdown_timeout":               &hcldec.AttrSpec{Name: "shutdown_timeout", Type: cty.String, Required: false},
-		"disable_shutdown":               &hcldec.AttrSpec{Name: "disable_shutdown", Type: cty.Bool, Required: false},
-		"create_snapshot":                &hcldec.AttrSpec{Name: "create_snapshot", Type: cty.Bool, Required: false},
-		"snapshot_name":                  &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
-		"convert_to_template":            &hcldec.AttrSpec{Name: "convert_to_template", Type: cty.Bool, Required: false},
-		"export":                         &hcldec.BlockSpec{TypeName: "export", Nested: hcldec.ObjectSpec((*common.FlatExportConfig)(nil).HCL2Spec())},
-		"content_library_destination":    &hcldec.BlockSpec{TypeName: "content_library_destination", Nested: hcldec.ObjectSpec((*common.FlatContentLibraryDestinationConfig)(nil).HCL2Spec())},
-		"local_cache_overwrite":          &hcldec.AttrSpec{Name: "local_cache_overwrite", Type: cty.Bool, Required: false},
-		"remote_cache_cleanup":           &hcldec.AttrSpec{Name: "remote_cache_cleanup", Type: cty.Bool, Required: false},
-		"remote_cache_overwrite":         &hcldec.AttrSpec{Name: "remote_cache_overwrite", Type: cty.Bool, Required: false},
-		"remote_cache_datastore":         &hcldec.AttrSpec{Name: "remote_cache_datastore", Type: cty.String, Required: false},
-		"remote_cache_path":              &hcldec.AttrSpec{Name: "remote_cache_path", Type: cty.String, Required: false},
+		"packer_build_name":               &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":             &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":             &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                    &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                    &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":                 &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":           &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":      &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"http_directory":                  &hcldec.AttrSpec{Name: "http_directory", Type: cty.String, Required: false},
+		"http_content":                    &hcldec.AttrSpec{Name: "http_content", Type: cty.Map(cty.String), Required: false},
+		"http_port_min":                   &hcldec.AttrSpec{Name: "http_port_min", Type: cty.Number, Required: false},
+		"http_port_max":                   &hcldec.AttrSpec{Name: "http_port_max", Type: cty.Number, Required: false},
+		"http_bind_address":               &hcldec.AttrSpec{Name: "http_bind_address", Type: cty.String, Required: false},
+		"http_interface":                  &hcldec.AttrSpec{Name: "http_interface", Type: cty.String, Required: false},
+		"cd_files":                        &hcldec.AttrSpec{Name: "cd_files", Type: cty.List(cty.String), Required: false},
+		"cd_content":                      &hcldec.AttrSpec{Name: "cd_content", Type: cty.Map(cty.String), Required: false},
+		"cd_label":                        &hcldec.AttrSpec{Name: "cd_label", Type: cty.String, Required: false},
+		"cd_image_datastore":              &hcldec.AttrSpec{Name: "cd_image_datastore", Type: cty.String, Required: false},
+		"cd_image_path":                   &hcldec.AttrSpec{Name: "cd_image_path", Type: cty.String, Required: false},
+		"cd_image_name":                   &hcldec.AttrSpec{Name: "cd_image_name", Type: cty.String, Required: false},
+		"cd_image_upload_retries":         &hcldec.AttrSpec{Name: "cd_image_upload_retries", Type: cty.Number, Required: false},
+		"cd_image_cleanup":                &hcldec.AttrSpec{Name: "cd_image_cleanup", Type: cty.Bool, Required: false},
+		"vcenter_server":                  &hcldec.AttrSpec{Name: "vcenter_server", Type: cty.String, Required: false},
+		"username":                        &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
+		"password":                        &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
+		"insecure_connection":             &hcldec.AttrSpec{Name: "insecure_connection", Type: cty.Bool, Required: false},
+		"vcenter_ca_cert_file":            &hcldec.AttrSpec{Name: "vcenter_ca_cert_file", Type: cty.String, Required: false},
+		"vcenter_ca_cert_pem":             &hcldec.AttrSpec{Name: "vcenter_ca_cert_pem", Type: cty.String, Required: false},
+		"vcenter_client_cert_file":        &hcldec.AttrSpec{Name: "vcenter_client_cert_file", Type: cty.String, Required: false},
+		"vcenter_client_key_file":         &hcldec.AttrSpec{Name: "vcenter_client_key_file", Type: cty.String, Required: false},
+		"vcenter_saml_token":              &hcldec.AttrSpec{Name: "vcenter_saml_token", Type: cty.String, Required: false},
+		"vcenter_saml_token_file":         &hcldec.AttrSpec{Name: "vcenter_saml_token_file", Type: cty.String, Required: false},
+		"vcenter_session_ticket":          &hcldec.AttrSpec{Name: "vcenter_session_ticket", Type: cty.String, Required: false},
+		"datacenter":                      &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
+		"session_cache":                   &hcldec.AttrSpec{Name: "session_cache", Type: cty.Bool, Required: false},
+		"session_cache_directory":         &hcldec.AttrSpec{Name: "session_cache_directory", Type: cty.String, Required: false},
+		"task_retry_count":                &hcldec.AttrSpec{Name: "task_retry_count", Type: cty.Number, Required: false},
+		"task_retry_delay":                &hcldec.AttrSpec{Name: "task_retry_delay", Type: cty.String, Required: false},
+		"unreachable_timeout":             &hcldec.AttrSpec{Name: "unreachable_timeout", Type: cty.String, Required: false},
+		"vcenter_api_log_path":            &hcldec.AttrSpec{Name: "vcenter_api_log_path", Type: cty.String, Required: false},
+		"vm_version":                      &hcldec.AttrSpec{Name: "vm_version", Type: cty.Number, Required: false},
+		"guest_os_type":                   &hcldec.AttrSpec{Name: "guest_os_type", Type: cty.String, Required: false},
+		"disk_controller_type":            &hcldec.AttrSpec{Name: "disk_controller_type", Type: cty.List(cty.String), Required: false},
+		"storage":                         &hcldec.BlockListSpec{TypeName: "storage", Nested: hcldec.ObjectSpec((*common.FlatDiskConfig)(nil).HCL2Spec())},
+		"first_class_disk":                &hcldec.BlockListSpec{TypeName: "first_class_disk", Nested: hcldec.ObjectSpec((*common.FlatFirstClassDiskConfig)(nil).HCL2Spec())},
+		"storage_policy":                  &hcldec.AttrSpec{Name: "storage_policy", Type: cty.String, Required: false},
+		"attach_disks":                    &hcldec.AttrSpec{Name: "attach_disks", Type: cty.List(cty.String), Required: false},
+		"attach_disk_mode":                &hcldec.AttrSpec{Name: "attach_disk_mode", Type: cty.String, Required: false},
+		"network_adapters":                &hcldec.BlockListSpec{TypeName: "network_adapters", Nested: hcldec.ObjectSpec((*FlatNIC)(nil).HCL2Spec())},
+		"usb_controller":                  &hcldec.AttrSpec{Name: "usb_controller", Type: cty.List(cty.String), Required: false},
+		"notes":                           &hcldec.AttrSpec{Name: "notes", Type: cty.String, Required: false},
+		"append_notes":                    &hcldec.AttrSpec{Name: "append_notes", Type: cty.Bool, Required: false},
+		"destroy":                         &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
+		"vm_name":                         &hcldec.AttrSpec{Name: "vm_name", Type: cty.String, Required: false},
+		"folder":                          &hcldec.AttrSpec{Name: "folder", Type: cty.String, Required: false},
+		"cluster":                         &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
+		"host":                            &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
+		"resource_pool":                   &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
+		"datastore":                       &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
+		"host_local_datastore":            &hcldec.AttrSpec{Name: "host_local_datastore", Type: cty.Bool, Required: false},
+		"set_host_for_datastore_uploads":  &hcldec.AttrSpec{Name: "set_host_for_datastore_uploads", Type: cty.Bool, Required: false},
+		"CPUs":                            &hcldec.AttrSpec{Name: "CPUs", Type: cty.Number, Required: false},
+		"cpu_cores":                       &hcldec.AttrSpec{Name: "cpu_cores", Type: cty.Number, Required: false},
+		"CPU_reservation":                 &hcldec.AttrSpec{Name: "CPU_reservation", Type: cty.Number, Required: false},
+		"CPU_limit":                       &hcldec.AttrSpec{Name: "CPU_limit", Type: cty.Number, Required: false},
+		"CPU_hot_plug":                    &hcldec.AttrSpec{Name: "CPU_hot_plug", Type: cty.Bool, Required: false},
+		"RAM":                             &hcldec.AttrSpec{Name: "RAM", Type: cty.Number, Required: false},
+		"RAM_reservation":                 &hcldec.AttrSpec{Name: "RAM_reservation", Type: cty.Number, Required: false},
+		"RAM_reserve_all":                 &hcldec.AttrSpec{Name: "RAM_reserve_all", Type: cty.Bool, Required: false},
+		"RAM_hot_plug":                    &hcldec.AttrSpec{Name: "RAM_hot_plug", Type: cty.Bool, Required: false},
+		"video_ram":                       &hcldec.AttrSpec{Name: "video_ram", Type: cty.Number, Required: false},
+		"displays":                        &hcldec.AttrSpec{Name: "displays", Type: cty.Number, Required: false},
+		"pci_passthrough_allowed_device":  &hcldec.BlockListSpec{TypeName: "pci_passthrough_allowed_device", Nested: hcldec.ObjectSpec((*common.FlatPCIPassthroughAllowedDevice)(nil).HCL2Spec())},
+		"vgpu_profile":                    &hcldec.AttrSpec{Name: "vgpu_profile", Type: cty.String, Required: false},
+		"vgpu_profiles":                   &hcldec.AttrSpec{Name: "vgpu_profiles", Type: cty.List(cty.String), Required: false},
+		"vgpu_device_group":               &hcldec.AttrSpec{Name: "vgpu_device_group", Type: cty.String, Required: false},
+		"pci_passthrough_devices":         &hcldec.AttrSpec{Name: "pci_passthrough_devices", Type: cty.List(cty.String), Required: false},
+		"pci_passthrough_mmio_size":       &hcldec.AttrSpec{Name: "pci_passthrough_mmio_size", Type: cty.Number, Required: false},
+		"NestedHV":                        &hcldec.AttrSpec{Name: "NestedHV", Type: cty.Bool, Required: false},
+		"firmware":                        &hcldec.AttrSpec{Name: "firmware", Type: cty.String, Required: false},
+		"force_bios_setup":                &hcldec.AttrSpec{Name: "force_bios_setup", Type: cty.Bool, Required: false},
+		"vTPM":                            &hcldec.AttrSpec{Name: "vTPM", Type: cty.Bool, Required: false},
+		"guest_profile":                   &hcldec.AttrSpec{Name: "guest_profile", Type: cty.String, Required: false},
+		"precision_clock":                 &hcldec.AttrSpec{Name: "precision_clock", Type: cty.String, Required: false},
+		"watchdog_timer":                  &hcldec.AttrSpec{Name: "watchdog_timer", Type: cty.Bool, Required: false},
+		"watchdog_timer_run_on_boot":      &hcldec.AttrSpec{Name: "watchdog_timer_run_on_boot", Type: cty.Bool, Required: false},
+		"sgx_epc_size":                    &hcldec.AttrSpec{Name: "sgx_epc_size", Type: cty.Number, Required: false},
+		"sgx_flc_mode":                    &hcldec.AttrSpec{Name: "sgx_flc_mode", Type: cty.String, Required: false},
+		"sgx_le_pubkey_hash":              &hcldec.AttrSpec{Name: "sgx_le_pubkey_hash", Type: cty.String, Required: false},
+		"pmem":                            &hcldec.BlockListSpec{TypeName: "pmem", Nested: hcldec.ObjectSpec((*common.FlatPMemConfig)(nil).HCL2Spec())},
+		"serial_ports":                    &hcldec.BlockListSpec{TypeName: "serial_ports", Nested: hcldec.ObjectSpec((*common.FlatSerialPortConfig)(nil).HCL2Spec())},
+		"configuration_parameters":        &hcldec.AttrSpec{Name: "configuration_parameters", Type: cty.Map(cty.String), Required: false},
+		"configuration_parameters_strict": &hcldec.AttrSpec{Name: "configuration_parameters_strict", Type: cty.Bool, Required: false},
+		"tools_sync_time":                 &hcldec.AttrSpec{Name: "tools_sync_time", Type: cty.Bool, Required: false},
+		"tools_upgrade_policy":            &hcldec.AttrSpec{Name: "tools_upgrade_policy", Type: cty.Bool, Required: false},
+		"vbs_enabled":                     &hcldec.AttrSpec{Name: "vbs_enabled", Type: cty.Bool, Required: false},
+		"vvtd_enabled":                    &hcldec.AttrSpec{Name: "vvtd_enabled", Type: cty.Bool, Required: false},
+		"iso_checksum":                    &hcldec.AttrSpec{Name: "iso_checksum", Type: cty.String, Required: false},
+		"iso_url":                         &hcldec.AttrSpec{Name: "iso_url", Type: cty.String, Required: false},
+		"iso_urls":                        &hcldec.AttrSpec{Name: "iso_urls", Type: cty.List(cty.String), Required: false},
+		"iso_target_path":                 &hcldec.AttrSpec{Name: "iso_target_path", Type: cty.String, Required: false},
+		"iso_target_extension":            &hcldec.AttrSpec{Name: "iso_target_extension", Type: cty.String, Required: false},
+		"cdrom_type":                      &hcldec.AttrSpec{Name: "cdrom_type", Type: cty.String, Required: false},
+		"iso_paths":                       &hcldec.AttrSpec{Name: "iso_paths", Type: cty.List(cty.String), Required: false},
+		"cdroms":                          &hcldec.BlockListSpec{TypeName: "cdroms", Nested: hcldec.ObjectSpec((*common.FlatCDRomDeviceConfig)(nil).HCL2Spec())},
+		"remove_cdrom":                    &hcldec.AttrSpec{Name: "remove_cdrom", Type: cty.Bool, Required: false},
+		"reattach_cdroms":                 &hcldec.AttrSpec{Name: "reattach_cdroms", Type: cty.Number, Required: false},
+		"remove_network_adapter":          &hcldec.AttrSpec{Name: "remove_network_adapter", Type: cty.Bool, Required: false},
+		"floppy_img_path":                 &hcldec.AttrSpec{Name: "floppy_img_path", Type: cty.String, Required: false},
+		"floppy_files":                    &hcldec.AttrSpec{Name: "floppy_files", Type: cty.List(cty.String), Required: false},
+		"floppy_dirs":                     &hcldec.AttrSpec{Name: "floppy_dirs", Type: cty.List(cty.String), Required: false},
+		"floppy_content":                  &hcldec.AttrSpec{Name: "floppy_content", Type: cty.Map(cty.String), Required: false},
+		"floppy_label":                    &hcldec.AttrSpec{Name: "floppy_label", Type: cty.String, Required: false},
+		"boot_order":                      &hcldec.AttrSpec{Name: "boot_order", Type: cty.String, Required: false},
+		"firmware_boot":                   &hcldec.BlockSpec{TypeName: "firmware_boot", Nested: hcldec.ObjectSpec((*common.FlatFirmwareBootConfig)(nil).HCL2Spec())},
+		"power_on_retries":                &hcldec.AttrSpec{Name: "power_on_retries", Type: cty.Number, Required: false},
+		"boot_keygroup_interval":          &hcldec.AttrSpec{Name: "boot_keygroup_interval", Type: cty.String, Required: false},
+		"boot_wait":                       &hcldec.AttrSpec{Name: "boot_wait", Type: cty.String, Required: false},
+		"boot_command":                    &hcldec.AttrSpec{Name: "boot_command", Type: cty.List(cty.String), Required: false},
+		"boot_commands":                   &hcldec.BlockListSpec{TypeName: "boot_commands", Nested: hcldec.ObjectSpec((*common.FlatBootCommandEntry)(nil).HCL2Spec())},
+		"boot_keyboard":                   &hcldec.AttrSpec{Name: "boot_keyboard", Type: cty.String, Required: false},
+		"boot_key_interval":               &hcldec.AttrSpec{Name: "boot_key_interval", Type: cty.String, Required: false},
+		"boot_key_retries":                &hcldec.AttrSpec{Name: "boot_key_retries", Type: cty.Number, Required: false},
+		"http_ip":                         &hcldec.AttrSpec{Name: "http_ip", Type: cty.String, Required: false},
+		"http_ip_discovery":               &hcldec.AttrSpec{Name: "http_ip_discovery", Type: cty.String, Required: false},
+		"ip_wait_timeout":                 &hcldec.AttrSpec{Name: "ip_wait_timeout", Type: cty.String, Required: false},
+		"ip_settle_timeout":               &hcldec.AttrSpec{Name: "ip_settle_timeout", Type: cty.String, Required: false},
+		"ip_wait_address":                 &hcldec.AttrSpec{Name: "ip_wait_address", Type: cty.String, Required: false},
+		"ip_version":                      &hcldec.AttrSpec{Name: "ip_version", Type: cty.String, Required: false},
+		"ip_wait_nic_index":               &hcldec.AttrSpec{Name: "ip_wait_nic_index", Type: cty.Number, Required: false},
+		"ip_wait_exclude_cidrs":           &hcldec.AttrSpec{Name: "ip_wait_exclude_cidrs", Type: cty.List(cty.String), Required: false},
+		"ip_reachability_check":           &hcldec.AttrSpec{Name: "ip_reachability_check", Type: cty.Bool, Required: false},
+		"ip_reachability_timeout":         &hcldec.AttrSpec{Name: "ip_reachability_timeout", Type: cty.String, Required: false},
+		"ip_select_reachable":             &hcldec.AttrSpec{Name: "ip_select_reachable", Type: cty.Bool, Required: false},
+		"wait_for_ports":                  &hcldec.AttrSpec{Name: "wait_for_ports", Type: cty.List(cty.Number), Required: false},
+		"wait_for_port":                   &hcldec.BlockListSpec{TypeName: "wait_for_port", Nested: hcldec.ObjectSpec((*common.FlatPortCheckConfig)(nil).HCL2Spec())},
+		"wait_for_ports_timeout":          &hcldec.AttrSpec{Name: "wait_for_ports_timeout", Type: cty.String, Required: false},
+		"verify_networks":                 &hcldec.AttrSpec{Name: "verify_networks", Type: cty.Bool, Required: false},
+		"verify_networks_timeout":         &hcldec.AttrSpec{Name: "verify_networks_timeout", Type: cty.String, Required: false},
+		"communicator":                    &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
+		"pause_before_connecting":         &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
+		"ssh_host":                        &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
+		"ssh_port":                        &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
+		"ssh_username":                    &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
+		"ssh_password":                    &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
+		"ssh_keypair_name":                &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_name":         &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_type":         &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
+		"temporary_key_pair_bits":         &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
+		"ssh_ciphers":                     &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
+		"ssh_clear_authorized_keys":       &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
+		"ssh_key_exchange_algorithms":     &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
+		"ssh_private_key_file":            &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
+		"ssh_certificate_file":            &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
+		"ssh_pty":                         &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
+		"ssh_timeout":                     &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
+		"ssh_wait_timeout":                &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
+		"ssh_agent_auth":                  &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_disable_agent_forwarding":    &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
+		"ssh_handshake_attempts":          &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
+		"ssh_bastion_host":                &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
+		"ssh_bastion_port":                &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
+		"ssh_bastion_agent_auth":          &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_bastion_username":            &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
+		"ssh_bastion_password":            &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
+		"ssh_bastion_interactive":         &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
+		"ssh_bastion_private_key_file":    &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
+		"ssh_bastion_certificate_file":    &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
+		"ssh_file_transfer_method":        &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
+		"ssh_proxy_host":                  &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
+		"ssh_proxy_port":                  &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
+		"ssh_proxy_username":              &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
+		"ssh_proxy_password":              &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
+		"ssh_keep_alive_interval":         &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
+		"ssh_read_write_timeout":          &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
+		"ssh_remote_tunnels":              &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_local_tunnels":               &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_public_key":                  &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
+		"ssh_private_key":                 &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
+		"winrm_username":                  &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
+		"winrm_password":                  &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
+		"winrm_host":                      &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
+		"winrm_no_proxy":                  &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
+		"winrm_port":                      &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
+		"winrm_timeout":                   &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
+		"winrm_use_ssl":                   &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
+		"winrm_insecure":                  &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
+		"winrm_use_ntlm":                  &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
+		"shutdown_command":                &hcldec.AttrSpec{Name: "shutdown_command", Type: cty.String, Required: false},
+		"shutdown_timeout":                &hcldec.AttrSpec{Name: "shutdown_timeout", Type: cty.String, Required: false},
+		"disable_shutdown":                &hcldec.AttrSpec{Name: "disable_shutdown", Type: cty.Bool, Required: false},
+		"generate_config_snippet":         &hcldec.AttrSpec{Name: "generate_config_snippet", Type: cty.Bool, Required: false},
+		"config_snippet_path":             &hcldec.AttrSpec{Name: "config_snippet_path", Type: cty.String, Required: false},
+		"serial_log":                      &hcldec.AttrSpec{Name: "serial_log", Type: cty.Bool, Required: false},
+		"crash_dump":                      &hcldec.AttrSpec{Name: "crash_dump", Type: cty.Bool, Required: false},
+		"max_builds_per_host":             &hcldec.AttrSpec{Name: "max_builds_per_host", Type: cty.Number, Required: false},
+		"max_builds_per_datastore":        &hcldec.AttrSpec{Name: "max_builds_per_datastore", Type: cty.Number, Required: false},
+		"build_slot_timeout":              &hcldec.AttrSpec{Name: "build_slot_timeout", Type: cty.String, Required: false},
+		"build_slot_stale_age":            &hcldec.AttrSpec{Name: "build_slot_stale_age", Type: cty.String, Required: false},
+		"affinity_hosts":                  &hcldec.AttrSpec{Name: "affinity_hosts", Type: cty.List(cty.String), Required: false},
+		"affinity_rule_preferred":         &hcldec.AttrSpec{Name: "affinity_rule_preferred", Type: cty.Bool, Required: false},
+		"managed_by_extension_key":        &hcldec.AttrSpec{Name: "managed_by_extension_key", Type: cty.String, Required: false},
+		"managed_by_type":                 &hcldec.AttrSpec{Name: "managed_by_type", Type: cty.String, Required: false},
+		"tags":                            &hcldec.BlockListSpec{TypeName: "tags", Nested: hcldec.ObjectSpec((*common.FlatTagConfig)(nil).HCL2Spec())},
+		"create_tags":                     &hcldec.AttrSpec{Name: "create_tags", Type: cty.Bool, Required: false},
+		"custom_attributes":               &hcldec.AttrSpec{Name: "custom_attributes", Type: cty.Map(cty.String), Required: false},
+		"enable_cbt":                      &hcldec.AttrSpec{Name: "enable_cbt", Type: cty.Bool, Required: false},
+		"create_snapshot":                 &hcldec.AttrSpec{Name: "create_snapshot", Type: cty.Bool, Required: false},
+		"snapshot_name":                   &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
+		"snapshot_description":            &hcldec.AttrSpec{Name: "snapshot_description", Type: cty.String, Required: false},
+		"snapshot_memory":                 &hcldec.AttrSpec{Name: "snapshot_memory", Type: cty.Bool, Required: false},
+		"remove_existing_snapshots":       &hcldec.AttrSpec{Name: "remove_existing_snapshots", Type: cty.Bool, Required: false},
+		"device_labels":                   &hcldec.AttrSpec{Name: "device_labels", Type: cty.Map(cty.String), Required: false},
+		"check_datastore_space":           &hcldec.AttrSpec{Name: "check_datastore_space", Type: cty.Bool, Required: false},
+		"datastore_space_headroom":        &hcldec.AttrSpec{Name: "datastore_space_headroom", Type: cty.Number, Required: false},
+		"record_capacity":                 &hcldec.AttrSpec{Name: "record_capacity", Type: cty.Bool, Required: false},
+		"build_fingerprint":               &hcldec.AttrSpec{Name: "build_fingerprint", Type: cty.Bool, Required: false},
+		"skip_if_fingerprint_matches":     &hcldec.AttrSpec{Name: "skip_if_fingerprint_matches", Type: cty.Bool, Required: false},
+		"fingerprint_extra":               &hcldec.AttrSpec{Name: "fingerprint_extra", Type: cty.Map(cty.String), Required: false},
+		"preflight_checks":                &hcldec.AttrSpec{Name: "preflight_checks", Type: cty.Bool, Required: false},
+		"check_privileges":                &hcldec.AttrSpec{Name: "check_privileges", Type: cty.Bool, Required: false},
+		"http_boot_url":                   &hcldec.AttrSpec{Name: "http_boot_url", Type: cty.String, Required: false},
+		"convert_to_template":             &hcldec.AttrSpec{Name: "convert_to_template", Type: cty.Bool, Required: false},
+		"export":                          &hcldec.BlockSpec{TypeName: "export", Nested: hcldec.ObjectSpec((*common.FlatExportConfig)(nil).HCL2Spec())},
+		"content_library_destination":     &hcldec.BlockSpec{TypeName: "content_library_destination", Nested: hcldec.ObjectSpec((*common.FlatContentLibraryDestinationConfig)(nil).HCL2Spec())},
+		"timeouts":                        &hcldec.BlockSpec{TypeName: "timeouts", Nested: hcldec.ObjectSpec((*common.FlatTimeoutsConfig)(nil).HCL2Spec())},
+		"cloud_init_guestinfo":            &hcldec.BlockSpec{TypeName: "cloud_init_guestinfo", Nested: hcldec.ObjectSpec((*common.FlatCloudInitGuestinfoConfig)(nil).HCL2Spec())},
+		"sysprep":                         &hcldec.BlockSpec{TypeName: "sysprep", Nested: hcldec.ObjectSpec((*common.FlatSysprepConfig)(nil).HCL2Spec())},
+		"encryption":                      &hcldec.BlockSpec{TypeName: "encryption", Nested: hcldec.ObjectSpec((*common.FlatEncryptionConfig)(nil).HCL2Spec())},
+		"guest_operations":                &hcldec.BlockSpec{TypeName: "guest_operations", Nested: hcldec.ObjectSpec((*common.FlatGuestOperationsConfig)(nil).HCL2Spec())},
+		"base_template":                   &hcldec.BlockSpec{TypeName: "base_template", Nested: hcldec.ObjectSpec((*FlatBaseTemplateConfig)(nil).HCL2Spec())},
+		"local_cache_overwrite":           &hcldec.AttrSpec{Name: "local_cache_overwrite", Type: cty.Bool, Required: false},
+		"remote_cache_cleanup":            &hcldec.AttrSpec{Name: "remote_cache_cleanup", Type: cty.Bool, Required: false},
+		"remote_cache_overwrite":          &hcldec.AttrSpec{Name: "remote_cache_overwrite", Type: cty.Bool, Required: false},
+		"remote_cache_datastore":          &hcldec.AttrSpec{Name: "remote_cache_datastore", Type: cty.String, Required: false},
+		"remote_cache_path":               &hcldec.AttrSpec{Name: "remote_cache_path", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"testing"
+)
+
+func TestConfig_HTTPBootURL(t *testing.T) {
+	tc := []struct {
+		name             string
+		url              string
+		firmware         string
+		params           map[string]interface{}
+		expectedProtocol string
+		fail             bool
+	}{
+		{
+			name:             "IPv4",
+			url:              "http://192.168.1.10/ubuntu/grubx64.efi",
+			firmware:         "efi",
+			expectedProtocol: "httpv4",
+		},
+		{
+			name:             "IPv6",
+			url:              "https://[2001:db8::10]/ubuntu/grubx64.efi",
+			firmware:         "efi-secure",
+			expectedProtocol: "httpv6",
+		},
+		{
+			name:     "BIOS firmware",
+			url:      "http://192.168.1.10/ubuntu/grubx64.efi",
+			firmware: "bios",
+			fail:     true,
+		},
+		{
+			name:     "Unsupported scheme",
+			url:      "tftp://192.168.1.10/ubuntu/grubx64.efi",
+			firmware: "efi",
+			fail:     true,
+		},
+		{
+			name:     "Conflicting configuration parameter",
+			url:      "http://192.168.1.10/ubuntu/grubx64.efi",
+			firmware: "efi",
+			params:   map[string]interface{}{"networkBootUri": "http://192.168.1.20/boot.efi"},
+			fail:     true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"vcenter_server": "vcenter.example.com",
+				"username":       "administrator@vsphere.local",
+				"password":       "VMw@re1!",
+				"vm_name":        "vm-01",
+				"host":           "esxi-01.example.com",
+				"ssh_username":   "root",
+				"ssh_password":   "VMw@re1!",
+				"firmware":       c.firmware,
+				"http_boot_url":  c.url,
+				"storage": []map[string]interface{}{
+					{"disk_size": 20000},
+				},
+			}
+			if c.params != nil {
+				raw["configuration_parameters"] = c.params
+			}
+
+			config := new(Config)
+			_, err := config.Prepare(raw)
+			if c.fail {
+				if err == nil {
+					t.Fatal("unexpected success: expected failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if protocol := config.ConfigParams["networkBootProtocol"]; protocol != c.expectedProtocol {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expectedProtocol, protocol)
+			}
+			if uri := config.ConfigParams["networkBootUri"]; uri != c.url {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.url, uri)
+			}
+		})
+	}
+}
+
+func TestConfig_CloudInitGuestinfo(t *testing.T) {
+	raw := map[string]interface{}{
+		"vcenter_server": "vcenter.example.com",
+		"username":       "administrator@vsphere.local",
+		"password":       "VMw@re1!",
+		"vm_name":        "vm-01",
+		"host":           "esxi-01.example.com",
+		"ssh_username":   "root",
+		"ssh_password":   "VMw@re1!",
+		"storage": []map[string]interface{}{
+			{"disk_size": 20000},
+		},
+		"cloud_init_guestinfo": map[string]interface{}{
+			"user_data": "#cloud-config\nhostname: {{ .Name }}\n",
+		},
+	}
+
+	config := new(Config)
+	if _, err := config.Prepare(raw); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	params := config.CloudInitGuestinfo.ConfigParams()
+	for _, key := range []string{"guestinfo.userdata", "guestinfo.metadata"} {
+		if params[key] == "" || params[key+".encoding"] != "gzip+base64" {
+			t.Fatalf("unexpected result: expected '%s' to be set", key)
+		}
+	}
+}
+
+func TestConfig_SRIOVReservesAllMemory(t *testing.T) {
+	raw := map[string]interface{}{
+		"vcenter_server": "vcenter.example.com",
+		"username":       "administrator@vsphere.local",
+		"password":       "VMw@re1!",
+		"vm_name":        "vm-01",
+		"host":           "esxi-01.example.com",
+		"ssh_username":   "root",
+		"ssh_password":   "VMw@re1!",
+		"storage": []map[string]interface{}{
+			{"disk_size": 20000},
+		},
+		"network_adapters": []map[string]interface{}{
+			{"network": "VM Network", "network_card": "sriov"},
+		},
+	}
+
+	config := new(Config)
+	warnings, err := config.Prepare(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !config.RAMReserveAll {
+		t.Fatal("unexpected result: expected 'RAM_reserve_all' to be set")
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("unexpected result: expected a warning for 'RAM_reserve_all', but returned '%v'", warnings)
+	}
+
+	// No warning is displayed if all memory is reserved by the configuration.
+	raw["RAM_reserve_all"] = true
+	config = new(Config)
+	warnings, err = config.Prepare(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: '%v'", warnings)
+	}
+}
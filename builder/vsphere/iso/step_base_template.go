@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type BaseTemplateConfig
+
+package iso
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// The name of the snapshot of the base template for linked clones.
+const baseTemplateSnapshotName = "packer-base-template"
+
+// BaseTemplateConfig splits the build into two phases. The first phase
+// installs the guest operating system from the ISO, shuts down the virtual
+// machine, and converts it to an intermediate base template. The second phase
+// creates the virtual machine of the build as a linked clone of the base
+// template and runs the provisioners. The other options of the build, such as
+// `convert_to_template` and `export`, apply to the virtual machine of the
+// second phase.
+//
+// With `reuse`, the base template of a previous build is cloned and the first
+// phase is skipped, which shortens the iteration on the provisioners to the
+// time it takes to clone and boot the virtual machine.
+//
+// In the first phase, the build waits for the communicator to connect after
+// the boot command, without running the provisioners, and shuts down the
+// virtual machine with `shutdown_command`. If `communicator` is set to
+// `none`, the installation must shut down the guest operating system.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	base_template {
+//	  name  = "ubuntu-base"
+//	  reuse = true
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"base_template": {
+//	  "name": "ubuntu-base",
+//	  "reuse": true
+//	}
+//
+// ```
+//
+// ~> **Note:** The linked clone depends on the disks of the base template. Do
+// not delete the base template while virtual machines or templates that are
+// linked clones of it exist, or set `full_clone` to `true`.
+type BaseTemplateConfig struct {
+	// The name of the base template, which is created in `folder`. Must be
+	// different from `vm_name`.
+	Name string `mapstructure:"name" required:"true"`
+	// Clone the base template if it already exists and skip the installation.
+	// Use `-force` to replace an existing base template instead. Defaults to
+	// `false`.
+	Reuse bool `mapstructure:"reuse"`
+	// Create the virtual machine of the second phase as a full clone of the
+	// base template instead of a linked clone, so it does not depend on the
+	// disks of the base template. Defaults to `false`.
+	FullClone bool `mapstructure:"full_clone"`
+}
+
+func (c *BaseTemplateConfig) Prepare(location *common.LocationConfig, storage *common.StorageConfig) []error {
+	var errs []error
+
+	if c.Name == "" {
+		errs = append(errs, fmt.Errorf("'base_template' 'name' is required"))
+	} else if c.Name == location.VMName {
+		errs = append(errs, fmt.Errorf("'base_template' 'name' must be different from 'vm_name'"))
+	}
+	if len(storage.FirstClassDisks) > 0 {
+		errs = append(errs, fmt.Errorf("'base_template' and 'first_class_disk' cannot be used together"))
+	}
+	return errs
+}
+
+type StepInstallBaseTemplate struct {
+	Config   *BaseTemplateConfig
+	Location *common.LocationConfig
+	// Replace an existing base template instead of reusing it.
+	Force bool
+	// Runs the steps that install the guest operating system on the virtual
+	// machine of the base template.
+	Runner multistep.Runner
+}
+
+func (s *StepInstallBaseTemplate) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	basePath := path.Join(s.Location.Folder, s.Config.Name)
+
+	if s.Config.Reuse && s.Force {
+		ui.Sayf("Replacing base template %s, since -force is set...", basePath)
+	} else if s.Config.Reuse {
+		if vm, err := d.FindVM(basePath); err == nil {
+			template, err := vm.IsTemplate()
+			if err != nil {
+				state.Put("error", fmt.Errorf("error checking the base template: %s", err))
+				return multistep.ActionHalt
+			}
+			if !template {
+				state.Put("error", fmt.Errorf("the base template %s exists and is not a template", basePath))
+				return multistep.ActionHalt
+			}
+			ui.Sayf("Reusing base template %s...", basePath)
+			state.Put("base_template", vm)
+			return multistep.ActionContinue
+		}
+	}
+
+	ui.Sayf("Installing base template %s...", basePath)
+	s.Runner.Run(ctx, state)
+	if _, ok := state.GetOk("error"); ok {
+		return multistep.ActionHalt
+	}
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if cancelled || halted {
+		return multistep.ActionHalt
+	}
+
+	vm := state.Get("vm").(driver.VirtualMachine)
+	state.Remove("vm")
+
+	ui.Say("Converting the virtual machine to the base template...")
+	if err := convertToBaseTemplate(vm); err != nil {
+		state.Put("error", fmt.Errorf("error converting the virtual machine to the base template: %s", err))
+		if err := vm.Destroy(); err != nil {
+			ui.Errorf("error destroying the virtual machine of the base template: %s", err)
+		}
+		return multistep.ActionHalt
+	}
+	state.Put("base_template", vm)
+
+	return multistep.ActionContinue
+}
+
+// convertToBaseTemplate converts the virtual machine to a template with a
+// snapshot for linked clones.
+func convertToBaseTemplate(vm driver.VirtualMachine) error {
+	if err := vm.CreateSnapshot(baseTemplateSnapshotName); err != nil {
+		return err
+	}
+	return vm.ConvertToTemplate()
+}
+
+func (s *StepInstallBaseTemplate) Cleanup(multistep.StateBag) {}
+
+type StepCloneBaseTemplate struct {
+	Config       *BaseTemplateConfig
+	CreateConfig *CreateConfig
+	Location     *common.LocationConfig
+	Force        bool
+	Ctx          interpolate.Context
+	// The ISO used for the build, recorded in the notes.
+	Source            string
+	ConvertToTemplate bool
+}
+
+func (s *StepCloneBaseTemplate) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	d := state.Get("driver").(driver.Driver)
+	template := state.Get("base_template").(driver.VirtualMachine)
+	vmPath := path.Join(s.Location.Folder, s.Location.VMName)
+
+	err := d.PreCleanVM(ui, vmPath, s.Force, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	notes, err := renderNotes(d, s.Ctx, s.CreateConfig, s.Location, s.Source)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Cloning the base template...")
+	vm, err := template.Clone(ctx, &driver.CloneConfig{
+		Name:              s.Location.VMName,
+		Folder:            s.Location.Folder,
+		Cluster:           s.Location.Cluster,
+		Host:              s.Location.Host,
+		ResourcePool:      s.Location.ResourcePool,
+		Datastore:         s.Location.Datastore,
+		LinkedClone:       !s.Config.FullClone,
+		Annotation:        notes,
+		ConvertToTemplate: s.ConvertToTemplate,
+	})
+	if err != nil {
+		state.Put("error", fmt.Errorf("error cloning the base template: %s", err))
+		return multistep.ActionHalt
+	}
+	if s.CreateConfig.Destroy {
+		state.Put("destroy_vm", s.CreateConfig.Destroy)
+	}
+	state.Put("vm", vm)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepCloneBaseTemplate) Cleanup(state multistep.StateBag) {
+	common.CleanupVM(state)
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+// stepInstall is a step of the installation of the base template that creates
+// the virtual machine or fails.
+type stepInstall struct {
+	vm  driver.VirtualMachine
+	err error
+	ran bool
+}
+
+func (s *stepInstall) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	s.ran = true
+	if s.err != nil {
+		state.Put("error", s.err)
+		return multistep.ActionHalt
+	}
+	state.Put("vm", s.vm)
+	return multistep.ActionContinue
+}
+
+func (s *stepInstall) Cleanup(multistep.StateBag) {}
+
+func TestBaseTemplateConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name    string
+		config  *BaseTemplateConfig
+		storage common.StorageConfig
+		fail    bool
+	}{
+		{
+			name:   "Valid",
+			config: &BaseTemplateConfig{Name: "base", Reuse: true},
+		},
+		{
+			name:   "Missing name",
+			config: &BaseTemplateConfig{},
+			fail:   true,
+		},
+		{
+			name:   "Name of the virtual machine",
+			config: &BaseTemplateConfig{Name: "vm"},
+			fail:   true,
+		},
+		{
+			name:    "First class disks",
+			config:  &BaseTemplateConfig{Name: "base"},
+			storage: common.StorageConfig{FirstClassDisks: []common.FirstClassDiskConfig{{ID: "fcd-1", Datastore: "datastore1"}}},
+			fail:    true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			errs := c.config.Prepare(&common.LocationConfig{VMName: "vm"}, &c.storage)
+			if c.fail && len(errs) == 0 {
+				t.Fatal("unexpected success: expected failure")
+			}
+			if !c.fail && len(errs) != 0 {
+				t.Fatalf("unexpected errors: '%v'", errs)
+			}
+		})
+	}
+}
+
+func TestStepInstallBaseTemplate_Run(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	install := &stepInstall{vm: vm}
+	state := basicStateBag()
+	state.Put("driver", &driver.DriverMock{FindDatastoreErr: errors.New("not found")})
+
+	step := &StepInstallBaseTemplate{
+		Config:   &BaseTemplateConfig{Name: "base", Reuse: true},
+		Location: &common.LocationConfig{Folder: "folder"},
+		Runner:   &multistep.BasicRunner{Steps: []multistep.Step{install}},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v': %v", action, state.Get("error"))
+	}
+	if !install.ran {
+		t.Fatal("expected the installation to run")
+	}
+	if vm.CreateSnapshotName != baseTemplateSnapshotName || !vm.ConvertToTemplateCalled {
+		t.Fatal("expected the virtual machine to be converted to a template with a snapshot")
+	}
+	if _, ok := state.GetOk("vm"); ok {
+		t.Fatal("unexpected virtual machine in the state")
+	}
+	if state.Get("base_template") != vm {
+		t.Fatalf("unexpected base template: '%v'", state.Get("base_template"))
+	}
+}
+
+func TestStepInstallBaseTemplate_RunReuse(t *testing.T) {
+	base := &driver.VirtualMachineMock{IsTemplateReturn: true}
+	install := &stepInstall{vm: new(driver.VirtualMachineMock)}
+	d := &driver.DriverMock{VM: base}
+	state := basicStateBag()
+	state.Put("driver", d)
+
+	step := &StepInstallBaseTemplate{
+		Config:   &BaseTemplateConfig{Name: "base", Reuse: true},
+		Location: &common.LocationConfig{Folder: "folder"},
+		Runner:   &multistep.BasicRunner{Steps: []multistep.Step{install}},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v': %v", action, state.Get("error"))
+	}
+	if d.FindVMName != "folder/base" {
+		t.Fatalf("unexpected base template path: '%s'", d.FindVMName)
+	}
+	if install.ran {
+		t.Fatal("unexpected installation of the reused base template")
+	}
+	if state.Get("base_template") != base {
+		t.Fatalf("unexpected base template: '%v'", state.Get("base_template"))
+	}
+
+	// A virtual machine with the name of the base template is not reused.
+	base.IsTemplateReturn = false
+	state = basicStateBag()
+	state.Put("driver", d)
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+}
+
+func TestStepInstallBaseTemplate_RunReuseForce(t *testing.T) {
+	base := &driver.VirtualMachineMock{IsTemplateReturn: true}
+	vm := new(driver.VirtualMachineMock)
+	install := &stepInstall{vm: vm}
+	d := &driver.DriverMock{VM: base}
+	state := basicStateBag()
+	state.Put("driver", d)
+
+	step := &StepInstallBaseTemplate{
+		Config:   &BaseTemplateConfig{Name: "base", Reuse: true},
+		Location: &common.LocationConfig{Folder: "folder"},
+		Force:    true,
+		Runner:   &multistep.BasicRunner{Steps: []multistep.Step{install}},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v': %v", action, state.Get("error"))
+	}
+	if !install.ran {
+		t.Fatal("expected the installation to replace the existing base template")
+	}
+	if state.Get("base_template") != vm {
+		t.Fatalf("unexpected base template: '%v'", state.Get("base_template"))
+	}
+}
+
+func TestStepInstallBaseTemplate_RunInstallFailure(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	state := basicStateBag()
+	state.Put("driver", new(driver.DriverMock))
+
+	step := &StepInstallBaseTemplate{
+		Config:   &BaseTemplateConfig{Name: "base"},
+		Location: &common.LocationConfig{},
+		Runner:   &multistep.BasicRunner{Steps: []multistep.Step{&stepInstall{vm: vm, err: errors.New("boot failed")}}},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if vm.CreateSnapshotCalled || vm.ConvertToTemplateCalled {
+		t.Fatal("unexpected conversion to a template after a failed installation")
+	}
+}
+
+func TestStepCloneBaseTemplate_Run(t *testing.T) {
+	base := new(driver.VirtualMachineMock)
+	state := basicStateBag()
+	state.Put("driver", new(driver.DriverMock))
+	state.Put("base_template", base)
+
+	step := &StepCloneBaseTemplate{
+		Config:       &BaseTemplateConfig{Name: "base"},
+		CreateConfig: &CreateConfig{Notes: "Built from {{ .Source }}"},
+		Location:     &common.LocationConfig{VMName: "vm", Folder: "folder", Datastore: "datastore"},
+		Source:       "ubuntu.iso",
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v': %v", action, state.Get("error"))
+	}
+	config := base.CloneConfig
+	if config == nil || config.Name != "vm" || config.Folder != "folder" || !config.LinkedClone {
+		t.Fatalf("unexpected clone configuration: '%#v'", config)
+	}
+	if config.Annotation != "Built from ubuntu.iso" {
+		t.Fatalf("unexpected notes: '%s'", config.Annotation)
+	}
+	if _, ok := state.GetOk("vm"); !ok {
+		t.Fatal("expected the clone in the state")
+	}
+
+	step.Config.FullClone = true
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: '%#v'", action)
+	}
+	if base.CloneConfig.LinkedClone {
+		t.Fatal("unexpected linked clone with 'full_clone'")
+	}
+}
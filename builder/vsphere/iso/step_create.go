@@ -15,8 +15,10 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/object"
 )
 
 // If no adapter is defined, network tasks (communicators, most provisioners)
@@ -68,18 +70,48 @@ type NIC struct {
 	// - Segment ID: `/infra/segments/<SegmentID>`
 	//
 	// ~> **Note:** If more than one network resolves to the same name, either
-	// the inventory path to network or an ID must be provided.
+	// the inventory path to network or an ID must be provided, or the lookup
+	// must be scoped with `network_switch` or `network_host`.
 	//
 	// ~> **Note:** If no network is specified, provide `host` to allow the
 	// plugin to search for an available network.
 	Network string `mapstructure:"network"`
+	// The name of the distributed virtual switch that owns the network. Used
+	// to select the network when more than one network resolves to the same
+	// name.
+	NetworkSwitch string `mapstructure:"network_switch"`
+	// The ESXi host that must have access to the network. Used to select the
+	// network when more than one network resolves to the same name. Defaults
+	// to the value of `host`.
+	NetworkHost string `mapstructure:"network_host"`
 	// The virtual machine network card type. For example `vmxnet3`.
+	//
+	// Use `sriov` for a network adapter that is backed by a virtual function
+	// of an SR-IOV capable physical adapter of the host. The host must have a
+	// physical adapter with SR-IOV enabled, and all memory of the virtual
+	// machine is reserved.
 	NetworkCard string `mapstructure:"network_card" required:"true"`
 	// The network card MAC address. For example `00:50:56:00:00:00`.
 	MacAddress string `mapstructure:"mac_address"`
 	// Enable DirectPath I/O passthrough for the network device.
 	// Defaults to `false`.
 	Passthrough *bool `mapstructure:"passthrough"`
+	// Create the network device disconnected. The network device is connected
+	// before the plugin waits for the IP address of the communicator and is
+	// disconnected after the virtual machine is shut down, so the virtual
+	// machine is not connected to the network while the operating system is
+	// installed and the resulting image starts disconnected. Defaults to `false`.
+	StartDisconnected bool `mapstructure:"start_disconnected"`
+	// The SR-IOV physical function that backs the network adapter, as the
+	// name of the physical adapter of the host, for example `vmnic4`, or as
+	// the PCI address of the physical adapter, for example `0000:3b:00.0`.
+	// Requires `network_card` to be set to `sriov`. Defaults to a physical
+	// function from the SR-IOV device pool of the network, which is assigned
+	// when the virtual machine is powered on.
+	//
+	// -> **Note:** Set `host` to select a physical function of a specific
+	// host.
+	PhysicalFunction string `mapstructure:"physical_function"`
 }
 
 type CreateConfig struct {
@@ -104,6 +136,26 @@ type CreateConfig struct {
 	// ```
 	GuestOSType   string               `mapstructure:"guest_os_type"`
 	StorageConfig common.StorageConfig `mapstructure:",squash"`
+	// The datastore paths of existing virtual disk files to attach to the
+	// first disk controller of the virtual machine after the disks of
+	// `storage`, such as data disks that are prepared ahead of the build. For
+	// example, `["[datastore1] images/data.vmdk"]`. The files are not
+	// modified. If `storage` is not set, the first attached disk is the boot
+	// disk.
+	AttachDisks []string `mapstructure:"attach_disks"`
+	// How the disks of `attach_disks` are attached. One of `copy` or `link`.
+	// Defaults to `copy`.
+	//
+	// - `copy`: The virtual disk file is copied to the directory of the
+	//   virtual machine and the copy is attached.
+	// - `link`: A delta disk that is backed by the virtual disk file is
+	//   created in the directory of the virtual machine and attached, which
+	//   is faster and uses less space than a copy.
+	//
+	// -> **Note:** A linked disk depends on the virtual disk file, which must
+	// not be modified, moved, or deleted while the virtual machine or a
+	// template converted from it exists.
+	AttachDiskMode string `mapstructure:"attach_disk_mode"`
 	// The network adapters for the virtual machine.
 	//
 	// -> **Note:** If no network adapter is defined, all network-related
@@ -118,13 +170,60 @@ type CreateConfig struct {
 	//
 	// -> **Note:** A maximum of one of each controller type can be defined.
 	USBController []string `mapstructure:"usb_controller"`
-	// The annotations for the virtual machine.
+	// The annotations for the virtual machine. The notes are a template that
+	// can use the `{{ .Name }}` (the name of the virtual machine),
+	// `{{ .Source }}` (the source of the build), `{{ .PluginVersion }}`, and
+	// `{{ .Date }}` build variables, and the [`vsphere`](#vsphere-template-function)
+	// template function.
 	Notes string `mapstructure:"notes"`
+	// Append the rendered notes and the build provenance, including the
+	// plugin version, the date, and the source ISO, to the notes of the virtual
+	// machine. Defaults to `false`.
+	AppendNotes bool `mapstructure:"append_notes"`
 	// Destroy the virtual machine after the build completes.
 	// Defaults to `false`.
 	Destroy bool `mapstructure:"destroy"`
 }
 
+// DisconnectedNICs returns the indices of the network adapters that are
+// created disconnected.
+func (c *CreateConfig) DisconnectedNICs() []int {
+	var indices []int
+	for i, nic := range c.NICs {
+		if nic.StartDisconnected {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// Networks returns the networks of the network adapters.
+func (c *CreateConfig) Networks() []string {
+	var networks []string
+	for _, nic := range c.NICs {
+		networks = append(networks, nic.Network)
+	}
+	return networks
+}
+
+// driverNICs returns the network adapters of the driver configuration.
+func (c *CreateConfig) driverNICs() []driver.NIC {
+	var nics []driver.NIC
+	for _, nic := range c.NICs {
+		nics = append(nics, driver.NIC{
+			Network:           nic.Network,
+			NetworkCard:       nic.NetworkCard,
+			MacAddress:        strings.ToLower(nic.MacAddress),
+			Passthrough:       nic.Passthrough,
+			NetworkSwitch:     nic.NetworkSwitch,
+			NetworkHost:       nic.NetworkHost,
+			StartDisconnected: nic.StartDisconnected,
+			PhysicalFunction:  nic.PhysicalFunction,
+		})
+	}
+	return nics
+}
+
 func (c *CreateConfig) Prepare() []error {
 	var errs []error
 
@@ -133,11 +232,43 @@ func (c *CreateConfig) Prepare() []error {
 	}
 
 	// there should be at least one
-	if len(c.StorageConfig.Storage) == 0 {
+	if len(c.StorageConfig.Storage) == 0 && len(c.AttachDisks) == 0 {
 		errs = append(errs, fmt.Errorf("no storage devices have been defined"))
 	}
 	errs = append(errs, c.StorageConfig.Prepare()...)
 
+	switch c.AttachDiskMode {
+	case "":
+		c.AttachDiskMode = driver.AttachDiskModeCopy
+	case driver.AttachDiskModeCopy, driver.AttachDiskModeLink:
+	default:
+		errs = append(errs, fmt.Errorf("'attach_disk_mode' must be one of 'copy' or 'link'"))
+	}
+	copies := make(map[string]bool)
+	for i, disk := range c.AttachDisks {
+		var dsPath object.DatastorePath
+		if !dsPath.FromString(disk) || dsPath.Path == "" {
+			errs = append(errs, fmt.Errorf("attach_disks[%d] must be a datastore path, for example '[datastore1] images/data.vmdk'", i))
+			continue
+		}
+		// The copies are named after the files in the directory of the
+		// virtual machine.
+		name := path.Base(dsPath.Path)
+		if c.AttachDiskMode == driver.AttachDiskModeCopy && copies[name] {
+			errs = append(errs, fmt.Errorf("attach_disks[%d] has the same file name as another disk to copy: %s", i, name))
+		}
+		copies[name] = true
+	}
+
+	for i, nic := range c.NICs {
+		if nic.PhysicalFunction != "" && nic.NetworkCard != driver.NetworkCardSRIOV {
+			errs = append(errs, fmt.Errorf("network_adapters[%d] must set 'network_card' to '%s' when 'physical_function' is set", i, driver.NetworkCardSRIOV))
+		}
+		if nic.NetworkCard == driver.NetworkCardSRIOV && nic.Passthrough != nil && *nic.Passthrough {
+			errs = append(errs, fmt.Errorf("network_adapters[%d] cannot enable 'passthrough' for an SR-IOV network adapter", i))
+		}
+	}
+
 	if c.GuestOSType == "" {
 		c.GuestOSType = "otherGuest"
 	}
@@ -171,6 +302,10 @@ type StepCreateVM struct {
 	Location      *common.LocationConfig
 	Force         bool
 	GeneratedData *packerbuilderdata.GeneratedData
+	Ctx           interpolate.Context
+	// The ISO used for the build, recorded in the notes.
+	Source            string
+	ConvertToTemplate bool
 }
 
 func (s *StepCreateVM) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
@@ -184,38 +319,38 @@ func (s *StepCreateVM) Run(_ context.Context, state multistep.StateBag) multiste
 		return multistep.ActionHalt
 	}
 
+	notes, err := renderNotes(d, s.Ctx, s.Config, s.Location, s.Source)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
 	ui.Say("Creating virtual machine...")
 
 	// Add network/network card on the first NIC for backwards compatibility in
 	// the type is defined.
-	var networkCards []driver.NIC
-	for _, nic := range s.Config.NICs {
-		networkCards = append(networkCards, driver.NIC{
-			Network:     nic.Network,
-			NetworkCard: nic.NetworkCard,
-			MacAddress:  strings.ToLower(nic.MacAddress),
-			Passthrough: nic.Passthrough,
-		})
-	}
+	networkCards := s.Config.driverNICs()
 
 	// Add disk as the first drive for backwards compatibility if the type is
 	// defined
-	var disks []driver.Disk
-	for _, disk := range s.Config.StorageConfig.Storage {
-		disks = append(disks, driver.Disk{
-			DiskSize:            disk.DiskSize,
-			DiskEagerlyScrub:    disk.DiskEagerlyScrub,
-			DiskThinProvisioned: disk.DiskThinProvisioned,
-			ControllerIndex:     disk.DiskControllerIndex,
-		})
+	disks, err := s.Config.StorageConfig.Disks(d, s.Location.Host)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	storagePolicyID, err := s.Config.StorageConfig.StoragePolicyID(d)
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
 	}
 
 	vm, err := d.CreateVM(&driver.CreateConfig{
 		StorageConfig: driver.StorageConfig{
 			DiskControllerType: s.Config.StorageConfig.DiskControllerType,
 			Storage:            disks,
+			StoragePolicyID:    storagePolicyID,
 		},
-		Annotation:    s.Config.Notes,
+		Annotation:    notes,
 		Name:          s.Location.VMName,
 		Folder:        s.Location.Folder,
 		Cluster:       s.Location.Cluster,
@@ -226,6 +361,11 @@ func (s *StepCreateVM) Run(_ context.Context, state multistep.StateBag) multiste
 		NICs:          networkCards,
 		USBController: s.Config.USBController,
 		Version:       s.Config.Version,
+
+		AttachDisks:    s.Config.AttachDisks,
+		AttachDiskMode: s.Config.AttachDiskMode,
+
+		ConvertToTemplate: s.ConvertToTemplate,
 	})
 	if err != nil {
 		state.Put("error", fmt.Errorf("error creating virtual machine: %v", err))
@@ -234,6 +374,9 @@ func (s *StepCreateVM) Run(_ context.Context, state multistep.StateBag) multiste
 	if s.Config.Destroy {
 		state.Put("destroy_vm", s.Config.Destroy)
 	}
+	if keep := s.Config.StorageConfig.KeepOnDestroy(); len(keep) > 0 {
+		state.Put("keep_disks", keep)
+	}
 	state.Put("vm", vm)
 
 	return multistep.ActionContinue
@@ -242,3 +385,20 @@ func (s *StepCreateVM) Run(_ context.Context, state multistep.StateBag) multiste
 func (s *StepCreateVM) Cleanup(state multistep.StateBag) {
 	common.CleanupVM(state)
 }
+
+// renderNotes renders the notes of the virtual machine that is created from
+// the specified source.
+func renderNotes(d driver.Driver, ctx interpolate.Context, config *CreateConfig, location *common.LocationConfig, source string) (string, error) {
+	values := &common.VSphereTemplateValues{
+		Driver:    d,
+		Datastore: location.Datastore,
+		Host:      location.Host,
+	}
+	if len(config.NICs) > 0 {
+		values.Network = config.NICs[0].Network
+	}
+	return common.RenderNotes(common.WithVSphereFunc(ctx, values), config.Notes, config.AppendNotes, "", common.NotesTemplateData{
+		Name:   location.VMName,
+		Source: source,
+	})
+}
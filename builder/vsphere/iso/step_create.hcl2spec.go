@@ -11,14 +11,19 @@ import (
 // FlatCreateConfig is an auto-generated flat version of CreateConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatCreateConfig struct {
-	Version            *uint                   `mapstructure:"vm_version" cty:"vm_version" hcl:"vm_version"`
-	GuestOSType        *string                 `mapstructure:"guest_os_type" cty:"guest_os_type" hcl:"guest_os_type"`
-	DiskControllerType []string                `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
-	Storage            []common.FlatDiskConfig `mapstructure:"storage" cty:"storage" hcl:"storage"`
-	NICs               []FlatNIC               `mapstructure:"network_adapters" cty:"network_adapters" hcl:"network_adapters"`
-	USBController      []string                `mapstructure:"usb_controller" cty:"usb_controller" hcl:"usb_controller"`
-	Notes              *string                 `mapstructure:"notes" cty:"notes" hcl:"notes"`
-	Destroy            *bool                   `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
+	Version            *uint                             `mapstructure:"vm_version" cty:"vm_version" hcl:"vm_version"`
+	GuestOSType        *string                           `mapstructure:"guest_os_type" cty:"guest_os_type" hcl:"guest_os_type"`
+	DiskControllerType []string                          `mapstructure:"disk_controller_type" cty:"disk_controller_type" hcl:"disk_controller_type"`
+	Storage            []common.FlatDiskConfig           `mapstructure:"storage" cty:"storage" hcl:"storage"`
+	FirstClassDisks    []common.FlatFirstClassDiskConfig `mapstructure:"first_class_disk" cty:"first_class_disk" hcl:"first_class_disk"`
+	StoragePolicy      *string                           `mapstructure:"storage_policy" cty:"storage_policy" hcl:"storage_policy"`
+	AttachDisks        []string                          `mapstructure:"attach_disks" cty:"attach_disks" hcl:"attach_disks"`
+	AttachDiskMode     *string                           `mapstructure:"attach_disk_mode" cty:"attach_disk_mode" hcl:"attach_disk_mode"`
+	NICs               []FlatNIC                         `mapstructure:"network_adapters" cty:"network_adapters" hcl:"network_adapters"`
+	USBController      []string                          `mapstructure:"usb_controller" cty:"usb_controller" hcl:"usb_controller"`
+	Notes              *string                           `mapstructure:"notes" cty:"notes" hcl:"notes"`
+	AppendNotes        *bool                             `mapstructure:"append_notes" cty:"append_notes" hcl:"append_notes"`
+	Destroy            *bool                             `mapstructure:"destroy" cty:"destroy" hcl:"destroy"`
 }
 
 // FlatMapstructure returns a new FlatCreateConfig.
@@ -37,9 +42,14 @@ func (*FlatCreateConfig) HCL2Spec() map[string]hcldec.Spec {
 		"guest_os_type":        &hcldec.AttrSpec{Name: "guest_os_type", Type: cty.String, Required: false},
 		"disk_controller_type": &hcldec.AttrSpec{Name: "disk_controller_type", Type: cty.List(cty.String), Required: false},
 		"storage":              &hcldec.BlockListSpec{TypeName: "storage", Nested: hcldec.ObjectSpec((*common.FlatDiskConfig)(nil).HCL2Spec())},
+		"first_class_disk":     &hcldec.BlockListSpec{TypeName: "first_class_disk", Nested: hcldec.ObjectSpec((*common.FlatFirstClassDiskConfig)(nil).HCL2Spec())},
+		"storage_policy":       &hcldec.AttrSpec{Name: "storage_policy", Type: cty.String, Required: false},
+		"attach_disks":         &hcldec.AttrSpec{Name: "attach_disks", Type: cty.List(cty.String), Required: false},
+		"attach_disk_mode":     &hcldec.AttrSpec{Name: "attach_disk_mode", Type: cty.String, Required: false},
 		"network_adapters":     &hcldec.BlockListSpec{TypeName: "network_adapters", Nested: hcldec.ObjectSpec((*FlatNIC)(nil).HCL2Spec())},
 		"usb_controller":       &hcldec.AttrSpec{Name: "usb_controller", Type: cty.List(cty.String), Required: false},
 		"notes":                &hcldec.AttrSpec{Name: "notes", Type: cty.String, Required: false},
+		"append_notes":         &hcldec.AttrSpec{Name: "append_notes", Type: cty.Bool, Required: false},
 		"destroy":              &hcldec.AttrSpec{Name: "destroy", Type: cty.Bool, Required: false},
 	}
 	return s
@@ -48,10 +58,14 @@ func (*FlatCreateConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatNIC is an auto-generated flat version of NIC.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatNIC struct {
-	Network     *string `mapstructure:"network" cty:"network" hcl:"network"`
-	NetworkCard *string `mapstructure:"network_card" required:"true" cty:"network_card" hcl:"network_card"`
-	MacAddress  *string `mapstructure:"mac_address" cty:"mac_address" hcl:"mac_address"`
-	Passthrough *bool   `mapstructure:"passthrough" cty:"passthrough" hcl:"passthrough"`
+	Network           *string `mapstructure:"network" cty:"network" hcl:"network"`
+	NetworkSwitch     *string `mapstructure:"network_switch" cty:"network_switch" hcl:"network_switch"`
+	NetworkHost       *string `mapstructure:"network_host" cty:"network_host" hcl:"network_host"`
+	NetworkCard       *string `mapstructure:"network_card" required:"true" cty:"network_card" hcl:"network_card"`
+	MacAddress        *string `mapstructure:"mac_address" cty:"mac_address" hcl:"mac_address"`
+	Passthrough       *bool   `mapstructure:"passthrough" cty:"passthrough" hcl:"passthrough"`
+	StartDisconnected *bool   `mapstructure:"start_disconnected" cty:"start_disconnected" hcl:"start_disconnected"`
+	PhysicalFunction  *string `mapstructure:"physical_function" cty:"physical_function" hcl:"physical_function"`
 }
 
 // FlatMapstructure returns a new FlatNIC.
@@ -66,10 +80,14 @@ func (*NIC) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
 // The decoded values from this spec will then be applied to a FlatNIC.
 func (*FlatNIC) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"network":      &hcldec.AttrSpec{Name: "network", Type: cty.String, Required: false},
-		"network_card": &hcldec.AttrSpec{Name: "network_card", Type: cty.String, Required: false},
-		"mac_address":  &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
-		"passthrough":  &hcldec.AttrSpec{Name: "passthrough", Type: cty.Bool, Required: false},
+		"network":            &hcldec.AttrSpec{Name: "network", Type: cty.String, Required: false},
+		"network_switch":     &hcldec.AttrSpec{Name: "network_switch", Type: cty.String, Required: false},
+		"network_host":       &hcldec.AttrSpec{Name: "network_host", Type: cty.String, Required: false},
+		"network_card":       &hcldec.AttrSpec{Name: "network_card", Type: cty.String, Required: false},
+		"mac_address":        &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
+		"passthrough":        &hcldec.AttrSpec{Name: "passthrough", Type: cty.Bool, Required: false},
+		"start_disconnected": &hcldec.AttrSpec{Name: "start_disconnected", Type: cty.Bool, Required: false},
+		"physical_function":  &hcldec.AttrSpec{Name: "physical_function", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -16,6 +16,7 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func TestCreateConfig_Prepare(t *testing.T) {
@@ -77,6 +78,92 @@ func TestCreateConfig_Prepare(t *testing.T) {
 			fail:           true,
 			expectedErrMsg: "storage[0].'disk_controller_index' references an unknown disk controller",
 		},
+		{
+			name: "Storage validate disk_path is required for disk_keep_on_destroy",
+			config: &CreateConfig{
+				StorageConfig: common.StorageConfig{
+					Storage: []common.DiskConfig{
+						{
+							DiskSize:          32768,
+							DiskKeepOnDestroy: true,
+						},
+					},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "storage[0].'disk_path' is required when 'disk_keep_on_destroy' is set",
+		},
+		{
+			name: "Storage validate disk_path is a datastore path",
+			config: &CreateConfig{
+				StorageConfig: common.StorageConfig{
+					Storage: []common.DiskConfig{
+						{
+							DiskSize: 32768,
+							DiskPath: "packer-disks/cache.vmdk",
+						},
+					},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "storage[0].'disk_path' must be a datastore path, for example '[datastore1] packer-disks/disk.vmdk'",
+		},
+		{
+			name: "Storage validate disk_keep_on_destroy with disk_path",
+			config: &CreateConfig{
+				StorageConfig: common.StorageConfig{
+					Storage: []common.DiskConfig{
+						{
+							DiskSize:          32768,
+							DiskPath:          "[datastore1] packer-disks/cache.vmdk",
+							DiskKeepOnDestroy: true,
+							DiskReuseExisting: true,
+						},
+					},
+				},
+			},
+			fail: false,
+		},
+		{
+			name: "AttachDisks validate storage is not required",
+			config: &CreateConfig{
+				AttachDisks: []string{"[datastore1] images/data.vmdk"},
+			},
+			fail: false,
+		},
+		{
+			name: "AttachDisks validate datastore path",
+			config: &CreateConfig{
+				AttachDisks: []string{"images/data.vmdk"},
+			},
+			fail:           true,
+			expectedErrMsg: "attach_disks[0] must be a datastore path, for example '[datastore1] images/data.vmdk'",
+		},
+		{
+			name: "AttachDisks validate file names of copies",
+			config: &CreateConfig{
+				AttachDisks: []string{"[datastore1] a/data.vmdk", "[datastore2] b/data.vmdk"},
+			},
+			fail:           true,
+			expectedErrMsg: "attach_disks[1] has the same file name as another disk to copy: data.vmdk",
+		},
+		{
+			name: "AttachDisks validate file names of linked disks",
+			config: &CreateConfig{
+				AttachDisks:    []string{"[datastore1] a/data.vmdk", "[datastore2] b/data.vmdk"},
+				AttachDiskMode: "link",
+			},
+			fail: false,
+		},
+		{
+			name: "AttachDisks validate attach_disk_mode",
+			config: &CreateConfig{
+				AttachDisks:    []string{"[datastore1] images/data.vmdk"},
+				AttachDiskMode: "move",
+			},
+			fail:           true,
+			expectedErrMsg: "'attach_disk_mode' must be one of 'copy' or 'link'",
+		},
 		{
 			name: "USBController validate 'usb' and 'xhci' can be set together",
 			config: &CreateConfig{
@@ -179,6 +266,68 @@ func TestCreateConfig_Prepare(t *testing.T) {
 			fail:           true,
 			expectedErrMsg: "usb_controller[0] references an unknown usb controller",
 		},
+		{
+			name: "NIC validate physical_function requires an SR-IOV network card",
+			config: &CreateConfig{
+				NICs: []NIC{
+					{
+						Network:          "VM Network",
+						NetworkCard:      "vmxnet3",
+						PhysicalFunction: "vmnic4",
+					},
+				},
+				StorageConfig: common.StorageConfig{
+					Storage: []common.DiskConfig{
+						{
+							DiskSize: 32768,
+						},
+					},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "network_adapters[0] must set 'network_card' to 'sriov' when 'physical_function' is set",
+		},
+		{
+			name: "NIC validate passthrough cannot be enabled for an SR-IOV network card",
+			config: &CreateConfig{
+				NICs: []NIC{
+					{
+						Network:     "VM Network",
+						NetworkCard: "sriov",
+						Passthrough: types.NewBool(true),
+					},
+				},
+				StorageConfig: common.StorageConfig{
+					Storage: []common.DiskConfig{
+						{
+							DiskSize: 32768,
+						},
+					},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "network_adapters[0] cannot enable 'passthrough' for an SR-IOV network adapter",
+		},
+		{
+			name: "NIC validate SR-IOV network card with physical_function",
+			config: &CreateConfig{
+				NICs: []NIC{
+					{
+						Network:          "VM Network",
+						NetworkCard:      "sriov",
+						PhysicalFunction: "0000:3b:00.0",
+					},
+				},
+				StorageConfig: common.StorageConfig{
+					Storage: []common.DiskConfig{
+						{
+							DiskSize: 32768,
+						},
+					},
+				},
+			},
+			fail: false,
+		},
 	}
 
 	for _, c := range tc {
@@ -416,5 +565,8 @@ func driverCreateConfig(config *CreateConfig, location *common.LocationConfig) *
 		NICs:          networkCards,
 		USBController: config.USBController,
 		Version:       config.Version,
+
+		AttachDisks:    config.AttachDisks,
+		AttachDiskMode: config.AttachDiskMode,
 	}
 }
@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package supervisor
+
+import (
+	"fmt"
+
+	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
+)
+
+// BuilderId is the identifier of the artifacts of the vsphere-supervisor
+// builder.
+const BuilderId = "vsphere.supervisor"
+
+// Artifact is the VM image that the source VM is published as. The name of
+// the artifact is the name of the VirtualMachineImage in the Supervisor
+// namespace, which can be referenced by VirtualMachine resources.
+type Artifact struct {
+	// The name of the VirtualMachineImage.
+	ImageName string
+	// The Supervisor namespace of the image.
+	Namespace string
+	// The name of the content library that the image is published to.
+	PublishLocationName string
+	// The name of the source VM.
+	SourceName string
+	// StateData should store data such as GeneratedData
+	// to be shared with post-processors
+	StateData map[string]interface{}
+}
+
+func (a *Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *Artifact) Files() []string {
+	return nil
+}
+
+func (a *Artifact) Id() string {
+	return a.ImageName
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("VM image %q in namespace %q (content library %q)", a.ImageName, a.Namespace, a.PublishLocationName)
+}
+
+func (a *Artifact) State(name string) interface{} {
+	if name == registryimage.ArtifactStateURI {
+		img, _ := registryimage.FromArtifact(a,
+			registryimage.WithID(a.ImageName),
+			registryimage.WithRegion(a.Namespace),
+			registryimage.WithProvider("vsphere-supervisor"),
+			registryimage.WithSourceID(a.SourceName),
+			registryimage.SetLabels(map[string]interface{}{
+				"publish_location_name": a.PublishLocationName,
+			}),
+		)
+		return img
+	}
+	return a.StateData[name]
+}
+
+// Destroy does not delete the image, which is an item of the content library
+// that is managed by vSphere.
+func (a *Artifact) Destroy() error {
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package supervisor_test
+
+import (
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/supervisor"
+)
+
+func TestArtifact_ImplementsArtifact(t *testing.T) {
+	var _ packersdk.Artifact = &supervisor.Artifact{}
+}
+
+func TestArtifact(t *testing.T) {
+	artifact := &supervisor.Artifact{
+		ImageName:           "vmi-0123456789abcdef0",
+		Namespace:           "test-namespace",
+		PublishLocationName: "test-library",
+		SourceName:          "test-source",
+		StateData: map[string]interface{}{
+			supervisor.StateKeyPublishedImageName: "vmi-0123456789abcdef0",
+		},
+	}
+
+	if id := artifact.Id(); id != "vmi-0123456789abcdef0" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "vmi-0123456789abcdef0", id)
+	}
+	if id := artifact.BuilderId(); id != supervisor.BuilderId {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", supervisor.BuilderId, id)
+	}
+	if name := artifact.State(supervisor.StateKeyPublishedImageName); name != "vmi-0123456789abcdef0" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", "vmi-0123456789abcdef0", name)
+	}
+
+	img, ok := artifact.State(registryimage.ArtifactStateURI).(*registryimage.Image)
+	if !ok {
+		t.Fatal("unexpected result: expected the HCP Packer registry image")
+	}
+	if img.ImageID != "vmi-0123456789abcdef0" || img.ProviderRegion != "test-namespace" {
+		t.Fatalf("unexpected result: returned '%#v'", img)
+	}
+	if img.Labels["publish_location_name"] != "test-library" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "test-library", img.Labels["publish_location_name"])
+	}
+}
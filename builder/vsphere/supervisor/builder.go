@@ -5,6 +5,7 @@ package supervisor
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
@@ -59,8 +60,22 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		},
 	)
 
+	// Serve the local source image over HTTP so that the Supervisor cluster can import it.
+	if b.config.ImportImageConfig.ImportSourcePath != "" {
+		steps = append(steps,
+			&common.StepHTTPIPDiscover{
+				HTTPIP: b.config.ImportImageConfig.ImportSourceHTTPIP,
+			},
+			&commonsteps.StepHTTPServer{
+				HTTPDir:     filepath.Dir(b.config.ImportImageConfig.ImportSourcePath),
+				HTTPPortMin: b.config.ImportImageConfig.ImportSourceHTTPPortMin,
+				HTTPPortMax: b.config.ImportImageConfig.ImportSourceHTTPPortMax,
+			},
+		)
+	}
+
 	// conditionally add steps to validate import spec and import images from source URL as VM image.
-	if b.config.ImportImageConfig.ImportSourceURL != "" {
+	if b.config.ImportImageConfig.ImportSourceURL != "" || b.config.ImportImageConfig.ImportSourcePath != "" {
 		steps = append(steps,
 			&StepImportImage{
 				ImportImageConfig: &b.config.ImportImageConfig,
@@ -74,6 +89,10 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Config:             &b.config.CreateSourceConfig,
 			CommunicatorConfig: &b.config.CommunicatorConfig,
 		},
+		// Log the diagnostics of the source VM if the build fails before connecting to it.
+		&StepConsoleLog{
+			Config: &b.config.ConsoleLogConfig,
+		},
 		// Watch for the source VM to be powered on and accessible.
 		&StepWatchSource{
 			Config: &b.config.WatchSourceConfig,
@@ -100,13 +119,27 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	}
 
 	logger.Info("Build 'vsphere-supervisor' finished successfully.")
-	return nil, nil
+
+	// The artifact is the VM image of the published source VM, if published.
+	imageName, _ := state.Get(StateKeyPublishedImageName).(string)
+	if imageName == "" {
+		return nil, nil
+	}
+	return &Artifact{
+		ImageName:           imageName,
+		Namespace:           b.config.SupervisorNamespace,
+		PublishLocationName: b.config.PublishLocationName,
+		SourceName:          b.config.SourceName,
+		StateData: map[string]interface{}{
+			StateKeyPublishedImageName: imageName,
+		},
+	}, nil
 }
 
 func (b *Builder) getCommunicatorStepConnect() *communicator.StepConnect {
 	stepConnect := &communicator.StepConnect{
 		Config: &b.config.CommunicatorConfig,
-		Host:   common.CommHost(b.config.CommunicatorConfig.Host()),
+		Host:   common.CommConnectHost(&b.config.CommunicatorConfig),
 	}
 
 	if b.config.CommunicatorConfig.Type == "ssh" {
@@ -28,6 +28,7 @@ type Config struct {
 	ImportImageConfig         `mapstructure:",squash"`
 	CreateSourceConfig        `mapstructure:",squash"`
 	WatchSourceConfig         `mapstructure:",squash"`
+	ConsoleLogConfig          `mapstructure:",squash"`
 	PublishSourceConfig       `mapstructure:",squash"`
 
 	ctx interpolate.Context
@@ -49,6 +50,8 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		return nil, err
 	}
 
+	common.RegisterSensitiveValues(c)
+
 	// Set a default username as it's required for both SSH and WinRM communicators.
 	// This must call before the CommunicatorConfig.Prepare to avoid an error.
 	commType := c.CommunicatorConfig.Type
@@ -66,6 +69,7 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	errs = packersdk.MultiErrorAppend(errs, c.ImportImageConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.CreateSourceConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.WatchSourceConfig.Prepare()...)
+	errs = packersdk.MultiErrorAppend(errs, c.ConsoleLogConfig.Prepare()...)
 	errs = packersdk.MultiErrorAppend(errs, c.PublishSourceConfig.Prepare()...)
 
 	if len(errs.Errors) > 0 {
@@ -74,3 +78,7 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	return nil, nil
 }
+
+func (c Config) GoString() string { return common.GoString(c) }
+
+func (c FlatConfig) GoString() string { return common.GoString(c) }
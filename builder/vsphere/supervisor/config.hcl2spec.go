@@ -71,6 +71,10 @@ type FlatConfig struct {
 	KubeconfigPath             *string           `mapstructure:"kubeconfig_path" cty:"kubeconfig_path" hcl:"kubeconfig_path"`
 	SupervisorNamespace        *string           `mapstructure:"supervisor_namespace" cty:"supervisor_namespace" hcl:"supervisor_namespace"`
 	ImportSourceURL            *string           `mapstructure:"import_source_url" cty:"import_source_url" hcl:"import_source_url"`
+	ImportSourcePath           *string           `mapstructure:"import_source_path" cty:"import_source_path" hcl:"import_source_path"`
+	ImportSourceHTTPIP         *string           `mapstructure:"import_source_http_ip" cty:"import_source_http_ip" hcl:"import_source_http_ip"`
+	ImportSourceHTTPPortMin    *int              `mapstructure:"import_source_http_port_min" cty:"import_source_http_port_min" hcl:"import_source_http_port_min"`
+	ImportSourceHTTPPortMax    *int              `mapstructure:"import_source_http_port_max" cty:"import_source_http_port_max" hcl:"import_source_http_port_max"`
 	ImportSourceSSLCertificate *string           `mapstructure:"import_source_ssl_certificate" cty:"import_source_ssl_certificate" hcl:"import_source_ssl_certificate"`
 	ImportTargetLocationName   *string           `mapstructure:"import_target_location_name" cty:"import_target_location_name" hcl:"import_target_location_name"`
 	ImportTargetImageType      *string           `mapstructure:"import_target_image_type" cty:"import_target_image_type" hcl:"import_target_image_type"`
@@ -89,6 +93,8 @@ type FlatConfig struct {
 	BootstrapProvider          *string           `mapstructure:"bootstrap_provider" cty:"bootstrap_provider" hcl:"bootstrap_provider"`
 	BootstrapDataFile          *string           `mapstructure:"bootstrap_data_file" cty:"bootstrap_data_file" hcl:"bootstrap_data_file"`
 	WatchSourceTimeoutSec      *int              `mapstructure:"watch_source_timeout_sec" cty:"watch_source_timeout_sec" hcl:"watch_source_timeout_sec"`
+	ConsoleLog                 *bool             `mapstructure:"console_log" cty:"console_log" hcl:"console_log"`
+	ConsoleLogTimeoutSec       *int              `mapstructure:"console_log_timeout_sec" cty:"console_log_timeout_sec" hcl:"console_log_timeout_sec"`
 	PublishImageName           *string           `mapstructure:"publish_image_name" cty:"publish_image_name" hcl:"publish_image_name"`
 	WatchPublishTimeoutSec     *int              `mapstructure:"watch_publish_timeout_sec" cty:"watch_publish_timeout_sec" hcl:"watch_publish_timeout_sec"`
 }
@@ -166,6 +172,10 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"kubeconfig_path":               &hcldec.AttrSpec{Name: "kubeconfig_path", Type: cty.String, Required: false},
 		"supervisor_namespace":          &hcldec.AttrSpec{Name: "supervisor_namespace", Type: cty.String, Required: false},
 		"import_source_url":             &hcldec.AttrSpec{Name: "import_source_url", Type: cty.String, Required: false},
+		"import_source_path":            &hcldec.AttrSpec{Name: "import_source_path", Type: cty.String, Required: false},
+		"import_source_http_ip":         &hcldec.AttrSpec{Name: "import_source_http_ip", Type: cty.String, Required: false},
+		"import_source_http_port_min":   &hcldec.AttrSpec{Name: "import_source_http_port_min", Type: cty.Number, Required: false},
+		"import_source_http_port_max":   &hcldec.AttrSpec{Name: "import_source_http_port_max", Type: cty.Number, Required: false},
 		"import_source_ssl_certificate": &hcldec.AttrSpec{Name: "import_source_ssl_certificate", Type: cty.String, Required: false},
 		"import_target_location_name":   &hcldec.AttrSpec{Name: "import_target_location_name", Type: cty.String, Required: false},
 		"import_target_image_type":      &hcldec.AttrSpec{Name: "import_target_image_type", Type: cty.String, Required: false},
@@ -184,6 +194,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"bootstrap_provider":            &hcldec.AttrSpec{Name: "bootstrap_provider", Type: cty.String, Required: false},
 		"bootstrap_data_file":           &hcldec.AttrSpec{Name: "bootstrap_data_file", Type: cty.String, Required: false},
 		"watch_source_timeout_sec":      &hcldec.AttrSpec{Name: "watch_source_timeout_sec", Type: cty.Number, Required: false},
+		"console_log":                   &hcldec.AttrSpec{Name: "console_log", Type: cty.Bool, Required: false},
+		"console_log_timeout_sec":       &hcldec.AttrSpec{Name: "console_log_timeout_sec", Type: cty.Number, Required: false},
 		"publish_image_name":            &hcldec.AttrSpec{Name: "publish_image_name", Type: cty.String, Required: false},
 		"watch_publish_timeout_sec":     &hcldec.AttrSpec{Name: "watch_publish_timeout_sec", Type: cty.Number, Required: false},
 	}
@@ -0,0 +1,232 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type ConsoleLogConfig
+
+package supervisor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/retry"
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultConsoleLogTimeoutSec = 60
+
+	consoleRequestKeyBits = 2048
+)
+
+type ConsoleLogConfig struct {
+	// Log the diagnostics of the source VM if the build fails before the
+	// communicator connects to it, such as when the source VM does not boot
+	// or is not assigned an IP. The status and conditions of the VirtualMachine
+	// object are logged, and a one-time web console ticket is requested
+	// from VM Operator and its URL is logged, so that the console of the
+	// source VM can be opened without access to vCenter Server. Defaults to
+	// `false`.
+	//
+	// -> **Note:** The source VM is deleted after a failed build unless
+	// `keep_input_artifact` is set, which invalidates the ticket.
+	ConsoleLog bool `mapstructure:"console_log"`
+	// The timeout in seconds to wait for the web console ticket. Defaults to
+	// `60`.
+	ConsoleLogTimeoutSec int `mapstructure:"console_log_timeout_sec"`
+}
+
+func (c *ConsoleLogConfig) Prepare() []error {
+	if c.ConsoleLogTimeoutSec < 0 {
+		return []error{fmt.Errorf("'console_log_timeout_sec' must be greater than or equal to 0")}
+	}
+	if c.ConsoleLogTimeoutSec == 0 {
+		c.ConsoleLogTimeoutSec = DefaultConsoleLogTimeoutSec
+	}
+
+	return nil
+}
+
+type StepConsoleLog struct {
+	Config *ConsoleLogConfig
+
+	SourceName, Namespace string
+	KubeClient            client.Client
+}
+
+func (s *StepConsoleLog) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Config.ConsoleLog {
+		return multistep.ActionContinue
+	}
+
+	if err := s.initStep(state); err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup logs the diagnostics of the source VM if the build failed before
+// the communicator connected to it. It runs before the source VM is deleted.
+func (s *StepConsoleLog) Cleanup(state multistep.StateBag) {
+	if !s.Config.ConsoleLog || s.KubeClient == nil {
+		return
+	}
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+	if _, ok := state.GetOk("communicator"); ok {
+		return
+	}
+
+	logger := state.Get("logger").(*PackerLogger)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.Config.ConsoleLogTimeoutSec)*time.Second)
+	defer cancel()
+
+	s.logVMStatus(ctx, logger)
+
+	logger.Info("Requesting a web console ticket for the source VM...")
+	url, request, err := s.requestWebConsole(ctx)
+	if err != nil {
+		logger.Error("Failed to request a web console ticket for the source VM: %s", err)
+		return
+	}
+	logger.Info("Web console URL of the source VM (expires at %s): %s",
+		request.Status.ExpiryTime.Format(time.RFC3339), url)
+	if request.Status.ProxyAddr != "" {
+		logger.Info("The web console is accessible through the proxy at %s", request.Status.ProxyAddr)
+	}
+}
+
+func (s *StepConsoleLog) initStep(state multistep.StateBag) error {
+	if err := CheckRequiredStates(state,
+		StateKeyKubeClient,
+		StateKeySupervisorNamespace,
+		StateKeySourceName,
+	); err != nil {
+		return err
+	}
+
+	var (
+		ok                    bool
+		sourceName, namespace string
+		kubeClient            client.Client
+	)
+
+	if sourceName, ok = state.Get(StateKeySourceName).(string); !ok {
+		return fmt.Errorf("failed to cast %s to type string", StateKeySourceName)
+	}
+	if namespace, ok = state.Get(StateKeySupervisorNamespace).(string); !ok {
+		return fmt.Errorf("failed to cast %s to type string", StateKeySupervisorNamespace)
+	}
+	if kubeClient, ok = state.Get(StateKeyKubeClient).(client.Client); !ok {
+		return fmt.Errorf("failed to cast %s to type client.Client", StateKeyKubeClient)
+	}
+
+	s.SourceName = sourceName
+	s.Namespace = namespace
+	s.KubeClient = kubeClient
+
+	return nil
+}
+
+// logVMStatus logs the power state, phase, and conditions of the source VM,
+// which include the reason if VM Operator fails to create or power on the VM.
+func (s *StepConsoleLog) logVMStatus(ctx context.Context, logger *PackerLogger) {
+	vmObj := &vmopv1alpha1.VirtualMachine{}
+	if err := s.KubeClient.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.SourceName}, vmObj); err != nil {
+		logger.Error("Failed to get the source VM object: %s", err)
+		return
+	}
+
+	logger.Info("Source VM status: phase %q, power state %q, host %q, IP %q",
+		vmObj.Status.Phase, vmObj.Status.PowerState, vmObj.Status.Host, vmObj.Status.VmIp)
+	for _, condition := range vmObj.Status.Conditions {
+		msg := fmt.Sprintf("Source VM condition %s: %s", condition.Type, condition.Status)
+		if condition.Reason != "" {
+			msg += fmt.Sprintf(" (%s)", condition.Reason)
+		}
+		if condition.Message != "" {
+			msg += ": " + condition.Message
+		}
+		logger.Info("%s", msg)
+	}
+}
+
+// requestWebConsole creates a WebConsoleRequest object for the source VM and
+// waits for VM Operator to respond with a ticket. The ticket is encrypted
+// with a key pair generated for the request. Returns the decrypted URL of
+// the web console.
+func (s *StepConsoleLog) requestWebConsole(ctx context.Context) (string, *vmopv1alpha1.WebConsoleRequest, error) {
+	key, err := rsa.GenerateKey(rand.Reader, consoleRequestKeyBits)
+	if err != nil {
+		return "", nil, err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	request := &vmopv1alpha1.WebConsoleRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-console", s.SourceName),
+			Namespace: s.Namespace,
+		},
+		Spec: vmopv1alpha1.WebConsoleRequestSpec{
+			VirtualMachineName: s.SourceName,
+			PublicKey:          string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		},
+	}
+	if err := s.KubeClient.Create(ctx, request); err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = s.KubeClient.Delete(context.Background(), request)
+	}()
+
+	err = retry.Config{
+		RetryDelay: func() time.Duration {
+			return 2 * time.Second
+		},
+		ShouldRetry: func(err error) bool {
+			return !errors.Is(err, context.DeadlineExceeded)
+		},
+	}.Run(ctx, func(ctx context.Context) error {
+		if err := s.KubeClient.Get(ctx, client.ObjectKeyFromObject(request), request); err != nil {
+			return err
+		}
+		if request.Status.Response == "" {
+			return errors.New("web console ticket is empty")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("timed out waiting for the web console ticket")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(request.Status.Response)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode the web console ticket: %s", err)
+	}
+	url, err := rsa.DecryptOAEP(sha512.New(), nil, key, ciphertext, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt the web console ticket: %s", err)
+	}
+
+	return string(url), request, nil
+}
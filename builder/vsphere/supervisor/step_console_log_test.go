@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package supervisor_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	vmopv1alpha1 "github.com/vmware-tanzu/vm-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/supervisor"
+)
+
+func TestConsoleLog_Prepare(t *testing.T) {
+	config := &supervisor.ConsoleLogConfig{}
+	if errs := config.Prepare(); len(errs) != 0 {
+		t.Fatalf("unexpected failure: expected success, but failed: %v", errs[0])
+	}
+	if config.ConsoleLogTimeoutSec != supervisor.DefaultConsoleLogTimeoutSec {
+		t.Fatalf("Default timeout should be %d, but returned %d", supervisor.DefaultConsoleLogTimeoutSec, config.ConsoleLogTimeoutSec)
+	}
+
+	config = &supervisor.ConsoleLogConfig{ConsoleLogTimeoutSec: -1}
+	if errs := config.Prepare(); len(errs) == 0 {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestConsoleLog_Cleanup(t *testing.T) {
+	testNamespace := "test-ns"
+	testSourceName := "test-source"
+	testURL := "wss://esxi-01.example.com:443/ticket/secret"
+
+	vmObj := newFakeVMObj(testNamespace, testSourceName, "")
+	vmObj.Status.Phase = vmopv1alpha1.Created
+	vmObj.Status.PowerState = vmopv1alpha1.VirtualMachinePoweredOn
+	vmObj.Status.Conditions = []vmopv1alpha1.Condition{
+		{
+			Type:    vmopv1alpha1.VirtualMachinePrereqReadyCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  "Ready",
+			Message: "prerequisites are ready",
+		},
+	}
+	kubeClient := newFakeKubeClient(vmObj)
+
+	testWriter := new(bytes.Buffer)
+	state := newBasicTestState(testWriter)
+	state.Put(supervisor.StateKeyKubeClient, kubeClient)
+	state.Put(supervisor.StateKeySupervisorNamespace, testNamespace)
+	state.Put(supervisor.StateKeySourceName, testSourceName)
+
+	step := &supervisor.StepConsoleLog{
+		Config: &supervisor.ConsoleLogConfig{
+			ConsoleLog:           true,
+			ConsoleLogTimeoutSec: 30,
+		},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+
+	// Respond to the web console request like VM Operator, with the URL
+	// encrypted by the public key of the request.
+	go func() {
+		ctx := context.TODO()
+		key := client.ObjectKey{Namespace: testNamespace, Name: testSourceName + "-console"}
+		for i := 0; i < 30; i++ {
+			request := &vmopv1alpha1.WebConsoleRequest{}
+			if err := kubeClient.Get(ctx, key, request); err != nil {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			block, _ := pem.Decode([]byte(request.Spec.PublicKey))
+			publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				t.Errorf("unexpected error: '%s'", err)
+				return
+			}
+			ciphertext, err := rsa.EncryptOAEP(sha512.New(), rand.Reader, publicKey.(*rsa.PublicKey), []byte(testURL), nil)
+			if err != nil {
+				t.Errorf("unexpected error: '%s'", err)
+				return
+			}
+			request.Status.Response = base64.StdEncoding.EncodeToString(ciphertext)
+			request.Status.ExpiryTime = metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			_ = kubeClient.Update(ctx, request)
+			return
+		}
+	}()
+
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+
+	expectedOutput := []string{
+		`Source VM status: phase "Created", power state "poweredOn", host "", IP ""`,
+		"Source VM condition VirtualMachinePrereqReady: True (Ready): prerequisites are ready",
+		"Requesting a web console ticket for the source VM...",
+		"Web console URL of the source VM (expires at 2024-01-01T00:00:00Z): " + testURL,
+	}
+	checkOutputLines(t, testWriter, expectedOutput)
+
+	// The web console request is deleted once the ticket is retrieved.
+	requests := &vmopv1alpha1.WebConsoleRequestList{}
+	if err := kubeClient.List(context.TODO(), requests); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(requests.Items) != 0 {
+		t.Fatalf("unexpected result: expected no web console requests, but returned %d", len(requests.Items))
+	}
+}
+
+func TestConsoleLog_CleanupAfterConnect(t *testing.T) {
+	testWriter := new(bytes.Buffer)
+	state := newBasicTestState(testWriter)
+	state.Put(supervisor.StateKeyKubeClient, newFakeKubeClient())
+	state.Put(supervisor.StateKeySupervisorNamespace, "test-ns")
+	state.Put(supervisor.StateKeySourceName, "test-source")
+
+	step := &supervisor.StepConsoleLog{
+		Config: &supervisor.ConsoleLogConfig{ConsoleLog: true},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
+	}
+
+	// The diagnostics are not logged if the build fails after the
+	// communicator connects, such as in a provisioner.
+	state.Put(multistep.StateHalted, true)
+	state.Put("communicator", struct{}{})
+	step.Cleanup(state)
+
+	if output := strings.TrimSpace(testWriter.String()); output != "" {
+		t.Fatalf("unexpected result: expected no output, but returned '%s'", output)
+	}
+}
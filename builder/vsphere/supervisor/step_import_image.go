@@ -9,6 +9,10 @@ package supervisor
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +32,9 @@ const (
 
 	DefaultWatchImageImportTimeoutSec = 600
 
+	DefaultImportSourceHTTPPortMin = 8000
+	DefaultImportSourceHTTPPortMax = 9000
+
 	ImportRequestDefaultNamePrefix = "packer-vsphere-supervisor-import-req-"
 
 	StateKeyImageImportRequestCreated = "item_import_req_created"
@@ -39,6 +46,21 @@ const (
 type ImportImageConfig struct {
 	// The remote URL where the to-be-imported image is hosted.
 	ImportSourceURL string `mapstructure:"import_source_url"`
+	// The local path of an OVF or OVA file to import. The directory that
+	// contains the file is served over HTTP from the host running Packer for
+	// the duration of the build, so the Supervisor cluster must be able to
+	// reach this host. Cannot be used with `import_source_url`.
+	ImportSourcePath string `mapstructure:"import_source_path"`
+	// The IP address of the host running Packer on which the file specified
+	// by `import_source_path` is served. Defaults to the first non-loopback
+	// IPv4 address of the host.
+	ImportSourceHTTPIP string `mapstructure:"import_source_http_ip"`
+	// The minimum port on which the file specified by `import_source_path` is
+	// served. Defaults to `8000`.
+	ImportSourceHTTPPortMin int `mapstructure:"import_source_http_port_min"`
+	// The maximum port on which the file specified by `import_source_path` is
+	// served. Defaults to `9000`.
+	ImportSourceHTTPPortMax int `mapstructure:"import_source_http_port_max"`
 	// The SSL certificate of the remote HTTP server that hosts the to-be-imported image.
 	ImportSourceSSLCertificate string `mapstructure:"import_source_ssl_certificate"`
 	// Name of a writable and import-allowed ContentLibrary resource in the namespace where the image will be imported.
@@ -64,11 +86,41 @@ type ImportImageConfig struct {
 }
 
 func (c *ImportImageConfig) Prepare() []error {
-	if c.ImportSourceURL == "" {
+	if c.ImportSourceURL == "" && c.ImportSourcePath == "" {
 		return nil
 	}
 
 	var errs []error
+	if c.ImportSourceURL != "" && c.ImportSourcePath != "" {
+		errs = append(errs, fmt.Errorf("config import_source_url and import_source_path cannot be used together"))
+	}
+
+	if c.ImportSourcePath != "" {
+		switch strings.ToLower(filepath.Ext(c.ImportSourcePath)) {
+		case ".ovf", ".ova":
+			if info, err := os.Stat(c.ImportSourcePath); err != nil {
+				errs = append(errs, fmt.Errorf("config import_source_path is not accessible: %s", err))
+			} else if info.IsDir() {
+				errs = append(errs, fmt.Errorf("config import_source_path must be a file"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("config import_source_path must be an OVF or OVA file"))
+		}
+
+		if c.ImportSourceHTTPIP != "" && net.ParseIP(c.ImportSourceHTTPIP) == nil {
+			errs = append(errs, fmt.Errorf("config import_source_http_ip is not a valid IP address"))
+		}
+		if c.ImportSourceHTTPPortMin == 0 {
+			c.ImportSourceHTTPPortMin = DefaultImportSourceHTTPPortMin
+		}
+		if c.ImportSourceHTTPPortMax == 0 {
+			c.ImportSourceHTTPPortMax = DefaultImportSourceHTTPPortMax
+		}
+		if c.ImportSourceHTTPPortMin > c.ImportSourceHTTPPortMax {
+			errs = append(errs, fmt.Errorf("config import_source_http_port_min must be less than or equal to import_source_http_port_max"))
+		}
+	}
+
 	if c.ImportTargetLocationName == "" {
 		errs = append(errs, fmt.Errorf("config import_target_location_name is required for importing image"))
 	}
@@ -99,6 +151,10 @@ func (c *ImportImageConfig) Prepare() []error {
 type StepImportImage struct {
 	ImportImageConfig *ImportImageConfig
 
+	// The URL of the image to import, which is either the configured source
+	// URL or the URL of the local source file served from the host.
+	SourceURL string
+
 	ImportItemResourceName, Namespace string
 	TargetItemType                    imgregv1.ContentLibraryItemType
 	KubeWatchClient                   client.WithWatch
@@ -129,7 +185,7 @@ func (s *StepImportImage) Run(ctx context.Context, state multistep.StateBag) mul
 	}
 
 	logger.Info("Importing the source image from %s to %s.",
-		s.ImportImageConfig.ImportSourceURL, s.ImportImageConfig.ImportTargetLocationName)
+		s.SourceURL, s.ImportImageConfig.ImportTargetLocationName)
 
 	if err = s.createImageImportRequest(ctx, logger); err != nil {
 		return multistep.ActionHalt
@@ -141,7 +197,7 @@ func (s *StepImportImage) Run(ctx context.Context, state multistep.StateBag) mul
 	}
 
 	logger.Info("Finished importing the image from %s to %s.",
-		s.ImportImageConfig.ImportSourceURL, s.ImportImageConfig.ImportTargetLocationName)
+		s.SourceURL, s.ImportImageConfig.ImportTargetLocationName)
 
 	return multistep.ActionContinue
 }
@@ -234,6 +290,23 @@ func (s *StepImportImage) initStep(state multistep.StateBag, logger *PackerLogge
 		return fmt.Errorf("failed to cast %s to type client.WithWatch", StateKeyKubeClient)
 	}
 
+	s.SourceURL = s.ImportImageConfig.ImportSourceURL
+	if s.ImportImageConfig.ImportSourcePath != "" {
+		ip, ok := state.Get("http_ip").(string)
+		if !ok {
+			return fmt.Errorf("failed to cast http_ip to type string")
+		}
+		port, ok := state.Get("http_port").(int)
+		if !ok {
+			return fmt.Errorf("failed to cast http_port to type int")
+		}
+		s.SourceURL = (&url.URL{
+			Scheme: "http",
+			Host:   net.JoinHostPort(ip, fmt.Sprint(port)),
+			Path:   "/" + filepath.Base(s.ImportImageConfig.ImportSourcePath),
+		}).String()
+	}
+
 	if s.ImportImageConfig.ImportTargetImageType != "" {
 		s.TargetItemType = imgregv1.ContentLibraryItemType(strings.ToUpper(s.ImportImageConfig.ImportTargetImageType))
 	}
@@ -290,7 +363,7 @@ func (s *StepImportImage) createImageImportRequest(ctx context.Context, logger *
 		},
 		Spec: imgregv1.ContentLibraryItemImportRequestSpec{
 			Source: imgregv1.ContentLibraryItemImportRequestSource{
-				URL:            s.ImportImageConfig.ImportSourceURL,
+				URL:            s.SourceURL,
 				SSLCertificate: s.ImportImageConfig.ImportSourceSSLCertificate,
 			},
 			Target: imgregv1.ContentLibraryItemImportRequestTarget{
@@ -11,6 +11,10 @@ import (
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatImportImageConfig struct {
 	ImportSourceURL            *string `mapstructure:"import_source_url" cty:"import_source_url" hcl:"import_source_url"`
+	ImportSourcePath           *string `mapstructure:"import_source_path" cty:"import_source_path" hcl:"import_source_path"`
+	ImportSourceHTTPIP         *string `mapstructure:"import_source_http_ip" cty:"import_source_http_ip" hcl:"import_source_http_ip"`
+	ImportSourceHTTPPortMin    *int    `mapstructure:"import_source_http_port_min" cty:"import_source_http_port_min" hcl:"import_source_http_port_min"`
+	ImportSourceHTTPPortMax    *int    `mapstructure:"import_source_http_port_max" cty:"import_source_http_port_max" hcl:"import_source_http_port_max"`
 	ImportSourceSSLCertificate *string `mapstructure:"import_source_ssl_certificate" cty:"import_source_ssl_certificate" hcl:"import_source_ssl_certificate"`
 	ImportTargetLocationName   *string `mapstructure:"import_target_location_name" cty:"import_target_location_name" hcl:"import_target_location_name"`
 	ImportTargetImageType      *string `mapstructure:"import_target_image_type" cty:"import_target_image_type" hcl:"import_target_image_type"`
@@ -34,6 +38,10 @@ func (*ImportImageConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hc
 func (*FlatImportImageConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
 		"import_source_url":             &hcldec.AttrSpec{Name: "import_source_url", Type: cty.String, Required: false},
+		"import_source_path":            &hcldec.AttrSpec{Name: "import_source_path", Type: cty.String, Required: false},
+		"import_source_http_ip":         &hcldec.AttrSpec{Name: "import_source_http_ip", Type: cty.String, Required: false},
+		"import_source_http_port_min":   &hcldec.AttrSpec{Name: "import_source_http_port_min", Type: cty.Number, Required: false},
+		"import_source_http_port_max":   &hcldec.AttrSpec{Name: "import_source_http_port_max", Type: cty.Number, Required: false},
 		"import_source_ssl_certificate": &hcldec.AttrSpec{Name: "import_source_ssl_certificate", Type: cty.String, Required: false},
 		"import_target_location_name":   &hcldec.AttrSpec{Name: "import_target_location_name", Type: cty.String, Required: false},
 		"import_target_image_type":      &hcldec.AttrSpec{Name: "import_target_image_type", Type: cty.String, Required: false},
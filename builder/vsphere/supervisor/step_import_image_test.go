@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -93,6 +95,93 @@ func TestImportImage_Prepare(t *testing.T) {
 	}
 }
 
+func TestImportImage_PrepareSourcePath(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "example.ova")
+	if err := os.WriteFile(sourcePath, nil, 0644); err != nil {
+		t.Fatalf("failed to create the source file: %s", err)
+	}
+
+	// 1. Prepare() should succeed and set the default ports.
+	config := &supervisor.ImportImageConfig{
+		ImportSourcePath:         sourcePath,
+		ImportTargetLocationName: testTargetLibrary,
+	}
+	if actualErrs := config.Prepare(); len(actualErrs) != 0 {
+		t.Fatalf("Prepare should NOT fail: %v", actualErrs)
+	}
+	if config.ImportSourceHTTPPortMin != supervisor.DefaultImportSourceHTTPPortMin ||
+		config.ImportSourceHTTPPortMax != supervisor.DefaultImportSourceHTTPPortMax {
+		t.Fatalf("Default ports should be %d-%d, but got %d-%d",
+			supervisor.DefaultImportSourceHTTPPortMin, supervisor.DefaultImportSourceHTTPPortMax,
+			config.ImportSourceHTTPPortMin, config.ImportSourceHTTPPortMax)
+	}
+
+	// 2. Prepare() should fail by setting both the source URL and path.
+	config.ImportSourceURL = "http://example.com/example.ova"
+	actualErrs := config.Prepare()
+	if len(actualErrs) != 1 {
+		t.Fatalf("Prepare should have failed.")
+	}
+	expectedErr := "config import_source_url and import_source_path cannot be used together"
+	if actualErrs[0].Error() != expectedErr {
+		t.Fatalf("expected error is %v, but got %v", expectedErr, actualErrs[0].Error())
+	}
+
+	// 3. Prepare() should fail by setting a path that is not an OVF or OVA file.
+	config.ImportSourceURL = ""
+	config.ImportSourcePath = filepath.Join(dir, "example.iso")
+	actualErrs = config.Prepare()
+	if len(actualErrs) != 1 {
+		t.Fatalf("Prepare should have failed.")
+	}
+	expectedErr = "config import_source_path must be an OVF or OVA file"
+	if actualErrs[0].Error() != expectedErr {
+		t.Fatalf("expected error is %v, but got %v", expectedErr, actualErrs[0].Error())
+	}
+
+	// 4. Prepare() should fail by setting an invalid IP address.
+	config.ImportSourcePath = sourcePath
+	config.ImportSourceHTTPIP = "example.com"
+	actualErrs = config.Prepare()
+	if len(actualErrs) != 1 {
+		t.Fatalf("Prepare should have failed.")
+	}
+	expectedErr = "config import_source_http_ip is not a valid IP address"
+	if actualErrs[0].Error() != expectedErr {
+		t.Fatalf("expected error is %v, but got %v", expectedErr, actualErrs[0].Error())
+	}
+}
+
+func TestStepImportImage_Run_SourcePath(t *testing.T) {
+	config := &supervisor.ImportImageConfig{
+		ImportRequestName:        testImportReqName,
+		ImportSourcePath:         filepath.Join("images", "example.ova"),
+		ImportTargetLocationName: testTargetLibrary,
+		ImportTargetImageType:    "ovf",
+	}
+	step := &supervisor.StepImportImage{
+		ImportImageConfig: config,
+	}
+
+	testWriter := new(bytes.Buffer)
+	state := newBasicTestState(testWriter)
+	state.Put(supervisor.StateKeySupervisorNamespace, testNamespace)
+	state.Put(supervisor.StateKeyKubeClient, newFakeKubeClient())
+	state.Put("http_ip", "10.0.0.1")
+	state.Put("http_port", 8080)
+
+	// The step halts as the target content library does not exist, after the source URL is set.
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatal("Step should halt")
+	}
+
+	expectedURL := "http://10.0.0.1:8080/example.ova"
+	if step.SourceURL != expectedURL {
+		t.Fatalf("expected source URL to be '%s', got '%s'", expectedURL, step.SourceURL)
+	}
+}
+
 func TestStepImportImage_Run_Validate(t *testing.T) {
 	// 1. Test with `supervisor_namespace` not set.
 	config := &supervisor.ImportImageConfig{
@@ -22,6 +22,7 @@ const (
 	DefaultWatchPublishTimeoutSec = 600
 
 	StateKeyVMPublishRequestCreated = "vm_pub_req_created"
+	StateKeyPublishedImageName      = "published_image_name"
 )
 
 var IsWatchingVMPublish bool
@@ -75,9 +76,11 @@ func (s *StepPublishSource) Run(ctx context.Context, state multistep.StateBag) m
 	}
 	state.Put(StateKeyVMPublishRequestCreated, true)
 
-	if err = s.watchVMPublish(ctx, logger); err != nil {
+	imageName, err := s.watchVMPublish(ctx, logger)
+	if err != nil {
 		return multistep.ActionHalt
 	}
+	state.Put(StateKeyPublishedImageName, imageName)
 
 	logger.Info("Finished publishing the source VM")
 
@@ -173,7 +176,9 @@ func (s *StepPublishSource) createVMPublishRequest(ctx context.Context, logger *
 	return nil
 }
 
-func (s *StepPublishSource) watchVMPublish(ctx context.Context, logger *PackerLogger) error {
+// watchVMPublish waits for the VM to be published and returns the name of the
+// VirtualMachineImage of the published VM.
+func (s *StepPublishSource) watchVMPublish(ctx context.Context, logger *PackerLogger) (string, error) {
 	vmPublishReqWatch, err := s.KubeWatchClient.Watch(ctx, &vmopv1alpha1.VirtualMachinePublishRequestList{}, &client.ListOptions{
 		FieldSelector: fields.OneTermEqualSelector("metadata.name", s.SourceName),
 		Namespace:     s.Namespace,
@@ -181,7 +186,7 @@ func (s *StepPublishSource) watchVMPublish(ctx context.Context, logger *PackerLo
 
 	if err != nil {
 		logger.Error("Failed to watch the VirtualMachinePublishRequest object in Supervisor cluster")
-		return err
+		return "", err
 	}
 
 	timedCtx, cancel := context.WithTimeout(ctx, time.Duration(s.Config.WatchPublishTimeoutSec)*time.Second)
@@ -203,23 +208,23 @@ func (s *StepPublishSource) watchVMPublish(ctx context.Context, logger *PackerLo
 		select {
 		case event := <-vmPublishReqWatch.ResultChan():
 			if event.Object == nil {
-				return fmt.Errorf("watch VirtualMachinePublishRequest event object is nil")
+				return "", fmt.Errorf("watch VirtualMachinePublishRequest event object is nil")
 			}
 
 			vmPublishReqObj, ok := event.Object.(*vmopv1alpha1.VirtualMachinePublishRequest)
 			if !ok {
-				return fmt.Errorf("failed to convert the watch VirtualMachinePublishRequest event object")
+				return "", fmt.Errorf("failed to convert the watch VirtualMachinePublishRequest event object")
 			}
 
 			if !vmPublishReqObj.Status.Ready {
 				logger.Info("Waiting for the VM publish request to complete...")
 			} else {
 				logger.Info("Successfully published the VM to image %q", vmPublishReqObj.Status.ImageName)
-				return nil
+				return vmPublishReqObj.Status.ImageName, nil
 			}
 
 		case <-timedCtx.Done():
-			return fmt.Errorf("timed out watching for VirtualMachinePublishRequest object to complete")
+			return "", fmt.Errorf("timed out watching for VirtualMachinePublishRequest object to complete")
 		}
 	}
 }
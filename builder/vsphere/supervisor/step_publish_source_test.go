@@ -96,6 +96,9 @@ func TestStepPublishSource_Run(t *testing.T) {
 			}
 			t.Errorf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
 		}
+		if imageName := state.Get(supervisor.StateKeyPublishedImageName); imageName != testImageName {
+			t.Errorf("unexpected result: expected '%s', but returned '%v'", testImageName, imageName)
+		}
 
 		// check if the VirtualMachinePublishRequest object is created with the expected spec.
 		objKey := client.ObjectKey{
@@ -0,0 +1,257 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,Tag,DatasourceOutput
+
+package contentlibrary
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The content library item types that can be used as a source for a build.
+// ISO items are matched only if `types` includes `iso`.
+var defaultItemTypes = []string{"ovf", "vm-template"}
+
+type Config struct {
+	common.ConnectConfig `mapstructure:",squash"`
+	// The name of the content library to search. If not specified, all
+	// content libraries are searched.
+	Library string `mapstructure:"library"`
+	// The name of the content library item.
+	Name string `mapstructure:"name"`
+	// A regular expression to match the name of the content library item.
+	NameRegex string `mapstructure:"name_regex"`
+	// The tags that must be attached to the content library item.
+	//
+	// HCL Example:
+	//
+	// ```hcl
+	// tag {
+	//   category = "team"
+	//   name     = "operations"
+	// }
+	// ```
+	Tags []Tag `mapstructure:"tag"`
+	// The types of content library items to match, such as `iso`, `ovf`, or
+	// `vm-template`. Defaults to `["ovf", "vm-template"]`, the types that can
+	// be used as a source for a build. Set to `["iso"]` to match an ISO item
+	// for `iso_paths`.
+	Types []string `mapstructure:"types"`
+	// Return the most recently modified item if more than one item matches
+	// the filters. Otherwise, the data source fails if more than one item
+	// matches. Defaults to `false`.
+	Latest bool `mapstructure:"latest"`
+
+	nameRegex *regexp.Regexp
+}
+
+type Tag struct {
+	// The name of the tag category.
+	Category string `mapstructure:"category" required:"true"`
+	// The name of the tag.
+	Name string `mapstructure:"name" required:"true"`
+}
+
+type DatasourceOutput struct {
+	// The identifier of the content library item.
+	ItemID string `mapstructure:"item_id"`
+	// The name of the content library item.
+	ItemName string `mapstructure:"item_name"`
+	// The type of the content library item.
+	ItemType string `mapstructure:"item_type"`
+	// The identifier of the content library.
+	LibraryID string `mapstructure:"library_id"`
+	// The name of the content library.
+	LibraryName string `mapstructure:"library_name"`
+	// The version of the content of the content library item.
+	Version string `mapstructure:"version"`
+	// The content library path of the content library item. For an ISO item,
+	// the path of the ISO file, such as `Library/Item/file.iso`, which can be
+	// used in `iso_paths`.
+	Path string `mapstructure:"path"`
+	// The datastore path of the content library item. For an ISO item, the
+	// datastore path of the ISO file.
+	DatastorePath string `mapstructure:"datastore_path"`
+	// The creation time of the content library item in RFC 3339 format.
+	CreationTime string `mapstructure:"creation_time"`
+	// The last modified time of the content library item in RFC 3339 format.
+	LastModifiedTime string `mapstructure:"last_modified_time"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+	common.RegisterSensitiveValues(d.config)
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.ConnectConfig.Prepare()...)
+
+	if d.config.Name != "" && d.config.NameRegex != "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name' and 'name_regex' cannot be used together"))
+	}
+	if d.config.NameRegex != "" {
+		d.config.nameRegex, err = regexp.Compile(d.config.NameRegex)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name_regex' is not a valid regular expression: %s", err))
+		}
+	}
+	for i, tag := range d.config.Tags {
+		if tag.Category == "" || tag.Name == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'category' and 'name' are required for 'tag' %d", i))
+		}
+	}
+	if len(d.config.Types) == 0 {
+		d.config.Types = defaultItemTypes
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	dr, err := driver.NewDriver(d.config.DriverConfig())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error connecting to vCenter Server: %s", err)
+	}
+	vcenter := dr.(*driver.VCenterDriver)
+	defer func() {
+		_, _ = vcenter.Cleanup()
+	}()
+
+	items, err := vcenter.FindContentLibraryItems(d.config.Library, len(d.config.Tags) > 0)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error retrieving content library items: %s", err)
+	}
+
+	item, err := d.config.selectItem(items)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	libraryPath, datastorePath, err := vcenter.FindContentLibraryItemPaths(*item)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error retrieving the paths of content library item %s: %s", item.Item.Name, err)
+	}
+
+	output := DatasourceOutput{
+		ItemID:           item.Item.ID,
+		ItemName:         item.Item.Name,
+		ItemType:         item.Item.Type,
+		LibraryID:        item.Library.ID,
+		LibraryName:      item.Library.Name,
+		Version:          item.Item.ContentVersion,
+		Path:             libraryPath,
+		DatastorePath:    datastorePath,
+		CreationTime:     formatTime(item.Item.CreationTime),
+		LastModifiedTime: formatTime(item.Item.LastModifiedTime),
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// selectItem returns the content library item that matches the filters.
+func (c *Config) selectItem(items []driver.ContentLibraryItem) (*driver.ContentLibraryItem, error) {
+	var matches []driver.ContentLibraryItem
+	for _, item := range items {
+		if c.matches(item) {
+			matches = append(matches, item)
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no content library item matches the filters")
+	case len(matches) == 1:
+		return &matches[0], nil
+	case !c.Latest:
+		return nil, fmt.Errorf("%d content library items match the filters; refine the filters or set 'latest' to true", len(matches))
+	}
+
+	latest := &matches[0]
+	for i := range matches[1:] {
+		if modifiedTime(&matches[i+1]).After(modifiedTime(latest)) {
+			latest = &matches[i+1]
+		}
+	}
+	return latest, nil
+}
+
+func (c *Config) matches(item driver.ContentLibraryItem) bool {
+	if c.Name != "" && item.Item.Name != c.Name {
+		return false
+	}
+	if c.nameRegex != nil && !c.nameRegex.MatchString(item.Item.Name) {
+		return false
+	}
+
+	typeMatch := false
+	for _, t := range c.Types {
+		if item.Item.Type == t {
+			typeMatch = true
+			break
+		}
+	}
+	if !typeMatch {
+		return false
+	}
+
+	for _, tag := range c.Tags {
+		attached := false
+		for _, t := range item.Tags {
+			if t.Category == tag.Category && t.Name == tag.Name {
+				attached = true
+				break
+			}
+		}
+		if !attached {
+			return false
+		}
+	}
+	return true
+}
+
+// modifiedTime returns the last modified time of a content library item, or
+// its creation time if the item has not been modified.
+func modifiedTime(item *driver.ContentLibraryItem) time.Time {
+	if item.Item.LastModifiedTime != nil {
+		return *item.Item.LastModifiedTime
+	}
+	if item.Item.CreationTime != nil {
+		return *item.Item.CreationTime
+	}
+	return time.Time{}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
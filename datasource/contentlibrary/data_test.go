@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package contentlibrary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+func basicConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"vcenter_server": "vcenter.example.com",
+		"username":       "root",
+		"password":       "vmware",
+	}
+}
+
+func TestDatasource_Configure(t *testing.T) {
+	tc := []struct {
+		name   string
+		config map[string]interface{}
+		fail   bool
+	}{
+		{
+			name:   "Should not fail for minimal config",
+			config: map[string]interface{}{},
+		},
+		{
+			name:   "Name and name regex",
+			config: map[string]interface{}{"name": "ubuntu", "name_regex": "^ubuntu"},
+			fail:   true,
+		},
+		{
+			name:   "Invalid name regex",
+			config: map[string]interface{}{"name_regex": "("},
+			fail:   true,
+		},
+		{
+			name:   "Tag without category",
+			config: map[string]interface{}{"tag": []map[string]interface{}{{"name": "stable"}}},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d := new(Datasource)
+			err := d.Configure(basicConfig(), c.config)
+			if c.fail && err == nil {
+				t.Fatalf("unexpected success")
+			}
+			if !c.fail && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+
+	d := new(Datasource)
+	if err := d.Configure(basicConfig()); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(d.config.Types) != len(defaultItemTypes) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", defaultItemTypes, d.config.Types)
+	}
+}
+
+func TestConfig_SelectItem(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+
+	items := []driver.ContentLibraryItem{
+		{
+			Item:    library.Item{ID: "1", Name: "ubuntu-22.04", Type: "ovf", LastModifiedTime: &older},
+			Library: library.Library{ID: "lib-1", Name: "templates"},
+			Tags:    []driver.ContentLibraryItemTag{{Category: "release", Name: "stable"}},
+		},
+		{
+			Item:    library.Item{ID: "2", Name: "ubuntu-24.04", Type: "vm-template", LastModifiedTime: &newer},
+			Library: library.Library{ID: "lib-1", Name: "templates"},
+		},
+		{
+			Item:    library.Item{ID: "3", Name: "ubuntu-24.04.iso", Type: "iso", LastModifiedTime: &newer},
+			Library: library.Library{ID: "lib-1", Name: "templates"},
+		},
+	}
+
+	tc := []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+		fail     bool
+	}{
+		{
+			name:     "Name",
+			config:   map[string]interface{}{"name": "ubuntu-22.04"},
+			expected: "1",
+		},
+		{
+			name:     "Tag",
+			config:   map[string]interface{}{"tag": []map[string]interface{}{{"category": "release", "name": "stable"}}},
+			expected: "1",
+		},
+		{
+			name:   "Multiple matches",
+			config: map[string]interface{}{"name_regex": "^ubuntu"},
+			fail:   true,
+		},
+		{
+			name:     "Latest",
+			config:   map[string]interface{}{"name_regex": "^ubuntu", "latest": true},
+			expected: "2",
+		},
+		{
+			name:     "Type",
+			config:   map[string]interface{}{"name_regex": "^ubuntu", "types": []string{"iso"}},
+			expected: "3",
+		},
+		{
+			name:   "ISO without type",
+			config: map[string]interface{}{"name": "ubuntu-24.04.iso"},
+			fail:   true,
+		},
+		{
+			name:   "No match",
+			config: map[string]interface{}{"name": "windows"},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d := new(Datasource)
+			if err := d.Configure(basicConfig(), c.config); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			item, err := d.config.selectItem(items)
+			if c.fail {
+				if err == nil {
+					t.Fatalf("unexpected success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if item.Item.ID != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, item.Item.ID)
+			}
+		})
+	}
+}
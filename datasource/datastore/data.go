@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package datastore
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type Config struct {
+	common.ConnectConfig `mapstructure:",squash"`
+	// The name of the datastore. Required if `cluster` is not specified.
+	Name string `mapstructure:"name"`
+	// The name of the cluster. The accessible datastore of the cluster with
+	// the most free space is returned. Required if `name` is not specified.
+	Cluster string `mapstructure:"cluster"`
+}
+
+type DatasourceOutput struct {
+	// The managed object identifier of the datastore.
+	ID string `mapstructure:"id"`
+	// The name of the datastore.
+	Name string `mapstructure:"name"`
+	// The type of the datastore, such as `VMFS`, `NFS`, `NFS41`, `vsan`, or
+	// `VVOL`.
+	Type string `mapstructure:"type"`
+	// The capacity of the datastore in bytes.
+	Capacity int64 `mapstructure:"capacity"`
+	// The free space of the datastore in bytes.
+	FreeSpace int64 `mapstructure:"free_space"`
+	// Whether the datastore is accessible.
+	Accessible bool `mapstructure:"accessible"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+	common.RegisterSensitiveValues(d.config)
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.ConnectConfig.Prepare()...)
+
+	if d.config.Name == "" && d.config.Cluster == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name' or 'cluster' is required"))
+	}
+	if d.config.Name != "" && d.config.Cluster != "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name' and 'cluster' cannot be used together"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	dr, err := driver.NewDriver(d.config.DriverConfig())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error connecting to vCenter Server: %s", err)
+	}
+	vcenter := dr.(*driver.VCenterDriver)
+	defer func() {
+		_, _ = vcenter.Cleanup()
+	}()
+
+	var summary *driver.DatastoreSummary
+	if d.config.Name != "" {
+		summary, err = vcenter.DatastoreSummary(d.config.Name)
+	} else {
+		var summaries []driver.DatastoreSummary
+		summaries, err = vcenter.ClusterDatastoreSummaries(d.config.Cluster)
+		if err == nil {
+			summary, err = leastUsed(d.config.Cluster, summaries)
+		}
+	}
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output := DatasourceOutput{
+		ID:         summary.ID,
+		Name:       summary.Name,
+		Type:       summary.Type,
+		Capacity:   summary.Capacity,
+		FreeSpace:  summary.FreeSpace,
+		Accessible: summary.Accessible,
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// leastUsed returns the accessible datastore with the most free space that is
+// not in maintenance mode.
+func leastUsed(cluster string, summaries []driver.DatastoreSummary) (*driver.DatastoreSummary, error) {
+	var result *driver.DatastoreSummary
+	for i, s := range summaries {
+		if !s.Accessible || (s.MaintenanceMode != "" && s.MaintenanceMode != "normal") {
+			continue
+		}
+		if result == nil || s.FreeSpace > result.FreeSpace {
+			result = &summaries[i]
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no accessible datastores found in cluster %s", cluster)
+	}
+	return result, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package datastore
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func basicConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"vcenter_server": "vcenter.example.com",
+		"username":       "root",
+		"password":       "vmware",
+	}
+}
+
+func TestDatasource_Configure(t *testing.T) {
+	tc := []struct {
+		name   string
+		config map[string]interface{}
+		fail   bool
+	}{
+		{
+			name:   "Name",
+			config: map[string]interface{}{"name": "datastore-01"},
+		},
+		{
+			name:   "Cluster",
+			config: map[string]interface{}{"cluster": "cluster-01"},
+		},
+		{
+			name:   "Without name or cluster",
+			config: map[string]interface{}{},
+			fail:   true,
+		},
+		{
+			name:   "Name and cluster",
+			config: map[string]interface{}{"name": "datastore-01", "cluster": "cluster-01"},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d := new(Datasource)
+			err := d.Configure(basicConfig(), c.config)
+			if c.fail && err == nil {
+				t.Fatalf("unexpected success")
+			}
+			if !c.fail && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+}
+
+func TestLeastUsed(t *testing.T) {
+	summaries := []driver.DatastoreSummary{
+		{Name: "datastore-01", FreeSpace: 100, Accessible: true, MaintenanceMode: "normal"},
+		{Name: "datastore-02", FreeSpace: 500, Accessible: false, MaintenanceMode: "normal"},
+		{Name: "datastore-03", FreeSpace: 400, Accessible: true, MaintenanceMode: "inMaintenance"},
+		{Name: "datastore-04", FreeSpace: 300, Accessible: true, MaintenanceMode: "normal"},
+	}
+
+	result, err := leastUsed("cluster-01", summaries)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if result.Name != "datastore-04" {
+		t.Fatalf("unexpected result: expected 'datastore-04', but returned '%s'", result.Name)
+	}
+
+	if _, err := leastUsed("cluster-01", summaries[1:3]); err == nil {
+		t.Fatalf("unexpected success: expected no accessible datastores")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package tag
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type Config struct {
+	common.ConnectConfig `mapstructure:",squash"`
+	// The name of the tag category.
+	Category string `mapstructure:"category" required:"true"`
+	// The name of the tag.
+	Name string `mapstructure:"name" required:"true"`
+	// The types of the objects to return, such as `VirtualMachine` or
+	// `Datastore`. If not specified, all objects the tag is attached to are
+	// returned.
+	ObjectTypes []string `mapstructure:"object_types"`
+}
+
+type DatasourceOutput struct {
+	// The identifier of the tag.
+	TagID string `mapstructure:"tag_id"`
+	// The identifier of the tag category.
+	CategoryID string `mapstructure:"category_id"`
+	// The managed object identifiers of the objects the tag is attached to.
+	ObjectIDs []string `mapstructure:"object_ids"`
+	// The names of the objects the tag is attached to, in the same order as
+	// `object_ids`.
+	ObjectNames []string `mapstructure:"object_names"`
+	// The inventory paths of the objects the tag is attached to, in the same
+	// order as `object_ids`.
+	ObjectPaths []string `mapstructure:"object_paths"`
+	// The types of the objects the tag is attached to, in the same order as
+	// `object_ids`.
+	ObjectTypes []string `mapstructure:"object_types"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+	common.RegisterSensitiveValues(d.config)
+
+	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, d.config.ConnectConfig.Prepare()...)
+
+	if d.config.Category == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'category' is required"))
+	}
+	if d.config.Name == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name' is required"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	dr, err := driver.NewDriver(d.config.DriverConfig())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error connecting to vCenter Server: %s", err)
+	}
+	vcenter := dr.(*driver.VCenterDriver)
+	defer func() {
+		_, _ = vcenter.Cleanup()
+	}()
+
+	tag, err := vcenter.FindTag(d.config.Category, d.config.Name)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output := d.config.output(tag)
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// output returns the data source output for a tag, with the objects filtered
+// by type.
+func (c *Config) output(tag *driver.Tag) DatasourceOutput {
+	output := DatasourceOutput{
+		TagID:       tag.ID,
+		CategoryID:  tag.CategoryID,
+		ObjectIDs:   []string{},
+		ObjectNames: []string{},
+		ObjectPaths: []string{},
+		ObjectTypes: []string{},
+	}
+	for _, o := range tag.Objects {
+		if !c.matchesType(o.Type) {
+			continue
+		}
+		output.ObjectIDs = append(output.ObjectIDs, o.ID)
+		output.ObjectNames = append(output.ObjectNames, o.Name)
+		output.ObjectPaths = append(output.ObjectPaths, o.Path)
+		output.ObjectTypes = append(output.ObjectTypes, o.Type)
+	}
+	return output
+}
+
+func (c *Config) matchesType(objectType string) bool {
+	if len(c.ObjectTypes) == 0 {
+		return true
+	}
+	for _, t := range c.ObjectTypes {
+		if t == objectType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func basicConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"vcenter_server": "vcenter.example.com",
+		"username":       "root",
+		"password":       "vmware",
+	}
+}
+
+func TestDatasource_Configure(t *testing.T) {
+	tc := []struct {
+		name   string
+		config map[string]interface{}
+		fail   bool
+	}{
+		{
+			name:   "Category and name",
+			config: map[string]interface{}{"category": "release", "name": "current-golden"},
+		},
+		{
+			name:   "Without category",
+			config: map[string]interface{}{"name": "current-golden"},
+			fail:   true,
+		},
+		{
+			name:   "Without name",
+			config: map[string]interface{}{"category": "release"},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d := new(Datasource)
+			err := d.Configure(basicConfig(), c.config)
+			if c.fail && err == nil {
+				t.Fatalf("unexpected success")
+			}
+			if !c.fail && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Output(t *testing.T) {
+	tag := &driver.Tag{
+		ID:         "urn:vmomi:InventoryServiceTag:1:GLOBAL",
+		Name:       "current-golden",
+		CategoryID: "urn:vmomi:InventoryServiceCategory:1:GLOBAL",
+		Objects: []driver.TaggedObject{
+			{Type: "VirtualMachine", ID: "vm-1", Name: "ubuntu", Path: "/dc/vm/ubuntu"},
+			{Type: "Datastore", ID: "datastore-1", Name: "ds", Path: "/dc/datastore/ds"},
+		},
+	}
+
+	c := &Config{ObjectTypes: []string{"VirtualMachine"}}
+	output := c.output(tag)
+	if !reflect.DeepEqual(output.ObjectIDs, []string{"vm-1"}) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", []string{"vm-1"}, output.ObjectIDs)
+	}
+	if !reflect.DeepEqual(output.ObjectPaths, []string{"/dc/vm/ubuntu"}) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", []string{"/dc/vm/ubuntu"}, output.ObjectPaths)
+	}
+
+	c = &Config{}
+	output = c.output(tag)
+	if len(output.ObjectIDs) != 2 {
+		t.Fatalf("unexpected result: expected '2' objects, but returned '%d'", len(output.ObjectIDs))
+	}
+}
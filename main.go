@@ -12,18 +12,33 @@ import (
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/clone"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/iso"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/supervisor"
+	"github.com/hashicorp/packer-plugin-vsphere/datasource/contentlibrary"
+	"github.com/hashicorp/packer-plugin-vsphere/datasource/datastore"
+	"github.com/hashicorp/packer-plugin-vsphere/datasource/tag"
 	"github.com/hashicorp/packer-plugin-vsphere/post-processor/vsphere"
+	vsphereMetadata "github.com/hashicorp/packer-plugin-vsphere/post-processor/vsphere-metadata"
+	vsphereOvf "github.com/hashicorp/packer-plugin-vsphere/post-processor/vsphere-ovf"
 	vsphereTemplate "github.com/hashicorp/packer-plugin-vsphere/post-processor/vsphere-template"
+	"github.com/hashicorp/packer-plugin-vsphere/upgrade"
 	"github.com/hashicorp/packer-plugin-vsphere/version"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == upgrade.Command {
+		os.Exit(upgrade.Run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	pps := plugin.NewSet()
 	pps.RegisterBuilder("iso", new(iso.Builder))
 	pps.RegisterBuilder("clone", new(clone.Builder))
 	pps.RegisterBuilder("supervisor", new(supervisor.Builder))
+	pps.RegisterDatasource("contentlibrary", new(contentlibrary.Datasource))
+	pps.RegisterDatasource("datastore", new(datastore.Datasource))
+	pps.RegisterDatasource("tag", new(tag.Datasource))
 	pps.RegisterPostProcessor(plugin.DEFAULT_NAME, new(vsphere.PostProcessor))
 	pps.RegisterPostProcessor("template", new(vsphereTemplate.PostProcessor))
+	pps.RegisterPostProcessor("ovf", new(vsphereOvf.PostProcessor))
+	pps.RegisterPostProcessor("metadata", new(vsphereMetadata.PostProcessor))
 	pps.SetVersion(version.PluginVersion)
 	err := pps.Run()
 	if err != nil {
@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,TagConfig
+
+package vsphere_metadata
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	vsphere "github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The following example attaches two tags of the same category to the
+// virtual machine:
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	tag {
+//	  category = "os"
+//	  names    = ["linux", "ubuntu"]
+//	}
+//
+// ```
+//
+// JSON Example:
+//
+// ```json
+//
+//	"tag": [
+//	  {
+//	    "category": "os",
+//	    "names": ["linux", "ubuntu"]
+//	  }
+//	],
+//
+// ```
+type TagConfig struct {
+	// The name of the tag category.
+	Category string `mapstructure:"category" required:"true"`
+	// The names of the tags in the category to attach to the virtual machine.
+	Names []string `mapstructure:"names" required:"true"`
+}
+
+type Config struct {
+	common.PackerConfig   `mapstructure:",squash"`
+	vsphere.ConnectConfig `mapstructure:",squash"`
+	// The tags to attach to the virtual machine, grouped by category. Refer
+	// to the [Tag Configuration](#tag-configuration) section for additional
+	// information. Tags that are already attached are left unchanged.
+	Tags []TagConfig `mapstructure:"tag"`
+	// The values of the custom attributes to set on the virtual machine, by
+	// name. The custom attributes must exist in vCenter Server.
+	CustomAttributes map[string]string `mapstructure:"custom_attributes"`
+	// The notes to set on the virtual machine.
+	Notes string `mapstructure:"notes"`
+	// Append `notes` to the existing notes of the virtual machine instead of
+	// replacing them. Defaults to `false`.
+	AppendNotes bool `mapstructure:"append_notes"`
+	// The virtual machine folder to move the virtual machine to. The folder
+	// is created if it does not exist.
+	Folder string `mapstructure:"folder"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         vsphere.BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	vsphere.RegisterSensitiveValues(&p.config)
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, p.config.ConnectConfig.Prepare()...)
+
+	for i, tag := range p.config.Tags {
+		if tag.Category == "" {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'tag[%d].category' is required", i))
+		}
+		if len(tag.Names) == 0 {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'tag[%d].names' is required", i))
+		}
+	}
+	if p.config.AppendNotes && p.config.Notes == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'append_notes' requires 'notes'"))
+	}
+	if len(p.config.Tags) == 0 && len(p.config.CustomAttributes) == 0 && p.config.Notes == "" && p.config.Folder == "" {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("at least one of 'tag', 'custom_attributes', 'notes', or 'folder' is required"))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	if artifact.BuilderId() != vsphere.BuilderId {
+		return nil, false, false, fmt.Errorf(
+			"error: unsupported artifact type %s. supported types: vsphere-iso and vsphere-clone builders", artifact.BuilderId())
+	}
+
+	d, err := driver.NewDriver(p.config.DriverConfig())
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error connecting to vCenter Server: %s", err)
+	}
+	vcenter := d.(*driver.VCenterDriver)
+	defer func() {
+		_, _ = vcenter.Cleanup()
+	}()
+
+	vm, err := findVM(vcenter, artifact)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error finding virtual machine %s: %s", artifact.Id(), err)
+	}
+
+	// All of the changes are resolved before any is applied, so that an
+	// error in the configuration does not leave the virtual machine partially
+	// updated.
+	changes, err := p.changes(vcenter, vm)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if err := applyChanges(ui, changes); err != nil {
+		return nil, false, false, err
+	}
+	return artifact, true, true, nil
+}
+
+// findVM returns the virtual machine of the artifact. The managed object ID
+// recorded by the builders is preferred over the name of the artifact, which
+// may not be unique.
+func findVM(d *driver.VCenterDriver, artifact packersdk.Artifact) (*driver.VirtualMachineDriver, error) {
+	if id, ok := artifact.State("vm_id").(string); ok && id != "" {
+		return d.NewVM(&types.ManagedObjectReference{Type: "VirtualMachine", Value: id}).(*driver.VirtualMachineDriver), nil
+	}
+	vm, err := d.FindVM(artifact.Id())
+	if err != nil {
+		return nil, err
+	}
+	return vm.(*driver.VirtualMachineDriver), nil
+}
+
+type change struct {
+	name  string
+	apply func() error
+	undo  func() error
+}
+
+// changes returns the updates of the virtual machine that differ from its
+// current metadata, in the order in which they are applied. Each change holds
+// the update that reverts it.
+func (p *PostProcessor) changes(d *driver.VCenterDriver, vm *driver.VirtualMachineDriver) ([]change, error) {
+	var changes []change
+
+	if len(p.config.Tags) > 0 {
+		attached, err := vm.Tags()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the tags of the virtual machine: %s", err)
+		}
+		for _, tag := range p.config.Tags {
+			for _, name := range tag.Names {
+				id, err := d.FindTagID(tag.Category, name)
+				if err != nil {
+					return nil, err
+				}
+				if slices.Contains(attached, id) {
+					continue
+				}
+				attached = append(attached, id)
+				changes = append(changes, change{
+					name:  fmt.Sprintf("tag %s/%s", tag.Category, name),
+					apply: func() error { return vm.AttachTag(id) },
+					undo:  func() error { return vm.DetachTag(id) },
+				})
+			}
+		}
+	}
+
+	if len(p.config.CustomAttributes) > 0 {
+		current, err := vm.CustomAttributes()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the custom attributes of the virtual machine: %s", err)
+		}
+		names := make([]string, 0, len(p.config.CustomAttributes))
+		for name := range p.config.CustomAttributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key, err := d.FindCustomAttributeKey(name)
+			if err != nil {
+				return nil, err
+			}
+			value, previous := p.config.CustomAttributes[name], current[key]
+			if value == previous {
+				continue
+			}
+			changes = append(changes, change{
+				name:  fmt.Sprintf("custom attribute %s", name),
+				apply: func() error { return vm.SetCustomAttribute(key, value) },
+				undo:  func() error { return vm.SetCustomAttribute(key, previous) },
+			})
+		}
+	}
+
+	if p.config.Notes != "" {
+		previous, err := vm.Notes()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the notes of the virtual machine: %s", err)
+		}
+		notes := p.config.Notes
+		if p.config.AppendNotes && previous != "" {
+			notes = strings.TrimRight(previous, "\n") + "\n" + notes
+		}
+		if notes != previous {
+			changes = append(changes, change{
+				name:  "notes",
+				apply: func() error { return vm.SetNotes(notes) },
+				undo:  func() error { return vm.SetNotes(previous) },
+			})
+		}
+	}
+
+	// The virtual machine is moved last, since the move is the most visible
+	// change to other users of the inventory.
+	if p.config.Folder != "" {
+		previous, err := vm.Folder()
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the folder of the virtual machine: %s", err)
+		}
+		// The folder is found or created when the change is applied, so that
+		// no folder is created if an earlier change fails. The folders that
+		// are created are removed when the change is reverted.
+		var created []*driver.Folder
+		changes = append(changes, change{
+			name: fmt.Sprintf("folder %s", p.config.Folder),
+			apply: func() error {
+				folder, c, err := d.FindOrCreateFolder(p.config.Folder)
+				created = c
+				if err == nil {
+					err = vm.MoveToFolder(folder)
+				}
+				if err != nil {
+					if destroyErr := destroyFolders(created); destroyErr != nil {
+						return fmt.Errorf("%s; error removing the created folders: %s", err, destroyErr)
+					}
+				}
+				return err
+			},
+			undo: func() error {
+				if err := vm.MoveToFolder(previous); err != nil {
+					return err
+				}
+				return destroyFolders(created)
+			},
+		})
+	}
+
+	return changes, nil
+}
+
+// destroyFolders removes the folders, from the innermost to the outermost.
+func destroyFolders(folders []*driver.Folder) error {
+	for i := len(folders) - 1; i >= 0; i-- {
+		if err := folders[i].Destroy(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyChanges applies the changes in order. If a change fails, the changes
+// that were applied are reverted in reverse order.
+func applyChanges(ui packersdk.Ui, changes []change) error {
+	for i, c := range changes {
+		ui.Sayf("Applying %s...", c.name)
+		if err := c.apply(); err != nil {
+			ui.Errorf("Error applying %s: %s", c.name, err)
+			for j := i - 1; j >= 0; j-- {
+				ui.Sayf("Reverting %s...", changes[j].name)
+				if err := changes[j].undo(); err != nil {
+					ui.Errorf("Error reverting %s: %s", changes[j].name, err)
+				}
+			}
+			return fmt.Errorf("error applying %s: %s", c.name, err)
+		}
+	}
+	if len(changes) == 0 {
+		ui.Say("The metadata of the virtual machine is up to date.")
+	}
+	return nil
+}
+
+func (c Config) GoString() string { return vsphere.GoString(c) }
+
+func (c FlatConfig) GoString() string { return vsphere.GoString(c) }
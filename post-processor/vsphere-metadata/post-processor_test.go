@@ -0,0 +1,314 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_metadata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/soap"
+	vimxml "github.com/vmware/govmomi/vim25/xml"
+)
+
+func testUi() packersdk.Ui {
+	return &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	}
+}
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"vcenter_server": "vcenter.example.com",
+		"username":       "administrator@vsphere.local",
+		"password":       "password",
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	tc := []struct {
+		name           string
+		config         map[string]interface{}
+		fail           bool
+		expectedErrMsg string
+	}{
+		{
+			name: "Tags",
+			config: map[string]interface{}{
+				"tag": []map[string]interface{}{
+					{"category": "os", "names": []string{"linux", "ubuntu"}},
+				},
+			},
+		},
+		{
+			name: "Custom attributes, notes, and folder",
+			config: map[string]interface{}{
+				"custom_attributes": map[string]string{"owner": "platform-team"},
+				"notes":             "Built by Packer.",
+				"append_notes":      true,
+				"folder":            "templates/linux",
+			},
+		},
+		{
+			name:           "No metadata",
+			config:         map[string]interface{}{},
+			fail:           true,
+			expectedErrMsg: "at least one of 'tag', 'custom_attributes', 'notes', or 'folder' is required",
+		},
+		{
+			name: "Tag without names",
+			config: map[string]interface{}{
+				"tag": []map[string]interface{}{
+					{"category": "os"},
+				},
+			},
+			fail:           true,
+			expectedErrMsg: "'tag[0].names' is required",
+		},
+		{
+			name: "Append notes without notes",
+			config: map[string]interface{}{
+				"folder":       "templates/linux",
+				"append_notes": true,
+			},
+			fail:           true,
+			expectedErrMsg: "'append_notes' requires 'notes'",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var p PostProcessor
+			err := p.Configure(testConfig(), c.config)
+			if c.fail {
+				if err == nil {
+					t.Fatalf("unexpected success: expected failure")
+				}
+				if !strings.Contains(err.Error(), c.expectedErrMsg) {
+					t.Fatalf("unexpected error: expected '%s', but returned '%s'", c.expectedErrMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+}
+
+func TestConfigure_ConnectConfig(t *testing.T) {
+	var p PostProcessor
+	err := p.Configure(map[string]interface{}{"folder": "templates/linux"})
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if !strings.Contains(err.Error(), "'vcenter_server' is required") {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestPostProcess_UnsupportedArtifact(t *testing.T) {
+	var p PostProcessor
+	if err := p.Configure(testConfig(), map[string]interface{}{"folder": "templates/linux"}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	artifact := &packersdk.MockArtifact{BuilderIdValue: "packer.post-processor.vsphere-ovf"}
+	_, _, _, err := p.PostProcess(context.TODO(), testUi(), artifact)
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if !strings.Contains(err.Error(), "unsupported artifact type") {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	var calls []string
+	record := func(call string, err error) func() error {
+		return func() error {
+			calls = append(calls, call)
+			return err
+		}
+	}
+
+	changes := []change{
+		{name: "tag os/linux", apply: record("attach linux", nil), undo: record("detach linux", nil)},
+		{name: "custom attribute owner", apply: record("set owner", nil), undo: record("reset owner", nil)},
+		{name: "notes", apply: record("set notes", errors.New("permission denied")), undo: record("reset notes", nil)},
+		{name: "folder templates", apply: record("move", nil), undo: record("move back", nil)},
+	}
+
+	err := applyChanges(testUi(), changes)
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	expectedErr := "error applying notes: permission denied"
+	if err.Error() != expectedErr {
+		t.Fatalf("unexpected error: expected '%s', but returned '%s'", expectedErr, err)
+	}
+
+	expected := []string{"attach linux", "set owner", "set notes", "reset owner", "detach linux"}
+	if diff := cmp.Diff(expected, calls); diff != "" {
+		t.Fatalf("unexpected calls: '%s'", diff)
+	}
+}
+
+func TestApplyChanges_UndoError(t *testing.T) {
+	var calls []string
+	record := func(call string, err error) func() error {
+		return func() error {
+			calls = append(calls, call)
+			return err
+		}
+	}
+
+	changes := []change{
+		{name: "tag os/linux", apply: record("attach linux", nil), undo: record("detach linux", nil)},
+		{name: "tag os/ubuntu", apply: record("attach ubuntu", nil), undo: record("detach ubuntu", errors.New("not found"))},
+		{name: "folder templates", apply: record("move", errors.New("permission denied")), undo: record("move back", nil)},
+	}
+
+	if err := applyChanges(testUi(), changes); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+
+	// A change that cannot be reverted does not stop the remaining changes
+	// from being reverted.
+	expected := []string{"attach linux", "attach ubuntu", "move", "detach ubuntu", "detach linux"}
+	if diff := cmp.Diff(expected, calls); diff != "" {
+		t.Fatalf("unexpected calls: '%s'", diff)
+	}
+}
+
+// failingRoundTripper records the vSphere API requests and fails the calls of
+// a method.
+type failingRoundTripper struct {
+	next     soap.RoundTripper
+	fail     string
+	requests []string
+}
+
+func (r *failingRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.Indirect(reflect.ValueOf(req)).Type().Name(), "Body")
+	b, _ := vimxml.Marshal(req)
+	r.requests = append(r.requests, string(b))
+	if method == r.fail {
+		return fmt.Errorf("%s failed", method)
+	}
+	return r.next.RoundTrip(ctx, req, res)
+}
+
+// simulatorDriver returns a driver connected to a vCenter Server simulator
+// whose calls of the method fail, a virtual machine of the simulator, and a
+// finder for the inventory of the simulator.
+func simulatorDriver(t *testing.T, rt *failingRoundTripper) (*driver.VCenterDriver, *driver.VirtualMachineDriver, *find.Finder) {
+	model := simulator.VPX()
+	model.Machine = 1
+	t.Cleanup(model.Remove)
+	if err := model.Create(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	server := model.Service.NewServer()
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	client, err := govmomi.NewClient(ctx, server.URL, true)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	rt.next = client.Client.RoundTripper
+	client.Client.RoundTripper = rt
+
+	finder := find.NewFinder(client.Client, false)
+	datacenter, err := finder.DatacenterOrDefault(ctx, "")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	finder.SetDatacenter(datacenter)
+	d := driver.NewVCenterDriver(ctx, client, client.Client, server.URL.User, finder, datacenter)
+
+	vm, err := d.FindVM("DC0_H0_VM0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return d, vm.(*driver.VirtualMachineDriver), finder
+}
+
+func TestApplyChanges_RevertNotes(t *testing.T) {
+	rt := &failingRoundTripper{fail: "MoveIntoFolder_Task"}
+	d, vm, _ := simulatorDriver(t, rt)
+	if notes, err := vm.Notes(); err != nil || notes != "" {
+		t.Fatalf("unexpected result: expected no notes, but returned '%s': %v", notes, err)
+	}
+
+	p := PostProcessor{config: Config{Notes: "Built by Packer.", Folder: "templates"}}
+	changes, err := p.changes(d, vm)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := applyChanges(testUi(), changes); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+
+	// The notes of a virtual machine without notes are reverted with an
+	// empty annotation, since an omitted annotation leaves the notes
+	// unchanged.
+	var reconfigure []string
+	for _, r := range rt.requests {
+		if strings.Contains(r, "<ReconfigVM_Task") {
+			reconfigure = append(reconfigure, r)
+		}
+	}
+	if len(reconfigure) != 2 {
+		t.Fatalf("unexpected result: expected 2 reconfigurations, but returned %d", len(reconfigure))
+	}
+	if !strings.Contains(reconfigure[0], "<annotation>Built by Packer.</annotation>") {
+		t.Fatalf("unexpected result: expected the notes to be set, but sent '%s'", reconfigure[0])
+	}
+	if !strings.Contains(reconfigure[1], "<annotation></annotation>") {
+		t.Fatalf("unexpected result: expected the notes to be cleared, but sent '%s'", reconfigure[1])
+	}
+}
+
+func TestApplyChanges_RevertCreatedFolders(t *testing.T) {
+	rt := &failingRoundTripper{fail: "MoveIntoFolder_Task"}
+	d, vm, finder := simulatorDriver(t, rt)
+
+	// An existing folder is not removed.
+	if _, err := d.FindFolder("templates"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	p := PostProcessor{config: Config{Folder: "templates/linux/ubuntu"}}
+	changes, err := p.changes(d, vm)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	// No folder is created until the changes are applied.
+	if _, err := finder.Folder(context.Background(), "/DC0/vm/templates/linux"); err == nil {
+		t.Fatalf("unexpected result: expected folder templates/linux not to exist")
+	}
+
+	if err := applyChanges(testUi(), changes); err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if _, err := finder.Folder(context.Background(), "/DC0/vm/templates/linux"); err == nil {
+		t.Fatalf("unexpected result: expected folder templates/linux to be removed")
+	}
+	if _, err := finder.Folder(context.Background(), "/DC0/vm/templates"); err != nil {
+		t.Fatalf("unexpected result: expected folder templates to exist: %s", err)
+	}
+}
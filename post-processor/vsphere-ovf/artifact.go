@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_ovf
+
+import (
+	"fmt"
+	"os"
+)
+
+const BuilderId = "packer.post-processor.vsphere-ovf"
+
+type Artifact struct {
+	files []string
+}
+
+func NewArtifact(files []string) *Artifact {
+	return &Artifact{
+		files: files,
+	}
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *Artifact) Files() []string {
+	return a.files
+}
+
+// Id returns the path of the descriptor or the archive.
+func (a *Artifact) Id() string {
+	if len(a.files) == 0 {
+		return ""
+	}
+	return a.files[0]
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("Converted artifact: %s", a.Id())
+}
+
+func (*Artifact) State(name string) interface{} {
+	return nil
+}
+
+func (a *Artifact) Destroy() error {
+	for _, file := range a.files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
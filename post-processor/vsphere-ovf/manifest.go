@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_ovf
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// Supported hash algorithms of a manifest.
+var sha = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// A manifest entry in the form of `SHA256(example.ovf)= <checksum>`.
+var manifestEntryRegex = regexp.MustCompile(`^(\w+)\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+
+type manifestEntry struct {
+	algorithm string
+	name      string
+	checksum  string
+}
+
+func parseManifest(r io.Reader) ([]manifestEntry, error) {
+	var entries []manifestEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		m := manifestEntryRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("invalid manifest entry: %q", line)
+		}
+		algorithm := strings.ToUpper(m[1])
+		if _, ok := sha[algorithm]; !ok {
+			return nil, fmt.Errorf("unsupported manifest hash algorithm: %s", m[1])
+		}
+		entries = append(entries, manifestEntry{
+			algorithm: algorithm,
+			name:      m[2],
+			checksum:  strings.ToLower(m[3]),
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// verifyManifest verifies the checksums of the files listed in a manifest in
+// the specified directory. A missing manifest is not an error.
+func verifyManifest(ui packersdk.Ui, dir string, name string) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		ui.Sayf("Manifest %s not found; skipping checksum verification...", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening manifest: %s", err)
+	}
+	defer f.Close()
+
+	entries, err := parseManifest(f)
+	if err != nil {
+		return fmt.Errorf("error reading manifest %s: %s", name, err)
+	}
+
+	ui.Sayf("Verifying checksums in manifest %s...", name)
+	for _, entry := range entries {
+		if err := checkEntryName(entry.name); err != nil {
+			return err
+		}
+		actual, err := fileChecksum(filepath.Join(dir, entry.name), sha[entry.algorithm]())
+		if err != nil {
+			return fmt.Errorf("error computing checksum of %s: %s", entry.name, err)
+		}
+		if actual != entry.checksum {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, but found %s", entry.name, entry.checksum, actual)
+		}
+	}
+	return nil
+}
+
+func fileChecksum(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
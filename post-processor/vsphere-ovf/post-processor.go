@@ -0,0 +1,340 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package vsphere_ovf
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/vmware/govmomi/ovf"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+	// The format to convert the artifact to. Available options include `ova`
+	// and `ovf`.
+	//
+	// An artifact in Open Virtualization Format (`.ovf`) is packaged as an
+	// Open Virtualization Archive (`.ova`), and an archive is extracted to
+	// Open Virtualization Format. An artifact that is already in the specified
+	// format is passed through unchanged.
+	Format string `mapstructure:"format" required:"true"`
+	// The path to the directory where the converted files are written.
+	// Defaults to the directory of the artifact.
+	OutputDir string `mapstructure:"output_directory"`
+	// Overwrite existing files. Defaults to `false`. If set to `false`, an
+	// error is returned if a converted file already exists.
+	Force bool `mapstructure:"force"`
+	// Skip the verification of the checksums in the manifest (`.mf`) file of
+	// the artifact. Defaults to `false`.
+	SkipManifestVerification bool `mapstructure:"skip_manifest_verification"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+
+	switch p.config.Format {
+	case "ova", "ovf":
+	case "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'format' is required"))
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unsupported format: %s. available options include 'ova' and 'ovf'", p.config.Format))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	source := ""
+	for _, path := range artifact.Files() {
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".ovf" || ext == ".ova" {
+			source = path
+			break
+		}
+	}
+
+	if source == "" {
+		return nil, false, false, fmt.Errorf("error locating expected .ovf or .ova artifact")
+	}
+
+	outputDir := p.config.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(source)
+	}
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		return nil, false, false, fmt.Errorf("error creating output directory: %s", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(source), "."+p.config.Format) {
+		ui.Sayf("Artifact %s is already in %s format; skipping conversion...", filepath.Base(source), strings.ToUpper(p.config.Format))
+		return artifact, true, true, nil
+	}
+
+	var files []string
+	var err error
+	switch p.config.Format {
+	case "ova":
+		files, err = p.convertToOva(ui, source, outputDir)
+	case "ovf":
+		files, err = p.convertToOvf(ui, source, outputDir)
+	}
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	// The input artifact is always kept, since destroying the artifact of the
+	// builder removes its output directory, which is also the default output
+	// directory of the converted files, and the virtual machine.
+	return NewArtifact(files), true, true, nil
+}
+
+// convertToOva verifies the manifest of an OVF and packages the descriptor,
+// the manifest, the certificate, and the referenced files as an OVA.
+func (p *PostProcessor) convertToOva(ui packersdk.Ui, source string, outputDir string) ([]string, error) {
+	dir := filepath.Dir(source)
+	name := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+
+	if !p.config.SkipManifestVerification {
+		if err := verifyManifest(ui, dir, name+".mf"); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ovf descriptor: %s", err)
+	}
+	envelope, err := ovf.Unmarshal(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading ovf descriptor: %s", err)
+	}
+
+	// The descriptor must be the first file in the archive, followed by the
+	// manifest and the certificate, if present.
+	entries := []string{filepath.Base(source)}
+	for _, ext := range []string{".mf", ".cert"} {
+		if _, err := os.Stat(filepath.Join(dir, name+ext)); err == nil {
+			entries = append(entries, name+ext)
+		}
+	}
+	for _, file := range envelope.References {
+		if err := checkEntryName(file.Href); err != nil {
+			return nil, err
+		}
+		entries = append(entries, file.Href)
+	}
+
+	target := filepath.Join(outputDir, name+".ova")
+	if err := p.checkTarget(target); err != nil {
+		return nil, err
+	}
+
+	ui.Sayf("Converting %s to Open Virtualization Archive (OVA)...", filepath.Base(source))
+	// The archive is written to a temporary file that is renamed when it is
+	// complete, so that a failed conversion does not leave a partial archive
+	// that prevents the conversion from being retried.
+	out, err := os.CreateTemp(outputDir, name+".ova.*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating ova file: %s", err)
+	}
+	defer func() {
+		out.Close()
+		_ = os.Remove(out.Name())
+	}()
+
+	tw := tar.NewWriter(out)
+	for _, entry := range entries {
+		ui.Sayf("Adding %s...", entry)
+		if err := addTarEntry(tw, dir, entry); err != nil {
+			return nil, fmt.Errorf("error adding %s to ova file: %s", entry, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("error writing ova file: %s", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("error closing ova file: %s", err)
+	}
+	if err := os.Chmod(out.Name(), 0644); err != nil {
+		return nil, fmt.Errorf("error writing ova file: %s", err)
+	}
+	if err := os.Rename(out.Name(), target); err != nil {
+		return nil, fmt.Errorf("error writing ova file: %s", err)
+	}
+
+	ui.Sayf("Completed conversion to Open Virtualization Archive (OVA): %s", filepath.Base(target))
+	return []string{target}, nil
+}
+
+// convertToOvf extracts the files of an OVA and verifies the manifest of the
+// extracted OVF. The extracted files are removed if the conversion fails.
+func (p *PostProcessor) convertToOvf(ui packersdk.Ui, source string, outputDir string) ([]string, error) {
+	files, err := p.extractOva(ui, source, outputDir)
+	if err != nil {
+		for _, file := range files {
+			_ = os.Remove(file)
+		}
+		return nil, err
+	}
+	ui.Sayf("Completed conversion to Open Virtualization Format (OVF): %s", filepath.Base(files[0]))
+	return files, nil
+}
+
+// extractOva extracts the files of an OVA and verifies the manifest of the
+// extracted OVF. Returns the files that were extracted, including a partially
+// extracted file, even if the extraction fails.
+func (p *PostProcessor) extractOva(ui packersdk.Ui, source string, outputDir string) ([]string, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ova file: %s", err)
+	}
+	defer f.Close()
+
+	ui.Sayf("Converting %s to Open Virtualization Format (OVF)...", filepath.Base(source))
+
+	var files []string
+	var manifest string
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return files, fmt.Errorf("error reading ova file: %s", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := checkEntryName(header.Name); err != nil {
+			return files, err
+		}
+
+		target := filepath.Join(outputDir, header.Name)
+		if err := p.checkTarget(target); err != nil {
+			return files, err
+		}
+
+		ui.Sayf("Extracting %s...", header.Name)
+		files = append(files, target)
+		if err := extractTarEntry(tr, target); err != nil {
+			return files, fmt.Errorf("error extracting %s: %s", header.Name, err)
+		}
+		if strings.EqualFold(filepath.Ext(header.Name), ".mf") {
+			manifest = header.Name
+		}
+	}
+
+	if len(files) == 0 || !strings.EqualFold(filepath.Ext(files[0]), ".ovf") {
+		return files, fmt.Errorf("error locating ovf descriptor in ova file")
+	}
+
+	if !p.config.SkipManifestVerification && manifest != "" {
+		if err := verifyManifest(ui, outputDir, manifest); err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+func (p *PostProcessor) checkTarget(target string) error {
+	if p.config.Force {
+		return nil
+	}
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("force disabled, file already exists: %s", target)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to check if file exists: %s", target)
+	}
+	return nil
+}
+
+// checkEntryName returns an error if the name of a file in an OVF or OVA is
+// not a plain file name, which prevents writing or reading files outside of
+// the directory of the artifact.
+func checkEntryName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("unsupported file name in artifact: %q", name)
+	}
+	return nil
+}
+
+func addTarEntry(tw *tar.Writer, dir string, name string) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// The OVF specification requires the USTAR format, which is used unless
+	// the size of a file exceeds the limit of the format.
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     info.Size(),
+		ModTime:  info.ModTime().Truncate(time.Second),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func extractTarEntry(r io.Reader, target string) error {
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,287 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_ovf
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const testDescriptor = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:href="example-disk-0.vmdk" ovf:id="file1"/>
+  </References>
+</Envelope>
+`
+
+func testUi() packersdk.Ui {
+	return &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	}
+}
+
+// writeTestOvf writes an OVF with a disk and a manifest to a directory.
+func writeTestOvf(t *testing.T, dir string, disk string) string {
+	files := map[string]string{
+		"example.ovf":         testDescriptor,
+		"example-disk-0.vmdk": disk,
+	}
+	var manifest string
+	for _, name := range []string{"example.ovf", "example-disk-0.vmdk"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(files[name]), 0644); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		manifest += fmt.Sprintf("SHA256(%s)= %x\n", name, sha256.Sum256([]byte(files[name])))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "example.mf"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return filepath.Join(dir, "example.ovf")
+}
+
+func TestConfigure(t *testing.T) {
+	tc := []struct {
+		name   string
+		config map[string]interface{}
+		fail   bool
+	}{
+		{
+			name:   "OVA",
+			config: map[string]interface{}{"format": "ova"},
+		},
+		{
+			name:   "OVF",
+			config: map[string]interface{}{"format": "ovf"},
+		},
+		{
+			name:   "Missing format",
+			config: map[string]interface{}{},
+			fail:   true,
+		},
+		{
+			name:   "Unsupported format",
+			config: map[string]interface{}{"format": "vmx"},
+			fail:   true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var p PostProcessor
+			err := p.Configure(c.config)
+			if c.fail && err == nil {
+				t.Fatalf("unexpected success")
+			}
+			if !c.fail && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		})
+	}
+}
+
+func TestPostProcess_RoundTrip(t *testing.T) {
+	source := writeTestOvf(t, t.TempDir(), "disk")
+
+	// Convert the OVF to an OVA.
+	var toOva PostProcessor
+	if err := toOva.Configure(map[string]interface{}{"format": "ova"}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	artifact, keep, _, err := toOva.PostProcess(context.TODO(), testUi(), &packersdk.MockArtifact{FilesValue: []string{source}})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !keep {
+		t.Fatalf("unexpected result: expected the input artifact to be kept")
+	}
+	ova := artifact.Files()[0]
+
+	f, err := os.Open(ova)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer f.Close()
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	expected := []string{"example.ovf", "example.mf", "example-disk-0.vmdk"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, names)
+	}
+
+	// Convert the OVA back to an OVF in another directory.
+	outputDir := t.TempDir()
+	var toOvf PostProcessor
+	if err := toOvf.Configure(map[string]interface{}{"format": "ovf", "output_directory": outputDir}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	artifact, _, _, err = toOvf.PostProcess(context.TODO(), testUi(), artifact)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(artifact.Files()) != len(expected) {
+		t.Fatalf("unexpected result: expected '%d' files, but returned '%v'", len(expected), artifact.Files())
+	}
+	if artifact.Id() != filepath.Join(outputDir, "example.ovf") {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", filepath.Join(outputDir, "example.ovf"), artifact.Id())
+	}
+
+	// An artifact already in the format is passed through.
+	passthrough, keep, _, err := toOvf.PostProcess(context.TODO(), testUi(), artifact)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !keep || passthrough != artifact {
+		t.Fatalf("unexpected result: expected the artifact to be passed through")
+	}
+}
+
+// exportArtifact is an artifact that removes its output directory when it is
+// destroyed, like the artifact of an exported virtual machine.
+type exportArtifact struct {
+	packersdk.MockArtifact
+	outputDir string
+}
+
+func (a *exportArtifact) Destroy() error {
+	a.DestroyCalled = true
+	return os.RemoveAll(a.outputDir)
+}
+
+func TestPostProcess_KeepInputArtifact(t *testing.T) {
+	dir := t.TempDir()
+	source := writeTestOvf(t, dir, "disk")
+	input := &exportArtifact{
+		MockArtifact: packersdk.MockArtifact{FilesValue: []string{source}},
+		outputDir:    dir,
+	}
+
+	// The converted files are written to the export directory by default.
+	var p PostProcessor
+	if err := p.Configure(map[string]interface{}{"format": "ova"}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	artifact, keep, forceOverride, err := p.PostProcess(context.TODO(), testUi(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !keep || !forceOverride {
+		t.Fatalf("unexpected result: expected the input artifact to be kept regardless of 'keep_input_artifact'")
+	}
+	if filepath.Dir(artifact.Id()) != dir {
+		t.Fatalf("unexpected result: expected the archive in '%s', but returned '%s'", dir, artifact.Id())
+	}
+
+	// Packer destroys the input artifact only if it is not kept.
+	if !keep {
+		if err := input.Destroy(); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	if input.DestroyCalled {
+		t.Fatalf("unexpected result: expected the input artifact not to be destroyed")
+	}
+	if _, err := os.Stat(artifact.Id()); err != nil {
+		t.Fatalf("unexpected result: expected the archive to exist, but returned '%s'", err)
+	}
+}
+
+func TestPostProcess_ChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	source := writeTestOvf(t, dir, "disk")
+	if err := os.WriteFile(filepath.Join(dir, "example-disk-0.vmdk"), []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	var p PostProcessor
+	if err := p.Configure(map[string]interface{}{"format": "ova"}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, _, _, err := p.PostProcess(context.TODO(), testUi(), &packersdk.MockArtifact{FilesValue: []string{source}}); err == nil {
+		t.Fatalf("unexpected success: expected a checksum mismatch")
+	}
+
+	p.config.SkipManifestVerification = true
+	if _, _, _, err := p.PostProcess(context.TODO(), testUi(), &packersdk.MockArtifact{FilesValue: []string{source}}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
+
+func TestPostProcess_RemovesPartialOutput(t *testing.T) {
+	dir := t.TempDir()
+	source := writeTestOvf(t, dir, "disk")
+	if err := os.WriteFile(filepath.Join(dir, "example-disk-0.vmdk"), []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The OVA with the corrupt disk is converted to an OVF whose manifest
+	// does not match, and the extracted files are removed.
+	var toOva PostProcessor
+	if err := toOva.Configure(map[string]interface{}{"format": "ova", "skip_manifest_verification": true}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	artifact, _, _, err := toOva.PostProcess(context.TODO(), testUi(), &packersdk.MockArtifact{FilesValue: []string{source}})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	outputDir := t.TempDir()
+	var toOvf PostProcessor
+	if err := toOvf.Configure(map[string]interface{}{"format": "ovf", "output_directory": outputDir}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, _, _, err := toOvf.PostProcess(context.TODO(), testUi(), artifact); err == nil {
+		t.Fatalf("unexpected success: expected a checksum mismatch")
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Fatalf("unexpected result: expected the extracted files to be removed, but returned '%v'", entries)
+	}
+
+	// An OVA whose disk cannot be added is not left in the output directory.
+	if err := os.Remove(filepath.Join(dir, "example-disk-0.vmdk")); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := toOva.Configure(map[string]interface{}{"format": "ova", "skip_manifest_verification": true, "output_directory": outputDir}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, _, _, err := toOva.PostProcess(context.TODO(), testUi(), &packersdk.MockArtifact{FilesValue: []string{source}}); err == nil {
+		t.Fatalf("unexpected success: expected a missing disk")
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Fatalf("unexpected result: expected the partial archive to be removed, but returned '%v'", entries)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	entries, err := parseManifest(bytes.NewBufferString("SHA256(example.ovf)= ABCD\n\nsha1(example.mf) = ef\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected := []manifestEntry{
+		{algorithm: "SHA256", name: "example.ovf", checksum: "abcd"},
+		{algorithm: "SHA1", name: "example.mf", checksum: "ef"},
+	}
+	if fmt.Sprint(entries) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, entries)
+	}
+
+	if _, err := parseManifest(bytes.NewBufferString("MD5(example.ovf)= abcd\n")); err == nil {
+		t.Fatalf("unexpected success: expected an unsupported hash algorithm")
+	}
+}
@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,Placement
 
 package vsphere_template
 
@@ -64,10 +64,68 @@ type Config struct {
 	SnapshotDescription string `mapstructure:"snapshot_description"`
 	// Keepe the virtual machine registered after marking as a template.
 	ReregisterVM config.Trilean `mapstructure:"reregister_vm"`
+	// Copy the template to additional placements in the same vCenter Server
+	// instance after the template is created, such as to other datacenters
+	// or folders. Can be specified multiple times. Refer to the
+	// [placement configuration](#placement-configuration) section for more
+	// information.
+	Placements []Placement `mapstructure:"placement"`
 
 	ctx interpolate.Context
 }
 
+// A placement to copy the template to. The template is cloned as a template
+// to the placement, so that one build is available in several datacenters or
+// folders. The copy fails if an object with the same name exists in the
+// folder.
+//
+// HCL Example:
+//
+// ```hcl
+//
+//	post-processor "vsphere-template" {
+//	  # ...
+//	  placement {
+//	    datacenter = "dc-02"
+//	    folder     = "templates/linux"
+//	    cluster    = "cluster-02"
+//	    datastore  = "datastore-02"
+//	  }
+//	}
+//
+// ```
+type Placement struct {
+	// The name of the datacenter to copy the template to. Defaults to
+	// `datacenter`.
+	Datacenter string `mapstructure:"datacenter"`
+	// The virtual machine folder path to copy the template to. Folders that
+	// do not exist are created. Defaults to `folder`.
+	Folder string `mapstructure:"folder"`
+	// The name of the cluster in which to register the copy of the template.
+	// Required if `host` is not set.
+	Cluster string `mapstructure:"cluster"`
+	// The name of the ESXi host on which to register the copy of the template.
+	// Required if `cluster` is not set, or if Distributed Resource Scheduler
+	// (DRS) is not enabled on the cluster.
+	Host string `mapstructure:"host"`
+	// The name of the datastore to store the copy of the template on.
+	Datastore string `mapstructure:"datastore"`
+	// The name of the copy of the template. Defaults to the name of the
+	// template.
+	TemplateName string `mapstructure:"template_name"`
+}
+
+func (c *Placement) Prepare(i int) []error {
+	var errs []error
+	if c.Cluster == "" && c.Host == "" {
+		errs = append(errs, fmt.Errorf("error: placement[%d]: one of cluster or host must be set", i))
+	}
+	if c.Datastore == "" {
+		errs = append(errs, fmt.Errorf("error: placement[%d]: datastore must be set", i))
+	}
+	return errs
+}
+
 type PostProcessor struct {
 	config Config
 	url    *url.URL
@@ -91,6 +149,8 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		return err
 	}
 
+	vsphere.RegisterSensitiveValues(&p.config)
+
 	errs := new(packersdk.MultiError)
 	vc := map[string]*string{
 		"host":     &p.config.Host,
@@ -105,6 +165,17 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		}
 	}
 
+	for i := range p.config.Placements {
+		placement := &p.config.Placements[i]
+		if placement.Datacenter == "" {
+			placement.Datacenter = p.config.Datacenter
+		}
+		if placement.Folder == "" {
+			placement.Folder = p.config.Folder
+		}
+		errs = packersdk.MultiErrorAppend(errs, placement.Prepare(i)...)
+	}
+
 	sdk, err := url.Parse(fmt.Sprintf("https://%v/sdk", p.config.Host))
 	if err != nil {
 		errs = packersdk.MultiErrorAppend(
@@ -163,6 +234,11 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 		NewStepCreateSnapshot(artifact, p),
 		NewStepMarkAsTemplate(artifact, p),
 	}
+	if len(p.config.Placements) > 0 {
+		steps = append(steps, &stepCopyTemplate{
+			Placements: p.config.Placements,
+		})
+	}
 	runner := commonsteps.NewRunnerWithPauseFn(steps, p.config.PackerConfig, ui, state)
 	runner.Run(ctx, state)
 	if rawErr, ok := state.GetOk("error"); ok {
@@ -174,3 +250,7 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 func (p *PostProcessor) Logout(c *govmomi.Client) {
 	_ = c.Logout(context.Background())
 }
+
+func (c Config) GoString() string { return vsphere.GoString(c) }
+
+func (c FlatConfig) GoString() string { return vsphere.GoString(c) }
@@ -29,6 +29,7 @@ type FlatConfig struct {
 	SnapshotName        *string           `mapstructure:"snapshot_name" cty:"snapshot_name" hcl:"snapshot_name"`
 	SnapshotDescription *string           `mapstructure:"snapshot_description" cty:"snapshot_description" hcl:"snapshot_description"`
 	ReregisterVM        *bool             `mapstructure:"reregister_vm" cty:"reregister_vm" hcl:"reregister_vm"`
+	Placements          []FlatPlacement   `mapstructure:"placement" cty:"placement" hcl:"placement"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -62,6 +63,40 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"snapshot_name":              &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
 		"snapshot_description":       &hcldec.AttrSpec{Name: "snapshot_description", Type: cty.String, Required: false},
 		"reregister_vm":              &hcldec.AttrSpec{Name: "reregister_vm", Type: cty.Bool, Required: false},
+		"placement":                  &hcldec.BlockListSpec{TypeName: "placement", Nested: hcldec.ObjectSpec((*FlatPlacement)(nil).HCL2Spec())},
+	}
+	return s
+}
+
+// FlatPlacement is an auto-generated flat version of Placement.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatPlacement struct {
+	Datacenter   *string `mapstructure:"datacenter" cty:"datacenter" hcl:"datacenter"`
+	Folder       *string `mapstructure:"folder" cty:"folder" hcl:"folder"`
+	Cluster      *string `mapstructure:"cluster" cty:"cluster" hcl:"cluster"`
+	Host         *string `mapstructure:"host" cty:"host" hcl:"host"`
+	Datastore    *string `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
+	TemplateName *string `mapstructure:"template_name" cty:"template_name" hcl:"template_name"`
+}
+
+// FlatMapstructure returns a new FlatPlacement.
+// FlatPlacement is an auto-generated flat version of Placement.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Placement) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatPlacement)
+}
+
+// HCL2Spec returns the hcl spec of a Placement.
+// This spec is used by HCL to read the fields of Placement.
+// The decoded values from this spec will then be applied to a FlatPlacement.
+func (*FlatPlacement) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"datacenter":    &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
+		"folder":        &hcldec.AttrSpec{Name: "folder", Type: cty.String, Required: false},
+		"cluster":       &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
+		"host":          &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
+		"datastore":     &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
+		"template_name": &hcldec.AttrSpec{Name: "template_name", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -40,3 +40,26 @@ func TestConfigure_ReRegisterVM(t *testing.T) {
 		t.Errorf("error: should be unset, not false")
 	}
 }
+
+func TestConfigure_Placements(t *testing.T) {
+	var p PostProcessor
+
+	config := getTestConfig()
+	config.Datacenter = "dc-01"
+	config.Folder = "templates"
+	config.Placements = []Placement{
+		{Datacenter: "dc-02", Cluster: "cluster-02", Datastore: "datastore-02"},
+	}
+
+	if err := p.Configure(config); err != nil {
+		t.Fatalf("error: %s", err)
+	}
+	if placement := p.config.Placements[0]; placement.Datacenter != "dc-02" || placement.Folder != "templates" {
+		t.Errorf("error: unexpected placement: %#v", placement)
+	}
+
+	config.Placements = []Placement{{Datacenter: "dc-02"}}
+	if err := p.Configure(config); err == nil {
+		t.Errorf("error: expected failure for a placement without cluster, host, and datastore")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_template
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type stepCopyTemplate struct {
+	Placements []Placement
+}
+
+func (s *stepCopyTemplate) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	cli := state.Get("client").(*govmomi.Client)
+	template := state.Get("template").(*object.VirtualMachine)
+
+	name, err := template.ObjectName(context.Background())
+	if err != nil {
+		state.Put("error", err)
+		ui.Errorf("%s", err)
+		return multistep.ActionHalt
+	}
+
+	for _, placement := range s.Placements {
+		if err := copyTemplate(ui, cli, template, name, placement); err != nil {
+			state.Put("error", err)
+			ui.Errorf("%s", err)
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+// copyTemplate clones the template as a template to the placement.
+func copyTemplate(ui packersdk.Ui, cli *govmomi.Client, template *object.VirtualMachine, name string, placement Placement) error {
+	finder := find.NewFinder(cli.Client, false)
+
+	dc, err := finder.DatacenterOrDefault(context.Background(), placement.Datacenter)
+	if err != nil {
+		return fmt.Errorf("error finding datacenter %s: %s", placement.Datacenter, err)
+	}
+	finder.SetDatacenter(dc)
+
+	folder, err := createFolder(ui, cli, dc.InventoryPath, placement.Folder)
+	if err != nil {
+		return err
+	}
+
+	var relocate types.VirtualMachineRelocateSpec
+	var pool *object.ResourcePool
+	if placement.Host != "" {
+		host, err := finder.HostSystem(context.Background(), placement.Host)
+		if err != nil {
+			return fmt.Errorf("error finding host %s: %s", placement.Host, err)
+		}
+		hostRef := host.Reference()
+		relocate.Host = &hostRef
+		if placement.Cluster == "" {
+			if pool, err = host.ResourcePool(context.Background()); err != nil {
+				return err
+			}
+		}
+	}
+	if placement.Cluster != "" {
+		cluster, err := finder.ClusterComputeResource(context.Background(), placement.Cluster)
+		if err != nil {
+			return fmt.Errorf("error finding cluster %s: %s", placement.Cluster, err)
+		}
+		if pool, err = cluster.ResourcePool(context.Background()); err != nil {
+			return err
+		}
+	}
+	poolRef := pool.Reference()
+	relocate.Pool = &poolRef
+
+	ds, err := finder.Datastore(context.Background(), placement.Datastore)
+	if err != nil {
+		return fmt.Errorf("error finding datastore %s: %s", placement.Datastore, err)
+	}
+	dsRef := ds.Reference()
+	relocate.Datastore = &dsRef
+
+	if placement.TemplateName != "" {
+		name = placement.TemplateName
+	}
+	ui.Message(fmt.Sprintf("Copying template to %s...", path.Join(folder.InventoryPath, name)))
+
+	task, err := template.Clone(context.Background(), folder, name, types.VirtualMachineCloneSpec{
+		Location: relocate,
+		Template: true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := task.Wait(context.Background()); err != nil {
+		return fmt.Errorf("error copying template to %s: %s", folder.InventoryPath, err)
+	}
+	return nil
+}
+
+func (s *stepCopyTemplate) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_template
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+)
+
+func TestStepCopyTemplate(t *testing.T) {
+	model := simulator.VPX()
+	model.Datacenter = 2
+	model.Machine = 1
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	server := model.Service.NewServer()
+	defer server.Close()
+
+	cli, err := govmomi.NewClient(context.Background(), server.URL, true)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	finder := find.NewFinder(cli.Client, false)
+	vm, err := finder.VirtualMachine(context.Background(), "/DC0/vm/DC0_H0_VM0")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	task, err := vm.PowerOff(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := task.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := vm.MarkAsTemplate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("client", cli)
+	state.Put("template", vm)
+
+	step := &stepCopyTemplate{
+		Placements: []Placement{
+			{Datacenter: "DC1", Folder: "templates/linux", Cluster: "DC1_C0", Host: "DC1_C0_H0", Datastore: "LocalDS_0", TemplateName: "linux"},
+			{Datacenter: "DC1", Host: "DC1_H0", Datastore: "LocalDS_0", TemplateName: "copy"},
+		},
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected result: expected '%#v', but returned '%#v': %s", multistep.ActionContinue, action, state.Get("error"))
+	}
+
+	for _, p := range []string{"/DC1/vm/templates/linux/linux", "/DC1/vm/copy"} {
+		copied, err := finder.VirtualMachine(context.Background(), p)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		var template bool
+		if template, err = copied.IsTemplate(context.Background()); err != nil || !template {
+			t.Fatalf("unexpected result: expected %s to be a template: %v", p, err)
+		}
+	}
+}
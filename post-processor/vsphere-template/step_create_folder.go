@@ -25,8 +25,22 @@ func (s *stepCreateFolder) Run(ctx context.Context, state multistep.StateBag) mu
 
 	ui.Message("Creating or checking destination folder...")
 
+	folder, err := createFolder(ui, cli, dcPath, s.Folder)
+	if err != nil {
+		state.Put("error", err)
+		ui.Errorf("%s", err)
+		return multistep.ActionHalt
+	}
+	state.Put("folder", folder)
+
+	return multistep.ActionContinue
+}
+
+// createFolder returns the virtual machine folder at the path in the
+// datacenter, and creates the folders of the path that do not exist.
+func createFolder(ui packersdk.Ui, cli *govmomi.Client, dcPath string, folderPath string) (*object.Folder, error) {
 	base := path.Join(dcPath, "vm")
-	fullPath := path.Join(base, s.Folder)
+	fullPath := path.Join(base, folderPath)
 	si := object.NewSearchIndex(cli.Client)
 
 	var folders []string
@@ -38,9 +52,7 @@ func (s *stepCreateFolder) Run(ctx context.Context, state multistep.StateBag) mu
 	for {
 		ref, err = si.FindByInventoryPath(context.Background(), fullPath)
 		if err != nil {
-			state.Put("error", err)
-			ui.Errorf("%s", err)
-			return multistep.ActionHalt
+			return nil, err
 		}
 
 		if ref == nil {
@@ -48,10 +60,7 @@ func (s *stepCreateFolder) Run(ctx context.Context, state multistep.StateBag) mu
 			fullPath = path.Clean(dir)
 
 			if fullPath == dcPath {
-				err = fmt.Errorf("error finding base path %s", base)
-				state.Put("error", err)
-				ui.Errorf("%s", err)
-				return multistep.ActionHalt
+				return nil, fmt.Errorf("error finding base path %s", base)
 			}
 
 			folders = append(folders, folder)
@@ -60,29 +69,22 @@ func (s *stepCreateFolder) Run(ctx context.Context, state multistep.StateBag) mu
 		}
 	}
 
-	if root, ok := ref.(*object.Folder); ok {
-		for i := len(folders) - 1; i >= 0; i-- {
-			ui.Message(fmt.Sprintf("Creating virtual machine folder %v...", folders[i]))
-
-			root, err = root.CreateFolder(context.Background(), folders[i])
-			if err != nil {
-				state.Put("error", err)
-				ui.Errorf("%s", err)
-				return multistep.ActionHalt
-			}
+	root, ok := ref.(*object.Folder)
+	if !ok {
+		return nil, fmt.Errorf("error finding virtual machine folder at path %v", ref)
+	}
+	for i := len(folders) - 1; i >= 0; i-- {
+		ui.Message(fmt.Sprintf("Creating virtual machine folder %v...", folders[i]))
 
-			fullPath = path.Join(fullPath, folders[i])
+		root, err = root.CreateFolder(context.Background(), folders[i])
+		if err != nil {
+			return nil, err
 		}
-		root.SetInventoryPath(fullPath)
-		state.Put("folder", root)
-	} else {
-		err = fmt.Errorf("error finding virtual machine folder at path %v", ref)
-		state.Put("error", err)
-		ui.Errorf("%s", err)
-		return multistep.ActionHalt
-	}
 
-	return multistep.ActionContinue
+		fullPath = path.Join(fullPath, folders[i])
+	}
+	root.SetInventoryPath(fullPath)
+	return root, nil
 }
 
 func (s *stepCreateFolder) Cleanup(multistep.StateBag) {}
@@ -73,6 +73,7 @@ func (s *stepMarkAsTemplate) Run(ctx context.Context, state multistep.StateBag)
 			ui.Errorf("vm.MarkAsTemplate: %s", err)
 			return multistep.ActionHalt
 		}
+		state.Put("template", vm)
 		return multistep.ActionContinue
 	}
 
@@ -115,12 +116,17 @@ func (s *stepMarkAsTemplate) Run(ctx context.Context, state multistep.StateBag)
 		return multistep.ActionHalt
 	}
 
-	if err = task.Wait(context.Background()); err != nil {
+	info, err := task.WaitForResult(context.Background())
+	if err != nil {
 		state.Put("error", err)
 		ui.Errorf("task.Wait: %s", err)
 		return multistep.ActionHalt
 	}
 
+	template := object.NewVirtualMachine(cli.Client, info.Result.(types.ManagedObjectReference))
+	template.SetInventoryPath(path.Join(folder.InventoryPath, artifactName))
+	state.Put("template", template)
+
 	return multistep.ActionContinue
 }
 
@@ -48,3 +48,44 @@ func (*Artifact) State(name string) interface{} {
 func (a *Artifact) Destroy() error {
 	return nil
 }
+
+// LibraryArtifact is an OVF template uploaded to a content library item.
+type LibraryArtifact struct {
+	files   []string
+	library string
+	item    string
+	itemID  string
+}
+
+func NewLibraryArtifact(library, item, itemID string, files []string) *LibraryArtifact {
+	return &LibraryArtifact{
+		files:   files,
+		library: library,
+		item:    item,
+		itemID:  itemID,
+	}
+}
+
+func (*LibraryArtifact) BuilderId() string {
+	return BuilderId
+}
+
+func (a *LibraryArtifact) Files() []string {
+	return a.files
+}
+
+func (a *LibraryArtifact) Id() string {
+	return a.itemID
+}
+
+func (a *LibraryArtifact) String() string {
+	return fmt.Sprintf("Content library item: %s Library: %s", a.item, a.library)
+}
+
+func (*LibraryArtifact) State(name string) interface{} {
+	return nil
+}
+
+func (a *LibraryArtifact) Destroy() error {
+	return nil
+}
@@ -19,3 +19,14 @@ func TestArtifact_Id(t *testing.T) {
 		t.Fatalf("unexpected result: must return datastore, vmfolder, and vmname split by :: as id")
 	}
 }
+
+func TestLibraryArtifact_ImplementsArtifact(t *testing.T) {
+	var _ packersdk.Artifact = &LibraryArtifact{}
+}
+
+func TestLibraryArtifact_Id(t *testing.T) {
+	artifact := NewLibraryArtifact("library", "item", "item-id", nil)
+	if artifact.Id() != "item-id" {
+		t.Fatalf("unexpected result: must return the content library item identifier as id")
+	}
+}
@@ -24,6 +24,8 @@ import (
 	shelllocal "github.com/hashicorp/packer-plugin-sdk/shell-local"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	vspherecommon "github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
 const DefaultMaxRetries = 5
@@ -101,6 +103,21 @@ type Config struct {
 	// The maximum number of times to retry the upload operation if it fails.
 	// Defaults to `5`.
 	MaxRetries int `mapstructure:"max_retries"`
+	// The name of a content library on the vCenter Server instance to upload
+	// the OVF or OVA artifact to as an OVF template, instead of deploying a
+	// virtual machine with `ovftool`. The files are uploaded with the content
+	// library update session API, and the OVA is not extracted on the Packer
+	// host. The content library must be of type Local. `ovftool`, `cluster`,
+	// and `datacenter` are not required when this option is set.
+	ContentLibrary string `mapstructure:"content_library"`
+	// The name of the content library item to upload the OVF template to.
+	// If an item with the name exists, a new version of the item is created
+	// with the OVF template. Otherwise, a new item is created. Defaults to
+	// `vm_name`.
+	ContentLibraryItem string `mapstructure:"content_library_item"`
+	// The description of the content library item. Defaults to
+	// "Packer uploaded `<content_library_item>` OVF template".
+	ContentLibraryItemDescription string `mapstructure:"content_library_item_description"`
 
 	ctx interpolate.Context
 }
@@ -124,6 +141,8 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		return err
 	}
 
+	vspherecommon.RegisterSensitiveValues(&p.config)
+
 	// Set default value for MaxRetries if not provided.
 	if p.config.MaxRetries == 0 {
 		p.config.MaxRetries = DefaultMaxRetries // Set default value
@@ -141,20 +160,30 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		ovftool = OvftoolWindows
 	}
 
-	if _, err := exec.LookPath(ovftool); err != nil {
-		errs = packersdk.MultiErrorAppend(
-			errs, fmt.Errorf("ovftool not found: %s", err))
-	}
-
 	// First define all our templatable parameters that are _required_
 	templates := map[string]*string{
-		"cluster":    &p.config.Cluster,
-		"datacenter": &p.config.Datacenter,
-		"diskmode":   &p.config.DiskMode,
-		"host":       &p.config.Host,
-		"password":   &p.config.Password,
-		"username":   &p.config.Username,
-		"vm_name":    &p.config.VMName,
+		"host":     &p.config.Host,
+		"password": &p.config.Password,
+		"username": &p.config.Username,
+	}
+
+	if p.config.ContentLibrary != "" {
+		if p.config.ContentLibraryItem == "" {
+			p.config.ContentLibraryItem = p.config.VMName
+		}
+		if p.config.ContentLibraryItemDescription == "" {
+			p.config.ContentLibraryItemDescription = fmt.Sprintf("Packer uploaded %s OVF template", p.config.ContentLibraryItem)
+		}
+		templates["content_library_item"] = &p.config.ContentLibraryItem
+	} else {
+		if _, err := exec.LookPath(ovftool); err != nil {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("ovftool not found: %s", err))
+		}
+		templates["cluster"] = &p.config.Cluster
+		templates["datacenter"] = &p.config.Datacenter
+		templates["diskmode"] = &p.config.DiskMode
+		templates["vm_name"] = &p.config.VMName
 	}
 	for key, ptr := range templates {
 		if *ptr == "" {
@@ -210,6 +239,10 @@ func getEncodedPassword(u *url.URL) (string, bool) {
 }
 
 func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	if p.config.ContentLibrary != "" {
+		return p.uploadToContentLibrary(ctx, ui, artifact)
+	}
+
 	source := ""
 	for _, path := range artifact.Files() {
 		if strings.HasSuffix(path, ".vmx") || strings.HasSuffix(path, ".ovf") || strings.HasSuffix(path, ".ova") {
@@ -272,6 +305,62 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 	return artifact, false, false, nil
 }
 
+// uploadToContentLibrary uploads the OVF or OVA of the artifact to the content
+// library item.
+func (p *PostProcessor) uploadToContentLibrary(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	source := ""
+	for _, path := range artifact.Files() {
+		if strings.HasSuffix(path, ".ovf") || strings.HasSuffix(path, ".ova") {
+			source = path
+			break
+		}
+	}
+	if source == "" {
+		return nil, false, false, fmt.Errorf("error locating expected .ovf or .ova artifact to upload to the content library")
+	}
+
+	connect := &vspherecommon.ConnectConfig{
+		VCenterServer:      p.config.Host,
+		Username:           p.config.Username,
+		Password:           p.config.Password,
+		InsecureConnection: p.config.Insecure,
+		Datacenter:         p.config.Datacenter,
+	}
+	d, err := driver.NewDriver(connect.DriverConfig())
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error connecting to vCenter Server: %s", err)
+	}
+	vcenter := d.(*driver.VCenterDriver)
+	defer func() {
+		_, _ = vcenter.Cleanup()
+	}()
+
+	ui.Message(fmt.Sprintf("Uploading %s to content library %s as item %s...", source, p.config.ContentLibrary, p.config.ContentLibraryItem))
+	var itemID string
+	err = retry.Config{
+		Tries: p.config.MaxRetries,
+		ShouldRetry: func(err error) bool {
+			return err != nil
+		},
+		RetryDelay: (&retry.Backoff{InitialBackoff: 200 * time.Millisecond, MaxBackoff: 30 * time.Second, Multiplier: 2}).Linear,
+	}.Run(ctx, func(ctx context.Context) error {
+		itemID, err = vcenter.UploadToContentLibrary(ctx, &driver.UploadLibraryItemConfig{
+			Library:     p.config.ContentLibrary,
+			Name:        p.config.ContentLibraryItem,
+			Description: p.config.ContentLibraryItemDescription,
+			Source:      source,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error uploading to content library: %s", err)
+	}
+	ui.Message(fmt.Sprintf("Uploaded content library item %s.", p.config.ContentLibraryItem))
+
+	artifact = NewLibraryArtifact(p.config.ContentLibrary, p.config.ContentLibraryItem, itemID, artifact.Files())
+	return artifact, false, false, nil
+}
+
 func (p *PostProcessor) ValidateOvfTool(args []string, ofvtool string, ui packersdk.Ui) error {
 	args = append([]string{"--verifyOnly"}, args...)
 	if p.config.Insecure {
@@ -349,3 +438,7 @@ func (p *PostProcessor) BuildArgs(source, ovftoolURI string) ([]string, error) {
 
 	return args, nil
 }
+
+func (c Config) GoString() string { return vspherecommon.GoString(c) }
+
+func (c FlatConfig) GoString() string { return vspherecommon.GoString(c) }
@@ -10,31 +10,34 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	Cluster             *string           `mapstructure:"cluster" required:"true" cty:"cluster" hcl:"cluster"`
-	Datacenter          *string           `mapstructure:"datacenter" required:"true" cty:"datacenter" hcl:"datacenter"`
-	Datastore           *string           `mapstructure:"datastore" required:"true" cty:"datastore" hcl:"datastore"`
-	DiskMode            *string           `mapstructure:"disk_mode" cty:"disk_mode" hcl:"disk_mode"`
-	Host                *string           `mapstructure:"host" required:"true" cty:"host" hcl:"host"`
-	ESXiHost            *string           `mapstructure:"esxi_host" cty:"esxi_host" hcl:"esxi_host"`
-	Insecure            *bool             `mapstructure:"insecure" cty:"insecure" hcl:"insecure"`
-	Options             []string          `mapstructure:"options" cty:"options" hcl:"options"`
-	Overwrite           *bool             `mapstructure:"overwrite" cty:"overwrite" hcl:"overwrite"`
-	Password            *string           `mapstructure:"password" required:"true" cty:"password" hcl:"password"`
-	ResourcePool        *string           `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
-	Username            *string           `mapstructure:"username" required:"true" cty:"username" hcl:"username"`
-	VMFolder            *string           `mapstructure:"vm_folder" cty:"vm_folder" hcl:"vm_folder"`
-	VMName              *string           `mapstructure:"vm_name" cty:"vm_name" hcl:"vm_name"`
-	VMNetwork           *string           `mapstructure:"vm_network" cty:"vm_network" hcl:"vm_network"`
-	HardwareVersion     *string           `mapstructure:"hardware_version" cty:"hardware_version" hcl:"hardware_version"`
-	MaxRetries          *int              `mapstructure:"max_retries" cty:"max_retries" hcl:"max_retries"`
+	PackerBuildName               *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType             *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion             *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug                   *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce                   *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError                 *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars                map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars           []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Cluster                       *string           `mapstructure:"cluster" required:"true" cty:"cluster" hcl:"cluster"`
+	Datacenter                    *string           `mapstructure:"datacenter" required:"true" cty:"datacenter" hcl:"datacenter"`
+	Datastore                     *string           `mapstructure:"datastore" required:"true" cty:"datastore" hcl:"datastore"`
+	DiskMode                      *string           `mapstructure:"disk_mode" cty:"disk_mode" hcl:"disk_mode"`
+	Host                          *string           `mapstructure:"host" required:"true" cty:"host" hcl:"host"`
+	ESXiHost                      *string           `mapstructure:"esxi_host" cty:"esxi_host" hcl:"esxi_host"`
+	Insecure                      *bool             `mapstructure:"insecure" cty:"insecure" hcl:"insecure"`
+	Options                       []string          `mapstructure:"options" cty:"options" hcl:"options"`
+	Overwrite                     *bool             `mapstructure:"overwrite" cty:"overwrite" hcl:"overwrite"`
+	Password                      *string           `mapstructure:"password" required:"true" cty:"password" hcl:"password"`
+	ResourcePool                  *string           `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
+	Username                      *string           `mapstructure:"username" required:"true" cty:"username" hcl:"username"`
+	VMFolder                      *string           `mapstructure:"vm_folder" cty:"vm_folder" hcl:"vm_folder"`
+	VMName                        *string           `mapstructure:"vm_name" cty:"vm_name" hcl:"vm_name"`
+	VMNetwork                     *string           `mapstructure:"vm_network" cty:"vm_network" hcl:"vm_network"`
+	HardwareVersion               *string           `mapstructure:"hardware_version" cty:"hardware_version" hcl:"hardware_version"`
+	MaxRetries                    *int              `mapstructure:"max_retries" cty:"max_retries" hcl:"max_retries"`
+	ContentLibrary                *string           `mapstructure:"content_library" cty:"content_library" hcl:"content_library"`
+	ContentLibraryItem            *string           `mapstructure:"content_library_item" cty:"content_library_item" hcl:"content_library_item"`
+	ContentLibraryItemDescription *string           `mapstructure:"content_library_item_description" cty:"content_library_item_description" hcl:"content_library_item_description"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -49,31 +52,34 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":          &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":        &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":        &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"cluster":                    &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
-		"datacenter":                 &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
-		"datastore":                  &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
-		"disk_mode":                  &hcldec.AttrSpec{Name: "disk_mode", Type: cty.String, Required: false},
-		"host":                       &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
-		"esxi_host":                  &hcldec.AttrSpec{Name: "esxi_host", Type: cty.String, Required: false},
-		"insecure":                   &hcldec.AttrSpec{Name: "insecure", Type: cty.Bool, Required: false},
-		"options":                    &hcldec.AttrSpec{Name: "options", Type: cty.List(cty.String), Required: false},
-		"overwrite":                  &hcldec.AttrSpec{Name: "overwrite", Type: cty.Bool, Required: false},
-		"password":                   &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
-		"resource_pool":              &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
-		"username":                   &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
-		"vm_folder":                  &hcldec.AttrSpec{Name: "vm_folder", Type: cty.String, Required: false},
-		"vm_name":                    &hcldec.AttrSpec{Name: "vm_name", Type: cty.String, Required: false},
-		"vm_network":                 &hcldec.AttrSpec{Name: "vm_network", Type: cty.String, Required: false},
-		"hardware_version":           &hcldec.AttrSpec{Name: "hardware_version", Type: cty.String, Required: false},
-		"max_retries":                &hcldec.AttrSpec{Name: "max_retries", Type: cty.Number, Required: false},
+		"packer_build_name":                &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":              &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":              &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                     &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                     &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":                  &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":            &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":       &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"cluster":                          &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
+		"datacenter":                       &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
+		"datastore":                        &hcldec.AttrSpec{Name: "datastore", Type: cty.String, Required: false},
+		"disk_mode":                        &hcldec.AttrSpec{Name: "disk_mode", Type: cty.String, Required: false},
+		"host":                             &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
+		"esxi_host":                        &hcldec.AttrSpec{Name: "esxi_host", Type: cty.String, Required: false},
+		"insecure":                         &hcldec.AttrSpec{Name: "insecure", Type: cty.Bool, Required: false},
+		"options":                          &hcldec.AttrSpec{Name: "options", Type: cty.List(cty.String), Required: false},
+		"overwrite":                        &hcldec.AttrSpec{Name: "overwrite", Type: cty.Bool, Required: false},
+		"password":                         &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
+		"resource_pool":                    &hcldec.AttrSpec{Name: "resource_pool", Type: cty.String, Required: false},
+		"username":                         &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
+		"vm_folder":                        &hcldec.AttrSpec{Name: "vm_folder", Type: cty.String, Required: false},
+		"vm_name":                          &hcldec.AttrSpec{Name: "vm_name", Type: cty.String, Required: false},
+		"vm_network":                       &hcldec.AttrSpec{Name: "vm_network", Type: cty.String, Required: false},
+		"hardware_version":                 &hcldec.AttrSpec{Name: "hardware_version", Type: cty.String, Required: false},
+		"max_retries":                      &hcldec.AttrSpec{Name: "max_retries", Type: cty.Number, Required: false},
+		"content_library":                  &hcldec.AttrSpec{Name: "content_library", Type: cty.String, Required: false},
+		"content_library_item":             &hcldec.AttrSpec{Name: "content_library_item", Type: cty.String, Required: false},
+		"content_library_item_description": &hcldec.AttrSpec{Name: "content_library_item_description", Type: cty.String, Required: false},
 	}
 	return s
 }
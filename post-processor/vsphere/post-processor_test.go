@@ -131,3 +131,40 @@ func TestGetEncodedPassword(t *testing.T) {
 	}
 
 }
+
+func TestConfigure_ContentLibrary(t *testing.T) {
+	var p PostProcessor
+	err := p.Configure(map[string]interface{}{
+		"host":            "vcenter.example.com",
+		"username":        "administrator@vsphere.local",
+		"password":        "password",
+		"vm_name":         "template",
+		"content_library": "library",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if p.config.ContentLibraryItem != "template" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "template", p.config.ContentLibraryItem)
+	}
+	expectedDescription := "Packer uploaded template OVF template"
+	if p.config.ContentLibraryItemDescription != expectedDescription {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expectedDescription, p.config.ContentLibraryItemDescription)
+	}
+}
+
+func TestConfigure_ContentLibraryWithoutItem(t *testing.T) {
+	var p PostProcessor
+	err := p.Configure(map[string]interface{}{
+		"host":            "vcenter.example.com",
+		"username":        "administrator@vsphere.local",
+		"password":        "password",
+		"content_library": "library",
+	})
+	if err == nil {
+		t.Fatalf("unexpected success: expected failure")
+	}
+	if !strings.Contains(err.Error(), "content_library_item must be set") {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
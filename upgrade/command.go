@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package upgrade
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Command is the name of the plugin command that upgrades a legacy JSON
+// template.
+const Command = "upgrade-config"
+
+const usage = `Usage: packer-plugin-vsphere upgrade-config [template.json]
+
+  Converts the vsphere-iso and vsphere-clone builders of a legacy JSON
+  template to HCL2 and writes the configuration to standard output. The
+  template is read from standard input if no file is specified. The options
+  that were renamed, restructured, or removed are reported to standard error.
+`
+
+// Run runs the command with the arguments and returns the exit code.
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 1 || (len(args) == 1 && (args[0] == "-h" || args[0] == "--help")) {
+		fmt.Fprint(stderr, usage)
+		return 1
+	}
+
+	r := stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	config, notes, err := Upgrade(r)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if _, err := stdout.Write(config); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	for _, n := range notes {
+		fmt.Fprintln(stderr, n)
+	}
+	return 0
+}
@@ -0,0 +1,377 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package upgrade converts the vsphere-iso and vsphere-clone builders of
+// legacy JSON templates to HCL2 sources, with the options renamed or
+// restructured since the builders were split from Packer.
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/clone"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/iso"
+)
+
+// The specifications of the builders by the type of the legacy builder.
+var builderSpecs = map[string]func() map[string]hcldec.Spec{
+	"vsphere-iso":   func() map[string]hcldec.Spec { return (*iso.FlatConfig)(nil).HCL2Spec() },
+	"vsphere-clone": func() map[string]hcldec.Spec { return (*clone.FlatConfig)(nil).HCL2Spec() },
+}
+
+var (
+	userVariable = regexp.MustCompile("{{\\s*user\\s+`([^`]+)`\\s*}}")
+	envVariable  = regexp.MustCompile("^{{\\s*env\\s+`([^`]+)`\\s*}}$")
+	// Template functions other than user variables, such as `{{timestamp}}`.
+	// Template variables, such as `{{ .HTTPIP }}`, are still supported.
+	templateFunction = regexp.MustCompile(`{{-?\s*[^.\s]`)
+)
+
+// Note is a change to the configuration that could not be converted as is
+// and should be reviewed.
+type Note struct {
+	Source  string
+	Option  string
+	Message string
+}
+
+func (n Note) String() string {
+	if n.Option == "" {
+		return fmt.Sprintf("%s: %s", n.Source, n.Message)
+	}
+	return fmt.Sprintf("%s: '%s' %s", n.Source, n.Option, n.Message)
+}
+
+type converter struct {
+	source string
+	notes  []Note
+}
+
+func (c *converter) note(option string, format string, args ...interface{}) {
+	c.notes = append(c.notes, Note{Source: c.source, Option: option, Message: fmt.Sprintf(format, args...)})
+}
+
+// Upgrade reads a legacy JSON template, or the JSON configuration of a
+// single builder, and returns the equivalent HCL2 configuration of its
+// vsphere-iso and vsphere-clone builders and user variables. Provisioners,
+// post-processors, and the builders of other plugins are not converted and
+// are reported in the notes.
+func Upgrade(r io.Reader) ([]byte, []Note, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var template map[string]interface{}
+	if err := decoder.Decode(&template); err != nil {
+		return nil, nil, fmt.Errorf("error parsing the JSON template: %s", err)
+	}
+
+	builders := []interface{}{template}
+	if raw, ok := template["builders"]; ok {
+		if builders, ok = raw.([]interface{}); !ok {
+			return nil, nil, fmt.Errorf("'builders' must be a list")
+		}
+	}
+
+	file := hclwrite.NewEmptyFile()
+	c := &converter{source: "template"}
+
+	if variables, ok := template["variables"].(map[string]interface{}); ok {
+		c.writeVariables(file.Body(), variables, template["sensitive-variables"])
+	}
+	for _, section := range []string{"provisioners", "post-processors"} {
+		if _, ok := template[section]; ok {
+			c.note("", "the %s are not converted, use `packer hcl2_upgrade` to convert them", section)
+		}
+	}
+
+	var sources []string
+	for i, raw := range builders {
+		builder, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("builder %d must be an object", i)
+		}
+		builderType, _ := builder["type"].(string)
+		name, _ := builder["name"].(string)
+		if name == "" {
+			name = builderType
+		}
+		c.source = fmt.Sprintf("source.%s.%s", builderType, name)
+
+		spec, ok := builderSpecs[builderType]
+		if !ok {
+			c.note("", "builders of type '%s' are not converted", builderType)
+			continue
+		}
+		delete(builder, "type")
+		delete(builder, "name")
+		c.upgradeOptions(builderType, builder)
+
+		block := appendBlock(file.Body(), "source", []string{builderType, name})
+		c.writeBody(block.Body(), "", builder, spec())
+		sources = append(sources, c.source)
+	}
+	if len(sources) == 0 {
+		return nil, nil, fmt.Errorf("no vsphere-iso or vsphere-clone builder found")
+	}
+
+	build := appendBlock(file.Body(), "build", nil)
+	var refs []cty.Value
+	for _, source := range sources {
+		refs = append(refs, cty.StringVal(source))
+	}
+	build.Body().SetAttributeValue("sources", cty.ListVal(refs))
+
+	return hclwrite.Format(file.Bytes()), c.notes, nil
+}
+
+// appendBlock appends a block to the body, separated from the previous block
+// by an empty line.
+func appendBlock(body *hclwrite.Body, typeName string, labels []string) *hclwrite.Block {
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	return body.AppendNewBlock(typeName, labels)
+}
+
+// writeVariables declares the user variables as string variables, since the
+// variables of legacy templates are strings. The variables that are set to
+// null are required, so they are declared without a default.
+func (c *converter) writeVariables(body *hclwrite.Body, variables map[string]interface{}, sensitive interface{}) {
+	sensitiveNames := make(map[string]bool)
+	if list, ok := sensitive.([]interface{}); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				sensitiveNames[s] = true
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(variables) {
+		block := appendBlock(body, "variable", []string{name})
+		block.Body().SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+		if variables[name] != nil {
+			value := fmt.Sprint(variables[name])
+			if m := envVariable.FindStringSubmatch(value); m != nil {
+				block.Body().SetAttributeRaw("default", hclwrite.TokensForFunctionCall("env", hclwrite.TokensForValue(cty.StringVal(m[1]))))
+			} else {
+				block.Body().SetAttributeValue("default", cty.StringVal(value))
+			}
+		}
+		if sensitiveNames[name] {
+			block.Body().SetAttributeValue("sensitive", cty.True)
+		}
+	}
+}
+
+// upgradeOptions renames and restructures the legacy options of the builder.
+func (c *converter) upgradeOptions(builderType string, options map[string]interface{}) {
+	c.rename(options, "ssh_wait_timeout", "ssh_timeout")
+
+	if v, ok := options["disk_controller_type"].(string); ok {
+		options["disk_controller_type"] = []interface{}{v}
+	}
+
+	if builderType != "vsphere-iso" {
+		return
+	}
+
+	if v, ok := options["iso_path"]; ok {
+		delete(options, "iso_path")
+		paths, _ := options["iso_paths"].([]interface{})
+		options["iso_paths"] = append([]interface{}{v}, paths...)
+		c.note("iso_path", "was replaced by 'iso_paths'")
+	}
+
+	if checksumType, ok := options["iso_checksum_type"].(string); ok {
+		delete(options, "iso_checksum_type")
+		switch checksum, _ := options["iso_checksum"].(string); {
+		case checksumType == "none":
+			options["iso_checksum"] = "none"
+		case checksum != "":
+			options["iso_checksum"] = checksumType + ":" + checksum
+		}
+		c.note("iso_checksum_type", "was merged into 'iso_checksum' as '<type>:<checksum>'")
+	}
+	if url, ok := options["iso_checksum_url"].(string); ok {
+		delete(options, "iso_checksum_url")
+		options["iso_checksum"] = "file:" + url
+		c.note("iso_checksum_url", "was merged into 'iso_checksum' as 'file:<url>'")
+	}
+
+	if v, ok := options["usb_controller"].(bool); ok {
+		delete(options, "usb_controller")
+		if v {
+			options["usb_controller"] = []interface{}{"usb"}
+		}
+		c.note("usb_controller", "is a list of controller types, such as [\"usb\", \"xhci\"]")
+	}
+
+	c.nest(options, "network_adapters", "network", "network_card")
+	c.nest(options, "storage", "disk_size", "disk_thin_provisioned", "disk_eagerly_scrub")
+}
+
+func (c *converter) rename(options map[string]interface{}, from string, to string) {
+	v, ok := options[from]
+	if !ok {
+		return
+	}
+	delete(options, from)
+	if _, ok := options[to]; !ok {
+		options[to] = v
+	}
+	c.note(from, "was replaced by '%s'", to)
+}
+
+// nest moves the legacy top-level options to a block, unless the block is
+// already defined.
+func (c *converter) nest(options map[string]interface{}, block string, keys ...string) {
+	nested := make(map[string]interface{})
+	for _, key := range keys {
+		if v, ok := options[key]; ok {
+			nested[key] = v
+			delete(options, key)
+		}
+	}
+	if len(nested) == 0 {
+		return
+	}
+	if _, ok := options[block]; ok {
+		c.note(block, "is already defined, the legacy options %s were removed", strings.Join(sortedKeys(nested), ", "))
+		return
+	}
+	options[block] = []interface{}{nested}
+	c.note(block, "replaces the legacy options %s", strings.Join(sortedKeys(nested), ", "))
+}
+
+// writeBody writes the options as attributes and blocks, as defined by the
+// specification of the builder. Options that are not defined are removed.
+func (c *converter) writeBody(body *hclwrite.Body, prefix string, options map[string]interface{}, spec map[string]hcldec.Spec) {
+	for _, key := range sortedKeys(options) {
+		option := prefix + key
+		value := options[key]
+
+		switch s := spec[key].(type) {
+		case nil:
+			c.note(option, "is not supported and was removed")
+		case *hcldec.BlockSpec:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				c.note(option, "must be an object and was removed")
+				continue
+			}
+			c.writeBlock(body, option, key, object, s.Nested)
+		case *hcldec.BlockListSpec:
+			list, ok := value.([]interface{})
+			if !ok {
+				c.note(option, "must be a list of objects and was removed")
+				continue
+			}
+			for i, item := range list {
+				object, ok := item.(map[string]interface{})
+				if !ok {
+					c.note(fmt.Sprintf("%s[%d]", option, i), "must be an object and was removed")
+					continue
+				}
+				c.writeBlock(body, fmt.Sprintf("%s[%d]", option, i), key, object, s.Nested)
+			}
+		default:
+			body.SetAttributeRaw(key, c.tokens(option, value))
+		}
+	}
+}
+
+func (c *converter) writeBlock(body *hclwrite.Body, option string, name string, options map[string]interface{}, nested hcldec.Spec) {
+	block := body.AppendNewBlock(name, nil)
+	spec, _ := nested.(hcldec.ObjectSpec)
+	c.writeBody(block.Body(), option+".", options, spec)
+}
+
+// tokens returns the expression of the value. User variables in strings are
+// converted to references to the input variables.
+func (c *converter) tokens(option string, value interface{}) hclwrite.Tokens {
+	switch v := value.(type) {
+	case string:
+		return c.stringTokens(option, v)
+	case bool:
+		return hclwrite.TokensForValue(cty.BoolVal(v))
+	case json.Number:
+		n, err := cty.ParseNumberVal(v.String())
+		if err != nil {
+			return hclwrite.TokensForValue(cty.StringVal(v.String()))
+		}
+		return hclwrite.TokensForValue(n)
+	case []interface{}:
+		var elems []hclwrite.Tokens
+		for _, e := range v {
+			elems = append(elems, c.tokens(option, e))
+		}
+		return hclwrite.TokensForTuple(elems)
+	case map[string]interface{}:
+		var attrs []hclwrite.ObjectAttrTokens
+		for _, key := range sortedKeys(v) {
+			name := hclwrite.TokensForIdentifier(key)
+			if !hclsyntax.ValidIdentifier(key) {
+				name = hclwrite.TokensForValue(cty.StringVal(key))
+			}
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: name, Value: c.tokens(option, v[key])})
+		}
+		return hclwrite.TokensForObject(attrs)
+	default:
+		return hclwrite.TokensForValue(cty.NullVal(cty.DynamicPseudoType))
+	}
+}
+
+func (c *converter) stringTokens(option string, s string) hclwrite.Tokens {
+	matches := userVariable.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		return hclwrite.TokensForTraversal(hcl.Traversal{
+			hcl.TraverseRoot{Name: "var"},
+			hcl.TraverseAttr{Name: s[matches[0][2]:matches[0][3]]},
+		})
+	}
+
+	tokens := hclwrite.Tokens{{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)}}
+	literal := func(lit string) {
+		if lit == "" {
+			return
+		}
+		if templateFunction.MatchString(lit) {
+			c.note(option, "contains a template expression that must be converted manually")
+		}
+		quoted := hclwrite.TokensForValue(cty.StringVal(lit))
+		tokens = append(tokens, quoted[1:len(quoted)-1]...)
+	}
+	last := 0
+	for _, m := range matches {
+		literal(s[last:m[0]])
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")},
+			&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("var")},
+			&hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
+			&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(s[m[2]:m[3]])},
+			&hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")},
+		)
+		last = m[1]
+	}
+	literal(s[last:])
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)})
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package upgrade
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpgrade(t *testing.T) {
+	legacy := `{
+  "variables": {
+    "password": "{{env ` + "`VSPHERE_PASSWORD`" + `}}",
+    "vm_name": "ubuntu"
+  },
+  "sensitive-variables": ["password"],
+  "builders": [
+    {
+      "type": "vsphere-iso",
+      "name": "ubuntu",
+      "password": "{{user ` + "`password`" + `}}",
+      "vm_name": "{{user ` + "`vm_name`" + `}}-{{timestamp}}",
+      "CPUs": 2,
+      "iso_path": "[datastore1] iso/ubuntu.iso",
+      "iso_checksum_type": "sha256",
+      "iso_checksum": "abc",
+      "network": "VM Network",
+      "network_card": "vmxnet3",
+      "disk_size": 32768,
+      "disk_controller_type": "pvscsi",
+      "ssh_wait_timeout": "30m",
+      "boot_command": ["<esc>{{ .HTTPIP }} ${literal}"],
+      "configuration_parameters": {"disk.EnableUUID": "TRUE"},
+      "export": {"output_directory": "output"},
+      "removed_option": true
+    },
+    {
+      "type": "amazon-ebs"
+    }
+  ],
+  "provisioners": [{"type": "shell", "inline": ["ls"]}]
+}`
+
+	expected := `variable "password" {
+  type      = string
+  default   = env("VSPHERE_PASSWORD")
+  sensitive = true
+}
+
+variable "vm_name" {
+  type    = string
+  default = "ubuntu"
+}
+
+source "vsphere-iso" "ubuntu" {
+  CPUs         = 2
+  boot_command = ["<esc>{{ .HTTPIP }} $${literal}"]
+  configuration_parameters = {
+    "disk.EnableUUID" = "TRUE"
+  }
+  disk_controller_type = ["pvscsi"]
+  export {
+    output_directory = "output"
+  }
+  iso_checksum = "sha256:abc"
+  iso_paths    = ["[datastore1] iso/ubuntu.iso"]
+  network_adapters {
+    network      = "VM Network"
+    network_card = "vmxnet3"
+  }
+  password    = var.password
+  ssh_timeout = "30m"
+  storage {
+    disk_size = 32768
+  }
+  vm_name = "${var.vm_name}-{{timestamp}}"
+}
+
+build {
+  sources = ["source.vsphere-iso.ubuntu"]
+}
+`
+
+	config, notes, err := Upgrade(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if diff := cmp.Diff(expected, string(config)); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+
+	var messages []string
+	for _, n := range notes {
+		messages = append(messages, n.String())
+	}
+	expectedNotes := []string{
+		"template: the provisioners are not converted, use `packer hcl2_upgrade` to convert them",
+		"source.vsphere-iso.ubuntu: 'ssh_wait_timeout' was replaced by 'ssh_timeout'",
+		"source.vsphere-iso.ubuntu: 'iso_path' was replaced by 'iso_paths'",
+		"source.vsphere-iso.ubuntu: 'iso_checksum_type' was merged into 'iso_checksum' as '<type>:<checksum>'",
+		"source.vsphere-iso.ubuntu: 'network_adapters' replaces the legacy options network, network_card",
+		"source.vsphere-iso.ubuntu: 'storage' replaces the legacy options disk_size",
+		"source.vsphere-iso.ubuntu: 'removed_option' is not supported and was removed",
+		"source.vsphere-iso.ubuntu: 'vm_name' contains a template expression that must be converted manually",
+		"source.amazon-ebs.amazon-ebs: builders of type 'amazon-ebs' are not converted",
+	}
+	if diff := cmp.Diff(expectedNotes, messages); diff != "" {
+		t.Fatalf("unexpected notes: %s", diff)
+	}
+}
+
+func TestUpgrade_Builder(t *testing.T) {
+	legacy := `{
+  "type": "vsphere-clone",
+  "template": "ubuntu",
+  "customize": {
+    "linux_options": {"host_name": "ubuntu", "domain": "example.com"},
+    "network_interface": [{"ipv4_address": "10.0.0.10"}, {}]
+  }
+}`
+
+	expected := `source "vsphere-clone" "vsphere-clone" {
+  customize {
+    linux_options {
+      domain    = "example.com"
+      host_name = "ubuntu"
+    }
+    network_interface {
+      ipv4_address = "10.0.0.10"
+    }
+    network_interface {
+    }
+  }
+  template = "ubuntu"
+}
+
+build {
+  sources = ["source.vsphere-clone.vsphere-clone"]
+}
+`
+
+	config, notes, err := Upgrade(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if diff := cmp.Diff(expected, string(config)); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+	if len(notes) != 0 {
+		t.Fatalf("unexpected notes: %v", notes)
+	}
+}
+
+func TestUpgrade_RequiredVariable(t *testing.T) {
+	legacy := `{
+  "variables": {"x": null},
+  "builders": [{"type": "vsphere-clone", "template": "{{user ` + "`x`" + `}}"}]
+}`
+
+	expected := `variable "x" {
+  type = string
+}
+
+source "vsphere-clone" "vsphere-clone" {
+  template = var.x
+}
+
+build {
+  sources = ["source.vsphere-clone.vsphere-clone"]
+}
+`
+
+	config, _, err := Upgrade(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if diff := cmp.Diff(expected, string(config)); diff != "" {
+		t.Fatalf("unexpected result: %s", diff)
+	}
+}
+
+func TestUpgrade_NoBuilder(t *testing.T) {
+	if _, _, err := Upgrade(strings.NewReader(`{"builders": [{"type": "null"}]}`)); err == nil {
+		t.Fatal("unexpected success: expected failure")
+	}
+}
+
+func TestRun(t *testing.T) {
+	stdin := strings.NewReader(`{"type": "vsphere-clone", "template": "ubuntu", "ssh_wait_timeout": "10m"}`)
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if code := Run(nil, stdin, stdout, stderr); code != 0 {
+		t.Fatalf("unexpected exit code: %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), `ssh_timeout = "10m"`) {
+		t.Fatalf("unexpected result: '%s'", stdout)
+	}
+	expected := "source.vsphere-clone.vsphere-clone: 'ssh_wait_timeout' was replaced by 'ssh_timeout'\n"
+	if stderr.String() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, stderr)
+	}
+}